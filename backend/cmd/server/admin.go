@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"payforwardnow/internal/middleware"
+)
+
+// healthPattern is served on both listeners, so load balancers can keep
+// probing the public port
+const healthPattern = "GET /api/health"
+
+// adminListenerRoute reports whether r moves to the admin listener when
+// ADMIN_ADDR is set: the admin API and metrics
+func adminListenerRoute(r route) bool {
+	return r.admin || r.pattern == "GET /metrics"
+}
+
+// splitRoutes separates the routes of the public listener from those of the
+// admin listener. The health check is on both.
+func splitRoutes(routes []route) (public, admin []route) {
+	for _, r := range routes {
+		switch {
+		case r.pattern == healthPattern:
+			public = append(public, r)
+			admin = append(admin, r)
+		case adminListenerRoute(r):
+			admin = append(admin, r)
+		default:
+			public = append(public, r)
+		}
+	}
+	return public, admin
+}
+
+// newAdminMux returns the routes of the admin listener: routes, which still
+// check the admin role, and the pprof profiles, which are never served on
+// the public port and require the admin role too, since profiles and the
+// command line can reveal secrets to anyone who reaches the listener
+func newAdminMux(routes []route, adminOnly middleware.Middleware) *http.ServeMux {
+	mux := http.NewServeMux()
+	registerRoutes(mux, routes, adminOnly)
+	mux.Handle("GET /debug/pprof/", adminOnly(http.HandlerFunc(pprof.Index)))
+	mux.Handle("GET /debug/pprof/cmdline", adminOnly(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("GET /debug/pprof/profile", adminOnly(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("GET /debug/pprof/symbol", adminOnly(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("GET /debug/pprof/trace", adminOnly(http.HandlerFunc(pprof.Trace)))
+	return mux
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/handlers"
+)
+
+func TestSplitRoutes(t *testing.T) {
+	public, admin := splitRoutes(apiRoutes(new(handlers.Handler)))
+
+	has := func(routes []route, pattern string) bool {
+		for _, r := range routes {
+			if r.pattern == pattern {
+				return true
+			}
+		}
+		return false
+	}
+	if !has(public, healthPattern) || !has(admin, healthPattern) {
+		t.Error("expected the health check on both listeners")
+	}
+	if has(public, "GET /metrics") || !has(admin, "GET /metrics") {
+		t.Error("expected metrics only on the admin listener")
+	}
+	for _, r := range public {
+		if r.admin {
+			t.Errorf("admin route %s left on the public listener", r.pattern)
+		}
+	}
+	if !has(public, "GET /api/openapi.json") {
+		t.Error("expected the public routes to stay on the public listener")
+	}
+}
+
+func TestAdminMux(t *testing.T) {
+	var wrapped int
+	adminOnly := func(next http.Handler) http.Handler {
+		wrapped++
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	_, admin := splitRoutes(apiRoutes(new(handlers.Handler)))
+	mux := newAdminMux(admin, adminOnly)
+
+	if wrapped == 0 {
+		t.Error("expected the admin routes to still require the admin role")
+	}
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/pprof/heap"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("anonymous GET %s = %d, want 401", path, rec.Code)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer admin")
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("admin GET %s = %d, want 200", path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /api/openapi.json = %d, want 404 on the admin listener", rec.Code)
+	}
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/backup"
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/migrations"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/seed"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// commandTimeout bounds the database work of a command
+const commandTimeout = 10 * time.Minute
+
+// dbFlags are the flags of commands that use the database
+type dbFlags struct {
+	config *string
+	tenant *string
+}
+
+func addDBFlags(flags *flag.FlagSet) dbFlags {
+	return dbFlags{
+		config: configFlag(flags),
+		tenant: flags.String("tenant", "", "tenant whose database to use, from TENANT_DATABASES; the default database when empty"),
+	}
+}
+
+// connect loads the configuration and connects to the database it names.
+// The returned context carries the tenant's database and the command's
+// timeout; release frees both.
+func (f dbFlags) connect() (ctx context.Context, config *Config, client *database.Neo4jClient, release func(), err error) {
+	config, err = LoadConfig(*f.config)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	databaseName := ""
+	if *f.tenant != "" {
+		name, ok := config.TenantDatabases[strings.ToLower(*f.tenant)]
+		if !ok {
+			return nil, nil, nil, nil, fmt.Errorf("unknown tenant %q", *f.tenant)
+		}
+		databaseName = name
+	}
+
+	// Bulk transactions run as long as they need within the command's timeout
+	driverCfg := config.Neo4jDriver
+	driverCfg.QueryTimeout = 0
+	client, err = database.NewNeo4jClientWithConfig(config.Neo4jURI, config.Neo4jUser, config.Neo4jPassword, driverCfg)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("failed to connect to Neo4j: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	ctx = database.WithDatabase(ctx, databaseName)
+	return ctx, config, client, func() {
+		cancel()
+		client.Close()
+	}, nil
+}
+
+// runMigrate applies, reverts or inspects the schema migrations
+func runMigrate(args []string) error {
+	flags := newFlagSet("migrate", "[flags] up | down [N] | status | drift")
+	db := addDBFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return usageError(flags)
+	}
+
+	steps := 1
+	switch args[0] {
+	case "up", "status", "drift":
+		if len(args) > 1 {
+			return usageError(flags)
+		}
+	case "down":
+		if len(args) > 2 {
+			return usageError(flags)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("down takes a positive number of migrations, got %q", args[1])
+			}
+			steps = n
+		}
+	default:
+		return usageError(flags)
+	}
+
+	schema, err := migrations.Embedded()
+	if err != nil {
+		return fmt.Errorf("invalid migrations: %w", err)
+	}
+
+	ctx, _, client, release, err := db.connect()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	migrator := migrations.New(client, schema)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, version := range applied {
+			fmt.Fprintf(stdout, "applied %04d\n", version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(stdout, "schema is up to date")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		for _, version := range reverted {
+			fmt.Fprintf(stdout, "reverted %04d\n", version)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(stdout, "%04d  %-30s  %s\n", s.Version, s.Name, applied)
+		}
+	case "drift":
+		drift, err := migrator.Drift(ctx, embeddings.IndexName)
+		if err != nil {
+			return err
+		}
+		for _, version := range drift.Pending {
+			fmt.Fprintf(stdout, "pending     %04d\n", version)
+		}
+		for _, obj := range drift.Missing {
+			fmt.Fprintf(stdout, "missing     %s\n", obj)
+		}
+		for _, obj := range drift.Unexpected {
+			fmt.Fprintf(stdout, "unexpected  %s\n", obj)
+		}
+		for _, change := range drift.Changed {
+			fmt.Fprintf(stdout, "changed     %s, expected %s\n", change.Actual, change.Expected)
+		}
+		if !drift.Clean() {
+			return errors.New("schema has drifted from the migrations")
+		}
+		fmt.Fprintln(stdout, "schema matches the migrations")
+	}
+	return nil
+}
+
+// runSeed fills the database with generated users, acts, chains and
+// testimonials. The same -seed always produces the same data, including IDs,
+// so seeding twice refreshes the fixtures instead of duplicating them.
+func runSeed(args []string) error {
+	flags := newFlagSet("seed", "[flags]")
+	db := addDBFlags(flags)
+	cfg := seed.DefaultConfig()
+	flags.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed; the same seed generates the same data")
+	flags.IntVar(&cfg.Users, "users", cfg.Users, "number of users")
+	flags.IntVar(&cfg.Acts, "acts", cfg.Acts, "number of acts")
+	flags.IntVar(&cfg.Chains, "chains", cfg.Chains, "number of chains")
+	flags.IntVar(&cfg.Testimonials, "testimonials", cfg.Testimonials, "number of testimonials")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usageError(flags)
+	}
+	if cfg.Users < 0 || cfg.Acts < 0 || cfg.Chains < 0 || cfg.Testimonials < 0 {
+		return fmt.Errorf("counts must not be negative")
+	}
+
+	ctx, _, client, release, err := db.connect()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	d := seed.Generate(cfg)
+	if err := seed.Load(ctx, client, d); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "seeded %d users, %d acts, %d chains, %d testimonials and %d reactions (seed %d)\n",
+		len(d.Users), len(d.Acts), len(d.Chains), len(d.Testimonials), len(d.Reactions), cfg.Seed)
+	if len(d.Users) > 0 {
+		fmt.Fprintf(stdout, "sign in as %s with password %q\n", d.Users[0].Email, seed.Password)
+	}
+	return nil
+}
+
+// runCreateAdmin creates a Keycloak user with the admin realm role, which is
+// what the admin routes check, and the matching user in the database
+func runCreateAdmin(args []string) error {
+	flags := newFlagSet("create-admin", "-email EMAIL -name NAME [flags]")
+	db := addDBFlags(flags)
+	email := flags.String("email", "", "email address, also used as the Keycloak username")
+	name := flags.String("name", "", "display name")
+	password := flags.String("password", os.Getenv("ADMIN_PASSWORD"), "password; generated and printed when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 || *email == "" || *name == "" {
+		return usageError(flags)
+	}
+
+	generated := *password == ""
+	if generated {
+		var err error
+		if *password, err = generatePassword(); err != nil {
+			return err
+		}
+	}
+
+	ctx, config, client, release, err := db.connect()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if config.KeycloakURL == "" || config.KeycloakRealm == "" {
+		return errors.New("create-admin needs KEYCLOAK_URL and KEYCLOAK_REALM, since admin routes check the Keycloak admin role")
+	}
+
+	users := repository.NewNeo4j(client).Users
+	exists, err := users.EmailExists(ctx, *email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("a user with email %s already exists", *email)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(*password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	firstName, lastName, _ := strings.Cut(*name, " ")
+	keycloak := auth.NewKeycloakAuth(config.KeycloakURL, config.KeycloakRealm, config.KeycloakClientID, config.KeycloakClientSecret)
+	id, err := keycloak.CreateUser(ctx, auth.KeycloakUser{
+		Email:     *email,
+		FirstName: firstName,
+		LastName:  lastName,
+		Password:  *password,
+	}, middleware.AdminRole)
+	if id == "" && err != nil {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("created keycloak user %s but failed to grant the %s role: %w", id, middleware.AdminRole, err)
+	}
+
+	// The local user shares the Keycloak ID, which tokens carry as subject
+	now := time.Now().UTC()
+	user := &models.User{ID: id, Email: *email, Name: *name, IsVerified: true, CreatedAt: now, UpdatedAt: now}
+	if err := users.Create(ctx, user, string(hash)); err != nil {
+		return fmt.Errorf("created keycloak user %s but failed to create the local user: %w", id, err)
+	}
+
+	fmt.Fprintf(stdout, "created admin %s (%s)\n", *email, id)
+	if generated {
+		fmt.Fprintf(stdout, "password: %s\n", *password)
+	}
+	return nil
+}
+
+// generatePassword returns a random password for accounts created without one
+func generatePassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// runExport writes every user or act, oldest first, as newline-delimited
+// JSON, like the admin export endpoints, or backs up the graph. Password
+// hashes are never included in user exports.
+func runExport(args []string) (err error) {
+	flags := newFlagSet("export", "[flags] users | acts | graph")
+	db := addDBFlags(flags)
+	output := flags.String("o", "", "file to export to; standard output when empty")
+	formatName := flags.String("format", "", "graph backup format: ndjson or cypher; ndjson when empty")
+	labels := flags.String("labels", "", "comma-separated labels to back up; all nodes when empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	kind := flags.Arg(0)
+	if flags.NArg() != 1 || kind != "users" && kind != "acts" && kind != "graph" {
+		return usageError(flags)
+	}
+	if kind != "graph" && (*formatName != "" || *labels != "") {
+		return usageError(flags)
+	}
+	format := backup.FormatJSON
+	if *formatName != "" {
+		if format, err = backup.ParseFormat(*formatName); err != nil {
+			return err
+		}
+	}
+
+	ctx, _, client, release, err := db.connect()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var w io.Writer = stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		w = f
+	}
+
+	if kind == "graph" {
+		summary, err := backup.Export(ctx, client, w, format, backup.ExportOptions{Labels: splitList(*labels)})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "exported %d nodes and %d relationships\n", summary.Nodes, summary.Relationships)
+		return nil
+	}
+
+	repos := repository.NewNeo4j(client)
+	enc := json.NewEncoder(w)
+	rows := 0
+	write := func(v any) error {
+		rows++
+		return enc.Encode(v)
+	}
+	if kind == "users" {
+		err = repos.Users.Stream(ctx, func(u *models.User) error { return write(u) })
+	} else {
+		err = repos.Acts.Stream(ctx, func(a *models.Act) error { return write(a) })
+	}
+	if err != nil {
+		return fmt.Errorf("export stopped after %d %s: %w", rows, kind, err)
+	}
+
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "exported %d %s to %s\n", rows, kind, *output)
+	}
+	return nil
+}
+
+// runImport loads a graph backup made by export graph, from FILE or
+// standard input. Import into a freshly migrated database, since nodes are
+// created rather than merged.
+func runImport(args []string) error {
+	flags := newFlagSet("import", "[flags] [FILE]")
+	db := addDBFlags(flags)
+	formatName := flags.String("format", string(backup.FormatJSON), "backup format: ndjson or cypher")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return usageError(flags)
+	}
+	format, err := backup.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader = os.Stdin
+	if flags.NArg() == 1 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	ctx, _, client, release, err := db.connect()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	summary, err := backup.Import(ctx, client, in, format)
+	fmt.Fprintf(os.Stderr, "imported %d nodes and %d relationships\n", summary.Nodes, summary.Relationships)
+	return err
+}
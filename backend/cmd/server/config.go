@@ -0,0 +1,797 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/features"
+	"payforwardnow/internal/geocode"
+	"payforwardnow/internal/jobs"
+	"payforwardnow/internal/logging"
+	"payforwardnow/internal/mail"
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/migrations"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/outbox"
+	"payforwardnow/internal/payments"
+	"payforwardnow/internal/rates"
+	"payforwardnow/internal/search"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultJWTSecret signs tokens in development when JWT_SECRET is unset. It
+// is rejected in production.
+const defaultJWTSecret = "your-secret-key-change-in-production"
+
+// Config holds the application configuration
+type Config struct {
+	Port                 string
+	Neo4jURI             string
+	Neo4jUser            string
+	Neo4jPassword        string
+	Neo4jDriver          database.ClientConfig
+	JWTSecret            string
+	Environment          string
+	KeycloakURL          string
+	KeycloakRealm        string
+	KeycloakClientID     string
+	KeycloakClientSecret string
+	AllowedOrigins       []string
+	RateLimitPerMin      int
+	RateLimitUserPerMin  int
+	RateClasses          []middleware.RateClass
+	LogLevel             string
+	LogFormat            string
+	DebugErrors          bool
+	DBBreakerFailures    int
+	DBBreakerOpenTimeout time.Duration
+	RequestTimeout       time.Duration
+	ShutdownTimeout      time.Duration
+	DrainDelay           time.Duration
+	ReusePort            bool
+	WorkerPoolSize       int
+	WorkerQueueSize      int
+	RouteTimeouts        []middleware.RouteTimeout
+	RouteGroups          []RouteGroup
+	MaintenanceMode      bool
+	IPFilterFile         string
+	ResponseCacheSize    int
+	RevocationCacheSize  int
+	RedisURL             string
+	SentryDSN            string
+	LogBodies            bool
+	LogRedactFields      []string
+	BotRejectScore       int
+	BotChallengeScore    int
+	CaptchaVerifyURL     string
+	CaptchaSecret        string
+	SecurityHeaders      middleware.SecurityHeadersConfig
+	AccessLog            string
+	AccessLogFormat      string
+	DedupWindow          time.Duration
+	SchemaSetup          string
+	SchemaRequired       bool
+	DeletedRetention     time.Duration
+	ExposeBookmarks      bool
+	ServeFrontend        bool
+	AdminAddr            string
+	FrontendDir          string
+	ReadRouting          middleware.ReadRoutingConfig
+	TenantDatabases      map[string]string
+	TenantHeader         string
+	TenantBaseDomain     string
+	Embeddings           embeddings.Config
+	Mail                 mail.Config
+	MailBaseURL          string
+	MailMaxAttempts      int
+	WebhookMaxAttempts   int
+	WebhookRetention     time.Duration
+	Outbox               outbox.Config
+	OutboxPollInterval   time.Duration
+	OutboxRetention      time.Duration
+	JobConcurrency       int
+	JobMaxAttempts       int
+	JobLease             time.Duration
+	JobRetention         time.Duration
+	ExportDir            string
+	ActExpiry            time.Duration
+	ReceiptPrefix        string
+	ReceiptIssuer        string
+	ReceiptIssuerTaxID   string
+	ReceiptIssuerAddress string
+	Payments             payments.Config
+	PledgeAutoRelease    time.Duration
+	Rates                rates.Config
+	BaseCurrency         string
+	Geocoder             geocode.Config
+	GeocodeCacheTTL      time.Duration
+	Search               search.Config
+	Moderation           moderation.Config
+	MediaHosts           []string
+	TLS                  TLSConfig
+	Features             map[string]bool
+}
+
+// LoadConfig loads the configuration from the YAML file at path, if any,
+// with environment variables taking precedence over it and built-in
+// defaults filling in the rest. The returned error lists every invalid
+// setting, so they can all be fixed at once.
+func LoadConfig(path string) (*Config, error) {
+	src := &configSource{}
+	if path != "" {
+		file, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		src.file = file
+	}
+
+	// ENVIRONMENT selects the profile other defaults come from
+	environment := src.get("ENVIRONMENT", "development")
+	defaults := environmentProfile(environment)
+
+	allowedOrigins := defaults.allowedOrigins
+	if origins := src.get("ALLOWED_ORIGINS", ""); origins != "" {
+		allowedOrigins = strings.Split(origins, ",")
+	}
+
+	rateLimitPerMin := src.getInt("RATE_LIMIT_PER_MIN", 100*defaults.rateLimitScale)
+	rateLimitUserPerMin := src.getInt("RATE_LIMIT_USER_PER_MIN", 300*defaults.rateLimitScale)
+
+	// Route groups with their own timeouts, body size limits and rate
+	// limits, shared by anonymous and authenticated callers
+	requestTimeout := src.getDuration("REQUEST_TIMEOUT", 10*time.Second)
+	routeGroups := loadRouteGroups(src, requestTimeout, defaults.rateLimitScale)
+
+	// Live streams stay open indefinitely and exports are streamed unbuffered
+	// for as long as they take; other routes may be given their own
+	// timeouts as comma-separated prefix=duration pairs
+	routeTimeouts := []middleware.RouteTimeout{
+		{PathPrefix: "/api/v1/stats/stream", Timeout: 0},
+		{PathPrefix: "/api/v1/ws", Timeout: 0},
+		{PathPrefix: "/api/v1/events", Timeout: 0},
+		{PathPrefix: "/api/v1/admin/export/", Timeout: 0},
+		{PathPrefix: "/api/v1/admin/exports/", Timeout: 0},
+	}
+	extraTimeouts, err := parseRouteTimeouts(src.get("REQUEST_TIMEOUT_ROUTES", ""))
+	src.check("REQUEST_TIMEOUT_ROUTES", err)
+	routeTimeouts = append(routeTimeouts, extraTimeouts...)
+	routeTimeouts = append(routeTimeouts, groupTimeouts(routeGroups)...)
+
+	// Driver pool and network settings
+	neo4jDriver := database.DefaultClientConfig()
+	neo4jDriver.MaxConnectionPoolSize = src.getInt("NEO4J_MAX_POOL_SIZE", neo4jDriver.MaxConnectionPoolSize)
+	neo4jDriver.MaxConnectionLifetime = src.getDuration("NEO4J_MAX_CONNECTION_LIFETIME", neo4jDriver.MaxConnectionLifetime)
+	neo4jDriver.ConnectionAcquisitionTimeout = src.getDuration("NEO4J_ACQUISITION_TIMEOUT", neo4jDriver.ConnectionAcquisitionTimeout)
+	neo4jDriver.SocketConnectTimeout = src.getDuration("NEO4J_CONNECT_TIMEOUT", neo4jDriver.SocketConnectTimeout)
+	neo4jDriver.SocketKeepalive = src.get("NEO4J_KEEPALIVE", strconv.FormatBool(neo4jDriver.SocketKeepalive)) == "true"
+	neo4jDriver.FetchSize = src.getInt("NEO4J_FETCH_SIZE", neo4jDriver.FetchSize)
+	neo4jDriver.MaxTransactionRetryTime = src.getDuration("NEO4J_MAX_RETRY_TIME", neo4jDriver.MaxTransactionRetryTime)
+	neo4jDriver.SlowQueryThreshold = src.getDuration("NEO4J_SLOW_QUERY_THRESHOLD", neo4jDriver.SlowQueryThreshold)
+	neo4jDriver.QueryTimeout = src.getDuration("NEO4J_QUERY_TIMEOUT", neo4jDriver.QueryTimeout)
+	neo4jDriver.CACertFile = src.get("NEO4J_CA_CERT", "")
+	neo4jDriver.VerifyTimeout = src.getDuration("NEO4J_VERIFY_TIMEOUT", neo4jDriver.VerifyTimeout)
+	neo4jDriver.ReadRouting = src.get("NEO4J_READ_ROUTING", neo4jDriver.ReadRouting)
+
+	// Migrations are applied at startup outside production, where the
+	// migrate command runs before deploying. AUTO_MIGRATE is the older
+	// switch for the same choice.
+	schemaSetup := migrations.SetupCheck
+	if src.get("AUTO_MIGRATE", strconv.FormatBool(defaults.autoMigrate)) == "true" {
+		schemaSetup = migrations.SetupApply
+	}
+
+	// Security headers default to a locked-down API policy; CSP_DIRECTIVES
+	// overrides individual directives, e.g. for a frontend served from a CDN
+	securityHeaders := middleware.DefaultSecurityHeadersConfig()
+	securityHeaders.HSTS = src.get("HSTS_ENABLED", strconv.FormatBool(defaults.hsts)) == "true"
+	securityHeaders.HSTSMaxAge = src.getDuration("HSTS_MAX_AGE", securityHeaders.HSTSMaxAge)
+	securityHeaders.HSTSPreload = src.get("HSTS_PRELOAD", "false") == "true"
+	if ancestors := src.get("FRAME_ANCESTORS", ""); ancestors != "" {
+		securityHeaders.FrameAncestors = strings.Fields(ancestors)
+	}
+	if directives := src.get("CSP_DIRECTIVES", ""); directives != "" {
+		csp, err := middleware.ParseCSP(directives)
+		if src.check("CSP_DIRECTIVES", err) {
+			securityHeaders.CSP = securityHeaders.CSP.Merge(csp)
+		}
+	}
+
+	tenantDatabases, err := parseTenantDatabases(src.get("TENANT_DATABASES", ""))
+	src.check("TENANT_DATABASES", err)
+	featureFlags, err := features.Parse(src.get("FEATURE_FLAGS", ""))
+	src.check("FEATURE_FLAGS", err)
+
+	config := &Config{
+		Port:                 src.get("PORT", "8080"),
+		Neo4jURI:             src.get("NEO4J_URI", "bolt://localhost:7687"),
+		Neo4jUser:            src.get("NEO4J_USER", "neo4j"),
+		Neo4jPassword:        src.get("NEO4J_PASSWORD", "password"),
+		Neo4jDriver:          neo4jDriver,
+		JWTSecret:            src.get("JWT_SECRET", defaultJWTSecret),
+		Environment:          environment,
+		KeycloakURL:          src.get("KEYCLOAK_URL", ""),
+		KeycloakRealm:        src.get("KEYCLOAK_REALM", ""),
+		KeycloakClientID:     src.get("KEYCLOAK_CLIENT_ID", ""),
+		KeycloakClientSecret: src.get("KEYCLOAK_CLIENT_SECRET", ""),
+		AllowedOrigins:       allowedOrigins,
+		RateLimitPerMin:      rateLimitPerMin,
+		RateLimitUserPerMin:  rateLimitUserPerMin,
+		RateClasses:          groupRateClasses(routeGroups),
+		RouteGroups:          routeGroups,
+		LogLevel:             src.get("LOG_LEVEL", "info"),
+		LogFormat:            src.get("LOG_FORMAT", defaults.logFormat),
+		DebugErrors:          src.get("DEBUG_ERRORS", strconv.FormatBool(defaults.debugErrors)) == "true",
+		DBBreakerFailures:    src.getInt("DB_BREAKER_FAILURES", 5),
+		DBBreakerOpenTimeout: src.getDuration("DB_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+		RequestTimeout:       requestTimeout,
+		DrainDelay:           src.getDuration("DRAIN_DELAY", 0),
+		ReusePort:            src.get("REUSE_PORT", "false") == "true",
+		WorkerPoolSize:       src.getInt("WORKER_POOL_SIZE", 8),
+		WorkerQueueSize:      src.getInt("WORKER_QUEUE_SIZE", 1000),
+		ShutdownTimeout:      src.getDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		RouteTimeouts:        routeTimeouts,
+		MaintenanceMode:      src.get("MAINTENANCE_MODE", "false") == "true",
+		IPFilterFile:         src.get("IP_FILTER_FILE", ""),
+		ResponseCacheSize:    src.getInt("RESPONSE_CACHE_SIZE", 1000),
+		RevocationCacheSize:  src.getInt("REVOKED_TOKENS_CACHE_SIZE", 10000),
+		RedisURL:             src.get("REDIS_URL", ""),
+		SentryDSN:            src.get("SENTRY_DSN", ""),
+		LogBodies:            src.get("LOG_BODIES", "false") == "true",
+		LogRedactFields:      splitList(src.get("LOG_REDACT_FIELDS", "")),
+		BotRejectScore:       src.getInt("BOT_REJECT_SCORE", 100),
+		BotChallengeScore:    src.getInt("BOT_CHALLENGE_SCORE", 60),
+		CaptchaVerifyURL:     src.get("CAPTCHA_VERIFY_URL", ""),
+		CaptchaSecret:        src.get("CAPTCHA_SECRET", ""),
+		SecurityHeaders:      securityHeaders,
+		AccessLog:            src.get("ACCESS_LOG", ""),
+		AccessLogFormat:      src.get("ACCESS_LOG_FORMAT", "combined"),
+		DedupWindow:          src.getDuration("DEDUP_WINDOW", 5*time.Second),
+		ExposeBookmarks:      src.get("NEO4J_EXPOSE_BOOKMARKS", "false") == "true",
+		ServeFrontend:        src.get("SERVE_FRONTEND", "true") == "true",
+		AdminAddr:            src.get("ADMIN_ADDR", ""),
+		FrontendDir:          src.get("FRONTEND_DIR", ""),
+		ReadRouting: middleware.ReadRoutingConfig{
+			LeaderPaths: splitList(src.get("NEO4J_LEADER_READ_ROUTES", "")),
+			AllowHeader: src.get("NEO4J_READ_ROUTING_HEADER", "false") == "true",
+		},
+		TenantDatabases:  tenantDatabases,
+		TenantHeader:     src.get("TENANT_HEADER", middleware.TenantHeader),
+		TenantBaseDomain: src.get("TENANT_BASE_DOMAIN", ""),
+		SchemaSetup:      src.get("SCHEMA_SETUP", schemaSetup),
+		SchemaRequired:   src.get("SCHEMA_REQUIRED", "false") == "true",
+		DeletedRetention: src.getDuration("DELETED_RETENTION", 30*24*time.Hour),
+		Embeddings: embeddings.Config{
+			Provider:   src.get("EMBEDDINGS_PROVIDER", ""),
+			URL:        src.get("EMBEDDINGS_URL", ""),
+			APIKey:     src.get("EMBEDDINGS_API_KEY", ""),
+			Model:      src.get("EMBEDDINGS_MODEL", ""),
+			Dimensions: src.getInt("EMBEDDINGS_DIMENSIONS", 0),
+		},
+		Mail: mail.Config{
+			Driver:             src.get("MAIL_DRIVER", ""),
+			From:               src.get("MAIL_FROM", ""),
+			SMTPAddr:           src.get("SMTP_ADDR", ""),
+			SMTPUsername:       src.get("SMTP_USERNAME", ""),
+			SMTPPassword:       src.get("SMTP_PASSWORD", ""),
+			SESRegion:          src.get("SES_REGION", src.get("AWS_REGION", "")),
+			SESAccessKeyID:     src.get("SES_ACCESS_KEY_ID", src.get("AWS_ACCESS_KEY_ID", "")),
+			SESSecretAccessKey: src.get("SES_SECRET_ACCESS_KEY", src.get("AWS_SECRET_ACCESS_KEY", "")),
+			SendGridAPIKey:     src.get("SENDGRID_API_KEY", ""),
+		},
+		MailBaseURL:        src.get("MAIL_BASE_URL", "http://localhost:3000"),
+		MailMaxAttempts:    src.getInt("MAIL_MAX_ATTEMPTS", 5),
+		WebhookMaxAttempts: src.getInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookRetention:   src.getDuration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour),
+		Outbox: outbox.Config{
+			Driver:        src.get("OUTBOX_DRIVER", ""),
+			KafkaRESTURL:  src.get("KAFKA_REST_URL", ""),
+			KafkaTopic:    src.get("KAFKA_TOPIC", "payforward.events"),
+			KafkaUsername: src.get("KAFKA_USERNAME", ""),
+			KafkaPassword: src.get("KAFKA_PASSWORD", ""),
+			NATSURL:       src.get("NATS_URL", ""),
+			NATSSubject:   src.get("NATS_SUBJECT", "payforward.events"),
+		},
+		OutboxPollInterval:   src.getDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:      src.getDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		JobConcurrency:       src.getInt("JOB_CONCURRENCY", jobs.DefaultConcurrency),
+		JobMaxAttempts:       src.getInt("JOB_MAX_ATTEMPTS", jobs.DefaultMaxAttempts),
+		JobLease:             src.getDuration("JOB_LEASE", jobs.DefaultLease),
+		JobRetention:         src.getDuration("JOB_RETENTION", 7*24*time.Hour),
+		ExportDir:            src.get("EXPORT_DIR", "exports"),
+		ActExpiry:            src.getDuration("ACT_EXPIRY", 0),
+		ReceiptPrefix:        src.get("RECEIPT_NUMBER_PREFIX", "PF"),
+		ReceiptIssuer:        src.get("RECEIPT_ISSUER_NAME", "PayForward Now"),
+		ReceiptIssuerTaxID:   src.get("RECEIPT_ISSUER_TAX_ID", ""),
+		ReceiptIssuerAddress: src.get("RECEIPT_ISSUER_ADDRESS", ""),
+		Payments: payments.Config{
+			Driver:          src.get("PAYMENTS_DRIVER", ""),
+			StripeSecretKey: src.get("STRIPE_SECRET_KEY", ""),
+		},
+		PledgeAutoRelease: src.getDuration("PLEDGE_AUTO_RELEASE", 5*24*time.Hour),
+		Rates: rates.Config{
+			Driver:                 src.get("RATES_DRIVER", ""),
+			OpenExchangeRatesAppID: src.get("OPENEXCHANGERATES_APP_ID", ""),
+		},
+		BaseCurrency: strings.ToUpper(src.get("BASE_CURRENCY", "USD")),
+		Geocoder: geocode.Config{
+			Driver:       src.get("GEOCODER_DRIVER", ""),
+			NominatimURL: src.get("NOMINATIM_URL", ""),
+			GoogleAPIKey: src.get("GOOGLE_MAPS_API_KEY", ""),
+		},
+		GeocodeCacheTTL: src.getDuration("GEOCODE_CACHE_TTL", 30*24*time.Hour),
+		Moderation: moderation.Config{
+			Provider: src.get("MODERATION_PROVIDER", ""),
+			APIURL:   src.get("MODERATION_API_URL", ""),
+			APIKey:   src.get("MODERATION_API_KEY", ""),
+		},
+		MediaHosts: splitList(src.get("MEDIA_HOSTS", "")),
+		Search: search.Config{
+			Driver:                src.get("SEARCH_DRIVER", ""),
+			ElasticsearchURL:      src.get("ELASTICSEARCH_URL", ""),
+			ElasticsearchIndex:    src.get("ELASTICSEARCH_INDEX", "payforward"),
+			ElasticsearchUsername: src.get("ELASTICSEARCH_USERNAME", ""),
+			ElasticsearchPassword: src.get("ELASTICSEARCH_PASSWORD", ""),
+		},
+		Features: featureFlags,
+		TLS: TLSConfig{
+			CertFile:         src.get("TLS_CERT_FILE", ""),
+			KeyFile:          src.get("TLS_KEY_FILE", ""),
+			ACMEDomains:      splitList(src.get("ACME_DOMAINS", "")),
+			ACMEEmail:        src.get("ACME_EMAIL", ""),
+			ACMECacheDir:     src.get("ACME_CACHE_DIR", "acme-cache"),
+			ACMEDirectoryURL: src.get("ACME_DIRECTORY_URL", ""),
+			RedirectPort:     src.get("HTTP_REDIRECT_PORT", ""),
+		},
+	}
+
+	errs := append(src.errs, src.unknownKeys()...)
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+	return config, nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
+
+// validCurrency reports whether code looks like an ISO 4217 currency code:
+// three uppercase letters
+func validCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// validURL reports whether value is an absolute URL with one of schemes and,
+// unless it names a unix socket, a host
+func validURL(value string, schemes ...string) bool {
+	u, err := url.Parse(value)
+	return err == nil && slices.Contains(schemes, u.Scheme) && (u.Host != "" || u.Scheme == "unix")
+}
+
+// configSource looks settings up in the environment, then in the config
+// file, recording the values that fail to parse instead of stopping at the
+// first one
+type configSource struct {
+	// file holds the config file's settings under their environment
+	// variable names
+	file map[string]string
+	used map[string]bool
+	errs []error
+}
+
+func (s *configSource) lookup(key string) (string, bool) {
+	if s.used == nil {
+		s.used = make(map[string]bool)
+	}
+	s.used[key] = true
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+	value, ok := s.file[key]
+	return value, ok
+}
+
+// get returns the setting for key, or defaultValue if it is unset or empty
+func (s *configSource) get(key, defaultValue string) string {
+	if value, ok := s.lookup(key); ok && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (s *configSource) getInt(key string, defaultValue int) int {
+	value := s.get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if !s.check(key, err) {
+		return defaultValue
+	}
+	return n
+}
+
+func (s *configSource) getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := s.get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if !s.check(key, err) {
+		return defaultValue
+	}
+	return d
+}
+
+// check records err against key and reports whether there was none
+func (s *configSource) check(key string, err error) bool {
+	if err != nil {
+		s.errs = append(s.errs, fmt.Errorf("%s: %w", key, err))
+		return false
+	}
+	return true
+}
+
+// unknownKeys reports the config file settings LoadConfig never looked up,
+// which are most likely typos
+func (s *configSource) unknownKeys() []error {
+	var unknown []string
+	for key := range s.file {
+		if !s.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	errs := make([]error, 0, len(unknown))
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting in config file", key))
+	}
+	return errs
+}
+
+// readConfigFile reads a YAML (or JSON) config file into settings keyed by
+// their environment variable names. Keys are matched case-insensitively with
+// dashes read as underscores, and nested sections are joined with an
+// underscore, so
+//
+//	neo4j:
+//	  max-pool-size: 50
+//
+// sets NEO4J_MAX_POOL_SIZE. Lists become comma-separated values.
+func readConfigFile(path string) (map[string]string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", ".json":
+	default:
+		return nil, fmt.Errorf("unsupported config file %s; use YAML (.yaml, .yml) or JSON", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	settings := make(map[string]string)
+	if err := flattenConfig("", doc, settings); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+func flattenConfig(prefix string, doc map[string]interface{}, settings map[string]string) error {
+	for name, value := range doc {
+		key := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		if section, ok := value.(map[string]interface{}); ok {
+			if err := flattenConfig(key, section, settings); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := settings[key]; ok {
+			return fmt.Errorf("%s is set twice", key)
+		}
+
+		switch v := value.(type) {
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			settings[key] = strings.Join(items, ",")
+		case nil:
+			settings[key] = ""
+		default:
+			settings[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// validate checks the settings that parsed but don't make sense, returning
+// one error per field
+func (c *Config) validate() []error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+	}
+
+	if !validPort(c.Port) {
+		invalid("PORT", "must be a port number, got %q", c.Port)
+	}
+	if c.Neo4jURI == "" {
+		invalid("NEO4J_URI", "must be set")
+	}
+	if err := c.Neo4jDriver.Validate(); err != nil {
+		invalid("NEO4J driver settings", "%v", err)
+	}
+	if _, ok := profiles[c.Environment]; !ok {
+		invalid("ENVIRONMENT", "must be %s, got %q", environmentNames(), c.Environment)
+	}
+	if c.Environment == "production" && c.JWTSecret == defaultJWTSecret {
+		invalid("JWT_SECRET", "must be changed from the default in production")
+	}
+	if _, err := middleware.NewOriginMatcher(c.AllowedOrigins); err != nil {
+		invalid("ALLOWED_ORIGINS", "%v", err)
+	}
+	if c.KeycloakURL != "" {
+		if !validURL(c.KeycloakURL, "http", "https") {
+			invalid("KEYCLOAK_URL", "must be an http or https URL, such as https://auth.example.com, got %q", c.KeycloakURL)
+		}
+		if c.KeycloakRealm == "" {
+			invalid("KEYCLOAK_REALM", "must be set along with KEYCLOAK_URL")
+		}
+	}
+	urls := []struct {
+		key     string
+		value   string
+		schemes []string
+	}{
+		{"REDIS_URL", c.RedisURL, []string{"redis", "rediss", "unix"}},
+		{"SENTRY_DSN", c.SentryDSN, []string{"http", "https"}},
+		{"CAPTCHA_VERIFY_URL", c.CaptchaVerifyURL, []string{"http", "https"}},
+		{"EMBEDDINGS_URL", c.Embeddings.URL, []string{"http", "https"}},
+		{"MAIL_BASE_URL", c.MailBaseURL, []string{"http", "https"}},
+		{"NOMINATIM_URL", c.Geocoder.NominatimURL, []string{"http", "https"}},
+		{"ELASTICSEARCH_URL", c.Search.ElasticsearchURL, []string{"http", "https"}},
+		{"MODERATION_API_URL", c.Moderation.APIURL, []string{"http", "https"}},
+	}
+	for _, u := range urls {
+		if u.value != "" && !validURL(u.value, u.schemes...) {
+			schemes := strings.Join(u.schemes[:len(u.schemes)-1], ", ") + " or " + u.schemes[len(u.schemes)-1]
+			invalid(u.key, "must be a %s URL, got %q", schemes, u.value)
+		}
+	}
+	if _, err := embeddings.NewProvider(c.Embeddings); err != nil {
+		invalid("EMBEDDINGS_PROVIDER", "%v", err)
+	}
+	if _, err := mail.NewDriver(c.Mail); err != nil {
+		invalid("MAIL_DRIVER", "%v", err)
+	}
+	if _, err := outbox.NewPublisher(c.Outbox); err != nil {
+		invalid("OUTBOX_DRIVER", "%v", err)
+	}
+	if _, err := payments.NewProvider(c.Payments); err != nil {
+		invalid("PAYMENTS_DRIVER", "%v", err)
+	}
+	if _, err := rates.NewProvider(c.Rates); err != nil {
+		invalid("RATES_DRIVER", "%v", err)
+	}
+	if _, err := geocode.NewGeocoder(c.Geocoder); err != nil {
+		invalid("GEOCODER_DRIVER", "%v", err)
+	}
+	if _, err := moderation.NewProvider(c.Moderation); err != nil {
+		invalid("MODERATION_PROVIDER", "%v", err)
+	}
+	if _, err := search.NewIndex(c.Search); err != nil {
+		invalid("SEARCH_DRIVER", "%v", err)
+	}
+	if c.GeocodeCacheTTL <= 0 {
+		invalid("GEOCODE_CACHE_TTL", "must be positive, got %s", c.GeocodeCacheTTL)
+	}
+	if !validCurrency(c.BaseCurrency) {
+		invalid("BASE_CURRENCY", "must be a three-letter ISO 4217 code, got %q", c.BaseCurrency)
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		invalid("LOG_LEVEL", "%v", err)
+	}
+	for _, host := range c.MediaHosts {
+		if u, err := url.Parse("https://" + host); err != nil || u.Host != host || u.Path != "" || u.User != nil {
+			invalid("MEDIA_HOSTS", "must list host names such as cdn.example.com, got %q", host)
+		}
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		invalid("LOG_FORMAT", "must be text or json, got %q", c.LogFormat)
+	}
+	if _, err := middleware.ParseAccessLogFormat(c.AccessLogFormat); err != nil {
+		invalid("ACCESS_LOG_FORMAT", "%v", err)
+	}
+	if !slices.Contains([]string{migrations.SetupApply, migrations.SetupCheck, migrations.SetupSkip}, c.SchemaSetup) {
+		invalid("SCHEMA_SETUP", "must be %s, %s or %s, got %q", migrations.SetupApply, migrations.SetupCheck, migrations.SetupSkip, c.SchemaSetup)
+	}
+
+	positive := []struct {
+		key   string
+		value int
+	}{
+		{"RATE_LIMIT_PER_MIN", c.RateLimitPerMin},
+		{"RATE_LIMIT_USER_PER_MIN", c.RateLimitUserPerMin},
+		{"DB_BREAKER_FAILURES", c.DBBreakerFailures},
+		{"RESPONSE_CACHE_SIZE", c.ResponseCacheSize},
+		{"REVOKED_TOKENS_CACHE_SIZE", c.RevocationCacheSize},
+		{"WORKER_POOL_SIZE", c.WorkerPoolSize},
+		{"WORKER_QUEUE_SIZE", c.WorkerQueueSize},
+		{"MAIL_MAX_ATTEMPTS", c.MailMaxAttempts},
+		{"WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts},
+		{"JOB_CONCURRENCY", c.JobConcurrency},
+		{"JOB_MAX_ATTEMPTS", c.JobMaxAttempts},
+	}
+	for _, g := range c.RouteGroups {
+		positive = append(positive, struct {
+			key   string
+			value int
+		}{g.rateKey, g.PerMinute})
+	}
+	for _, p := range positive {
+		if p.value <= 0 {
+			invalid(p.key, "must be positive, got %d", p.value)
+		}
+	}
+
+	durations := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"DB_BREAKER_OPEN_TIMEOUT", c.DBBreakerOpenTimeout},
+		{"REQUEST_TIMEOUT", c.RequestTimeout},
+		{"DEDUP_WINDOW", c.DedupWindow},
+		{"DELETED_RETENTION", c.DeletedRetention},
+		{"WEBHOOK_DELIVERY_RETENTION", c.WebhookRetention},
+		{"OUTBOX_RETENTION", c.OutboxRetention},
+		{"JOB_RETENTION", c.JobRetention},
+		{"ACT_EXPIRY", c.ActExpiry},
+		{"DRAIN_DELAY", c.DrainDelay},
+	}
+	for _, g := range c.RouteGroups {
+		prefix := g.settingPrefix()
+		durations = append(durations, []struct {
+			key   string
+			value time.Duration
+		}{
+			{prefix + "TIMEOUT", g.Timeout},
+			{prefix + "READ_TIMEOUT", g.ReadTimeout},
+			{prefix + "WRITE_TIMEOUT", g.WriteTimeout},
+		}...)
+		if g.MaxBodyBytes < 0 {
+			invalid(prefix+"MAX_BODY_BYTES", "must not be negative, got %d", g.MaxBodyBytes)
+		}
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			invalid(d.key, "must not be negative, got %s", d.value)
+		}
+	}
+
+	if c.AdminAddr != "" {
+		if _, port, err := net.SplitHostPort(c.AdminAddr); err != nil || !validPort(port) {
+			invalid("ADMIN_ADDR", "must be host:port, such as 127.0.0.1:9090, got %q", c.AdminAddr)
+		} else if port == c.Port || port == c.TLS.RedirectPort {
+			invalid("ADMIN_ADDR", "must use a port of its own, got %s", port)
+		}
+	}
+	if c.FrontendDir != "" {
+		if _, err := os.Stat(filepath.Join(c.FrontendDir, "index.html")); err != nil {
+			invalid("FRONTEND_DIR", "must contain the frontend's index.html: %v", err)
+		}
+	}
+	if c.ShutdownTimeout <= 0 {
+		invalid("SHUTDOWN_TIMEOUT", "must be positive, got %s", c.ShutdownTimeout)
+	}
+	if c.OutboxPollInterval <= 0 {
+		invalid("OUTBOX_POLL_INTERVAL", "must be positive, got %s", c.OutboxPollInterval)
+	}
+	if c.JobLease <= 0 {
+		invalid("JOB_LEASE", "must be positive, got %s", c.JobLease)
+	}
+	// Card authorizations expire after 7 days, so pledges must be released
+	// before then
+	if c.PledgeAutoRelease <= 0 || (c.Payments.Driver == "stripe" && c.PledgeAutoRelease > 7*24*time.Hour) {
+		invalid("PLEDGE_AUTO_RELEASE", "must be positive, and at most 168h with the stripe driver, got %s", c.PledgeAutoRelease)
+	}
+	if c.BotChallengeScore > c.BotRejectScore {
+		invalid("BOT_CHALLENGE_SCORE", "must not be above BOT_REJECT_SCORE (%d), got %d", c.BotRejectScore, c.BotChallengeScore)
+	}
+	if c.TLS.RedirectPort != "" && !validPort(c.TLS.RedirectPort) {
+		invalid("HTTP_REDIRECT_PORT", "must be a port number, got %q", c.TLS.RedirectPort)
+	} else if c.TLS.RedirectPort == c.Port {
+		invalid("HTTP_REDIRECT_PORT", "must differ from PORT")
+	}
+	errs = append(errs, c.TLS.validate()...)
+	if c.Embeddings.Dimensions < 0 {
+		invalid("EMBEDDINGS_DIMENSIONS", "must not be negative, got %d", c.Embeddings.Dimensions)
+	}
+	return errs
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseTenantDatabases parses "tenant=database" pairs such as
+// "acme=acme,oslo=community_oslo". A bare tenant uses a database of the same
+// name.
+func parseTenantDatabases(value string) (map[string]string, error) {
+	databases := make(map[string]string)
+	var errs []error
+	for _, entry := range splitList(value) {
+		tenant, name, ok := strings.Cut(entry, "=")
+		tenant, name = strings.ToLower(strings.TrimSpace(tenant)), strings.TrimSpace(name)
+		if !ok {
+			name = tenant
+		}
+		if tenant == "" || name == "" {
+			errs = append(errs, fmt.Errorf("invalid tenant database %q", entry))
+			continue
+		}
+		databases[tenant] = name
+	}
+	return databases, errors.Join(errs...)
+}
+
+// parseRouteTimeouts parses "prefix=duration" pairs such as
+// "/api/v1/users/=30s,/api/v1/admin/=1m"
+func parseRouteTimeouts(value string) ([]middleware.RouteTimeout, error) {
+	var routes []middleware.RouteTimeout
+	var errs []error
+	for _, entry := range splitList(value) {
+		prefix, duration, ok := strings.Cut(entry, "=")
+		if !ok || prefix == "" {
+			errs = append(errs, fmt.Errorf("invalid route timeout %q; use prefix=duration", entry))
+			continue
+		}
+		timeout, err := time.ParseDuration(duration)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid route timeout %q: %w", entry, err))
+			continue
+		}
+		routes = append(routes, middleware.RouteTimeout{PathPrefix: prefix, Timeout: timeout})
+	}
+	return routes, errors.Join(errs...)
+}
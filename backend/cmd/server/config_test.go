@@ -0,0 +1,212 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/migrations"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	// Development's rate limits are ten times production's
+	if config.Port != "8080" || config.RateLimitPerMin != 1000 || config.RequestTimeout != 10*time.Second {
+		t.Errorf("unexpected defaults: port %s, rate %d, timeout %s", config.Port, config.RateLimitPerMin, config.RequestTimeout)
+	}
+}
+
+func TestLoadConfigFileWithEnvOverrides(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+port: 9090
+log-level: debug
+allowed_origins:
+  - https://a.example
+  - https://b.example
+neo4j:
+  uri: neo4j://db:7687
+  max-pool-size: 20
+request_timeout: 30s
+`)
+	t.Setenv("PORT", "9191")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.Port != "9191" {
+		t.Errorf("PORT = %s, want the environment's 9191", config.Port)
+	}
+	if config.LogLevel != "debug" {
+		t.Errorf("LOG_LEVEL = %s, want debug", config.LogLevel)
+	}
+	if strings.Join(config.AllowedOrigins, " ") != "https://a.example https://b.example" {
+		t.Errorf("ALLOWED_ORIGINS = %v", config.AllowedOrigins)
+	}
+	if config.Neo4jURI != "neo4j://db:7687" || config.Neo4jDriver.MaxConnectionPoolSize != 20 {
+		t.Errorf("nested settings not applied: uri %s, pool %d", config.Neo4jURI, config.Neo4jDriver.MaxConnectionPoolSize)
+	}
+	if config.RequestTimeout != 30*time.Second {
+		t.Errorf("REQUEST_TIMEOUT = %s, want 30s", config.RequestTimeout)
+	}
+}
+
+func TestLoadConfigRouteGroups(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+request_timeout: 20s
+route_group:
+  public-read:
+    timeout: 5s
+    write_timeout: 1m
+  admin:
+    max_body_bytes: 1024
+rate_limit_admin_per_min: 12
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	groups := map[string]RouteGroup{}
+	for _, g := range config.RouteGroups {
+		groups[g.Name] = g
+	}
+	if g := groups["public-read"]; g.Timeout != 5*time.Second || g.WriteTimeout != time.Minute {
+		t.Errorf("public-read = %+v, want the file's timeouts", g)
+	}
+	if g := groups["admin"]; g.MaxBodyBytes != 1024 || g.PerMinute != 12 || g.Timeout != 20*time.Second {
+		t.Errorf("admin = %+v, want the file's limits and REQUEST_TIMEOUT", g)
+	}
+	if g := groups["write"]; g.MaxBodyBytes != 1<<20 || g.PerMinute != 300 {
+		t.Errorf("write = %+v, want the defaults", g)
+	}
+
+	// The groups feed the rate classes and request timeouts
+	if len(config.RateClasses) != len(config.RouteGroups) || config.RateClasses[1].Name != "admin" || config.RateClasses[1].PerMinute != 12 {
+		t.Errorf("rate classes = %+v", config.RateClasses)
+	}
+	for _, path := range []string{"/api/v1/admin/export/users", "/api/v1/admin/exports/job-1"} {
+		for _, rt := range config.RouteTimeouts {
+			if strings.HasPrefix(path, rt.PathPrefix) {
+				if rt.Timeout != 0 {
+					t.Errorf("%s timeout = %+v, want exports exempt", path, rt)
+				}
+				break
+			}
+		}
+	}
+	last := config.RouteTimeouts[len(config.RouteTimeouts)-1]
+	if last.PathPrefix != "/api/" || last.Timeout != 5*time.Second {
+		t.Errorf("last route timeout = %+v, want public-read's", last)
+	}
+}
+
+func TestLoadConfigListsEveryInvalidField(t *testing.T) {
+	path := writeConfigFile(t, "config.yml", `
+port: 70000
+rate_limit_per_min: lots
+log_format: xml
+request_timeout_routes: /api/v1/users/=soon
+potr: 8080
+frontend_dir: /nonexistent
+admin_addr: localhost
+allowed_origins: https://a.example/
+keycloak_url: auth.example.com
+redis_url: localhost:6379
+embeddings_provider: word2vec
+media_hosts: https://cdn.example.com/
+route_group:
+  auth:
+    max_body_bytes: -1
+`)
+	t.Setenv("BOT_CHALLENGE_SCORE", "150")
+
+	_, err := LoadConfig(path)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"PORT", "RATE_LIMIT_PER_MIN", "LOG_FORMAT", "REQUEST_TIMEOUT_ROUTES", "POTR", "BOT_CHALLENGE_SCORE", "FRONTEND_DIR", "ADMIN_ADDR", "ROUTE_GROUP_AUTH_MAX_BODY_BYTES", "ALLOWED_ORIGINS", "KEYCLOAK_URL", "KEYCLOAK_REALM", "REDIS_URL", "EMBEDDINGS_PROVIDER", "MEDIA_HOSTS"} {
+		if !strings.Contains(err.Error(), want+":") {
+			t.Errorf("error doesn't mention %s:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadConfigRejectsDefaultSecretInProduction(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	_, err := LoadConfig("")
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") {
+		t.Fatalf("expected a JWT_SECRET error, got %v", err)
+	}
+
+	t.Setenv("JWT_SECRET", "a-real-secret")
+	if _, err := LoadConfig(""); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+}
+
+func TestLoadConfigProfiles(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("JWT_SECRET", "a-real-secret")
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.LogFormat != "json" || !config.SecurityHeaders.HSTS || config.DebugErrors || len(config.AllowedOrigins) != 0 || config.RateLimitPerMin != 100 {
+		t.Errorf("unexpected production defaults: %+v", config)
+	}
+	if config.SchemaSetup != migrations.SetupCheck {
+		t.Errorf("SCHEMA_SETUP = %s, want migrations checked in production", config.SchemaSetup)
+	}
+
+	// Each default can be overridden
+	t.Setenv("LOG_FORMAT", "text")
+	t.Setenv("DEBUG_ERRORS", "true")
+	t.Setenv("RATE_LIMIT_PER_MIN", "50")
+	config, err = LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if config.LogFormat != "text" || !config.DebugErrors || config.RateLimitPerMin != 50 {
+		t.Errorf("expected settings to override the profile, got %+v", config)
+	}
+
+	t.Setenv("ENVIRONMENT", "prod")
+	if _, err := LoadConfig(""); err == nil || !strings.Contains(err.Error(), "ENVIRONMENT:") {
+		t.Errorf("expected an unknown environment to be rejected, got %v", err)
+	}
+}
+
+func TestReadConfigFile(t *testing.T) {
+	if _, err := readConfigFile(writeConfigFile(t, "config.toml", `port = 8080`)); err == nil {
+		t.Error("expected TOML to be rejected")
+	}
+	if _, err := readConfigFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	if _, err := readConfigFile(writeConfigFile(t, "dup.yaml", "neo4j_uri: a\nneo4j:\n  uri: b\n")); err == nil {
+		t.Error("expected an error for a setting given twice")
+	}
+
+	settings, err := readConfigFile(writeConfigFile(t, "config.json", `{"neo4j": {"user": "admin"}, "maintenance_mode": true}`))
+	if err != nil {
+		t.Fatalf("readConfigFile: %v", err)
+	}
+	if settings["NEO4J_USER"] != "admin" || settings["MAINTENANCE_MODE"] != "true" {
+		t.Errorf("settings = %v", settings)
+	}
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/diagnostics"
+	"payforwardnow/internal/migrations"
+)
+
+// doctorCheckTimeout bounds each check of the doctor command and endpoint
+const doctorCheckTimeout = 10 * time.Second
+
+// certExpiryWarning is how long before a certificate expires the doctor
+// starts warning about it
+const certExpiryWarning = 14 * 24 * time.Hour
+
+// doctor checks the server's configuration and the services it depends on.
+// Connections that weren't made are checked as unavailable or not
+// configured.
+type doctor struct {
+	config *Config
+	neo4j  *database.Neo4jClient
+	// neo4jErr is why neo4j is nil
+	neo4jErr error
+	keycloak *auth.KeycloakAuth
+	redis    *cache.Redis
+	// redisErr is why redis is nil although REDIS_URL is set
+	redisErr error
+}
+
+// run runs every check
+func (d *doctor) run(ctx context.Context) diagnostics.Report {
+	return diagnostics.Run(ctx, d.checks(), doctorCheckTimeout)
+}
+
+func (d *doctor) checks() []diagnostics.Check {
+	checks := []diagnostics.Check{
+		{Name: "config", Run: d.checkConfig},
+		{Name: "neo4j", Run: d.checkNeo4j},
+		{Name: "schema default", Run: d.checkSchema("")},
+	}
+
+	tenants := make([]string, 0, len(d.config.TenantDatabases))
+	for tenant := range d.config.TenantDatabases {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	for _, tenant := range tenants {
+		checks = append(checks, diagnostics.Check{
+			Name: "schema " + tenant,
+			Run:  d.checkSchema(d.config.TenantDatabases[tenant]),
+		})
+	}
+
+	return append(checks,
+		diagnostics.Check{Name: "keycloak", Run: d.checkKeycloak},
+		diagnostics.Check{Name: "jwks", Run: d.checkJWKS},
+		diagnostics.Check{Name: "redis", Run: d.checkRedis},
+		diagnostics.Check{Name: "tls", Run: d.checkTLS},
+		diagnostics.Check{Name: "files", Run: d.checkFiles},
+	)
+}
+
+// checkConfig warns about settings that are valid but unsafe. LoadConfig
+// has already rejected invalid ones.
+func (d *doctor) checkConfig(context.Context) (string, string) {
+	var warnings []string
+	if d.config.JWTSecret == defaultJWTSecret {
+		warnings = append(warnings, "JWT_SECRET is the development default")
+	}
+	if d.config.DebugErrors && d.config.Environment == "production" {
+		warnings = append(warnings, "DEBUG_ERRORS returns stack traces to clients")
+	}
+	if d.config.Mail.Driver != "" && d.config.Environment == "production" && strings.Contains(d.config.MailBaseURL, "localhost") {
+		warnings = append(warnings, "MAIL_BASE_URL points links in emails at localhost")
+	}
+	if d.config.KeycloakURL == "" && d.config.Environment == "production" {
+		warnings = append(warnings, "Keycloak is not configured, so the admin API is disabled")
+	}
+	if len(warnings) > 0 {
+		return diagnostics.Warn("%s", strings.Join(warnings, "; "))
+	}
+	return diagnostics.Pass("valid for %s", d.config.Environment)
+}
+
+func (d *doctor) checkNeo4j(ctx context.Context) (string, string) {
+	if d.neo4j == nil {
+		return diagnostics.Fail(d.neo4jErr)
+	}
+	if err := d.neo4j.GetDriver().VerifyConnectivity(ctx); err != nil {
+		return diagnostics.Fail(err)
+	}
+	return diagnostics.Pass("connected to %s", d.config.Neo4jURI)
+}
+
+// checkSchema compares the migrations applied to databaseName, the default
+// database when empty, with those shipped with the binary
+func (d *doctor) checkSchema(databaseName string) func(context.Context) (string, string) {
+	return func(ctx context.Context) (string, string) {
+		if d.neo4j == nil {
+			return diagnostics.Skip("Neo4j is unavailable")
+		}
+		schema, err := migrations.Embedded()
+		if err != nil {
+			return diagnostics.Fail(err)
+		}
+
+		statuses, err := migrations.New(d.neo4j, schema).Status(database.WithDatabase(ctx, databaseName))
+		if err != nil {
+			return diagnostics.Fail(err)
+		}
+		version, pending := 0, 0
+		for _, s := range statuses {
+			if s.AppliedAt == nil {
+				pending++
+			} else {
+				version = s.Version
+			}
+		}
+		if pending > 0 {
+			return diagnostics.Warn("version %04d, %d migrations pending; run server migrate up", version, pending)
+		}
+		return diagnostics.Pass("version %04d, up to date", version)
+	}
+}
+
+func (d *doctor) checkKeycloak(ctx context.Context) (string, string) {
+	if d.keycloak == nil {
+		return diagnostics.Skip("KEYCLOAK_URL is not set; JWT tokens are used")
+	}
+	if err := d.keycloak.CheckRealm(ctx); err != nil {
+		return diagnostics.Fail(err)
+	}
+	return diagnostics.Pass("realm %s is reachable", d.config.KeycloakRealm)
+}
+
+func (d *doctor) checkJWKS(ctx context.Context) (string, string) {
+	if d.keycloak == nil {
+		return diagnostics.Skip("KEYCLOAK_URL is not set")
+	}
+	n, err := d.keycloak.CheckKeys(ctx)
+	if err != nil {
+		return diagnostics.Fail(err)
+	}
+	if n == 0 {
+		return diagnostics.Fail(errors.New("the realm has no RSA signing keys, so no token can be verified"))
+	}
+	return diagnostics.Pass("%d signing keys", n)
+}
+
+func (d *doctor) checkRedis(ctx context.Context) (string, string) {
+	switch {
+	case d.config.RedisURL == "":
+		return diagnostics.Skip("REDIS_URL is not set; state is kept in memory")
+	case d.redis == nil:
+		return diagnostics.Fail(d.redisErr)
+	}
+	if err := d.redis.Ping(ctx); err != nil {
+		return diagnostics.Fail(err)
+	}
+	return diagnostics.Pass("reachable")
+}
+
+// checkTLS loads the certificate and key files and warns when the
+// certificate is about to expire. ACME certificates are renewed by the
+// server, so only their cache directory is checked.
+func (d *doctor) checkTLS(context.Context) (string, string) {
+	c := d.config.TLS
+	switch {
+	case !c.Enabled():
+		return diagnostics.Skip("HTTPS is not configured")
+	case len(c.ACMEDomains) > 0:
+		if err := checkWritableDir(c.ACMECacheDir); err != nil {
+			return diagnostics.Fail(fmt.Errorf("ACME_CACHE_DIR: %w", err))
+		}
+		return diagnostics.Pass("ACME certificates for %s", strings.Join(c.ACMEDomains, ", "))
+	}
+
+	pair, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return diagnostics.Fail(err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return diagnostics.Fail(err)
+	}
+	expires := time.Until(cert.NotAfter)
+	switch {
+	case expires <= 0:
+		return diagnostics.Fail(fmt.Errorf("the certificate expired on %s", cert.NotAfter.Format(time.DateOnly)))
+	case expires < certExpiryWarning:
+		return diagnostics.Warn("the certificate expires on %s", cert.NotAfter.Format(time.DateOnly))
+	}
+	return diagnostics.Pass("certificate valid until %s", cert.NotAfter.Format(time.DateOnly))
+}
+
+// checkFiles checks that the files the server reads exist and that it can
+// write where it logs
+func (d *doctor) checkFiles(context.Context) (string, string) {
+	var checked []string
+	var errs []error
+	if path := d.config.AccessLog; path != "" && path != "stdout" && path != "stderr" {
+		checked = append(checked, "ACCESS_LOG")
+		if err := checkWritableDir(filepath.Dir(path)); err != nil {
+			errs = append(errs, fmt.Errorf("ACCESS_LOG: %w", err))
+		}
+	}
+	if path := d.config.IPFilterFile; path != "" {
+		checked = append(checked, "IP_FILTER_FILE")
+		if _, err := os.ReadFile(path); err != nil {
+			errs = append(errs, fmt.Errorf("IP_FILTER_FILE: %w", err))
+		}
+	}
+	if path := d.config.Neo4jDriver.CACertFile; path != "" {
+		checked = append(checked, "NEO4J_CA_CERT")
+		if _, err := os.ReadFile(path); err != nil {
+			errs = append(errs, fmt.Errorf("NEO4J_CA_CERT: %w", err))
+		}
+	}
+	if dir := d.config.FrontendDir; dir != "" {
+		checked = append(checked, "FRONTEND_DIR")
+		if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+			errs = append(errs, fmt.Errorf("FRONTEND_DIR: %w", err))
+		}
+	}
+
+	switch {
+	case len(errs) > 0:
+		return diagnostics.Fail(errors.Join(errs...))
+	case len(checked) == 0:
+		return diagnostics.Skip("no files configured")
+	}
+	return diagnostics.Pass("%s", strings.Join(checked, ", "))
+}
+
+// checkWritableDir reports whether a file can be created in dir
+func checkWritableDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// runDoctor checks the configuration and the services it names, printing
+// a report. It fails when a check does, so deployments can run it before
+// starting the server.
+func runDoctor(args []string) error {
+	flags := newFlagSet("doctor", "[-config FILE] [-json]")
+	configPath := configFlag(flags)
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usageError(flags)
+	}
+
+	var report diagnostics.Report
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		// Nothing else can be checked without a valid configuration
+		report = diagnostics.Run(context.Background(), []diagnostics.Check{{
+			Name: "config",
+			Run:  func(context.Context) (string, string) { return diagnostics.Fail(err) },
+		}}, doctorCheckTimeout)
+	} else {
+		d, release := connectDoctor(config)
+		report = d.run(context.Background())
+		release()
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else if err := diagnostics.WriteText(stdout, report); err != nil {
+		return err
+	}
+	if report.Failed() {
+		return errors.New("some checks failed")
+	}
+	return nil
+}
+
+// connectDoctor connects to the services config names, recording failures
+// for the checks to report. release closes the connections.
+func connectDoctor(config *Config) (*doctor, func()) {
+	d := &doctor{config: config}
+	var closers []func() error
+
+	d.neo4j, d.neo4jErr = database.NewNeo4jClientWithConfig(config.Neo4jURI, config.Neo4jUser, config.Neo4jPassword, config.Neo4jDriver)
+	if d.neo4jErr == nil {
+		closers = append(closers, d.neo4j.Close)
+	}
+	if config.KeycloakURL != "" && config.KeycloakRealm != "" {
+		d.keycloak = auth.NewKeycloakAuth(config.KeycloakURL, config.KeycloakRealm, config.KeycloakClientID, config.KeycloakClientSecret)
+		closers = append(closers, d.keycloak.Close)
+	}
+	if config.RedisURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), doctorCheckTimeout)
+		defer cancel()
+		d.redis, d.redisErr = cache.NewRedis(ctx, config.RedisURL, "payforward:")
+		if d.redisErr == nil {
+			closers = append(closers, d.redis.Close)
+		}
+	}
+
+	return d, func() {
+		for _, close := range closers {
+			close()
+		}
+	}
+}
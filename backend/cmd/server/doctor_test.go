@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/diagnostics"
+)
+
+func TestDoctorChecksWithoutServices(t *testing.T) {
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	config.AccessLog = filepath.Join(dir, "access.log")
+	config.IPFilterFile = filepath.Join(dir, "missing.txt")
+	config.TLS.CertFile, config.TLS.KeyFile = writeCertificate(t, dir)
+	config.TenantDatabases = map[string]string{"acme": "acme"}
+
+	d := &doctor{config: config, neo4jErr: context.DeadlineExceeded}
+	report := d.run(context.Background())
+
+	want := map[string]string{
+		"config":         diagnostics.StatusWarn, // the default JWT secret
+		"neo4j":          diagnostics.StatusFail,
+		"schema default": diagnostics.StatusSkip,
+		"schema acme":    diagnostics.StatusSkip,
+		"keycloak":       diagnostics.StatusSkip,
+		"jwks":           diagnostics.StatusSkip,
+		"redis":          diagnostics.StatusSkip,
+		"tls":            diagnostics.StatusWarn, // expires within the hour
+		"files":          diagnostics.StatusFail,
+	}
+	if len(report.Checks) != len(want) {
+		t.Errorf("ran %d checks, want %d", len(report.Checks), len(want))
+	}
+	for _, result := range report.Checks {
+		if result.Status != want[result.Name] {
+			t.Errorf("%s = %s (%s), want %s", result.Name, result.Status, result.Detail, want[result.Name])
+		}
+	}
+	if files := report.Checks[len(report.Checks)-1]; !strings.Contains(files.Detail, "IP_FILTER_FILE") || strings.Contains(files.Detail, "ACCESS_LOG") {
+		t.Errorf("files detail = %q, want only the missing IP filter file", files.Detail)
+	}
+	if !report.Failed() {
+		t.Error("expected the report to fail")
+	}
+}
+
+func TestRunDoctorReportsInvalidConfig(t *testing.T) {
+	out := captureStdout(t)
+	t.Setenv("PORT", "none")
+
+	err := run([]string{"doctor"})
+	if err == nil {
+		t.Fatal("expected doctor to fail")
+	}
+	if !strings.Contains(out.String(), "fail  config  invalid configuration:\n              PORT:") {
+		t.Errorf("unexpected report:\n%s", out)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// dotenvFile holds settings for local development, so contributors don't
+// have to export them in every shell
+const dotenvFile = ".env"
+
+// loadDotenv sets the variables of the dotenv file at path that aren't set
+// already, so the environment still takes precedence. It only does so in
+// development: when ENVIRONMENT, from the environment or else the file, is
+// unset or "development". A missing file is not an error.
+func loadDotenv(path string) error {
+	vars, err := readDotenv(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	environment, ok := os.LookupEnv("ENVIRONMENT")
+	if !ok {
+		environment = vars["ENVIRONMENT"]
+	}
+	if environment != "" && environment != "development" {
+		return nil
+	}
+
+	for key, value := range vars {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// readDotenv parses a dotenv file: KEY=VALUE lines, optionally prefixed with
+// export, with # comments and blank lines ignored. Values may be wrapped in
+// double quotes, which support Go escapes such as \n, or single quotes,
+// which are taken literally.
+func readDotenv(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		value, err := dotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %s: %w", path, n, key, err)
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return vars, nil
+}
+
+// dotenvValue unquotes a value. Unquoted values end at a " #" comment.
+func dotenvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		end := closingQuote(value)
+		if end < 0 {
+			return "", errors.New("unterminated double quote")
+		}
+		return strconv.Unquote(value[:end+1])
+	case strings.HasPrefix(value, "'"):
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", errors.New("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// closingQuote returns the index of the double quote closing the one value
+// starts with, skipping escaped quotes, or -1
+func closingQuote(value string) int {
+	for i := 1; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
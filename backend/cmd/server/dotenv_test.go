@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDotenv(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadDotenv(t *testing.T) {
+	path := writeDotenv(t, `
+# Local settings
+PORT=9000
+export NEO4J_USER = neo4j
+NEO4J_PASSWORD="se#cret \"quoted\"" # trailing comment
+JWT_SECRET='literal \n'
+LOG_LEVEL=debug # trailing comment
+EMPTY=
+`)
+	vars, err := readDotenv(path)
+	if err != nil {
+		t.Fatalf("readDotenv: %v", err)
+	}
+	want := map[string]string{
+		"PORT":           "9000",
+		"NEO4J_USER":     "neo4j",
+		"NEO4J_PASSWORD": `se#cret "quoted"`,
+		"JWT_SECRET":     `literal \n`,
+		"LOG_LEVEL":      "debug",
+		"EMPTY":          "",
+	}
+	if len(vars) != len(want) {
+		t.Errorf("got %d variables, want %d: %v", len(vars), len(want), vars)
+	}
+	for key, value := range want {
+		if vars[key] != value {
+			t.Errorf("%s = %q, want %q", key, vars[key], value)
+		}
+	}
+
+	for _, bad := range []string{"PORT 9000", `SECRET="open`, "=value"} {
+		_, err := readDotenv(writeDotenv(t, "\n"+bad+"\n"))
+		if err == nil || !strings.Contains(err.Error(), ":2:") {
+			t.Errorf("%s: expected an error on line 2, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	path := writeDotenv(t, "DOTENV_TEST_NEW=from-file\nDOTENV_TEST_SET=from-file\n")
+
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("DOTENV_TEST_SET", "from-env")
+	os.Unsetenv("DOTENV_TEST_NEW")
+	if err := loadDotenv(path); err != nil {
+		t.Fatalf("loadDotenv: %v", err)
+	}
+	if _, ok := os.LookupEnv("DOTENV_TEST_NEW"); ok {
+		t.Error("expected the file to be ignored outside development")
+	}
+
+	t.Setenv("ENVIRONMENT", "development")
+	t.Cleanup(func() { os.Unsetenv("DOTENV_TEST_NEW") })
+	if err := loadDotenv(path); err != nil {
+		t.Fatalf("loadDotenv: %v", err)
+	}
+	if got := os.Getenv("DOTENV_TEST_NEW"); got != "from-file" {
+		t.Errorf("DOTENV_TEST_NEW = %q, want from-file", got)
+	}
+	if got := os.Getenv("DOTENV_TEST_SET"); got != "from-env" {
+		t.Errorf("DOTENV_TEST_SET = %q, want the environment to win", got)
+	}
+
+	if err := loadDotenv(filepath.Join(t.TempDir(), ".env")); err != nil {
+		t.Errorf("expected a missing file to be ignored, got %v", err)
+	}
+}
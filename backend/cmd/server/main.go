@@ -1,179 +1,152 @@
+// Command server runs the PayForward API and the operational tasks that go
+// with it.
+//
+// Usage:
+//
+//	server [serve] [-config FILE]          run the HTTP server (the default)
+//	server migrate up | down [N] | status | drift
+//	                                       manage the schema migrations
+//	server seed [-seed N] [-users N] ...   load development fixtures
+//	server create-admin -email E -name N   create a Keycloak user with the
+//	                                       admin role and its local profile
+//	server export users|acts [-o FILE]     export users or acts as NDJSON
+//	server routes                          list the routes the server serves
+//	server doctor [-json]                  check the database, schema,
+//	                                       Keycloak and other dependencies
+//	server version                         print the version and build details
+//
+// Every command reads the same configuration as the server: environment
+// variables over the -config file (or CONFIG_FILE) over defaults. In
+// development, variables that aren't set are first read from a .env file in
+// the working directory. Commands that use the database take -tenant to work
+// on a tenant's database instead of the default one.
 package main
 
 import (
-	"context"
-	"log"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"os"
-	"os/signal"
-	"strconv"
+	"sort"
 	"strings"
-	"syscall"
-	"time"
 
-	"payforwardnow/internal/auth"
-	"payforwardnow/internal/database"
-	"payforwardnow/internal/handlers"
-	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/version"
 )
 
-func main() {
-	// Load configuration
-	config := LoadConfig()
+// command is a subcommand of the server binary
+type command struct {
+	summary string
+	run     func(args []string) error
+}
 
-	// Initialize Neo4j connection
-	neo4jClient, err := database.NewNeo4jClient(config.Neo4jURI, config.Neo4jUser, config.Neo4jPassword)
-	if err != nil {
-		log.Fatalf("Failed to connect to Neo4j: %v", err)
+var commands = map[string]command{
+	"serve":        {"run the HTTP server", runServe},
+	"migrate":      {"apply, revert or inspect schema migrations", runMigrate},
+	"seed":         {"load development fixtures", runSeed},
+	"create-admin": {"create an administrator account", runCreateAdmin},
+	"export":       {"export users or acts as newline-delimited JSON, or back up the graph", runExport},
+	"import":       {"restore a graph backup", runImport},
+	"routes":       {"list the HTTP routes", runRoutes},
+	"doctor":       {"check the configuration and the services it names", runDoctor},
+	"version":      {"print the version and build details", runVersion},
+}
+
+// stdout receives command output; tests replace it
+var stdout io.Writer = os.Stdout
+
+// errUsage is returned once a usage message has been printed
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	err := loadDotenv(dotenvFile)
+	if err == nil {
+		err = run(os.Args[1:])
 	}
-	defer neo4jClient.Close()
-
-	// Initialize Keycloak authentication (if configured)
-	var keycloakAuth *auth.KeycloakAuth
-	if config.KeycloakURL != "" && config.KeycloakRealm != "" {
-		keycloakAuth = auth.NewKeycloakAuth(
-			config.KeycloakURL,
-			config.KeycloakRealm,
-			config.KeycloakClientID,
-			config.KeycloakClientSecret,
-		)
-		_ = middleware.NewKeycloakAuthMiddleware(keycloakAuth)
-		log.Printf("Keycloak authentication enabled for realm: %s", config.KeycloakRealm)
-	} else {
-		log.Println("Keycloak authentication disabled, using JWT tokens")
+	if err != nil {
+		if !errors.Is(err, errUsage) && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
 	}
+}
 
-	// Initialize handlers
-	h := handlers.NewHandler(neo4jClient)
-
-	// Setup router
-	mux := http.NewServeMux()
-
-	// API routes
-	mux.HandleFunc("GET /api/health", h.HealthCheck)
-	mux.HandleFunc("GET /api/v1/users/{id}", h.GetUser)
-	mux.HandleFunc("POST /api/v1/users", h.CreateUser)
-	mux.HandleFunc("PUT /api/v1/users/{id}", h.UpdateUser)
-	mux.HandleFunc("DELETE /api/v1/users/{id}", h.DeleteUser)
-
-	// Auth routes
-	mux.HandleFunc("POST /api/v1/auth/register", h.Register)
-	mux.HandleFunc("POST /api/v1/auth/login", h.Login)
-	mux.HandleFunc("POST /api/v1/auth/logout", h.Logout)
-	mux.HandleFunc("POST /api/v1/auth/refresh", h.RefreshToken)
-
-	// Pay it forward routes
-	mux.HandleFunc("GET /api/v1/acts", h.GetActs)
-	mux.HandleFunc("POST /api/v1/acts", h.CreateAct)
-	mux.HandleFunc("GET /api/v1/acts/{id}", h.GetAct)
-	mux.HandleFunc("PUT /api/v1/acts/{id}", h.UpdateAct)
-	mux.HandleFunc("DELETE /api/v1/acts/{id}", h.DeleteAct)
-
-	// Chain routes
-	mux.HandleFunc("GET /api/v1/chains/{id}", h.GetChain)
-	mux.HandleFunc("GET /api/v1/users/{id}/chains", h.GetUserChains)
-
-	// Stats routes
-	mux.HandleFunc("GET /api/v1/stats/global", h.GetGlobalStats)
-	mux.HandleFunc("GET /api/v1/stats/user/{id}", h.GetUserStats)
-
-	// Testimonials routes
-	mux.HandleFunc("GET /api/v1/testimonials", h.GetTestimonials)
-	mux.HandleFunc("POST /api/v1/testimonials", h.CreateTestimonial)
-
-	// Apply middleware stack
-	handler := middleware.Chain(
-		mux,
-		middleware.Logger,
-		middleware.CORS(config.AllowedOrigins),
-		middleware.RateLimit(config.RateLimitPerMin),
-		middleware.Recovery,
-		middleware.SecurityHeaders,
-		middleware.RequestID,
-	)
-
-	// Create server
-	server := &http.Server{
-		Addr:         ":" + config.Port,
-		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+// run dispatches args to a command. Without one, or with only flags, the
+// server is started, as before there were subcommands.
+func run(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServe(args)
+	}
+	if args[0] == "help" {
+		printCommands(stdout)
+		return nil
 	}
 
-	// Start server in goroutine
-	go func() {
-		log.Printf("Server starting on port %s", config.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
-		}
-	}()
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+		printCommands(os.Stderr)
+		return errUsage
+	}
+	return cmd.run(args[1:])
+}
 
-	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down server...")
+func printCommands(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	fmt.Fprintln(w, "usage: server <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nrun server <command> -h for a command's flags")
+}
 
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+// newFlagSet creates the flags of a command, whose arguments are described
+// by usage
+func newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet("server "+name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: server %s %s\n", name, usage)
+		flags.PrintDefaults()
 	}
+	return flags
+}
 
-	log.Println("Server exited gracefully")
+// configFlag adds the -config flag to flags
+func configFlag(flags *flag.FlagSet) *string {
+	return flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file; environment variables override it")
 }
 
-// Config holds the application configuration
-type Config struct {
-	Port                 string
-	Neo4jURI             string
-	Neo4jUser            string
-	Neo4jPassword        string
-	JWTSecret            string
-	Environment          string
-	KeycloakURL          string
-	KeycloakRealm        string
-	KeycloakClientID     string
-	KeycloakClientSecret string
-	AllowedOrigins       []string
-	RateLimitPerMin      int
+// usageError prints the flags' usage and returns errUsage
+func usageError(flags *flag.FlagSet) error {
+	flags.Usage()
+	return errUsage
 }
 
-// LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
-	allowedOrigins := []string{"*"}
-	if origins := getEnv("ALLOWED_ORIGINS", ""); origins != "" {
-		allowedOrigins = strings.Split(origins, ",")
+// runVersion prints the build's version, commit and build time
+func runVersion(args []string) error {
+	flags := newFlagSet("version", "")
+	if err := flags.Parse(args); err != nil {
+		return err
 	}
-
-	rateLimitPerMin := 100
-	if limit := getEnv("RATE_LIMIT_PER_MIN", ""); limit != "" {
-		if val, err := strconv.Atoi(limit); err == nil {
-			rateLimitPerMin = val
-		}
+	if flags.NArg() > 0 {
+		return usageError(flags)
 	}
 
-	return &Config{
-		Port:                 getEnv("PORT", "8080"),
-		Neo4jURI:             getEnv("NEO4J_URI", "bolt://localhost:7687"),
-		Neo4jUser:            getEnv("NEO4J_USER", "neo4j"),
-		Neo4jPassword:        getEnv("NEO4J_PASSWORD", "password"),
-		JWTSecret:            getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-		Environment:          getEnv("ENVIRONMENT", "development"),
-		KeycloakURL:          getEnv("KEYCLOAK_URL", ""),
-		KeycloakRealm:        getEnv("KEYCLOAK_REALM", ""),
-		KeycloakClientID:     getEnv("KEYCLOAK_CLIENT_ID", ""),
-		KeycloakClientSecret: getEnv("KEYCLOAK_CLIENT_SECRET", ""),
-		AllowedOrigins:       allowedOrigins,
-		RateLimitPerMin:      rateLimitPerMin,
+	build := version.Get()
+	fmt.Fprintf(stdout, "payforward %s\n", build.Version)
+	if build.Commit != "" {
+		fmt.Fprintf(stdout, "commit     %s\n", build.Commit)
 	}
-}
-
-func getEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+	if build.BuildTime != "" {
+		fmt.Fprintf(stdout, "built      %s\n", build.BuildTime)
 	}
-	return fallback
+	fmt.Fprintf(stdout, "go         %s\n", build.GoVersion)
+	return nil
 }
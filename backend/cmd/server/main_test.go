@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/handlers"
+)
+
+func captureStdout(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := stdout
+	stdout = &buf
+	t.Cleanup(func() { stdout = prev })
+	return &buf
+}
+
+func TestRunHelpListsCommands(t *testing.T) {
+	out := captureStdout(t)
+	if err := run([]string{"help"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for name := range commands {
+		if !strings.Contains(out.String(), name) {
+			t.Errorf("help doesn't list %s:\n%s", name, out)
+		}
+	}
+}
+
+func TestRunRejectsBadUsage(t *testing.T) {
+	for _, args := range [][]string{
+		{"frobnicate"},
+		{"migrate"},
+		{"migrate", "sideways"},
+		{"migrate", "up", "now"},
+		{"export", "chains"},
+		{"export", "-format", "cypher", "users"},
+		{"import", "a.ndjson", "b.ndjson"},
+		{"create-admin", "-email", "a@example.com"},
+		{"routes", "extra"},
+	} {
+		if err := run(args); !errors.Is(err, errUsage) {
+			t.Errorf("run(%q) = %v, want errUsage", args, err)
+		}
+	}
+
+	if err := run([]string{"migrate", "down", "0"}); err == nil || errors.Is(err, errUsage) {
+		t.Errorf("expected a step count error, got %v", err)
+	}
+}
+
+func TestRunRoutes(t *testing.T) {
+	out := captureStdout(t)
+	if err := run([]string{"routes"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != len(apiRoutes(new(handlers.Handler))) {
+		t.Errorf("listed %d routes, want one per route", len(lines))
+	}
+	if fields := strings.Fields(lines[0]); len(fields) != 2 || fields[0] != "GET" || fields[1] != "/api/health" {
+		t.Errorf("first route = %q, want the public health check", lines[0])
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "/admin/") && !strings.HasSuffix(line, "admin") {
+			t.Errorf("admin route not marked: %q", line)
+		}
+	}
+}
+
+func TestRunVersion(t *testing.T) {
+	out := captureStdout(t)
+	if err := run([]string{"version"}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "payforward dev\n") || !strings.Contains(out.String(), "go         go1.") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRegisterRoutesWrapsAdminRoutes(t *testing.T) {
+	var wrapped int
+	adminOnly := func(next http.Handler) http.Handler {
+		wrapped++
+		return next
+	}
+
+	routes := apiRoutes(new(handlers.Handler))
+	registerRoutes(http.NewServeMux(), routes, adminOnly)
+
+	admin := 0
+	for _, r := range routes {
+		if r.admin {
+			admin++
+		}
+	}
+	if admin == 0 || wrapped != admin {
+		t.Errorf("wrapped %d routes, want the %d admin ones", wrapped, admin)
+	}
+}
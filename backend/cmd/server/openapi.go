@@ -0,0 +1,497 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"payforwardnow/internal/diagnostics"
+	"payforwardnow/internal/handlers"
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/migrations"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/openapi"
+	"payforwardnow/internal/version"
+)
+
+// apiDoc documents a route for the OpenAPI document. Responses are wrapped in
+// the API's {"success", "data", "error", "meta"} envelope unless raw is set.
+type apiDoc struct {
+	tag     string
+	summary string
+	query   []openapi.Parameter
+	// request is a value of the body's type, or nil when there is no body
+	request any
+	// response is a value of the type of a successful response's data
+	response any
+	// status of a successful response; 200 when zero
+	status int
+	// paged responses carry pagination in meta
+	paged bool
+	// raw is the content type of a response sent without the envelope
+	raw string
+	// formats are other content types a successful response may have,
+	// chosen with ?format= or the Accept header
+	formats []string
+}
+
+// Shapes of the data of responses built from maps in the handlers
+type (
+	messageData struct {
+		Message string `json:"message"`
+	}
+	versionedMessageData struct {
+		Message string `json:"message"`
+		Version int64  `json:"version"`
+	}
+	authData struct {
+		User   models.User       `json:"user"`
+		Tokens models.AuthTokens `json:"tokens"`
+	}
+	changedData struct {
+		Changed []string `json:"changed"`
+	}
+	featureFlagsData struct {
+		Flags     map[string]bool `json:"flags"`
+		Overrides map[string]bool `json:"overrides"`
+	}
+	memberRoleData struct {
+		UserID string         `json:"userId"`
+		Role   models.OrgRole `json:"role"`
+	}
+)
+
+var pageParams = []openapi.Parameter{
+	queryParam("page", "integer", "page number, from 1"),
+	queryParam("per_page", "integer", "items per page, at most 100"),
+	queryParam("sort_by", "string", "field to sort by"),
+	queryParam("order", "string", "asc or desc"),
+}
+
+var dateParams = []openapi.Parameter{
+	queryParam("from", "string", "first day, as YYYY-MM-DD"),
+	queryParam("to", "string", "last day, as YYYY-MM-DD"),
+}
+
+var qrParams = []openapi.Parameter{
+	queryParam("format", "string", "png or svg"),
+	queryParam("size", "integer", "width in pixels, 512 by default, from 64 to 2048"),
+	queryParam("ecc", "string", "error correction level: L, M (the default), Q or H"),
+}
+
+var apiTags = []openapi.Tag{
+	{Name: "system", Description: "Health, metrics, feature flags and this document"},
+	{Name: "auth", Description: "Registration and sessions"},
+	{Name: "users", Description: "User profiles"},
+	{Name: "acts", Description: "Acts of kindness"},
+	{Name: "pledges", Description: "Funds held for monetary acts until the receiver confirms them"},
+	{Name: "chains", Description: "Chains of acts passed forward"},
+	{Name: "stats", Description: "Statistics, leaderboards and reports"},
+	{Name: "testimonials", Description: "Stories of the impact of acts"},
+	{Name: "search", Description: "Search across acts, chains, users and testimonials"},
+	{Name: "notifications", Description: "Acts received, chains grown and testimonials approved"},
+	{Name: "campaigns", Description: "Drives with dates and goals that users join and tag acts to"},
+	{Name: "organizations", Description: "Schools, companies and other groups running pay-it-forward programs"},
+	{Name: "reports", Description: "Reports of content that breaks the rules"},
+	{Name: "admin", Description: "Operations that need the admin role"},
+}
+
+// apiDocs documents every route of apiRoutes, keyed by pattern
+var apiDocs = map[string]apiDoc{
+	"GET /api/health": {tag: "system", summary: "Check the health of the service and its database",
+		query: []openapi.Parameter{queryParam("verbose", "boolean", "include connection pool statistics")},
+		raw:   "application/json", response: map[string]any{}},
+	"GET /api/version":          {tag: "system", summary: "Describe the running build", response: version.Info{}},
+	"GET /metrics":              {tag: "system", summary: "Prometheus metrics", raw: "text/plain"},
+	"GET /api/v1/features":      {tag: "system", summary: "List the feature flags", response: map[string]bool{}},
+	"GET /api/openapi.json":     {tag: "system", summary: "This OpenAPI document", raw: "application/json", response: map[string]any{}},
+	"GET /docs":                 {tag: "system", summary: "Swagger UI for this document", raw: "text/html"},
+	"GET /api/v1/users/{id}":    {tag: "users", summary: "Get a user", response: models.User{}},
+	"POST /api/v1/users":        {tag: "users", summary: "Create a user", request: models.CreateUserRequest{}, response: models.User{}, status: http.StatusCreated},
+	"PUT /api/v1/users/{id}":    {tag: "users", summary: "Update a user", request: models.UpdateUserRequest{}, response: versionedMessageData{}},
+	"DELETE /api/v1/users/{id}": {tag: "users", summary: "Delete a user", response: messageData{}},
+
+	"POST /api/v1/auth/register": {tag: "auth", summary: "Register an account", request: models.RegisterRequest{}, response: authData{}, status: http.StatusCreated},
+	"POST /api/v1/auth/login":    {tag: "auth", summary: "Log in", request: models.LoginRequest{}, response: authData{}},
+	"POST /api/v1/auth/logout":   {tag: "auth", summary: "Log out, revoking the access token", response: messageData{}},
+	"POST /api/v1/auth/refresh":  {tag: "auth", summary: "Refresh the access token", request: models.RefreshRequest{}, response: models.AuthTokens{}},
+
+	"GET /api/v1/acts":      {tag: "acts", summary: "List acts", query: pageParams, response: []models.Act{}, paged: true},
+	"POST /api/v1/acts":     {tag: "acts", summary: "Create an act", request: models.CreateActRequest{}, response: models.Act{}, status: http.StatusCreated},
+	"GET /api/v1/acts/{id}": {tag: "acts", summary: "Get an act", response: models.Act{}},
+	"GET /api/v1/acts/{id}/similar": {tag: "acts", summary: "Find acts similar to an act",
+		query: []openapi.Parameter{queryParam("limit", "integer", "number of acts to return")}, response: []models.SimilarAct{}},
+	"GET /api/v1/acts/{id}/receipt": {tag: "acts", summary: "Get the donation receipt of a completed monetary act",
+		query: []openapi.Parameter{queryParam("format", "string", "json or pdf")}, response: models.DonationReceipt{}, formats: []string{"application/pdf"}},
+	"GET /api/v1/acts/{id}/qr": {tag: "acts", summary: "Get a QR code of an act's link",
+		query: qrParams, raw: "image/png", formats: []string{"image/svg+xml"}},
+	"GET /api/v1/acts/match": {tag: "acts", summary: "Find acts matching a description",
+		query:    []openapi.Parameter{queryParam("q", "string", "free-text description, such as a need"), queryParam("limit", "integer", "number of acts to return")},
+		response: []models.SimilarAct{}},
+	"GET /api/v1/acts/nearby": {tag: "acts", summary: "List the acts placed near a point, nearest first",
+		query: append([]openapi.Parameter{
+			queryParam("lat", "number", "latitude of the point"),
+			queryParam("lng", "number", "longitude of the point"),
+			queryParam("radius", "number", "search radius in kilometers, 25 by default and at most 500"),
+		}, pageParams...),
+		response: []models.NearbyAct{}, paged: true},
+	"PUT /api/v1/acts/{id}":    {tag: "acts", summary: "Update an act", request: models.UpdateActRequest{}, response: versionedMessageData{}},
+	"DELETE /api/v1/acts/{id}": {tag: "acts", summary: "Delete an act", response: messageData{}},
+
+	"POST /api/v1/acts/{id}/pledge": {tag: "pledges", summary: "Pledge the value of a monetary act, held until the receiver confirms it",
+		request: models.CreatePledgeRequest{}, response: models.Pledge{}, status: http.StatusCreated},
+	"GET /api/v1/acts/{id}/pledge":          {tag: "pledges", summary: "Get the pledge of an act", response: models.Pledge{}},
+	"POST /api/v1/acts/{id}/pledge/confirm": {tag: "pledges", summary: "Confirm an act, releasing its pledge to the receiver", response: models.Pledge{}},
+	"POST /api/v1/acts/{id}/pledge/cancel":  {tag: "pledges", summary: "Cancel a held pledge, refunding the giver and cancelling the act", response: models.Pledge{}},
+	"POST /api/v1/acts/{id}/pledge/dispute": {tag: "pledges", summary: "Dispute a held pledge, holding it until an admin resolves it",
+		request: models.DisputePledgeRequest{}, response: models.Pledge{}},
+
+	"GET /api/v1/chains/{id}":       {tag: "chains", summary: "Get a chain and its acts", response: models.Chain{}},
+	"GET /api/v1/users/{id}/chains": {tag: "chains", summary: "List the chains a user started", response: []models.Chain{}},
+	"GET /api/v1/chains/{id}/qr": {tag: "chains", summary: "Get a QR code of a chain's link, for printing on pay-it-forward cards",
+		query: qrParams, raw: "image/png", formats: []string{"image/svg+xml"}},
+	"GET /api/v1/users/{id}/impact-report": {tag: "stats", summary: "Get a user's yearly impact report",
+		query:    []openapi.Parameter{queryParam("year", "integer", "year of the report; the current year when empty"), queryParam("format", "string", "json or pdf")},
+		response: models.ImpactReport{}, formats: []string{"application/pdf"}},
+
+	"GET /api/v1/stats/global": {tag: "stats", summary: "Get global statistics", response: models.GlobalStats{}},
+	"GET /api/v1/rates":        {tag: "stats", summary: "Get the exchange rates act values are converted to the base currency with", response: models.ExchangeRates{}},
+	"GET /api/v1/stats/user/{id}": {tag: "stats", summary: "Get a user's statistics",
+		query: []openapi.Parameter{queryParam("format", "string", "json or csv")}, response: models.UserStats{}, formats: []string{"text/csv"}},
+	"GET /api/v1/stats/categories": {tag: "stats", summary: "Get statistics by category or type",
+		query: []openapi.Parameter{queryParam("groupBy", "string", "category or type")}, response: []models.CategoryStats{}},
+	"GET /api/v1/stats/stream": {tag: "stats", summary: "Stream live statistics as server-sent events", raw: "text/event-stream"},
+	"GET /api/v1/ws": {tag: "chains", summary: "Receive new acts, reactions and chain milestones over a WebSocket",
+		query:  []openapi.Parameter{queryParam("topics", "string", "comma-separated chain:<id> and user:<id> topics to subscribe to")},
+		status: http.StatusSwitchingProtocols, raw: "application/json", response: models.LiveMessage{}},
+	"GET /api/v1/events": {tag: "acts", summary: "Stream acts as they are completed as server-sent events",
+		query: []openapi.Parameter{queryParam("lastEventId", "string", "resume after this event, for clients that can't send Last-Event-ID")},
+		raw:   "text/event-stream"},
+	"GET /api/v1/stats/top": {tag: "stats", summary: "Get the leaderboard",
+		query: []openapi.Parameter{
+			queryParam("role", "string", "giver or receiver"),
+			queryParam("period", "string", "week, month, year or all"),
+			queryParam("limit", "integer", "number of users to return"),
+		},
+		response: []models.LeaderboardEntry{}},
+	"GET /api/v1/stats/widget": {tag: "stats", summary: "Get an embeddable badge of the global statistics",
+		query: []openapi.Parameter{queryParam("format", "string", "svg or json")}, raw: "image/svg+xml", formats: []string{"application/json"}},
+	"GET /api/v1/orgs/{id}/stats": {tag: "stats", summary: "Get an organization's statistics",
+		query: append([]openapi.Parameter{queryParam("format", "string", "json or csv")}, dateParams...), response: models.OrgStats{}, formats: []string{"text/csv"}},
+
+	"GET /api/v1/testimonials": {tag: "testimonials", summary: "List testimonials",
+		query: append([]openapi.Parameter{
+			queryParam("userId", "string", "only testimonials by this user"),
+			queryParam("featured", "boolean", "only featured or only other testimonials"),
+			queryParam("status", "string", "approved, pending or all; admins only for the last two"),
+			queryParam("locale", "string", "language to translate stories to"),
+		}, pageParams...),
+		response: []models.Testimonial{}, paged: true},
+	"POST /api/v1/testimonials": {tag: "testimonials", summary: "Submit a testimonial", request: models.CreateTestimonialRequest{}, response: models.Testimonial{}, status: http.StatusCreated},
+	"GET /api/v1/testimonials/featured": {tag: "testimonials", summary: "List featured testimonials",
+		query:    []openapi.Parameter{queryParam("limit", "integer", "number of testimonials to return"), queryParam("locale", "string", "language to translate stories to")},
+		response: []models.Testimonial{}},
+	"PUT /api/v1/testimonials/{id}":            {tag: "testimonials", summary: "Update a testimonial", request: models.UpdateTestimonialRequest{}, response: models.Testimonial{}},
+	"DELETE /api/v1/testimonials/{id}":         {tag: "testimonials", summary: "Delete a testimonial", response: messageData{}},
+	"POST /api/v1/testimonials/{id}/reactions": {tag: "testimonials", summary: "Toggle the caller's reaction to a testimonial", response: models.ReactionResult{}},
+
+	"GET /api/v1/search": {tag: "search", summary: "Search acts, chains, users and approved testimonials, best match first",
+		query: append([]openapi.Parameter{
+			queryParam("q", "string", "text to search, at most 200 characters"),
+			queryParam("kinds", "string", "comma separated kinds to search among act, chain, user and testimonial; all by default"),
+		}, pageParams...),
+		response: []models.SearchHit{}, paged: true},
+	"GET /api/v1/recommendations": {tag: "users", summary: "Suggest acts to join, chains to follow and needs to fulfill to the caller",
+		query: []openapi.Parameter{queryParam("limit", "integer", "number of suggestions of each kind")}, response: models.Recommendations{}},
+
+	"GET /api/v1/campaigns": {tag: "campaigns", summary: "List campaigns with their progress, latest starting first",
+		query:    append([]openapi.Parameter{queryParam("status", "string", "upcoming, active or ended")}, pageParams...),
+		response: []models.Campaign{}, paged: true},
+	"GET /api/v1/campaigns/{id}":         {tag: "campaigns", summary: "Get a campaign and its progress towards its goals", response: models.Campaign{}},
+	"POST /api/v1/campaigns/{id}/join":   {tag: "campaigns", summary: "Join a campaign", response: messageData{}},
+	"DELETE /api/v1/campaigns/{id}/join": {tag: "campaigns", summary: "Leave a campaign", response: messageData{}},
+	"GET /api/v1/campaigns/{id}/leaderboard": {tag: "campaigns", summary: "Rank a campaign's participants by the acts they tagged to it",
+		query:    []openapi.Parameter{queryParam("limit", "integer", "number of users to return")},
+		response: []models.LeaderboardEntry{}},
+
+	"POST /api/v1/orgs": {tag: "organizations", summary: "Create an organization owned by the caller",
+		request: models.CreateOrganizationRequest{}, response: models.Organization{}, status: http.StatusCreated},
+	"GET /api/v1/orgs/{id}":       {tag: "organizations", summary: "Get an organization", response: models.Organization{}},
+	"GET /api/v1/users/{id}/orgs": {tag: "organizations", summary: "List the organizations a user is a member of, with their role", response: []models.Organization{}},
+	"GET /api/v1/orgs/{id}/members": {tag: "organizations", summary: "List an organization's members; members only",
+		query: pageParams, response: []models.OrgMember{}, paged: true},
+	"PUT /api/v1/orgs/{id}/members/{userId}": {tag: "organizations", summary: "Change a member's role; owners and admins only",
+		request: models.UpdateMemberRequest{}, response: memberRoleData{}},
+	"DELETE /api/v1/orgs/{id}/members/{userId}": {tag: "organizations", summary: "Leave an organization or remove a member", response: messageData{}},
+	"POST /api/v1/orgs/{id}/invitations": {tag: "organizations", summary: "Invite someone to an organization by email; owners and admins only",
+		request: models.InviteMemberRequest{}, response: models.OrgInvitation{}, status: http.StatusCreated},
+	"GET /api/v1/orgs/{id}/invitations":                   {tag: "organizations", summary: "List an organization's pending invitations; owners and admins only", response: []models.OrgInvitation{}},
+	"DELETE /api/v1/orgs/{id}/invitations/{invitationId}": {tag: "organizations", summary: "Revoke a pending invitation; owners and admins only", response: messageData{}},
+	"POST /api/v1/invitations/accept": {tag: "organizations", summary: "Accept an invitation sent to the caller's email address",
+		request: models.AcceptInvitationRequest{}, response: models.OrgInvitation{}},
+	"GET /api/v1/orgs/{id}/acts": {tag: "organizations", summary: "List the acts given for an organization, newest first",
+		query: pageParams, response: []models.Act{}, paged: true},
+	"GET /api/v1/orgs/{id}/chains":    {tag: "organizations", summary: "List the chains containing acts given for an organization", response: []models.Chain{}},
+	"GET /api/v1/orgs/{id}/dashboard": {tag: "organizations", summary: "Sum up an organization's program; members only", response: models.OrgDashboard{}},
+
+	"POST /api/v1/reports": {tag: "reports", summary: "Report an act, testimonial or user that breaks the rules",
+		request: models.CreateReportRequest{}, response: models.ContentReport{}, status: http.StatusCreated},
+
+	"GET /api/v1/notifications": {tag: "notifications", summary: "List the caller's notifications",
+		query:    append([]openapi.Parameter{queryParam("unread", "boolean", "only unread notifications")}, pageParams...),
+		response: []models.Notification{}, paged: true},
+	"GET /api/v1/notifications/unread-count": {tag: "notifications", summary: "Count the caller's unread notifications", response: models.UnreadNotifications{}},
+	"POST /api/v1/notifications/read":        {tag: "notifications", summary: "Mark the listed notifications, or all of them, read", request: models.MarkNotificationsReadRequest{}, response: models.MarkNotificationsReadResult{}},
+	"POST /api/v1/notifications/{id}/read":   {tag: "notifications", summary: "Mark a notification read", response: models.MarkNotificationsReadResult{}},
+
+	"GET /api/v1/admin/stats/retention": {tag: "admin", summary: "Get weekly cohort retention",
+		query:    []openapi.Parameter{queryParam("weeks", "integer", "number of weekly cohorts"), queryParam("format", "string", "json or csv")},
+		response: models.RetentionReport{}, formats: []string{"text/csv"}},
+	"GET /api/v1/admin/maintenance":    {tag: "admin", summary: "Get the maintenance mode", response: models.MaintenanceStatus{}},
+	"PUT /api/v1/admin/maintenance":    {tag: "admin", summary: "Turn maintenance mode on or off", request: models.SetMaintenanceRequest{}, response: models.MaintenanceStatus{}},
+	"POST /api/v1/admin/config/reload": {tag: "admin", summary: "Reload the configuration", response: changedData{}},
+	"GET /api/v1/admin/features":       {tag: "admin", summary: "List the feature flags and this instance's overrides", response: featureFlagsData{}},
+	"PUT /api/v1/admin/features/{name}": {tag: "admin", summary: "Override a feature flag on this instance",
+		request: models.SetFeatureRequest{}, response: map[string]bool{}},
+	"DELETE /api/v1/admin/features/{name}": {tag: "admin", summary: "Return a feature flag to its configured setting", response: map[string]bool{}},
+	"GET /api/v1/admin/audit": {tag: "admin", summary: "List audit events",
+		query: append([]openapi.Parameter{
+			queryParam("userId", "string", "only events by this user"),
+			queryParam("entityId", "string", "only events on this entity"),
+		}, append(dateParams, pageParams...)...),
+		response: []models.AuditEvent{}, paged: true},
+	"GET /api/v1/admin/schema/drift":  {tag: "admin", summary: "Compare the database schema with the migrations", response: migrations.Drift{}},
+	"GET /api/v1/admin/doctor":        {tag: "admin", summary: "Check the database, schema, Keycloak and other dependencies", response: diagnostics.Report{}},
+	"POST /api/v1/admin/import/users": {tag: "admin", summary: "Import users", request: models.ImportUsersRequest{}, response: models.ImportResult{}},
+	"POST /api/v1/admin/import/acts":  {tag: "admin", summary: "Import acts", request: models.ImportActsRequest{}, response: models.ImportResult{}},
+	"GET /api/v1/admin/export/users": {tag: "admin", summary: "Export users as newline-delimited JSON or CSV",
+		query: []openapi.Parameter{queryParam("format", "string", "ndjson or csv")}, raw: "application/x-ndjson", formats: []string{"text/csv"}},
+	"GET /api/v1/admin/export/acts": {tag: "admin", summary: "Export acts as newline-delimited JSON or CSV",
+		query: []openapi.Parameter{queryParam("format", "string", "ndjson or csv")}, raw: "application/x-ndjson", formats: []string{"text/csv"}},
+	"POST /api/v1/admin/exports": {tag: "admin", summary: "Generate an export of users or acts in the background",
+		request: models.CreateExportRequest{}, response: models.Job{}, status: http.StatusAccepted},
+	"GET /api/v1/admin/exports/{id}": {tag: "admin", summary: "Download a generated export, or 202 while it is being generated",
+		raw: "application/x-ndjson", formats: []string{"text/csv"}},
+	"GET /api/v1/admin/users": {tag: "admin", summary: "List users, newest first",
+		query: append([]openapi.Parameter{
+			queryParam("q", "string", "only users whose name or email contains this"),
+			queryParam("suspended", "boolean", "only suspended users, or only others"),
+		}, pageParams...),
+		response: []models.User{}, paged: true},
+	"PUT /api/v1/admin/users/{id}/suspension": {tag: "admin", summary: "Suspend or reinstate a user",
+		request: models.SuspendUserRequest{}, response: models.User{}},
+	"DELETE /api/v1/admin/users/{id}/personal-data": {tag: "admin", summary: "Erase a user's personal data", response: models.ErasureReport{}},
+	"POST /api/v1/admin/{kind}/{id}/restore":        {tag: "admin", summary: "Restore a deleted user, act or testimonial", response: messageData{}},
+	"GET /api/v1/admin/email/suppressions": {tag: "admin", summary: "List the addresses no email is sent to",
+		query: pageParams, response: []models.EmailSuppression{}, paged: true},
+	"PUT /api/v1/admin/email/suppressions/{email}":    {tag: "admin", summary: "Stop emailing an address", request: models.SuppressEmailRequest{}, response: messageData{}},
+	"DELETE /api/v1/admin/email/suppressions/{email}": {tag: "admin", summary: "Allow emailing an address again", response: messageData{}},
+	"GET /api/v1/admin/webhooks":                      {tag: "admin", summary: "List webhook subscriptions", response: []models.Webhook{}},
+	"POST /api/v1/admin/webhooks":                     {tag: "admin", summary: "Subscribe a URL to events, returning its signing secret", request: models.CreateWebhookRequest{}, response: models.Webhook{}, status: http.StatusCreated},
+	"DELETE /api/v1/admin/webhooks/{id}":              {tag: "admin", summary: "Delete a webhook subscription and its delivery log", response: messageData{}},
+	"GET /api/v1/admin/webhooks/{id}/deliveries": {tag: "admin", summary: "List a webhook's delivery attempts",
+		query: pageParams, response: []models.WebhookDelivery{}, paged: true},
+	"GET /api/v1/admin/jobs": {tag: "admin", summary: "List background jobs, most recently updated first",
+		query: append([]openapi.Parameter{
+			queryParam("status", "string", "queued, running, succeeded or failed"),
+			queryParam("type", "string", "only jobs of this type"),
+		}, pageParams...),
+		response: []models.Job{}, paged: true},
+	"GET /api/v1/admin/jobs/schedules":   {tag: "admin", summary: "List the scheduled jobs with their next occurrence", response: []models.JobSchedule{}},
+	"GET /api/v1/admin/jobs/{id}":        {tag: "admin", summary: "Get a background job", response: models.Job{}},
+	"POST /api/v1/admin/jobs/{id}/retry": {tag: "admin", summary: "Queue a failed job to run again", response: models.Job{}},
+	"GET /api/v1/admin/pledges": {tag: "admin", summary: "List pledges, most recently updated first",
+		query:    append([]openapi.Parameter{queryParam("status", "string", "held, released, refunded or disputed")}, pageParams...),
+		response: []models.Pledge{}, paged: true},
+	"POST /api/v1/admin/pledges/{id}/resolve": {tag: "admin", summary: "Resolve a disputed pledge by releasing or refunding it",
+		request: models.ResolvePledgeRequest{}, response: models.Pledge{}},
+	"POST /api/v1/admin/rates/refresh": {tag: "admin", summary: "Refresh the exchange rates in the background",
+		response: models.Job{}, status: http.StatusAccepted},
+	"POST /api/v1/admin/search/reindex": {tag: "admin", summary: "Rebuild the external search index in the background",
+		response: models.Job{}, status: http.StatusAccepted},
+	"GET /api/v1/admin/acts/flagged": {tag: "admin", summary: "List the acts screening flagged, highest score first",
+		query: pageParams, response: []models.FlaggedAct{}, paged: true},
+	"DELETE /api/v1/admin/acts/{id}/flag": {tag: "admin", summary: "Dismiss the flag of an act found acceptable", response: messageData{}},
+	"POST /api/v1/admin/acts/{id}/takedown": {tag: "admin", summary: "Take down an act that breaks the rules",
+		request: models.TakeDownActRequest{}, response: messageData{}},
+	"POST /api/v1/admin/campaigns": {tag: "admin", summary: "Create a campaign",
+		request: models.CreateCampaignRequest{}, response: models.Campaign{}, status: http.StatusCreated},
+	"GET /api/v1/admin/reports": {tag: "admin", summary: "List content reports, oldest first",
+		query:    append([]openapi.Parameter{queryParam("status", "string", "open, resolved or dismissed")}, pageParams...),
+		response: []models.ContentReport{}, paged: true},
+	"PUT /api/v1/admin/reports/{id}": {tag: "admin", summary: "Resolve or dismiss a content report",
+		request: models.HandleReportRequest{}, response: models.ContentReport{}},
+	"GET /api/v1/admin/testimonials": {tag: "admin", summary: "List the moderation queue",
+		query: append([]openapi.Parameter{
+			queryParam("status", "string", "moderation status to list"),
+			queryParam("flagged", "boolean", "only testimonials screening flagged, or only others"),
+			queryParam("reviewerId", "string", "only testimonials assigned to this reviewer"),
+		}, pageParams...),
+		response: []models.Testimonial{}, paged: true},
+	"PUT /api/v1/admin/testimonials/{id}/featured":                 {tag: "admin", summary: "Feature or unfeature a testimonial", request: models.FeatureTestimonialRequest{}, response: models.Testimonial{}},
+	"PUT /api/v1/admin/testimonials/{id}/reviewer":                 {tag: "admin", summary: "Assign a testimonial to a reviewer", request: models.AssignReviewerRequest{}, response: messageData{}},
+	"PUT /api/v1/admin/testimonials/{id}/review":                   {tag: "admin", summary: "Approve or reject a testimonial", request: models.ReviewTestimonialRequest{}, response: models.Testimonial{}},
+	"POST /api/v1/admin/testimonials/{id}/notes":                   {tag: "admin", summary: "Add a moderation note", request: models.AddModerationNoteRequest{}, response: models.ModerationNote{}, status: http.StatusCreated},
+	"PUT /api/v1/admin/testimonials/{id}/translations/{locale}":    {tag: "admin", summary: "Translate a testimonial", request: models.TranslateTestimonialRequest{}, response: messageData{}},
+	"DELETE /api/v1/admin/testimonials/{id}/translations/{locale}": {tag: "admin", summary: "Delete a testimonial's translation", response: messageData{}},
+}
+
+func queryParam(name, typ, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: &openapi.Schema{Type: typ}}
+}
+
+// buildOpenAPI documents routes from apiDocs. Routes without an entry are
+// left out; a test makes sure there are none.
+func buildOpenAPI(routes []route) (*openapi.Document, error) {
+	doc := openapi.New(openapi.Info{
+		Title:       "PayForward API",
+		Version:     "1.0.0",
+		Description: "Track acts of kindness and the chains they start.",
+	})
+	doc.Tags = apiTags
+	doc.Components.SecuritySchemes["bearerAuth"] = openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+	doc.Components.Schemas["ErrorResponse"] = &openapi.Schema{
+		Type:     "object",
+		Required: []string{"success", "error"},
+		Properties: map[string]*openapi.Schema{
+			"success": {Type: "boolean"},
+			"error":   doc.Schema(models.APIError{}),
+		},
+	}
+	errorResponse := &openapi.Response{
+		Description: "Error",
+		Content:     openapi.JSON(&openapi.Schema{Ref: "#/components/schemas/ErrorResponse"}),
+	}
+
+	for _, r := range routes {
+		d, ok := apiDocs[r.pattern]
+		if !ok {
+			continue
+		}
+		op := &openapi.Operation{
+			Tags:       []string{d.tag},
+			Summary:    d.summary,
+			Parameters: append([]openapi.Parameter(nil), d.query...),
+			Responses:  map[string]*openapi.Response{"default": errorResponse},
+		}
+		if d.request != nil {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(doc.Schema(d.request))}
+		}
+		if r.admin {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+		}
+
+		status := d.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := &openapi.Response{Description: http.StatusText(status)}
+		if d.raw != "" {
+			schema := &openapi.Schema{Type: "string"}
+			if d.response != nil {
+				schema = doc.Schema(d.response)
+			}
+			success.Content = map[string]openapi.MediaType{d.raw: {Schema: schema}}
+		} else {
+			success.Content = openapi.JSON(envelope(doc, d.response, d.paged))
+		}
+		for _, format := range d.formats {
+			if _, ok := success.Content[format]; !ok {
+				success.Content[format] = openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = success
+
+		if err := doc.Add(r.pattern, op); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// envelope returns the schema of a successful response carrying data
+func envelope(doc *openapi.Document, data any, paged bool) *openapi.Schema {
+	s := &openapi.Schema{
+		Type:       "object",
+		Required:   []string{"success"},
+		Properties: map[string]*openapi.Schema{"success": {Type: "boolean"}},
+	}
+	if data != nil {
+		s.Properties["data"] = doc.Schema(data)
+	}
+	if paged {
+		s.Properties["meta"] = doc.Schema(models.APIMeta{})
+	}
+	return s
+}
+
+// openAPIHandler serves the OpenAPI document of the server's routes. It is
+// built on first use, since the route table includes this handler.
+func openAPIHandler() http.Handler {
+	var once sync.Once
+	var body []byte
+	var err error
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var doc *openapi.Document
+			if doc, err = buildOpenAPI(apiRoutes(new(handlers.Handler))); err == nil {
+				body, err = json.Marshal(doc)
+			}
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to build the OpenAPI document: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	})
+}
+
+// swaggerUIScript starts Swagger UI on the OpenAPI document
+const swaggerUIScript = `window.onload = () => {
+  window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+};`
+
+// swaggerUIVersion is the swagger-ui-dist release loaded from unpkg
+const swaggerUIVersion = "5.17.14"
+
+var swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>PayForward API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@` + swaggerUIVersion + `/swagger-ui-bundle.js" crossorigin></script>
+  <script>` + swaggerUIScript + `</script>
+</body>
+</html>
+`
+
+// swaggerUICSP relaxes the API's policy just enough for the page: Swagger UI
+// comes from unpkg, and only the inline script above may run
+var swaggerUICSP = func() string {
+	sum := sha256.Sum256([]byte(swaggerUIScript))
+	return middleware.CSP{}.
+		Set("default-src", "'self'").
+		Set("script-src", "https://unpkg.com", "'sha256-"+base64.StdEncoding.EncodeToString(sum[:])+"'").
+		Set("style-src", "https://unpkg.com").
+		Set("img-src", "'self'", "data:").
+		Set("frame-ancestors", "'none'").
+		String()
+}()
+
+// swaggerUIHandler serves Swagger UI for the OpenAPI document
+func swaggerUIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Security-Policy", swaggerUICSP)
+		fmt.Fprint(w, swaggerUIPage)
+	})
+}
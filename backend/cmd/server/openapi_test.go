@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/handlers"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	routes := apiRoutes(new(handlers.Handler))
+	doc, err := buildOpenAPI(routes)
+	if err != nil {
+		t.Fatalf("buildOpenAPI: %v", err)
+	}
+
+	served := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		served[r.pattern] = true
+		op := doc.Operation(r.pattern)
+		if op == nil || op.Summary == "" {
+			t.Errorf("%s is not documented; add it to apiDocs", r.pattern)
+			continue
+		}
+		if r.admin && len(op.Security) == 0 {
+			t.Errorf("%s is an admin route but documents no security", r.pattern)
+		}
+	}
+	for pattern := range apiDocs {
+		if !served[pattern] {
+			t.Errorf("apiDocs documents %s, which is not a route", pattern)
+		}
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	openAPIHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	if _, ok := doc.Paths["/api/v1/acts/{id}"]["get"]; !ok {
+		t.Error("expected GET /api/v1/acts/{id}")
+	}
+	for _, name := range []string{"Act", "User", "CreateActRequest", "ErrorResponse"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("expected the %s schema", name)
+		}
+	}
+}
+
+func TestSwaggerUIHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	swaggerUIHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if !strings.Contains(rec.Body.String(), "/api/openapi.json") {
+		t.Error("expected the page to load the OpenAPI document")
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "'sha256-") || strings.Contains(csp, "unsafe-inline") {
+		t.Errorf("Content-Security-Policy = %q, want only the page's own inline script allowed", csp)
+	}
+}
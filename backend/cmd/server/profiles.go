@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// profile holds the defaults ENVIRONMENT selects. Each can still be
+// overridden by its own setting.
+type profile struct {
+	// logFormat is the default LOG_FORMAT
+	logFormat string
+	// allowedOrigins is the default ALLOWED_ORIGINS
+	allowedOrigins []string
+	// hsts is the default HSTS_ENABLED
+	hsts bool
+	// autoMigrate is the default AUTO_MIGRATE
+	autoMigrate bool
+	// debugErrors is the default DEBUG_ERRORS
+	debugErrors bool
+	// rateLimitScale multiplies the default of every rate limit
+	rateLimitScale int
+}
+
+// profiles are the environments the server knows. Development and test
+// allow any origin, return panics with their stack trace and raise rate
+// limits tenfold, so reloading the frontend or running the end-to-end
+// suite doesn't hit them. Staging behaves like production except that it
+// still applies migrations at startup and allows any origin, for preview
+// deployments. Production logs JSON, sends HSTS and allows no cross-origin
+// callers until ALLOWED_ORIGINS lists them.
+var profiles = map[string]profile{
+	"development": {logFormat: "text", allowedOrigins: []string{"*"}, autoMigrate: true, debugErrors: true, rateLimitScale: 10},
+	"test":        {logFormat: "text", allowedOrigins: []string{"*"}, autoMigrate: true, debugErrors: true, rateLimitScale: 10},
+	"staging":     {logFormat: "json", allowedOrigins: []string{"*"}, hsts: true, autoMigrate: true, rateLimitScale: 1},
+	"production":  {logFormat: "json", hsts: true, rateLimitScale: 1},
+}
+
+// environmentProfile returns the profile of environment, or the
+// development one when it is unknown, which validate reports
+func environmentProfile(environment string) profile {
+	if p, ok := profiles[environment]; ok {
+		return p
+	}
+	return profiles["development"]
+}
+
+// environmentNames lists the known environments for error messages
+func environmentNames() string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
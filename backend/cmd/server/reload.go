@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"payforwardnow/internal/features"
+	"payforwardnow/internal/logging"
+	"payforwardnow/internal/middleware"
+)
+
+// reloader applies the settings that can change without a restart: rate
+// limits, CORS origins, feature flags and the log level. The process's
+// environment can't change from outside, so in practice a reload picks up
+// edits to the config file. Other settings still need a restart.
+type reloader struct {
+	mu      sync.Mutex
+	path    string
+	current *Config
+
+	logLevel   *slog.LevelVar
+	rateLimits *middleware.RateLimits
+	origins    *middleware.OriginMatcher
+	features   *features.Flags
+}
+
+// reload loads the configuration again and applies it, returning the names
+// of the settings that changed. An invalid configuration changes nothing.
+func (r *reloader) reload(ctx context.Context) ([]string, error) {
+	config, err := LoadConfig(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.current
+
+	// Validate every setting before applying any, so a rejected reload
+	// leaves the running settings untouched
+	var origins *middleware.OriginMatcher
+	if !slices.Equal(config.AllowedOrigins, old.AllowedOrigins) {
+		if origins, err = middleware.NewOriginMatcher(config.AllowedOrigins); err != nil {
+			return nil, err
+		}
+	}
+	levelChanged := !strings.EqualFold(config.LogLevel, old.LogLevel)
+	level, err := logging.ParseLevel(config.LogLevel)
+	if levelChanged && err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	if origins != nil {
+		r.origins.Replace(origins)
+		changed = append(changed, "ALLOWED_ORIGINS")
+	}
+	if levelChanged {
+		r.logLevel.Set(level)
+		changed = append(changed, "LOG_LEVEL")
+	}
+	if rateLimitsChanged(old, config) {
+		r.rateLimits.SetLimits(rateLimitConfig(config))
+		changed = append(changed, "RATE_LIMIT_*")
+	}
+	if !maps.Equal(config.Features, old.Features) {
+		r.features.Set(config.Features)
+		changed = append(changed, "FEATURE_FLAGS")
+	}
+
+	// Keep the settings that weren't applied, so they are reported as
+	// changed again by the next reload rather than forgotten
+	applied := *old
+	applied.AllowedOrigins = config.AllowedOrigins
+	applied.LogLevel = config.LogLevel
+	applied.RateLimitPerMin, applied.RateLimitUserPerMin, applied.RateClasses = config.RateLimitPerMin, config.RateLimitUserPerMin, config.RateClasses
+	applied.Features = config.Features
+	r.current = &applied
+
+	slog.InfoContext(ctx, "Configuration reloaded", "changed", changed)
+	return changed, nil
+}
+
+// watchSignals reloads on every SIGHUP until ctx is done
+func (r *reloader) watchSignals(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if _, err := r.reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "Configuration reload rejected; keeping the current settings", "error", err)
+			}
+		}
+	}
+}
+
+// rateLimitConfig returns the limits of config, without a store
+func rateLimitConfig(config *Config) middleware.RateLimitConfig {
+	return middleware.RateLimitConfig{
+		PerMinute:     config.RateLimitPerMin,
+		UserPerMinute: config.RateLimitUserPerMin,
+		Classes:       config.RateClasses,
+	}
+}
+
+func rateLimitsChanged(a, b *Config) bool {
+	if a.RateLimitPerMin != b.RateLimitPerMin || a.RateLimitUserPerMin != b.RateLimitUserPerMin {
+		return true
+	}
+	return !slices.EqualFunc(a.RateClasses, b.RateClasses, func(x, y middleware.RateClass) bool {
+		return x.Name == y.Name && x.PerMinute == y.PerMinute && x.UserPerMinute == y.UserPerMinute
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"testing"
+
+	"payforwardnow/internal/features"
+	"payforwardnow/internal/middleware"
+)
+
+func TestReloaderAppliesReloadableSettings(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "allowed_origins: https://a.example\nlog_level: info\n")
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	origins, _ := middleware.NewOriginMatcher(config.AllowedOrigins)
+	r := &reloader{
+		path:       path,
+		current:    config,
+		logLevel:   new(slog.LevelVar),
+		rateLimits: middleware.NewRateLimits(rateLimitConfig(config)),
+		origins:    origins,
+		features:   features.New(config.Features),
+	}
+	ctx := context.Background()
+
+	if changed, err := r.reload(ctx); err != nil || len(changed) != 0 {
+		t.Fatalf("reload of an unchanged file = %v, %v", changed, err)
+	}
+
+	os.WriteFile(path, []byte(`
+allowed_origins: https://b.example
+log_level: debug
+rate_limit_per_min: 5
+feature_flags: similar-acts
+port: 9999
+`), 0o600)
+	changed, err := r.reload(ctx)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	for _, want := range []string{"ALLOWED_ORIGINS", "LOG_LEVEL", "RATE_LIMIT_*", "FEATURE_FLAGS"} {
+		if !slices.Contains(changed, want) {
+			t.Errorf("changed = %v, missing %s", changed, want)
+		}
+	}
+	if origins.Match("https://a.example") || !origins.Match("https://b.example") {
+		t.Error("expected the new origins to apply")
+	}
+	if r.logLevel.Level() != slog.LevelDebug {
+		t.Errorf("log level = %s, want debug", r.logLevel.Level())
+	}
+	if !r.features.Enabled("similar-acts") {
+		t.Error("expected the feature flag to be on")
+	}
+	if r.current.Port != config.Port {
+		t.Error("expected PORT, which needs a restart, not to be applied")
+	}
+
+	os.WriteFile(path, []byte("allowed_origins: https://c.example\nlog_level: loud\n"), 0o600)
+	if _, err := r.reload(ctx); err == nil {
+		t.Fatal("expected an invalid configuration to be rejected")
+	}
+	if !origins.Match("https://b.example") || r.logLevel.Level() != slog.LevelDebug {
+		t.Error("expected a rejected reload to change nothing")
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/middleware"
+)
+
+// RouteGroup is a set of routes with its own request timeout, connection
+// deadlines, body size limit and rate limit. The first group matching a
+// request applies.
+type RouteGroup struct {
+	middleware.RouteProfile
+	// Timeout bounds the handler like REQUEST_TIMEOUT; zero disables it
+	Timeout time.Duration
+	// PerMinute limits anonymous and authenticated callers alike
+	PerMinute int
+	// rateKey is the setting PerMinute is read from
+	rateKey string
+}
+
+var (
+	writeMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	readMethods  = []string{http.MethodGet, http.MethodHead}
+)
+
+// loadRouteGroups reads the route groups: sign-in endpoints, the admin API,
+// then writes and reads of the rest of the API. Each group's settings are
+// ROUTE_GROUP_<NAME>_TIMEOUT, _READ_TIMEOUT, _WRITE_TIMEOUT and
+// _MAX_BODY_BYTES, with its rate limit in RATE_LIMIT_<CLASS>_PER_MIN, whose
+// default is multiplied by rateScale.
+func loadRouteGroups(src *configSource, requestTimeout time.Duration, rateScale int) []RouteGroup {
+	defaults := []struct {
+		profile   middleware.RouteProfile
+		rateKey   string
+		perMinute int
+	}{
+		{middleware.RouteProfile{Name: "auth", PathPrefix: "/api/v1/auth/", MaxBodyBytes: 64 << 10}, "RATE_LIMIT_AUTH_PER_MIN", 10},
+		// Imports carry up to 10,000 records
+		{middleware.RouteProfile{Name: "admin", PathPrefix: "/api/v1/admin/", MaxBodyBytes: 32 << 20}, "RATE_LIMIT_ADMIN_PER_MIN", 60},
+		{middleware.RouteProfile{Name: "write", PathPrefix: "/api/", Methods: writeMethods, MaxBodyBytes: 1 << 20}, "RATE_LIMIT_WRITE_PER_MIN", 30},
+		{middleware.RouteProfile{Name: "public-read", PathPrefix: "/api/", Methods: readMethods}, "RATE_LIMIT_READ_PER_MIN", 300},
+	}
+
+	groups := make([]RouteGroup, 0, len(defaults))
+	for _, d := range defaults {
+		g := RouteGroup{RouteProfile: d.profile, rateKey: d.rateKey}
+		prefix := g.settingPrefix()
+		g.Timeout = src.getDuration(prefix+"TIMEOUT", requestTimeout)
+		g.ReadTimeout = src.getDuration(prefix+"READ_TIMEOUT", 0)
+		g.WriteTimeout = src.getDuration(prefix+"WRITE_TIMEOUT", 0)
+		g.MaxBodyBytes = int64(src.getInt(prefix+"MAX_BODY_BYTES", int(d.profile.MaxBodyBytes)))
+		g.PerMinute = src.getInt(d.rateKey, d.perMinute*rateScale)
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// settingPrefix is the prefix of the group's settings, such as
+// ROUTE_GROUP_PUBLIC_READ_
+func (g RouteGroup) settingPrefix() string {
+	return "ROUTE_GROUP_" + strings.ToUpper(strings.ReplaceAll(g.Name, "-", "_")) + "_"
+}
+
+// routeProfiles returns the connection limits of groups
+func routeProfiles(groups []RouteGroup) []middleware.RouteProfile {
+	profiles := make([]middleware.RouteProfile, len(groups))
+	for i, g := range groups {
+		profiles[i] = g.RouteProfile
+	}
+	return profiles
+}
+
+// groupRateClasses returns the rate class of each group
+func groupRateClasses(groups []RouteGroup) []middleware.RateClass {
+	classes := make([]middleware.RateClass, len(groups))
+	for i, g := range groups {
+		classes[i] = middleware.RateClass{
+			Name:          g.Name,
+			PathPrefix:    g.PathPrefix,
+			Methods:       g.Methods,
+			PerMinute:     g.PerMinute,
+			UserPerMinute: g.PerMinute,
+		}
+	}
+	return classes
+}
+
+// groupTimeouts returns the request timeout of each group
+func groupTimeouts(groups []RouteGroup) []middleware.RouteTimeout {
+	timeouts := make([]middleware.RouteTimeout, len(groups))
+	for i, g := range groups {
+		timeouts[i] = middleware.RouteTimeout{PathPrefix: g.PathPrefix, Methods: g.Methods, Timeout: g.Timeout}
+	}
+	return timeouts
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	"payforwardnow/internal/handlers"
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/middleware"
+)
+
+// route is an entry of the server's route table
+type route struct {
+	// pattern is the ServeMux pattern, as "METHOD /path"
+	pattern string
+	handler http.Handler
+	// admin routes require the Keycloak admin role
+	admin bool
+}
+
+// apiRoutes returns the route table served by h
+func apiRoutes(h *handlers.Handler) []route {
+	return []route{
+		// API routes
+		{"GET /api/health", http.HandlerFunc(h.HealthCheck), false},
+		{"GET /api/version", http.HandlerFunc(h.GetVersion), false},
+		{"GET /metrics", metrics.Handler(), false},
+		{"GET /api/v1/features", http.HandlerFunc(h.GetFeatures), false},
+		{"GET /api/openapi.json", openAPIHandler(), false},
+		{"GET /docs", swaggerUIHandler(), false},
+		{"GET /api/v1/users/{id}", http.HandlerFunc(h.GetUser), false},
+		{"POST /api/v1/users", http.HandlerFunc(h.CreateUser), false},
+		{"PUT /api/v1/users/{id}", http.HandlerFunc(h.UpdateUser), false},
+		{"DELETE /api/v1/users/{id}", http.HandlerFunc(h.DeleteUser), false},
+
+		// Auth routes
+		{"POST /api/v1/auth/register", http.HandlerFunc(h.Register), false},
+		{"POST /api/v1/auth/login", http.HandlerFunc(h.Login), false},
+		{"POST /api/v1/auth/logout", http.HandlerFunc(h.Logout), false},
+		{"POST /api/v1/auth/refresh", http.HandlerFunc(h.RefreshToken), false},
+
+		// Pay it forward routes
+		{"GET /api/v1/acts", http.HandlerFunc(h.GetActs), false},
+		{"POST /api/v1/acts", http.HandlerFunc(h.CreateAct), false},
+		{"GET /api/v1/acts/{id}", http.HandlerFunc(h.GetAct), false},
+		{"GET /api/v1/acts/{id}/similar", http.HandlerFunc(h.GetSimilarActs), false},
+		{"GET /api/v1/acts/{id}/receipt", http.HandlerFunc(h.GetActReceipt), false},
+		{"GET /api/v1/acts/{id}/qr", http.HandlerFunc(h.GetActQR), false},
+		{"GET /api/v1/acts/match", http.HandlerFunc(h.MatchActs), false},
+		{"GET /api/v1/acts/nearby", http.HandlerFunc(h.GetNearbyActs), false},
+		{"PUT /api/v1/acts/{id}", http.HandlerFunc(h.UpdateAct), false},
+		{"DELETE /api/v1/acts/{id}", http.HandlerFunc(h.DeleteAct), false},
+
+		// Pledge routes
+		{"POST /api/v1/acts/{id}/pledge", http.HandlerFunc(h.CreatePledge), false},
+		{"GET /api/v1/acts/{id}/pledge", http.HandlerFunc(h.GetPledge), false},
+		{"POST /api/v1/acts/{id}/pledge/confirm", http.HandlerFunc(h.ConfirmPledge), false},
+		{"POST /api/v1/acts/{id}/pledge/cancel", http.HandlerFunc(h.CancelPledge), false},
+		{"POST /api/v1/acts/{id}/pledge/dispute", http.HandlerFunc(h.DisputePledge), false},
+
+		// Chain routes
+		{"GET /api/v1/chains/{id}", http.HandlerFunc(h.GetChain), false},
+		{"GET /api/v1/chains/{id}/qr", http.HandlerFunc(h.GetChainQR), false},
+		{"GET /api/v1/users/{id}/chains", http.HandlerFunc(h.GetUserChains), false},
+		{"GET /api/v1/users/{id}/impact-report", http.HandlerFunc(h.GetImpactReport), false},
+
+		// Stats routes
+		{"GET /api/v1/stats/global", http.HandlerFunc(h.GetGlobalStats), false},
+		{"GET /api/v1/stats/user/{id}", http.HandlerFunc(h.GetUserStats), false},
+		{"GET /api/v1/stats/categories", http.HandlerFunc(h.GetCategoryStats), false},
+		{"GET /api/v1/stats/stream", http.HandlerFunc(h.StreamStats), false},
+		{"GET /api/v1/ws", http.HandlerFunc(h.LiveUpdates), false},
+		{"GET /api/v1/events", http.HandlerFunc(h.StreamActivity), false},
+		{"GET /api/v1/stats/top", http.HandlerFunc(h.GetTopUsers), false},
+		{"GET /api/v1/stats/widget", http.HandlerFunc(h.GetStatsWidget), false},
+		{"GET /api/v1/orgs/{id}/stats", http.HandlerFunc(h.GetOrgStats), false},
+		{"GET /api/v1/rates", http.HandlerFunc(h.GetExchangeRates), false},
+
+		// Testimonials routes
+		{"GET /api/v1/testimonials", http.HandlerFunc(h.GetTestimonials), false},
+		{"POST /api/v1/testimonials", http.HandlerFunc(h.CreateTestimonial), false},
+		{"GET /api/v1/testimonials/featured", http.HandlerFunc(h.GetFeaturedTestimonials), false},
+		{"PUT /api/v1/testimonials/{id}", http.HandlerFunc(h.UpdateTestimonial), false},
+		{"DELETE /api/v1/testimonials/{id}", http.HandlerFunc(h.DeleteTestimonial), false},
+		{"POST /api/v1/testimonials/{id}/reactions", http.HandlerFunc(h.ToggleReaction), false},
+
+		// Discovery routes
+		{"GET /api/v1/search", http.HandlerFunc(h.Search), false},
+		{"GET /api/v1/recommendations", http.HandlerFunc(h.GetRecommendations), false},
+
+		// Campaign routes
+		{"GET /api/v1/campaigns", http.HandlerFunc(h.GetCampaigns), false},
+		{"GET /api/v1/campaigns/{id}", http.HandlerFunc(h.GetCampaign), false},
+		{"POST /api/v1/campaigns/{id}/join", http.HandlerFunc(h.JoinCampaign), false},
+		{"DELETE /api/v1/campaigns/{id}/join", http.HandlerFunc(h.LeaveCampaign), false},
+		{"GET /api/v1/campaigns/{id}/leaderboard", http.HandlerFunc(h.GetCampaignLeaderboard), false},
+
+		// Organization routes
+		{"POST /api/v1/orgs", http.HandlerFunc(h.CreateOrganization), false},
+		{"GET /api/v1/orgs/{id}", http.HandlerFunc(h.GetOrganization), false},
+		{"GET /api/v1/users/{id}/orgs", http.HandlerFunc(h.GetUserOrganizations), false},
+		{"GET /api/v1/orgs/{id}/members", http.HandlerFunc(h.GetOrgMembers), false},
+		{"PUT /api/v1/orgs/{id}/members/{userId}", http.HandlerFunc(h.UpdateOrgMember), false},
+		{"DELETE /api/v1/orgs/{id}/members/{userId}", http.HandlerFunc(h.RemoveOrgMember), false},
+		{"POST /api/v1/orgs/{id}/invitations", http.HandlerFunc(h.InviteOrgMember), false},
+		{"GET /api/v1/orgs/{id}/invitations", http.HandlerFunc(h.GetOrgInvitations), false},
+		{"DELETE /api/v1/orgs/{id}/invitations/{invitationId}", http.HandlerFunc(h.RevokeOrgInvitation), false},
+		{"POST /api/v1/invitations/accept", http.HandlerFunc(h.AcceptOrgInvitation), false},
+		{"GET /api/v1/orgs/{id}/acts", http.HandlerFunc(h.GetOrgActs), false},
+		{"GET /api/v1/orgs/{id}/chains", http.HandlerFunc(h.GetOrgChains), false},
+		{"GET /api/v1/orgs/{id}/dashboard", http.HandlerFunc(h.GetOrgDashboard), false},
+
+		// Content report routes
+		{"POST /api/v1/reports", http.HandlerFunc(h.CreateContentReport), false},
+
+		// Notifications routes
+		{"GET /api/v1/notifications", http.HandlerFunc(h.GetNotifications), false},
+		{"GET /api/v1/notifications/unread-count", http.HandlerFunc(h.GetUnreadNotificationCount), false},
+		{"POST /api/v1/notifications/read", http.HandlerFunc(h.MarkNotificationsRead), false},
+		{"POST /api/v1/notifications/{id}/read", http.HandlerFunc(h.MarkNotificationRead), false},
+
+		// Admin routes
+		{"GET /api/v1/admin/stats/retention", http.HandlerFunc(h.GetRetention), true},
+		{"GET /api/v1/admin/maintenance", http.HandlerFunc(h.GetMaintenance), true},
+		{"PUT /api/v1/admin/maintenance", http.HandlerFunc(h.SetMaintenanceMode), true},
+		{"GET /api/v1/admin/features", http.HandlerFunc(h.GetFeatureFlags), true},
+		{"PUT /api/v1/admin/features/{name}", http.HandlerFunc(h.SetFeatureFlag), true},
+		{"DELETE /api/v1/admin/features/{name}", http.HandlerFunc(h.ClearFeatureFlag), true},
+		{"POST /api/v1/admin/config/reload", http.HandlerFunc(h.ReloadConfig), true},
+		{"GET /api/v1/admin/audit", http.HandlerFunc(h.GetAuditEvents), true},
+		{"GET /api/v1/admin/schema/drift", http.HandlerFunc(h.GetSchemaDrift), true},
+		{"GET /api/v1/admin/doctor", http.HandlerFunc(h.GetDiagnostics), true},
+		{"POST /api/v1/admin/import/users", http.HandlerFunc(h.ImportUsers), true},
+		{"POST /api/v1/admin/import/acts", http.HandlerFunc(h.ImportActs), true},
+		{"GET /api/v1/admin/export/users", http.HandlerFunc(h.ExportUsers), true},
+		{"GET /api/v1/admin/export/acts", http.HandlerFunc(h.ExportActs), true},
+		{"POST /api/v1/admin/exports", http.HandlerFunc(h.CreateExport), true},
+		{"GET /api/v1/admin/exports/{id}", http.HandlerFunc(h.GetExport), true},
+		{"GET /api/v1/admin/users", http.HandlerFunc(h.GetUsers), true},
+		{"PUT /api/v1/admin/users/{id}/suspension", http.HandlerFunc(h.SuspendUser), true},
+		{"DELETE /api/v1/admin/users/{id}/personal-data", http.HandlerFunc(h.EraseUserData), true},
+		{"POST /api/v1/admin/{kind}/{id}/restore", http.HandlerFunc(h.RestoreDeleted), true},
+		{"GET /api/v1/admin/email/suppressions", http.HandlerFunc(h.GetEmailSuppressions), true},
+		{"PUT /api/v1/admin/email/suppressions/{email}", http.HandlerFunc(h.SuppressEmail), true},
+		{"DELETE /api/v1/admin/email/suppressions/{email}", http.HandlerFunc(h.UnsuppressEmail), true},
+		{"GET /api/v1/admin/webhooks", http.HandlerFunc(h.GetWebhooks), true},
+		{"POST /api/v1/admin/webhooks", http.HandlerFunc(h.CreateWebhook), true},
+		{"DELETE /api/v1/admin/webhooks/{id}", http.HandlerFunc(h.DeleteWebhook), true},
+		{"GET /api/v1/admin/webhooks/{id}/deliveries", http.HandlerFunc(h.GetWebhookDeliveries), true},
+		{"GET /api/v1/admin/jobs", http.HandlerFunc(h.GetJobs), true},
+		{"GET /api/v1/admin/jobs/schedules", http.HandlerFunc(h.GetJobSchedules), true},
+		{"GET /api/v1/admin/jobs/{id}", http.HandlerFunc(h.GetJob), true},
+		{"POST /api/v1/admin/jobs/{id}/retry", http.HandlerFunc(h.RetryJob), true},
+		{"GET /api/v1/admin/pledges", http.HandlerFunc(h.GetPledges), true},
+		{"POST /api/v1/admin/pledges/{id}/resolve", http.HandlerFunc(h.ResolvePledge), true},
+		{"POST /api/v1/admin/rates/refresh", http.HandlerFunc(h.RequestRatesRefresh), true},
+		{"POST /api/v1/admin/search/reindex", http.HandlerFunc(h.RequestSearchReindex), true},
+		{"GET /api/v1/admin/acts/flagged", http.HandlerFunc(h.GetFlaggedActs), true},
+		{"DELETE /api/v1/admin/acts/{id}/flag", http.HandlerFunc(h.DismissActFlag), true},
+		{"POST /api/v1/admin/acts/{id}/takedown", http.HandlerFunc(h.TakeDownAct), true},
+		{"POST /api/v1/admin/campaigns", http.HandlerFunc(h.CreateCampaign), true},
+		{"GET /api/v1/admin/reports", http.HandlerFunc(h.GetContentReports), true},
+		{"PUT /api/v1/admin/reports/{id}", http.HandlerFunc(h.HandleContentReport), true},
+		{"GET /api/v1/admin/testimonials", http.HandlerFunc(h.GetModerationQueue), true},
+		{"PUT /api/v1/admin/testimonials/{id}/featured", http.HandlerFunc(h.FeatureTestimonial), true},
+		{"PUT /api/v1/admin/testimonials/{id}/reviewer", http.HandlerFunc(h.AssignReviewer), true},
+		{"PUT /api/v1/admin/testimonials/{id}/review", http.HandlerFunc(h.ReviewTestimonial), true},
+		{"POST /api/v1/admin/testimonials/{id}/notes", http.HandlerFunc(h.AddModerationNote), true},
+		{"PUT /api/v1/admin/testimonials/{id}/translations/{locale}", http.HandlerFunc(h.PutTestimonialTranslation), true},
+		{"DELETE /api/v1/admin/testimonials/{id}/translations/{locale}", http.HandlerFunc(h.DeleteTestimonialTranslation), true},
+	}
+}
+
+// registerRoutes adds routes to mux, wrapping admin routes in adminOnly
+func registerRoutes(mux *http.ServeMux, routes []route, adminOnly middleware.Middleware) {
+	for _, r := range routes {
+		handler := r.handler
+		if r.admin {
+			handler = adminOnly(handler)
+		}
+		mux.Handle(r.pattern, handler)
+	}
+}
+
+// runRoutes lists the routes the server serves
+func runRoutes(args []string) error {
+	flags := newFlagSet("routes", "")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usageError(flags)
+	}
+
+	// Only the method values are needed, so the handler isn't initialised
+	printRoutes(stdout, apiRoutes(new(handlers.Handler)))
+	return nil
+}
+
+func printRoutes(w io.Writer, routes []route) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, r := range routes {
+		access := ""
+		if r.admin {
+			access = "admin"
+		}
+		method, path, _ := strings.Cut(r.pattern, " ")
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", method, path, access)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,909 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/errortracking"
+	"payforwardnow/internal/features"
+	"payforwardnow/internal/geocode"
+	"payforwardnow/internal/handlers"
+	"payforwardnow/internal/jobs"
+	"payforwardnow/internal/lifecycle"
+	"payforwardnow/internal/logging"
+	"payforwardnow/internal/mail"
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/migrations"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/outbox"
+	"payforwardnow/internal/payments"
+	"payforwardnow/internal/queries"
+	"payforwardnow/internal/rates"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/search"
+	"payforwardnow/internal/socket"
+	"payforwardnow/internal/telemetry"
+	"payforwardnow/internal/version"
+	"payforwardnow/internal/web"
+	"payforwardnow/internal/webhooks"
+	"payforwardnow/internal/workers"
+)
+
+// componentStopTimeout bounds how long each background component may take
+// to stop once the HTTP server has shut down
+const componentStopTimeout = 10 * time.Second
+
+// runServe runs the HTTP server until it receives SIGINT or SIGTERM
+func runServe(args []string) error {
+	flags := newFlagSet("serve", "[-config FILE]")
+	configPath := configFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usageError(flags)
+	}
+	config, err := LoadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	// Initialize logging. The level can be changed by a reload.
+	logLevel := new(slog.LevelVar)
+	logger, err := logging.New(os.Stdout, logging.Config{
+		Level:    config.LogLevel,
+		Format:   config.LogFormat,
+		LevelVar: logLevel,
+	})
+	if err != nil {
+		slog.Error("Invalid logging configuration", "error", err)
+		os.Exit(1)
+	}
+	// Every line carries the version, to tell deployments apart
+	build := version.Get()
+	logger = logger.With("version", build.Version)
+	slog.SetDefault(logger)
+	slog.Info("Starting PayForward", "commit", build.Commit, "buildTime", build.BuildTime, "go", build.GoVersion)
+
+	// Background components are stopped in the reverse order they are
+	// registered in once the HTTP server has drained, so the database and
+	// cache outlive the jobs and writes that use them
+	lc := lifecycle.New(componentStopTimeout)
+
+	// Optionally write an access log in a standard format for log ingestion
+	loggerConfig := middleware.LoggerConfig{}
+	if config.AccessLog != "" {
+		format, err := middleware.ParseAccessLogFormat(config.AccessLogFormat)
+		if err != nil {
+			fatal("Invalid ACCESS_LOG_FORMAT", err)
+		}
+		accessLog, err := openAccessLog(config.AccessLog)
+		if err != nil {
+			fatal("Failed to open access log", err)
+		}
+		lc.OnClose("access log", accessLog.Close)
+		loggerConfig = middleware.LoggerConfig{AccessLog: accessLog, AccessLogFormat: format}
+	}
+
+	// Initialize tracing, exporting over OTLP when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	shutdownTracing, err := telemetry.Setup(context.Background())
+	if err != nil {
+		fatal("Failed to initialize tracing", err)
+	}
+	if telemetry.Enabled() {
+		slog.Info("OpenTelemetry tracing enabled")
+	}
+	lc.OnStop("tracing", shutdownTracing)
+
+	// Report panics and server errors to Sentry when a DSN is configured
+	var errorReporter errortracking.Reporter = errortracking.Nop{}
+	if config.SentryDSN != "" {
+		sentryReporter, err := errortracking.NewSentry(config.SentryDSN, config.Environment)
+		if err != nil {
+			fatal("Failed to initialize Sentry", err)
+		}
+		errorReporter = sentryReporter
+		slog.Info("Sentry error reporting enabled")
+	}
+	lc.OnStop("error reporting", func(ctx context.Context) error {
+		if !errorReporter.Flush(componentStopTimeout) {
+			return errors.New("events were left unsent")
+		}
+		return nil
+	})
+
+	// A query that declares the wrong parameters would only fail when it runs
+	if err := queries.Check(); err != nil {
+		fatal("Invalid query registry", err)
+	}
+
+	// Initialize Neo4j connection
+	neo4jClient, err := database.NewNeo4jClientWithConfig(config.Neo4jURI, config.Neo4jUser, config.Neo4jPassword, config.Neo4jDriver)
+	if err != nil {
+		fatal("Failed to connect to Neo4j", err)
+	}
+	lc.OnClose("neo4j", neo4jClient.Close)
+
+	// Schema changes are applied by the migrate command, or here with
+	// SCHEMA_SETUP=apply. Index builds can outlast the query timeout. A
+	// failure only degrades the health check unless SCHEMA_REQUIRED is set,
+	// since managed instances may not let the server's user change the schema.
+	migrateCtx := database.WithQueryTimeout(context.Background(), 0)
+	schemaReadiness := migrations.NewReadiness()
+	setupSchema(migrateCtx, neo4jClient, schemaReadiness, "default", config)
+	for tenant, name := range config.TenantDatabases {
+		setupSchema(database.WithDatabase(migrateCtx, name), neo4jClient, schemaReadiness, tenant, config)
+	}
+
+	// Initialize Keycloak authentication (if configured)
+	var keycloakAuth *auth.KeycloakAuth
+	var keycloakMiddleware *middleware.KeycloakAuthMiddleware
+	if config.KeycloakURL != "" && config.KeycloakRealm != "" {
+		keycloakAuth = auth.NewKeycloakAuth(
+			config.KeycloakURL,
+			config.KeycloakRealm,
+			config.KeycloakClientID,
+			config.KeycloakClientSecret,
+		)
+		keycloakMiddleware = middleware.NewKeycloakAuthMiddleware(keycloakAuth)
+		lc.OnClose("keycloak keys", keycloakAuth.Close)
+		slog.Info("Keycloak authentication enabled", "realm", config.KeycloakRealm)
+	} else {
+		slog.Info("Keycloak authentication disabled, using the tokens issued by login")
+	}
+
+	// Fail fast with 503s while the database is unavailable
+	db := database.NewCircuitBreaker(neo4jClient, database.BreakerConfig{
+		FailureThreshold: config.DBBreakerFailures,
+		OpenTimeout:      config.DBBreakerOpenTimeout,
+	})
+
+	// Initialize handlers
+	h := handlers.NewHandler(db)
+
+	// Short-lived state (cached responses and stats, rate limit counts,
+	// deduplicated responses and revoked tokens) lives in Redis when
+	// configured, so all instances share it, and in memory otherwise
+	var sharedStore cache.Store
+	var redisStore *cache.Redis
+	if config.RedisURL != "" {
+		redisStore, err = cache.NewRedis(context.Background(), config.RedisURL, "payforward:")
+		if err != nil {
+			fatal("Failed to connect to Redis", err)
+		}
+		lc.OnClose("redis", redisStore.Close)
+		sharedStore = redisStore
+		h.SetCache(redisStore)
+		slog.Info("Redis cache enabled")
+	}
+
+	// Work started by requests, such as storing audit events and building
+	// heavy reports, runs on a bounded pool. Queued tasks are finished on
+	// shutdown, before the connections they need are closed.
+	pool := workers.New("background", config.WorkerPoolSize, config.WorkerQueueSize)
+	lc.OnStop("background tasks", pool.Stop)
+	h.SetWorkers(pool)
+
+	// Background jobs are stopped when the server shuts down. Their batches
+	// may take longer than a request's queries.
+	jobsCtx := database.WithQueryTimeout(context.Background(), time.Minute)
+
+	// Scheduled and requested work, such as stats aggregation, digests,
+	// expiration sweeps and exports, runs from a job queue in the database
+	// shared by every instance. Each scheduled occurrence runs once, on
+	// whichever instance claims it; failed attempts are retried with
+	// backoff.
+	queue := jobs.New(repository.NewNeo4jJobs(db), jobs.Options{
+		Concurrency: config.JobConcurrency,
+		MaxAttempts: config.JobMaxAttempts,
+		Lease:       config.JobLease,
+	})
+	h.SetJobs(queue, config.ExportDir)
+	h.SetReceipts(config.ReceiptPrefix, models.ReceiptIssuer{
+		Name:    config.ReceiptIssuer,
+		TaxID:   config.ReceiptIssuerTaxID,
+		Address: config.ReceiptIssuerAddress,
+	})
+	// Retention is computed for the default database and every tenant's,
+	// each cached under its database like the requests that read it
+	scheduleJob(queue, "stats.retention", "@hourly", func(ctx context.Context) error {
+		_, err := h.RefreshRetention(ctx, handlers.DefaultRetentionWeeks)
+		errs := []error{err}
+		for tenant, name := range config.TenantDatabases {
+			if _, err := h.RefreshRetention(database.WithDatabase(ctx, name), handlers.DefaultRetentionWeeks); err != nil {
+				errs = append(errs, fmt.Errorf("tenant %s: %w", tenant, err))
+			}
+		}
+		return errors.Join(errs...)
+	})
+	scheduleJob(queue, "stats.leaderboards", "*/15 * * * *", func(ctx context.Context) error {
+		for _, role := range handlers.LeaderboardRoles {
+			for _, period := range handlers.LeaderboardPeriods {
+				if _, err := h.RefreshLeaderboard(ctx, role, period); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	// Deleted users, acts and testimonials stay restorable by admins for
+	// the retention period, then are removed for good
+	if config.DeletedRetention > 0 {
+		scheduleJob(queue, "trash.purge", "@hourly", func(ctx context.Context) error {
+			return h.PurgeDeleted(ctx, config.DeletedRetention)
+		})
+	}
+	// Acts nobody took up within the expiry period are marked expired
+	if config.ActExpiry > 0 {
+		scheduleJob(queue, "acts.expire", "@hourly", func(ctx context.Context) error {
+			return h.ExpireActs(ctx, config.ActExpiry)
+		})
+	}
+	if config.JobRetention > 0 {
+		scheduleJob(queue, "jobs.prune", "@daily", func(ctx context.Context) error {
+			return h.PruneJobs(ctx, config.JobRetention)
+		})
+	}
+
+	// Act embeddings power the similarity endpoints. New and edited acts
+	// are picked up by a background refresh.
+	provider, err := embeddings.NewProvider(config.Embeddings)
+	if err != nil {
+		fatal("Invalid embeddings configuration", err)
+	}
+	if provider != nil {
+		index := embeddings.NewIndex(db, provider)
+		if err := index.EnsureIndex(context.Background()); err != nil {
+			fatal("Failed to create the vector index", err)
+		}
+		h.SetEmbeddings(index)
+		startJob(lc, jobsCtx, "embeddings", time.Minute, func(ctx context.Context) error {
+			_, err := index.Refresh(ctx)
+			return err
+		})
+		slog.Info("Act embeddings enabled", "model", provider.Model(), "dimensions", provider.Dimensions())
+	}
+
+	// QR codes printed on pay-it-forward cards link to the frontend, as
+	// links in emails do
+	h.SetLinkBase(config.MailBaseURL)
+
+	// Transactional emails are rendered from embedded templates and
+	// delivered on the worker pool, skipping suppressed addresses. Users
+	// with unread notifications get a weekly digest.
+	mailDriver, err := mail.NewDriver(config.Mail)
+	if err != nil {
+		fatal("Invalid mail configuration", err)
+	}
+	if mailDriver != nil {
+		mailer, err := mail.New(mailDriver, repository.NewNeo4jSuppressions(db), mail.Options{
+			BaseURL:     config.MailBaseURL,
+			MaxAttempts: config.MailMaxAttempts,
+		})
+		if err != nil {
+			fatal("Failed to set up email", err)
+		}
+		mailer.SetPool(pool)
+		h.SetMailer(mailer)
+		scheduleJob(queue, "mail.weekly_digests", "@hourly", h.SendWeeklyDigests)
+		slog.Info("Email enabled", "driver", mailDriver.Name())
+	}
+
+	// Events are delivered to the webhooks subscribed to them on the worker
+	// pool, signed with each one's secret. Their delivery logs are pruned
+	// after the retention period.
+	dispatcher := webhooks.New(repository.NewNeo4jWebhooks(db), webhooks.Options{
+		MaxAttempts: config.WebhookMaxAttempts,
+	})
+	dispatcher.SetPool(pool)
+	h.SetWebhooks(dispatcher)
+	if config.WebhookRetention > 0 {
+		scheduleJob(queue, "webhooks.prune_deliveries", "@hourly", func(ctx context.Context) error {
+			return h.PruneWebhookDeliveries(ctx, config.WebhookRetention)
+		})
+	}
+
+	// Pledged funds are held by the payments provider until the receiver
+	// confirms the act; a sweep releases the ones left unconfirmed and
+	// refunds those whose act was cancelled
+	paymentsProvider, err := payments.NewProvider(config.Payments)
+	if err != nil {
+		fatal("Invalid payments configuration", err)
+	}
+	if paymentsProvider != nil {
+		h.SetPayments(paymentsProvider, config.PledgeAutoRelease)
+		scheduleJob(queue, "pledges.settle", "*/15 * * * *", h.SettlePledges)
+		slog.Info("Pledges enabled", "driver", paymentsProvider.Name())
+	}
+
+	// Act values are converted to the base currency for stats and
+	// leaderboards with exchange rates refreshed daily, after the ECB
+	// publishes its reference rates around 16:00 CET
+	ratesProvider, err := rates.NewProvider(config.Rates)
+	if err != nil {
+		fatal("Invalid rates configuration", err)
+	}
+	if ratesProvider != nil {
+		h.SetRates(ratesProvider, config.BaseCurrency)
+		scheduleJob(queue, handlers.JobRefreshRates, "0 17 * * *", h.RefreshRates)
+		slog.Info("Exchange rates enabled", "driver", ratesProvider.Name(), "base", config.BaseCurrency)
+	}
+
+	// Locations are geocoded in the background as users and acts are
+	// written, with a sweep catching up on those missed. Answers are cached
+	// in Redis when configured, so replicas share them.
+	geocoder, err := geocode.NewGeocoder(config.Geocoder)
+	if err != nil {
+		fatal("Invalid geocoder configuration", err)
+	}
+	if geocoder != nil {
+		geocodeStore := sharedStore
+		if geocodeStore == nil {
+			geocodeStore = cache.NewLRU(10000)
+		}
+		h.SetGeocoder(geocode.NewCached(geocoder, geocodeStore, config.GeocodeCacheTTL))
+		scheduleJob(queue, "locations.geocode", "*/5 * * * *", h.GeocodeLocations)
+		slog.Info("Geocoding enabled", "driver", geocoder.Name())
+	}
+
+	// Changes record domain events in the outbox in the transaction making
+	// the change. A relay publishes them, in order, to the configured
+	// broker. They are pruned after the retention period, once published
+	// if there is a broker.
+	publisher, err := outbox.NewPublisher(config.Outbox)
+	if err != nil {
+		fatal("Invalid outbox configuration", err)
+	}
+	events := repository.NewNeo4jOutbox(db)
+	if closer, ok := publisher.(interface{ Close() error }); ok {
+		lc.OnClose("outbox publisher", closer.Close)
+	}
+	if publisher != nil {
+		relay := outbox.NewRelay(events, publisher)
+		startJob(lc, jobsCtx, "outbox relay", config.OutboxPollInterval, func(ctx context.Context) error {
+			_, err := relay.Run(ctx)
+			return err
+		})
+		slog.Info("Domain event publishing enabled", "driver", publisher.Name())
+	}
+	if config.OutboxRetention > 0 {
+		scheduleJob(queue, "outbox.prune", "@hourly", func(ctx context.Context) error {
+			_, err := events.Prune(ctx, time.Now().Add(-config.OutboxRetention), publisher != nil)
+			return err
+		})
+	}
+
+	h.SetMediaHosts(config.MediaHosts)
+
+	// Acts and testimonials are screened with word lists, and with a
+	// moderation API when configured
+	moderationProvider, err := moderation.NewProvider(config.Moderation)
+	if err != nil {
+		fatal("Invalid moderation configuration", err)
+	}
+	if moderationProvider != nil {
+		h.SetModerationProvider(moderationProvider)
+		slog.Info("Moderation provider enabled", "provider", moderationProvider.Name())
+	}
+
+	// Search runs on the Neo4j full-text indexes unless an external index
+	// is configured, which an indexer keeps in sync by following the domain
+	// events. It is built on the first run and rebuilt on request.
+	searchIndex, err := search.NewIndex(config.Search)
+	if err != nil {
+		fatal("Invalid search configuration", err)
+	}
+	if searchIndex != nil {
+		h.SetSearch(searchIndex, search.NewIndexer(searchIndex, events, repository.NewNeo4jSearch(db)))
+		h.RegisterSearchJobs(queue)
+		startJob(lc, jobsCtx, "search indexer", config.OutboxPollInterval, h.SyncSearchIndex)
+		slog.Info("External search index enabled", "driver", searchIndex.Name())
+	}
+	lc.Go(jobsCtx, "job queue", queue.Run)
+
+	// Maintenance mode can be toggled at runtime by admins; health checks,
+	// metrics and the toggle itself stay reachable
+	maintenance := middleware.NewMaintenance(config.MaintenanceMode,
+		"/api/health", "/metrics", "/api/v1/admin/maintenance")
+	h.SetMaintenance(maintenance)
+	h.SetSchemaReadiness(schemaReadiness)
+	if config.MaintenanceMode {
+		slog.Warn("Starting in maintenance mode")
+	}
+
+	// Block abusive networks and restrict sensitive routes by client IP. The
+	// rules file is reloaded whenever it changes.
+	filterIPs := func(next http.Handler) http.Handler { return next }
+	if config.IPFilterFile != "" {
+		cfg, err := middleware.LoadIPFilterConfig(config.IPFilterFile)
+		if err != nil {
+			fatal("Failed to load IP filter", err)
+		}
+		ipFilter, err := middleware.NewIPFilter(cfg)
+		if err != nil {
+			fatal("Invalid IP filter", err)
+		}
+		watchCtx, stopWatching := context.WithCancel(jobsCtx)
+		ipFilter.WatchFile(watchCtx, config.IPFilterFile, 30*time.Second)
+		lc.OnStop("ip filter watcher", func(context.Context) error {
+			stopWatching()
+			return nil
+		})
+		filterIPs = ipFilter.Middleware
+		slog.Info("IP filter enabled", "path", config.IPFilterFile)
+	}
+
+	// Reject access tokens after their owners log out, and those of
+	// suspended users
+	revocationStore := sharedStore
+	if revocationStore == nil {
+		revocationStore = cache.NewLRU(config.RevocationCacheSize)
+	}
+	revocations := auth.NewRevocations(revocationStore)
+	h.SetRevocations(revocations)
+	h.SetTokenSecret(config.JWTSecret)
+	if keycloakMiddleware != nil {
+		keycloakMiddleware.SetRevocations(revocations)
+		keycloakMiddleware.SetSuspensions(repository.NewNeo4jUsers(db))
+	}
+
+	// Cache public GET responses
+	responseStore := sharedStore
+	if responseStore == nil {
+		responseStore = cache.NewLRU(config.ResponseCacheSize)
+	}
+	responseCache := middleware.NewResponseCache(middleware.ResponseCacheConfig{
+		Store: responseStore,
+		Routes: []middleware.CacheRoute{
+			{PathPrefix: "/api/v1/stats/global", TTL: 30 * time.Second},
+			{PathPrefix: "/api/v1/stats/categories", TTL: time.Minute},
+			{PathPrefix: "/api/v1/testimonials", TTL: time.Minute},
+			{PathPrefix: "/api/v1/acts", TTL: 30 * time.Second},
+		},
+		Invalidations: []middleware.CacheInvalidation{
+			{WritePrefix: "/api/v1/acts", Invalidates: []string{"/api/v1/acts", "/api/v1/stats/"}},
+			{WritePrefix: "/api/v1/testimonials", Invalidates: []string{"/api/v1/testimonials"}},
+			{WritePrefix: "/api/v1/admin/testimonials", Invalidates: []string{"/api/v1/testimonials"}},
+			{WritePrefix: "/api/v1/users", Invalidates: []string{"/api/v1/stats/", "/api/v1/testimonials"}},
+			// Erasing a user redacts their acts and deletes their testimonials
+			{WritePrefix: "/api/v1/admin/users/", Invalidates: []string{"/api/v1/acts", "/api/v1/stats/", "/api/v1/testimonials"}},
+			// Takedowns, restores, handled reports and imports remove or add
+			// acts and testimonials
+			{WritePrefix: "/api/v1/admin/acts/", Invalidates: []string{"/api/v1/acts", "/api/v1/stats/"}},
+			{WritePrefix: "/api/v1/admin/reports/", Invalidates: []string{"/api/v1/acts", "/api/v1/stats/", "/api/v1/testimonials"}},
+			{WritePrefix: "/api/v1/admin/import/", Invalidates: []string{"/api/v1/acts", "/api/v1/stats/"}},
+			// Acts are listed with their organization and campaign
+			{WritePrefix: "/api/v1/orgs", Invalidates: []string{"/api/v1/acts"}},
+			{WritePrefix: "/api/v1/campaigns", Invalidates: []string{"/api/v1/acts"}},
+			{WritePrefix: "/api/v1/admin/campaigns", Invalidates: []string{"/api/v1/acts"}},
+		},
+	})
+
+	// Log redacted request and response bodies while debugging integrations
+	logBodies := func(next http.Handler) http.Handler { return next }
+	if config.LogBodies {
+		logBodies = middleware.LogBodies(middleware.BodyLogConfig{
+			Redactor: logging.NewRedactor(config.LogRedactFields...),
+		})
+		slog.Warn("Request body logging enabled; disable it once debugging is done")
+	}
+
+	// Screen sign-ups and new acts for bots. Suspicious requests must solve a
+	// CAPTCHA when a verification endpoint is configured.
+	botGuard := middleware.BotGuardConfig{
+		Routes: []string{
+			"POST /api/v1/auth/register",
+			"POST /api/v1/users",
+			"POST /api/v1/acts",
+		},
+		Rules:          middleware.DefaultBotRules(),
+		RejectScore:    config.BotRejectScore,
+		ChallengeScore: config.BotChallengeScore,
+	}
+	if config.CaptchaVerifyURL != "" {
+		botGuard.Verifier = &middleware.SiteVerifier{URL: config.CaptchaVerifyURL, Secret: config.CaptchaSecret}
+	}
+
+	// Coalesce accidental double-submits of identical POSTs
+	dedup := func(next http.Handler) http.Handler { return next }
+	if config.DedupWindow > 0 {
+		dedupCtx, stopDedup := context.WithCancel(context.Background())
+		lc.OnStop("deduplicator cleanup", func(context.Context) error {
+			stopDedup()
+			return nil
+		})
+		var deduplicator *middleware.Deduplicator
+		if sharedStore != nil {
+			deduplicator = middleware.NewSharedDeduplicator(dedupCtx, config.DedupWindow, sharedStore)
+		} else {
+			deduplicator = middleware.NewDeduplicator(dedupCtx, config.DedupWindow)
+		}
+		dedup = deduplicator.Middleware
+	}
+
+	// Allowed CORS origins may include wildcard subdomains and regexes
+	allowedOrigins, err := middleware.NewOriginMatcher(config.AllowedOrigins)
+	if err != nil {
+		fatal("Invalid ALLOWED_ORIGINS", err)
+	}
+
+	// Rate limits, allowed origins, feature flags and the log level are
+	// reloaded on SIGHUP and by admins
+	rateLimitCfg := rateLimitConfig(config)
+	rateLimitCfg.Store = sharedStore
+	rateLimits := middleware.NewRateLimits(rateLimitCfg)
+	lc.OnClose("rate limiter cleanup", rateLimits.Close)
+	featureFlags := features.New(config.Features)
+	h.SetFeatures(featureFlags)
+	reload := &reloader{
+		path:       *configPath,
+		current:    config,
+		logLevel:   logLevel,
+		rateLimits: rateLimits,
+		origins:    allowedOrigins,
+		features:   featureFlags,
+	}
+	h.SetConfigReloader(reload.reload)
+	lc.Go(jobsCtx, "config reload", reload.watchSignals)
+
+	// The doctor endpoint checks the server's own connections
+	d := &doctor{config: config, neo4j: neo4jClient, keycloak: keycloakAuth, redis: redisStore}
+	h.SetDiagnoser(d.run)
+
+	// API versions served side by side under /api/{version}/. Set Deprecated
+	// and Sunset on a version to announce its retirement to clients.
+	apiVersions := middleware.NewAPIVersions("v1", []string{"/api/health", "/api/version", "/api/openapi.json"},
+		middleware.APIVersion{Name: "v1"},
+	)
+
+	// Panics are answered with a generic 500, or with their stack trace
+	// when DEBUG_ERRORS is set
+	recoverPanics := middleware.RecoveryWithReporter(errorReporter)
+	if config.DebugErrors {
+		recoverPanics = middleware.DebugRecovery(errorReporter)
+	}
+
+	// Audit events still being stored are waited for on shutdown
+	auditor := middleware.NewAuditor(h)
+	auditor.SetPool(pool)
+	lc.OnStop("audit events", auditor.Wait)
+
+	// Setup router
+	// With ADMIN_ADDR set, the admin API and metrics move to a listener of
+	// their own, which can be bound to localhost or the cluster network
+	routes := apiRoutes(h)
+	adminOnly := middleware.RequireAdmin(keycloakMiddleware)
+	var adminRoutes []route
+	if config.AdminAddr != "" {
+		routes, adminRoutes = splitRoutes(routes)
+	}
+	mux := http.NewServeMux()
+	registerRoutes(mux, routes, adminOnly)
+
+	// Serve the frontend from FRONTEND_DIR or the copy embedded at build
+	// time on every path the API doesn't use, so one binary can run both
+	if frontend := frontendFS(config); frontend != nil {
+		spa, err := web.NewHandler(frontend, "/api/", "/metrics", "/docs")
+		if err != nil {
+			fatal("Invalid frontend", err)
+		}
+		mux.Handle("GET /", spa)
+		slog.Info("Serving the frontend", "dir", config.FrontendDir)
+	}
+
+	// Identify the caller on every route, so rate limits can key on the
+	// user, by their Keycloak token or, without Keycloak, the token issued
+	// by login; routes that require authentication enforce it themselves
+	identify := middleware.OptionalJWTAuth(config.JWTSecret, revocations, repository.NewNeo4jUsers(db))
+	if keycloakMiddleware != nil {
+		identify = keycloakMiddleware.OptionalAuth
+	}
+
+	// Route tenants to their own databases when any are configured
+	tenants := func(next http.Handler) http.Handler { return next }
+	if len(config.TenantDatabases) > 0 {
+		tenants = middleware.Tenant(middleware.TenantConfig{
+			Databases:  config.TenantDatabases,
+			Header:     config.TenantHeader,
+			BaseDomain: config.TenantBaseDomain,
+		})
+		slog.Info("Multi-tenant databases enabled", "tenants", len(config.TenantDatabases))
+	}
+
+	// Apply middleware stack
+	handler := middleware.Chain(
+		middleware.TraceRoutes(mux),
+		middleware.LoggerWithConfig(loggerConfig),
+		middleware.RequestID,
+		middleware.Language,
+		logBodies,
+		middleware.Tracing,
+		middleware.Bookmarks(middleware.BookmarkConfig{Expose: config.ExposeBookmarks}),
+		middleware.ReadRouting(config.ReadRouting),
+		apiVersions.Middleware,
+		middleware.RouteProfiles(routeProfiles(config.RouteGroups)),
+		filterIPs,
+		middleware.CORSWithMatcher(allowedOrigins),
+		tenants,
+		maintenance.Middleware,
+		identify,
+		rateLimits.Middleware,
+		middleware.BotGuard(botGuard),
+		dedup,
+		responseCache.Middleware,
+		middleware.Timeout(middleware.TimeoutConfig{
+			Default: config.RequestTimeout,
+			Routes:  config.RouteTimeouts,
+		}),
+		recoverPanics,
+		middleware.SecurityHeadersWithConfig(config.SecurityHeaders),
+		auditor.Middleware,
+	)
+
+	// Sockets passed by systemd are used instead of listening anew, so
+	// restarts don't refuse connections; see socket.Inherited
+	inherited, err := socket.Inherited()
+	if err != nil {
+		fatal("Invalid sockets from the service manager", err)
+	}
+
+	// The admin listener filters, identifies and audits callers like the
+	// public one, and invalidates the response cache, but skips the limits
+	// meant for public traffic
+	var adminServer *http.Server
+	if config.AdminAddr != "" {
+		adminListener, err := listen(inherited, "admin", config.AdminAddr, config.ReusePort)
+		if err != nil {
+			fatal("Admin listener failed to start", err)
+		}
+		adminServer = &http.Server{
+			Addr: config.AdminAddr,
+			Handler: middleware.Chain(
+				middleware.TraceRoutes(newAdminMux(adminRoutes, adminOnly)),
+				middleware.LoggerWithConfig(loggerConfig),
+				middleware.RequestID,
+				middleware.Language,
+				middleware.RouteProfiles(routeProfiles(config.RouteGroups)),
+				middleware.Tracing,
+				filterIPs,
+				tenants,
+				identify,
+				// Admin writes clear the public responses they change
+				responseCache.Middleware,
+				recoverPanics,
+				middleware.SecurityHeadersWithConfig(config.SecurityHeaders),
+				auditor.Middleware,
+			),
+			ReadTimeout: 15 * time.Second,
+			// CPU profiles and traces run for 30 seconds by default
+			WriteTimeout: 2 * time.Minute,
+			IdleTimeout:  60 * time.Second,
+		}
+		go func() {
+			slog.Info("Admin listener starting", "addr", config.AdminAddr)
+			if err := adminServer.Serve(adminListener); err != nil && err != http.ErrServerClosed {
+				fatal("Admin listener failed to start", err)
+			}
+		}()
+	}
+
+	// Serve HTTPS with certificate files or ACME certificates when
+	// configured, optionally redirecting plain HTTP to it
+	tlsConfig, redirect, err := setupTLS(config.TLS, config.Port)
+	if err != nil {
+		fatal("Invalid TLS configuration", err)
+	}
+
+	// Create server
+	server := &http.Server{
+		Addr:         ":" + config.Port,
+		Handler:      handler,
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Start server in goroutine
+	listener, err := listen(inherited, "http", server.Addr, config.ReusePort)
+	if err != nil {
+		fatal("Server failed to start", err)
+	}
+	go func() {
+		slog.Info("Server starting", "port", config.Port, "tls", tlsConfig != nil)
+		var err error
+		if tlsConfig != nil {
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			fatal("Server failed to start", err)
+		}
+	}()
+
+	var redirectServer *http.Server
+	if tlsConfig != nil && config.TLS.RedirectPort != "" {
+		redirectListener, err := listen(inherited, "redirect", ":"+config.TLS.RedirectPort, config.ReusePort)
+		if err != nil {
+			fatal("Redirect listener failed to start", err)
+		}
+		redirectServer = &http.Server{
+			Addr:         ":" + config.TLS.RedirectPort,
+			Handler:      redirect,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 5 * time.Second,
+		}
+		go func() {
+			slog.Info("Redirecting HTTP to HTTPS", "port", config.TLS.RedirectPort)
+			if err := redirectServer.Serve(redirectListener); err != nil && err != http.ErrServerClosed {
+				fatal("Redirect listener failed to start", err)
+			}
+		}()
+	}
+
+	for name := range inherited {
+		slog.Warn("Ignoring a socket from the service manager", "name", name)
+	}
+	inherited.Close()
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	slog.Info("Shutting down server")
+
+	// Fail the health check and close idle connections first, so load
+	// balancers move traffic to the new process before this one stops
+	// accepting connections
+	h.Drain()
+	if config.DrainDelay > 0 {
+		slog.Info("Draining before shutdown", "delay", config.DrainDelay)
+		server.SetKeepAlivesEnabled(false)
+		time.Sleep(config.DrainDelay)
+	}
+
+	// Stop taking requests first, then stop the components they used
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if redirectServer != nil {
+		redirectServer.Shutdown(ctx)
+	}
+	if adminServer != nil {
+		adminServer.Shutdown(ctx)
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Requests were still running at the shutdown timeout", "error", err)
+	}
+
+	if err := lc.Shutdown(context.Background()); err != nil {
+		slog.Warn("Server exited; some components did not stop cleanly")
+		return nil
+	}
+	slog.Info("Server exited gracefully")
+	return nil
+}
+
+// listen returns the socket the service manager passed as name or, without
+// one, listens on addr
+func listen(inherited socket.Listeners, name, addr string, reusePort bool) (net.Listener, error) {
+	if l := inherited.Take(name); l != nil {
+		slog.Info("Using socket from the service manager", "name", name, "addr", l.Addr().String())
+		return l, nil
+	}
+	return socket.Listen(context.Background(), addr, reusePort)
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// setupSchema sets up the schema of the database in ctx according to
+// config.SchemaSetup and records the outcome under name. Failures exit when
+// config.SchemaRequired is set and are logged otherwise.
+func setupSchema(ctx context.Context, db migrations.Executor, readiness *migrations.Readiness, name string, config *Config) {
+	schema, err := migrations.Embedded()
+	if err != nil {
+		fatal("Invalid migrations", err)
+	}
+
+	state, err := migrations.New(db, schema).Setup(ctx, config.SchemaSetup)
+	readiness.Set(name, state)
+	if len(state.Applied) > 0 {
+		slog.Info("Applied schema migrations", "database", name, "versions", state.Applied)
+	}
+	switch {
+	case err != nil && config.SchemaRequired:
+		fatal("Failed to set up schema of "+name+" database", err)
+	case err != nil:
+		slog.Error("Failed to set up schema; serving with a degraded health check", "database", name, "error", err)
+	case state.Status == migrations.SchemaPending:
+		slog.Warn("Schema migrations are pending; run the migrate command or set SCHEMA_SETUP=apply",
+			"database", name, "pending", state.Pending)
+	}
+}
+
+// frontendFS returns the frontend to serve, or nil when there is none
+func frontendFS(config *Config) fs.FS {
+	switch {
+	case !config.ServeFrontend:
+		return nil
+	case config.FrontendDir != "":
+		return os.DirFS(config.FrontendDir)
+	default:
+		return web.Embedded()
+	}
+}
+
+// scheduleJob registers job as the handler of jobType on q and enqueues it
+// on the cron schedule spec
+func scheduleJob(q *jobs.Queue, jobType, spec string, job func(context.Context) error) {
+	q.Register(jobType, func(ctx context.Context, _ *models.Job) (any, error) {
+		return nil, job(ctx)
+	})
+	if err := q.Schedule(jobType, spec); err != nil {
+		fatal("Invalid job schedule", err)
+	}
+}
+
+// startJob runs job every interval as a component of lc
+func startJob(lc *lifecycle.Manager, ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	lc.Go(ctx, name+" job", func(ctx context.Context) {
+		runPeriodically(ctx, name, interval, job)
+	})
+}
+
+// runPeriodically runs job immediately and then on every interval until ctx
+// is cancelled
+func runPeriodically(ctx context.Context, name string, interval time.Duration, job func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Background job failed", "job", name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// openAccessLog opens the access log destination: "stdout", "stderr" or a
+// file path, appended to
+func openAccessLog(path string) (io.WriteCloser, error) {
+	switch path {
+	case "stdout":
+		return nopCloser{os.Stdout}, nil
+	case "stderr":
+		return nopCloser{os.Stderr}, nil
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+}
+
+// nopCloser keeps the standard streams open when the access log is closed
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSConfig configures HTTPS. The server speaks plain HTTP unless a
+// certificate and key or ACME domains are set.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ACMEDomains are the host names to get certificates for from an ACME
+	// CA, Let's Encrypt unless ACMEDirectoryURL says otherwise
+	ACMEDomains      []string
+	ACMEEmail        string
+	ACMECacheDir     string
+	ACMEDirectoryURL string
+	// RedirectPort, when set, serves plain HTTP on that port, redirecting
+	// to HTTPS and answering ACME HTTP-01 challenges
+	RedirectPort string
+}
+
+// Enabled reports whether the server serves HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != "" || len(c.ACMEDomains) > 0
+}
+
+// validate returns one error per invalid setting
+func (c TLSConfig) validate() []error {
+	var errs []error
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE: TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if c.CertFile != "" && len(c.ACMEDomains) > 0 {
+		errs = append(errs, errors.New("ACME_DOMAINS: can't be combined with TLS_CERT_FILE"))
+	}
+	if c.RedirectPort != "" && !c.Enabled() {
+		errs = append(errs, errors.New("HTTP_REDIRECT_PORT: needs TLS_CERT_FILE or ACME_DOMAINS"))
+	}
+	return errs
+}
+
+// setupTLS returns the TLS settings of the server and the handler of the
+// redirect listener, or nils when HTTPS is off. Certificate files are loaded
+// now, so a bad pair stops the server at startup.
+func setupTLS(cfg TLSConfig, httpsPort string) (*tls.Config, http.Handler, error) {
+	if !cfg.Enabled() {
+		return nil, nil, nil
+	}
+	redirect := redirectToHTTPS(httpsPort)
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, redirect, nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+		Cache:      autocert.DirCache(cfg.ACMECacheDir),
+		Email:      cfg.ACMEEmail,
+	}
+	if cfg.ACMEDirectoryURL != "" {
+		manager.Client = &acme.Client{DirectoryURL: cfg.ACMEDirectoryURL}
+	}
+	tlsConfig := manager.TLSConfig()
+	tlsConfig.MinVersion = tls.VersionTLS12
+	return tlsConfig, manager.HTTPHandler(redirect), nil
+}
+
+// redirectToHTTPS redirects every request to the same URL over HTTPS on
+// port. GETs are redirected with 301; other methods with 308, which keeps
+// the method and body.
+func redirectToHTTPS(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate and its key to dir
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestSetupTLS(t *testing.T) {
+	if tlsConfig, redirect, err := setupTLS(TLSConfig{}, "8443"); tlsConfig != nil || redirect != nil || err != nil {
+		t.Errorf("expected HTTPS to be off without settings")
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir)
+	tlsConfig, redirect, err := setupTLS(TLSConfig{CertFile: certFile, KeyFile: keyFile}, "8443")
+	if err != nil {
+		t.Fatalf("setupTLS: %v", err)
+	}
+	if len(tlsConfig.Certificates) != 1 || redirect == nil {
+		t.Errorf("expected the certificate and a redirect handler")
+	}
+
+	if _, _, err := setupTLS(TLSConfig{CertFile: certFile, KeyFile: certFile}, "8443"); err == nil {
+		t.Error("expected an error for a mismatched key")
+	}
+
+	tlsConfig, redirect, err = setupTLS(TLSConfig{ACMEDomains: []string{"payforward.example"}, ACMECacheDir: dir}, "443")
+	if err != nil {
+		t.Fatalf("setupTLS with ACME: %v", err)
+	}
+	if tlsConfig.GetCertificate == nil || !slices.Contains(tlsConfig.NextProtos, "acme-tls/1") {
+		t.Error("expected certificates from the ACME manager")
+	}
+
+	// The redirect listener answers HTTP-01 challenges itself
+	rec := httptest.NewRecorder()
+	redirect.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://payforward.example/api/health", nil))
+	if rec.Code != http.StatusMovedPermanently {
+		t.Errorf("status = %d, want 301", rec.Code)
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		port, method, url, want string
+		status                  int
+	}{
+		{"443", http.MethodGet, "http://example.org/acts?page=2", "https://example.org/acts?page=2", http.StatusMovedPermanently},
+		{"443", http.MethodGet, "http://example.org:8080/", "https://example.org/", http.StatusMovedPermanently},
+		{"8443", http.MethodHead, "http://example.org/", "https://example.org:8443/", http.StatusMovedPermanently},
+		{"8443", http.MethodGet, "http://[::1]:8080/x", "https://[::1]:8443/x", http.StatusMovedPermanently},
+		{"443", http.MethodPost, "http://example.org/api/v1/acts", "https://example.org/api/v1/acts", http.StatusPermanentRedirect},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		redirectToHTTPS(tt.port).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.url, nil))
+		if rec.Code != tt.status || rec.Header().Get("Location") != tt.want {
+			t.Errorf("%s %s: got %d %s, want %d %s", tt.method, tt.url, rec.Code, rec.Header().Get("Location"), tt.status, tt.want)
+		}
+	}
+}
+
+func TestTLSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  TLSConfig
+		errs int
+	}{
+		{"off", TLSConfig{}, 0},
+		{"files", TLSConfig{CertFile: "c", KeyFile: "k", RedirectPort: "80"}, 0},
+		{"acme", TLSConfig{ACMEDomains: []string{"a.example"}}, 0},
+		{"cert without key", TLSConfig{CertFile: "c"}, 1},
+		{"files and acme", TLSConfig{CertFile: "c", KeyFile: "k", ACMEDomains: []string{"a.example"}}, 1},
+		{"redirect without tls", TLSConfig{RedirectPort: "80"}, 1},
+	}
+	for _, tt := range tests {
+		if errs := tt.cfg.validate(); len(errs) != tt.errs {
+			t.Errorf("%s: got errors %v, want %d", tt.name, errs, tt.errs)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package analytics
+
+import (
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+const week = 7 * 24 * time.Hour
+
+// UserActivity is the raw input for retention: when a user signed up and when
+// they performed acts
+type UserActivity struct {
+	SignedUpAt time.Time
+	ActTimes   []time.Time
+}
+
+// WeekStart returns the Monday 00:00 UTC starting the week containing t
+func WeekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -offset)
+}
+
+// ComputeRetention groups users by signup week and computes, for each of the
+// following weeks (up to weeks), the fraction of the cohort that performed at
+// least one act in that week. Week 0 is the signup week itself. Weeks that
+// have not started yet relative to now are omitted from a cohort's row.
+func ComputeRetention(users []UserActivity, weeks int, now time.Time) []models.RetentionCohort {
+	type cohortData struct {
+		size   int
+		active []map[int]struct{}
+	}
+
+	cohorts := make(map[time.Time]*cohortData)
+
+	for i, user := range users {
+		start := WeekStart(user.SignedUpAt)
+		data, ok := cohorts[start]
+		if !ok {
+			data = &cohortData{active: make([]map[int]struct{}, weeks+1)}
+			for w := range data.active {
+				data.active[w] = make(map[int]struct{})
+			}
+			cohorts[start] = data
+		}
+		data.size++
+
+		for _, actTime := range user.ActTimes {
+			if actTime.Before(start) {
+				continue
+			}
+			offset := int(actTime.Sub(start) / week)
+			if offset <= weeks {
+				data.active[offset][i] = struct{}{}
+			}
+		}
+	}
+
+	starts := make([]time.Time, 0, len(cohorts))
+	for start := range cohorts {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	result := make([]models.RetentionCohort, 0, len(starts))
+	for _, start := range starts {
+		data := cohorts[start]
+		row := models.RetentionCohort{WeekStart: start, Size: data.size, Retention: []float64{}}
+
+		for w := 0; w <= weeks; w++ {
+			if start.Add(time.Duration(w) * week).After(now) {
+				break
+			}
+			row.Retention = append(row.Retention, float64(len(data.active[w]))/float64(data.size))
+		}
+
+		result = append(result, row)
+	}
+
+	return result
+}
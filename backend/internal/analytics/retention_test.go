@@ -0,0 +1,67 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekStart(t *testing.T) {
+	// Wednesday 2024-01-10 -> Monday 2024-01-08
+	got := WeekStart(time.Date(2024, 1, 10, 15, 30, 0, 0, time.UTC))
+	expected := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// Sunday belongs to the week that started the previous Monday
+	got = WeekStart(time.Date(2024, 1, 14, 23, 0, 0, 0, time.UTC))
+	if !got.Equal(expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestComputeRetention(t *testing.T) {
+	monday := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	users := []UserActivity{
+		{
+			SignedUpAt: monday.Add(day),
+			ActTimes:   []time.Time{monday.Add(2 * day), monday.Add(8 * day), monday.Add(9 * day)},
+		},
+		{
+			SignedUpAt: monday.Add(2 * day),
+			ActTimes:   []time.Time{monday.Add(15 * day)},
+		},
+		{
+			SignedUpAt: monday.Add(7 * day),
+		},
+	}
+
+	now := monday.Add(16 * day)
+	cohorts := ComputeRetention(users, 4, now)
+
+	if len(cohorts) != 2 {
+		t.Fatalf("expected 2 cohorts, got %d", len(cohorts))
+	}
+
+	first := cohorts[0]
+	if !first.WeekStart.Equal(monday) || first.Size != 2 {
+		t.Fatalf("unexpected first cohort %+v", first)
+	}
+
+	expected := []float64{0.5, 0.5, 0.5}
+	if len(first.Retention) != len(expected) {
+		t.Fatalf("expected %d weeks, got %v", len(expected), first.Retention)
+	}
+	for i, v := range expected {
+		if first.Retention[i] != v {
+			t.Errorf("week %d: expected %v, got %v", i, v, first.Retention[i])
+		}
+	}
+
+	second := cohorts[1]
+	if second.Size != 1 || len(second.Retention) != 2 || second.Retention[0] != 0 {
+		t.Errorf("unexpected second cohort %+v", second)
+	}
+}
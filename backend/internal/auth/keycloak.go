@@ -12,9 +12,15 @@ import (
 	"sync"
 	"time"
 
+	"payforwardnow/internal/version"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// httpClient makes the requests to Keycloak, identifying the server in their
+// User-Agent
+var httpClient = &http.Client{Transport: version.NewTransport(nil)}
+
 type KeycloakAuth struct {
 	realm        string
 	serverURL    string
@@ -22,6 +28,9 @@ type KeycloakAuth struct {
 	clientSecret string
 	publicKeys   map[string]*rsa.PublicKey
 	mu           sync.RWMutex
+
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
 type JWKSResponse struct {
@@ -60,6 +69,7 @@ func NewKeycloakAuth(serverURL, realm, clientID, clientSecret string) *KeycloakA
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		publicKeys:   make(map[string]*rsa.PublicKey),
+		stop:         make(chan struct{}),
 	}
 
 	// Load public keys on initialization
@@ -69,26 +79,29 @@ func NewKeycloakAuth(serverURL, realm, clientID, clientSecret string) *KeycloakA
 	go func() {
 		ticker := time.NewTicker(1 * time.Hour)
 		defer ticker.Stop()
-		for range ticker.C {
-			ka.refreshPublicKeys()
+		for {
+			select {
+			case <-ka.stop:
+				return
+			case <-ticker.C:
+				ka.refreshPublicKeys()
+			}
 		}
 	}()
 
 	return ka
 }
 
-func (ka *KeycloakAuth) refreshPublicKeys() error {
-	url := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/certs", ka.serverURL, ka.realm)
+// Close stops refreshing the realm's public keys
+func (ka *KeycloakAuth) Close() error {
+	ka.closeOnce.Do(func() { close(ka.stop) })
+	return nil
+}
 
-	resp, err := http.Get(url)
+func (ka *KeycloakAuth) refreshPublicKeys() error {
+	jwks, err := ka.fetchJWKS(context.Background())
 	if err != nil {
-		return fmt.Errorf("failed to fetch JWKS: %w", err)
-	}
-	defer resp.Body.Close()
-
-	var jwks JWKSResponse
-	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
-		return fmt.Errorf("failed to decode JWKS: %w", err)
+		return err
 	}
 
 	ka.mu.Lock()
@@ -107,6 +120,69 @@ func (ka *KeycloakAuth) refreshPublicKeys() error {
 	return nil
 }
 
+// fetchJWKS fetches the realm's public keys
+func (ka *KeycloakAuth) fetchJWKS(ctx context.Context) (JWKSResponse, error) {
+	var jwks JWKSResponse
+	resp, err := ka.get(ctx, "/protocol/openid-connect/certs")
+	if err != nil {
+		return jwks, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return jwks, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+	return jwks, nil
+}
+
+// get requests path under the realm's URL, failing on any status but 200
+func (ka *KeycloakAuth) get(ctx context.Context, path string) (*http.Response, error) {
+	url := fmt.Sprintf("%s/realms/%s%s", ka.serverURL, ka.realm, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// CheckRealm verifies that the realm's OpenID configuration can be fetched,
+// for diagnostics
+func (ka *KeycloakAuth) CheckRealm(ctx context.Context) error {
+	resp, err := ka.get(ctx, "/.well-known/openid-configuration")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// CheckKeys fetches the realm's JWKS and returns how many of its keys can
+// verify tokens, for diagnostics. The keys in use are left alone.
+func (ka *KeycloakAuth) CheckKeys(ctx context.Context) (int, error) {
+	jwks, err := ka.fetchJWKS(ctx)
+	if err != nil {
+		return 0, err
+	}
+	usable := 0
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" || key.Use != "sig" {
+			continue
+		}
+		if _, err := ka.parseRSAPublicKey(key); err == nil {
+			usable++
+		}
+	}
+	return usable, nil
+}
+
 func (ka *KeycloakAuth) parseRSAPublicKey(jwk JWK) (*rsa.PublicKey, error) {
 	nBytes, err := base64.RawURLEncoding.DecodeString(jwk.N)
 	if err != nil {
@@ -226,7 +302,7 @@ func (ka *KeycloakAuth) GetUserInfo(ctx context.Context, accessToken string) (ma
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -244,6 +320,11 @@ func (ka *KeycloakAuth) GetUserInfo(ctx context.Context, accessToken string) (ma
 	return userInfo, nil
 }
 
+// ClientID returns the Keycloak client ID this instance validates tokens for
+func (ka *KeycloakAuth) ClientID() string {
+	return ka.clientID
+}
+
 func (ka *KeycloakAuth) HasRole(claims *KeycloakClaims, role string) bool {
 	// Check realm roles
 	for _, r := range claims.RealmAccess.Roles {
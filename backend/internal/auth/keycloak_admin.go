@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrKeycloakUserExists is returned by CreateUser when the username or email
+// is already taken in the realm
+var ErrKeycloakUserExists = errors.New("keycloak user already exists")
+
+// KeycloakUser is a user to create through the Keycloak admin API
+type KeycloakUser struct {
+	Email     string
+	FirstName string
+	LastName  string
+	Password  string
+}
+
+// CreateUser creates an enabled user with a verified email and a permanent
+// password, grants it the given realm roles and returns its ID. The client
+// signs in with its own credentials, so it needs a service account with the
+// realm-management manage-users and view-realm roles.
+func (ka *KeycloakAuth) CreateUser(ctx context.Context, user KeycloakUser, realmRoles ...string) (string, error) {
+	token, err := ka.serviceToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	body := map[string]interface{}{
+		"username":      user.Email,
+		"email":         user.Email,
+		"firstName":     user.FirstName,
+		"lastName":      user.LastName,
+		"enabled":       true,
+		"emailVerified": true,
+		"credentials": []map[string]interface{}{
+			{"type": "password", "value": user.Password, "temporary": false},
+		},
+	}
+	resp, err := ka.adminRequest(ctx, token, http.MethodPost, "users", body)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+	case http.StatusConflict:
+		return "", ErrKeycloakUserExists
+	default:
+		return "", fmt.Errorf("failed to create keycloak user: status %d", resp.StatusCode)
+	}
+
+	// The new user's ID is only given by the Location header
+	id := path.Base(resp.Header.Get("Location"))
+	if id == "" || id == "." || id == "/" {
+		return "", fmt.Errorf("keycloak didn't return the new user's location")
+	}
+
+	roles := make([]json.RawMessage, 0, len(realmRoles))
+	for _, name := range realmRoles {
+		resp, err := ka.adminRequest(ctx, token, http.MethodGet, "roles/"+url.PathEscape(name), nil)
+		if err != nil {
+			return id, err
+		}
+		role, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return id, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return id, fmt.Errorf("failed to look up realm role %q: status %d", name, resp.StatusCode)
+		}
+		roles = append(roles, role)
+	}
+	if len(roles) > 0 {
+		resp, err := ka.adminRequest(ctx, token, http.MethodPost, "users/"+url.PathEscape(id)+"/role-mappings/realm", roles)
+		if err != nil {
+			return id, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+			return id, fmt.Errorf("failed to grant realm roles: status %d", resp.StatusCode)
+		}
+	}
+	return id, nil
+}
+
+// serviceToken gets an access token for the client's service account
+func (ka *KeycloakAuth) serviceToken(ctx context.Context) (string, error) {
+	tokenURL := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", ka.serverURL, ka.realm)
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {ka.clientID},
+		"client_secret": {ka.clientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get service account token: status %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// adminRequest calls the admin API of the realm at the relative path
+func (ka *KeycloakAuth) adminRequest(ctx context.Context, token, method, relPath string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	adminURL := fmt.Sprintf("%s/admin/realms/%s/%s", ka.serverURL, ka.realm, relPath)
+	req, err := http.NewRequestWithContext(ctx, method, adminURL, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return httpClient.Do(req)
+}
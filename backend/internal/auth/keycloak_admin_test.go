@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeKeycloak serves the admin API endpoints CreateUser uses
+func fakeKeycloak(t *testing.T, existing string) (*KeycloakAuth, *[]string) {
+	t.Helper()
+	var granted []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /realms/test/protocol/openid-connect/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "service-token"})
+	})
+	mux.HandleFunc("POST /admin/realms/test/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer service-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var user map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&user)
+		if user["email"] == existing {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.Header().Set("Location", "http://"+r.Host+"/admin/realms/test/users/kc-123")
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /admin/realms/test/roles/{role}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"id": "role-" + r.PathValue("role"), "name": r.PathValue("role")})
+	})
+	mux.HandleFunc("POST /admin/realms/test/users/kc-123/role-mappings/realm", func(w http.ResponseWriter, r *http.Request) {
+		var roles []map[string]string
+		json.NewDecoder(r.Body).Decode(&roles)
+		for _, role := range roles {
+			granted = append(granted, role["name"])
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return &KeycloakAuth{serverURL: server.URL, realm: "test", clientID: "api", clientSecret: "secret"}, &granted
+}
+
+func TestCreateUser(t *testing.T) {
+	ka, granted := fakeKeycloak(t, "taken@example.com")
+	ctx := context.Background()
+
+	id, err := ka.CreateUser(ctx, KeycloakUser{Email: "admin@example.com", Password: "pw"}, "admin")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if id != "kc-123" {
+		t.Errorf("id = %q, want kc-123", id)
+	}
+	if len(*granted) != 1 || (*granted)[0] != "admin" {
+		t.Errorf("granted roles = %v, want [admin]", *granted)
+	}
+
+	if _, err := ka.CreateUser(ctx, KeycloakUser{Email: "taken@example.com", Password: "pw"}); !errors.Is(err, ErrKeycloakUserExists) {
+		t.Errorf("expected ErrKeycloakUserExists, got %v", err)
+	}
+
+	ka.clientSecret = "wrong"
+	if _, err := ka.CreateUser(ctx, KeycloakUser{Email: "other@example.com", Password: "pw"}); err == nil {
+		t.Error("expected an error with bad client credentials")
+	}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckRealmAndKeys(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /realms/test/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": "http://" + r.Host + "/realms/test"})
+	})
+	mux.HandleFunc("GET /realms/test/protocol/openid-connect/certs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(JWKSResponse{Keys: []JWK{
+			{Kid: "sig", Kty: "RSA", Use: "sig", N: "sXchDaQebHnPiGvyDOAT4saGEUetSyo9MKLOoWFsueri23bOdgWp4Dy1WlUzewbgBHod5pcM9H95GQRV3JDXboIRROSBigeC5yjU1hGzHHyXss8UDprecbAYxknTcQkhslANGRUZmdTOQ5qTRsLAt6BTYuyvVRdhS8exSZEy_c4gs_7svlJJQ4H9_NxsiIoLwAEk7-Q3UXERGYw_75IDrGA84-lA_-Ct4eTlXHBIY2EaV7t7LjJaynVJCpkv4LKjTTAumiGUIuQhrNhZLuF_RJLqHpM2kgWFLU7-VTdL1VbC2tejvcI2BlMkEpk1BzBZI0KQB0GaDWFLN-aEAw3vRw", E: "AQAB"},
+			{Kid: "enc", Kty: "RSA", Use: "enc", N: "AQAB", E: "AQAB"},
+			{Kid: "broken", Kty: "RSA", Use: "sig", N: "not base64!", E: "AQAB"},
+		}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	ctx := context.Background()
+
+	ka := &KeycloakAuth{serverURL: server.URL, realm: "test"}
+	if err := ka.CheckRealm(ctx); err != nil {
+		t.Errorf("CheckRealm: %v", err)
+	}
+	if n, err := ka.CheckKeys(ctx); err != nil || n != 1 {
+		t.Errorf("CheckKeys = %d, %v; want the 1 usable signing key", n, err)
+	}
+
+	missing := &KeycloakAuth{serverURL: server.URL, realm: "missing"}
+	if err := missing.CheckRealm(ctx); err == nil {
+		t.Error("expected an unknown realm to fail")
+	}
+	if _, err := missing.CheckKeys(ctx); err == nil {
+		t.Error("expected the keys of an unknown realm to fail")
+	}
+}
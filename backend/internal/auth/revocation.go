@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"payforwardnow/internal/cache"
+)
+
+// Revocations tracks access tokens invalidated by logging out before they
+// expire. Only token hashes are stored, and each entry lasts until the token
+// would have expired anyway.
+type Revocations struct {
+	store cache.Store
+}
+
+// NewRevocations creates a revocation list kept in store
+func NewRevocations(store cache.Store) *Revocations {
+	return &Revocations{store: store}
+}
+
+// Revoke invalidates token until expiresAt
+func (r *Revocations) Revoke(ctx context.Context, token string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return r.store.Set(ctx, revocationKey(token), []byte{1}, ttl)
+}
+
+// Revoked reports whether token has been revoked
+func (r *Revocations) Revoked(ctx context.Context, token string) (bool, error) {
+	_, ok, err := r.store.Get(ctx, revocationKey(token))
+	return ok, err
+}
+
+func revocationKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "revoked:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/cache"
+)
+
+func TestRevocations(t *testing.T) {
+	ctx := context.Background()
+	revocations := NewRevocations(cache.NewLRU(10))
+
+	if err := revocations.Revoke(ctx, "token-a", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	// Already expired tokens don't need an entry
+	if err := revocations.Revoke(ctx, "token-b", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if revoked, _ := revocations.Revoked(ctx, "token-a"); !revoked {
+		t.Error("expected token-a to be revoked")
+	}
+	if revoked, _ := revocations.Revoked(ctx, "token-b"); revoked {
+		t.Error("expected token-b not to be stored")
+	}
+	if revoked, _ := revocations.Revoked(ctx, "token-c"); revoked {
+		t.Error("expected token-c not to be revoked")
+	}
+}
@@ -0,0 +1,173 @@
+// Package backup exports the graph, or the nodes with some labels, to
+// newline-delimited JSON or Cypher and imports it again. It relies only on
+// plain Cypher, so it works on databases without APOC.
+//
+// Nodes are exported with the element ID they had in the source database.
+// On import they are created with a temporary _BackupImport label and
+// _backupId property, indexed, so relationships can find their ends; both
+// are removed once the import finishes. Schema version nodes are left out,
+// since the target database is expected to be migrated before importing.
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Format is the encoding of a backup
+type Format string
+
+const (
+	// FormatJSON writes one JSON record per line, nodes first
+	FormatJSON Format = "ndjson"
+	// FormatCypher writes one Cypher statement per line, which can also be
+	// replayed with cypher-shell
+	FormatCypher Format = "cypher"
+)
+
+// ParseFormat returns the format named s
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case FormatJSON, FormatCypher:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("unknown backup format %q; use %s or %s", s, FormatJSON, FormatCypher)
+}
+
+// Record types
+const (
+	typeNode         = "node"
+	typeRelationship = "relationship"
+)
+
+// Record is a node or relationship in a backup. Properties hold driver
+// values, such as int64 and time.Time.
+type Record struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id,omitempty"`
+	Labels     []string       `json:"labels,omitempty"`
+	Label      string         `json:"label,omitempty"`
+	Start      string         `json:"start,omitempty"`
+	End        string         `json:"end,omitempty"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Summary counts the nodes and relationships in a backup
+type Summary struct {
+	Nodes         int
+	Relationships int
+}
+
+// Names used while importing
+const (
+	importLabel    = "_BackupImport"
+	importProperty = "_backupId"
+	importIndex    = "backup_import"
+	schemaLabel    = "SchemaVersion"
+)
+
+// batchSize is the number of records written per transaction on import
+const batchSize = 500
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writer encodes records in one format
+type writer interface {
+	write(rec Record) error
+	close() error
+}
+
+func newWriter(w io.Writer, format Format) (writer, error) {
+	buf := bufio.NewWriter(w)
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		return &jsonWriter{buf: buf, enc: enc}, nil
+	case FormatCypher:
+		cw := &cypherWriter{buf: buf}
+		return cw, cw.header()
+	}
+	return nil, fmt.Errorf("unknown backup format %q", format)
+}
+
+type jsonWriter struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *jsonWriter) write(rec Record) error {
+	props := make(map[string]any, len(rec.Properties))
+	for key, value := range rec.Properties {
+		encoded, err := encodeValue(value)
+		if err != nil {
+			return fmt.Errorf("%s %s, property %q: %w", rec.Type, rec.ID, key, err)
+		}
+		props[key] = encoded
+	}
+	rec.Properties = props
+	return w.enc.Encode(rec)
+}
+
+func (w *jsonWriter) close() error {
+	return w.buf.Flush()
+}
+
+type cypherWriter struct {
+	buf *bufio.Writer
+}
+
+func (w *cypherWriter) header() error {
+	_, err := fmt.Fprintf(w.buf, "CREATE INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.%s);\nCALL db.awaitIndexes(300);\n",
+		importIndex, importLabel, importProperty)
+	return err
+}
+
+func (w *cypherWriter) write(rec Record) error {
+	props, err := cypherMap(rec.Properties)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", rec.Type, rec.ID, err)
+	}
+
+	if rec.Type == typeNode {
+		labels := ""
+		for _, label := range rec.Labels {
+			labels += ":" + identifier(label)
+		}
+		_, err = fmt.Fprintf(w.buf, "CREATE (n%s:%s) SET n = %s, n.%s = %s;\n",
+			labels, importLabel, props, importProperty, quote(rec.ID))
+		return err
+	}
+
+	_, err = fmt.Fprintf(w.buf, "MATCH (a:%s {%s: %s}), (b:%s {%s: %s}) CREATE (a)-[:%s %s]->(b);\n",
+		importLabel, importProperty, quote(rec.Start), importLabel, importProperty, quote(rec.End),
+		identifier(rec.Label), props)
+	return err
+}
+
+func (w *cypherWriter) close() error {
+	_, err := fmt.Fprintf(w.buf, "MATCH (n:%s) REMOVE n:%s, n.%s;\nDROP INDEX %s IF EXISTS;\n",
+		importLabel, importLabel, importProperty, importIndex)
+	if err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
+
+// labelFilter returns a Cypher predicate on the node bound to variable
+// that excludes schema version nodes and, when $labels is not empty, nodes
+// without any of the listed labels
+func labelFilter(variable string) string {
+	return fmt.Sprintf("NOT %[1]s:%[2]s AND ($labels = [] OR any(l IN labels(%[1]s) WHERE l IN $labels))",
+		variable, schemaLabel)
+}
@@ -0,0 +1,160 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+func TestValues_JSONRoundTrip(t *testing.T) {
+	created := time.Date(2025, 3, 4, 5, 6, 7, 890, time.FixedZone("", 3600))
+	props := map[string]any{
+		"name":      "Ann \"the\" <giver>",
+		"verified":  true,
+		"count":     int64(42),
+		"value":     float64(3),
+		"ratio":     0.25,
+		"infinite":  math.Inf(1),
+		"createdAt": created,
+		"day":       dbtype.Date(time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)),
+		"local":     dbtype.LocalDateTime(time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)),
+		"duration":  dbtype.Duration{Months: 1, Days: 2, Seconds: 3, Nanos: 4},
+		"point":     dbtype.Point2D{SpatialRefId: 4326, X: 12.5, Y: 41.9},
+		"tags":      []any{"food", int64(1), 1.5},
+		"empty":     nil,
+	}
+
+	var buf bytes.Buffer
+	w, err := newWriter(&buf, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.write(Record{Type: typeNode, ID: "4:abc:1", Labels: []string{"User"}, Properties: props}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	dec.UseNumber()
+	var rec Record
+	if err := dec.Decode(&rec); err != nil {
+		t.Fatal(err)
+	}
+	if err := decodeProperties(&rec); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range props {
+		got := rec.Properties[key]
+		if wantTime, ok := want.(time.Time); ok {
+			if gotTime, ok := got.(time.Time); !ok || !gotTime.Equal(wantTime) {
+				t.Errorf("%s: expected %v, got %v", key, want, got)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %#v, got %#v", key, want, got)
+		}
+	}
+}
+
+func TestValues_Unsupported(t *testing.T) {
+	if _, err := decodeValue(map[string]any{"$unknown": "x"}); err == nil {
+		t.Error("expected an unknown tag to fail")
+	}
+	if _, err := cypherLiteral([]byte("raw")); err == nil {
+		t.Error("expected byte arrays to have no Cypher literal")
+	}
+}
+
+func TestCypherLiteral(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, "null"},
+		{"say \"hi\"\n\\", `"say \"hi\"\n\\"`},
+		{"bell\a", `"bell\u0007"`},
+		{int64(-7), "-7"},
+		{float64(2), "2.0"},
+		{math.NaN(), "0.0/0.0"},
+		{time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), `datetime("2025-01-02T03:04:05Z")`},
+		{dbtype.Date(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)), `date("2025-01-02")`},
+		{dbtype.Duration{Days: 3}, "duration({months: 0, days: 3, seconds: 0, nanoseconds: 0})"},
+		{[]any{"a", int64(1)}, `["a", 1]`},
+	}
+	for _, tt := range tests {
+		got, err := cypherLiteral(tt.value)
+		if err != nil {
+			t.Errorf("%#v: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%#v: expected %s, got %s", tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestCypherWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newWriter(&buf, FormatCypher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []Record{
+		{Type: typeNode, ID: "n1", Labels: []string{"User"}, Properties: map[string]any{"id": "u1", "name": "Ann"}},
+		{Type: typeNode, ID: "n2", Labels: []string{"Act"}, Properties: map[string]any{"id": "a1"}},
+		{Type: typeRelationship, ID: "r1", Label: "GAVE", Start: "n1", End: "n2", Properties: map[string]any{}},
+	}
+	for _, rec := range records {
+		if err := w.write(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"CREATE INDEX backup_import IF NOT EXISTS FOR (n:_BackupImport) ON (n._backupId);",
+		"CALL db.awaitIndexes(300);",
+		"CREATE (n:`User`:_BackupImport) SET n = {`id`: \"u1\", `name`: \"Ann\"}, n._backupId = \"n1\";",
+		"CREATE (n:`Act`:_BackupImport) SET n = {`id`: \"a1\"}, n._backupId = \"n2\";",
+		"MATCH (a:_BackupImport {_backupId: \"n1\"}), (b:_BackupImport {_backupId: \"n2\"}) CREATE (a)-[:`GAVE` {}]->(b);",
+		"MATCH (n:_BackupImport) REMOVE n:_BackupImport, n._backupId;",
+		"DROP INDEX backup_import IF EXISTS;",
+	}
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected statements:\n%s", buf.String())
+	}
+
+	for _, line := range got {
+		if isSchemaStatement(strings.TrimSuffix(line, ";")) != (strings.Contains(line, "INDEX") || strings.HasPrefix(line, "CALL")) {
+			t.Errorf("misclassified statement %q", line)
+		}
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	if got := identifier("we`ird"); got != "`we``ird`" {
+		t.Errorf("expected backticks to be doubled, got %s", got)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("cypher"); err != nil || f != FormatCypher {
+		t.Errorf("expected cypher, got %q, %v", f, err)
+	}
+	if _, err := ParseFormat("csv"); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+}
@@ -0,0 +1,118 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"payforwardnow/internal/database"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ExportOptions selects what Export writes
+type ExportOptions struct {
+	// Labels limits the export to nodes with any of these labels and the
+	// relationships between them. Empty exports the whole graph.
+	Labels []string
+}
+
+// Export writes the nodes and then the relationships selected by opts to w.
+// Both are streamed from a single read session, so large graphs aren't held
+// in memory.
+func Export(ctx context.Context, db database.DBClient, w io.Writer, format Format, opts ExportOptions) (Summary, error) {
+	out, err := newWriter(w, format)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	session := db.ReadSession(ctx)
+	defer session.Close(ctx)
+
+	labels := opts.Labels
+	if labels == nil {
+		labels = []string{}
+	}
+	params := map[string]any{"labels": labels}
+
+	var summary Summary
+	nodesQuery := fmt.Sprintf(`
+		MATCH (n) WHERE %s
+		RETURN elementId(n) AS id, labels(n) AS labels, properties(n) AS properties
+	`, labelFilter("n"))
+	summary.Nodes, err = exportQuery(ctx, session, nodesQuery, params, out, nodeRecord)
+	if err != nil {
+		return summary, fmt.Errorf("failed to export nodes: %w", err)
+	}
+
+	relationshipsQuery := fmt.Sprintf(`
+		MATCH (a)-[r]->(b) WHERE %s AND %s
+		RETURN elementId(r) AS id, type(r) AS label, elementId(a) AS start, elementId(b) AS end,
+			properties(r) AS properties
+	`, labelFilter("a"), labelFilter("b"))
+	summary.Relationships, err = exportQuery(ctx, session, relationshipsQuery, params, out, relationshipRecord)
+	if err != nil {
+		return summary, fmt.Errorf("failed to export relationships: %w", err)
+	}
+
+	return summary, out.close()
+}
+
+// exportQuery writes every record returned by query and returns how many
+// there were
+func exportQuery(ctx context.Context, session neo4j.SessionWithContext, query string, params map[string]any, out writer, mapper func(*neo4j.Record) (Record, error)) (int, error) {
+	result, err := session.Run(ctx, query, params)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for result.Next(ctx) {
+		rec, err := mapper(result.Record())
+		if err != nil {
+			return n, err
+		}
+		if err := out.write(rec); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, result.Err()
+}
+
+func nodeRecord(record *neo4j.Record) (Record, error) {
+	id, err := database.RecordValue[string](record, "id")
+	if err != nil {
+		return Record{}, err
+	}
+	rawLabels, err := database.RecordValue[[]any](record, "labels")
+	if err != nil {
+		return Record{}, err
+	}
+	props, err := database.RecordValue[map[string]any](record, "properties")
+	if err != nil {
+		return Record{}, err
+	}
+
+	labels := make([]string, 0, len(rawLabels))
+	for _, label := range rawLabels {
+		if s, ok := label.(string); ok {
+			labels = append(labels, s)
+		}
+	}
+	return Record{Type: typeNode, ID: id, Labels: labels, Properties: props}, nil
+}
+
+func relationshipRecord(record *neo4j.Record) (Record, error) {
+	rec := Record{Type: typeRelationship}
+	var err error
+	for key, dst := range map[string]*string{"id": &rec.ID, "label": &rec.Label, "start": &rec.Start, "end": &rec.End} {
+		if *dst, err = database.RecordValue[string](record, key); err != nil {
+			return Record{}, err
+		}
+	}
+	if rec.Properties, err = database.RecordValue[map[string]any](record, "properties"); err != nil {
+		return Record{}, err
+	}
+	return rec, nil
+}
@@ -0,0 +1,315 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"payforwardnow/internal/database"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// maxStatementSize bounds the length of a line in a Cypher backup
+const maxStatementSize = 16 << 20
+
+// Import reads a backup written by Export into db and returns what it
+// created. The database should already be migrated and hold none of the
+// backed up nodes, or the import fails on their uniqueness constraints or
+// duplicates them. Records are written in batches, so a failed import can
+// leave part of the backup behind; restore into an empty database and start
+// over in that case.
+func Import(ctx context.Context, db database.DBClient, r io.Reader, format Format) (Summary, error) {
+	switch format {
+	case FormatJSON:
+		return importJSON(ctx, db, r)
+	case FormatCypher:
+		return importCypher(ctx, db, r)
+	}
+	return Summary{}, fmt.Errorf("unknown backup format %q", format)
+}
+
+func importJSON(ctx context.Context, db database.DBClient, r io.Reader) (Summary, error) {
+	im := &importer{db: db}
+	if err := im.prepare(ctx); err != nil {
+		return Summary{}, err
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for n := 1; ; n++ {
+		var rec Record
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return im.summary, fmt.Errorf("record %d: %w", n, err)
+		}
+		if err := decodeProperties(&rec); err != nil {
+			return im.summary, fmt.Errorf("record %d: %w", n, err)
+		}
+		if err := im.add(ctx, rec); err != nil {
+			return im.summary, fmt.Errorf("record %d: %w", n, err)
+		}
+	}
+
+	if err := im.flush(ctx); err != nil {
+		return im.summary, err
+	}
+	return im.summary, im.finish(ctx)
+}
+
+// decodeProperties converts the JSON property values of rec back to driver
+// values
+func decodeProperties(rec *Record) error {
+	for key, value := range rec.Properties {
+		decoded, err := decodeValue(value)
+		if err != nil {
+			return fmt.Errorf("property %q: %w", key, err)
+		}
+		rec.Properties[key] = decoded
+	}
+	return nil
+}
+
+// importer batches records by label or relationship type, since labels and
+// types can't be parameters
+type importer struct {
+	db            database.DBClient
+	nodes         map[string][]map[string]any
+	relationships map[string][]map[string]any
+	pending       int
+	summary       Summary
+}
+
+// prepare creates the index used to match relationship ends
+func (im *importer) prepare(ctx context.Context) error {
+	for _, statement := range []string{
+		fmt.Sprintf("CREATE INDEX %s IF NOT EXISTS FOR (n:%s) ON (n.%s)", importIndex, importLabel, importProperty),
+		"CALL db.awaitIndexes(300)",
+	} {
+		if err := run(ctx, im.db, statement, nil); err != nil {
+			return fmt.Errorf("failed to create import index: %w", err)
+		}
+	}
+	return nil
+}
+
+func (im *importer) add(ctx context.Context, rec Record) error {
+	switch rec.Type {
+	case typeNode:
+		if rec.ID == "" {
+			return fmt.Errorf("node without an id")
+		}
+		if im.nodes == nil {
+			im.nodes = make(map[string][]map[string]any)
+		}
+		key := strings.Join(rec.Labels, ":")
+		im.nodes[key] = append(im.nodes[key], map[string]any{"id": rec.ID, "properties": rec.Properties})
+	case typeRelationship:
+		if rec.Label == "" || rec.Start == "" || rec.End == "" {
+			return fmt.Errorf("relationship without a type, start or end")
+		}
+		// Every node a relationship may refer to must exist first
+		if len(im.nodes) > 0 {
+			if err := im.flush(ctx); err != nil {
+				return err
+			}
+		}
+		if im.relationships == nil {
+			im.relationships = make(map[string][]map[string]any)
+		}
+		im.relationships[rec.Label] = append(im.relationships[rec.Label], map[string]any{
+			"start":      rec.Start,
+			"end":        rec.End,
+			"properties": rec.Properties,
+		})
+	default:
+		return fmt.Errorf("unknown record type %q", rec.Type)
+	}
+
+	im.pending++
+	if im.pending >= batchSize {
+		return im.flush(ctx)
+	}
+	return nil
+}
+
+// flush writes the pending nodes, then the pending relationships, one
+// transaction per label set or relationship type
+func (im *importer) flush(ctx context.Context) error {
+	for key, rows := range im.nodes {
+		labels := ""
+		if key != "" {
+			for _, label := range strings.Split(key, ":") {
+				labels += ":" + identifier(label)
+			}
+		}
+		query := fmt.Sprintf(`
+			UNWIND $rows AS row
+			CREATE (n%s:%s)
+			SET n = row.properties, n.%s = row.id
+		`, labels, importLabel, importProperty)
+		if err := run(ctx, im.db, query, map[string]any{"rows": rows}); err != nil {
+			return fmt.Errorf("failed to import nodes: %w", err)
+		}
+		im.summary.Nodes += len(rows)
+	}
+
+	for label, rows := range im.relationships {
+		query := fmt.Sprintf(`
+			UNWIND $rows AS row
+			MATCH (a:%[1]s {%[2]s: row.start}), (b:%[1]s {%[2]s: row.end})
+			CREATE (a)-[r:%[3]s]->(b)
+			SET r = row.properties
+			RETURN count(r) AS created
+		`, importLabel, importProperty, identifier(label))
+		created, err := database.ExecuteWrite(ctx, im.db, func(tx neo4j.ManagedTransaction) (int64, error) {
+			result, err := tx.Run(ctx, query, map[string]any{"rows": rows})
+			if err != nil {
+				return 0, err
+			}
+			record, err := result.Single(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return database.RecordValue[int64](record, "created")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to import relationships: %w", err)
+		}
+		im.summary.Relationships += int(created)
+	}
+
+	im.nodes, im.relationships, im.pending = nil, nil, 0
+	return nil
+}
+
+// finish removes the import label and IDs, a batch at a time, and then
+// their index
+func (im *importer) finish(ctx context.Context) error {
+	query := fmt.Sprintf(`
+		MATCH (n:%[1]s)
+		WITH n LIMIT %[3]d
+		REMOVE n:%[1]s, n.%[2]s
+		RETURN count(n) AS cleaned
+	`, importLabel, importProperty, batchSize)
+	for {
+		cleaned, err := database.ExecuteWrite(ctx, im.db, func(tx neo4j.ManagedTransaction) (int64, error) {
+			result, err := tx.Run(ctx, query, nil)
+			if err != nil {
+				return 0, err
+			}
+			record, err := result.Single(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return database.RecordValue[int64](record, "cleaned")
+		})
+		if err != nil {
+			return fmt.Errorf("failed to clean up import markers: %w", err)
+		}
+		if cleaned == 0 {
+			break
+		}
+	}
+
+	if err := run(ctx, im.db, fmt.Sprintf("DROP INDEX %s IF EXISTS", importIndex), nil); err != nil {
+		return fmt.Errorf("failed to drop import index: %w", err)
+	}
+	return nil
+}
+
+// importCypher replays a Cypher backup, one statement per line. Schema
+// statements run on their own, since Neo4j doesn't allow them in the same
+// transaction as writes; the rest run batchSize to a transaction.
+func importCypher(ctx context.Context, db database.DBClient, r io.Reader) (Summary, error) {
+	var (
+		summary Summary
+		batch   []string
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		statements := batch
+		batch = nil
+		_, err := db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			for _, statement := range statements {
+				result, err := tx.Run(ctx, statement, nil)
+				if err != nil {
+					return nil, err
+				}
+				if _, err := result.Consume(ctx); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		})
+		return err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxStatementSize)
+	for line := 1; scanner.Scan(); line++ {
+		statement := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ";")
+		if statement == "" || strings.HasPrefix(statement, "//") {
+			continue
+		}
+
+		if isSchemaStatement(statement) {
+			if err := flush(); err != nil {
+				return summary, fmt.Errorf("line %d: %w", line, err)
+			}
+			if err := run(ctx, db, statement, nil); err != nil {
+				return summary, fmt.Errorf("line %d: %w", line, err)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(statement, "CREATE (n"):
+			summary.Nodes++
+		case strings.HasPrefix(statement, "MATCH (a:"+importLabel):
+			summary.Relationships++
+		}
+		batch = append(batch, statement)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return summary, fmt.Errorf("line %d: %w", line, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return summary, err
+	}
+	return summary, flush()
+}
+
+func isSchemaStatement(statement string) bool {
+	upper := strings.ToUpper(statement)
+	for _, prefix := range []string{"CREATE INDEX", "DROP INDEX", "CREATE CONSTRAINT", "DROP CONSTRAINT", "CALL DB.AWAITINDEX"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// run executes a single statement in its own write transaction
+func run(ctx context.Context, db database.DBClient, query string, params map[string]any) error {
+	_, err := db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		_, err = result.Consume(ctx)
+		return nil, err
+	})
+	return err
+}
@@ -0,0 +1,284 @@
+package backup
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+// Layouts of the temporal types that have no time zone, or only an offset
+const (
+	dateLayout          = "2006-01-02"
+	localDateTimeLayout = "2006-01-02T15:04:05.999999999"
+	localTimeLayout     = "15:04:05.999999999"
+	timeLayout          = "15:04:05.999999999Z07:00"
+)
+
+// encodeValue converts a property value returned by the driver to one that
+// survives a round trip through JSON. Strings, booleans, integers and lists
+// stay as they are; floats always carry a decimal point so they aren't read
+// back as integers; other types become a single-key object naming the type,
+// such as {"$date": "2024-05-01"}.
+func encodeValue(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, string, bool, int64:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return map[string]any{"$float": strconv.FormatFloat(v, 'g', -1, 64)}, nil
+		}
+		return json.Number(formatFloat(v)), nil
+	case time.Time:
+		return map[string]any{"$datetime": v.Format(time.RFC3339Nano)}, nil
+	case dbtype.Date:
+		return map[string]any{"$date": v.Time().Format(dateLayout)}, nil
+	case dbtype.LocalDateTime:
+		return map[string]any{"$localdatetime": v.Time().Format(localDateTimeLayout)}, nil
+	case dbtype.LocalTime:
+		return map[string]any{"$localtime": v.Time().Format(localTimeLayout)}, nil
+	case dbtype.Time:
+		return map[string]any{"$time": v.Time().Format(timeLayout)}, nil
+	case dbtype.Duration:
+		return map[string]any{"$duration": []int64{v.Months, v.Days, v.Seconds, int64(v.Nanos)}}, nil
+	case dbtype.Point2D:
+		return map[string]any{"$point": []any{int64(v.SpatialRefId), v.X, v.Y}}, nil
+	case dbtype.Point3D:
+		return map[string]any{"$point": []any{int64(v.SpatialRefId), v.X, v.Y, v.Z}}, nil
+	case []byte:
+		return map[string]any{"$bytes": base64.StdEncoding.EncodeToString(v)}, nil
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			encoded, err := encodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = encoded
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unsupported property type %T", value)
+}
+
+// decodeValue reverses encodeValue for a value decoded from JSON with
+// json.Decoder.UseNumber
+func decodeValue(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return v.Float64()
+		}
+		return v.Int64()
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			decoded, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = decoded
+		}
+		return items, nil
+	case map[string]any:
+		if len(v) == 1 {
+			for tag, raw := range v {
+				return decodeTagged(tag, raw)
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported value %v", value)
+}
+
+func decodeTagged(tag string, raw any) (any, error) {
+	if tag == "$duration" || tag == "$point" {
+		parts, err := decodeNumbers(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", tag, err)
+		}
+		return decodeComposite(tag, parts)
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: expected a string", tag)
+	}
+	var (
+		t   time.Time
+		err error
+	)
+	switch tag {
+	case "$float":
+		return strconv.ParseFloat(s, 64)
+	case "$bytes":
+		return base64.StdEncoding.DecodeString(s)
+	case "$datetime":
+		return time.Parse(time.RFC3339Nano, s)
+	case "$date":
+		t, err = time.Parse(dateLayout, s)
+		return dbtype.Date(t), err
+	case "$localdatetime":
+		t, err = time.Parse(localDateTimeLayout, s)
+		return dbtype.LocalDateTime(t), err
+	case "$localtime":
+		t, err = time.Parse(localTimeLayout, s)
+		return dbtype.LocalTime(t), err
+	case "$time":
+		t, err = time.Parse(timeLayout, s)
+		return dbtype.Time(t), err
+	}
+	return nil, fmt.Errorf("unknown value type %q", tag)
+}
+
+func decodeComposite(tag string, parts []float64) (any, error) {
+	switch {
+	case tag == "$duration" && len(parts) == 4:
+		return dbtype.Duration{
+			Months:  int64(parts[0]),
+			Days:    int64(parts[1]),
+			Seconds: int64(parts[2]),
+			Nanos:   int(parts[3]),
+		}, nil
+	case tag == "$point" && len(parts) == 3:
+		return dbtype.Point2D{SpatialRefId: uint32(parts[0]), X: parts[1], Y: parts[2]}, nil
+	case tag == "$point" && len(parts) == 4:
+		return dbtype.Point3D{SpatialRefId: uint32(parts[0]), X: parts[1], Y: parts[2], Z: parts[3]}, nil
+	}
+	return nil, fmt.Errorf("invalid %s: wrong number of parts", tag)
+}
+
+func decodeNumbers(raw any) ([]float64, error) {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a list")
+	}
+	numbers := make([]float64, len(items))
+	for i, item := range items {
+		n, ok := item.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("expected a number")
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = f
+	}
+	return numbers, nil
+}
+
+// cypherLiteral writes value as a Cypher expression
+func cypherLiteral(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "0.0/0.0", nil
+		case math.IsInf(v, 1):
+			return "1.0/0.0", nil
+		case math.IsInf(v, -1):
+			return "-1.0/0.0", nil
+		}
+		return formatFloat(v), nil
+	case time.Time:
+		return "datetime(" + quote(v.Format(time.RFC3339Nano)) + ")", nil
+	case dbtype.Date:
+		return "date(" + quote(v.Time().Format(dateLayout)) + ")", nil
+	case dbtype.LocalDateTime:
+		return "localdatetime(" + quote(v.Time().Format(localDateTimeLayout)) + ")", nil
+	case dbtype.LocalTime:
+		return "localtime(" + quote(v.Time().Format(localTimeLayout)) + ")", nil
+	case dbtype.Time:
+		return "time(" + quote(v.Time().Format(timeLayout)) + ")", nil
+	case dbtype.Duration:
+		return fmt.Sprintf("duration({months: %d, days: %d, seconds: %d, nanoseconds: %d})",
+			v.Months, v.Days, v.Seconds, v.Nanos), nil
+	case dbtype.Point2D:
+		return fmt.Sprintf("point({srid: %d, x: %s, y: %s})",
+			v.SpatialRefId, formatFloat(v.X), formatFloat(v.Y)), nil
+	case dbtype.Point3D:
+		return fmt.Sprintf("point({srid: %d, x: %s, y: %s, z: %s})",
+			v.SpatialRefId, formatFloat(v.X), formatFloat(v.Y), formatFloat(v.Z)), nil
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			literal, err := cypherLiteral(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = literal
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("property type %T has no Cypher literal", value)
+}
+
+// cypherMap writes props as a Cypher map literal with sorted keys
+func cypherMap(props map[string]any) (string, error) {
+	parts := make([]string, 0, len(props))
+	for _, key := range sortedKeys(props) {
+		literal, err := cypherLiteral(props[key])
+		if err != nil {
+			return "", fmt.Errorf("property %q: %w", key, err)
+		}
+		parts = append(parts, identifier(key)+": "+literal)
+	}
+	return "{" + strings.Join(parts, ", ") + "}", nil
+}
+
+// quote writes s as a Cypher string literal
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// identifier quotes a label, relationship type or property name with
+// backticks
+func identifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// GetJSON returns the value stored under key decoded into a T
+func GetJSON[T any](ctx context.Context, store Store, key string) (T, bool, error) {
+	var value T
+	data, ok, err := store.Get(ctx, key)
+	if err != nil || !ok {
+		return value, false, err
+	}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, false, fmt.Errorf("invalid cached value for %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SetJSON stores value under key as JSON for the given ttl
+func SetJSON(ctx context.Context, store Store, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return store.Set(ctx, key, data, ttl)
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRU is an in-memory Store holding at most a fixed number of entries,
+// evicting the least recently used one when full
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// Ensure LRU implements Store
+var _ Store = (*LRU)(nil)
+
+// NewLRU creates an LRU store holding up to capacity entries
+func NewLRU(capacity int) *LRU {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &LRU{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value for key if present and not expired
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(elem)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores value under key for the given ttl
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Delete removes key
+func (c *LRU) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	return nil
+}
+
+// DeletePrefix removes every key starting with prefix
+func (c *LRU) DeletePrefix(_ context.Context, prefix string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+// Incr increments the counter at key. Counters are evicted like any other
+// entry, so a full cache may forget them early.
+func (c *LRU) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		if now.Before(entry.expiresAt) {
+			n, err := strconv.ParseInt(string(entry.value), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("value of %q is not a counter", key)
+			}
+			n++
+			entry.value = strconv.AppendInt(nil, n, 10)
+			c.order.MoveToFront(elem)
+			return n, nil
+		}
+		c.remove(elem)
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: []byte("1"), expiresAt: now.Add(ttl)})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return 1, nil
+}
+
+// Len returns the number of entries, including expired ones not yet evicted
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func (c *LRU) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*lruEntry).key)
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(2)
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+
+	// Touch a so b becomes the least recently used
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("expected a to be present")
+	}
+	c.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if value, ok, _ := c.Get(ctx, "a"); !ok || string(value) != "1" {
+		t.Errorf("expected a to survive, got %q", value)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestLRU_ExpiryAndDeletePrefix(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	c.Set(ctx, "expired", []byte("x"), -time.Second)
+	if _, ok, _ := c.Get(ctx, "expired"); ok {
+		t.Error("expected expired entry to be missing")
+	}
+
+	c.Set(ctx, "stats:global", []byte("1"), time.Minute)
+	c.Set(ctx, "stats:categories", []byte("2"), time.Minute)
+	c.Set(ctx, "acts:list", []byte("3"), time.Minute)
+
+	c.DeletePrefix(ctx, "stats:")
+
+	if c.Len() != 1 {
+		t.Errorf("expected only acts:list to remain, got %d entries", c.Len())
+	}
+	if _, ok, _ := c.Get(ctx, "acts:list"); !ok {
+		t.Error("expected acts:list to remain")
+	}
+}
+
+func TestLRU_Incr(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	for want := int64(1); want <= 3; want++ {
+		n, err := c.Incr(ctx, "hits", time.Minute)
+		if err != nil || n != want {
+			t.Fatalf("Incr = %d, %v; want %d", n, err, want)
+		}
+	}
+
+	c.Set(ctx, "stale", []byte("7"), -time.Second)
+	if n, _ := c.Incr(ctx, "stale", time.Minute); n != 1 {
+		t.Errorf("expected an expired counter to restart at 1, got %d", n)
+	}
+
+	c.Set(ctx, "text", []byte("abc"), time.Minute)
+	if _, err := c.Incr(ctx, "text", time.Minute); err == nil {
+		t.Error("expected an error incrementing a non-counter")
+	}
+
+	c.Delete(ctx, "hits")
+	if _, ok, _ := c.Get(ctx, "hits"); ok {
+		t.Error("expected hits to be deleted")
+	}
+}
+
+func TestJSON_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRU(10)
+
+	type report struct {
+		Weeks int `json:"weeks"`
+	}
+	if err := SetJSON(ctx, c, "report", &report{Weeks: 4}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok, err := GetJSON[*report](ctx, c, "report")
+	if err != nil || !ok || got.Weeks != 4 {
+		t.Errorf("GetJSON = %+v, %v, %v", got, ok, err)
+	}
+	if _, ok, _ := GetJSON[*report](ctx, c, "missing"); ok {
+		t.Error("expected a missing key to miss")
+	}
+}
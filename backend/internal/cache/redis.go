@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Store backed by Redis, letting several server instances share
+// cached data. All keys are namespaced under a common prefix.
+type Redis struct {
+	client    *redis.Client
+	namespace string
+}
+
+// Ensure Redis implements Store
+var _ Store = (*Redis)(nil)
+
+// NewRedis connects to the Redis server at url, such as
+// "redis://localhost:6379/0", and verifies the connection
+func NewRedis(ctx context.Context, url, namespace string) (*Redis, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &Redis{client: client, namespace: namespace}, nil
+}
+
+// Ping checks that the server is reachable
+func (r *Redis) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// Get returns the value for key if present
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.namespace+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Set stores value under key for the given ttl
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.namespace+key, value, ttl).Err()
+}
+
+// Delete removes key
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.namespace+key).Err()
+}
+
+// incrScript increments the counter at KEYS[1], setting its expiry to
+// ARGV[1] milliseconds when the increment creates it. Running both as one
+// script means a counter can't be left without an expiry.
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+// Incr increments the counter at key, setting its expiry when the
+// increment creates it
+func (r *Redis) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, r.client, []string{r.namespace + key}, ttl.Milliseconds()).Int64()
+}
+
+// DeletePrefix removes every key starting with prefix. It scans the
+// keyspace incrementally rather than blocking Redis with KEYS.
+func (r *Redis) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := r.client.Scan(ctx, 0, r.namespace+prefix+"*", 100).Iterator()
+
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 100 {
+			if err := r.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return r.client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
+
+// Close closes the connection pool
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store is a byte-oriented cache that can be backed by memory or Redis and
+// supports invalidating groups of keys by prefix. Features that keep
+// short-lived state, such as cached stats, rate limits, deduplicated
+// responses and revoked tokens, share a Store so that state is visible to
+// every server instance when Redis is configured.
+type Store interface {
+	// Get returns the value for key if present and not expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key for the given ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every key starting with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+	// Incr atomically increments the counter at key and returns its new
+	// value. A missing counter starts at zero and expires after ttl; later
+	// increments keep the original expiry.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+}
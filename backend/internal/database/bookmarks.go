@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Bookmarks collects the causal consistency bookmarks of a unit of work,
+// typically an API request. Sessions opened with a context carrying
+// Bookmarks wait until the cluster member serving them has caught up with
+// those bookmarks, so a read following a write sees its data even when it
+// is routed to a different member.
+type Bookmarks struct {
+	mu     sync.Mutex
+	values neo4j.Bookmarks
+}
+
+type bookmarksKey struct{}
+
+// WithBookmarks returns a context carrying a new Bookmarks, starting from
+// initial (for example bookmarks a client returned from a previous request)
+func WithBookmarks(ctx context.Context, initial ...string) (context.Context, *Bookmarks) {
+	b := &Bookmarks{values: slices.Clone(initial)}
+	return context.WithValue(ctx, bookmarksKey{}, b), b
+}
+
+// BookmarksFromContext returns the Bookmarks carried by ctx, or nil
+func BookmarksFromContext(ctx context.Context) *Bookmarks {
+	b, _ := ctx.Value(bookmarksKey{}).(*Bookmarks)
+	return b
+}
+
+// Values returns the current bookmarks
+func (b *Bookmarks) Values() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.values)
+}
+
+// update replaces the bookmarks with those of a session that started from
+// them. A session's last bookmarks cover everything it was started with, so
+// they supersede the previous values.
+func (b *Bookmarks) update(last neo4j.Bookmarks) {
+	if len(last) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values = slices.Clone(last)
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBookmarks(t *testing.T) {
+	if BookmarksFromContext(context.Background()) != nil {
+		t.Fatal("expected no bookmarks on a plain context")
+	}
+
+	ctx, b := WithBookmarks(context.Background(), "bm:1")
+	if BookmarksFromContext(ctx) != b {
+		t.Fatal("expected the context to carry the bookmarks")
+	}
+	if got := b.Values(); !reflect.DeepEqual(got, []string{"bm:1"}) {
+		t.Errorf("expected initial bookmarks, got %v", got)
+	}
+
+	// An empty result keeps the previous bookmarks
+	b.update(nil)
+	if got := b.Values(); !reflect.DeepEqual(got, []string{"bm:1"}) {
+		t.Errorf("expected bookmarks to be kept, got %v", got)
+	}
+
+	b.update([]string{"bm:2"})
+	if got := b.Values(); !reflect.DeepEqual(got, []string{"bm:2"}) {
+		t.Errorf("expected the latest bookmarks, got %v", got)
+	}
+}
+
+func TestTrackBookmarks_SkipsFailedTransactions(t *testing.T) {
+	ctx, b := WithBookmarks(context.Background(), "bm:1")
+
+	trackBookmarks(ctx, nil, errors.New("transaction failed"))
+
+	if got := b.Values(); !reflect.DeepEqual(got, []string{"bm:1"}) {
+		t.Errorf("expected bookmarks to be kept, got %v", got)
+	}
+}
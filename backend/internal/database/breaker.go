@@ -0,0 +1,225 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/metrics"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ErrCircuitOpen is returned without contacting the database while the
+// circuit breaker is open
+var ErrCircuitOpen = errors.New("database circuit breaker is open")
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets all calls through
+	BreakerClosed BreakerState = iota
+	// BreakerHalfOpen lets a single probe call through to test recovery
+	BreakerHalfOpen
+	// BreakerOpen fails all calls fast with ErrCircuitOpen
+	BreakerOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a CircuitBreaker
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before a probe is let
+	// through
+	OpenTimeout time.Duration
+}
+
+// DefaultBreakerConfig returns the breaker settings used when none are
+// configured
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// CircuitBreaker wraps a DBClient and fails fast while the database is
+// consistently unavailable. Only connectivity problems, timeouts and
+// server-side transient or database errors count as failures; errors
+// returned by the transaction function itself, such as not found, do not.
+type CircuitBreaker struct {
+	DBClient
+
+	config   BreakerConfig
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+// NewCircuitBreaker wraps client with a circuit breaker
+func NewCircuitBreaker(client DBClient, config BreakerConfig) *CircuitBreaker {
+	defaults := DefaultBreakerConfig()
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaults.OpenTimeout
+	}
+
+	metrics.DBCircuitState.Set(float64(BreakerClosed))
+
+	return &CircuitBreaker{
+		DBClient: client,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// State returns the current breaker state. An open breaker whose timeout
+// has elapsed reports half-open, since the next call will be a probe.
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == BreakerOpen && cb.now().Sub(cb.openedAt) >= cb.config.OpenTimeout {
+		return BreakerHalfOpen
+	}
+	return cb.state
+}
+
+// ExecuteRead runs a read transaction unless the circuit is open
+func (cb *CircuitBreaker) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	ctx = withCallerName(ctx, 1)
+	return cb.call(func() (interface{}, error) {
+		return cb.DBClient.ExecuteRead(ctx, work)
+	})
+}
+
+// ExecuteWrite runs a write transaction unless the circuit is open
+func (cb *CircuitBreaker) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	ctx = withCallerName(ctx, 1)
+	return cb.call(func() (interface{}, error) {
+		return cb.DBClient.ExecuteWrite(ctx, work)
+	})
+}
+
+func (cb *CircuitBreaker) call(fn func() (interface{}, error)) (interface{}, error) {
+	if !cb.acquire() {
+		metrics.DBCircuitRejections.Inc()
+		return nil, ErrCircuitOpen
+	}
+
+	result, err := fn()
+	cb.record(isBreakerFailure(err))
+	return result, err
+}
+
+// acquire reports whether a call may proceed, moving an expired open
+// circuit to half-open and admitting a single probe
+func (cb *CircuitBreaker) acquire() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case BreakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.config.OpenTimeout {
+			return false
+		}
+		cb.transition(BreakerHalfOpen)
+		cb.probing = true
+		return true
+	case BreakerHalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (cb *CircuitBreaker) record(failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == BreakerHalfOpen {
+		cb.probing = false
+		if failed {
+			cb.open()
+		} else {
+			cb.failures = 0
+			cb.transition(BreakerClosed)
+		}
+		return
+	}
+
+	if !failed {
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	if cb.state == BreakerClosed && cb.failures >= cb.config.FailureThreshold {
+		cb.open()
+	}
+}
+
+func (cb *CircuitBreaker) open() {
+	cb.openedAt = cb.now()
+	cb.transition(BreakerOpen)
+}
+
+func (cb *CircuitBreaker) transition(to BreakerState) {
+	if cb.state == to {
+		return
+	}
+
+	slog.Warn("database circuit breaker state changed", "from", cb.state.String(), "to", to.String())
+	cb.state = to
+	metrics.DBCircuitState.Set(float64(to))
+	metrics.DBCircuitTransitions.WithLabelValues(to.String()).Inc()
+}
+
+// isBreakerFailure reports whether err indicates the database itself is
+// unhealthy rather than an application-level error or a cancelled request
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if neo4j.IsConnectivityError(err) || neo4j.IsTransactionExecutionLimit(err) {
+		return true
+	}
+
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		switch neo4jErr.Classification() {
+		case "TransientError", "DatabaseError":
+			return true
+		}
+	}
+	return false
+}
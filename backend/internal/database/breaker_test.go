@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// stubClient returns err from every transaction and records the context
+// of the last call
+type stubClient struct {
+	DBClient
+	err   error
+	calls int
+	ctx   context.Context
+}
+
+func (s *stubClient) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	s.calls++
+	s.ctx = ctx
+	return nil, s.err
+}
+
+func (s *stubClient) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	return s.ExecuteRead(ctx, work)
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	now := time.Now()
+	stub := &stubClient{err: context.DeadlineExceeded}
+	cb := NewCircuitBreaker(stub, BreakerConfig{FailureThreshold: 3, OpenTimeout: 10 * time.Second})
+	cb.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		cb.ExecuteRead(ctx, nil)
+	}
+	if cb.State() != BreakerOpen {
+		t.Fatalf("expected open after 3 failures, got %s", cb.State())
+	}
+
+	if _, err := cb.ExecuteWrite(ctx, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if stub.calls != 3 {
+		t.Errorf("expected open breaker not to call the database, got %d calls", stub.calls)
+	}
+
+	// A failed probe reopens the circuit
+	now = now.Add(10 * time.Second)
+	if cb.State() != BreakerHalfOpen {
+		t.Errorf("expected half-open after the timeout, got %s", cb.State())
+	}
+	cb.ExecuteRead(ctx, nil)
+	if stub.calls != 4 || cb.State() != BreakerOpen {
+		t.Errorf("expected probe to fail and reopen, got %d calls and %s", stub.calls, cb.State())
+	}
+
+	// A successful probe closes it
+	now = now.Add(10 * time.Second)
+	stub.err = nil
+	if _, err := cb.ExecuteRead(ctx, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cb.State() != BreakerClosed {
+		t.Errorf("expected closed after a successful probe, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreaker_IgnoresApplicationErrors(t *testing.T) {
+	stub := &stubClient{err: errors.New("testimonial not found")}
+	cb := NewCircuitBreaker(stub, BreakerConfig{FailureThreshold: 2})
+
+	for i := 0; i < 5; i++ {
+		cb.ExecuteRead(context.Background(), nil)
+	}
+	if cb.State() != BreakerClosed {
+		t.Errorf("expected application errors not to open the circuit, got %s", cb.State())
+	}
+
+	stub.err = context.Canceled
+	for i := 0; i < 5; i++ {
+		cb.ExecuteRead(context.Background(), nil)
+	}
+	if cb.State() != BreakerClosed {
+		t.Errorf("expected cancelled requests not to open the circuit, got %s", cb.State())
+	}
+}
+
+func TestCircuitBreaker_NamesSpanAfterCaller(t *testing.T) {
+	stub := &stubClient{}
+	cb := NewCircuitBreaker(stub, BreakerConfig{})
+
+	cb.ExecuteRead(context.Background(), nil)
+	if name, _ := stub.ctx.Value(queryNameKey{}).(string); name != "TestCircuitBreaker_NamesSpanAfterCaller" {
+		t.Errorf("expected span named after the caller, got %q", name)
+	}
+
+	cb.ExecuteRead(WithQueryName(context.Background(), "custom"), nil)
+	if name, _ := stub.ctx.Value(queryNameKey{}).(string); name != "custom" {
+		t.Errorf("expected explicit query name to be kept, got %q", name)
+	}
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout returns a copy of ctx whose transactions may run for up
+// to timeout instead of the client's QueryTimeout. Zero lifts the limit,
+// for migrations and bulk jobs that are expected to run long.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// queryTimeout returns the timeout set by WithQueryTimeout, or fallback
+func queryTimeout(ctx context.Context, fallback time.Duration) time.Duration {
+	if timeout, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+		return timeout
+	}
+	return fallback
+}
+
+// withQueryDeadline bounds ctx by the query timeout in effect, so a
+// transaction can't outlive it even when the caller set no deadline of its
+// own. An earlier deadline on ctx is kept. It reports whether the new
+// deadline applies, so a timeout can be told apart from the caller's.
+func withQueryDeadline(ctx context.Context, fallback time.Duration) (context.Context, context.CancelFunc, bool) {
+	timeout := queryTimeout(ctx, fallback)
+	if timeout <= 0 {
+		return ctx, func() {}, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= timeout {
+		return ctx, func() {}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, true
+}
+
+// timedOut reports whether err comes from a deadline set by
+// withQueryDeadline on ctx rather than one its parent set
+func timedOut(ctx, parent context.Context, err error) bool {
+	return err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil
+}
+
+// txTimeout asks the server to abort the transaction when ctx expires, so
+// a query the client has given up on stops using database resources
+func txTimeout(ctx context.Context) []func(*neo4j.TransactionConfig) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return nil
+	}
+	return []func(*neo4j.TransactionConfig){neo4j.WithTxTimeout(remaining)}
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestWithQueryDeadline(t *testing.T) {
+	ctx, cancel, bounded := withQueryDeadline(context.Background(), time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !bounded || !ok || time.Until(deadline) > time.Second {
+		t.Errorf("expected a deadline within 1s, got %v (bounded %v)", deadline, bounded)
+	}
+
+	parent, cancelParent := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancelParent()
+	ctx, cancel, bounded = withQueryDeadline(parent, time.Second)
+	defer cancel()
+	if bounded || ctx != parent {
+		t.Error("expected an earlier caller deadline to be kept")
+	}
+
+	ctx, cancel, bounded = withQueryDeadline(WithQueryTimeout(context.Background(), 0), time.Second)
+	defer cancel()
+	if _, ok := ctx.Deadline(); bounded || ok {
+		t.Error("expected WithQueryTimeout(0) to lift the timeout")
+	}
+
+	ctx, cancel, _ = withQueryDeadline(WithQueryTimeout(context.Background(), time.Minute), time.Second)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); time.Until(deadline) < 30*time.Second {
+		t.Errorf("expected the overridden timeout to apply, got %v left", time.Until(deadline))
+	}
+
+	ctx, cancel, bounded = withQueryDeadline(context.Background(), 0)
+	defer cancel()
+	if _, ok := ctx.Deadline(); bounded || ok {
+		t.Error("expected a zero timeout to leave the context alone")
+	}
+}
+
+func TestTimedOut(t *testing.T) {
+	parent := context.Background()
+	ctx, cancel := context.WithTimeout(parent, time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	if !timedOut(ctx, parent, context.DeadlineExceeded) {
+		t.Error("expected an expired query deadline to count as a timeout")
+	}
+	if timedOut(ctx, parent, nil) {
+		t.Error("expected a successful transaction not to count")
+	}
+
+	expired, cancelExpired := context.WithTimeout(parent, time.Nanosecond)
+	defer cancelExpired()
+	<-expired.Done()
+	if timedOut(ctx, expired, errors.New("boom")) {
+		t.Error("expected the caller's own deadline not to count")
+	}
+}
+
+func TestTxTimeout(t *testing.T) {
+	if configurers := txTimeout(context.Background()); len(configurers) != 0 {
+		t.Errorf("expected no timeout without a deadline, got %d configurers", len(configurers))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var config neo4j.TransactionConfig
+	for _, configure := range txTimeout(ctx) {
+		configure(&config)
+	}
+	if config.Timeout <= 0 || config.Timeout > 2*time.Second {
+		t.Errorf("expected a server timeout within 2s, got %v", config.Timeout)
+	}
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+// DecodeNode maps the properties of node into a new T. See DecodeProps.
+func DecodeNode[T any](node neo4j.Node) (T, error) {
+	var value T
+	err := DecodeProps(node.Props, &value)
+	return value, err
+}
+
+// DecodeProps maps node properties into the struct pointed to by dst. Fields
+// are matched by their neo4j tag, as in
+//
+//	Status string `neo4j:"status,default=pending"`
+//
+// and fields without one are left alone. A property that is missing or null
+// leaves the field at its default: the tag's default if it has one, or else
+// the field's current value. Values are converted between Neo4j's types and
+// the field's, so an int64 property fills an int field and a Date fills a
+// time.Time. Fields whose property can't be converted keep their default
+// and are reported together in the returned error.
+func DecodeProps(props map[string]any, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode destination must be a non-nil struct pointer, got %T", dst)
+	}
+	v = v.Elem()
+
+	var errs []error
+	for _, f := range structFields(v.Type()) {
+		field := v.FieldByIndex(f.index)
+
+		raw, ok := props[f.name]
+		if !ok || raw == nil {
+			if f.hasDefault {
+				if err := setFromString(field, f.def); err != nil {
+					errs = append(errs, fmt.Errorf("default for %q: %w", f.name, err))
+				}
+			}
+			continue
+		}
+
+		if err := assign(field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("property %q: %w", f.name, err))
+			if f.hasDefault {
+				_ = setFromString(field, f.def)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type tagField struct {
+	index      []int
+	name       string
+	def        string
+	hasDefault bool
+}
+
+// fieldCache holds the tagged fields of each decoded struct type
+var fieldCache sync.Map
+
+func structFields(t reflect.Type) []tagField {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]tagField)
+	}
+
+	var fields []tagField
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup("neo4j")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		f := tagField{index: sf.Index, name: name}
+		if def, ok := strings.CutPrefix(options, "default="); ok {
+			f.def, f.hasDefault = def, true
+		}
+		fields = append(fields, f)
+	}
+
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// assign converts raw, a value returned by the driver, to the type of field
+// and stores it
+func assign(field reflect.Value, raw any) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := assign(elem.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if field.Type() == timeType {
+		t, err := toTime(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		if s, ok := raw.(string); ok {
+			field.SetString(s)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := raw.(bool); ok {
+			field.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch x := raw.(type) {
+		case int64:
+			n = x
+		case float64:
+			if x != math.Trunc(x) || x < math.MinInt64 || x > math.MaxInt64 {
+				return fmt.Errorf("%v is not an integer", x)
+			}
+			n = int64(x)
+		default:
+			return conversionError(raw, field)
+		}
+		if field.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, field.Type())
+		}
+		field.SetInt(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch x := raw.(type) {
+		case float64:
+			field.SetFloat(x)
+			return nil
+		case int64:
+			field.SetFloat(float64(x))
+			return nil
+		}
+	case reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			break
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if item == nil {
+				continue
+			}
+			if err := assign(slice.Index(i), item); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return conversionError(raw, field)
+}
+
+func conversionError(raw any, field reflect.Value) error {
+	return fmt.Errorf("can't convert %T to %s", raw, field.Type())
+}
+
+func toTime(raw any) (time.Time, error) {
+	switch x := raw.(type) {
+	case time.Time:
+		return x, nil
+	case dbtype.Date:
+		return x.Time(), nil
+	case dbtype.LocalDateTime:
+		return x.Time(), nil
+	case string:
+		return time.Parse(time.RFC3339Nano, x)
+	}
+	return time.Time{}, fmt.Errorf("can't convert %T to time.Time", raw)
+}
+
+// setFromString parses a tag default into field
+func setFromString(field reflect.Value, s string) error {
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setFromString(elem.Elem(), s); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("defaults are not supported for %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+type mappedNode struct {
+	ID        string     `neo4j:"id"`
+	Status    string     `neo4j:"status,default=pending"`
+	Count     int        `neo4j:"count"`
+	Score     float64    `neo4j:"score"`
+	Active    bool       `neo4j:"active,default=true"`
+	Order     *int       `neo4j:"order"`
+	Tags      []string   `neo4j:"tags"`
+	Born      time.Time  `neo4j:"born"`
+	UpdatedAt *time.Time `neo4j:"updatedAt"`
+	Secret    string
+	Ignored   string `neo4j:"-"`
+}
+
+func TestDecodeNode(t *testing.T) {
+	born := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+	node := neo4j.Node{Props: map[string]any{
+		"id":      "n1",
+		"count":   int64(3),
+		"score":   int64(7),
+		"order":   int64(2),
+		"tags":    []any{"a", "b"},
+		"born":    dbtype.Date(born),
+		"Secret":  "hash",
+		"Ignored": "x",
+	}}
+
+	got, err := DecodeNode[mappedNode](node)
+	if err != nil {
+		t.Fatalf("DecodeNode failed: %v", err)
+	}
+
+	if got.ID != "n1" || got.Count != 3 || got.Score != 7 {
+		t.Errorf("unexpected scalars %+v", got)
+	}
+	if got.Status != "pending" || !got.Active {
+		t.Errorf("expected tag defaults for missing properties, got %q %v", got.Status, got.Active)
+	}
+	if got.Order == nil || *got.Order != 2 {
+		t.Errorf("expected order 2, got %v", got.Order)
+	}
+	if len(got.Tags) != 2 || got.Tags[1] != "b" {
+		t.Errorf("expected tags [a b], got %v", got.Tags)
+	}
+	if !got.Born.Equal(born) {
+		t.Errorf("expected %v, got %v", born, got.Born)
+	}
+	if got.UpdatedAt != nil {
+		t.Errorf("expected missing pointer to stay nil, got %v", got.UpdatedAt)
+	}
+	if got.Secret != "" || got.Ignored != "" {
+		t.Error("untagged and ignored fields must not be mapped")
+	}
+}
+
+func TestDecodeProps_Mismatch(t *testing.T) {
+	var got mappedNode
+	err := DecodeProps(map[string]any{
+		"id":     int64(5),
+		"status": "done",
+		"count":  1.5,
+	}, &got)
+
+	if err == nil {
+		t.Fatal("expected an error for unconvertible properties")
+	}
+	if got.ID != "" || got.Count != 0 {
+		t.Errorf("expected unconvertible fields to keep their zero value, got %+v", got)
+	}
+	if got.Status != "done" {
+		t.Errorf("expected convertible fields to be mapped, got %q", got.Status)
+	}
+}
+
+func TestDecodeProps_InvalidDestination(t *testing.T) {
+	var got mappedNode
+	if err := DecodeProps(nil, got); err == nil {
+		t.Error("expected an error for a non-pointer destination")
+	}
+}
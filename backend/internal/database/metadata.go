@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"payforwardnow/internal/requestid"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type userIDKey struct{}
+
+// WithUserID returns a copy of ctx whose transactions are tagged with the ID
+// of the authenticated user
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserIDFromContext returns the user ID set by WithUserID, if any
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userIDKey{}).(string)
+	return userID
+}
+
+// txConfig returns the transaction configuration for ctx. The request and
+// user IDs are attached as transaction metadata, which Neo4j records in its
+// query log and shows in SHOW TRANSACTIONS, so queries can be traced back to
+// API requests and the users who made them.
+func txConfig(ctx context.Context) []func(*neo4j.TransactionConfig) {
+	metadata := map[string]any{}
+	if id := requestid.FromContext(ctx); id != "" {
+		metadata["requestId"] = id
+	}
+	if userID := UserIDFromContext(ctx); userID != "" {
+		metadata["userId"] = userID
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return []func(*neo4j.TransactionConfig){
+		neo4j.WithTxMetadata(metadata),
+	}
+}
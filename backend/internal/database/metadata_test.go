@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"payforwardnow/internal/requestid"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestTxConfig(t *testing.T) {
+	if configurers := txConfig(context.Background()); len(configurers) != 0 {
+		t.Errorf("expected no metadata without a request ID, got %d configurers", len(configurers))
+	}
+
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	var config neo4j.TransactionConfig
+	for _, configure := range txConfig(ctx) {
+		configure(&config)
+	}
+
+	if config.Metadata["requestId"] != "req-1" {
+		t.Errorf("expected requestId metadata, got %v", config.Metadata)
+	}
+}
+
+func TestTxConfig_UserID(t *testing.T) {
+	ctx := WithUserID(requestid.NewContext(context.Background(), "req-1"), "user-1")
+	var config neo4j.TransactionConfig
+	for _, configure := range txConfig(ctx) {
+		configure(&config)
+	}
+
+	if config.Metadata["requestId"] != "req-1" || config.Metadata["userId"] != "user-1" {
+		t.Errorf("expected request and user ID metadata, got %v", config.Metadata)
+	}
+
+	config = neo4j.TransactionConfig{}
+	for _, configure := range txConfig(WithUserID(context.Background(), "user-1")) {
+		configure(&config)
+	}
+	if _, ok := config.Metadata["requestId"]; ok || config.Metadata["userId"] != "user-1" {
+		t.Errorf("expected only user ID metadata, got %v", config.Metadata)
+	}
+}
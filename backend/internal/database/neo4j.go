@@ -6,44 +6,141 @@ import (
 	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"payforwardnow/internal/metrics"
 )
 
 // Neo4jClient wraps the Neo4j driver
 type Neo4jClient struct {
-	driver neo4j.DriverWithContext
+	driver             neo4j.DriverWithContext
+	slowQueryThreshold time.Duration
+	queryTimeout       time.Duration
+	readRouting        string
+	pool               *poolTracker
+}
+
+// ClientConfig tunes the driver's connection pool and network behaviour
+type ClientConfig struct {
+	// MaxConnectionPoolSize caps the connections kept open per server
+	MaxConnectionPoolSize int
+	// MaxConnectionLifetime closes pooled connections older than this
+	MaxConnectionLifetime time.Duration
+	// ConnectionAcquisitionTimeout bounds the wait for a free connection
+	ConnectionAcquisitionTimeout time.Duration
+	// SocketConnectTimeout bounds establishing a new connection
+	SocketConnectTimeout time.Duration
+	// SocketKeepalive enables TCP keepalive on connections
+	SocketKeepalive bool
+	// FetchSize is the number of records fetched per batch; -1 fetches all
+	// records at once
+	FetchSize int
+	// MaxTransactionRetryTime bounds the retries of transient failures in
+	// ExecuteRead and ExecuteWrite
+	MaxTransactionRetryTime time.Duration
+	// SlowQueryThreshold is the duration above which a transaction is logged
+	// and counted as slow; zero disables slow query reporting
+	SlowQueryThreshold time.Duration
+	// QueryTimeout bounds every ExecuteRead and ExecuteWrite, retries
+	// included, unless the context has an earlier deadline or overrides it
+	// with WithQueryTimeout; zero disables it
+	QueryTimeout time.Duration
+	// CACertFile is a PEM bundle of CAs trusted in addition to the system
+	// roots, for bolt+s and neo4j+s servers with private certificates
+	CACertFile string
+	// VerifyTimeout bounds the connectivity check at startup, which is
+	// retried while the server is unreachable
+	VerifyTimeout time.Duration
+	// ReadRouting is where read transactions go on a cluster,
+	// ReadFromReplicas or ReadFromLeader, unless a request overrides it
+	// with WithReadRouting. Single servers ignore it.
+	ReadRouting string
+}
+
+// DefaultClientConfig returns the driver settings used by NewNeo4jClient
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		MaxConnectionPoolSize:        50,
+		MaxConnectionLifetime:        1 * time.Hour,
+		ConnectionAcquisitionTimeout: 2 * time.Minute,
+		SocketConnectTimeout:         5 * time.Second,
+		SocketKeepalive:              true,
+		FetchSize:                    1000,
+		MaxTransactionRetryTime:      30 * time.Second,
+		SlowQueryThreshold:           500 * time.Millisecond,
+		QueryTimeout:                 5 * time.Second,
+		VerifyTimeout:                5 * time.Second,
+		ReadRouting:                  ReadFromReplicas,
+	}
 }
 
-// NewNeo4jClient creates a new Neo4j client
+// Validate checks the settings NewNeo4jClient would reject
+func (c ClientConfig) Validate() error {
+	switch {
+	case c.MaxConnectionPoolSize <= 0:
+		return fmt.Errorf("connection pool size must be positive, got %d", c.MaxConnectionPoolSize)
+	case c.FetchSize == 0 || c.FetchSize < -1:
+		return fmt.Errorf("fetch size must be positive or -1, got %d", c.FetchSize)
+	case c.VerifyTimeout <= 0:
+		return fmt.Errorf("verify timeout must be positive, got %s", c.VerifyTimeout)
+	case c.MaxConnectionLifetime < 0, c.ConnectionAcquisitionTimeout < 0, c.SocketConnectTimeout < 0, c.MaxTransactionRetryTime < 0, c.SlowQueryThreshold < 0, c.QueryTimeout < 0:
+		return fmt.Errorf("driver timeouts must not be negative")
+	case c.ReadRouting != "":
+		return ValidReadRouting(c.ReadRouting)
+	}
+	return nil
+}
+
+// NewNeo4jClient creates a new Neo4j client with the default driver settings
 func NewNeo4jClient(uri, username, password string) (*Neo4jClient, error) {
+	return NewNeo4jClientWithConfig(uri, username, password, DefaultClientConfig())
+}
+
+// NewNeo4jClientWithConfig creates a new Neo4j client with the given driver
+// settings. Encryption is chosen by the URI scheme: bolt+s and neo4j+s
+// verify the server certificate, and are required for Neo4j Aura. The client
+// only checks that the database is reachable; setting up the schema is left
+// to the migrations package, so it needs no schema privileges.
+func NewNeo4jClientWithConfig(uri, username, password string, cfg ClientConfig) (*Neo4jClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	tlsCfg, err := tlsConfig(uri, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	driver, err := neo4j.NewDriverWithContext(
 		uri,
 		neo4j.BasicAuth(username, password, ""),
 		func(config *neo4j.Config) {
-			config.MaxConnectionPoolSize = 50
-			config.MaxConnectionLifetime = 1 * time.Hour
-			config.ConnectionAcquisitionTimeout = 2 * time.Minute
+			config.MaxConnectionPoolSize = cfg.MaxConnectionPoolSize
+			config.MaxConnectionLifetime = cfg.MaxConnectionLifetime
+			config.ConnectionAcquisitionTimeout = cfg.ConnectionAcquisitionTimeout
+			config.SocketConnectTimeout = cfg.SocketConnectTimeout
+			config.SocketKeepalive = cfg.SocketKeepalive
+			config.FetchSize = cfg.FetchSize
+			config.MaxTransactionRetryTime = cfg.MaxTransactionRetryTime
+			if tlsCfg != nil {
+				config.TlsConfig = tlsCfg
+			}
 		},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create driver: %w", err)
 	}
 
-	// Verify connectivity
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := driver.VerifyConnectivity(ctx); err != nil {
+	if err := verifyConnectivity(driver, cfg.VerifyTimeout); err != nil {
+		driver.Close(context.Background())
 		return nil, fmt.Errorf("failed to verify connectivity: %w", err)
 	}
 
-	client := &Neo4jClient{driver: driver}
-
-	// Initialize schema
-	if err := client.initializeSchema(ctx); err != nil {
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
-	}
-
-	return client, nil
+	return &Neo4jClient{
+		driver:             driver,
+		slowQueryThreshold: cfg.SlowQueryThreshold,
+		queryTimeout:       cfg.QueryTimeout,
+		readRouting:        cfg.ReadRouting,
+		pool:               newPoolTracker(cfg.MaxConnectionPoolSize),
+	}, nil
 }
 
 // Close closes the Neo4j driver
@@ -58,15 +155,28 @@ func (c *Neo4jClient) GetDriver() neo4j.DriverWithContext {
 	return c.driver
 }
 
-// Session creates a new session
+// PoolStats reports how transactions use the connection pool
+func (c *Neo4jClient) PoolStats() PoolStats {
+	return c.pool.stats()
+}
+
+// Session creates a new session on the database selected by WithDatabase.
+// When ctx carries Bookmarks, the session starts from them so it sees every
+// write they cover.
 func (c *Neo4jClient) Session(ctx context.Context, mode neo4j.AccessMode) neo4j.SessionWithContext {
-	return c.driver.NewSession(ctx, neo4j.SessionConfig{
-		AccessMode: mode,
-	})
+	config := neo4j.SessionConfig{AccessMode: mode, DatabaseName: DatabaseFromContext(ctx)}
+	if b := BookmarksFromContext(ctx); b != nil {
+		config.Bookmarks = neo4j.BookmarksFromRawValues(b.Values()...)
+	}
+	return c.driver.NewSession(ctx, config)
 }
 
-// ReadSession creates a read-only session
+// ReadSession creates a session for reading, on the writer when the read
+// routing of ctx says so
 func (c *Neo4jClient) ReadSession(ctx context.Context) neo4j.SessionWithContext {
+	if readsFromLeader(ctx, c.readRouting) {
+		return c.Session(ctx, neo4j.AccessModeWrite)
+	}
 	return c.Session(ctx, neo4j.AccessModeRead)
 }
 
@@ -75,74 +185,72 @@ func (c *Neo4jClient) WriteSession(ctx context.Context) neo4j.SessionWithContext
 	return c.Session(ctx, neo4j.AccessModeWrite)
 }
 
-// initializeSchema creates indexes and constraints
-func (c *Neo4jClient) initializeSchema(ctx context.Context) error {
-	session := c.WriteSession(ctx)
-	defer session.Close(ctx)
-
-	constraints := []string{
-		// User constraints
-		`CREATE CONSTRAINT user_id IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE`,
-		`CREATE CONSTRAINT user_email IF NOT EXISTS FOR (u:User) REQUIRE u.email IS UNIQUE`,
-
-		// Act constraints
-		`CREATE CONSTRAINT act_id IF NOT EXISTS FOR (a:Act) REQUIRE a.id IS UNIQUE`,
+// ExecuteRead executes a read transaction in its own tracing span. It runs
+// on the writer instead of a replica when the read routing of ctx says so.
+func (c *Neo4jClient) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	return c.execute(ctx, neo4j.AccessModeRead, queryName(ctx, 1), work)
+}
 
-		// Chain constraints
-		`CREATE CONSTRAINT chain_id IF NOT EXISTS FOR (c:Chain) REQUIRE c.id IS UNIQUE`,
+// ExecuteWrite executes a write transaction in its own tracing span
+func (c *Neo4jClient) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	return c.execute(ctx, neo4j.AccessModeWrite, queryName(ctx, 1), work)
+}
 
-		// Testimonial constraints
-		`CREATE CONSTRAINT testimonial_id IF NOT EXISTS FOR (t:Testimonial) REQUIRE t.id IS UNIQUE`,
+// execute runs work in a managed transaction named name, within the query
+// timeout, reporting it when it takes longer than the slow query threshold
+func (c *Neo4jClient) execute(ctx context.Context, mode neo4j.AccessMode, name string, work neo4j.ManagedTransactionWork) (result interface{}, err error) {
+	operation := "write"
+	if mode == neo4j.AccessModeRead {
+		operation = "read"
 	}
 
-	indexes := []string{
-		// User indexes
-		`CREATE INDEX user_created_at IF NOT EXISTS FOR (u:User) ON (u.createdAt)`,
-		`CREATE INDEX user_location IF NOT EXISTS FOR (u:User) ON (u.location)`,
-
-		// Act indexes
-		`CREATE INDEX act_created_at IF NOT EXISTS FOR (a:Act) ON (a.createdAt)`,
-		`CREATE INDEX act_type IF NOT EXISTS FOR (a:Act) ON (a.type)`,
-		`CREATE INDEX act_status IF NOT EXISTS FOR (a:Act) ON (a.status)`,
-
-		// Chain indexes
-		`CREATE INDEX chain_created_at IF NOT EXISTS FOR (c:Chain) ON (c.createdAt)`,
-
-		// Full-text search indexes
-		`CREATE FULLTEXT INDEX act_search IF NOT EXISTS FOR (a:Act) ON EACH [a.title, a.description]`,
+	parent := ctx
+	ctx, cancel, bounded := withQueryDeadline(ctx, c.queryTimeout)
+	defer cancel()
+	if bounded {
+		defer func() {
+			if timedOut(ctx, parent, err) {
+				metrics.DBQueryTimeouts.WithLabelValues(operation, name).Inc()
+			}
+		}()
 	}
 
-	// Create constraints
-	for _, constraint := range constraints {
-		_, err := session.Run(ctx, constraint, nil)
-		if err != nil {
-			return fmt.Errorf("failed to create constraint: %w", err)
-		}
-	}
+	ctx, span := startSpan(ctx, operation, name)
+	defer func() { endSpan(span, err) }()
 
-	// Create indexes
-	for _, index := range indexes {
-		_, err := session.Run(ctx, index, nil)
-		if err != nil {
-			return fmt.Errorf("failed to create index: %w", err)
-		}
+	var statements *statementLog
+	if c.slowQueryThreshold > 0 {
+		statements = &statementLog{}
+		work = statements.wrap(work)
 	}
 
-	return nil
-}
+	work, done := c.pool.track(operation, work)
+	defer func() { done(err) }()
 
-// ExecuteRead executes a read transaction
-func (c *Neo4jClient) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
-	session := c.ReadSession(ctx)
+	// Routing is decided by the transaction function, not the session's
+	// access mode, so reads routed to the leader run as write transactions
+	session := c.Session(ctx, mode)
 	defer session.Close(ctx)
 
-	return session.ExecuteRead(ctx, work)
-}
+	start := time.Now()
+	if mode == neo4j.AccessModeRead && !readsFromLeader(ctx, c.readRouting) {
+		result, err = session.ExecuteRead(ctx, work, append(txConfig(ctx), txTimeout(ctx)...)...)
+	} else {
+		result, err = session.ExecuteWrite(ctx, work, append(txConfig(ctx), txTimeout(ctx)...)...)
+	}
+	if statements != nil {
+		reportSlowQuery(ctx, c.slowQueryThreshold, operation, name, time.Since(start), statements.values(), err)
+	}
 
-// ExecuteWrite executes a write transaction
-func (c *Neo4jClient) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
-	session := c.WriteSession(ctx)
-	defer session.Close(ctx)
+	trackBookmarks(ctx, session, err)
+	return result, err
+}
 
-	return session.ExecuteWrite(ctx, work)
+// trackBookmarks records the bookmarks of a successful transaction in the
+// Bookmarks carried by ctx, so later sessions of the same unit of work read
+// their own writes
+func trackBookmarks(ctx context.Context, session neo4j.SessionWithContext, err error) {
+	if b := BookmarksFromContext(ctx); b != nil && err == nil {
+		b.update(session.LastBookmarks())
+	}
 }
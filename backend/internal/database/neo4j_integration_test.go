@@ -1,69 +1,17 @@
-package database
+package database_test
 
 import (
 	"context"
 	"testing"
-	"time"
+
+	"payforwardnow/internal/migrations"
+	"payforwardnow/internal/testinfra"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
-	"github.com/testcontainers/testcontainers-go"
-	"github.com/testcontainers/testcontainers-go/wait"
 )
 
-func setupNeo4jContainer(t *testing.T) (*Neo4jClient, func()) {
-	ctx := context.Background()
-
-	req := testcontainers.ContainerRequest{
-		Image:        "neo4j:5.15",
-		ExposedPorts: []string{"7687/tcp"},
-		Env: map[string]string{
-			"NEO4J_AUTH": "neo4j/testpassword",
-		},
-		WaitingFor: wait.ForLog("Started").WithStartupTimeout(60 * time.Second),
-	}
-
-	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
-		ContainerRequest: req,
-		Started:          true,
-	})
-	if err != nil {
-		t.Fatalf("Failed to start container: %v", err)
-	}
-
-	host, err := container.Host(ctx)
-	if err != nil {
-		t.Fatalf("Failed to get container host: %v", err)
-	}
-
-	mappedPort, err := container.MappedPort(ctx, "7687")
-	if err != nil {
-		t.Fatalf("Failed to get mapped port: %v", err)
-	}
-
-	uri := "bolt://" + host + ":" + mappedPort.Port()
-
-	time.Sleep(2 * time.Second)
-
-	client, err := NewNeo4jClient(uri, "neo4j", "testpassword")
-	if err != nil {
-		t.Fatalf("Failed to create Neo4j client: %v", err)
-	}
-
-	cleanup := func() {
-		client.Close()
-		container.Terminate(ctx)
-	}
-
-	return client, cleanup
-}
-
 func TestNeo4jClient_Connection(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
-	}
-
-	client, cleanup := setupNeo4jContainer(t)
-	defer cleanup()
+	client := testinfra.Shared(t).Client
 
 	ctx := context.Background()
 
@@ -81,12 +29,7 @@ func TestNeo4jClient_Connection(t *testing.T) {
 }
 
 func TestNeo4jClient_CreateAndReadNode(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
-	}
-
-	client, cleanup := setupNeo4jContainer(t)
-	defer cleanup()
+	client := testinfra.Shared(t).Client
 
 	ctx := context.Background()
 
@@ -142,12 +85,7 @@ func TestNeo4jClient_CreateAndReadNode(t *testing.T) {
 }
 
 func TestNeo4jClient_SchemaInitialization(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
-	}
-
-	client, cleanup := setupNeo4jContainer(t)
-	defer cleanup()
+	client := testinfra.Shared(t).Client
 
 	ctx := context.Background()
 
@@ -180,12 +118,7 @@ func TestNeo4jClient_SchemaInitialization(t *testing.T) {
 }
 
 func TestNeo4jClient_Transaction(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
-	}
-
-	client, cleanup := setupNeo4jContainer(t)
-	defer cleanup()
+	client := testinfra.Shared(t).Client
 
 	ctx := context.Background()
 
@@ -236,3 +169,40 @@ func TestNeo4jClient_Transaction(t *testing.T) {
 		t.Errorf("Expected 2 nodes, got %v", count)
 	}
 }
+
+func TestMigrations_UpDown(t *testing.T) {
+	// Reverting a migration would break the shared database
+	client := testinfra.Start(t).Client
+
+	ctx := context.Background()
+	schema, err := migrations.Embedded()
+	if err != nil {
+		t.Fatalf("Failed to load migrations: %v", err)
+	}
+	migrator := migrations.New(client, schema)
+
+	// Start already migrated, so nothing is re-run
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected no pending migrations, applied %v", applied)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if len(reverted) != 1 || reverted[0] != schema[len(schema)-1].Version {
+		t.Errorf("expected the latest migration to be reverted, got %v", reverted)
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		t.Fatalf("Pending failed: %v", err)
+	}
+	if len(pending) != 1 {
+		t.Errorf("expected 1 pending migration, got %d", len(pending))
+	}
+}
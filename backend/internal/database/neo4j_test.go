@@ -0,0 +1,45 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClientConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(*ClientConfig)
+		expectErr bool
+	}{
+		{"defaults", func(c *ClientConfig) {}, false},
+		{"fetch all", func(c *ClientConfig) { c.FetchSize = -1 }, false},
+		{"zero pool", func(c *ClientConfig) { c.MaxConnectionPoolSize = 0 }, true},
+		{"zero fetch size", func(c *ClientConfig) { c.FetchSize = 0 }, true},
+		{"negative fetch size", func(c *ClientConfig) { c.FetchSize = -5 }, true},
+		{"negative timeout", func(c *ClientConfig) { c.ConnectionAcquisitionTimeout = -time.Second }, true},
+		{"zero verify timeout", func(c *ClientConfig) { c.VerifyTimeout = 0 }, true},
+		{"leader reads", func(c *ClientConfig) { c.ReadRouting = ReadFromLeader }, false},
+		{"unknown read routing", func(c *ClientConfig) { c.ReadRouting = "primary" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultClientConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestNewNeo4jClientWithConfig_Invalid(t *testing.T) {
+	cfg := DefaultClientConfig()
+	cfg.MaxConnectionPoolSize = 0
+
+	if _, err := NewNeo4jClientWithConfig("bolt://localhost:7687", "neo4j", "password", cfg); err == nil {
+		t.Error("expected invalid settings to be rejected before connecting")
+	}
+}
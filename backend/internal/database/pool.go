@@ -0,0 +1,116 @@
+package database
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"payforwardnow/internal/metrics"
+)
+
+// PoolStats describes how the client uses the driver's connection pool. The
+// driver doesn't expose its pool, so connections in use are counted as the
+// transactions in flight, each of which holds one, and the acquisition wait
+// is the time from opening a session until the transaction function first
+// runs. A transaction that fails before its function runs counts as a
+// failed acquisition.
+type PoolStats struct {
+	MaxSize      int     `json:"maxSize"`
+	InUse        int64   `json:"inUse"`
+	Utilization  float64 `json:"utilization"`
+	Acquisitions int64   `json:"acquisitions"`
+	Failures     int64   `json:"failures"`
+	AvgWaitMs    float64 `json:"avgWaitMs"`
+	MaxWaitMs    float64 `json:"maxWaitMs"`
+}
+
+// PoolReporter is implemented by clients that can report their pool usage
+type PoolReporter interface {
+	PoolStats() PoolStats
+}
+
+// poolTracker accumulates PoolStats and mirrors them into metrics
+type poolTracker struct {
+	maxSize      int
+	inUse        atomic.Int64
+	acquisitions atomic.Int64
+	failures     atomic.Int64
+	totalWait    atomic.Int64
+	maxWait      atomic.Int64
+}
+
+func newPoolTracker(maxSize int) *poolTracker {
+	metrics.DBPoolMaxSize.Set(float64(maxSize))
+	return &poolTracker{maxSize: maxSize}
+}
+
+// track accounts for a transaction of the given operation. It returns work
+// wrapped to record the acquisition wait, and a function to call with the
+// transaction's error once it is done.
+func (p *poolTracker) track(operation string, work neo4j.ManagedTransactionWork) (neo4j.ManagedTransactionWork, func(error)) {
+	metrics.DBPoolInUse.Set(float64(p.inUse.Add(1)))
+	start := time.Now()
+
+	var acquired atomic.Bool
+	wrapped := func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if acquired.CompareAndSwap(false, true) {
+			p.acquired(operation, time.Since(start))
+		}
+		return work(tx)
+	}
+
+	done := func(err error) {
+		metrics.DBPoolInUse.Set(float64(p.inUse.Add(-1)))
+		if err != nil && !acquired.Load() {
+			p.failures.Add(1)
+			metrics.DBPoolAcquisitionFailures.WithLabelValues(operation).Inc()
+		}
+	}
+	return wrapped, done
+}
+
+func (p *poolTracker) acquired(operation string, wait time.Duration) {
+	p.acquisitions.Add(1)
+	p.totalWait.Add(int64(wait))
+	for {
+		longest := p.maxWait.Load()
+		if int64(wait) <= longest || p.maxWait.CompareAndSwap(longest, int64(wait)) {
+			break
+		}
+	}
+	metrics.DBPoolAcquisitionSeconds.WithLabelValues(operation).Observe(wait.Seconds())
+}
+
+func (p *poolTracker) stats() PoolStats {
+	stats := PoolStats{
+		MaxSize:      p.maxSize,
+		InUse:        p.inUse.Load(),
+		Acquisitions: p.acquisitions.Load(),
+		Failures:     p.failures.Load(),
+		MaxWaitMs:    durationMs(time.Duration(p.maxWait.Load())),
+	}
+	if p.maxSize > 0 {
+		stats.Utilization = float64(stats.InUse) / float64(p.maxSize)
+	}
+	if stats.Acquisitions > 0 {
+		stats.AvgWaitMs = durationMs(time.Duration(p.totalWait.Load() / stats.Acquisitions))
+	}
+	return stats
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// PoolStatsOf returns the pool usage of db, looking through a circuit
+// breaker, or false if db doesn't track it
+func PoolStatsOf(db DBClient) (PoolStats, bool) {
+	if breaker, ok := db.(*CircuitBreaker); ok {
+		db = breaker.DBClient
+	}
+	if reporter, ok := db.(PoolReporter); ok {
+		return reporter.PoolStats(), true
+	}
+	return PoolStats{}, false
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestPoolTracker(t *testing.T) {
+	pool := newPoolTracker(4)
+	work := func(tx neo4j.ManagedTransaction) (interface{}, error) { return nil, nil }
+
+	// A transaction whose function runs, retried once
+	wrapped, done := pool.track("read", work)
+	if stats := pool.stats(); stats.InUse != 1 || stats.Utilization != 0.25 {
+		t.Errorf("expected one connection in use, got %+v", stats)
+	}
+	time.Sleep(2 * time.Millisecond)
+	wrapped(nil)
+	wrapped(nil)
+	done(nil)
+
+	// A transaction failing before its function runs
+	_, done = pool.track("write", work)
+	done(errors.New("connection acquisition timed out"))
+
+	stats := pool.stats()
+	if stats.InUse != 0 || stats.Acquisitions != 1 || stats.Failures != 1 {
+		t.Errorf("expected 1 acquisition and 1 failure with nothing in use, got %+v", stats)
+	}
+	if stats.AvgWaitMs < 2 || stats.MaxWaitMs != stats.AvgWaitMs {
+		t.Errorf("expected the wait to be recorded, got %+v", stats)
+	}
+}
+
+func TestPoolStatsOf(t *testing.T) {
+	client := &Neo4jClient{pool: newPoolTracker(10)}
+	if stats, ok := PoolStatsOf(NewCircuitBreaker(client, BreakerConfig{})); !ok || stats.MaxSize != 10 {
+		t.Errorf("expected pool stats through the circuit breaker, got %+v, %v", stats, ok)
+	}
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// Read routing policies. On a Neo4j cluster, reads normally go to
+// secondaries and read replicas, which may lag behind the writer.
+const (
+	// ReadFromReplicas routes read transactions to followers and read
+	// replicas, keeping load off the writer
+	ReadFromReplicas = "replicas"
+	// ReadFromLeader routes read transactions to the writer, which always
+	// has the latest data
+	ReadFromLeader = "leader"
+)
+
+type readRoutingKey struct{}
+
+// WithReadRouting returns a context whose read transactions follow policy
+// instead of the client's default, for example ReadFromLeader on a path
+// that reads right after a write. An empty policy keeps the default.
+func WithReadRouting(ctx context.Context, policy string) context.Context {
+	return context.WithValue(ctx, readRoutingKey{}, policy)
+}
+
+// ReadRoutingFromContext returns the policy set with WithReadRouting, or ""
+func ReadRoutingFromContext(ctx context.Context) string {
+	policy, _ := ctx.Value(readRoutingKey{}).(string)
+	return policy
+}
+
+// ValidReadRouting checks that policy is a known read routing policy
+func ValidReadRouting(policy string) error {
+	switch policy {
+	case ReadFromReplicas, ReadFromLeader:
+		return nil
+	}
+	return fmt.Errorf("unknown read routing %q; use %s or %s", policy, ReadFromReplicas, ReadFromLeader)
+}
+
+// readsFromLeader reports whether the read transactions of ctx go to the
+// writer, under the policy of ctx or else defaultPolicy
+func readsFromLeader(ctx context.Context, defaultPolicy string) bool {
+	if policy := ReadRoutingFromContext(ctx); policy != "" {
+		return policy == ReadFromLeader
+	}
+	return defaultPolicy == ReadFromLeader
+}
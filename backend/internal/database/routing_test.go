@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReadsFromLeader(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		defaultPolicy string
+		expect        bool
+	}{
+		{"default replicas", ctx, ReadFromReplicas, false},
+		{"default leader", ctx, ReadFromLeader, true},
+		{"unset default", ctx, "", false},
+		{"request forces leader", WithReadRouting(ctx, ReadFromLeader), ReadFromReplicas, true},
+		{"request allows replicas", WithReadRouting(ctx, ReadFromReplicas), ReadFromLeader, false},
+		{"empty override keeps default", WithReadRouting(ctx, ""), ReadFromLeader, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readsFromLeader(tt.ctx, tt.defaultPolicy); got != tt.expect {
+				t.Errorf("expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+}
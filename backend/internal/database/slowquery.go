@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/queries"
+)
+
+// maxStatementLength bounds the Cypher text logged for each statement of a
+// slow transaction
+const maxStatementLength = 120
+
+// statementLog records the statements run by a transaction function so they
+// can be reported if the transaction turns out to be slow. A retried
+// transaction only keeps the statements of its last attempt.
+type statementLog struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+// wrap returns work with its transaction recording every statement it runs
+func (l *statementLog) wrap(work neo4j.ManagedTransactionWork) neo4j.ManagedTransactionWork {
+	return func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		l.mu.Lock()
+		l.statements = nil
+		l.mu.Unlock()
+		return work(recordingTx{ManagedTransaction: tx, log: l})
+	}
+}
+
+func (l *statementLog) add(cypher string, params map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.statements = append(l.statements, summarizeStatement(cypher, params))
+}
+
+func (l *statementLog) values() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.statements...)
+}
+
+// recordingTx is a ManagedTransaction that notes each statement it runs
+type recordingTx struct {
+	neo4j.ManagedTransaction
+	log *statementLog
+}
+
+func (tx recordingTx) Run(ctx context.Context, cypher string, params map[string]interface{}) (neo4j.ResultWithContext, error) {
+	tx.log.add(cypher, params)
+	return tx.ManagedTransaction.Run(ctx, cypher, params)
+}
+
+// summarizeStatement names cypher by its registered query name, or else
+// shortens it to a single line, and appends the names and shapes of its
+// parameters. Parameter values are never included, since they may hold
+// personal data.
+func summarizeStatement(cypher string, params map[string]interface{}) string {
+	statement, ok := queries.NameOf(cypher)
+	if !ok {
+		statement = strings.Join(strings.Fields(cypher), " ")
+		if len(statement) > maxStatementLength {
+			statement = statement[:maxStatementLength] + "..."
+		}
+	}
+	if len(params) == 0 {
+		return statement
+	}
+	return statement + " " + summarizeParams(params)
+}
+
+// summarizeParams describes params as "{id: string, rows: list[500]}", with
+// keys in sorted order
+func summarizeParams(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + ": " + paramShape(params[k])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func paramShape(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("list[%d]", v.Len())
+	case reflect.Map:
+		return fmt.Sprintf("map[%d]", v.Len())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	}
+	return v.Type().String()
+}
+
+// reportSlowQuery logs and counts a transaction that took longer than
+// threshold. A zero threshold disables reporting.
+func reportSlowQuery(ctx context.Context, threshold time.Duration, operation, name string, elapsed time.Duration, statements []string, err error) {
+	if threshold <= 0 || elapsed < threshold {
+		return
+	}
+
+	metrics.DBSlowQueries.WithLabelValues(operation, name).Inc()
+
+	attrs := []any{
+		"query", name,
+		"operation", operation,
+		"duration_ms", elapsed.Milliseconds(),
+		"threshold_ms", threshold.Milliseconds(),
+		"statements", statements,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	slog.WarnContext(ctx, "Slow database transaction", attrs...)
+}
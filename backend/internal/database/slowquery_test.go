@@ -0,0 +1,41 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/queries"
+)
+
+func TestSummarizeStatement(t *testing.T) {
+	got := summarizeStatement("MATCH (u:User {id: $id})\n  RETURN u LIMIT $limit", map[string]interface{}{
+		"limit": 10,
+		"id":    "secret-user-id",
+		"rows":  []map[string]interface{}{{}, {}},
+		"props": map[string]interface{}{"a": 1},
+		"none":  nil,
+		"score": 1.5,
+	})
+
+	want := "MATCH (u:User {id: $id}) RETURN u LIMIT $limit {id: string, limit: int, none: null, props: map[1], rows: list[2], score: float}"
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if strings.Contains(got, "secret") {
+		t.Error("summary must not include parameter values")
+	}
+}
+
+func TestSummarizeStatement_UsesRegisteredName(t *testing.T) {
+	got := summarizeStatement(queries.UserGet.Cypher, map[string]interface{}{"id": "u1"})
+	if want := "users.get {id: string}"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestSummarizeStatement_Truncates(t *testing.T) {
+	got := summarizeStatement(strings.Repeat("x", 500), nil)
+	if len(got) != maxStatementLength+len("...") {
+		t.Errorf("expected statement truncated to %d characters, got %d", maxStatementLength, len(got))
+	}
+}
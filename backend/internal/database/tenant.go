@@ -0,0 +1,19 @@
+package database
+
+import "context"
+
+type databaseKey struct{}
+
+// WithDatabase returns a context whose sessions run against the named
+// database rather than the server's default one. Tenants with their own
+// database are isolated this way; an empty name selects the default.
+func WithDatabase(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, databaseKey{}, name)
+}
+
+// DatabaseFromContext returns the database selected with WithDatabase, or
+// "" for the server's default database
+func DatabaseFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(databaseKey{}).(string)
+	return name
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// auraDomain is the host suffix of Neo4j Aura instances, which only accept
+// encrypted connections
+const auraDomain = ".databases.neo4j.io"
+
+// parseScheme checks that uri uses a scheme the driver supports and reports
+// whether it encrypts connections with verified certificates (bolt+s,
+// neo4j+s). The +ssc schemes encrypt but accept self-signed certificates.
+func parseScheme(uri string) (verified bool, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false, fmt.Errorf("invalid Neo4j URI: %w", err)
+	}
+
+	switch u.Scheme {
+	case "bolt", "neo4j", "bolt+ssc", "neo4j+ssc":
+	case "bolt+s", "neo4j+s":
+		verified = true
+	default:
+		return false, fmt.Errorf("unsupported Neo4j URI scheme %q; use bolt, neo4j or their +s and +ssc variants", u.Scheme)
+	}
+
+	if strings.HasSuffix(u.Hostname(), auraDomain) && !verified {
+		return false, fmt.Errorf("Neo4j Aura requires the neo4j+s scheme, got %q", u.Scheme)
+	}
+	return verified, nil
+}
+
+// tlsConfig returns the TLS settings for uri. Only bolt+s and neo4j+s
+// connections use a custom CA; the driver trusts the system roots otherwise.
+func tlsConfig(uri string, cfg ClientConfig) (*tls.Config, error) {
+	verified, err := parseScheme(uri)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.CACertFile == "" {
+		return nil, nil
+	}
+	if !verified {
+		return nil, fmt.Errorf("a CA certificate requires a bolt+s or neo4j+s URI")
+	}
+
+	pem, err := os.ReadFile(cfg.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.CACertFile)
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// verifyConnectivity checks that the database is reachable, retrying until
+// timeout since Aura instances can take a while to accept connections after
+// they resume. Authentication failures are returned straight away.
+func verifyConnectivity(driver neo4j.DriverWithContext, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	backoff := 250 * time.Millisecond
+	for {
+		err := driver.VerifyConnectivity(ctx)
+		if err == nil || !retryableConnectError(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		if backoff < 2*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// retryableConnectError reports whether err may go away on its own, as
+// opposed to bad credentials or certificate failures. The driver doesn't
+// export its TLS error type, so those are recognised by their message.
+func retryableConnectError(err error) bool {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		return neo4jErr.Code == "Neo.TransientError.General.DatabaseUnavailable"
+	}
+	if msg := err.Error(); strings.Contains(msg, "x509:") || strings.Contains(msg, "tls:") {
+		return false
+	}
+	var connErr *neo4j.ConnectivityError
+	return errors.As(err, &connErr)
+}
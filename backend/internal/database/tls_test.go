@@ -0,0 +1,97 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseScheme(t *testing.T) {
+	tests := []struct {
+		uri       string
+		verified  bool
+		expectErr bool
+	}{
+		{"bolt://localhost:7687", false, false},
+		{"neo4j://db.internal:7687", false, false},
+		{"neo4j+s://db.internal:7687", true, false},
+		{"bolt+s://db.internal:7687", true, false},
+		{"neo4j+ssc://db.internal:7687", false, false},
+		{"neo4j+s://abcd1234.databases.neo4j.io", true, false},
+		{"neo4j://abcd1234.databases.neo4j.io", false, true},
+		{"neo4j+ssc://abcd1234.databases.neo4j.io", false, true},
+		{"http://localhost:7474", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			verified, err := parseScheme(tt.uri)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("expected error %v, got %v", tt.expectErr, err)
+			}
+			if verified != tt.verified {
+				t.Errorf("expected verified %v, got %v", tt.verified, verified)
+			}
+		})
+	}
+}
+
+func TestTLSConfig(t *testing.T) {
+	cfg := DefaultClientConfig()
+	if tlsCfg, err := tlsConfig("neo4j+s://db.internal", cfg); err != nil || tlsCfg != nil {
+		t.Errorf("expected driver defaults without a CA, got %v (%v)", tlsCfg, err)
+	}
+
+	cfg.CACertFile = writeTestCA(t)
+	tlsCfg, err := tlsConfig("neo4j+s://db.internal", cfg)
+	if err != nil {
+		t.Fatalf("tlsConfig failed: %v", err)
+	}
+	if tlsCfg == nil || tlsCfg.RootCAs == nil {
+		t.Fatal("expected a TLS config trusting the CA")
+	}
+
+	if _, err := tlsConfig("neo4j://db.internal", cfg); err == nil {
+		t.Error("expected a CA to be rejected for an unencrypted scheme")
+	}
+
+	cfg.CACertFile = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err := tlsConfig("neo4j+s://db.internal", cfg); err == nil {
+		t.Error("expected an error for a missing CA file")
+	}
+}
+
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
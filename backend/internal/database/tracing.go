@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "payforwardnow/internal/database"
+
+type queryNameKey struct{}
+
+// WithQueryName sets the name used for the span of the next transaction run
+// with ctx. Without it the name of the calling function is used.
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// queryName returns the name set with WithQueryName, or else the name of the
+// function skip frames above the caller of queryName
+func queryName(ctx context.Context, skip int) string {
+	if name, ok := ctx.Value(queryNameKey{}).(string); ok && name != "" {
+		return name
+	}
+	return callerName(skip + 1)
+}
+
+// startSpan starts a client span for a transaction named name
+func startSpan(ctx context.Context, operation, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "neo4j."+operation+" "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "neo4j"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.query.summary", name),
+		),
+	)
+}
+
+// endSpan records err on span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// callerName returns a short name such as "Handler.GetActs" for the function
+// skip frames above the caller of callerName, ignoring closure suffixes
+func callerName(skip int) string {
+	pc, _, _, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	// Drop the package name
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	// Drop closure suffixes like ".func1"
+	for {
+		i := strings.LastIndex(name, ".func")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
+
+// withCallerName names the span of the next transaction after the function
+// skip frames above the caller, unless a name is already set. Wrappers around
+// a DBClient use it so spans are not named after the wrapper.
+func withCallerName(ctx context.Context, skip int) context.Context {
+	if name, ok := ctx.Value(queryNameKey{}).(string); ok && name != "" {
+		return ctx
+	}
+	return WithQueryName(ctx, callerName(skip+1))
+}
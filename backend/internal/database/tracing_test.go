@@ -0,0 +1,22 @@
+package database
+
+import (
+	"testing"
+)
+
+type tracedClient struct{}
+
+func (tracedClient) run() string {
+	return callerName(0)
+}
+
+func TestCallerName(t *testing.T) {
+	if got := (tracedClient{}).run(); got != "tracedClient.run" {
+		t.Errorf("expected tracedClient.run, got %s", got)
+	}
+
+	closure := func() string { return callerName(0) }
+	if got := closure(); got != "TestCallerName" {
+		t.Errorf("expected TestCallerName, got %s", got)
+	}
+}
@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ExecuteRead runs work in a read transaction on db and returns its result
+// with its static type, so callers don't need to assert it
+func ExecuteRead[T any](ctx context.Context, db DBClient, work func(tx neo4j.ManagedTransaction) (T, error)) (T, error) {
+	result, err := db.ExecuteRead(withCallerName(ctx, 1), func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return work(tx)
+	})
+	return typedResult[T](result, err)
+}
+
+// ExecuteWrite runs work in a write transaction on db and returns its result
+// with its static type, so callers don't need to assert it
+func ExecuteWrite[T any](ctx context.Context, db DBClient, work func(tx neo4j.ManagedTransaction) (T, error)) (T, error) {
+	result, err := db.ExecuteWrite(withCallerName(ctx, 1), func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return work(tx)
+	})
+	return typedResult[T](result, err)
+}
+
+// typedResult converts the result of an untyped transaction back to T. A nil
+// result, as returned on errors, becomes the zero value.
+func typedResult[T any](result interface{}, err error) (T, error) {
+	var zero T
+	if err != nil || result == nil {
+		return zero, err
+	}
+	typed, ok := result.(T)
+	if !ok {
+		return zero, fmt.Errorf("transaction returned %T, expected %T", result, zero)
+	}
+	return typed, nil
+}
+
+// Collect maps every remaining record of result with mapper
+func Collect[T any](ctx context.Context, result neo4j.ResultWithContext, mapper func(*neo4j.Record) (T, error)) ([]T, error) {
+	var values []T
+	for result.Next(ctx) {
+		value, err := mapper(result.Record())
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, result.Err()
+}
+
+// First maps the next record of result with mapper. It reports false, with
+// the zero value, when there are no more records.
+func First[T any](ctx context.Context, result neo4j.ResultWithContext, mapper func(*neo4j.Record) (T, error)) (T, bool, error) {
+	var zero T
+	if !result.Next(ctx) {
+		return zero, false, result.Err()
+	}
+	value, err := mapper(result.Record())
+	if err != nil {
+		return zero, false, err
+	}
+	return value, true, nil
+}
+
+// RecordValue returns the value of key in record as a T. A null value gives
+// the zero value; a missing key or a value of another type is an error.
+func RecordValue[T any](record *neo4j.Record, key string) (T, error) {
+	var zero T
+	raw, ok := record.Get(key)
+	if !ok {
+		return zero, fmt.Errorf("record has no key %q", key)
+	}
+	if raw == nil {
+		return zero, nil
+	}
+	value, ok := raw.(T)
+	if !ok {
+		return zero, fmt.Errorf("record key %q is %T, expected %T", key, raw, zero)
+	}
+	return value, nil
+}
+
+// Stream runs the query started by run in a read transaction and passes
+// each record, mapped with mapper, to fn as it arrives instead of collecting
+// them, so memory stays bounded however many records there are. An error
+// from fn stops the stream and is returned. The query must return records
+// in a stable order: if the driver retries the transaction after a
+// transient failure, the records fn already received are skipped.
+func Stream[T any](ctx context.Context, db DBClient, run func(tx neo4j.ManagedTransaction) (neo4j.ResultWithContext, error), mapper func(*neo4j.Record) (T, error), fn func(T) error) error {
+	delivered := 0
+	_, err := db.ExecuteRead(withCallerName(ctx, 1), func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := run(tx)
+		if err != nil {
+			return nil, err
+		}
+		for seen := 0; result.Next(ctx); seen++ {
+			if seen < delivered {
+				continue
+			}
+			value, err := mapper(result.Record())
+			if err != nil {
+				return nil, err
+			}
+			if err := fn(value); err != nil {
+				return nil, err
+			}
+			delivered++
+		}
+		return nil, result.Err()
+	})
+	return err
+}
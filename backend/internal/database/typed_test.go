@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestTypedResult(t *testing.T) {
+	if got, err := typedResult[int64](int64(42), nil); err != nil || got != 42 {
+		t.Errorf("expected 42, got %d (%v)", got, err)
+	}
+
+	if got, err := typedResult[*neo4j.Node](nil, nil); err != nil || got != nil {
+		t.Errorf("expected nil result to give the zero value, got %v (%v)", got, err)
+	}
+
+	dbErr := errors.New("connection refused")
+	if _, err := typedResult[int64](nil, dbErr); !errors.Is(err, dbErr) {
+		t.Errorf("expected database error, got %v", err)
+	}
+
+	if _, err := typedResult[int64]("42", nil); err == nil {
+		t.Error("expected an error for a result of the wrong type")
+	}
+}
+
+func TestRecordValue(t *testing.T) {
+	record := &neo4j.Record{
+		Keys:   []string{"name", "count", "avatar"},
+		Values: []any{"Ada", int64(3), nil},
+	}
+
+	if name, err := RecordValue[string](record, "name"); err != nil || name != "Ada" {
+		t.Errorf("expected Ada, got %q (%v)", name, err)
+	}
+	if avatar, err := RecordValue[string](record, "avatar"); err != nil || avatar != "" {
+		t.Errorf("expected null to give the zero value, got %q (%v)", avatar, err)
+	}
+	if _, err := RecordValue[string](record, "count"); err == nil {
+		t.Error("expected an error for a value of the wrong type")
+	}
+	if _, err := RecordValue[string](record, "missing"); err == nil {
+		t.Error("expected an error for a missing key")
+	}
+}
+
+// listResult is a result over a fixed list of records
+type listResult struct {
+	neo4j.ResultWithContext
+	records []*neo4j.Record
+	next    int
+}
+
+func (r *listResult) Next(context.Context) bool {
+	r.next++
+	return r.next <= len(r.records)
+}
+
+func (r *listResult) Record() *neo4j.Record { return r.records[r.next-1] }
+
+func (r *listResult) Err() error { return nil }
+
+// retryingDB runs read transactions twice, like the driver retrying after a
+// transient failure
+type retryingDB struct {
+	DBClient
+}
+
+func (db retryingDB) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	if _, err := work(nil); err != nil {
+		return nil, err
+	}
+	return work(nil)
+}
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	records := make([]*neo4j.Record, 3)
+	for i := range records {
+		records[i] = &neo4j.Record{Keys: []string{"n"}, Values: []any{int64(i)}}
+	}
+	run := func(tx neo4j.ManagedTransaction) (neo4j.ResultWithContext, error) {
+		return &listResult{records: records}, nil
+	}
+	mapper := func(record *neo4j.Record) (int64, error) { return RecordValue[int64](record, "n") }
+
+	var got []int64
+	err := Stream(ctx, retryingDB{}, run, mapper, func(n int64) error {
+		got = append(got, n)
+		return nil
+	})
+	if err != nil || !slices.Equal(got, []int64{0, 1, 2}) {
+		t.Errorf("expected each record once across retries, got %v (%v)", got, err)
+	}
+
+	stop := errors.New("client went away")
+	err = Stream(ctx, retryingDB{}, run, mapper, func(n int64) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Errorf("expected the callback's error, got %v", err)
+	}
+}
@@ -0,0 +1,344 @@
+package databasetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/repository"
+)
+
+// Acts is an in-memory ActRepository
+type Acts struct {
+	db *DB
+}
+
+// Ensure Acts implements repository.ActRepository
+var _ repository.ActRepository = (*Acts)(nil)
+
+// List returns a page of acts, newest first, and the total count
+func (r *Acts) List(_ context.Context, page models.PaginationParams) ([]models.Act, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	acts := make([]models.Act, 0, len(r.db.acts))
+	for _, act := range r.db.acts {
+		acts = append(acts, publicAct(act))
+	}
+	sort.Slice(acts, func(i, j int) bool {
+		return acts[i].CreatedAt.After(acts[j].CreatedAt)
+	})
+	return paginate(acts, page), int64(len(acts)), nil
+}
+
+// Nearby returns a page of the acts placed within radiusKm of latitude,
+// longitude, nearest first, and how many there are
+func (r *Acts) Nearby(_ context.Context, latitude, longitude, radiusKm float64, page models.PaginationParams) ([]models.NearbyAct, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	acts := []models.NearbyAct{}
+	for _, act := range r.db.acts {
+		if act.Latitude == nil || act.Longitude == nil {
+			continue
+		}
+		if d := distanceKm(latitude, longitude, *act.Latitude, *act.Longitude); d <= radiusKm {
+			acts = append(acts, models.NearbyAct{Act: publicAct(act), DistanceKm: d})
+		}
+	}
+	sort.Slice(acts, func(i, j int) bool {
+		if acts[i].DistanceKm != acts[j].DistanceKm {
+			return acts[i].DistanceKm < acts[j].DistanceKm
+		}
+		return acts[i].ID < acts[j].ID
+	})
+	return paginate(acts, page), int64(len(acts)), nil
+}
+
+// Screen records the screening of an act's title and description at now
+func (r *Acts) Screen(_ context.Context, id string, screening moderation.Result, now time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.acts[id]; !ok {
+		return repository.ErrActNotFound
+	}
+	r.db.actScreenings[id] = models.FlaggedAct{
+		Screening: models.Screening{
+			Score:   screening.Score,
+			Flags:   append([]string{}, screening.Flags...),
+			Verdict: string(screening.Verdict),
+		},
+		ScreenedAt: now.UTC(),
+	}
+	return nil
+}
+
+// Flagged returns a page of the acts screening flagged, highest score
+// first, and how many there are
+func (r *Acts) Flagged(_ context.Context, page models.PaginationParams) ([]models.FlaggedAct, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	flagged := []models.FlaggedAct{}
+	for id, screening := range r.db.actScreenings {
+		act, ok := r.db.acts[id]
+		if !ok || screening.Screening.Verdict != string(moderation.Flag) {
+			continue
+		}
+		screening.Act = publicAct(act)
+		flagged = append(flagged, screening)
+	}
+	sort.Slice(flagged, func(i, j int) bool {
+		if flagged[i].Screening.Score != flagged[j].Screening.Score {
+			return flagged[i].Screening.Score > flagged[j].Screening.Score
+		}
+		return flagged[i].ID < flagged[j].ID
+	})
+	return paginate(flagged, page), int64(len(flagged)), nil
+}
+
+// DismissFlag clears the flag of an act found acceptable
+func (r *Acts) DismissFlag(_ context.Context, id, _ string, _ time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	screening, ok := r.db.actScreenings[id]
+	if _, exists := r.db.acts[id]; !exists || !ok || screening.Screening.Verdict != string(moderation.Flag) {
+		return repository.ErrActNotFound
+	}
+	screening.Screening.Verdict = string(moderation.Allow)
+	r.db.actScreenings[id] = screening
+	return nil
+}
+
+// TakeDown moves an act to the trash like Delete, recording the moderator's
+// reason in its act.deleted event, or returns repository.ErrActNotFound
+func (r *Acts) TakeDown(_ context.Context, id, reason, moderatorID string, now time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	act, ok := r.db.acts[id]
+	if !ok {
+		return repository.ErrActNotFound
+	}
+	act.Version++
+	r.db.recordEvent(models.DomainActDeleted, id, models.ActTakedown{DeletedAt: now.UTC(), ModeratorID: moderatorID, Reason: reason})
+	r.db.trash["acts"][id] = trashed{entity: act, deletedAt: now.UTC()}
+	delete(r.db.acts, id)
+	return nil
+}
+
+// Stream passes every act to fn, oldest first. The acts are copied first,
+// so fn may use the other repositories.
+func (r *Acts) Stream(_ context.Context, fn func(*models.Act) error) error {
+	r.db.mu.Lock()
+	acts := make([]models.Act, 0, len(r.db.acts))
+	for _, act := range r.db.acts {
+		acts = append(acts, publicAct(act))
+	}
+	r.db.mu.Unlock()
+
+	sort.Slice(acts, func(i, j int) bool {
+		if !acts[i].CreatedAt.Equal(acts[j].CreatedAt) {
+			return acts[i].CreatedAt.Before(acts[j].CreatedAt)
+		}
+		return acts[i].ID < acts[j].ID
+	})
+	for i := range acts {
+		if err := fn(&acts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Completed returns up to limit acts completed after after, or at after
+// with an ID after afterID, in the order they were completed
+func (r *Acts) Completed(_ context.Context, after time.Time, afterID string, limit int) ([]models.Act, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	acts := []models.Act{}
+	for _, act := range r.db.acts {
+		if act.CompletedAt == nil {
+			continue
+		}
+		if act.CompletedAt.After(after) || (act.CompletedAt.Equal(after) && act.ID > afterID) {
+			acts = append(acts, publicAct(act))
+		}
+	}
+	sort.Slice(acts, func(i, j int) bool {
+		if !acts[i].CompletedAt.Equal(*acts[j].CompletedAt) {
+			return acts[i].CompletedAt.Before(*acts[j].CompletedAt)
+		}
+		return acts[i].ID < acts[j].ID
+	})
+	return acts[:min(limit, len(acts))], nil
+}
+
+// Get returns an act, or repository.ErrActNotFound
+func (r *Acts) Get(_ context.Context, id string) (*models.Act, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	act, ok := r.db.acts[id]
+	if !ok {
+		return nil, repository.ErrActNotFound
+	}
+	found := publicAct(act)
+	return &found, nil
+}
+
+// Giver returns the ID of the user who gave an act, even an anonymous one,
+// or repository.ErrActNotFound
+func (r *Acts) Giver(_ context.Context, id string) (string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	act, ok := r.db.acts[id]
+	if !ok {
+		return "", repository.ErrActNotFound
+	}
+	return act.GiverID, nil
+}
+
+// Create stores act, links it to its giver and adds it to the chain named by
+// act.ChainID, if it exists. It returns false if the giver does not exist,
+// in which case nothing is stored.
+func (r *Acts) Create(_ context.Context, act *models.Act) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[act.GiverID]; !ok {
+		return false, nil
+	}
+	r.db.convert(act)
+	stored := *act
+	stored.Giver, stored.Receiver = nil, nil
+	stored.Version = 1
+	r.db.acts[act.ID] = &stored
+
+	if chain, ok := r.db.chains[act.ChainID]; ok {
+		r.db.chainActs[chain.ID] = append(r.db.chainActs[chain.ID], act.ID)
+		if r.db.participants[chain.ID] == nil {
+			r.db.participants[chain.ID] = make(map[string]bool)
+		}
+		r.db.participants[chain.ID][act.GiverID] = true
+		chain.UpdatedAt = act.CreatedAt
+	}
+	if _, ok := r.db.campaigns[act.CampaignID]; ok {
+		r.db.joinCampaign(act.CampaignID, act.GiverID)
+	}
+	if _, ok := r.db.orgMembers[act.OrganizationID][act.GiverID]; ok {
+		r.db.givenFor[act.ID] = act.OrganizationID
+	}
+	r.db.recordEvent(models.DomainActCreated, act.ID, stored)
+	return true, nil
+}
+
+// Update changes the fields set in req, recording when an act is completed,
+// and returns the act's new version, or returns repository.ErrActNotFound or
+// repository.ErrVersionConflict
+func (r *Acts) Update(_ context.Context, id string, req models.UpdateActRequest) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	act, ok := r.db.acts[id]
+	if !ok {
+		return 0, repository.ErrActNotFound
+	}
+	if err := checkVersion(act.Version, req.Version); err != nil {
+		return 0, err
+	}
+	now := time.Now().UTC()
+	setIfNotEmpty(&act.Title, req.Title)
+	setIfNotEmpty(&act.Description, req.Description)
+	completed := req.Status == models.ActStatusCompleted && act.Status != models.ActStatusCompleted
+	if completed {
+		act.CompletedAt = &now
+	}
+	if req.Status != "" {
+		act.Status = req.Status
+	}
+	act.UpdatedAt = now
+	act.Version++
+	r.db.recordEvent(models.DomainActUpdated, id, act)
+	if completed {
+		r.db.recordEvent(models.DomainActCompleted, id, act)
+		r.db.issueReceipt(act, now)
+	}
+	return act.Version, nil
+}
+
+// Delete moves an act to the trash, where it keeps its relationships until
+// restored or purged
+func (r *Acts) Delete(_ context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if act, ok := r.db.acts[id]; ok {
+		act.Version++
+		r.db.trash["acts"][id] = r.db.softDelete(models.DomainActDeleted, id, act)
+		delete(r.db.acts, id)
+	}
+	return nil
+}
+
+// CreateBatch stores acts as given, replacing any with the same ID and
+// skipping those whose giver does not exist
+func (r *Acts) CreateBatch(_ context.Context, acts []models.Act) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	written := 0
+	for _, act := range acts {
+		if _, ok := r.db.users[act.GiverID]; !ok {
+			continue
+		}
+		stored := act
+		stored.Giver, stored.Receiver = nil, nil
+		stored.Version = 1
+		if existing, ok := r.db.acts[act.ID]; ok {
+			stored.Version = existing.Version + 1
+		}
+		r.db.acts[act.ID] = &stored
+		written++
+	}
+	return written, nil
+}
+
+// Expire marks the acts left pending since before before expired at now
+// and returns how many
+func (r *Acts) Expire(_ context.Context, before, now time.Time) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	expired := 0
+	for id, act := range r.db.acts {
+		if (act.Status != "" && act.Status != models.ActStatusPending) || !act.CreatedAt.Before(before) {
+			continue
+		}
+		act.Status = models.ActStatusExpired
+		act.UpdatedAt = now
+		act.Version++
+		r.db.recordEvent(models.DomainActExpired, id, struct{}{})
+		expired++
+	}
+	return expired, nil
+}
+
+// publicAct copies act, leaving out the giver of an anonymous act
+func publicAct(act *models.Act) models.Act {
+	found := *act
+	if found.CompletedAt != nil {
+		completedAt := *found.CompletedAt
+		found.CompletedAt = &completedAt
+	}
+	if found.IsAnonymous {
+		found.GiverID = ""
+	}
+	return found
+}
@@ -0,0 +1,181 @@
+package databasetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Campaigns is an in-memory CampaignRepository
+type Campaigns struct {
+	db *DB
+}
+
+// Ensure Campaigns implements repository.CampaignRepository
+var _ repository.CampaignRepository = (*Campaigns)(nil)
+
+// Create stores campaign
+func (r *Campaigns) Create(_ context.Context, campaign *models.Campaign) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored := *campaign
+	stored.Status, stored.Progress = "", nil
+	r.db.campaigns[campaign.ID] = &stored
+	r.db.recordEvent(models.DomainCampaignCreated, campaign.ID, stored)
+	return nil
+}
+
+// Get returns a campaign with its progress, or
+// repository.ErrCampaignNotFound
+func (r *Campaigns) Get(_ context.Context, id string) (*models.Campaign, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	campaign, ok := r.db.campaigns[id]
+	if !ok {
+		return nil, repository.ErrCampaignNotFound
+	}
+	found := r.db.withProgress(campaign)
+	return &found, nil
+}
+
+// List returns a page of the campaigns with status at now, or all of them,
+// latest starting first and with their progress, and how many match
+func (r *Campaigns) List(_ context.Context, status models.CampaignStatus, now time.Time, page models.PaginationParams) ([]models.Campaign, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	campaigns := []models.Campaign{}
+	for _, campaign := range r.db.campaigns {
+		if status == "" || campaign.StatusAt(now) == status {
+			campaigns = append(campaigns, r.db.withProgress(campaign))
+		}
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		if !campaigns[i].StartsAt.Equal(campaigns[j].StartsAt) {
+			return campaigns[i].StartsAt.After(campaigns[j].StartsAt)
+		}
+		return campaigns[i].ID < campaigns[j].ID
+	})
+	return paginate(campaigns, page), int64(len(campaigns)), nil
+}
+
+// Join adds a user to a campaign's participants, or returns
+// repository.ErrCampaignNotFound
+func (r *Campaigns) Join(_ context.Context, id, userID string, _ time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.campaigns[id]; !ok {
+		return repository.ErrCampaignNotFound
+	}
+	if _, ok := r.db.users[userID]; !ok {
+		return repository.ErrCampaignNotFound
+	}
+	r.db.joinCampaign(id, userID)
+	return nil
+}
+
+// Leave removes a user from a campaign's participants, or returns
+// repository.ErrCampaignNotFound
+func (r *Campaigns) Leave(_ context.Context, id, userID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.campaigns[id]; !ok {
+		return repository.ErrCampaignNotFound
+	}
+	delete(r.db.campaignMembers[id], userID)
+	return nil
+}
+
+// Leaderboard ranks up to limit users by the acts they tagged to a
+// campaign, leaving out anonymous acts and users who opted out
+func (r *Campaigns) Leaderboard(_ context.Context, id string, limit int) ([]models.LeaderboardEntry, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	totals := make(map[string]*models.LeaderboardEntry)
+	for _, act := range r.db.acts {
+		user, ok := r.db.users[act.GiverID]
+		if act.CampaignID != id || act.IsAnonymous || !ok || user.HideFromLeaderboards {
+			continue
+		}
+		entry, ok := totals[user.ID]
+		if !ok {
+			entry = &models.LeaderboardEntry{User: models.LeaderboardUser{ID: user.ID, Name: user.Name, Avatar: user.Avatar}}
+			totals[user.ID] = entry
+		}
+		entry.ActsCount++
+		entry.TotalValue += actValue(act)
+	}
+	return rankLeaderboard(totals, limit), nil
+}
+
+// rankLeaderboard ranks up to limit of the totals by user, by number of
+// acts, then value, then ID
+func rankLeaderboard(totals map[string]*models.LeaderboardEntry, limit int) []models.LeaderboardEntry {
+	entries := []models.LeaderboardEntry{}
+	for _, entry := range totals {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ActsCount != b.ActsCount {
+			return a.ActsCount > b.ActsCount
+		}
+		if a.TotalValue != b.TotalValue {
+			return a.TotalValue > b.TotalValue
+		}
+		return a.User.ID < b.User.ID
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries
+}
+
+// withProgress returns a copy of campaign with the acts tagged to it and
+// its participants counted. The caller must hold db.mu.
+func (db *DB) withProgress(campaign *models.Campaign) models.Campaign {
+	found := *campaign
+	progress := &models.CampaignProgress{}
+	for _, act := range db.acts {
+		if act.CampaignID == campaign.ID {
+			progress.Acts++
+			progress.Value += actValue(act)
+		}
+	}
+	for userID := range db.campaignMembers[campaign.ID] {
+		if _, ok := db.users[userID]; ok {
+			progress.Participants++
+		}
+	}
+	found.Progress = progress
+	return found
+}
+
+// joinCampaign adds a user to a campaign's participants. The caller must
+// hold db.mu.
+func (db *DB) joinCampaign(id, userID string) {
+	if db.campaignMembers[id] == nil {
+		db.campaignMembers[id] = make(map[string]bool)
+	}
+	db.campaignMembers[id][userID] = true
+}
+
+// actValue is an act's value in the base currency, or as given when it
+// couldn't be converted
+func actValue(act *models.Act) float64 {
+	if act.BaseValue != nil {
+		return *act.BaseValue
+	}
+	return act.Value
+}
@@ -0,0 +1,78 @@
+package databasetest
+
+import (
+	"context"
+	"sort"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Chains is an in-memory ChainRepository
+type Chains struct {
+	db *DB
+}
+
+// Ensure Chains implements repository.ChainRepository
+var _ repository.ChainRepository = (*Chains)(nil)
+
+// Get returns a chain with the number of acts in it, or
+// repository.ErrChainNotFound
+func (r *Chains) Get(_ context.Context, id string) (*models.Chain, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	chain, ok := r.db.chains[id]
+	if !ok {
+		return nil, repository.ErrChainNotFound
+	}
+	found := *chain
+	found.ActsCount = 0
+	for _, actID := range r.db.chainActs[id] {
+		if _, ok := r.db.acts[actID]; ok {
+			found.ActsCount++
+		}
+	}
+	return &found, nil
+}
+
+// ListByUser returns the chains a user started or took part in, newest first
+func (r *Chains) ListByUser(_ context.Context, userID string) ([]models.Chain, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var chains []models.Chain
+	for id, chain := range r.db.chains {
+		if chain.StarterID == userID || r.db.participants[id][userID] {
+			chains = append(chains, *chain)
+		}
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].CreatedAt.After(chains[j].CreatedAt)
+	})
+	return chains, nil
+}
+
+// Members returns the IDs of the users who started or took part in a chain,
+// sorted
+func (r *Chains) Members(_ context.Context, id string) ([]string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	chain, ok := r.db.chains[id]
+	if !ok {
+		return nil, nil
+	}
+	members := map[string]bool{chain.StarterID: true}
+	for userID := range r.db.participants[id] {
+		members[userID] = true
+	}
+	ids := make([]string, 0, len(members))
+	for userID := range members {
+		if _, ok := r.db.users[userID]; ok {
+			ids = append(ids, userID)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
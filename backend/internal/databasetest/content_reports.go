@@ -0,0 +1,83 @@
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// ContentReports is an in-memory ContentReportRepository
+type ContentReports struct {
+	db *DB
+}
+
+// Ensure ContentReports implements repository.ContentReportRepository
+var _ repository.ContentReportRepository = (*ContentReports)(nil)
+
+// Create stores report as open, or returns
+// repository.ErrReportTargetNotFound
+func (r *ContentReports) Create(_ context.Context, report *models.ContentReport) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var exists bool
+	switch report.Kind {
+	case "act":
+		_, exists = r.db.acts[report.TargetID]
+	case "testimonial":
+		_, exists = r.db.testimonials[report.TargetID]
+	case "user":
+		_, exists = r.db.users[report.TargetID]
+	default:
+		return fmt.Errorf("unknown report kind %q", report.Kind)
+	}
+	if !exists {
+		return repository.ErrReportTargetNotFound
+	}
+
+	report.Status = models.ReportOpen
+	stored := *report
+	r.db.contentReports[report.ID] = &stored
+	return nil
+}
+
+// List returns a page of the reports with status, or all of them, oldest
+// first, and how many match
+func (r *ContentReports) List(_ context.Context, status models.ReportStatus, page models.PaginationParams) ([]models.ContentReport, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	reports := []models.ContentReport{}
+	for _, report := range r.db.contentReports {
+		if status == "" || report.Status == status {
+			reports = append(reports, *report)
+		}
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if !reports[i].CreatedAt.Equal(reports[j].CreatedAt) {
+			return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+		}
+		return reports[i].ID < reports[j].ID
+	})
+	return paginate(reports, page), int64(len(reports)), nil
+}
+
+// Handle closes an open report and returns it, or returns
+// repository.ErrReportNotFound
+func (r *ContentReports) Handle(_ context.Context, id string, status models.ReportStatus, handlerID, note string, now time.Time) (*models.ContentReport, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	report, ok := r.db.contentReports[id]
+	if !ok || report.Status != models.ReportOpen {
+		return nil, repository.ErrReportNotFound
+	}
+	handledAt := now.UTC()
+	report.Status, report.HandlerID, report.Note, report.HandledAt = status, handlerID, note, &handledAt
+	handled := *report
+	return &handled, nil
+}
@@ -0,0 +1,243 @@
+// Package databasetest provides an in-memory implementation of the
+// repository interfaces for tests. Unlike a mock returning canned results,
+// it stores users, acts, chains, testimonials, notifications, webhooks,
+// background jobs, donation receipts, pledges, exchange rates, geocoded
+// places, content reports, campaigns and organizations along with the
+// relationships between them, so a test can create an entity through one
+// handler and read it back through another. Changes record domain events in
+// an outbox, like the Neo4j repositories do.
+package databasetest
+
+import (
+	"sync"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/repository"
+)
+
+// DB is an in-memory graph of the API's entities. It is safe for concurrent
+// use. The zero value is not usable; create one with New.
+type DB struct {
+	mu sync.Mutex
+
+	users          map[string]*models.User
+	passwordHashes map[string]string
+
+	acts map[string]*models.Act
+	// actScreenings holds the screening last recorded for each act
+	actScreenings map[string]models.FlaggedAct
+
+	chains       map[string]*models.Chain
+	chainActs    map[string][]string
+	participants map[string]map[string]bool
+
+	testimonials map[string]*models.Testimonial
+	reactions    map[string]map[string]bool
+	translations map[string]map[string]translation
+	screenings   map[string]moderation.Result
+
+	notifications map[string]*models.Notification
+	digestsSent   map[string]time.Time
+	suppressions  map[string]models.EmailSuppression
+
+	webhooks   map[string]*models.Webhook
+	deliveries map[string][]models.WebhookDelivery
+
+	jobs map[string]*models.Job
+
+	// receipts holds donation receipts by act ID, without the details
+	// joined from the act and its giver and receiver
+	receipts         map[string]*models.DonationReceipt
+	receiptSequences map[int]int64
+
+	pledges map[string]*models.Pledge
+	// settlingFrom holds the status each settling pledge was claimed from
+	settlingFrom map[string]models.PledgeStatus
+
+	rates *models.ExchangeRates
+
+	// geocoded holds the location each user and act was last geocoded
+	// from, by kind and ID
+	geocoded map[string]string
+
+	contentReports map[string]*models.ContentReport
+
+	campaigns map[string]*models.Campaign
+	// campaignMembers holds the IDs of the users who joined each campaign
+	campaignMembers map[string]map[string]bool
+
+	organizations map[string]*models.Organization
+	// orgMembers holds the memberships of each organization by user ID
+	orgMembers     map[string]map[string]models.OrgMember
+	orgInvitations map[string]*orgInvitation
+	// givenFor holds the organization each act was given for by act ID
+	givenFor map[string]string
+
+	// trash holds soft deleted entities by kind and ID
+	trash map[string]map[string]trashed
+
+	outbox   []outboxEntry
+	sequence int64
+}
+
+type translation struct {
+	story  string
+	impact string
+}
+
+// New creates an empty DB
+func New() *DB {
+	db := &DB{
+		users:            make(map[string]*models.User),
+		passwordHashes:   make(map[string]string),
+		acts:             make(map[string]*models.Act),
+		actScreenings:    make(map[string]models.FlaggedAct),
+		chains:           make(map[string]*models.Chain),
+		chainActs:        make(map[string][]string),
+		participants:     make(map[string]map[string]bool),
+		testimonials:     make(map[string]*models.Testimonial),
+		reactions:        make(map[string]map[string]bool),
+		translations:     make(map[string]map[string]translation),
+		screenings:       make(map[string]moderation.Result),
+		notifications:    make(map[string]*models.Notification),
+		digestsSent:      make(map[string]time.Time),
+		suppressions:     make(map[string]models.EmailSuppression),
+		webhooks:         make(map[string]*models.Webhook),
+		deliveries:       make(map[string][]models.WebhookDelivery),
+		jobs:             make(map[string]*models.Job),
+		receipts:         make(map[string]*models.DonationReceipt),
+		receiptSequences: make(map[int]int64),
+		pledges:          make(map[string]*models.Pledge),
+		settlingFrom:     make(map[string]models.PledgeStatus),
+		geocoded:         make(map[string]string),
+		contentReports:   make(map[string]*models.ContentReport),
+		campaigns:        make(map[string]*models.Campaign),
+		campaignMembers:  make(map[string]map[string]bool),
+		organizations:    make(map[string]*models.Organization),
+		orgMembers:       make(map[string]map[string]models.OrgMember),
+		orgInvitations:   make(map[string]*orgInvitation),
+		givenFor:         make(map[string]string),
+		trash:            make(map[string]map[string]trashed),
+	}
+	for _, kind := range repository.TrashKinds {
+		db.trash[kind] = make(map[string]trashed)
+	}
+	return db
+}
+
+// Repositories returns repositories backed by db
+func (db *DB) Repositories() repository.Repositories {
+	return repository.Repositories{
+		Users:           &Users{db: db},
+		Acts:            &Acts{db: db},
+		Chains:          &Chains{db: db},
+		Testimonials:    &Testimonials{db: db},
+		Notifications:   &Notifications{db: db},
+		Suppressions:    &Suppressions{db: db},
+		Webhooks:        &Webhooks{db: db},
+		Jobs:            &Jobs{db: db},
+		Receipts:        &Receipts{db: db},
+		Pledges:         &Pledges{db: db},
+		Rates:           &Rates{db: db},
+		Locations:       &Locations{db: db},
+		Recommendations: &Recommendations{db: db},
+		Search:          &Search{db: db},
+		ContentReports:  &ContentReports{db: db},
+		Campaigns:       &Campaigns{db: db},
+		Organizations:   &Organizations{db: db},
+		Erasure:         &Erasure{db: db},
+		Trash:           &Trash{db: db},
+	}
+}
+
+// AddUser stores user with the given password hash
+func (db *DB) AddUser(user models.User, passwordHash string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	user.Stats = models.UserStats{}
+	db.users[user.ID] = &user
+	db.passwordHashes[user.ID] = passwordHash
+}
+
+// AddAct stores act, given by act.GiverID and received by act.ReceiverID
+func (db *DB) AddAct(act models.Act) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	act.Giver, act.Receiver = nil, nil
+	db.acts[act.ID] = &act
+}
+
+// AddChain stores chain, started by chain.StarterID and containing the acts
+// with the given IDs
+func (db *DB) AddChain(chain models.Chain, actIDs ...string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	chain.Acts, chain.Starter = nil, nil
+	db.chains[chain.ID] = &chain
+	db.chainActs[chain.ID] = append([]string(nil), actIDs...)
+}
+
+// AddParticipant records that userID took part in a chain
+func (db *DB) AddParticipant(chainID, userID string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.participants[chainID] == nil {
+		db.participants[chainID] = make(map[string]bool)
+	}
+	db.participants[chainID][userID] = true
+}
+
+// AddTestimonial stores t, written by t.UserID
+func (db *DB) AddTestimonial(t models.Testimonial) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t.User, t.Locale = nil, ""
+	db.testimonials[t.ID] = cloneTestimonial(&t)
+}
+
+// AddTranslation stores a translation of a testimonial's story and impact
+func (db *DB) AddTranslation(testimonialID, locale, story, impact string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.translations[testimonialID] == nil {
+		db.translations[testimonialID] = make(map[string]translation)
+	}
+	db.translations[testimonialID][locale] = translation{story: story, impact: impact}
+}
+
+// Screening returns the automated screening result last recorded for a
+// testimonial
+func (db *DB) Screening(testimonialID string) (moderation.Result, bool) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	result, ok := db.screenings[testimonialID]
+	return result, ok
+}
+
+// checkVersion returns repository.ErrVersionConflict if expected is set and
+// isn't current
+func checkVersion(current int64, expected *int64) error {
+	if expected != nil && *expected != current {
+		return repository.ErrVersionConflict
+	}
+	return nil
+}
+
+// paginate returns the items of page, which is numbered from 1
+func paginate[T any](items []T, page models.PaginationParams) []T {
+	start := (page.Page - 1) * page.PerPage
+	if start < 0 || start >= len(items) {
+		return []T{}
+	}
+	end := min(start+page.PerPage, len(items))
+	return items[start:end]
+}
@@ -0,0 +1,189 @@
+package databasetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/repository"
+)
+
+func TestActsAndUserStats(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	repos := db.Repositories()
+	db.AddUser(models.User{ID: "u1", Email: "jane@example.org", Name: "Jane"}, "hash")
+
+	now := time.Now()
+	if created, _ := repos.Acts.Create(ctx, &models.Act{ID: "a1", GiverID: "u1", CreatedAt: now}); !created {
+		t.Fatal("expected the act to be created")
+	}
+	repos.Acts.Create(ctx, &models.Act{ID: "a2", GiverID: "u1", IsAnonymous: true, CreatedAt: now.Add(time.Second)})
+	if created, _ := repos.Acts.Create(ctx, &models.Act{ID: "a3", GiverID: "missing"}); created {
+		t.Error("expected an act without a giver not to be created")
+	}
+
+	acts, total, err := repos.Acts.List(ctx, models.PaginationParams{Page: 1, PerPage: 10})
+	if err != nil || total != 2 || len(acts) != 2 || acts[0].ID != "a2" {
+		t.Fatalf("expected a2 then a1, got %+v (total %d, err %v)", acts, total, err)
+	}
+	if acts[0].GiverID != "" {
+		t.Error("expected the giver of an anonymous act to be hidden")
+	}
+
+	user, err := repos.Users.Get(ctx, "u1")
+	if err != nil || user.Stats.ActsGiven != 2 {
+		t.Errorf("expected 2 acts given, got %+v (err %v)", user, err)
+	}
+
+	repos.Acts.Delete(ctx, "a1")
+	if _, err := repos.Acts.Get(ctx, "a1"); !errors.Is(err, repository.ErrActNotFound) {
+		t.Errorf("expected ErrActNotFound, got %v", err)
+	}
+}
+
+func TestTestimonialLifecycle(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	repos := db.Repositories()
+	db.AddUser(models.User{ID: "author", Name: "Ann", Location: "Turin"}, "")
+	db.AddUser(models.User{ID: "reader", Name: "Rob"}, "")
+
+	repos.Testimonials.Create(ctx, &models.Testimonial{ID: "t1", UserID: "author", Story: "story"}, moderation.Result{Verdict: moderation.Allow})
+	if _, err := repos.Testimonials.ToggleReaction(ctx, "t1", "reader"); !errors.Is(err, repository.ErrTestimonialNotFound) {
+		t.Errorf("expected reactions to pending testimonials to fail, got %v", err)
+	}
+	if _, err := repos.Testimonials.SetFeatured(ctx, "t1", true, nil); !errors.Is(err, repository.ErrNotApproved) {
+		t.Errorf("expected ErrNotApproved, got %v", err)
+	}
+
+	db.AddTestimonial(models.Testimonial{ID: "t2", UserID: "author", Story: "approved", IsApproved: true})
+	db.AddTranslation("t2", "it", "approvato", "")
+	result, err := repos.Testimonials.ToggleReaction(ctx, "t2", "reader")
+	if err != nil || !result.Reacted || result.ReactionCount != 1 {
+		t.Fatalf("expected a reaction, got %+v (err %v)", result, err)
+	}
+
+	approved := true
+	list, total, _ := repos.Testimonials.List(ctx, repository.TestimonialFilter{Approved: &approved},
+		models.PaginationParams{Page: 1, PerPage: 10}, []string{"it"})
+	if total != 1 || list[0].Story != "approvato" || list[0].User.Name != "Ann" {
+		t.Errorf("expected the translated testimonial with its author, got %+v", list)
+	}
+
+	if err := repos.Testimonials.Delete(ctx, "t2", "reader", false); !errors.Is(err, repository.ErrNotTestimonialOwner) {
+		t.Errorf("expected ErrNotTestimonialOwner, got %v", err)
+	}
+
+	// Edits by the author go back through screening
+	screen := func(story, impact string) moderation.Result { return moderation.Result{Verdict: moderation.Block} }
+	updated, err := repos.Testimonials.Update(ctx, "t2", repository.TestimonialUpdate{Story: "edited", UserID: "author"}, screen)
+	if err != nil || updated.IsApproved || updated.Story != "edited" {
+		t.Errorf("expected the edit to await moderation, got %+v (err %v)", updated, err)
+	}
+	if screening, _ := db.Screening("t2"); screening.Verdict != moderation.Block {
+		t.Errorf("expected the edit to be screened, got %+v", screening)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	repos := db.Repositories()
+	db.AddUser(models.User{ID: "u1", Email: "jane@example.org", Name: "Jane"}, "hash")
+	db.AddTestimonial(models.Testimonial{ID: "t1", UserID: "u1", Story: "story", IsApproved: true})
+	db.AddTranslation("t1", "it", "storia", "")
+
+	repos.Users.Delete(ctx, "u1")
+	if _, _, err := repos.Users.FindCredentials(ctx, "jane@example.org"); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Errorf("expected a deleted user not to sign in, got %v", err)
+	}
+	if exists, _ := repos.Users.EmailExists(ctx, "jane@example.org"); !exists {
+		t.Error("expected a deleted user's email to stay taken")
+	}
+	if err := repos.Trash.Restore(ctx, "users", "u1"); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if _, hash, err := repos.Users.FindCredentials(ctx, "jane@example.org"); err != nil || hash != "hash" {
+		t.Errorf("expected the restored user to sign in, got %q (err %v)", hash, err)
+	}
+	if err := repos.Trash.Restore(ctx, "users", "u1"); !errors.Is(err, repository.ErrNotDeleted) {
+		t.Errorf("expected ErrNotDeleted, got %v", err)
+	}
+
+	repos.Testimonials.Delete(ctx, "t1", "u1", false)
+	if len(db.translations["t1"]) != 0 {
+		t.Errorf("expected the deleted testimonial's translations to be dropped, got %v", db.translations["t1"])
+	}
+	if purged, _ := repos.Trash.Purge(ctx, time.Now().Add(-time.Hour)); purged["testimonials"] != 0 {
+		t.Errorf("expected a recent deletion to be kept, got %v", purged)
+	}
+	if purged, _ := repos.Trash.Purge(ctx, time.Now().Add(time.Hour)); purged["testimonials"] != 1 {
+		t.Errorf("expected the testimonial to be purged, got %v", purged)
+	}
+	if err := repos.Trash.Restore(ctx, "testimonials", "t1"); !errors.Is(err, repository.ErrNotDeleted) {
+		t.Errorf("expected a purged testimonial to be gone, got %v", err)
+	}
+}
+
+func TestOutbox(t *testing.T) {
+	ctx := context.Background()
+	db := New()
+	repos := db.Repositories()
+	outbox := db.Outbox()
+
+	repos.Users.Create(ctx, &models.User{ID: "u1", Email: "jane@example.org", Name: "Jane"}, "hash")
+	repos.Acts.Create(ctx, &models.Act{ID: "a1", GiverID: "u1"})
+	repos.Acts.Update(ctx, "a1", models.UpdateActRequest{Status: models.ActStatusCompleted})
+	repos.Acts.Delete(ctx, "a1")
+	repos.Trash.Restore(ctx, "acts", "a1")
+
+	events, _ := outbox.Pending(ctx, 10)
+	var types []string
+	for i, e := range events {
+		types = append(types, e.Type)
+		if e.Sequence != int64(i+1) {
+			t.Errorf("expected event %d to have sequence %d, got %d", i, i+1, e.Sequence)
+		}
+	}
+	expected := []string{models.DomainUserCreated, models.DomainActCreated, models.DomainActUpdated,
+		models.DomainActCompleted, models.DomainActDeleted, models.DomainActRestored}
+	if !slices.Equal(types, expected) {
+		t.Fatalf("expected %v, got %v", expected, types)
+	}
+	if events[1].AggregateType != "act" || events[1].AggregateID != "a1" {
+		t.Errorf("unexpected aggregate %s %s", events[1].AggregateType, events[1].AggregateID)
+	}
+
+	outbox.MarkPublished(ctx, []string{events[0].ID, events[1].ID}, time.Now())
+	if pending, _ := outbox.Pending(ctx, 10); len(pending) != 4 || pending[0].Type != models.DomainActUpdated {
+		t.Errorf("expected the published events to be skipped, got %+v", pending)
+	}
+
+	// Erasing a user drops the events carrying their profile or the text of
+	// their acts and records the redaction of their acts
+	repos.Erasure.Erase(ctx, "u1")
+	pending, _ := outbox.Pending(ctx, 10)
+	if len(pending) != 2 {
+		t.Fatalf("expected only the erasure's events, got %+v", pending)
+	}
+	if redacted := pending[0]; redacted.Type != models.DomainActRedacted || redacted.AggregateID != "a1" {
+		t.Errorf("expected an act.redacted event for a1, got %+v", redacted)
+	}
+	if last := pending[1]; last.Type != models.DomainUserErased || strings.Contains(string(last.Data), "jane@example.org") {
+		t.Errorf("expected a user.erased event last, got %+v", last)
+	}
+
+	outbox.MarkPublished(ctx, []string{pending[0].ID}, time.Now())
+	if pruned, _ := outbox.Prune(ctx, time.Now().Add(time.Minute), true); pruned != 1 {
+		t.Errorf("expected the published act.redacted event to be pruned, got %d", pruned)
+	}
+	if pruned, _ := outbox.Prune(ctx, time.Now().Add(time.Minute), false); pruned != 1 {
+		t.Errorf("expected the user.erased event to be pruned, got %d", pruned)
+	}
+}
@@ -0,0 +1,128 @@
+package databasetest
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Erasure is an in-memory ErasureRepository. The in-memory DB holds no
+// moderation notes or audit events, so only content reports count as
+// moderation scrubbed and no audit events are.
+type Erasure struct {
+	db *DB
+}
+
+// Ensure Erasure implements repository.ErasureRepository
+var _ repository.ErasureRepository = (*Erasure)(nil)
+
+// Erase replaces the user's profile with placeholders, removes their
+// reactions, testimonials and notifications, anonymizes the notifications
+// they caused and redacts the acts they gave, or returns
+// repository.ErrUserNotFound
+func (r *Erasure) Erase(_ context.Context, subjectID string) (*models.ErasureReport, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	// Deleted users are erased too, in the trash
+	user, ok := r.db.users[subjectID]
+	if item, trashed := r.db.trash["users"][subjectID]; !ok && trashed {
+		user, ok = item.entity.(*models.User), true
+		item.passwordHash = ""
+		r.db.trash["users"][subjectID] = item
+	}
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	now := time.Now().UTC()
+	report := &models.ErasureReport{SubjectID: subjectID, ErasedAt: now}
+
+	*user = models.User{
+		ID:                   user.ID,
+		Email:                repository.ErasedEmail(subjectID),
+		Name:                 repository.ErasedUserName,
+		IsVerified:           user.IsVerified,
+		CreatedAt:            user.CreatedAt,
+		UpdatedAt:            now,
+		Version:              user.Version + 1,
+		HideFromLeaderboards: true,
+	}
+	delete(r.db.passwordHashes, subjectID)
+
+	for id, users := range r.db.reactions {
+		if users[subjectID] {
+			delete(users, subjectID)
+			if t, ok := r.db.testimonials[id]; ok {
+				t.ReactionCount = max(t.ReactionCount-1, 0)
+			}
+			report.ReactionsRemoved++
+		}
+	}
+
+	for id, t := range r.db.testimonials {
+		if t.UserID == subjectID {
+			delete(r.db.testimonials, id)
+			delete(r.db.reactions, id)
+			delete(r.db.translations, id)
+			delete(r.db.screenings, id)
+			r.db.dropEvents("testimonial", id)
+			r.db.recordEvent(models.DomainTestimonialDeleted, id, map[string]time.Time{"erasedAt": now})
+			report.TestimonialsDeleted++
+		}
+	}
+	for id, item := range r.db.trash["testimonials"] {
+		if item.entity.(*models.Testimonial).UserID == subjectID {
+			delete(r.db.trash["testimonials"], id)
+			r.db.dropRelationships("testimonials", id)
+			r.db.dropEvents("testimonial", id)
+			r.db.recordEvent(models.DomainTestimonialDeleted, id, map[string]time.Time{"erasedAt": now})
+			report.TestimonialsDeleted++
+		}
+	}
+
+	for id, n := range r.db.notifications {
+		switch {
+		case n.UserID == subjectID:
+			delete(r.db.notifications, id)
+			report.NotificationsScrubbed++
+		case n.ActorID == subjectID:
+			n.ActorID = ""
+			report.NotificationsScrubbed++
+		}
+	}
+
+	for _, cr := range r.db.contentReports {
+		if cr.ReporterID != subjectID && cr.HandlerID != subjectID {
+			continue
+		}
+		if cr.ReporterID == subjectID {
+			cr.ReporterID, cr.Details = "", ""
+		}
+		if cr.HandlerID == subjectID {
+			cr.HandlerID = ""
+		}
+		report.ModerationScrubbed++
+	}
+
+	for _, act := range r.db.acts {
+		if act.GiverID != subjectID || (act.Title == repository.ErasedActTitle && act.IsAnonymous) {
+			continue
+		}
+		act.Title = repository.ErasedActTitle
+		act.Description = ""
+		act.Location = ""
+		act.Latitude, act.Longitude = nil, nil
+		act.IsAnonymous = true
+		act.UpdatedAt = now
+		act.Version++
+		r.db.dropEvents("act", act.ID)
+		r.db.recordEvent(models.DomainActRedacted, act.ID, map[string]time.Time{"erasedAt": now})
+		report.ActsRedacted++
+	}
+
+	r.db.dropEvents("user", subjectID)
+	r.db.recordEvent(models.DomainUserErased, subjectID, report)
+	return report, nil
+}
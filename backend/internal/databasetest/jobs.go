@@ -0,0 +1,176 @@
+package databasetest
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Jobs is an in-memory JobRepository
+type Jobs struct {
+	db *DB
+}
+
+// Ensure Jobs implements repository.JobRepository
+var _ repository.JobRepository = (*Jobs)(nil)
+
+// Enqueue stores job unless a job with its ID exists, and returns whether
+// it did
+func (r *Jobs) Enqueue(_ context.Context, job *models.Job) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.jobs[job.ID]; ok {
+		return false, nil
+	}
+	stored := copyJob(job)
+	stored.Status = models.JobQueued
+	stored.Attempts = 0
+	stored.UpdatedAt = stored.CreatedAt
+	r.db.jobs[job.ID] = stored
+	return true, nil
+}
+
+// Claim marks the due job of one of types that has waited longest as
+// running until now plus lease and returns it, or nil if none is due
+func (r *Jobs) Claim(_ context.Context, types []string, now time.Time, lease time.Duration) (*models.Job, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var due *models.Job
+	for _, job := range r.db.jobs {
+		if !slices.Contains(types, job.Type) || !jobDue(job, now) {
+			continue
+		}
+		if due == nil || job.RunAt.Before(due.RunAt) || (job.RunAt.Equal(due.RunAt) && job.ID < due.ID) {
+			due = job
+		}
+	}
+	if due == nil {
+		return nil, nil
+	}
+	lockedUntil := now.Add(lease)
+	due.Status = models.JobRunning
+	due.Attempts++
+	due.LockedUntil = &lockedUntil
+	due.UpdatedAt = now
+	return copyJob(due), nil
+}
+
+func jobDue(job *models.Job, now time.Time) bool {
+	switch job.Status {
+	case models.JobQueued:
+		return !job.RunAt.After(now)
+	case models.JobRunning:
+		return job.LockedUntil != nil && !job.LockedUntil.After(now)
+	}
+	return false
+}
+
+// Finish records the outcome of an attempt at job, unless the lease
+// claimed until claim expired and the job was claimed again since
+func (r *Jobs) Finish(_ context.Context, job *models.Job, claim time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.jobs[job.ID]
+	if !ok || stored.Status != models.JobRunning || stored.LockedUntil == nil || !stored.LockedUntil.Equal(claim) {
+		return nil
+	}
+	finished := copyJob(job)
+	finished.Payload = stored.Payload
+	finished.LockedUntil = nil
+	r.db.jobs[job.ID] = finished
+	return nil
+}
+
+// Get returns a job, or repository.ErrJobNotFound
+func (r *Jobs) Get(_ context.Context, id string) (*models.Job, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	job, ok := r.db.jobs[id]
+	if !ok {
+		return nil, repository.ErrJobNotFound
+	}
+	return copyJob(job), nil
+}
+
+// List returns a page of the jobs matching filter, most recently updated
+// first, and how many match
+func (r *Jobs) List(_ context.Context, filter repository.JobFilter, page models.PaginationParams) ([]models.Job, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	jobs := []models.Job{}
+	for _, job := range r.db.jobs {
+		if (filter.Status == "" || job.Status == filter.Status) && (filter.Type == "" || job.Type == filter.Type) {
+			jobs = append(jobs, *copyJob(job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].UpdatedAt.Equal(jobs[j].UpdatedAt) {
+			return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return paginate(jobs, page), int64(len(jobs)), nil
+}
+
+// Retry queues a failed job to run again at now with its attempts reset,
+// or returns repository.ErrJobNotFound or repository.ErrJobNotFailed
+func (r *Jobs) Retry(_ context.Context, id string, now time.Time) (*models.Job, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	job, ok := r.db.jobs[id]
+	if !ok {
+		return nil, repository.ErrJobNotFound
+	}
+	if job.Status != models.JobFailed {
+		return nil, repository.ErrJobNotFailed
+	}
+	job.Status = models.JobQueued
+	job.Attempts = 0
+	job.RunAt = now
+	job.LastError = ""
+	job.FinishedAt = nil
+	job.UpdatedAt = now
+	return copyJob(job), nil
+}
+
+// Prune deletes the jobs that succeeded or failed before before and
+// returns how many
+func (r *Jobs) Prune(_ context.Context, before time.Time) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pruned := 0
+	for id, job := range r.db.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(before) {
+			delete(r.db.jobs, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// copyJob copies job so callers can't change the stored one
+func copyJob(job *models.Job) *models.Job {
+	copied := *job
+	copied.Payload = slices.Clone(job.Payload)
+	copied.Result = slices.Clone(job.Result)
+	if job.LockedUntil != nil {
+		lockedUntil := *job.LockedUntil
+		copied.LockedUntil = &lockedUntil
+	}
+	if job.FinishedAt != nil {
+		finishedAt := *job.FinishedAt
+		copied.FinishedAt = &finishedAt
+	}
+	return &copied
+}
@@ -0,0 +1,90 @@
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Locations is an in-memory LocationRepository
+type Locations struct {
+	db *DB
+}
+
+// Ensure Locations implements repository.LocationRepository
+var _ repository.LocationRepository = (*Locations)(nil)
+
+// Pending returns up to limit users and acts whose location was set or
+// changed since it was last geocoded, users first
+func (r *Locations) Pending(_ context.Context, limit int) ([]repository.PendingLocation, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var pending []repository.PendingLocation
+	add := func(kind, id, location string) {
+		if location != "" && r.db.geocoded[kind+":"+id] != location {
+			pending = append(pending, repository.PendingLocation{Kind: kind, ID: id, Location: location})
+		}
+	}
+	for id, user := range r.db.users {
+		add("user", id, user.Location)
+	}
+	for id, act := range r.db.acts {
+		add("act", id, act.Location)
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		if pending[i].Kind != pending[j].Kind {
+			return pending[i].Kind == "user"
+		}
+		return pending[i].ID < pending[j].ID
+	})
+	return pending[:min(limit, len(pending))], nil
+}
+
+// SetPlace records the place location resolves to, unless the location of
+// the user or act changed since
+func (r *Locations) SetPlace(_ context.Context, kind, id, location string, place *models.Place) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var latitude, longitude **float64
+	var countryCode *string
+	switch kind {
+	case "user":
+		user, ok := r.db.users[id]
+		if !ok || user.Location != location {
+			return nil
+		}
+		latitude, longitude, countryCode = &user.Latitude, &user.Longitude, &user.CountryCode
+	case "act":
+		act, ok := r.db.acts[id]
+		if !ok || act.Location != location {
+			return nil
+		}
+		latitude, longitude, countryCode = &act.Latitude, &act.Longitude, &act.CountryCode
+	default:
+		return fmt.Errorf("unknown location kind %q", kind)
+	}
+
+	r.db.geocoded[kind+":"+id] = location
+	*latitude, *longitude, *countryCode = nil, nil, ""
+	if place != nil {
+		lat, lng := place.Latitude, place.Longitude
+		*latitude, *longitude, *countryCode = &lat, &lng, place.CountryCode
+	}
+	return nil
+}
+
+// distanceKm is the great-circle distance between two points, as Neo4j's
+// point.distance computes it for geographic points
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadiusKm = 6378.14
+	rad := math.Pi / 180
+	dLat, dLng := (lat2-lat1)*rad, (lng2-lng1)*rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
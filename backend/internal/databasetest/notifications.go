@@ -0,0 +1,139 @@
+package databasetest
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Notifications is an in-memory NotificationRepository
+type Notifications struct {
+	db *DB
+}
+
+// Ensure Notifications implements repository.NotificationRepository
+var _ repository.NotificationRepository = (*Notifications)(nil)
+
+// Create stores notifications, skipping those addressed to users that don't
+// exist, and returns how many it stored
+func (r *Notifications) Create(_ context.Context, notifications []models.Notification) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	created := 0
+	for _, n := range notifications {
+		if _, ok := r.db.users[n.UserID]; !ok {
+			continue
+		}
+		n.ReadAt = nil
+		r.db.notifications[n.ID] = &n
+		created++
+	}
+	return created, nil
+}
+
+// List returns a page of a user's notifications, newest first, and the
+// total count, of unread ones only when unreadOnly is set
+func (r *Notifications) List(_ context.Context, userID string, unreadOnly bool, page models.PaginationParams) ([]models.Notification, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	notifications := []models.Notification{}
+	for _, n := range r.db.notifications {
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			notifications = append(notifications, *n)
+		}
+	}
+	sort.Slice(notifications, func(i, j int) bool {
+		if !notifications[i].CreatedAt.Equal(notifications[j].CreatedAt) {
+			return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+		}
+		return notifications[i].ID < notifications[j].ID
+	})
+	return paginate(notifications, page), int64(len(notifications)), nil
+}
+
+// Unread returns the number of a user's unread notifications
+func (r *Notifications) Unread(_ context.Context, userID string) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var unread int64
+	for _, n := range r.db.notifications {
+		if n.UserID == userID && n.ReadAt == nil {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+// MarkRead marks the user's unread notifications with ids read, or all of
+// them when ids is empty, and returns how many it marked
+func (r *Notifications) MarkRead(_ context.Context, userID string, ids []string) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	now := time.Now().UTC()
+	marked := 0
+	for _, n := range r.db.notifications {
+		if n.UserID != userID || n.ReadAt != nil || (len(ids) > 0 && !slices.Contains(ids, n.ID)) {
+			continue
+		}
+		n.ReadAt = &now
+		marked++
+	}
+	return marked, nil
+}
+
+// DigestsDue returns up to limit users due a digest, who haven't had one
+// since since and have unread notifications created since then
+func (r *Notifications) DigestsDue(_ context.Context, since time.Time, limit int) ([]models.NotificationDigest, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	digests := make(map[string]*models.NotificationDigest)
+	for _, n := range r.db.notifications {
+		user, ok := r.db.users[n.UserID]
+		if !ok || n.ReadAt != nil || n.CreatedAt.Before(since) {
+			continue
+		}
+		if sent, ok := r.db.digestsSent[n.UserID]; ok && !sent.Before(since) {
+			continue
+		}
+		d := digests[n.UserID]
+		if d == nil {
+			d = &models.NotificationDigest{UserID: user.ID, Email: user.Email, Name: user.Name}
+			digests[n.UserID] = d
+		}
+		switch n.Type {
+		case models.NotificationActReceived:
+			d.ActsReceived++
+		case models.NotificationChainGrew:
+			d.ChainsGrown++
+		case models.NotificationTestimonialApproved:
+			d.TestimonialsApproved++
+		}
+	}
+
+	due := make([]models.NotificationDigest, 0, len(digests))
+	for _, d := range digests {
+		due = append(due, *d)
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].UserID < due[j].UserID })
+	return due[:min(limit, len(due))], nil
+}
+
+// DigestSent records that a user was sent a digest at at
+func (r *Notifications) DigestSent(_ context.Context, userID string, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[userID]; ok {
+		r.db.digestsSent[userID] = at
+	}
+	return nil
+}
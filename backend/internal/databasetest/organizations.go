@@ -0,0 +1,400 @@
+package databasetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Organizations is an in-memory OrganizationRepository
+type Organizations struct {
+	db *DB
+}
+
+// Ensure Organizations implements repository.OrganizationRepository
+var _ repository.OrganizationRepository = (*Organizations)(nil)
+
+// orgInvitation is an invitation with the hash of its token
+type orgInvitation struct {
+	invitation models.OrgInvitation
+	tokenHash  string
+}
+
+// Create stores org with its creator as its owner. It returns false if the
+// creator does not exist.
+func (r *Organizations) Create(_ context.Context, org *models.Organization) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[org.CreatedBy]; !ok {
+		return false, nil
+	}
+	stored := *org
+	stored.Members, stored.Role = 0, ""
+	r.db.organizations[org.ID] = &stored
+	r.db.orgMembers[org.ID] = map[string]models.OrgMember{
+		org.CreatedBy: {UserID: org.CreatedBy, Role: models.OrgRoleOwner, JoinedAt: org.CreatedAt},
+	}
+	r.db.recordEvent(models.DomainOrganizationCreated, org.ID, stored)
+	return true, nil
+}
+
+// Get returns an organization with its number of members, or
+// repository.ErrOrganizationNotFound
+func (r *Organizations) Get(_ context.Context, id string) (*models.Organization, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	org, ok := r.db.organizations[id]
+	if !ok {
+		return nil, repository.ErrOrganizationNotFound
+	}
+	found := r.db.withMembers(org)
+	return &found, nil
+}
+
+// ListByUser returns the organizations a user is a member of, by name,
+// with their role in each
+func (r *Organizations) ListByUser(_ context.Context, userID string) ([]models.Organization, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	orgs := []models.Organization{}
+	if _, ok := r.db.users[userID]; !ok {
+		return orgs, nil
+	}
+	for id, org := range r.db.organizations {
+		if member, ok := r.db.orgMembers[id][userID]; ok {
+			found := r.db.withMembers(org)
+			found.Role = member.Role
+			orgs = append(orgs, found)
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		if orgs[i].Name != orgs[j].Name {
+			return orgs[i].Name < orgs[j].Name
+		}
+		return orgs[i].ID < orgs[j].ID
+	})
+	return orgs, nil
+}
+
+// Role returns a user's role in an organization, empty if they aren't a
+// member, or repository.ErrOrganizationNotFound
+func (r *Organizations) Role(_ context.Context, id, userID string) (models.OrgRole, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.organizations[id]; !ok {
+		return "", repository.ErrOrganizationNotFound
+	}
+	if _, ok := r.db.users[userID]; !ok {
+		return "", nil
+	}
+	return r.db.orgMembers[id][userID].Role, nil
+}
+
+// Members returns a page of an organization's members, owners first, then
+// admins, then by name, and how many there are
+func (r *Organizations) Members(_ context.Context, id string, page models.PaginationParams) ([]models.OrgMember, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	members := []models.OrgMember{}
+	for userID, member := range r.db.orgMembers[id] {
+		user, ok := r.db.users[userID]
+		if !ok {
+			continue
+		}
+		member.Name, member.Avatar = user.Name, user.Avatar
+		members = append(members, member)
+	}
+	rank := map[models.OrgRole]int{models.OrgRoleOwner: 0, models.OrgRoleAdmin: 1, models.OrgRoleMember: 2}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if rank[a.Role] != rank[b.Role] {
+			return rank[a.Role] < rank[b.Role]
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.UserID < b.UserID
+	})
+	return paginate(members, page), int64(len(members)), nil
+}
+
+// SetRole changes a member's role, or returns repository.ErrNotOrgMember or
+// repository.ErrLastOwner
+func (r *Organizations) SetRole(_ context.Context, id, userID string, role models.OrgRole) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.db.checkMemberChange(id, userID, role); err != nil {
+		return err
+	}
+	member := r.db.orgMembers[id][userID]
+	member.Role = role
+	r.db.orgMembers[id][userID] = member
+	return nil
+}
+
+// RemoveMember removes a user from an organization's members, or returns
+// repository.ErrNotOrgMember or repository.ErrLastOwner
+func (r *Organizations) RemoveMember(_ context.Context, id, userID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if err := r.db.checkMemberChange(id, userID, ""); err != nil {
+		return err
+	}
+	delete(r.db.orgMembers[id], userID)
+	return nil
+}
+
+// CreateInvitation stores invitation, accepted with the token whose hash is
+// tokenHash, or returns repository.ErrOrganizationNotFound
+func (r *Organizations) CreateInvitation(_ context.Context, invitation *models.OrgInvitation, tokenHash string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.organizations[invitation.OrganizationID]; !ok {
+		return repository.ErrOrganizationNotFound
+	}
+	stored := *invitation
+	stored.Token = ""
+	r.db.orgInvitations[invitation.ID] = &orgInvitation{invitation: stored, tokenHash: tokenHash}
+	return nil
+}
+
+// Invitations returns the invitations to an organization that weren't
+// accepted and haven't expired by now, newest first
+func (r *Organizations) Invitations(_ context.Context, id string, now time.Time) ([]models.OrgInvitation, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	invitations := []models.OrgInvitation{}
+	for _, stored := range r.db.orgInvitations {
+		invitation := stored.invitation
+		if invitation.OrganizationID == id && invitation.AcceptedAt == nil && invitation.ExpiresAt.After(now) {
+			invitations = append(invitations, invitation)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		if !invitations[i].CreatedAt.Equal(invitations[j].CreatedAt) {
+			return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+		}
+		return invitations[i].ID < invitations[j].ID
+	})
+	return invitations, nil
+}
+
+// RevokeInvitation deletes an invitation to an organization that wasn't
+// accepted, or returns repository.ErrInvitationNotFound
+func (r *Organizations) RevokeInvitation(_ context.Context, id, invitationID string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored, ok := r.db.orgInvitations[invitationID]
+	if !ok || stored.invitation.OrganizationID != id || stored.invitation.AcceptedAt != nil {
+		return repository.ErrInvitationNotFound
+	}
+	delete(r.db.orgInvitations, invitationID)
+	return nil
+}
+
+// AcceptInvitation accepts the pending invitation for email with tokenHash
+// at now, adding userID to its organization unless they are a member
+// already. It returns the invitation, or repository.ErrInvitationNotFound.
+func (r *Organizations) AcceptInvitation(_ context.Context, tokenHash, email, userID string, now time.Time) (*models.OrgInvitation, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[userID]; !ok {
+		return nil, repository.ErrInvitationNotFound
+	}
+	for _, stored := range r.db.orgInvitations {
+		invitation := &stored.invitation
+		if stored.tokenHash != tokenHash || invitation.Email != email || invitation.AcceptedAt != nil || !invitation.ExpiresAt.After(now) {
+			continue
+		}
+		if _, ok := r.db.organizations[invitation.OrganizationID]; !ok {
+			break
+		}
+		accepted := now.UTC()
+		invitation.AcceptedAt, invitation.AcceptedBy = &accepted, userID
+		if _, ok := r.db.orgMembers[invitation.OrganizationID][userID]; !ok {
+			r.db.orgMembers[invitation.OrganizationID][userID] = models.OrgMember{UserID: userID, Role: invitation.Role, JoinedAt: accepted}
+		}
+		r.db.recordEvent(models.DomainOrganizationJoined, invitation.OrganizationID,
+			models.OrgMember{UserID: userID, Role: invitation.Role, JoinedAt: accepted})
+		found := *invitation
+		return &found, nil
+	}
+	return nil, repository.ErrInvitationNotFound
+}
+
+// Acts returns a page of the acts given for an organization, newest first,
+// and how many there are
+func (r *Organizations) Acts(_ context.Context, id string, page models.PaginationParams) ([]models.Act, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	acts := r.db.orgActs(id)
+	sort.Slice(acts, func(i, j int) bool {
+		if !acts[i].CreatedAt.Equal(acts[j].CreatedAt) {
+			return acts[i].CreatedAt.After(acts[j].CreatedAt)
+		}
+		return acts[i].ID < acts[j].ID
+	})
+	return paginate(acts, page), int64(len(acts)), nil
+}
+
+// Chains returns up to limit chains containing acts given for an
+// organization, most recently extended first
+func (r *Organizations) Chains(_ context.Context, id string, limit int) ([]models.Chain, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	given := make(map[string]bool)
+	for _, act := range r.db.orgActs(id) {
+		given[act.ID] = true
+	}
+	chains := []models.Chain{}
+	for chainID, chain := range r.db.chains {
+		found := *chain
+		found.ActsCount, found.TotalValue = 0, 0
+		inOrg := false
+		for _, actID := range r.db.chainActs[chainID] {
+			act, ok := r.db.acts[actID]
+			if !ok {
+				continue
+			}
+			found.ActsCount++
+			found.TotalValue += actValue(act)
+			inOrg = inOrg || given[actID]
+		}
+		if inOrg {
+			chains = append(chains, found)
+		}
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		if !chains[i].UpdatedAt.Equal(chains[j].UpdatedAt) {
+			return chains[i].UpdatedAt.After(chains[j].UpdatedAt)
+		}
+		return chains[i].ID < chains[j].ID
+	})
+	if len(chains) > limit {
+		chains = chains[:limit]
+	}
+	return chains, nil
+}
+
+// Leaderboard ranks up to limit members by the acts they gave for an
+// organization, leaving out anonymous acts and users who opted out
+func (r *Organizations) Leaderboard(_ context.Context, id string, limit int) ([]models.LeaderboardEntry, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	totals := make(map[string]*models.LeaderboardEntry)
+	for actID, orgID := range r.db.givenFor {
+		act, ok := r.db.acts[actID]
+		if orgID != id || !ok || act.IsAnonymous {
+			continue
+		}
+		user, ok := r.db.users[act.GiverID]
+		if _, member := r.db.orgMembers[id][act.GiverID]; !ok || !member || user.HideFromLeaderboards {
+			continue
+		}
+		entry, ok := totals[user.ID]
+		if !ok {
+			entry = &models.LeaderboardEntry{User: models.LeaderboardUser{ID: user.ID, Name: user.Name, Avatar: user.Avatar}}
+			totals[user.ID] = entry
+		}
+		entry.ActsCount++
+		entry.TotalValue += actValue(act)
+	}
+	return rankLeaderboard(totals, limit), nil
+}
+
+// Stats sums up the activity of an organization's members at now, or
+// returns repository.ErrOrganizationNotFound
+func (r *Organizations) Stats(_ context.Context, id string, now time.Time) (*models.OrgStats, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.organizations[id]; !ok {
+		return nil, repository.ErrOrganizationNotFound
+	}
+	stats := &models.OrgStats{OrganizationID: id, GeneratedAt: now.UTC()}
+	active := make(map[string]bool)
+	for userID := range r.db.orgMembers[id] {
+		if _, ok := r.db.users[userID]; ok {
+			stats.Members++
+		}
+	}
+	for _, act := range r.db.acts {
+		if _, member := r.db.orgMembers[id][act.GiverID]; member {
+			if _, ok := r.db.users[act.GiverID]; ok {
+				stats.ActsGiven++
+				stats.TotalValue += actValue(act)
+				active[act.GiverID] = true
+			}
+		}
+	}
+	for _, chain := range r.db.chains {
+		if _, member := r.db.orgMembers[id][chain.StarterID]; member {
+			stats.ChainsStarted++
+		}
+	}
+	stats.ActiveMembers = int64(len(active))
+	return stats, nil
+}
+
+// withMembers returns a copy of org with its members counted. The caller
+// must hold db.mu.
+func (db *DB) withMembers(org *models.Organization) models.Organization {
+	found := *org
+	for userID := range db.orgMembers[org.ID] {
+		if _, ok := db.users[userID]; ok {
+			found.Members++
+		}
+	}
+	return found
+}
+
+// checkMemberChange checks that a member of an organization can be given
+// role, or removed if it is empty, without leaving it without an owner. The
+// caller must hold db.mu.
+func (db *DB) checkMemberChange(id, userID string, role models.OrgRole) error {
+	if _, ok := db.organizations[id]; !ok {
+		return repository.ErrOrganizationNotFound
+	}
+	member, ok := db.orgMembers[id][userID]
+	if _, exists := db.users[userID]; !ok || !exists {
+		return repository.ErrNotOrgMember
+	}
+	if member.Role != models.OrgRoleOwner || role == models.OrgRoleOwner {
+		return nil
+	}
+	for otherID, other := range db.orgMembers[id] {
+		if _, exists := db.users[otherID]; exists && otherID != userID && other.Role == models.OrgRoleOwner {
+			return nil
+		}
+	}
+	return repository.ErrLastOwner
+}
+
+// orgActs returns the acts given for an organization that weren't deleted.
+// The caller must hold db.mu.
+func (db *DB) orgActs(id string) []models.Act {
+	acts := []models.Act{}
+	for actID, orgID := range db.givenFor {
+		if act, ok := db.acts[actID]; ok && orgID == id {
+			acts = append(acts, publicAct(act))
+		}
+	}
+	return acts
+}
@@ -0,0 +1,140 @@
+package databasetest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// Outbox is an in-memory OutboxRepository holding the domain events
+// recorded by the DB's repositories
+type Outbox struct {
+	db *DB
+}
+
+// Ensure Outbox implements repository.OutboxRepository
+var _ repository.OutboxRepository = (*Outbox)(nil)
+
+// Outbox returns the outbox of the events db's repositories record
+func (db *DB) Outbox() *Outbox {
+	return &Outbox{db: db}
+}
+
+// Pending returns up to limit unpublished events, in sequence order
+func (r *Outbox) Pending(_ context.Context, limit int) ([]models.DomainEvent, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pending := []models.DomainEvent{}
+	for _, e := range r.db.outbox {
+		if len(pending) == limit {
+			break
+		}
+		if !e.published {
+			pending = append(pending, e.event)
+		}
+	}
+	return pending, nil
+}
+
+// MarkPublished records the events with ids as published
+func (r *Outbox) MarkPublished(_ context.Context, ids []string, _ time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	marked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		marked[id] = true
+	}
+	for i := range r.db.outbox {
+		if marked[r.db.outbox[i].event.ID] {
+			r.db.outbox[i].published = true
+		}
+	}
+	return nil
+}
+
+// After returns up to limit events numbered after sequence, in order
+func (r *Outbox) After(_ context.Context, sequence int64, limit int) ([]models.DomainEvent, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	events := []models.DomainEvent{}
+	for _, e := range r.db.outbox {
+		if len(events) == limit {
+			break
+		}
+		if e.event.Sequence > sequence {
+			events = append(events, e.event)
+		}
+	}
+	return events, nil
+}
+
+// Latest returns the number of the last event recorded
+func (r *Outbox) Latest(_ context.Context) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	return r.db.sequence, nil
+}
+
+// Prune deletes the events created before before, only the published ones
+// with publishedOnly, and returns how many
+func (r *Outbox) Prune(_ context.Context, before time.Time, publishedOnly bool) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	kept := r.db.outbox[:0]
+	for _, e := range r.db.outbox {
+		if !e.event.CreatedAt.Before(before) || (publishedOnly && !e.published) {
+			kept = append(kept, e)
+		}
+	}
+	pruned := len(r.db.outbox) - len(kept)
+	r.db.outbox = kept
+	return pruned, nil
+}
+
+// outboxEntry is a domain event and whether it was published
+type outboxEntry struct {
+	event     models.DomainEvent
+	published bool
+}
+
+// recordEvent appends a domain event to the outbox. The caller must hold
+// db.mu.
+func (db *DB) recordEvent(eventType, aggregateID string, data any) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	aggregateType, _, _ := strings.Cut(eventType, ".")
+	db.sequence++
+	db.outbox = append(db.outbox, outboxEntry{event: models.DomainEvent{
+		ID:            uuid.New().String(),
+		Sequence:      db.sequence,
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Data:          encoded,
+		CreatedAt:     time.Now().UTC(),
+	}})
+}
+
+// dropEvents deletes the events about an aggregate, like
+// queries.OutboxEraseAggregates. The caller must hold db.mu.
+func (db *DB) dropEvents(aggregateType, aggregateID string) {
+	kept := db.outbox[:0]
+	for _, e := range db.outbox {
+		if e.event.AggregateType != aggregateType || e.event.AggregateID != aggregateID {
+			kept = append(kept, e)
+		}
+	}
+	db.outbox = kept
+}
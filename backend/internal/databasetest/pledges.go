@@ -0,0 +1,206 @@
+package databasetest
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Pledges is an in-memory PledgeRepository
+type Pledges struct {
+	db *DB
+}
+
+// Ensure Pledges implements repository.PledgeRepository
+var _ repository.PledgeRepository = (*Pledges)(nil)
+
+// Create stores pledge as held for its act, or returns
+// repository.ErrActNotFound, repository.ErrNotActGiver or
+// repository.ErrPledgeExists
+func (r *Pledges) Create(_ context.Context, pledge *models.Pledge) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	act, ok := r.db.acts[pledge.ActID]
+	if !ok {
+		return repository.ErrActNotFound
+	}
+	if act.GiverID != pledge.GiverID {
+		return repository.ErrNotActGiver
+	}
+	if r.db.pledgeForAct(pledge.ActID) != nil {
+		return repository.ErrPledgeExists
+	}
+	pledge.Status = models.PledgeHeld
+	pledge.UpdatedAt = pledge.CreatedAt
+	stored := *pledge
+	r.db.pledges[pledge.ID] = &stored
+	r.db.recordEvent(models.DomainPledgeHeld, pledge.ID, stored)
+	return nil
+}
+
+// ForAct returns the pledge of an act, or repository.ErrPledgeNotFound
+func (r *Pledges) ForAct(_ context.Context, actID string) (*models.Pledge, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pledge := r.db.pledgeForAct(actID)
+	if pledge == nil {
+		return nil, repository.ErrPledgeNotFound
+	}
+	copied := *pledge
+	return &copied, nil
+}
+
+// Get returns a pledge, or repository.ErrPledgeNotFound
+func (r *Pledges) Get(_ context.Context, id string) (*models.Pledge, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pledge, ok := r.db.pledges[id]
+	if !ok {
+		return nil, repository.ErrPledgeNotFound
+	}
+	copied := *pledge
+	return &copied, nil
+}
+
+// Transition applies change to a pledge whose status is one of from, or
+// returns repository.ErrPledgeNotFound or repository.ErrPledgeState
+func (r *Pledges) Transition(_ context.Context, id string, from []models.PledgeStatus, change repository.PledgeChange) (*models.Pledge, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pledge, ok := r.db.pledges[id]
+	if !ok {
+		return nil, repository.ErrPledgeNotFound
+	}
+	if !slices.Contains(from, pledge.Status) {
+		return nil, repository.ErrPledgeState
+	}
+	pledge.Status = change.Status
+	if change.DisputedBy != "" {
+		pledge.DisputedBy = change.DisputedBy
+	}
+	if change.DisputeReason != "" {
+		pledge.DisputeReason = change.DisputeReason
+	}
+	if change.Resolution != "" {
+		pledge.Resolution = change.Resolution
+	}
+	if change.Status == models.PledgeReleased || change.Status == models.PledgeRefunded {
+		at := change.At
+		pledge.SettledAt = &at
+	}
+	pledge.UpdatedAt = change.At
+	delete(r.db.settlingFrom, id)
+	copied := *pledge
+	r.db.recordEvent("pledge."+string(change.Status), id, copied)
+	return &copied, nil
+}
+
+// Claim moves a pledge whose status is one of from to settling at now, or
+// returns repository.ErrPledgeNotFound or repository.ErrPledgeState
+func (r *Pledges) Claim(_ context.Context, id string, from []models.PledgeStatus, now time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pledge, ok := r.db.pledges[id]
+	if !ok {
+		return repository.ErrPledgeNotFound
+	}
+	if !slices.Contains(from, pledge.Status) {
+		return repository.ErrPledgeState
+	}
+	r.db.settlingFrom[id] = pledge.Status
+	pledge.Status = models.PledgeSettling
+	pledge.UpdatedAt = now
+	return nil
+}
+
+// Unclaim moves a settling pledge back to the status it was claimed from
+func (r *Pledges) Unclaim(_ context.Context, id string, now time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pledge, ok := r.db.pledges[id]
+	if !ok || pledge.Status != models.PledgeSettling {
+		return nil
+	}
+	pledge.Status = r.db.settlingFrom[id]
+	pledge.UpdatedAt = now
+	delete(r.db.settlingFrom, id)
+	return nil
+}
+
+// List returns a page of the pledges with status, or all of them if it is
+// empty, most recently updated first, and how many match
+func (r *Pledges) List(_ context.Context, status models.PledgeStatus, page models.PaginationParams) ([]models.Pledge, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pledges := []models.Pledge{}
+	for _, pledge := range r.db.pledges {
+		if status == "" || pledge.Status == status {
+			pledges = append(pledges, *pledge)
+		}
+	}
+	sort.Slice(pledges, func(i, j int) bool {
+		if !pledges[i].UpdatedAt.Equal(pledges[j].UpdatedAt) {
+			return pledges[i].UpdatedAt.After(pledges[j].UpdatedAt)
+		}
+		return pledges[i].ID < pledges[j].ID
+	})
+	return paginate(pledges, page), int64(len(pledges)), nil
+}
+
+// Due returns up to limit held pledges to release because their ReleaseAt
+// passed by now, or to refund because their act was cancelled, expired or
+// deleted
+func (r *Pledges) Due(_ context.Context, now time.Time, limit int) ([]models.Pledge, []models.Pledge, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var held []models.Pledge
+	for _, pledge := range r.db.pledges {
+		if pledge.Status == models.PledgeHeld {
+			held = append(held, *pledge)
+		}
+	}
+	sort.Slice(held, func(i, j int) bool {
+		if !held[i].ReleaseAt.Equal(held[j].ReleaseAt) {
+			return held[i].ReleaseAt.Before(held[j].ReleaseAt)
+		}
+		return held[i].ID < held[j].ID
+	})
+
+	var release, refund []models.Pledge
+	for _, pledge := range held {
+		if len(release)+len(refund) == limit {
+			break
+		}
+		act, ok := r.db.acts[pledge.ActID]
+		switch {
+		case !ok || act.Status == models.ActStatusCancelled || act.Status == models.ActStatusExpired:
+			refund = append(refund, pledge)
+		case !pledge.ReleaseAt.After(now):
+			release = append(release, pledge)
+		}
+	}
+	return release, refund, nil
+}
+
+// pledgeForAct returns the pledge of an act, or nil. The caller must hold
+// db.mu.
+func (db *DB) pledgeForAct(actID string) *models.Pledge {
+	for _, pledge := range db.pledges {
+		if pledge.ActID == actID {
+			return pledge
+		}
+	}
+	return nil
+}
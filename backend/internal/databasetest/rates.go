@@ -0,0 +1,78 @@
+package databasetest
+
+import (
+	"context"
+	"maps"
+	"strings"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Rates is an in-memory RateRepository
+type Rates struct {
+	db *DB
+}
+
+// Ensure Rates implements repository.RateRepository
+var _ repository.RateRepository = (*Rates)(nil)
+
+// Replace stores rates in place of the current ones
+func (r *Rates) Replace(_ context.Context, rates *models.ExchangeRates) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored := *rates
+	stored.Rates = maps.Clone(rates.Rates)
+	r.db.rates = &stored
+	return nil
+}
+
+// Current returns the stored rates, or ErrNoRates
+func (r *Rates) Current(_ context.Context) (*models.ExchangeRates, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.rates == nil {
+		return nil, repository.ErrNoRates
+	}
+	rates := *r.db.rates
+	rates.Rates = maps.Clone(rates.Rates)
+	return &rates, nil
+}
+
+// Normalize converts the values of up to limit acts with a rate for their
+// currency that weren't converted to the current base currency yet
+func (r *Rates) Normalize(_ context.Context, limit int) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	n := 0
+	for _, act := range r.db.acts {
+		if n == limit || r.db.rates == nil {
+			break
+		}
+		if act.BaseValue != nil && act.BaseCurrency == r.db.rates.Base {
+			continue
+		}
+		if r.db.convert(act) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// convert sets the base value of act if there is a rate for its currency
+// and reports whether it did. db.mu must be held.
+func (db *DB) convert(act *models.Act) bool {
+	if db.rates == nil || act.Currency == "" {
+		return false
+	}
+	rate, ok := db.rates.Rates[strings.ToUpper(act.Currency)]
+	if !ok {
+		return false
+	}
+	baseValue := act.Value / rate
+	act.BaseValue, act.BaseCurrency = &baseValue, db.rates.Base
+	return true
+}
@@ -0,0 +1,110 @@
+package databasetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Receipts is an in-memory ReceiptRepository
+type Receipts struct {
+	db *DB
+}
+
+// Ensure Receipts implements repository.ReceiptRepository
+var _ repository.ReceiptRepository = (*Receipts)(nil)
+
+// ForAct returns the receipt of an act, issuing it at now if needed, or
+// returns ErrActNotFound or ErrNoReceipt
+func (r *Receipts) ForAct(_ context.Context, actID string, now time.Time) (*models.DonationReceipt, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	act, ok := r.db.acts[actID]
+	if !ok {
+		return nil, repository.ErrActNotFound
+	}
+	r.db.issueReceipt(act, now)
+	receipt, ok := r.db.receipts[actID]
+	if !ok {
+		return nil, repository.ErrNoReceipt
+	}
+	return r.db.joinReceipt(receipt), nil
+}
+
+// ForGiver returns the receipts of the acts a user completed from from
+// until to in number order, issuing those missing at now in the order the
+// acts were completed
+func (r *Receipts) ForGiver(_ context.Context, giverID string, from, to, now time.Time) ([]models.DonationReceipt, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var acts []*models.Act
+	for _, act := range r.db.acts {
+		if act.GiverID == giverID && act.CompletedAt != nil && !act.CompletedAt.Before(from) && act.CompletedAt.Before(to) {
+			acts = append(acts, act)
+		}
+	}
+	sort.Slice(acts, func(i, j int) bool {
+		if !acts[i].CompletedAt.Equal(*acts[j].CompletedAt) {
+			return acts[i].CompletedAt.Before(*acts[j].CompletedAt)
+		}
+		return acts[i].ID < acts[j].ID
+	})
+
+	receipts := []models.DonationReceipt{}
+	for _, act := range acts {
+		r.db.issueReceipt(act, now)
+		if receipt, ok := r.db.receipts[act.ID]; ok {
+			receipts = append(receipts, *r.db.joinReceipt(receipt))
+		}
+	}
+	sort.Slice(receipts, func(i, j int) bool {
+		if receipts[i].TaxYear != receipts[j].TaxYear {
+			return receipts[i].TaxYear < receipts[j].TaxYear
+		}
+		return receipts[i].Sequence < receipts[j].Sequence
+	})
+	return receipts, nil
+}
+
+// issueReceipt issues the receipt of act at now if it's a completed
+// monetary act with a receiver and without a receipt. The caller must hold
+// db.mu.
+func (db *DB) issueReceipt(act *models.Act, now time.Time) {
+	if _, ok := db.receipts[act.ID]; ok {
+		return
+	}
+	if act.Type != models.ActTypeMonetary || act.Status != models.ActStatusCompleted || act.CompletedAt == nil || act.ReceiverID == "" {
+		return
+	}
+	year := act.CompletedAt.Year()
+	db.receiptSequences[year]++
+	db.receipts[act.ID] = &models.DonationReceipt{
+		TaxYear:   year,
+		Sequence:  db.receiptSequences[year],
+		ActID:     act.ID,
+		Amount:    act.Value,
+		Currency:  act.Currency,
+		DonatedAt: *act.CompletedAt,
+		IssuedAt:  now,
+	}
+}
+
+// joinReceipt returns a copy of receipt with its act's title and its
+// giver's and receiver's details. The caller must hold db.mu.
+func (db *DB) joinReceipt(receipt *models.DonationReceipt) *models.DonationReceipt {
+	joined := *receipt
+	act := db.acts[receipt.ActID]
+	joined.Description = act.Title
+	if giver, ok := db.users[act.GiverID]; ok {
+		joined.Donor = models.ReceiptParty{ID: giver.ID, Name: giver.Name, Email: giver.Email, Location: giver.Location}
+	}
+	if receiver, ok := db.users[act.ReceiverID]; ok {
+		joined.Recipient = &models.ReceiptParty{ID: receiver.ID, Name: receiver.Name}
+	}
+	return &joined
+}
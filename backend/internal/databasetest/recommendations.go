@@ -0,0 +1,236 @@
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Recommendations is an in-memory RecommendationRepository
+type Recommendations struct {
+	db *DB
+}
+
+// Ensure Recommendations implements repository.RecommendationRepository
+var _ repository.RecommendationRepository = (*Recommendations)(nil)
+
+// Network returns the neighbourhood of a user, with up to recent of their
+// latest acts, each list but RecentActs sorted
+func (r *Recommendations) Network(_ context.Context, userID string, recent int) (*repository.Network, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	network := &repository.Network{}
+	if _, ok := r.db.users[userID]; !ok {
+		return network, nil
+	}
+	connections := r.db.connections(userID)
+	friendsOfFriends := map[string]bool{}
+	for id := range connections {
+		for fof := range r.db.connections(id) {
+			if fof != userID && !connections[fof] {
+				friendsOfFriends[fof] = true
+			}
+		}
+	}
+	network.Connections = sortedKeys(connections)
+	network.FriendsOfFriends = sortedKeys(friendsOfFriends)
+
+	categories := map[string]bool{}
+	var acts []*models.Act
+	for _, act := range r.db.acts {
+		if act.GiverID == userID || act.ReceiverID == userID {
+			acts = append(acts, act)
+			if act.Category != "" {
+				categories[act.Category] = true
+			}
+		}
+	}
+	network.Categories = sortedKeys(categories)
+	sort.Slice(acts, func(i, j int) bool {
+		return acts[i].CreatedAt.After(acts[j].CreatedAt)
+	})
+	for _, act := range acts[:min(recent, len(acts))] {
+		network.RecentActs = append(network.RecentActs, act.ID)
+	}
+	return network, nil
+}
+
+// Acts returns up to limit acts of kind close to a user, best first
+func (r *Recommendations) Acts(_ context.Context, kind, userID string, network *repository.Network, limit int) ([]models.RecommendedAct, error) {
+	if !slices.Contains(repository.RecommendedActKinds, kind) {
+		return nil, fmt.Errorf("unknown recommended act kind %q", kind)
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var found []models.RecommendedAct
+	for id, act := range r.db.acts {
+		if _, ok := r.db.users[act.GiverID]; !ok || act.GiverID == userID || act.ReceiverID == userID {
+			continue
+		}
+		switch kind {
+		case "acts":
+			chainID := r.db.chainOf(id)
+			if chainID == "" || act.Status == models.ActStatusCancelled || act.Status == models.ActStatusExpired ||
+				r.db.chainMembers(chainID)[userID] {
+				continue
+			}
+		case "needs":
+			if (act.Status != "" && act.Status != models.ActStatusPending) || act.ReceiverID != "" {
+				continue
+			}
+		}
+		known := !act.IsAnonymous
+		reasons := reasonsFor(
+			known && slices.Contains(network.Connections, act.GiverID),
+			known && slices.Contains(network.FriendsOfFriends, act.GiverID),
+			slices.Contains(network.Categories, act.Category))
+		if len(reasons) > 0 {
+			found = append(found, models.RecommendedAct{Act: publicAct(act), Reasons: reasons})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if c := compareReasons(found[i].Reasons, found[j].Reasons); c != 0 {
+			return c > 0
+		}
+		if !found[i].Act.CreatedAt.Equal(found[j].Act.CreatedAt) {
+			return found[i].Act.CreatedAt.After(found[j].Act.CreatedAt)
+		}
+		return found[i].Act.ID < found[j].Act.ID
+	})
+	return found[:min(limit, len(found))], nil
+}
+
+// Chains returns up to limit chains close to a user, best first
+func (r *Recommendations) Chains(_ context.Context, userID string, network *repository.Network, limit int) ([]models.RecommendedChain, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var found []models.RecommendedChain
+	for id, chain := range r.db.chains {
+		members := r.db.chainMembers(id)
+		if members[userID] {
+			continue
+		}
+		var connection, friendOfFriend, category bool
+		for member := range members {
+			connection = connection || slices.Contains(network.Connections, member)
+			friendOfFriend = friendOfFriend || slices.Contains(network.FriendsOfFriends, member)
+		}
+		count := 0
+		for _, actID := range r.db.chainActs[id] {
+			if act, ok := r.db.acts[actID]; ok {
+				count++
+				category = category || slices.Contains(network.Categories, act.Category)
+			}
+		}
+		if reasons := reasonsFor(connection, friendOfFriend, category); len(reasons) > 0 {
+			recommended := *chain
+			recommended.ActsCount = count
+			found = append(found, models.RecommendedChain{Chain: recommended, Reasons: reasons})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if c := compareReasons(found[i].Reasons, found[j].Reasons); c != 0 {
+			return c > 0
+		}
+		if !found[i].Chain.CreatedAt.Equal(found[j].Chain.CreatedAt) {
+			return found[i].Chain.CreatedAt.After(found[j].Chain.CreatedAt)
+		}
+		return found[i].Chain.ID < found[j].Chain.ID
+	})
+	return found[:min(limit, len(found))], nil
+}
+
+// connections returns the users userID gave to, received from or shared a
+// chain with
+func (db *DB) connections(userID string) map[string]bool {
+	found := map[string]bool{}
+	for _, act := range db.acts {
+		switch userID {
+		case act.GiverID:
+			found[act.ReceiverID] = true
+		case act.ReceiverID:
+			found[act.GiverID] = true
+		}
+	}
+	for id := range db.chains {
+		if members := db.chainMembers(id); members[userID] {
+			for member := range members {
+				found[member] = true
+			}
+		}
+	}
+	for id := range found {
+		if _, ok := db.users[id]; !ok || id == userID {
+			delete(found, id)
+		}
+	}
+	return found
+}
+
+// chainMembers returns the users who started or took part in a chain
+func (db *DB) chainMembers(chainID string) map[string]bool {
+	members := map[string]bool{}
+	if chain, ok := db.chains[chainID]; ok {
+		members[chain.StarterID] = true
+	}
+	for userID := range db.participants[chainID] {
+		members[userID] = true
+	}
+	return members
+}
+
+// chainOf returns the ID of the chain containing an act, if any
+func (db *DB) chainOf(actID string) string {
+	for chainID, actIDs := range db.chainActs {
+		if slices.Contains(actIDs, actID) {
+			return chainID
+		}
+	}
+	return ""
+}
+
+// reasonsFor lists the reasons for the flags of a recommendation, in the
+// order the Neo4j repository gives them
+func reasonsFor(connection, friendOfFriend, category bool) []models.RecommendationReason {
+	var reasons []models.RecommendationReason
+	if connection {
+		reasons = append(reasons, models.ReasonConnection)
+	}
+	if friendOfFriend {
+		reasons = append(reasons, models.ReasonFriendOfFriend)
+	}
+	if category {
+		reasons = append(reasons, models.ReasonCategory)
+	}
+	return reasons
+}
+
+// compareReasons orders recommendations like the Neo4j queries: by
+// connection, then friend of friend, then category
+func compareReasons(a, b []models.RecommendationReason) int {
+	for _, reason := range []models.RecommendationReason{models.ReasonConnection, models.ReasonFriendOfFriend, models.ReasonCategory} {
+		if x, y := slices.Contains(a, reason), slices.Contains(b, reason); x != y {
+			if x {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,119 @@
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Search is an in-memory SearchRepository. A document matches when its
+// title or text contains every word searched, ignoring case, and scores
+// one for each word in its title.
+type Search struct {
+	db *DB
+}
+
+// Ensure Search implements repository.SearchRepository
+var _ repository.SearchRepository = (*Search)(nil)
+
+// Search returns a page of the documents of kinds matching text, best
+// first, and their number
+func (r *Search) Search(_ context.Context, text string, kinds []string, page models.PaginationParams) ([]models.SearchHit, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	words := strings.Fields(strings.ToLower(text))
+	var hits []models.SearchHit
+	for _, kind := range kinds {
+		for _, doc := range r.db.searchDocuments(kind) {
+			title, body := strings.ToLower(doc.Title), strings.ToLower(doc.Text)
+			hit := models.SearchHit{SearchDocument: doc}
+			for _, word := range words {
+				if !strings.Contains(title, word) && !strings.Contains(body, word) {
+					hit.Score = -1
+					break
+				}
+				if strings.Contains(title, word) {
+					hit.Score++
+				}
+			}
+			if hit.Score >= 0 && len(words) > 0 {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	return paginate(hits, page), int64(len(hits)), nil
+}
+
+// Documents returns the searchable documents of kind with ids
+func (r *Search) Documents(_ context.Context, kind string, ids []string) ([]models.SearchDocument, error) {
+	if !slices.Contains(models.SearchKinds, kind) {
+		return nil, fmt.Errorf("unknown search kind %q", kind)
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	docs := []models.SearchDocument{}
+	for _, doc := range r.db.searchDocuments(kind) {
+		if slices.Contains(ids, doc.ID) {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// List returns up to limit searchable documents of kind with IDs after
+// after, in ID order
+func (r *Search) List(_ context.Context, kind, after string, limit int) ([]models.SearchDocument, error) {
+	if !slices.Contains(models.SearchKinds, kind) {
+		return nil, fmt.Errorf("unknown search kind %q", kind)
+	}
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	docs := []models.SearchDocument{}
+	for _, doc := range r.db.searchDocuments(kind) {
+		if doc.ID > after && len(docs) < limit {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// searchDocuments returns the searchable documents of kind in ID order,
+// leaving out unapproved testimonials. The caller must hold db.mu.
+func (db *DB) searchDocuments(kind string) []models.SearchDocument {
+	var docs []models.SearchDocument
+	switch kind {
+	case "act":
+		for _, act := range db.acts {
+			docs = append(docs, models.SearchDocument{Kind: kind, ID: act.ID, Title: act.Title, Text: act.Description})
+		}
+	case "chain":
+		for _, chain := range db.chains {
+			docs = append(docs, models.SearchDocument{Kind: kind, ID: chain.ID, Title: chain.Name, Text: chain.Description})
+		}
+	case "user":
+		for _, user := range db.users {
+			docs = append(docs, models.SearchDocument{Kind: kind, ID: user.ID, Title: user.Name, Text: user.Bio})
+		}
+	case "testimonial":
+		for _, t := range db.testimonials {
+			if t.IsApproved {
+				docs = append(docs, models.SearchDocument{Kind: kind, ID: t.ID, Title: t.Impact, Text: t.Story})
+			}
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].ID < docs[j].ID
+	})
+	return docs
+}
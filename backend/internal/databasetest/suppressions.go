@@ -0,0 +1,69 @@
+package databasetest
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Suppressions is an in-memory SuppressionRepository
+type Suppressions struct {
+	db *DB
+}
+
+// Ensure Suppressions implements repository.SuppressionRepository
+var _ repository.SuppressionRepository = (*Suppressions)(nil)
+
+// Suppressed reports whether email is on the list
+func (r *Suppressions) Suppressed(_ context.Context, email string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	_, ok := r.db.suppressions[repository.NormalizeEmail(email)]
+	return ok, nil
+}
+
+// Suppress adds email to the list, keeping the reason and time of an
+// existing entry
+func (r *Suppressions) Suppress(_ context.Context, email, reason string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	email = repository.NormalizeEmail(email)
+	if _, ok := r.db.suppressions[email]; !ok {
+		r.db.suppressions[email] = models.EmailSuppression{Email: email, Reason: reason, CreatedAt: time.Now().UTC()}
+	}
+	return nil
+}
+
+// Unsuppress removes email from the list, reporting whether it was on it
+func (r *Suppressions) Unsuppress(_ context.Context, email string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	email = repository.NormalizeEmail(email)
+	_, ok := r.db.suppressions[email]
+	delete(r.db.suppressions, email)
+	return ok, nil
+}
+
+// List returns a page of the list, newest first, and its size
+func (r *Suppressions) List(_ context.Context, page models.PaginationParams) ([]models.EmailSuppression, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	suppressions := make([]models.EmailSuppression, 0, len(r.db.suppressions))
+	for _, s := range r.db.suppressions {
+		suppressions = append(suppressions, s)
+	}
+	sort.Slice(suppressions, func(i, j int) bool {
+		if !suppressions[i].CreatedAt.Equal(suppressions[j].CreatedAt) {
+			return suppressions[i].CreatedAt.After(suppressions[j].CreatedAt)
+		}
+		return suppressions[i].Email < suppressions[j].Email
+	})
+	return paginate(suppressions, page), int64(len(suppressions)), nil
+}
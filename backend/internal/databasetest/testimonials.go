@@ -0,0 +1,297 @@
+package databasetest
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/repository"
+)
+
+// Testimonials is an in-memory TestimonialRepository
+type Testimonials struct {
+	db *DB
+}
+
+// Ensure Testimonials implements repository.TestimonialRepository
+var _ repository.TestimonialRepository = (*Testimonials)(nil)
+
+// List returns a page of testimonials matching filter, translated to the
+// first available of locales, and the total count
+func (r *Testimonials) List(_ context.Context, filter repository.TestimonialFilter, page models.PaginationParams, locales []string) ([]models.Testimonial, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var matched []*models.Testimonial
+	for _, t := range r.db.testimonials {
+		if filter.Approved != nil && t.IsApproved != *filter.Approved ||
+			filter.Featured != nil && t.IsFeatured != *filter.Featured ||
+			filter.UserID != "" && t.UserID != filter.UserID {
+			continue
+		}
+		matched = append(matched, t)
+	}
+
+	desc := page.Order != "asc"
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+		if page.SortBy == "reactions" && a.ReactionCount != b.ReactionCount {
+			return (a.ReactionCount > b.ReactionCount) == desc
+		}
+		if page.SortBy == "reactions" {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.CreatedAt.After(b.CreatedAt) == desc
+	})
+
+	testimonials := make([]models.Testimonial, 0, len(matched))
+	for _, t := range paginate(matched, page) {
+		testimonials = append(testimonials, r.db.view(t, locales))
+	}
+	return testimonials, int64(len(matched)), nil
+}
+
+// Featured returns approved, featured testimonials in their explicit order,
+// then newest first
+func (r *Testimonials) Featured(_ context.Context, limit int, locales []string) ([]models.Testimonial, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	var featured []*models.Testimonial
+	for _, t := range r.db.testimonials {
+		if t.IsApproved && t.IsFeatured {
+			featured = append(featured, t)
+		}
+	}
+	sort.Slice(featured, func(i, j int) bool {
+		a, b := featuredOrder(featured[i]), featuredOrder(featured[j])
+		if a != b {
+			return a < b
+		}
+		return featured[i].CreatedAt.After(featured[j].CreatedAt)
+	})
+
+	testimonials := []models.Testimonial{}
+	for _, t := range featured[:min(limit, len(featured))] {
+		testimonials = append(testimonials, r.db.view(t, locales))
+	}
+	return testimonials, nil
+}
+
+// SetFeatured features or unfeatures a testimonial. It returns
+// repository.ErrTestimonialNotFound or, when featuring a testimonial that
+// isn't approved yet, repository.ErrNotApproved.
+func (r *Testimonials) SetFeatured(_ context.Context, id string, featured bool, order *int) (*models.Testimonial, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	t, ok := r.db.testimonials[id]
+	if !ok {
+		return nil, repository.ErrTestimonialNotFound
+	}
+	if featured && !t.IsApproved {
+		return nil, repository.ErrNotApproved
+	}
+
+	t.IsFeatured = featured
+	t.FeaturedOrder = nil
+	t.Version++
+	if featured && order != nil {
+		value := *order
+		t.FeaturedOrder = &value
+	}
+
+	view := r.db.view(t, nil)
+	view.User = nil
+	return &view, nil
+}
+
+// Create stores t pending review and records the result of its screening.
+// It returns false if the author does not exist, in which case nothing is
+// stored.
+func (r *Testimonials) Create(_ context.Context, t *models.Testimonial, screening moderation.Result) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.users[t.UserID]; !ok {
+		return false, nil
+	}
+
+	stored := cloneTestimonial(t)
+	stored.IsApproved, stored.IsFeatured = false, false
+	stored.FeaturedOrder, stored.User, stored.Locale = nil, nil, ""
+	stored.Version = 1
+	r.db.testimonials[t.ID] = stored
+	r.db.recordEvent(models.DomainTestimonialCreated, t.ID, stored)
+	r.db.applyScreening(t.ID, screening)
+	return true, nil
+}
+
+// Update applies an edit by the author or an admin. Translations of changed
+// text are dropped, and edits by the author send the testimonial back to
+// moderation and edited text through screen.
+func (r *Testimonials) Update(_ context.Context, id string, update repository.TestimonialUpdate, screen repository.Screen) (*models.Testimonial, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	t, err := r.db.ownedTestimonial(id, update.UserID, update.Admin)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(t.Version, update.Version); err != nil {
+		return nil, err
+	}
+
+	textChanged := update.Story != "" || update.Impact != ""
+	if textChanged {
+		delete(r.db.translations, id)
+	}
+
+	setIfNotEmpty(&t.Story, update.Story)
+	setIfNotEmpty(&t.Impact, update.Impact)
+	switch {
+	case update.RemoveMedia:
+		t.Media = nil
+	case update.Media != nil:
+		media := *update.Media
+		t.Media = &media
+	}
+	now := time.Now().UTC()
+	t.UpdatedAt = &now
+	t.Version++
+
+	if !update.Admin {
+		t.IsApproved, t.IsFeatured, t.FeaturedOrder = false, false, nil
+		if textChanged && screen != nil {
+			r.db.applyScreening(id, screen(t.Story, t.Impact))
+		}
+	}
+
+	view := r.db.view(t, nil)
+	r.db.recordEvent(models.DomainTestimonialUpdated, id, view)
+	return &view, nil
+}
+
+// Delete moves a testimonial to the trash, unfeatured and without its
+// translations, if userID is its author or admin is set. It returns repository.ErrTestimonialNotFound or
+// repository.ErrNotTestimonialOwner otherwise.
+func (r *Testimonials) Delete(_ context.Context, id, userID string, admin bool) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	t, err := r.db.ownedTestimonial(id, userID, admin)
+	if err != nil {
+		return err
+	}
+	t.IsFeatured, t.FeaturedOrder = false, nil
+	t.Version++
+	r.db.trash["testimonials"][id] = r.db.softDelete(models.DomainTestimonialDeleted, id, t)
+	delete(r.db.testimonials, id)
+	delete(r.db.translations, id)
+	return nil
+}
+
+// ToggleReaction adds a user's reaction to an approved testimonial, or
+// removes it if already present. It returns
+// repository.ErrTestimonialNotFound or repository.ErrUserNotFound if either
+// doesn't exist.
+func (r *Testimonials) ToggleReaction(_ context.Context, id, userID string) (*models.ReactionResult, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	t, ok := r.db.testimonials[id]
+	if !ok || !t.IsApproved {
+		return nil, repository.ErrTestimonialNotFound
+	}
+
+	reacted := r.db.reactions[id][userID]
+	switch {
+	case reacted:
+		delete(r.db.reactions[id], userID)
+		t.ReactionCount = max(t.ReactionCount-1, 0)
+	default:
+		if _, ok := r.db.users[userID]; !ok {
+			return nil, repository.ErrUserNotFound
+		}
+		if r.db.reactions[id] == nil {
+			r.db.reactions[id] = make(map[string]bool)
+		}
+		r.db.reactions[id][userID] = true
+		t.ReactionCount++
+	}
+
+	eventType := models.DomainTestimonialReacted
+	if reacted {
+		eventType = models.DomainTestimonialUnreacted
+	}
+	r.db.recordEvent(eventType, id, models.ReactionChange{UserID: userID, ReactionCount: t.ReactionCount})
+	return &models.ReactionResult{Reacted: !reacted, ReactionCount: t.ReactionCount, AuthorID: t.UserID}, nil
+}
+
+// ownedTestimonial returns the testimonial id if userID may modify it. The
+// caller must hold db.mu.
+func (db *DB) ownedTestimonial(id, userID string, admin bool) (*models.Testimonial, error) {
+	t, ok := db.testimonials[id]
+	if !ok {
+		return nil, repository.ErrTestimonialNotFound
+	}
+	if !admin && t.UserID != userID {
+		return nil, repository.ErrNotTestimonialOwner
+	}
+	return t, nil
+}
+
+// applyScreening records a screening result. Blocked content is rejected
+// straight away. The caller must hold db.mu.
+func (db *DB) applyScreening(id string, screening moderation.Result) {
+	db.screenings[id] = screening
+	if screening.Verdict == moderation.Block {
+		t := db.testimonials[id]
+		t.IsApproved, t.IsFeatured, t.FeaturedOrder = false, false, nil
+	}
+}
+
+// view copies t with its author's public profile, translated to the first
+// available of locales. The caller must hold db.mu.
+func (db *DB) view(t *models.Testimonial, locales []string) models.Testimonial {
+	view := *cloneTestimonial(t)
+	if author, ok := db.users[t.UserID]; ok {
+		view.User = &models.User{ID: author.ID, Name: author.Name, Location: author.Location}
+	}
+	for _, locale := range locales {
+		if tr, ok := db.translations[t.ID][locale]; ok {
+			view.Story, view.Impact, view.Locale = tr.story, tr.impact, locale
+			break
+		}
+	}
+	return view
+}
+
+func featuredOrder(t *models.Testimonial) int {
+	if t.FeaturedOrder == nil {
+		return math.MaxInt32
+	}
+	return *t.FeaturedOrder
+}
+
+// cloneTestimonial copies t along with the values its pointer fields refer
+// to, so stored testimonials can't be changed through returned ones
+func cloneTestimonial(t *models.Testimonial) *models.Testimonial {
+	clone := *t
+	if t.FeaturedOrder != nil {
+		order := *t.FeaturedOrder
+		clone.FeaturedOrder = &order
+	}
+	if t.Media != nil {
+		media := *t.Media
+		clone.Media = &media
+	}
+	if t.UpdatedAt != nil {
+		updatedAt := *t.UpdatedAt
+		clone.UpdatedAt = &updatedAt
+	}
+	return &clone
+}
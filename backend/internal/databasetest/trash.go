@@ -0,0 +1,130 @@
+package databasetest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// trashed is a soft deleted user, act or testimonial
+type trashed struct {
+	entity       interface{}
+	passwordHash string
+	deletedAt    time.Time
+}
+
+// softDelete returns the trash entry of entity, deleted now, and records
+// eventType. The caller must hold db.mu.
+func (db *DB) softDelete(eventType, id string, entity interface{}) trashed {
+	now := time.Now().UTC()
+	db.recordEvent(eventType, id, map[string]time.Time{"deletedAt": now})
+	return trashed{entity: entity, deletedAt: now}
+}
+
+// Trash is an in-memory TrashRepository. Deleted entities are moved out of
+// the live maps, so the other repositories don't see them, and keep their
+// relationships until purged.
+type Trash struct {
+	db *DB
+}
+
+// Ensure Trash implements repository.TrashRepository
+var _ repository.TrashRepository = (*Trash)(nil)
+
+// Restore moves a deleted entity back, or returns repository.ErrNotDeleted
+func (r *Trash) Restore(_ context.Context, kind, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	kindTrash, ok := r.db.trash[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+	item, ok := kindTrash[id]
+	if !ok {
+		return repository.ErrNotDeleted
+	}
+	delete(kindTrash, id)
+
+	switch entity := item.entity.(type) {
+	case *models.User:
+		entity.Version++
+		r.db.users[id] = entity
+		r.db.passwordHashes[id] = item.passwordHash
+	case *models.Act:
+		entity.Version++
+		r.db.acts[id] = entity
+	case *models.Testimonial:
+		entity.Version++
+		r.db.testimonials[id] = entity
+	}
+	r.db.recordEvent(restoredEvents[kind], id, struct{}{})
+	return nil
+}
+
+// restoredEvents are the domain events recorded when an entity of each
+// kind is restored
+var restoredEvents = map[string]string{
+	"users":        models.DomainUserRestored,
+	"acts":         models.DomainActRestored,
+	"testimonials": models.DomainTestimonialRestored,
+}
+
+// Purge drops the entities deleted before before along with their
+// relationships. Acts a purged user gave or received are kept, as are
+// testimonials, which lose their author.
+func (r *Trash) Purge(_ context.Context, before time.Time) (map[string]int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	purged := make(map[string]int, len(repository.TrashKinds))
+	for _, kind := range repository.TrashKinds {
+		for id, item := range r.db.trash[kind] {
+			if !item.deletedAt.Before(before) {
+				continue
+			}
+			delete(r.db.trash[kind], id)
+			r.db.dropRelationships(kind, id)
+			purged[kind]++
+		}
+	}
+	return purged, nil
+}
+
+// dropRelationships removes what links to a purged entity. The caller must
+// hold db.mu.
+func (db *DB) dropRelationships(kind, id string) {
+	switch kind {
+	case "users":
+		for _, users := range db.participants {
+			delete(users, id)
+		}
+		for _, users := range db.reactions {
+			delete(users, id)
+		}
+		for notificationID, n := range db.notifications {
+			if n.UserID == id {
+				delete(db.notifications, notificationID)
+			}
+		}
+		delete(db.digestsSent, id)
+	case "acts":
+		delete(db.actScreenings, id)
+		for chainID, actIDs := range db.chainActs {
+			kept := actIDs[:0]
+			for _, actID := range actIDs {
+				if actID != id {
+					kept = append(kept, actID)
+				}
+			}
+			db.chainActs[chainID] = kept
+		}
+	case "testimonials":
+		delete(db.reactions, id)
+		delete(db.translations, id)
+		delete(db.screenings, id)
+	}
+}
@@ -0,0 +1,261 @@
+package databasetest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Users is an in-memory UserRepository
+type Users struct {
+	db *DB
+}
+
+// Ensure Users implements repository.UserRepository
+var _ repository.UserRepository = (*Users)(nil)
+
+// Get returns a user with their activity counts, or
+// repository.ErrUserNotFound
+func (r *Users) Get(_ context.Context, id string) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+
+	found := *user
+	for _, act := range r.db.acts {
+		if act.GiverID == id {
+			found.Stats.ActsGiven++
+		}
+		if act.ReceiverID == id {
+			found.Stats.ActsReceived++
+		}
+	}
+	for _, chain := range r.db.chains {
+		if chain.StarterID == id {
+			found.Stats.ChainsStarted++
+		}
+	}
+	return &found, nil
+}
+
+// Stream passes every user to fn, oldest first and without activity
+// counts. The users are copied first, so fn may use the other repositories.
+func (r *Users) Stream(_ context.Context, fn func(*models.User) error) error {
+	r.db.mu.Lock()
+	users := make([]models.User, 0, len(r.db.users))
+	for _, user := range r.db.users {
+		users = append(users, *user)
+	}
+	r.db.mu.Unlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.Before(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+	for i := range users {
+		if err := fn(&users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindCredentials returns the user registered with email and their password
+// hash, or repository.ErrUserNotFound
+func (r *Users) FindCredentials(_ context.Context, email string) (*models.User, string, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user := r.db.userByEmail(email)
+	if user == nil {
+		return nil, "", repository.ErrUserNotFound
+	}
+	found := *user
+	return &found, r.db.passwordHashes[user.ID], nil
+}
+
+// EmailExists reports whether a user registered with email, including
+// deleted users who haven't been purged
+func (r *Users) EmailExists(_ context.Context, email string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if r.db.userByEmail(email) != nil {
+		return true, nil
+	}
+	for _, item := range r.db.trash["users"] {
+		if item.entity.(*models.User).Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Create stores a new, unverified user
+func (r *Users) Create(_ context.Context, user *models.User, passwordHash string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored := *user
+	stored.IsVerified = false
+	stored.Version = 1
+	stored.Stats = models.UserStats{}
+	r.db.users[user.ID] = &stored
+	r.db.passwordHashes[user.ID] = passwordHash
+	r.db.recordEvent(models.DomainUserCreated, user.ID, stored)
+	return nil
+}
+
+// Update changes the profile fields set in req and returns the user's new
+// version, or returns repository.ErrUserNotFound or
+// repository.ErrVersionConflict
+func (r *Users) Update(_ context.Context, id string, req models.UpdateUserRequest) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[id]
+	if !ok {
+		return 0, repository.ErrUserNotFound
+	}
+	if err := checkVersion(user.Version, req.Version); err != nil {
+		return 0, err
+	}
+	setIfNotEmpty(&user.Name, req.Name)
+	setIfNotEmpty(&user.Avatar, req.Avatar)
+	setIfNotEmpty(&user.Bio, req.Bio)
+	setIfNotEmpty(&user.Location, req.Location)
+	setIfNotEmpty(&user.Locale, req.Locale)
+	if req.HideFromLeaderboards != nil {
+		user.HideFromLeaderboards = *req.HideFromLeaderboards
+	}
+	user.UpdatedAt = time.Now().UTC()
+	user.Version++
+	r.db.recordEvent(models.DomainUserUpdated, id, user)
+	return user.Version, nil
+}
+
+// Delete moves a user to the trash, where they keep their relationships
+// until restored or purged
+func (r *Users) Delete(_ context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if user, ok := r.db.users[id]; ok {
+		user.Version++
+		deleted := r.db.softDelete(models.DomainUserDeleted, id, user)
+		deleted.passwordHash = r.db.passwordHashes[id]
+		r.db.trash["users"][id] = deleted
+		delete(r.db.users, id)
+		delete(r.db.passwordHashes, id)
+	}
+	return nil
+}
+
+// CreateBatch stores users as given, replacing any with the same ID
+func (r *Users) CreateBatch(_ context.Context, users []repository.BatchUser) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	for _, u := range users {
+		stored := u.User
+		stored.Stats = models.UserStats{}
+		stored.Version = 1
+		if existing, ok := r.db.users[stored.ID]; ok {
+			stored.Version = existing.Version + 1
+		}
+		r.db.users[stored.ID] = &stored
+		r.db.passwordHashes[stored.ID] = u.PasswordHash
+	}
+	return len(users), nil
+}
+
+// List returns a page of the users matching filter, newest first and
+// without activity counts, and how many match
+func (r *Users) List(_ context.Context, filter repository.UserFilter, page models.PaginationParams) ([]models.User, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	users := []models.User{}
+	for _, user := range r.db.users {
+		if query != "" && !strings.Contains(strings.ToLower(user.Name), query) && !strings.Contains(strings.ToLower(user.Email), query) {
+			continue
+		}
+		if filter.Suspended != nil && (user.SuspendedAt != nil) != *filter.Suspended {
+			continue
+		}
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID < users[j].ID
+	})
+	return paginate(users, page), int64(len(users)), nil
+}
+
+// Suspend suspends or reinstates a user and records the change, or returns
+// repository.ErrUserNotFound
+func (r *Users) Suspend(_ context.Context, id string, suspended bool, reason, adminID string, now time.Time) (*models.User, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	user, ok := r.db.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	eventType := models.DomainUserReinstated
+	user.SuspendedAt, user.SuspensionReason = nil, ""
+	if suspended {
+		at := now.UTC()
+		user.SuspendedAt, user.SuspensionReason = &at, reason
+		eventType = models.DomainUserSuspended
+	}
+	user.UpdatedAt = now.UTC()
+	user.Version++
+	r.db.recordEvent(eventType, id, models.UserSuspension{AdminID: adminID, Reason: reason})
+	found := *user
+	return &found, nil
+}
+
+// Suspended reports whether an admin suspended user id or they were erased
+func (r *Users) Suspended(_ context.Context, id string) (bool, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if user, ok := r.db.users[id]; ok {
+		return user.SuspendedAt != nil || repository.IsErasedEmail(user.Email), nil
+	}
+	if item, ok := r.db.trash["users"][id]; ok {
+		return repository.IsErasedEmail(item.entity.(*models.User).Email), nil
+	}
+	return false, nil
+}
+
+// userByEmail returns the user registered with email, or nil. The caller
+// must hold db.mu.
+func (db *DB) userByEmail(email string) *models.User {
+	for _, user := range db.users {
+		if user.Email == email {
+			return user
+		}
+	}
+	return nil
+}
+
+func setIfNotEmpty(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
@@ -0,0 +1,128 @@
+package databasetest
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Webhooks is an in-memory WebhookRepository
+type Webhooks struct {
+	db *DB
+}
+
+// Ensure Webhooks implements repository.WebhookRepository
+var _ repository.WebhookRepository = (*Webhooks)(nil)
+
+// Create stores hook
+func (r *Webhooks) Create(_ context.Context, hook *models.Webhook) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	stored := *hook
+	stored.Events = slices.Clone(hook.Events)
+	r.db.webhooks[hook.ID] = &stored
+	return nil
+}
+
+// List returns every webhook, newest first, without their secrets
+func (r *Webhooks) List(_ context.Context) ([]models.Webhook, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	hooks := []models.Webhook{}
+	for _, hook := range r.db.webhooks {
+		found := *hook
+		found.Secret = ""
+		hooks = append(hooks, found)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		if !hooks[i].CreatedAt.Equal(hooks[j].CreatedAt) {
+			return hooks[i].CreatedAt.After(hooks[j].CreatedAt)
+		}
+		return hooks[i].ID < hooks[j].ID
+	})
+	return hooks, nil
+}
+
+// Subscribed returns the webhooks subscribed to event, with their secrets,
+// sorted by ID
+func (r *Webhooks) Subscribed(_ context.Context, event string) ([]models.Webhook, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	hooks := []models.Webhook{}
+	for _, hook := range r.db.webhooks {
+		if slices.Contains(hook.Events, event) {
+			hooks = append(hooks, *hook)
+		}
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].ID < hooks[j].ID })
+	return hooks, nil
+}
+
+// Delete removes a webhook and its delivery log, or returns
+// repository.ErrWebhookNotFound
+func (r *Webhooks) Delete(_ context.Context, id string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[id]; !ok {
+		return repository.ErrWebhookNotFound
+	}
+	delete(r.db.webhooks, id)
+	delete(r.db.deliveries, id)
+	return nil
+}
+
+// RecordDelivery adds an attempt to its webhook's delivery log, dropping it
+// if the webhook was deleted
+func (r *Webhooks) RecordDelivery(_ context.Context, d models.WebhookDelivery) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[d.WebhookID]; ok {
+		r.db.deliveries[d.WebhookID] = append(r.db.deliveries[d.WebhookID], d)
+	}
+	return nil
+}
+
+// Deliveries returns a page of a webhook's delivery log, newest first, and
+// its size, or repository.ErrWebhookNotFound
+func (r *Webhooks) Deliveries(_ context.Context, id string, page models.PaginationParams) ([]models.WebhookDelivery, int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	if _, ok := r.db.webhooks[id]; !ok {
+		return nil, 0, repository.ErrWebhookNotFound
+	}
+	deliveries := slices.Clone(r.db.deliveries[id])
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].Attempt > deliveries[j].Attempt
+	})
+	return paginate(deliveries, page), int64(len(deliveries)), nil
+}
+
+// PruneDeliveries deletes the delivery log entries created before before
+// and returns how many
+func (r *Webhooks) PruneDeliveries(_ context.Context, before time.Time) (int, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+
+	pruned := 0
+	for id, deliveries := range r.db.deliveries {
+		kept := slices.DeleteFunc(deliveries, func(d models.WebhookDelivery) bool {
+			return d.CreatedAt.Before(before)
+		})
+		pruned += len(deliveries) - len(kept)
+		r.db.deliveries[id] = kept
+	}
+	return pruned, nil
+}
@@ -0,0 +1,128 @@
+// Package diagnostics runs self-checks against the server's dependencies,
+// such as the database and identity provider, and reports which pass, for
+// the doctor command and the admin endpoint of the same name.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Check outcomes. A warning points at something that works but may not be
+// intended; a skipped check doesn't apply to the configuration.
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Check is one self-check. Run returns the outcome and a detail for the
+// report, using Pass, Warn, Fail or Skip.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (status, detail string)
+}
+
+// Pass reports a check that passed
+func Pass(format string, args ...any) (string, string) {
+	return StatusPass, fmt.Sprintf(format, args...)
+}
+
+// Warn reports a check that passed with a caveat
+func Warn(format string, args ...any) (string, string) {
+	return StatusWarn, fmt.Sprintf(format, args...)
+}
+
+// Fail reports a check that failed because of err
+func Fail(err error) (string, string) {
+	return StatusFail, err.Error()
+}
+
+// Skip reports a check that doesn't apply
+func Skip(reason string) (string, string) {
+	return StatusSkip, reason
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Report is the outcome of every check. Status is fail if any check failed,
+// warn if any warned, and pass otherwise.
+type Report struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Checks    []Result  `json:"checks"`
+}
+
+// Failed reports whether any check failed
+func (r Report) Failed() bool {
+	return r.Status == StatusFail
+}
+
+// Run runs checks concurrently, giving each at most timeout, and reports
+// them in the order given. A check that panics fails.
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runOne(ctx, check, timeout)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusPass, CheckedAt: time.Now().UTC(), Checks: results}
+	for _, result := range results {
+		switch {
+		case result.Status == StatusFail:
+			report.Status = StatusFail
+		case result.Status == StatusWarn && report.Status == StatusPass:
+			report.Status = StatusWarn
+		}
+	}
+	return report
+}
+
+func runOne(ctx context.Context, check Check, timeout time.Duration) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	result.Name = check.Name
+	defer func() {
+		if p := recover(); p != nil {
+			result.Status, result.Detail = Fail(fmt.Errorf("check panicked: %v", p))
+		}
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+	result.Status, result.Detail = check.Run(ctx)
+	return result
+}
+
+// WriteText writes report as a table, one check per line. Details of
+// several lines continue in the detail column.
+func WriteText(w io.Writer, report Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, result := range report.Checks {
+		lines := strings.Split(result.Detail, "\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.Status, result.Name, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(tw, "\t\t%s\n", line)
+		}
+	}
+	fmt.Fprintf(tw, "\n%s\n", report.Status)
+	return tw.Flush()
+}
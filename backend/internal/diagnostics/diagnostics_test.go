@@ -0,0 +1,63 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "database", Run: func(context.Context) (string, string) { return Pass("reachable") }},
+		{Name: "cache", Run: func(context.Context) (string, string) { return Skip("not configured") }},
+		{Name: "slow", Run: func(ctx context.Context) (string, string) {
+			<-ctx.Done()
+			return Fail(ctx.Err())
+		}},
+		{Name: "broken", Run: func(context.Context) (string, string) { panic("boom") }},
+	}
+
+	report := Run(context.Background(), checks, 20*time.Millisecond)
+	if !report.Failed() {
+		t.Errorf("status = %s, want fail", report.Status)
+	}
+	want := []string{StatusPass, StatusSkip, StatusFail, StatusFail}
+	for i, result := range report.Checks {
+		if result.Name != checks[i].Name || result.Status != want[i] {
+			t.Errorf("check %d = %s %s, want %s %s", i, result.Name, result.Status, checks[i].Name, want[i])
+		}
+	}
+	if !strings.Contains(report.Checks[3].Detail, "boom") {
+		t.Errorf("expected the panic in the detail, got %q", report.Checks[3].Detail)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteText(&buf, report); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "pass  database  reachable") {
+		t.Errorf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestRunStatus(t *testing.T) {
+	warn := Check{Name: "warn", Run: func(context.Context) (string, string) { return Warn("default secret") }}
+	pass := Check{Name: "pass", Run: func(context.Context) (string, string) { return Pass("ok") }}
+	fail := Check{Name: "fail", Run: func(context.Context) (string, string) { return Fail(errors.New("down")) }}
+
+	for _, tc := range []struct {
+		checks []Check
+		want   string
+	}{
+		{nil, StatusPass},
+		{[]Check{pass, warn}, StatusWarn},
+		{[]Check{fail, warn}, StatusFail},
+	} {
+		if got := Run(context.Background(), tc.checks, time.Second).Status; got != tc.want {
+			t.Errorf("status = %s, want %s", got, tc.want)
+		}
+	}
+}
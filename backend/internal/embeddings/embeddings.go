@@ -0,0 +1,136 @@
+// Package embeddings turns acts into vectors with a pluggable provider,
+// stores them on Act nodes and finds similar acts through a Neo4j vector
+// index.
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Provider turns texts into embedding vectors
+type Provider interface {
+	// Embed returns one vector per text, in order, each Dimensions long
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+	Dimensions() int
+	// Model names the provider and model. Vectors are only compared with
+	// vectors from the same model, so changing it re-embeds every act.
+	Model() string
+}
+
+// Config selects and configures a provider
+type Config struct {
+	// Provider is "hashing", "openai" or empty to disable embeddings
+	Provider string
+	// URL, APIKey and Model configure the openai provider; URL can point
+	// at any service implementing the OpenAI embeddings API
+	URL    string
+	APIKey string
+	Model  string
+	// Dimensions defaults to 256 for hashing and 1536 for openai
+	Dimensions int
+}
+
+// NewProvider returns the provider described by cfg, or nil if embeddings
+// are disabled
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "hashing":
+		dims := cfg.Dimensions
+		if dims == 0 {
+			dims = 256
+		}
+		return NewHashing(dims), nil
+	case "openai":
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("the openai embeddings provider needs an API key")
+		}
+		return &OpenAI{URL: cfg.URL, APIKey: cfg.APIKey, ModelName: cfg.Model, Dims: cfg.Dimensions}, nil
+	}
+	return nil, fmt.Errorf("unknown embeddings provider %q; use hashing or openai", cfg.Provider)
+}
+
+// ActText is the text embedded for an act
+func ActText(title, description, category string) string {
+	text := strings.TrimSpace(title)
+	if description = strings.TrimSpace(description); description != "" {
+		text += ". " + description
+	}
+	if category != "" {
+		text += " (" + category + ")"
+	}
+	return text
+}
+
+// Hashing embeds texts locally by hashing their words into a fixed number
+// of buckets. It needs no external service and catches acts that share
+// words, but not synonyms; use it for development and small deployments.
+type Hashing struct {
+	dims int
+}
+
+// NewHashing creates a Hashing provider producing vectors of dims
+// dimensions
+func NewHashing(dims int) *Hashing {
+	return &Hashing{dims: dims}
+}
+
+// Embed returns the normalized word-count vector of each text
+func (h *Hashing) Embed(_ context.Context, texts []string) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vector := make([]float64, h.dims)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			if len(word) < 3 {
+				continue
+			}
+			hash := fnv.New64a()
+			hash.Write([]byte(word))
+			sum := hash.Sum64()
+			// The top bit picks a sign, so collisions cancel out on average
+			sign := 1.0
+			if sum>>63 == 1 {
+				sign = -1
+			}
+			vector[sum%uint64(h.dims)] += sign
+		}
+		vectors[i] = normalize(vector)
+	}
+	return vectors, nil
+}
+
+// Dimensions returns the length of the vectors
+func (h *Hashing) Dimensions() int {
+	return h.dims
+}
+
+// Model names the hashing scheme and its size
+func (h *Hashing) Model() string {
+	return fmt.Sprintf("hashing-%d", h.dims)
+}
+
+// normalize scales vector to unit length. An all-zero vector, from a text
+// without words, is left as it is.
+func normalize(vector []float64) []float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += v * v
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
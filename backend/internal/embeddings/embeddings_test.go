@@ -0,0 +1,94 @@
+package embeddings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func TestHashing_SimilarTextsScoreHigher(t *testing.T) {
+	h := NewHashing(256)
+	vectors, err := h.Embed(context.Background(), []string{
+		ActText("Bought groceries for a neighbour", "Picked up groceries for an elderly neighbour", "food"),
+		ActText("Groceries for my neighbour", "Dropped off groceries next door", "food"),
+		ActText("Fixed a bicycle", "Repaired a flat tyre for a student", "repair"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, v := range vectors {
+		if len(v) != 256 {
+			t.Fatalf("vector %d: expected 256 dimensions, got %d", i, len(v))
+		}
+		if norm := dot(v, v); norm < 0.999 || norm > 1.001 {
+			t.Errorf("vector %d: expected unit length, got squared norm %f", i, norm)
+		}
+	}
+	if related, unrelated := dot(vectors[0], vectors[1]), dot(vectors[0], vectors[2]); related <= unrelated {
+		t.Errorf("expected related acts to be closer: %f <= %f", related, unrelated)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if p, err := NewProvider(Config{}); p != nil || err != nil {
+		t.Errorf("expected embeddings to be disabled, got %v, %v", p, err)
+	}
+	if p, err := NewProvider(Config{Provider: "hashing"}); err != nil || p.Model() != "hashing-256" {
+		t.Errorf("expected a default hashing provider, got %v, %v", p, err)
+	}
+	if _, err := NewProvider(Config{Provider: "openai"}); err == nil {
+		t.Error("expected the openai provider to need an API key")
+	}
+	if _, err := NewProvider(Config{Provider: "word2vec"}); err == nil {
+		t.Error("expected an unknown provider to fail")
+	}
+}
+
+func TestOpenAI_Embed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Model      string   `json:"model"`
+			Input      []string `json:"input"`
+			Dimensions int      `json:"dimensions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != DefaultOpenAIModel || req.Dimensions != 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// Answer out of order, as the API allows
+		json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{
+			{"index": 1, "embedding": []float64{0, 1}},
+			{"index": 0, "embedding": []float64{1, 0}},
+		}})
+	}))
+	defer server.Close()
+
+	o := &OpenAI{URL: server.URL, APIKey: "key", Dims: 2}
+	vectors, err := o.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("expected vectors in input order, got %v", vectors)
+	}
+
+	o.APIKey = "wrong"
+	if _, err := o.Embed(context.Background(), []string{"first"}); err == nil {
+		t.Error("expected a rejected request to fail")
+	}
+}
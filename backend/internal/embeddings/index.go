@@ -0,0 +1,191 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// IndexName is the Neo4j vector index over act embeddings
+const IndexName = "act_embedding"
+
+// refreshBatchSize is the number of acts embedded per provider request
+const refreshBatchSize = 64
+
+// Match is an act found by similarity, with its cosine similarity score
+// mapped to [0, 1]
+type Match struct {
+	ID    string
+	Score float64
+}
+
+// Searcher finds acts by embedding similarity
+type Searcher interface {
+	// Similar returns up to limit acts most similar to the act with id,
+	// best first, leaving out the act itself. It returns nothing for an act
+	// that hasn't been embedded yet.
+	Similar(ctx context.Context, id string, limit int) ([]Match, error)
+	// Search returns up to limit acts most similar to text, best first
+	Search(ctx context.Context, text string, limit int) ([]Match, error)
+}
+
+// Index keeps act embeddings up to date and searches them. Vectors are
+// stored in the embedding property of Act nodes, with the model that
+// produced them in embeddingModel and the act's updatedAt at the time in
+// embeddedAt, so edited acts are embedded again.
+type Index struct {
+	db       database.DBClient
+	provider Provider
+}
+
+// Ensure Index implements Searcher
+var _ Searcher = (*Index)(nil)
+
+// NewIndex creates an Index embedding acts with provider
+func NewIndex(db database.DBClient, provider Provider) *Index {
+	return &Index{db: db, provider: provider}
+}
+
+// EnsureIndex creates the vector index for the provider's dimensions,
+// replacing an existing index of another size. Vector indexes need Neo4j
+// 5.11 or later.
+func (i *Index) EnsureIndex(ctx context.Context) error {
+	dims, err := database.ExecuteRead(ctx, i.db, func(tx neo4j.ManagedTransaction) (int64, error) {
+		result, err := queries.IndexDimensions.Run(ctx, tx, map[string]interface{}{"name": IndexName})
+		if err != nil {
+			return 0, err
+		}
+		dims, _, err := database.First(ctx, result, func(record *neo4j.Record) (int64, error) {
+			return database.RecordValue[int64](record, "dimensions")
+		})
+		return dims, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read vector index: %w", err)
+	}
+	if dims == int64(i.provider.Dimensions()) {
+		return nil
+	}
+
+	statements := []string{
+		fmt.Sprintf(`CREATE VECTOR INDEX %s IF NOT EXISTS FOR (a:Act) ON (a.embedding)
+			OPTIONS {indexConfig: {`+"`vector.dimensions`: %d, `vector.similarity_function`"+`: 'cosine'}}`,
+			IndexName, i.provider.Dimensions()),
+	}
+	if dims != 0 {
+		statements = append([]string{"DROP INDEX " + IndexName}, statements...)
+	}
+	for _, statement := range statements {
+		if _, err := i.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			result, err := tx.Run(ctx, statement, nil)
+			if err != nil {
+				return nil, err
+			}
+			return result.Consume(ctx)
+		}); err != nil {
+			return fmt.Errorf("failed to create vector index: %w", err)
+		}
+	}
+	return nil
+}
+
+// staleAct is an act whose embedding is missing or out of date
+type staleAct struct {
+	id, text  string
+	updatedAt interface{}
+}
+
+// Refresh embeds every act that has no embedding from the current model or
+// has changed since it was embedded, and returns how many it embedded
+func (i *Index) Refresh(ctx context.Context) (int, error) {
+	model := i.provider.Model()
+	embedded := 0
+	for {
+		acts, err := database.ExecuteRead(ctx, i.db, func(tx neo4j.ManagedTransaction) ([]staleAct, error) {
+			result, err := queries.EmbeddingStale.Run(ctx, tx, map[string]interface{}{"model": model, "limit": refreshBatchSize})
+			if err != nil {
+				return nil, err
+			}
+			return database.Collect(ctx, result, func(record *neo4j.Record) (staleAct, error) {
+				id, err := database.RecordValue[string](record, "id")
+				if err != nil {
+					return staleAct{}, err
+				}
+				title, _ := database.RecordValue[string](record, "title")
+				description, _ := database.RecordValue[string](record, "description")
+				category, _ := database.RecordValue[string](record, "category")
+				updatedAt, _ := record.Get("updatedAt")
+				return staleAct{id: id, text: ActText(title, description, category), updatedAt: updatedAt}, nil
+			})
+		})
+		if err != nil {
+			return embedded, fmt.Errorf("failed to find acts to embed: %w", err)
+		}
+		if len(acts) == 0 {
+			return embedded, nil
+		}
+
+		texts := make([]string, len(acts))
+		for j, act := range acts {
+			texts[j] = act.text
+		}
+		vectors, err := i.provider.Embed(ctx, texts)
+		if err != nil {
+			return embedded, err
+		}
+
+		rows := make([]map[string]interface{}, len(acts))
+		for j, act := range acts {
+			rows[j] = map[string]interface{}{"id": act.id, "vector": vectors[j], "updatedAt": act.updatedAt}
+		}
+		_, err = i.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			result, err := queries.EmbeddingStore.Run(ctx, tx, map[string]interface{}{"rows": rows, "model": model, "now": time.Now().UTC()})
+			if err != nil {
+				return nil, err
+			}
+			return result.Consume(ctx)
+		})
+		if err != nil {
+			return embedded, fmt.Errorf("failed to store embeddings: %w", err)
+		}
+		embedded += len(acts)
+	}
+}
+
+// Similar returns the acts most similar to the act with id
+func (i *Index) Similar(ctx context.Context, id string, limit int) ([]Match, error) {
+	return i.query(ctx, queries.EmbeddingSimilar, map[string]interface{}{"id": id, "k": limit + 1, "limit": limit})
+}
+
+// Search returns the acts most similar to text
+func (i *Index) Search(ctx context.Context, text string, limit int) ([]Match, error) {
+	vectors, err := i.provider.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return i.query(ctx, queries.EmbeddingSearch, map[string]interface{}{"vector": vectors[0], "limit": limit})
+}
+
+func (i *Index) query(ctx context.Context, query *queries.Query, params map[string]interface{}) ([]Match, error) {
+	params["index"] = IndexName
+	params["model"] = i.provider.Model()
+	return database.ExecuteRead(ctx, i.db, func(tx neo4j.ManagedTransaction) ([]Match, error) {
+		result, err := query.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (Match, error) {
+			id, err := database.RecordValue[string](record, "id")
+			if err != nil {
+				return Match{}, err
+			}
+			score, err := database.RecordValue[float64](record, "score")
+			return Match{ID: id, Score: score}, err
+		})
+	})
+}
@@ -0,0 +1,117 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/version"
+)
+
+// Defaults for the openai provider
+const (
+	DefaultOpenAIURL   = "https://api.openai.com/v1/embeddings"
+	DefaultOpenAIModel = "text-embedding-3-small"
+	defaultOpenAIDims  = 1536
+)
+
+// OpenAI embeds texts with the OpenAI embeddings API, or any service that
+// implements it
+type OpenAI struct {
+	// URL defaults to DefaultOpenAIURL
+	URL    string
+	APIKey string
+	// ModelName defaults to DefaultOpenAIModel
+	ModelName string
+	// Dims defaults to 1536. Models that support it return shorter vectors
+	// when asked for fewer dimensions.
+	Dims int
+	// Client defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+// Embed sends texts in one request and returns their vectors in order
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(map[string]any{
+		"model":      o.modelName(),
+		"input":      texts,
+		"dimensions": o.Dimensions(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := o.URL
+	if url == "" {
+		url = DefaultOpenAIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+o.APIKey)
+
+	client := o.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedding request failed: status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid embedding response: %w", err)
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) || len(d.Embedding) != o.Dimensions() {
+			return nil, fmt.Errorf("invalid embedding response: unexpected vector for input %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("invalid embedding response: no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// Dimensions returns the length of the vectors
+func (o *OpenAI) Dimensions() int {
+	if o.Dims > 0 {
+		return o.Dims
+	}
+	return defaultOpenAIDims
+}
+
+// Model returns the model name, prefixed with the provider
+func (o *OpenAI) Model() string {
+	return "openai/" + o.modelName()
+}
+
+func (o *OpenAI) modelName() string {
+	if o.ModelName != "" {
+		return o.ModelName
+	}
+	return DefaultOpenAIModel
+}
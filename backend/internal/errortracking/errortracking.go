@@ -0,0 +1,35 @@
+package errortracking
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Event describes a server error to report: either a recovered panic or a
+// 5xx response
+type Event struct {
+	// Panic is the recovered value; nil for error responses
+	Panic     interface{}
+	Status    int
+	Request   *http.Request
+	RequestID string
+	Route     string
+	UserID    string
+}
+
+// Reporter sends server errors to an error tracking service
+type Reporter interface {
+	Report(ctx context.Context, event Event)
+	// Flush waits up to timeout for queued events to be sent
+	Flush(timeout time.Duration) bool
+}
+
+// Nop discards all events. It is used when no error tracker is configured.
+type Nop struct{}
+
+// Report does nothing
+func (Nop) Report(context.Context, Event) {}
+
+// Flush does nothing
+func (Nop) Flush(time.Duration) bool { return true }
@@ -0,0 +1,73 @@
+package errortracking
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Sentry reports events to Sentry
+type Sentry struct {
+	hub *sentry.Hub
+}
+
+// Ensure Sentry implements Reporter
+var _ Reporter = (*Sentry)(nil)
+
+// NewSentry creates a Sentry reporter for the project identified by dsn
+func NewSentry(dsn, environment string) (*Sentry, error) {
+	return newSentry(sentry.ClientOptions{
+		Dsn:              dsn,
+		Environment:      environment,
+		AttachStacktrace: true,
+	})
+}
+
+func newSentry(options sentry.ClientOptions) (*Sentry, error) {
+	client, err := sentry.NewClient(options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Sentry client: %w", err)
+	}
+	return &Sentry{hub: sentry.NewHub(client, sentry.NewScope())}, nil
+}
+
+// Report sends event to Sentry, tagged with the request ID, route and
+// status and attributed to the user. Panics are reported with the stack
+// trace of the panicking goroutine, so Report must be called from the
+// deferred function that recovered them.
+func (s *Sentry) Report(ctx context.Context, event Event) {
+	hub := s.hub.Clone()
+	hub.WithScope(func(scope *sentry.Scope) {
+		if event.Request != nil {
+			scope.SetRequest(event.Request)
+		}
+		if event.UserID != "" {
+			scope.SetUser(sentry.User{ID: event.UserID})
+		}
+		scope.SetTags(map[string]string{
+			"request_id": event.RequestID,
+			"route":      event.Route,
+			"status":     strconv.Itoa(event.Status),
+		})
+
+		if event.Panic != nil {
+			hub.RecoverWithContext(ctx, event.Panic)
+			return
+		}
+
+		scope.SetLevel(sentry.LevelError)
+		route := event.Route
+		if route == "" && event.Request != nil {
+			route = event.Request.Method + " " + event.Request.URL.Path
+		}
+		hub.CaptureMessage(fmt.Sprintf("%s returned %d", route, event.Status))
+	})
+}
+
+// Flush waits up to timeout for queued events to be sent
+func (s *Sentry) Flush(timeout time.Duration) bool {
+	return s.hub.Flush(timeout)
+}
@@ -0,0 +1,65 @@
+package errortracking
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// captureTransport keeps sent events in memory
+type captureTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *captureTransport) Flush(time.Duration) bool              { return true }
+func (t *captureTransport) FlushWithContext(context.Context) bool { return true }
+func (t *captureTransport) Configure(sentry.ClientOptions)        {}
+func (t *captureTransport) Close()                                {}
+func (t *captureTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func TestSentry_Report(t *testing.T) {
+	transport := &captureTransport{}
+	reporter, err := newSentry(sentry.ClientOptions{
+		Dsn:       "https://public@example.com/1",
+		Transport: transport,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/acts/1", nil)
+	reporter.Report(context.Background(), Event{
+		Status:    http.StatusInternalServerError,
+		Request:   req,
+		RequestID: "req-1",
+		Route:     "GET /api/v1/acts/{id}",
+		UserID:    "user-1",
+	})
+	reporter.Report(context.Background(), Event{Panic: "boom", Status: http.StatusInternalServerError, Request: req})
+
+	if len(transport.events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.events))
+	}
+
+	event := transport.events[0]
+	if event.Message != "GET /api/v1/acts/{id} returned 500" {
+		t.Errorf("unexpected message %q", event.Message)
+	}
+	if event.Tags["request_id"] != "req-1" || event.Tags["route"] != "GET /api/v1/acts/{id}" || event.User.ID != "user-1" {
+		t.Errorf("expected request context to be attached, got tags %v user %+v", event.Tags, event.User)
+	}
+
+	if panicEvent := transport.events[1]; panicEvent.Level != sentry.LevelFatal || panicEvent.Message != "boom" {
+		t.Errorf("unexpected panic event level %q message %q", panicEvent.Level, panicEvent.Message)
+	}
+}
@@ -0,0 +1,126 @@
+// Package features holds the server's feature flags: named switches set in
+// the configuration that handlers and clients check, and that can be
+// changed while the server runs.
+package features
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// namePattern matches valid flag names, such as "similar-acts"
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Parse reads flags from a comma-separated list such as
+// "similar-acts,impact-reports=false". A bare name turns the flag on.
+func Parse(value string) (map[string]bool, error) {
+	flags := make(map[string]bool)
+	var errs []error
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, setting, hasSetting := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !namePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid feature flag name %q", name))
+			continue
+		}
+		enabled := true
+		if hasSetting {
+			var err error
+			if enabled, err = strconv.ParseBool(strings.TrimSpace(setting)); err != nil {
+				errs = append(errs, fmt.Errorf("invalid setting for feature flag %s: %q", name, setting))
+				continue
+			}
+		}
+		flags[name] = enabled
+	}
+	return flags, errors.Join(errs...)
+}
+
+// Flags is a set of feature flags. It is safe for concurrent use.
+type Flags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+	// overrides are set by admins at runtime and win over flags until
+	// cleared, surviving configuration reloads
+	overrides map[string]bool
+}
+
+// New creates a set holding flags
+func New(flags map[string]bool) *Flags {
+	return &Flags{flags: maps.Clone(flags)}
+}
+
+// Enabled reports whether the flag name is on. Unknown flags are off.
+func (f *Flags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if enabled, ok := f.overrides[name]; ok {
+		return enabled
+	}
+	return f.flags[name]
+}
+
+// All returns a copy of every flag
+func (f *Flags) All() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	all := maps.Clone(f.flags)
+	if all == nil {
+		all = map[string]bool{}
+	}
+	maps.Copy(all, f.overrides)
+	return all
+}
+
+// Overrides returns a copy of the flags overridden with Override
+func (f *Flags) Overrides() map[string]bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	overrides := maps.Clone(f.overrides)
+	if overrides == nil {
+		overrides = map[string]bool{}
+	}
+	return overrides
+}
+
+// Override turns the flag name on or off whatever the configuration says,
+// until ClearOverride. It fails for an invalid name.
+func (f *Flags) Override(name string, enabled bool) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid feature flag name %q", name)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.overrides == nil {
+		f.overrides = make(map[string]bool)
+	}
+	f.overrides[name] = enabled
+	return nil
+}
+
+// ClearOverride returns the flag name to its configured setting, reporting
+// whether it was overridden
+func (f *Flags) ClearOverride(name string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.overrides[name]
+	delete(f.overrides, name)
+	return ok
+}
+
+// Set replaces every flag with flags, keeping the overrides
+func (f *Flags) Set(flags map[string]bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flags = maps.Clone(flags)
+}
@@ -0,0 +1,66 @@
+package features
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	flags, err := Parse("similar-acts, impact-reports=false ,Beta-UI=1,")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[string]bool{"similar-acts": true, "impact-reports": false, "beta-ui": true}
+	if !maps.Equal(flags, want) {
+		t.Errorf("Parse = %v, want %v", flags, want)
+	}
+
+	if _, err := Parse("ok,bad name,also=maybe"); err == nil {
+		t.Error("expected errors for a bad name and setting")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	f := New(map[string]bool{"a": true, "b": false})
+	if !f.Enabled("a") || f.Enabled("b") || f.Enabled("unknown") {
+		t.Errorf("unexpected flags %v", f.All())
+	}
+
+	f.Set(map[string]bool{"b": true})
+	if f.Enabled("a") || !f.Enabled("b") {
+		t.Errorf("expected Set to replace the flags, got %v", f.All())
+	}
+
+	// All returns a copy
+	f.All()["a"] = true
+	if f.Enabled("a") {
+		t.Error("expected All to return a copy")
+	}
+}
+
+func TestOverride(t *testing.T) {
+	f := New(map[string]bool{"a": true})
+	if err := f.Override("a", false); err != nil {
+		t.Fatal(err)
+	}
+	f.Override("b", true)
+	if f.Enabled("a") || !f.Enabled("b") {
+		t.Errorf("expected the overrides to win, got %v", f.All())
+	}
+
+	// A reload keeps the overrides
+	f.Set(map[string]bool{"a": true, "c": true})
+	if want := map[string]bool{"a": false, "b": true, "c": true}; !maps.Equal(f.All(), want) {
+		t.Errorf("All = %v, want %v", f.All(), want)
+	}
+
+	if !f.ClearOverride("a") || f.ClearOverride("a") {
+		t.Error("expected the override cleared once")
+	}
+	if !f.Enabled("a") {
+		t.Error("expected the configured setting back")
+	}
+	if err := f.Override("Bad Name", true); err == nil {
+		t.Error("expected an invalid name rejected")
+	}
+}
@@ -0,0 +1,72 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+)
+
+// Cached is a Geocoder keeping the answers of another in a cache.Store,
+// including the locations it couldn't place, so users and acts naming the
+// same place don't each cost a request. Cache failures are logged and the
+// geocoder is asked instead.
+type Cached struct {
+	geocoder Geocoder
+	store    cache.Store
+	ttl      time.Duration
+}
+
+// cachedPlace is a cached answer; a nil Place means the location wasn't
+// found
+type cachedPlace struct {
+	Place *models.Place `json:"place"`
+}
+
+// NewCached returns a Geocoder asking g for locations not cached in store,
+// caching its answers for ttl
+func NewCached(g Geocoder, store cache.Store, ttl time.Duration) *Cached {
+	return &Cached{geocoder: g, store: store, ttl: ttl}
+}
+
+// Geocode returns the cached place for location, asking the geocoder if
+// there is none
+func (c *Cached) Geocode(ctx context.Context, location string) (*models.Place, error) {
+	key := "geocode:" + c.geocoder.Name() + ":" + normalize(location)
+	cached, ok, err := cache.GetJSON[cachedPlace](ctx, c.store, key)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read the geocoding cache", "error", err)
+	}
+	if ok {
+		metrics.GeocodeLookups.WithLabelValues(c.geocoder.Name(), "cached").Inc()
+		if cached.Place == nil {
+			return nil, ErrNotFound
+		}
+		return cached.Place, nil
+	}
+
+	place, err := c.geocoder.Geocode(ctx, location)
+	result := "ok"
+	switch {
+	case errors.Is(err, ErrNotFound):
+		result = "not_found"
+	case err != nil:
+		metrics.GeocodeLookups.WithLabelValues(c.geocoder.Name(), "error").Inc()
+		return nil, err
+	}
+	metrics.GeocodeLookups.WithLabelValues(c.geocoder.Name(), result).Inc()
+	if err := cache.SetJSON(ctx, c.store, key, cachedPlace{Place: place}, c.ttl); err != nil {
+		slog.WarnContext(ctx, "Failed to cache a geocoded location", "error", err)
+	}
+	if place == nil {
+		return nil, ErrNotFound
+	}
+	return place, nil
+}
+
+// Name returns the name of the cached geocoder
+func (c *Cached) Name() string { return c.geocoder.Name() }
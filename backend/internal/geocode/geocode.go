@@ -0,0 +1,90 @@
+// Package geocode resolves the free-text locations of users and acts, such
+// as "Lisbon, Portugal", into coordinates and a country code, which power
+// the nearby search and the countries reached in the stats. Geocoders are
+// pluggable: OpenStreetMap's Nominatim or the Google Geocoding API, with
+// their answers cached by Cached.
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/version"
+)
+
+// ErrNotFound is returned for a location the geocoder can't place
+var ErrNotFound = errors.New("location not found")
+
+// Geocoder resolves free-text locations
+type Geocoder interface {
+	// Geocode returns the place location names, or ErrNotFound
+	Geocode(ctx context.Context, location string) (*models.Place, error)
+	// Name names the geocoder in logs and metrics
+	Name() string
+}
+
+// Config selects and configures a geocoder
+type Config struct {
+	// Driver is "nominatim", "google" or empty to leave locations
+	// unresolved
+	Driver string
+	// NominatimURL configures the nominatim driver, defaulting to the
+	// public OpenStreetMap server
+	NominatimURL string
+	// GoogleAPIKey configures the google driver
+	GoogleAPIKey string
+}
+
+// NewGeocoder returns the geocoder described by cfg, or nil if geocoding
+// is disabled
+func NewGeocoder(cfg Config) (Geocoder, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case "nominatim":
+		// The public server allows one request per second
+		return &Nominatim{URL: cfg.NominatimURL, Interval: time.Second}, nil
+	case "google":
+		if cfg.GoogleAPIKey == "" {
+			return nil, errors.New("the google geocoder needs an API key")
+		}
+		return &Google{APIKey: cfg.GoogleAPIKey}, nil
+	}
+	return nil, fmt.Errorf("unknown geocoder %q; use nominatim or google", cfg.Driver)
+}
+
+// normalize returns the form of location geocoders are asked for and
+// answers are cached under, ignoring case and extra whitespace
+func normalize(location string) string {
+	return strings.ToLower(strings.Join(strings.Fields(location), " "))
+}
+
+// get fetches url and returns its body, failing on a non-2xx status
+func get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return body, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return body, nil
+}
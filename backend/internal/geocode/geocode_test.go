@@ -0,0 +1,150 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/models"
+)
+
+func TestNominatim(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "jsonv2" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("q") != "lisbon, portugal" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"lat":"38.7077507","lon":"-9.1365919","address":{"city":"Lisboa","country_code":"pt"}}]`)
+	}))
+	defer server.Close()
+
+	n := &Nominatim{URL: server.URL}
+	place, err := n.Geocode(context.Background(), "  Lisbon,   Portugal ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.Latitude != 38.7077507 || place.Longitude != -9.1365919 || place.CountryCode != "PT" {
+		t.Errorf("unexpected place %+v", place)
+	}
+	if _, err := n.Geocode(context.Background(), "Atlantis"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNominatimThrottles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	n := &Nominatim{URL: server.URL, Interval: 50 * time.Millisecond}
+	start := time.Now()
+	for range 3 {
+		n.Geocode(context.Background(), "Atlantis")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected the requests to be spaced out, took %s", elapsed)
+	}
+}
+
+func TestGoogle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("key") != "key":
+			fmt.Fprint(w, `{"status":"REQUEST_DENIED","error_message":"The provided API key is invalid."}`)
+		case r.URL.Query().Get("address") == "nairobi":
+			fmt.Fprint(w, `{"status":"OK","results":[{"address_components":[
+				{"short_name":"Nairobi","types":["locality","political"]},
+				{"short_name":"KE","types":["country","political"]}],
+				"geometry":{"location":{"lat":-1.2921,"lng":36.8219}}}]}`)
+		default:
+			fmt.Fprint(w, `{"status":"ZERO_RESULTS","results":[]}`)
+		}
+	}))
+	defer server.Close()
+
+	g := &Google{APIKey: "key", URL: server.URL}
+	place, err := g.Geocode(context.Background(), "Nairobi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if place.Latitude != -1.2921 || place.Longitude != 36.8219 || place.CountryCode != "KE" {
+		t.Errorf("unexpected place %+v", place)
+	}
+	if _, err := g.Geocode(context.Background(), "Atlantis"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	g.APIKey = "wrong"
+	if _, err := g.Geocode(context.Background(), "Nairobi"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a denied request to fail, got %v", err)
+	}
+}
+
+// counter is a Geocoder placing only "here", counting its lookups
+type counter struct {
+	lookups int
+	fail    bool
+}
+
+func (c *counter) Geocode(_ context.Context, location string) (*models.Place, error) {
+	c.lookups++
+	if c.fail {
+		return nil, errors.New("unavailable")
+	}
+	if location != "here" {
+		return nil, ErrNotFound
+	}
+	return &models.Place{Latitude: 1, Longitude: 2, CountryCode: "IT"}, nil
+}
+
+func (c *counter) Name() string { return "counter" }
+
+func TestCached(t *testing.T) {
+	g := &counter{}
+	c := NewCached(g, cache.NewLRU(10), time.Hour)
+	ctx := context.Background()
+
+	for range 2 {
+		if place, err := c.Geocode(ctx, "here"); err != nil || place.CountryCode != "IT" {
+			t.Errorf("unexpected place %+v and %v", place, err)
+		}
+		if _, err := c.Geocode(ctx, "there"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if g.lookups != 2 {
+		t.Errorf("expected places and misses to be cached, got %d lookups", g.lookups)
+	}
+
+	g.fail = true
+	for range 2 {
+		if _, err := c.Geocode(ctx, "elsewhere"); err == nil {
+			t.Error("expected the failure to be returned")
+		}
+	}
+	if g.lookups != 4 {
+		t.Errorf("expected failures not to be cached, got %d lookups", g.lookups)
+	}
+}
+
+func TestNewGeocoder(t *testing.T) {
+	for _, cfg := range []Config{
+		{Driver: "google"},
+		{Driver: "mapbox"},
+	} {
+		if _, err := NewGeocoder(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	if g, err := NewGeocoder(Config{}); g != nil || err != nil {
+		t.Errorf("expected no geocoder without a driver, got %v and %v", g, err)
+	}
+}
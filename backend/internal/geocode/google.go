@@ -0,0 +1,82 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"payforwardnow/internal/models"
+)
+
+// DefaultGoogleURL is the base URL of Google's Geocoding API
+const DefaultGoogleURL = "https://maps.googleapis.com/maps/api/geocode/json"
+
+// Google resolves locations with the Google Geocoding API
+type Google struct {
+	APIKey string
+	// URL defaults to DefaultGoogleURL
+	URL string
+	// Client defaults to a client with a 10 second timeout
+	Client *http.Client
+}
+
+type googleResponse struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	Results      []struct {
+		Components []struct {
+			ShortName string   `json:"short_name"`
+			Types     []string `json:"types"`
+		} `json:"address_components"`
+		Geometry struct {
+			Location struct {
+				Lat float64 `json:"lat"`
+				Lng float64 `json:"lng"`
+			} `json:"location"`
+		} `json:"geometry"`
+	} `json:"results"`
+}
+
+// Geocode returns the best match for location
+func (g *Google) Geocode(ctx context.Context, location string) (*models.Place, error) {
+	base := g.URL
+	if base == "" {
+		base = DefaultGoogleURL
+	}
+	query := url.Values{"address": {normalize(location)}, "key": {g.APIKey}}
+	body, err := get(ctx, g.Client, base+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("google geocoding request failed: %w", err)
+	}
+
+	var resp googleResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid google geocoding response: %w", err)
+	}
+	switch resp.Status {
+	case "OK":
+	case "ZERO_RESULTS":
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("google geocoding request failed: %s %s", resp.Status, resp.ErrorMessage)
+	}
+	if len(resp.Results) == 0 {
+		return nil, ErrNotFound
+	}
+
+	result := resp.Results[0]
+	place := &models.Place{Latitude: result.Geometry.Location.Lat, Longitude: result.Geometry.Location.Lng}
+	for _, c := range result.Components {
+		if slices.Contains(c.Types, "country") {
+			place.CountryCode = strings.ToUpper(c.ShortName)
+		}
+	}
+	return place, nil
+}
+
+// Name returns "google"
+func (g *Google) Name() string { return "google" }
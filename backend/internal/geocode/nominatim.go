@@ -0,0 +1,102 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+// DefaultNominatimURL is OpenStreetMap's public Nominatim server
+const DefaultNominatimURL = "https://nominatim.openstreetmap.org"
+
+// Nominatim resolves locations with a Nominatim server. The public one
+// needs no account but asks for at most one request per second and a
+// User-Agent naming the application, which requests are sent with.
+type Nominatim struct {
+	// URL defaults to DefaultNominatimURL
+	URL string
+	// Interval is the least time between two requests; zero doesn't
+	// throttle them, for a self-hosted server
+	Interval time.Duration
+	// Client defaults to a client with a 10 second timeout
+	Client *http.Client
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+type nominatimResult struct {
+	Lat     string `json:"lat"`
+	Lon     string `json:"lon"`
+	Address struct {
+		CountryCode string `json:"country_code"`
+	} `json:"address"`
+}
+
+// Geocode returns the best match for location
+func (n *Nominatim) Geocode(ctx context.Context, location string) (*models.Place, error) {
+	if err := n.wait(ctx); err != nil {
+		return nil, err
+	}
+	base := n.URL
+	if base == "" {
+		base = DefaultNominatimURL
+	}
+	query := url.Values{
+		"q":              {normalize(location)},
+		"format":         {"jsonv2"},
+		"addressdetails": {"1"},
+		"limit":          {"1"},
+	}
+	body, err := get(ctx, n.Client, strings.TrimSuffix(base, "/")+"/search?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("nominatim request failed: %w", err)
+	}
+
+	var results []nominatimResult
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("invalid nominatim response: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	lat, latErr := strconv.ParseFloat(results[0].Lat, 64)
+	lon, lonErr := strconv.ParseFloat(results[0].Lon, 64)
+	if latErr != nil || lonErr != nil {
+		return nil, fmt.Errorf("invalid nominatim coordinates %q, %q", results[0].Lat, results[0].Lon)
+	}
+	return &models.Place{
+		Latitude:    lat,
+		Longitude:   lon,
+		CountryCode: strings.ToUpper(results[0].Address.CountryCode),
+	}, nil
+}
+
+// wait blocks until Interval has passed since the previous request
+func (n *Nominatim) wait(ctx context.Context) error {
+	if n.Interval <= 0 {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if delay := time.Until(n.last.Add(n.Interval)); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	n.last = time.Now()
+	return nil
+}
+
+// Name returns "nominatim"
+func (n *Nominatim) Name() string { return "nominatim" }
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/stream"
+	"payforwardnow/internal/webhooks"
+)
+
+const (
+	// activityReplayBatch is how many missed acts are read per query when
+	// a client resumes the activity stream
+	activityReplayBatch = 100
+	// activityReplayLimit bounds the missed acts replayed to a resuming
+	// client; older ones are skipped
+	activityReplayLimit = 1000
+)
+
+// activityCursor is a position in the activity stream: the completion time
+// and ID of the last act sent, which is the event ID clients resume from
+type activityCursor struct {
+	at time.Time
+	id string
+}
+
+func cursorOf(act *models.Act) activityCursor {
+	return activityCursor{at: *act.CompletedAt, id: act.ID}
+}
+
+// String encodes the cursor as an event ID
+func (c activityCursor) String() string {
+	return strconv.FormatInt(c.at.UnixNano(), 10) + ":" + c.id
+}
+
+// after reports whether c comes after other in the stream
+func (c activityCursor) after(other activityCursor) bool {
+	return c.at.After(other.at) || (c.at.Equal(other.at) && c.id > other.id)
+}
+
+// parseActivityCursor decodes an event ID
+func parseActivityCursor(s string) (activityCursor, bool) {
+	nanos, id, ok := strings.Cut(s, ":")
+	if !ok || id == "" {
+		return activityCursor{}, false
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return activityCursor{}, false
+	}
+	return activityCursor{at: time.Unix(0, n).UTC(), id: id}, true
+}
+
+// publishActCompleted publishes act id on the activity stream, and emits the
+// act.completed webhook event, if the update that produced version is the
+// one that completed it
+func (h *Handler) publishActCompleted(ctx context.Context, id string, version int64) {
+	act, err := h.acts.Get(ctx, id)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read completed act", "act_id", id, "error", err)
+		return
+	}
+	if act.Version != version || act.CompletedAt == nil || !act.CompletedAt.Equal(act.UpdatedAt) {
+		return
+	}
+	h.events.Publish(stream.Event{Type: EventActCompleted, Data: act})
+	h.emitWebhook(ctx, webhooks.EventActCompleted, completedAct(act))
+}
+
+// completedAct returns the public view of a completed act
+func completedAct(act *models.Act) models.CompletedAct {
+	return models.CompletedAct{
+		ID:          act.ID,
+		Title:       act.Title,
+		Type:        act.Type,
+		Category:    act.Category,
+		Value:       act.Value,
+		Currency:    act.Currency,
+		GiverID:     act.GiverID,
+		ReceiverID:  act.ReceiverID,
+		ChainID:     act.ChainID,
+		Location:    act.Location,
+		CompletedAt: *act.CompletedAt,
+	}
+}
+
+// StreamActivity handles GET /api/v1/events (Server-Sent Events), a public
+// stream of acts as they are completed, without the giver of anonymous ones.
+// Each event's ID is its position in the stream: a client reconnecting with
+// it as Last-Event-ID, or ?lastEventId= where the header can't be set, first
+// gets the acts completed since, up to a thousand.
+func (h *Handler) StreamActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	var cursor activityCursor
+	if lastID != "" {
+		var ok bool
+		if cursor, ok = parseActivityCursor(lastID); !ok {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	// Subscribing before reading the missed acts means none completed in
+	// between is lost; those read twice are skipped by their cursor
+	events := h.events.Subscribe()
+	defer h.events.Unsubscribe(events)
+
+	var missed []models.Act
+	for lastID != "" && len(missed) < activityReplayLimit {
+		after := cursor
+		if len(missed) > 0 {
+			after = cursorOf(&missed[len(missed)-1])
+		}
+		batch, err := h.acts.Completed(ctx, after.at, after.id, min(activityReplayBatch, activityReplayLimit-len(missed)))
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to fetch completed acts")
+			return
+		}
+		missed = append(missed, batch...)
+		if len(batch) < activityReplayBatch {
+			break
+		}
+	}
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		return
+	}
+	send := func(act *models.Act) error {
+		cursor = cursorOf(act)
+		return sse.Send(cursor.String(), EventActCompleted, completedAct(act))
+	}
+	for i := range missed {
+		if err := send(&missed[i]); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if err := sse.Heartbeat(); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			act, isAct := event.Data.(*models.Act)
+			if event.Type != EventActCompleted || !isAct || !cursorOf(act).after(cursor) {
+				continue
+			}
+			if err := send(act); err != nil {
+				return
+			}
+		}
+	}
+}
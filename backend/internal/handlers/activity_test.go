@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestStreamActivity(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver"}, "")
+	now := time.Now().UTC()
+	for _, act := range []models.Act{
+		{ID: "a1", Title: "Shared a meal", GiverID: "giver", IsAnonymous: true, CreatedAt: now},
+		{ID: "a2", Title: "Mowed a lawn", GiverID: "giver", CreatedAt: now},
+		{ID: "a3", Title: "Walked a dog", GiverID: "giver", CreatedAt: now},
+	} {
+		db.AddAct(act)
+	}
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	server := httptest.NewServer(http.HandlerFunc(handler.StreamActivity))
+	defer server.Close()
+
+	complete := func(id string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/acts/"+id, strings.NewReader(`{"status":"completed"}`))
+		req.SetPathValue("id", id)
+		req = asUser(req, "giver")
+		w := httptest.NewRecorder()
+		handler.UpdateAct(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+	connect := func(lastEventID string) (*http.Response, func() (string, models.CompletedAct)) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to connect: %v", err)
+		}
+		reader := bufio.NewReader(resp.Body)
+		return resp, func() (string, models.CompletedAct) {
+			t.Helper()
+			var id string
+			var act models.CompletedAct
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					t.Fatalf("failed to read stream: %v", err)
+				}
+				if value, ok := strings.CutPrefix(line, "id: "); ok {
+					id = strings.TrimSpace(value)
+				}
+				if value, ok := strings.CutPrefix(line, "data: "); ok {
+					if err := json.Unmarshal([]byte(value), &act); err != nil {
+						t.Fatalf("failed to decode event: %v", err)
+					}
+					return id, act
+				}
+			}
+		}
+	}
+
+	resp, next := connect("")
+	for handler.events.SubscriberCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	complete("a1")
+	lastID, act := next()
+	if act.ID != "a1" || act.GiverID != "" || act.CompletedAt.IsZero() {
+		t.Errorf("expected the anonymous act without its giver, got %+v", act)
+	}
+	resp.Body.Close()
+	for handler.events.SubscriberCount() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Acts completed while disconnected are replayed in order, and
+	// updating a completed act doesn't complete it again
+	complete("a2")
+	complete("a3")
+	complete("a1")
+	resp, next = connect(lastID)
+	defer resp.Body.Close()
+	for _, expected := range []string{"a2", "a3"} {
+		if _, act := next(); act.ID != expected || act.GiverID != "giver" {
+			t.Errorf("expected %s to be replayed, got %+v", expected, act)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+	req.Header.Set("Last-Event-ID", "not-an-id")
+	handler.StreamActivity(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an invalid Last-Event-ID, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// GetUsers handles GET /api/v1/admin/users, newest first, optionally
+// matching ?q= against names and emails and filtered by ?suspended=
+func (h *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	filter := repository.UserFilter{Query: r.URL.Query().Get("q")}
+	if len(filter.Query) > 200 {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "q must be at most 200 characters")
+		return
+	}
+	if v := r.URL.Query().Get("suspended"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "suspended must be true or false")
+			return
+		}
+		filter.Suspended = &parsed
+	}
+
+	params := getPaginationParams(r)
+	users, total, err := h.users.List(r.Context(), filter, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch users")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    users,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// SuspendUser handles PUT /api/v1/admin/users/{id}/suspension, suspending a
+// user, who can no longer sign in, or reinstating them
+func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	var req models.SuspendUserRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	if *req.Suspended && req.Reason == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "reason is required when suspending")
+		return
+	}
+
+	user, err := h.users.Suspend(r.Context(), r.PathValue("id"), *req.Suspended, req.Reason, currentUserID(r), time.Now())
+	if errors.Is(err, repository.ErrUserNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to update suspension")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: user})
+}
+
+// TakeDownAct handles POST /api/v1/admin/acts/{id}/takedown, deleting an
+// act that breaks the rules with the moderator's reason. It can be
+// restored like any deleted act.
+func (h *Handler) TakeDownAct(w http.ResponseWriter, r *http.Request) {
+	var req models.TakeDownActRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	err := h.acts.TakeDown(r.Context(), r.PathValue("id"), req.Reason, currentUserID(r), time.Now())
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to take down act")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Act taken down"},
+	})
+}
+
+// GetFeatureFlags handles GET /api/v1/admin/features, the effective flags
+// and the ones overridden on this instance
+func (h *Handler) GetFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	if h.features == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Feature flags are not configured")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"flags":     h.features.All(),
+			"overrides": h.features.Overrides(),
+		},
+	})
+}
+
+// SetFeatureFlag handles PUT /api/v1/admin/features/{name}, turning a flag
+// on or off on this instance whatever the configuration says, until the
+// override is cleared or the server restarts
+func (h *Handler) SetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	if h.features == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Feature flags are not configured")
+		return
+	}
+	var req models.SetFeatureRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	if err := h.features.Override(r.PathValue("name"), *req.Enabled); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: h.features.All()})
+}
+
+// ClearFeatureFlag handles DELETE /api/v1/admin/features/{name}, returning
+// an overridden flag to its configured setting
+func (h *Handler) ClearFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	if h.features == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Feature flags are not configured")
+		return
+	}
+	if !h.features.ClearOverride(r.PathValue("name")) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Feature flag is not overridden")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: h.features.All()})
+}
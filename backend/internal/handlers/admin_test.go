@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/features"
+	"payforwardnow/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserSuspension(t *testing.T) {
+	db := databasetest.New()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.MinCost)
+	db.AddUser(models.User{ID: "u1", Email: "jane@example.org", Name: "Jane"}, string(hash))
+	db.AddUser(models.User{ID: "u2", Email: "sam@example.org", Name: "Sam"}, "")
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	suspend := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/users/"+id+"/suspension", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		req = asUser(req, "admin")
+		w := httptest.NewRecorder()
+		handler.SuspendUser(w, req)
+		return w
+	}
+	login := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"jane@example.org","password":"secret-password"}`))
+		w := httptest.NewRecorder()
+		handler.Login(w, req)
+		return w.Code
+	}
+	list := func(query string) []models.User {
+		w := httptest.NewRecorder()
+		handler.GetUsers(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/users"+query, nil))
+		var resp struct{ Data []models.User }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+
+	if w := suspend("u1", `{"suspended": true}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d without a reason, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := suspend("missing", `{"suspended": true, "reason": "spam"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing user, got %d", http.StatusNotFound, w.Code)
+	}
+	w := suspend("u1", `{"suspended": true, "reason": "Repeated spam"}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "suspendedAt") {
+		t.Fatalf("expected the user suspended, got %d: %s", w.Code, w.Body.String())
+	}
+	if code := login(); code != http.StatusForbidden {
+		t.Errorf("expected a suspended user refused with %d, got %d", http.StatusForbidden, code)
+	}
+	if users := list("?suspended=true"); len(users) != 1 || users[0].ID != "u1" {
+		t.Errorf("expected only u1 suspended, got %+v", users)
+	}
+	if users := list("?q=SAM"); len(users) != 1 || users[0].ID != "u2" {
+		t.Errorf("expected Sam found by name, got %+v", users)
+	}
+
+	if w := suspend("u1", `{"suspended": false}`); w.Code != http.StatusOK {
+		t.Fatalf("expected the user reinstated, got %d", w.Code)
+	}
+	if code := login(); code != http.StatusOK {
+		t.Errorf("expected a reinstated user to log in, got %d", code)
+	}
+
+	events, _ := db.Outbox().Pending(t.Context(), 10)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if got := strings.Join(types, ","); got != "user.suspended,user.reinstated" {
+		t.Errorf("expected suspension events, got %s", got)
+	}
+}
+
+func TestTakeDownAct(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1"}, "")
+	db.AddAct(models.Act{ID: "a1", Title: "Act", GiverID: "u1"})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	takeDown := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/acts/a1/takedown", strings.NewReader(body))
+		req.SetPathValue("id", "a1")
+		w := httptest.NewRecorder()
+		handler.TakeDownAct(w, req)
+		return w.Code
+	}
+	if code := takeDown(`{}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d without a reason, got %d", http.StatusUnprocessableEntity, code)
+	}
+	if code := takeDown(`{"reason": "Scam"}`); code != http.StatusOK {
+		t.Fatalf("expected the act taken down, got %d", code)
+	}
+	if code := takeDown(`{"reason": "Scam"}`); code != http.StatusNotFound {
+		t.Errorf("expected %d once taken down, got %d", http.StatusNotFound, code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/acts/a1", nil)
+	req.SetPathValue("id", "a1")
+	w := httptest.NewRecorder()
+	handler.GetAct(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected a taken down act hidden, got %d", w.Code)
+	}
+}
+
+func TestContentReports(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1"}, "")
+	db.AddAct(models.Act{ID: "a1", Title: "Act", GiverID: "u1"})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	report := func(userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/reports", strings.NewReader(body))
+		if userID != "" {
+			req = asUser(req, userID)
+		}
+		w := httptest.NewRecorder()
+		handler.CreateContentReport(w, req)
+		return w
+	}
+	if w := report("", `{"kind": "act", "targetId": "a1", "reason": "spam"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d signed out, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := report("u1", `{"kind": "chain", "targetId": "a1", "reason": "spam"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d for an unknown kind, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	if w := report("u1", `{"kind": "act", "targetId": "missing", "reason": "spam"}`); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for missing content, got %d", http.StatusNotFound, w.Code)
+	}
+	w := report("u1", `{"kind": "act", "targetId": "a1", "reason": "spam", "details": "Links to a casino"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected the report created, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct{ Data models.ContentReport }
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	list := func(status string) []models.ContentReport {
+		w := httptest.NewRecorder()
+		handler.GetContentReports(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/reports?status="+status, nil))
+		var resp struct{ Data []models.ContentReport }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	if reports := list("open"); len(reports) != 1 || reports[0].ID != created.Data.ID {
+		t.Fatalf("expected the report open, got %+v", reports)
+	}
+
+	handle := func(body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/reports/"+created.Data.ID, strings.NewReader(body))
+		req.SetPathValue("id", created.Data.ID)
+		req = asUser(req, "admin")
+		w := httptest.NewRecorder()
+		handler.HandleContentReport(w, req)
+		return w.Code
+	}
+	if code := handle(`{"status": "open"}`); code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d reopening, got %d", http.StatusUnprocessableEntity, code)
+	}
+	if code := handle(`{"status": "resolved", "note": "Taken down"}`); code != http.StatusOK {
+		t.Fatalf("expected the report resolved, got %d", code)
+	}
+	if code := handle(`{"status": "dismissed"}`); code != http.StatusNotFound {
+		t.Errorf("expected %d once handled, got %d", http.StatusNotFound, code)
+	}
+	if reports := list("resolved"); len(reports) != 1 || reports[0].HandlerID != "admin" || reports[0].HandledAt == nil {
+		t.Errorf("expected the report resolved by admin, got %+v", reports)
+	}
+	if reports := list("open"); len(reports) != 0 {
+		t.Errorf("expected no open reports, got %+v", reports)
+	}
+}
+
+func TestFeatureFlagOverrides(t *testing.T) {
+	handler := NewHandlerWithRepositories(&MockDBClient{}, databasetest.New().Repositories())
+	flags := features.New(map[string]bool{"beta-ui": false})
+	handler.SetFeatures(flags)
+
+	request := func(method, name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/admin/features/"+name, strings.NewReader(body))
+		req.SetPathValue("name", name)
+		w := httptest.NewRecorder()
+		if method == http.MethodPut {
+			handler.SetFeatureFlag(w, req)
+		} else {
+			handler.ClearFeatureFlag(w, req)
+		}
+		return w
+	}
+
+	if w := request(http.MethodPut, "Bad_Name", `{"enabled": true}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an invalid name, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := request(http.MethodPut, "beta-ui", `{"enabled": true}`); w.Code != http.StatusOK || !flags.Enabled("beta-ui") {
+		t.Fatalf("expected beta-ui turned on, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	handler.GetFeatureFlags(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/features", nil))
+	if !strings.Contains(w.Body.String(), `"overrides":{"beta-ui":true}`) {
+		t.Errorf("expected the override listed, got %s", w.Body.String())
+	}
+
+	if w := request(http.MethodDelete, "beta-ui", ""); w.Code != http.StatusOK || flags.Enabled("beta-ui") {
+		t.Errorf("expected the configured setting back, got %d", w.Code)
+	}
+	if w := request(http.MethodDelete, "beta-ui", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d without an override, got %d", http.StatusNotFound, w.Code)
+	}
+}
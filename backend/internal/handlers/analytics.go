@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payforwardnow/internal/analytics"
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	// DefaultRetentionWeeks is the cohort window precomputed by the retention job
+	DefaultRetentionWeeks = 12
+	maxRetentionWeeks     = 52
+	retentionTTL          = 2 * time.Hour
+)
+
+// GetRetention handles GET /api/v1/admin/stats/retention
+func (h *Handler) GetRetention(w http.ResponseWriter, r *http.Request) {
+	weeks := DefaultRetentionWeeks
+	if v := r.URL.Query().Get("weeks"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > maxRetentionWeeks {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("weeks must be between 1 and %d", maxRetentionWeeks))
+			return
+		}
+		weeks = parsed
+	}
+
+	report, ok := cachedValue[*models.RetentionReport](r.Context(), h.cache, retentionCacheKey(r.Context(), weeks))
+	if !ok {
+		var err error
+		report, err = h.RefreshRetention(r.Context(), weeks)
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to compute retention")
+			return
+		}
+	}
+
+	if wantsCSV(r) {
+		respondRetentionCSV(w, report)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// RefreshRetention recomputes the cohort retention matrix for the given
+// number of weeks and caches it. It is run periodically by the retention job
+// and on demand for uncached windows.
+func (h *Handler) RefreshRetention(ctx context.Context, weeks int) (*models.RetentionReport, error) {
+	now := time.Now().UTC()
+	since := analytics.WeekStart(now).AddDate(0, 0, -7*weeks)
+
+	users, err := database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) ([]analytics.UserActivity, error) {
+		result, err := queries.StatsRetention.Run(ctx, tx, map[string]interface{}{"since": since})
+		if err != nil {
+			return nil, err
+		}
+
+		var users []analytics.UserActivity
+		for result.Next(ctx) {
+			record := result.Record()
+			createdAt, _ := record.Get("createdAt")
+			signedUp, ok := createdAt.(time.Time)
+			if !ok {
+				continue
+			}
+
+			activity := analytics.UserActivity{SignedUpAt: signedUp}
+			if actTimes, ok := record.Get("actTimes"); ok {
+				for _, v := range actTimes.([]interface{}) {
+					if t, ok := v.(time.Time); ok {
+						activity.ActTimes = append(activity.ActTimes, t)
+					}
+				}
+			}
+			users = append(users, activity)
+		}
+
+		return users, result.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.RetentionReport{
+		Weeks:       weeks,
+		Cohorts:     analytics.ComputeRetention(users, weeks, now),
+		GeneratedAt: now,
+	}
+
+	cacheValue(ctx, h.cache, retentionCacheKey(ctx, weeks), report, retentionTTL)
+	return report, nil
+}
+
+func retentionCacheKey(ctx context.Context, weeks int) string {
+	return tenantCacheKey(ctx, fmt.Sprintf("retention:%d", weeks))
+}
+
+func respondRetentionCSV(w http.ResponseWriter, report *models.RetentionReport) {
+	header := []string{"weekStart", "size"}
+	for i := 0; i <= report.Weeks; i++ {
+		header = append(header, fmt.Sprintf("week%d", i))
+	}
+
+	stream, err := newCSVStream(w, "retention.csv", header)
+	if err != nil {
+		return
+	}
+	for _, cohort := range report.Cohorts {
+		row := []string{cohort.WeekStart.Format("2006-01-02"), strconv.Itoa(cohort.Size)}
+		for _, v := range cohort.Retention {
+			row = append(row, strconv.FormatFloat(v, 'f', 4, 64))
+		}
+		if err := stream.Write(row); err != nil {
+			return
+		}
+	}
+	stream.Flush()
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// auditPage is one page of audit events and the number of events matching
+// the filters
+type auditPage struct {
+	events []models.AuditEvent
+	total  int64
+}
+
+// RecordAudit stores an audit event as an AuditEvent node
+func (h *Handler) RecordAudit(ctx context.Context, event models.AuditEvent) error {
+	_, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return queries.AuditCreate.Run(ctx, tx, map[string]interface{}{
+			"id":        event.ID,
+			"actorId":   nilIfEmpty(event.ActorID),
+			"method":    event.Method,
+			"route":     event.Route,
+			"path":      event.Path,
+			"entityId":  nilIfEmpty(event.EntityID),
+			"status":    event.Status,
+			"changes":   event.Changes,
+			"requestId": nilIfEmpty(event.RequestID),
+			"createdAt": event.CreatedAt,
+		})
+	})
+	return err
+}
+
+// GetAuditEvents handles GET /api/v1/admin/audit. It lists audit events,
+// newest first, filtered by userId, entityId and a from/to date range.
+func (h *Handler) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := getPaginationParams(r)
+
+	from, to, err := getDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+		return
+	}
+
+	queryParams := map[string]interface{}{
+		"actorId":  nilIfEmpty(r.URL.Query().Get("userId")),
+		"entityId": nilIfEmpty(r.URL.Query().Get("entityId")),
+		"from":     timeOrNil(from),
+		"to":       timeOrNil(to),
+		"skip":     (params.Page - 1) * params.PerPage,
+		"limit":    params.PerPage,
+	}
+
+	page, err := database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) (auditPage, error) {
+		countResult, err := queries.AuditCount.Run(ctx, tx, queryParams)
+		if err != nil {
+			return auditPage{}, err
+		}
+
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.AuditList.Run(ctx, tx, queryParams)
+		if err != nil {
+			return auditPage{}, err
+		}
+
+		page := auditPage{events: []models.AuditEvent{}, total: total}
+		for result.Next(ctx) {
+			page.events = append(page.events, auditEventFromRecord(result.Record()))
+		}
+		return page, result.Err()
+	})
+
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch audit events")
+		return
+	}
+
+	totalPages := (int(page.total) + params.PerPage - 1) / params.PerPage
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    page.events,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      page.total,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+func auditEventFromRecord(record *neo4j.Record) models.AuditEvent {
+	node, _ := record.Get("e")
+	props := node.(neo4j.Node).Props
+
+	event := models.AuditEvent{
+		ID:      props["id"].(string),
+		Method:  props["method"].(string),
+		Route:   props["route"].(string),
+		Path:    props["path"].(string),
+		Changes: []string{},
+	}
+	if actorID, ok := props["actorId"].(string); ok {
+		event.ActorID = actorID
+	}
+	if entityID, ok := props["entityId"].(string); ok {
+		event.EntityID = entityID
+	}
+	if status, ok := props["status"].(int64); ok {
+		event.Status = int(status)
+	}
+	if changes, ok := props["changes"].([]interface{}); ok {
+		for _, c := range changes {
+			if change, ok := c.(string); ok {
+				event.Changes = append(event.Changes, change)
+			}
+		}
+	}
+	if requestID, ok := props["requestId"].(string); ok {
+		event.RequestID = requestID
+	}
+	if createdAt, ok := props["createdAt"].(time.Time); ok {
+		event.CreatedAt = createdAt
+	}
+	return event
+}
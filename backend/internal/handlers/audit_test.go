@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestAuditEventFromRecord(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	record := &neo4j.Record{
+		Keys: []string{"e"},
+		Values: []interface{}{
+			neo4j.Node{Props: map[string]interface{}{
+				"id":        "evt-1",
+				"actorId":   "user-1",
+				"method":    "PUT",
+				"route":     "PUT /api/v1/acts/{id}",
+				"path":      "/api/v1/acts/act-1",
+				"entityId":  "act-1",
+				"status":    int64(200),
+				"changes":   []interface{}{"status", "title"},
+				"createdAt": createdAt,
+			}},
+		},
+	}
+
+	event := auditEventFromRecord(record)
+
+	if event.ID != "evt-1" || event.ActorID != "user-1" || event.EntityID != "act-1" || event.Status != 200 {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if len(event.Changes) != 2 || event.Changes[1] != "title" {
+		t.Errorf("unexpected changes %v", event.Changes)
+	}
+	if !event.CreatedAt.Equal(createdAt) {
+		t.Errorf("expected createdAt %v, got %v", createdAt, event.CreatedAt)
+	}
+}
+
+func TestGetAuditEvents_InvalidDate(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/audit?from=yesterday", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetAuditEvents(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
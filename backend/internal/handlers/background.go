@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"context"
+
+	"payforwardnow/internal/workers"
+)
+
+// SetWorkers sets the pool background work, such as heavy reports, runs on
+func (h *Handler) SetWorkers(pool *workers.Pool) {
+	h.workers = pool
+}
+
+// runInBackground runs task after the request has returned, on the worker
+// pool when there is one. The task keeps ctx's values, such as the tenant
+// database, but not its cancellation. It fails when the pool's queue is
+// full or the server is stopping.
+func (h *Handler) runInBackground(ctx context.Context, name string, task workers.Task) error {
+	return h.workers.Go(ctx, name, task)
+}
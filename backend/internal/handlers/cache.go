@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/cache"
+)
+
+// statsCacheSize bounds the number of computed stats kept by the default
+// in-memory cache
+const statsCacheSize = 1024
+
+// SetCache replaces the in-memory cache of computed stats, typically with a
+// Redis store shared by every instance
+func (h *Handler) SetCache(store cache.Store) {
+	h.cache = store
+}
+
+// SetRevocations records the access tokens of users who log out, so the
+// authentication middleware rejects them
+func (h *Handler) SetRevocations(revocations *auth.Revocations) {
+	h.revocations = revocations
+}
+
+// cachedValue returns the value cached under key. Cache errors are logged
+// and treated as misses, so the value is computed again.
+func cachedValue[T any](ctx context.Context, store cache.Store, key string) (T, bool) {
+	value, ok, err := cache.GetJSON[T](ctx, store, key)
+	if err != nil {
+		slog.WarnContext(ctx, "cache read failed", "key", key, "error", err)
+	}
+	return value, ok
+}
+
+// cacheValue stores value under key, logging failures
+func cacheValue(ctx context.Context, store cache.Store, key string, value any, ttl time.Duration) {
+	if err := cache.SetJSON(ctx, store, key, value, ttl); err != nil {
+		slog.WarnContext(ctx, "cache write failed", "key", key, "error", err)
+	}
+}
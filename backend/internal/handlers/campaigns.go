@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// campaignStatuses are the values GetCampaigns accepts for ?status=
+var campaignStatuses = []models.CampaignStatus{models.CampaignUpcoming, models.CampaignActive, models.CampaignEnded}
+
+// CreateCampaign handles POST /api/v1/admin/campaigns
+func (h *Handler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCampaignRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	campaign := &models.Campaign{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		StartsAt:    req.StartsAt.UTC(),
+		EndsAt:      req.EndsAt.UTC(),
+		GoalActs:    req.GoalActs,
+		GoalValue:   req.GoalValue,
+		CreatedBy:   currentUserID(r),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := h.campaigns.Create(r.Context(), campaign); err != nil {
+		respondDatabaseError(w, err, "Failed to create campaign")
+		return
+	}
+
+	presentCampaign(campaign, time.Now())
+	respondJSON(w, http.StatusCreated, models.APIResponse{Success: true, Data: campaign})
+}
+
+// GetCampaigns handles GET /api/v1/campaigns, latest starting first, of one
+// ?status= or all of them, with their progress
+func (h *Handler) GetCampaigns(w http.ResponseWriter, r *http.Request) {
+	status := models.CampaignStatus(r.URL.Query().Get("status"))
+	if status != "" && !slices.Contains(campaignStatuses, status) {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "status must be one of upcoming, active or ended")
+		return
+	}
+
+	now := time.Now()
+	params := getPaginationParams(r)
+	campaigns, total, err := h.campaigns.List(r.Context(), status, now, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch campaigns")
+		return
+	}
+	for i := range campaigns {
+		presentCampaign(&campaigns[i], now)
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    campaigns,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// GetCampaign handles GET /api/v1/campaigns/{id}, with the campaign's
+// progress towards its goals counted from the acts tagged to it so far
+func (h *Handler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	campaign, ok := h.findCampaign(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: campaign})
+}
+
+// JoinCampaign handles POST /api/v1/campaigns/{id}/join. Users can join
+// until a campaign ends; tagging an act to it joins the giver too.
+func (h *Handler) JoinCampaign(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	campaign, ok := h.findCampaign(w, r)
+	if !ok {
+		return
+	}
+	if campaign.Status == models.CampaignEnded {
+		respondError(w, http.StatusConflict, "CAMPAIGN_ENDED", "The campaign has ended")
+		return
+	}
+
+	err := h.campaigns.Join(r.Context(), campaign.ID, userID, time.Now())
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Campaign not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to join campaign")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Joined campaign"},
+	})
+}
+
+// LeaveCampaign handles DELETE /api/v1/campaigns/{id}/join. The acts the
+// user tagged to the campaign still count towards it.
+func (h *Handler) LeaveCampaign(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	err := h.campaigns.Leave(r.Context(), r.PathValue("id"), userID)
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Campaign not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to leave campaign")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Left campaign"},
+	})
+}
+
+// GetCampaignLeaderboard handles GET /api/v1/campaigns/{id}/leaderboard,
+// the participants who tagged the most acts to the campaign (limit)
+func (h *Handler) GetCampaignLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > leaderboardSize {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("limit must be between 1 and %d", leaderboardSize))
+			return
+		}
+		limit = parsed
+	}
+	campaign, ok := h.findCampaign(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.campaigns.Leaderboard(r.Context(), campaign.ID, limit)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch campaign leaderboard")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: entries})
+}
+
+// findCampaign fetches the campaign named by the {id} path value, or
+// responds with an error and returns false
+func (h *Handler) findCampaign(w http.ResponseWriter, r *http.Request) (*models.Campaign, bool) {
+	campaign, err := h.campaigns.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Campaign not found")
+		return nil, false
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch campaign")
+		return nil, false
+	}
+	presentCampaign(campaign, time.Now())
+	return campaign, true
+}
+
+// checkCampaignRunning checks that an act can be tagged to a campaign: the
+// giver is signed in, so they can join it, and it is active. Otherwise it
+// responds with an error and returns false.
+func (h *Handler) checkCampaignRunning(w http.ResponseWriter, r *http.Request, id string, signedIn bool) bool {
+	if !signedIn {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return false
+	}
+	campaign, err := h.campaigns.Get(r.Context(), id)
+	if errors.Is(err, repository.ErrCampaignNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Campaign not found")
+		return false
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch campaign")
+		return false
+	}
+	if campaign.StatusAt(time.Now()) != models.CampaignActive {
+		respondError(w, http.StatusConflict, "CAMPAIGN_NOT_ACTIVE", "The campaign is not running")
+		return false
+	}
+	return true
+}
+
+// presentCampaign sets a campaign's status at now and how much of its goals
+// its progress reached
+func presentCampaign(campaign *models.Campaign, now time.Time) {
+	campaign.Status = campaign.StatusAt(now)
+	if campaign.Progress == nil {
+		campaign.Progress = &models.CampaignProgress{}
+	}
+	if campaign.GoalActs > 0 {
+		percent := percentOf(float64(campaign.Progress.Acts), float64(campaign.GoalActs))
+		campaign.Progress.ActsPercent = &percent
+	}
+	if campaign.GoalValue > 0 {
+		percent := percentOf(campaign.Progress.Value, campaign.GoalValue)
+		campaign.Progress.ValuePercent = &percent
+	}
+}
+
+// percentOf returns part as a percentage of whole, rounded to one decimal
+func percentOf(part, whole float64) float64 {
+	return math.Round(part/whole*1000) / 10
+}
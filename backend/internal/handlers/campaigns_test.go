@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestCampaigns(t *testing.T) {
+	db := databasetest.New()
+	for _, id := range []string{"u1", "u2", "u3"} {
+		db.AddUser(models.User{ID: id, Name: id}, "")
+	}
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	create := func(startsAt, endsAt time.Time) models.Campaign {
+		body := fmt.Sprintf(`{"name": "Kindness Week", "startsAt": %q, "endsAt": %q, "goalActs": 4, "goalValue": 100}`,
+			startsAt.Format(time.RFC3339), endsAt.Format(time.RFC3339))
+		w := httptest.NewRecorder()
+		handler.CreateCampaign(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/campaigns", strings.NewReader(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected the campaign created, got %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.Campaign }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	now := time.Now()
+	active := create(now.Add(-time.Hour), now.Add(24*time.Hour))
+	ended := create(now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	if active.Status != models.CampaignActive || ended.Status != models.CampaignEnded {
+		t.Errorf("expected an active and an ended campaign, got %s and %s", active.Status, ended.Status)
+	}
+
+	w := httptest.NewRecorder()
+	body := fmt.Sprintf(`{"name": "Backwards", "startsAt": %q, "endsAt": %q}`, now.Format(time.RFC3339), now.Add(-time.Hour).Format(time.RFC3339))
+	handler.CreateCampaign(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/campaigns", strings.NewReader(body)))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d for a campaign ending before it starts, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	campaignRequest := func(method, id, action, userID string, call func(http.ResponseWriter, *http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/campaigns/"+id+action, nil)
+		req.SetPathValue("id", id)
+		if userID != "" {
+			req = asUser(req, userID)
+		}
+		w := httptest.NewRecorder()
+		call(w, req)
+		return w
+	}
+	if w := campaignRequest(http.MethodPost, active.ID, "/join", "", handler.JoinCampaign); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d signed out, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := campaignRequest(http.MethodPost, ended.ID, "/join", "u3", handler.JoinCampaign); w.Code != http.StatusConflict {
+		t.Errorf("expected %d joining an ended campaign, got %d", http.StatusConflict, w.Code)
+	}
+	if w := campaignRequest(http.MethodPost, active.ID, "/join", "u3", handler.JoinCampaign); w.Code != http.StatusOK {
+		t.Fatalf("expected u3 to join, got %d", w.Code)
+	}
+
+	createAct := func(userID, campaignID string, value float64, anonymous bool) int {
+		body := fmt.Sprintf(`{"title": "Bought groceries", "description": "Paid for a neighbour's shopping", "type": "monetary", "category": "food", "value": %v, "currency": "EUR", "campaignId": %q, "isAnonymous": %v}`,
+			value, campaignID, anonymous)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(body))
+		if userID != "" {
+			req = asUser(req, userID)
+		}
+		w := httptest.NewRecorder()
+		handler.CreateAct(w, req)
+		return w.Code
+	}
+	if code := createAct("u1", ended.ID, 10, false); code != http.StatusConflict {
+		t.Errorf("expected %d tagging an ended campaign, got %d", http.StatusConflict, code)
+	}
+	if code := createAct("", active.ID, 10, false); code != http.StatusUnauthorized {
+		t.Errorf("expected %d tagging signed out, got %d", http.StatusUnauthorized, code)
+	}
+	if code := createAct("u1", "missing", 10, false); code != http.StatusNotFound {
+		t.Errorf("expected %d tagging a missing campaign, got %d", http.StatusNotFound, code)
+	}
+	for _, act := range []struct {
+		userID    string
+		value     float64
+		anonymous bool
+	}{{"u1", 20, false}, {"u2", 10, false}, {"u2", 30, false}, {"u1", 5, true}} {
+		if code := createAct(act.userID, active.ID, act.value, act.anonymous); code != http.StatusCreated {
+			t.Fatalf("expected the act created, got %d", code)
+		}
+	}
+
+	var got struct{ Data models.Campaign }
+	json.Unmarshal(campaignRequest(http.MethodGet, active.ID, "", "", handler.GetCampaign).Body.Bytes(), &got)
+	progress := got.Data.Progress
+	if progress == nil || progress.Acts != 4 || progress.Value != 65 || progress.Participants != 3 {
+		t.Fatalf("expected 4 acts worth 65 by 3 participants, got %+v", progress)
+	}
+	if *progress.ActsPercent != 100 || *progress.ValuePercent != 65 {
+		t.Errorf("expected the goals 100%% and 65%% reached, got %v and %v", *progress.ActsPercent, *progress.ValuePercent)
+	}
+
+	var board struct{ Data []models.LeaderboardEntry }
+	json.Unmarshal(campaignRequest(http.MethodGet, active.ID, "/leaderboard", "", handler.GetCampaignLeaderboard).Body.Bytes(), &board)
+	if len(board.Data) != 2 || board.Data[0].User.ID != "u2" || board.Data[0].ActsCount != 2 || board.Data[1].User.ID != "u1" || board.Data[1].ActsCount != 1 {
+		t.Errorf("expected u2 then u1 without the anonymous act, got %+v", board.Data)
+	}
+
+	if w := campaignRequest(http.MethodDelete, active.ID, "/join", "u3", handler.LeaveCampaign); w.Code != http.StatusOK {
+		t.Fatalf("expected u3 to leave, got %d", w.Code)
+	}
+	var list struct {
+		Data []models.Campaign
+		Meta models.APIMeta
+	}
+	w = httptest.NewRecorder()
+	handler.GetCampaigns(w, httptest.NewRequest(http.MethodGet, "/api/v1/campaigns?status=active", nil))
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if list.Meta.Total != 1 || len(list.Data) != 1 || list.Data[0].Progress.Participants != 2 {
+		t.Errorf("expected the active campaign with 2 participants, got %+v", list.Data)
+	}
+	if w := campaignRequest(http.MethodGet, "missing", "", "", handler.GetCampaign); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing campaign, got %d", http.StatusNotFound, w.Code)
+	}
+}
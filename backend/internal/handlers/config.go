@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"payforwardnow/internal/features"
+	"payforwardnow/internal/models"
+)
+
+// ConfigReloader reloads the settings that can change while the server runs
+// and returns the names of the ones that changed
+type ConfigReloader func(ctx context.Context) ([]string, error)
+
+// SetFeatures sets the feature flags GetFeatures reports
+func (h *Handler) SetFeatures(f *features.Flags) {
+	h.features = f
+}
+
+// SetConfigReloader sets the function ReloadConfig calls
+func (h *Handler) SetConfigReloader(reload ConfigReloader) {
+	h.reloadConfig = reload
+}
+
+// GetFeatures handles GET /api/v1/features, listing the feature flags so
+// clients can show or hide what they control
+func (h *Handler) GetFeatures(w http.ResponseWriter, r *http.Request) {
+	flags := map[string]bool{}
+	if h.features != nil {
+		flags = h.features.All()
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: flags})
+}
+
+// ReloadConfig handles POST /api/v1/admin/config/reload, applying the
+// reloadable settings as SIGHUP does. An invalid configuration changes
+// nothing and is reported with every invalid setting.
+func (h *Handler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if h.reloadConfig == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Configuration reload is not available")
+		return
+	}
+
+	changed, err := h.reloadConfig(r.Context())
+	if err != nil {
+		slog.WarnContext(r.Context(), "Configuration reload rejected", "error", err)
+		respondError(w, http.StatusUnprocessableEntity, "INVALID_CONFIG", err.Error())
+		return
+	}
+	if changed == nil {
+		changed = []string{}
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]interface{}{"changed": changed},
+	})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/features"
+)
+
+func TestGetFeatures(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	handler.SetFeatures(features.New(map[string]bool{"similar-acts": true, "beta-ui": false}))
+
+	w := httptest.NewRecorder()
+	handler.GetFeatures(w, httptest.NewRequest(http.MethodGet, "/api/v1/features", nil))
+
+	var response struct {
+		Data map[string]bool `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || !response.Data["similar-acts"] || response.Data["beta-ui"] {
+		t.Errorf("unexpected response %d %v", w.Code, response.Data)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	reload := func(r ConfigReloader) *httptest.ResponseRecorder {
+		handler.SetConfigReloader(r)
+		w := httptest.NewRecorder()
+		handler.ReloadConfig(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/config/reload", nil))
+		return w
+	}
+
+	w := reload(func(context.Context) ([]string, error) { return []string{"LOG_LEVEL"}, nil })
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"changed":["LOG_LEVEL"]`) {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	w = reload(func(context.Context) ([]string, error) { return nil, errors.New("PORT: must be a port number") })
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "PORT: must be a port number") {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	if w := reload(nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a reloader, got %d", w.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// CreateContentReport handles POST /api/v1/reports, a signed in user
+// reporting an act, testimonial or user that breaks the rules
+func (h *Handler) CreateContentReport(w http.ResponseWriter, r *http.Request) {
+	reporterID := currentUserID(r)
+	if reporterID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	var req models.CreateReportRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	report := &models.ContentReport{
+		ID:         uuid.New().String(),
+		Kind:       req.Kind,
+		TargetID:   req.TargetID,
+		ReporterID: reporterID,
+		Reason:     req.Reason,
+		Details:    req.Details,
+		CreatedAt:  time.Now().UTC(),
+	}
+	err := h.contentReports.Create(r.Context(), report)
+	if errors.Is(err, repository.ErrReportTargetNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Reported content not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to create report")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.APIResponse{Success: true, Data: report})
+}
+
+// reportStatuses are the values GetContentReports accepts for ?status=
+var reportStatuses = []models.ReportStatus{models.ReportOpen, models.ReportResolved, models.ReportDismissed}
+
+// GetContentReports handles GET /api/v1/admin/reports, oldest first, of
+// one ?status= or all of them
+func (h *Handler) GetContentReports(w http.ResponseWriter, r *http.Request) {
+	status := models.ReportStatus(r.URL.Query().Get("status"))
+	if status != "" && !slices.Contains(reportStatuses, status) {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "status must be one of open, resolved or dismissed")
+		return
+	}
+
+	params := getPaginationParams(r)
+	list, total, err := h.contentReports.List(r.Context(), status, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch reports")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    list,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// HandleContentReport handles PUT /api/v1/admin/reports/{id}, closing an
+// open report as resolved, once the content was dealt with, or dismissed
+func (h *Handler) HandleContentReport(w http.ResponseWriter, r *http.Request) {
+	var req models.HandleReportRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	report, err := h.contentReports.Handle(r.Context(), r.PathValue("id"), req.Status, currentUserID(r), req.Note, time.Now())
+	if errors.Is(err, repository.ErrReportNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Open report not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to handle report")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: report})
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestWantsCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		accept   string
+		expected bool
+	}{
+		{"no preference", "/api/v1/stats/global", "", false},
+		{"format query", "/api/v1/stats/global?format=csv", "", true},
+		{"accept header", "/api/v1/stats/global", "text/csv", true},
+		{"format query wins over accept", "/api/v1/stats/global?format=json", "text/csv", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if got := wantsCSV(req); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetOrgStats_CSV(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	cacheValue(context.Background(), handler.cache, "orgstats:org-1", &models.OrgStats{
+		OrganizationID: "org-1",
+		Members:        3,
+		ActsGiven:      4,
+		TotalValue:     12.5,
+	}, time.Minute)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orgs/org-1/stats?format=csv", nil)
+	req.SetPathValue("id", "org-1")
+	w := httptest.NewRecorder()
+
+	handler.GetOrgStats(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("expected CSV content type, got %s", ct)
+	}
+
+	expected := "organizationId,members,activeMembers,actsGiven,chainsStarted,totalValue\norg-1,3,0,4,0,12.50\n"
+	if w.Body.String() != expected {
+		t.Errorf("unexpected CSV body:\n%s", w.Body.String())
+	}
+}
+
+func TestGetCategoryStats_CSV(t *testing.T) {
+	handler := NewHandler(&MockDBClient{
+		ExecuteReadFunc: func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+			return []models.CategoryStats{{Key: "food", ActsCount: 3, TotalValue: 7.5}, {Key: "time", ActsCount: 1}}, nil
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/categories?format=csv", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetCategoryStats(w, req)
+
+	expected := "category,actsCount,totalValue\nfood,3,7.50\ntime,1,0.00\n"
+	if w.Body.String() != expected {
+		t.Errorf("unexpected CSV body:\n%s", w.Body.String())
+	}
+}
+
+func TestGetCategoryStats_CSVError(t *testing.T) {
+	handler := NewHandler(&MockDBClient{
+		ExecuteReadFunc: func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+			return nil, errors.New("connection reset")
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/categories?format=csv", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetCategoryStats(w, req)
+
+	if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected a JSON error, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+}
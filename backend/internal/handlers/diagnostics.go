@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"payforwardnow/internal/diagnostics"
+	"payforwardnow/internal/models"
+)
+
+// Diagnoser runs the server's self-checks
+type Diagnoser func(ctx context.Context) diagnostics.Report
+
+// SetDiagnoser sets the function GetDiagnostics calls
+func (h *Handler) SetDiagnoser(diagnose Diagnoser) {
+	h.diagnose = diagnose
+}
+
+// GetDiagnostics handles GET /api/v1/admin/doctor, running the same checks
+// as the doctor command against the server's own connections. The report
+// says which failed; the request itself still succeeds.
+func (h *Handler) GetDiagnostics(w http.ResponseWriter, r *http.Request) {
+	if h.diagnose == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Diagnostics are not available")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.diagnose(r.Context()),
+	})
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/diagnostics"
+)
+
+func TestGetDiagnostics(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	diagnose := func(d Diagnoser) *httptest.ResponseRecorder {
+		handler.SetDiagnoser(d)
+		w := httptest.NewRecorder()
+		handler.GetDiagnostics(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/doctor", nil))
+		return w
+	}
+
+	w := diagnose(func(context.Context) diagnostics.Report {
+		return diagnostics.Report{Status: diagnostics.StatusFail, Checks: []diagnostics.Result{
+			{Name: "neo4j", Status: diagnostics.StatusFail, Detail: "connection refused"},
+		}}
+	})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"neo4j","status":"fail"`) {
+		t.Errorf("unexpected response %d %s", w.Code, w.Body)
+	}
+
+	if w := diagnose(nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without a diagnoser, got %d", w.Code)
+	}
+}
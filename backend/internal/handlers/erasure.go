@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// EraseUserData handles DELETE /api/v1/admin/users/{id}/personal-data, the
+// right to be forgotten. It scrubs the user's personal data and responds
+// with a report of what changed. Cached leaderboards and impact reports,
+// which may show the user's name, are dropped.
+func (h *Handler) EraseUserData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := r.PathValue("id")
+
+	report, err := h.erasure.Erase(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to erase user data")
+		return
+	}
+
+	if err := h.cache.DeletePrefix(ctx, tenantCacheKey(ctx, "leaderboard:")); err != nil {
+		slog.WarnContext(ctx, "Failed to drop cached leaderboards of erased user", "error", err)
+	}
+	h.reports.DeletePrefix(tenantCacheKey(ctx, "impact:"+userID+":"))
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
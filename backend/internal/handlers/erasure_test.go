@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+func TestEraseUserData(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Email: "ann@example.org", Name: "Ann", Bio: "Lives on Elm Street"}, "hash")
+	db.AddUser(models.User{ID: "u2", Email: "bob@example.org", Name: "Bob"}, "hash")
+	db.AddAct(models.Act{ID: "a1", Title: "Paid Bob's rent", Description: "Ann covered rent", Location: "Elm Street", GiverID: "u1", ReceiverID: "u2"})
+	db.AddAct(models.Act{ID: "a2", Title: "Fixed a bike", GiverID: "u2"})
+	db.AddTestimonial(models.Testimonial{ID: "t1", UserID: "u1", Story: "My story", IsApproved: true})
+	db.AddTestimonial(models.Testimonial{ID: "t2", UserID: "u2", Story: "Bob's story", IsApproved: true})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	repos := db.Repositories()
+	ctx := context.Background()
+	if _, err := repos.Testimonials.ToggleReaction(ctx, "t2", "u1"); err != nil {
+		t.Fatal(err)
+	}
+	cacheValue(ctx, handler.cache, leaderboardCacheKey(ctx, "giver", "all"), "cached", 0)
+	handler.reports.SetReady("impact:u1:2024", &models.ImpactReport{UserID: "u1", Year: 2024})
+	handler.reports.SetReady("impact:u2:2024", &models.ImpactReport{UserID: "u2", Year: 2024})
+
+	erase := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/users/"+id+"/personal-data", nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.EraseUserData(w, req)
+		return w
+	}
+
+	w := erase("u1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var resp struct {
+		Data models.ErasureReport `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if r := resp.Data; r.ActsRedacted != 1 || r.TestimonialsDeleted != 1 || r.ReactionsRemoved != 1 {
+		t.Errorf("unexpected report: %+v", r)
+	}
+
+	user, err := repos.Users.Get(ctx, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != repository.ErasedUserName || user.Email == "ann@example.org" || user.Bio != "" {
+		t.Errorf("expected the profile to be scrubbed, got %+v", user)
+	}
+	if _, _, err := repos.Users.FindCredentials(ctx, "ann@example.org"); err == nil {
+		t.Error("expected the erased user to no longer sign in")
+	}
+	if suspended, _ := repos.Users.Suspended(ctx, "u1"); !suspended {
+		t.Error("expected the tokens the erased user still holds to be rejected")
+	}
+
+	act, err := repos.Acts.Get(ctx, "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Title != repository.ErasedActTitle || act.Description != "" || act.Location != "" || !act.IsAnonymous {
+		t.Errorf("expected the act to be redacted, got %+v", act)
+	}
+	if act, _ := repos.Acts.Get(ctx, "a2"); act.Title != "Fixed a bike" {
+		t.Errorf("expected other users' acts to be kept, got %+v", act)
+	}
+	if _, ok := cachedValue[string](ctx, handler.cache, leaderboardCacheKey(ctx, "giver", "all")); ok {
+		t.Error("expected cached leaderboards to be dropped")
+	}
+	if handler.reports.Get("impact:u1:2024") != nil || handler.reports.Get("impact:u2:2024") == nil {
+		t.Error("expected only the erased user's impact reports to be dropped")
+	}
+
+	if w := erase("missing"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Event types published on the handler's event broker
+const (
+	EventActCreated      = "act.created"
+	EventActCompleted    = "act.completed"
+	EventReactionToggled = "reaction.toggled"
+	EventChainMilestone  = "chain.milestone"
+)
+
+// sseHeartbeatInterval is how often a comment line is sent on idle streams
+// so proxies don't close the connection
+const sseHeartbeatInterval = 15 * time.Second
+
+// sseWriter writes Server-Sent Events to a response
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newSSEWriter prepares the response for event streaming. Streams are long
+// lived, so the server's write deadline is lifted for this response only.
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		return nil, err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	s := &sseWriter{w: w, rc: rc}
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Send writes a single event with a JSON encoded payload
+func (s *sseWriter) Send(id, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		fmt.Fprintf(s.w, "id: %s\n", id)
+	}
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload)
+	return s.flush()
+}
+
+// Heartbeat writes a comment line to keep the connection open
+func (s *sseWriter) Heartbeat() error {
+	fmt.Fprint(s.w, ": ping\n\n")
+	return s.flush()
+}
+
+func (s *sseWriter) flush() error {
+	if err := s.rc.Flush(); err != nil && err != http.ErrNotSupported {
+		return err
+	}
+	return nil
+}
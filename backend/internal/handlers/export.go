@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+// exportStream writes the rows of an export as they are read from the
+// database, as CSV or newline-delimited JSON. The response is started by the
+// first row, so an error before it can still be reported as JSON. Exports
+// take as long as the data does, so the server's write deadline is lifted
+// for the response.
+type exportStream struct {
+	w        http.ResponseWriter
+	filename string
+	header   []string
+	csv      bool
+
+	csvOut *csvStream
+	json   *json.Encoder
+	rc     *http.ResponseController
+	rows   int
+}
+
+func newExportStream(w http.ResponseWriter, r *http.Request, name string, header []string) *exportStream {
+	return &exportStream{w: w, filename: name, header: header, csv: wantsCSV(r), rc: http.NewResponseController(w)}
+}
+
+func (s *exportStream) open() error {
+	if s.csvOut != nil || s.json != nil {
+		return nil
+	}
+	if err := s.rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if s.csv {
+		var err error
+		s.csvOut, err = newCSVStream(s.w, s.filename+".csv", s.header)
+		return err
+	}
+	s.w.Header().Set("Content-Type", "application/x-ndjson")
+	s.w.Header().Set("Content-Disposition", `attachment; filename="`+s.filename+`.ndjson"`)
+	s.w.WriteHeader(http.StatusOK)
+	s.json = json.NewEncoder(s.w)
+	return nil
+}
+
+// Write sends value, or row for CSV, flushing to the client periodically
+func (s *exportStream) Write(value any, row []string) error {
+	if err := s.open(); err != nil {
+		return err
+	}
+	if s.csvOut != nil {
+		return s.csvOut.Write(row)
+	}
+	if err := s.json.Encode(value); err != nil {
+		return err
+	}
+	s.rows++
+	if s.rows%csvFlushEvery == 0 {
+		if err := s.rc.Flush(); err != nil && err != http.ErrNotSupported {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finish completes the response. An error once rows were sent can only cut
+// the export short, so it is logged.
+func (s *exportStream) Finish(r *http.Request, err error) {
+	started := s.csvOut != nil || s.json != nil
+	if err != nil && !started {
+		respondDatabaseError(s.w, err, "Failed to export "+s.filename)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Export aborted", "export", s.filename, "rows", s.rows, "error", err)
+	}
+	if s.open() != nil {
+		return
+	}
+	if s.csvOut != nil {
+		s.csvOut.Flush()
+	} else {
+		s.rc.Flush()
+	}
+}
+
+// exportHeaders are the CSV header rows of each kind of export
+var exportHeaders = map[string][]string{
+	"users": {"id", "email", "name", "location", "isVerified", "createdAt"},
+	"acts": {"id", "title", "type", "category", "value", "currency", "status", "giverId", "receiverId", "location",
+		"isAnonymous", "createdAt"},
+}
+
+// streamExport passes the rows of the export of kind to write, oldest
+// first, as values and as CSV rows matching exportHeaders, with cells that
+// could run as formulas quoted
+func (h *Handler) streamExport(ctx context.Context, kind string, write func(value any, row []string) error) error {
+	switch kind {
+	case "users":
+		return h.users.Stream(ctx, func(u *models.User) error {
+			return write(u, csvSafe([]string{u.ID, u.Email, u.Name, u.Location, strconv.FormatBool(u.IsVerified), u.CreatedAt.Format(time.RFC3339)}))
+		})
+	case "acts":
+		return h.acts.Stream(ctx, func(a *models.Act) error {
+			return write(a, csvSafe([]string{a.ID, a.Title, string(a.Type), a.Category, formatFloat(a.Value), a.Currency, string(a.Status),
+				a.GiverID, a.ReceiverID, a.Location, strconv.FormatBool(a.IsAnonymous), a.CreatedAt.Format(time.RFC3339)}))
+		})
+	}
+	return fmt.Errorf("unknown export %q", kind)
+}
+
+// ExportUsers handles GET /api/v1/admin/export/users. Users are streamed
+// from the database, oldest first, as newline-delimited JSON or, with
+// ?format=csv, as CSV. Password hashes are never included.
+func (h *Handler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	out := newExportStream(w, r, "users", exportHeaders["users"])
+	out.Finish(r, h.streamExport(r.Context(), "users", out.Write))
+}
+
+// ExportActs handles GET /api/v1/admin/export/acts, streaming acts oldest
+// first like ExportUsers. Givers of anonymous acts are left out.
+func (h *Handler) ExportActs(w http.ResponseWriter, r *http.Request) {
+	out := newExportStream(w, r, "acts", exportHeaders["acts"])
+	out.Finish(r, h.streamExport(r.Context(), "acts", out.Write))
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// failingUsers fails to stream users
+type failingUsers struct {
+	repository.UserRepository
+}
+
+func (failingUsers) Stream(context.Context, func(*models.User) error) error {
+	return errors.New("connection refused")
+}
+
+func TestExportUsers(t *testing.T) {
+	db := databasetest.New()
+	now := time.Now()
+	db.AddUser(models.User{ID: "u2", Email: "bob@example.org", Name: "=HYPERLINK(\"http://example.com\")", CreatedAt: now}, "hash")
+	db.AddUser(models.User{ID: "u1", Email: "ann@example.org", Name: "Ann", CreatedAt: now.Add(-time.Hour)}, "hash")
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/export/users", nil)
+	w := httptest.NewRecorder()
+	handler.ExportUsers(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("expected NDJSON, got %q: %s", ct, w.Body)
+	}
+	var ids []string
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var user map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &user); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := user["passwordHash"]; ok {
+			t.Error("expected password hashes to be left out")
+		}
+		ids = append(ids, user["id"].(string))
+	}
+	if strings.Join(ids, ",") != "u1,u2" {
+		t.Errorf("expected users oldest first, got %v", ids)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/export/users?format=csv", nil)
+	w = httptest.NewRecorder()
+	handler.ExportUsers(w, req)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "id,email") || !strings.HasPrefix(lines[1], "u1,ann@example.org") {
+		t.Errorf("unexpected CSV: %q", lines)
+	}
+	if !strings.HasPrefix(lines[2], `u2,bob@example.org,"'=HYPERLINK(`) {
+		t.Errorf("expected a formula to be quoted, got %q", lines[2])
+	}
+
+	handler.users = failingUsers{}
+	w = httptest.NewRecorder()
+	handler.ExportUsers(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/export/users", nil))
+	if w.Code == http.StatusOK || !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("expected a JSON error before any rows, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestExportActs_Empty(t *testing.T) {
+	handler := NewHandlerWithRepositories(&MockDBClient{}, databasetest.New().Repositories())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/export/acts?format=csv", nil)
+	w := httptest.NewRecorder()
+	handler.ExportActs(w, req)
+
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "id,title,type,category,value,currency,status,giverId,receiverId,location,isAnonymous,createdAt" {
+		t.Errorf("expected only the header row, got %d: %q", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"payforwardnow/internal/geocode"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+const (
+	// locationBatch is the number of locations GeocodeLocations resolves per
+	// run
+	locationBatch = 100
+	// defaultNearbyRadius and maxNearbyRadius bound the radius, in
+	// kilometers, of GetNearbyActs
+	defaultNearbyRadius = 25
+	maxNearbyRadius     = 500
+)
+
+// SetGeocoder sets the geocoder the locations of users and acts are
+// resolved with. Without one, locations stay free text: acts can't be
+// searched by distance and countries aren't counted in the stats.
+func (h *Handler) SetGeocoder(g geocode.Geocoder) {
+	h.geocoder = g
+}
+
+// geocodeLater resolves the location of the user or act kind names in the
+// background. Locations it misses, such as when the pool is full, are
+// picked up by GeocodeLocations.
+func (h *Handler) geocodeLater(ctx context.Context, kind, id, location string) {
+	if h.geocoder == nil || location == "" {
+		return
+	}
+	pending := repository.PendingLocation{Kind: kind, ID: id, Location: location}
+	err := h.runInBackground(ctx, "geocode "+kind, func(ctx context.Context) error {
+		return h.geocodeLocation(ctx, pending)
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to queue geocoding", "kind", kind, "id", id, "error", err)
+	}
+}
+
+// geocodeLocation resolves a pending location and stores its place. A
+// location the geocoder can't place is stored without one, so it isn't
+// looked up again until it changes.
+func (h *Handler) geocodeLocation(ctx context.Context, pending repository.PendingLocation) error {
+	place, err := h.geocoder.Geocode(ctx, pending.Location)
+	if err != nil && !errors.Is(err, geocode.ErrNotFound) {
+		return fmt.Errorf("geocoding %s %s: %w", pending.Kind, pending.ID, err)
+	}
+	return h.locations.SetPlace(ctx, pending.Kind, pending.ID, pending.Location, place)
+}
+
+// GeocodeLocations resolves the locations of users and acts that weren't
+// geocoded yet or changed since, catching up on those geocodeLater missed
+// and on those set before geocoding was enabled
+func (h *Handler) GeocodeLocations(ctx context.Context) error {
+	pending, err := h.locations.Pending(ctx, locationBatch)
+	if err != nil {
+		return fmt.Errorf("listing locations to geocode: %w", err)
+	}
+	var errs []error
+	for _, p := range pending {
+		if err := h.geocodeLocation(ctx, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(pending) > 0 {
+		slog.InfoContext(ctx, "Geocoded locations", "locations", len(pending), "failed", len(errs))
+	}
+	return errors.Join(errs...)
+}
+
+// GetNearbyActs handles GET /api/v1/acts/nearby?lat=&lng=&radius=, the acts
+// placed within radius kilometers of a point, nearest first
+func (h *Handler) GetNearbyActs(w http.ResponseWriter, r *http.Request) {
+	if h.geocoder == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Geocoding is disabled")
+		return
+	}
+	query := r.URL.Query()
+	lat, err := strconv.ParseFloat(query.Get("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "lat must be a latitude between -90 and 90")
+		return
+	}
+	lng, err := strconv.ParseFloat(query.Get("lng"), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "lng must be a longitude between -180 and 180")
+		return
+	}
+	radius := float64(defaultNearbyRadius)
+	if raw := query.Get("radius"); raw != "" {
+		radius, err = strconv.ParseFloat(raw, 64)
+		if err != nil || radius <= 0 || radius > maxNearbyRadius {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER",
+				fmt.Sprintf("radius must be between 0 and %d kilometers", maxNearbyRadius))
+			return
+		}
+	}
+	params := getPaginationParams(r)
+
+	acts, total, err := h.acts.Nearby(r.Context(), lat, lng, radius, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch nearby acts")
+		return
+	}
+
+	totalPages := (int(total) + params.PerPage - 1) / params.PerPage
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    acts,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	})
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/geocode"
+	"payforwardnow/internal/models"
+)
+
+// fakeGeocoder is a geocode.Geocoder knowing a few cities
+type fakeGeocoder struct{}
+
+var fakePlaces = map[string]models.Place{
+	"Lisbon": {Latitude: 38.7223, Longitude: -9.1393, CountryCode: "PT"},
+	"Sintra": {Latitude: 38.8029, Longitude: -9.3817, CountryCode: "PT"},
+	"Madrid": {Latitude: 40.4168, Longitude: -3.7038, CountryCode: "ES"},
+}
+
+func (fakeGeocoder) Geocode(_ context.Context, location string) (*models.Place, error) {
+	place, ok := fakePlaces[location]
+	if !ok {
+		return nil, geocode.ErrNotFound
+	}
+	return &place, nil
+}
+
+func (fakeGeocoder) Name() string { return "fake" }
+
+func TestGeocodeLocations(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver", Location: "Madrid"}, "")
+	db.AddAct(models.Act{ID: "lisbon", Title: "Fed a neighbour", GiverID: "giver", Location: "Lisbon"})
+	db.AddAct(models.Act{ID: "sintra", Title: "Fixed a bike", GiverID: "giver", Location: "Sintra"})
+	db.AddAct(models.Act{ID: "madrid", Title: "Carried groceries", GiverID: "giver", Location: "Madrid"})
+	db.AddAct(models.Act{ID: "atlantis", Title: "Shared an umbrella", GiverID: "giver", Location: "Atlantis"})
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+
+	nearby := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.GetNearbyActs(w, httptest.NewRequest(http.MethodGet, "/api/v1/acts/nearby?"+query, nil))
+		return w
+	}
+	if w := nearby("lat=38.7&lng=-9.1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d without a geocoder, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	handler.SetGeocoder(fakeGeocoder{})
+	if err := handler.GeocodeLocations(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	user, _ := repos.Users.Get(context.Background(), "giver")
+	if user.Latitude == nil || user.CountryCode != "ES" {
+		t.Errorf("expected the user to be placed in Spain, got %+v", user)
+	}
+	act, _ := repos.Acts.Get(context.Background(), "atlantis")
+	if act.Latitude != nil || act.CountryCode != "" {
+		t.Errorf("expected an unknown location to stay unplaced, got %+v", act)
+	}
+	if pending, _ := repos.Locations.Pending(context.Background(), 10); len(pending) != 0 {
+		t.Errorf("expected every location to be geocoded once, got %+v", pending)
+	}
+
+	w := nearby("lat=38.7&lng=-9.1&radius=50")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data []models.NearbyAct
+		Meta models.APIMeta
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data) != 2 || resp.Data[0].ID != "lisbon" || resp.Data[1].ID != "sintra" || resp.Meta.Total != 2 {
+		t.Errorf("expected the Lisbon and Sintra acts, nearest first, got %s", w.Body.String())
+	}
+	if d := resp.Data[0].DistanceKm; d <= 0 || d > 10 {
+		t.Errorf("expected the Lisbon act a few kilometers away, got %v", d)
+	}
+
+	for _, query := range []string{"lng=-9.1", "lat=91&lng=-9.1", "lat=38.7&lng=181", "lat=38.7&lng=-9.1&radius=0", "lat=38.7&lng=-9.1&radius=501"} {
+		if w := nearby(query); w.Code != http.StatusBadRequest {
+			t.Errorf("expected %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+}
@@ -3,13 +3,38 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/cache"
 	"payforwardnow/internal/database"
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/features"
+	"payforwardnow/internal/geocode"
+	"payforwardnow/internal/i18n"
+	"payforwardnow/internal/jobs"
+	"payforwardnow/internal/mail"
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/migrations"
 	"payforwardnow/internal/models"
-
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/payments"
+	"payforwardnow/internal/queries"
+	"payforwardnow/internal/rates"
+	"payforwardnow/internal/reports"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/search"
+	"payforwardnow/internal/stream"
+	"payforwardnow/internal/version"
+	"payforwardnow/internal/webhooks"
+	"payforwardnow/internal/workers"
+
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"golang.org/x/crypto/bcrypt"
@@ -17,124 +42,207 @@ import (
 
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	db database.DBClient
+	db              database.DBClient
+	users           repository.UserRepository
+	acts            repository.ActRepository
+	chains          repository.ChainRepository
+	testimonials    repository.TestimonialRepository
+	notifications   repository.NotificationRepository
+	suppressions    repository.SuppressionRepository
+	webhooks        repository.WebhookRepository
+	jobs            repository.JobRepository
+	receipts        repository.ReceiptRepository
+	pledges         repository.PledgeRepository
+	rates           repository.RateRepository
+	locations       repository.LocationRepository
+	recommendations repository.RecommendationRepository
+	contentReports  repository.ContentReportRepository
+	campaigns       repository.CampaignRepository
+	organizations   repository.OrganizationRepository
+	erasure         repository.ErasureRepository
+	trash           repository.TrashRepository
+	reports         *reports.Store
+	cache           cache.Store
+	revocations     *auth.Revocations
+	tokenSecret     string
+	events          *stream.Broker
+	screener        *moderation.Screener
+	maintenance     *middleware.Maintenance
+	similar         embeddings.Searcher
+	schema          *migrations.Readiness
+	features        *features.Flags
+	reloadConfig    ConfigReloader
+	diagnose        Diagnoser
+	workers         *workers.Pool
+	mailer          *mail.Mailer
+	linkBase        string
+	mediaHosts      []string
+	dispatcher      *webhooks.Dispatcher
+	queue           *jobs.Queue
+	exportDir       string
+	receiptPrefix   string
+	issuer          models.ReceiptIssuer
+	payments        payments.Provider
+	pledgeRelease   time.Duration
+	ratesProvider   rates.Provider
+	baseCurrency    string
+	geocoder        geocode.Geocoder
+	search          search.Engine
+	searchIndexer   *search.Indexer
+	draining        atomic.Bool
 }
 
 // NewHandler creates a new Handler
 func NewHandler(db database.DBClient) *Handler {
-	return &Handler{db: db}
+	return NewHandlerWithRepositories(db, repository.NewNeo4j(db))
+}
+
+// NewHandlerWithRepositories creates a Handler using repos instead of the
+// Neo4j repositories, such as the in-memory ones from databasetest. Handlers
+// that query db directly still use it.
+func NewHandlerWithRepositories(db database.DBClient, repos repository.Repositories) *Handler {
+	return &Handler{
+		db:              db,
+		users:           repos.Users,
+		acts:            repos.Acts,
+		chains:          repos.Chains,
+		testimonials:    repos.Testimonials,
+		notifications:   repos.Notifications,
+		suppressions:    repos.Suppressions,
+		webhooks:        repos.Webhooks,
+		jobs:            repos.Jobs,
+		receipts:        repos.Receipts,
+		pledges:         repos.Pledges,
+		rates:           repos.Rates,
+		locations:       repos.Locations,
+		recommendations: repos.Recommendations,
+		contentReports:  repos.ContentReports,
+		campaigns:       repos.Campaigns,
+		organizations:   repos.Organizations,
+		erasure:         repos.Erasure,
+		trash:           repos.Trash,
+		search:          search.NewNeo4j(repos.Search),
+		reports:         reports.NewStore(time.Hour),
+		cache:           cache.NewLRU(statsCacheSize),
+		events:          stream.NewBroker(16),
+		screener:        moderation.NewScreener(),
+		receiptPrefix:   defaultReceiptPrefix,
+		issuer:          models.ReceiptIssuer{Name: defaultReceiptIssuer},
+		linkBase:        defaultLinkBase,
+	}
+}
+
+// Drain makes the health check report the server as draining, so load
+// balancers stop sending it new requests before it shuts down
+func (h *Handler) Drain() {
+	h.draining.Store(true)
 }
 
-// HealthCheck handles health check requests
+// HealthCheck handles health check requests. With ?verbose=true it also
+// reports connection pool usage. A reachable database whose schema setup
+// failed or is incomplete is reported as degraded, still with a 200, so the
+// server keeps receiving traffic while the schema is fixed. A draining
+// server answers 503.
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if h.draining.Load() {
+		respondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":    "draining",
+			"timestamp": time.Now().UTC(),
+			"service":   "payforwardnow-api",
+		})
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
+	dbInfo := map[string]interface{}{}
+	if verbose, _ := strconv.ParseBool(r.URL.Query().Get("verbose")); verbose {
+		if pool, ok := database.PoolStatsOf(h.db); ok {
+			dbInfo["pool"] = pool
+		}
+	}
+
+	// Report the breaker state without probing while it is open
+	if breaker, ok := h.db.(*database.CircuitBreaker); ok {
+		state := breaker.State()
+		dbInfo["circuit"] = state.String()
+		if state == database.BreakerOpen {
+			respondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status":    "unhealthy",
+				"timestamp": time.Now().UTC(),
+				"service":   "payforwardnow-api",
+				"database":  dbInfo,
+			})
+			return
+		}
+	}
+
 	// Check Neo4j connectivity
 	session := h.db.ReadSession(ctx)
 	defer session.Close(ctx)
 
-	_, err := session.Run(ctx, "RETURN 1", nil)
+	_, err := session.Run(ctx, queries.HealthPing.Cypher, nil)
 	if err != nil {
 		respondError(w, http.StatusServiceUnavailable, "DATABASE_ERROR", "Database connection failed")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]interface{}{
+	build := version.Get()
+	health := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now().UTC(),
 		"service":   "payforwardnow-api",
-		"version":   "1.0.0",
+		"version":   build.Version,
+		"commit":    build.Commit,
+		"buildTime": build.BuildTime,
+	}
+	if h.schema != nil {
+		dbInfo["schema"] = h.schema.States()
+		if !h.schema.Ready() {
+			health["status"] = "degraded"
+		}
+	}
+	if len(dbInfo) > 0 {
+		health["database"] = dbInfo
+	}
+	respondJSON(w, http.StatusOK, health)
+}
+
+// GetVersion handles GET /api/version, describing the running build
+func (h *Handler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    version.Get(),
 	})
 }
 
 // GetUser handles GET /api/v1/users/{id}
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("id")
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (u:User {id: $id})
-			OPTIONAL MATCH (u)-[:GAVE]->(given:Act)
-			OPTIONAL MATCH (u)-[:RECEIVED]->(received:Act)
-			OPTIONAL MATCH (u)-[:STARTED]->(chain:Chain)
-			RETURN u, 
-				   count(DISTINCT given) as actsGiven,
-				   count(DISTINCT received) as actsReceived,
-				   count(DISTINCT chain) as chainsStarted
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{"id": userID})
-		if err != nil {
-			return nil, err
-		}
-
-		if result.Next(ctx) {
-			record := result.Record()
-			userNode, _ := record.Get("u")
-			if userNode == nil {
-				return nil, nil
-			}
-
-			node := userNode.(neo4j.Node)
-			props := node.Props
-
-			user := &models.User{
-				ID:         props["id"].(string),
-				Email:      props["email"].(string),
-				Name:       props["name"].(string),
-				IsVerified: props["isVerified"].(bool),
-				CreatedAt:  props["createdAt"].(time.Time),
-				UpdatedAt:  props["updatedAt"].(time.Time),
-				Stats: models.UserStats{
-					ActsGiven:     int(record.Values[1].(int64)),
-					ActsReceived:  int(record.Values[2].(int64)),
-					ChainsStarted: int(record.Values[3].(int64)),
-				},
-			}
-
-			if avatar, ok := props["avatar"].(string); ok {
-				user.Avatar = avatar
-			}
-			if bio, ok := props["bio"].(string); ok {
-				user.Bio = bio
-			}
-			if location, ok := props["location"].(string); ok {
-				user.Location = location
-			}
-
-			return user, nil
-		}
-
-		return nil, nil
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch user")
+	user, err := h.users.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
 		return
 	}
-
-	if result == nil {
-		respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch user")
 		return
 	}
 
+	setVersionETag(w, user.Version)
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    result,
+		Data:    user,
 	})
 }
 
 // CreateUser handles POST /api/v1/users
 func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	if !decodeAndValidate(w, r, &req) {
 		return
 	}
 
-	ctx := r.Context()
-
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -143,55 +251,22 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	now := time.Now().UTC()
-	userID := uuid.New().String()
-
-	result, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			CREATE (u:User {
-				id: $id,
-				email: $email,
-				passwordHash: $passwordHash,
-				name: $name,
-				isVerified: false,
-				createdAt: $createdAt,
-				updatedAt: $updatedAt
-			})
-			RETURN u
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{
-			"id":           userID,
-			"email":        req.Email,
-			"passwordHash": string(hashedPassword),
-			"name":         req.Name,
-			"createdAt":    now,
-			"updatedAt":    now,
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		if result.Next(ctx) {
-			return &models.User{
-				ID:         userID,
-				Email:      req.Email,
-				Name:       req.Name,
-				IsVerified: false,
-				CreatedAt:  now,
-				UpdatedAt:  now,
-			}, nil
-		}
-
-		return nil, nil
-	})
+	user := &models.User{
+		ID:        uuid.New().String(),
+		Email:     req.Email,
+		Name:      req.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
 
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to create user")
+	if err := h.users.Create(r.Context(), user, string(hashedPassword)); err != nil {
+		respondDatabaseError(w, err, "Failed to create user")
 		return
 	}
 
 	respondJSON(w, http.StatusCreated, models.APIResponse{
 		Success: true,
-		Data:    result,
+		Data:    user,
 	})
 }
 
@@ -199,73 +274,47 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	userID := r.PathValue("id")
 	var req models.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	if !decodeAndValidate(w, r, &req) {
 		return
 	}
-
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (u:User {id: $id})
-			SET u.name = COALESCE($name, u.name),
-				u.avatar = COALESCE($avatar, u.avatar),
-				u.bio = COALESCE($bio, u.bio),
-				u.location = COALESCE($location, u.location),
-				u.updatedAt = $updatedAt
-			RETURN u
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{
-			"id":        userID,
-			"name":      nilIfEmpty(req.Name),
-			"avatar":    nilIfEmpty(req.Avatar),
-			"bio":       nilIfEmpty(req.Bio),
-			"location":  nilIfEmpty(req.Location),
-			"updatedAt": time.Now().UTC(),
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		if result.Next(ctx) {
-			return true, nil
+	if req.Locale != "" {
+		if req.Locale = i18n.Normalize(req.Locale); req.Locale == "" {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "locale must be a language tag such as 'it' or 'pt-br'")
+			return
 		}
-		return false, nil
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to update user")
+	}
+	expected, ok := expectedVersion(w, r, req.Version)
+	if !ok {
 		return
 	}
+	req.Version = expected
 
-	if result == false {
+	version, err := h.users.Update(r.Context(), userID, req)
+	if errors.Is(err, repository.ErrUserNotFound) {
 		respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
 		return
 	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		respondVersionConflict(w, "User")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to update user")
+		return
+	}
+	h.geocodeLater(r.Context(), "user", userID, req.Location)
 
+	setVersionETag(w, version)
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    map[string]string{"message": "User updated successfully"},
+		Data:    map[string]interface{}{"message": "User updated successfully", "version": version},
 	})
 }
 
 // DeleteUser handles DELETE /api/v1/users/{id}
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("id")
-	ctx := r.Context()
-
-	_, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (u:User {id: $id})
-			DETACH DELETE u
-		`
-		_, err := tx.Run(ctx, query, map[string]interface{}{"id": userID})
-		return nil, err
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to delete user")
+	if err := h.users.Delete(r.Context(), r.PathValue("id")); err != nil {
+		respondDatabaseError(w, err, "Failed to delete user")
 		return
 	}
 
@@ -278,23 +327,18 @@ func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 // Register handles POST /api/v1/auth/register
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	if !decodeAndValidate(w, r, &req) {
 		return
 	}
 
 	ctx := r.Context()
 
-	// Check if email exists
-	exists, _ := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		result, err := tx.Run(ctx, "MATCH (u:User {email: $email}) RETURN u", map[string]interface{}{"email": req.Email})
-		if err != nil {
-			return false, err
-		}
-		return result.Next(ctx), nil
-	})
-
-	if exists.(bool) {
+	exists, err := h.users.EmailExists(ctx, req.Email)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to create user")
+		return
+	}
+	if exists {
 		respondError(w, http.StatusConflict, "EMAIL_EXISTS", "Email already registered")
 		return
 	}
@@ -307,54 +351,29 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	now := time.Now().UTC()
-	userID := uuid.New().String()
-
-	_, err = h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			CREATE (u:User {
-				id: $id,
-				email: $email,
-				passwordHash: $passwordHash,
-				name: $name,
-				isVerified: false,
-				createdAt: $createdAt,
-				updatedAt: $updatedAt
-			})
-			RETURN u
-		`
-		return tx.Run(ctx, query, map[string]interface{}{
-			"id":           userID,
-			"email":        req.Email,
-			"passwordHash": string(hashedPassword),
-			"name":         req.Name,
-			"createdAt":    now,
-			"updatedAt":    now,
-		})
-	})
+	user := &models.User{
+		ID:        uuid.New().String(),
+		Email:     req.Email,
+		Name:      req.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
 
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to create user")
+	if err := h.users.Create(ctx, user, string(hashedPassword)); err != nil {
+		respondDatabaseError(w, err, "Failed to create user")
 		return
 	}
 
-	// Generate tokens (simplified - use proper JWT in production)
-	tokens := models.AuthTokens{
-		AccessToken:  uuid.New().String(),
-		RefreshToken: uuid.New().String(),
-		ExpiresIn:    3600,
+	tokens, err := h.issueTokens(user.ID, user.Email)
+	if err != nil {
+		respondTokenError(w)
+		return
 	}
 
 	respondJSON(w, http.StatusCreated, models.APIResponse{
 		Success: true,
 		Data: map[string]interface{}{
-			"user": models.User{
-				ID:         userID,
-				Email:      req.Email,
-				Name:       req.Name,
-				IsVerified: false,
-				CreatedAt:  now,
-				UpdatedAt:  now,
-			},
+			"user":   user,
 			"tokens": tokens,
 		},
 	})
@@ -363,60 +382,35 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 // Login handles POST /api/v1/auth/login
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	if !decodeAndValidate(w, r, &req) {
 		return
 	}
 
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `MATCH (u:User {email: $email}) RETURN u`
-		result, err := tx.Run(ctx, query, map[string]interface{}{"email": req.Email})
-		if err != nil {
-			return nil, err
-		}
-
-		if result.Next(ctx) {
-			record := result.Record()
-			userNode, _ := record.Get("u")
-			node := userNode.(neo4j.Node)
-			return node.Props, nil
-		}
-		return nil, nil
-	})
-
-	if err != nil || result == nil {
+	user, storedHash, err := h.users.FindCredentials(r.Context(), req.Email)
+	if err != nil {
 		respondError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
 		return
 	}
 
-	props := result.(map[string]interface{})
-	storedHash := props["passwordHash"].(string)
-
 	if err := bcrypt.CompareHashAndPassword([]byte(storedHash), []byte(req.Password)); err != nil {
 		respondError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password")
 		return
 	}
+	if user.SuspendedAt != nil {
+		respondError(w, http.StatusForbidden, "ACCOUNT_SUSPENDED", "This account is suspended")
+		return
+	}
 
-	// Generate tokens
-	tokens := models.AuthTokens{
-		AccessToken:  uuid.New().String(),
-		RefreshToken: uuid.New().String(),
-		ExpiresIn:    3600,
+	tokens, err := h.issueTokens(user.ID, user.Email)
+	if err != nil {
+		respondTokenError(w)
+		return
 	}
 
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
 		Data: map[string]interface{}{
-			"user": models.User{
-				ID:         props["id"].(string),
-				Email:      props["email"].(string),
-				Name:       props["name"].(string),
-				IsVerified: props["isVerified"].(bool),
-				CreatedAt:  props["createdAt"].(time.Time),
-				UpdatedAt:  props["updatedAt"].(time.Time),
-			},
+			"user":   user,
 			"tokens": tokens,
 		},
 	})
@@ -424,20 +418,75 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 
 // Logout handles POST /api/v1/auth/logout
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
-	// In a real implementation, invalidate the token
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && h.revocations != nil {
+		if err := h.revocations.Revoke(r.Context(), token, tokenExpiry(token)); err != nil {
+			respondError(w, http.StatusInternalServerError, "LOGOUT_FAILED", "Failed to revoke token")
+			return
+		}
+	}
+
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    map[string]string{"message": "Logged out successfully"},
 	})
 }
 
-// RefreshToken handles POST /api/v1/auth/refresh
+// maxRevocation bounds how long a logged out token is remembered, since the
+// expiry is read from the token before it has been verified
+const maxRevocation = 24 * time.Hour
+
+// tokenExpiry returns when token expires: its exp claim for JWTs, or an hour
+// from now for tokens without one
+func tokenExpiry(token string) time.Time {
+	now := time.Now()
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.ExpiresAt == nil {
+		return now.Add(time.Hour)
+	}
+	if limit := now.Add(maxRevocation); claims.ExpiresAt.After(limit) {
+		return limit
+	}
+	return claims.ExpiresAt.Time
+}
+
+// RefreshToken handles POST /api/v1/auth/refresh, exchanging a refresh
+// token issued by Login or Register for new tokens
 func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	// In a real implementation, validate refresh token and issue new tokens
-	tokens := models.AuthTokens{
-		AccessToken:  uuid.New().String(),
-		RefreshToken: uuid.New().String(),
-		ExpiresIn:    3600,
+	var req models.RefreshRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	claims, err := middleware.ParseToken(h.tokenSecret, req.RefreshToken)
+	if err != nil || !middleware.IsRefreshToken(claims) {
+		respondError(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired refresh token")
+		return
+	}
+	if h.revocations != nil {
+		if revoked, _ := h.revocations.Revoked(r.Context(), req.RefreshToken); revoked {
+			respondError(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired refresh token")
+			return
+		}
+	}
+	// Deleted users can't refresh, and suspended ones are told why
+	user, err := h.users.Get(r.Context(), claims.UserID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		respondError(w, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid or expired refresh token")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to refresh tokens")
+		return
+	}
+	if user.SuspendedAt != nil {
+		respondError(w, http.StatusForbidden, "ACCOUNT_SUSPENDED", "This account is suspended")
+		return
+	}
+
+	tokens, err := h.issueTokens(user.ID, user.Email)
+	if err != nil {
+		respondTokenError(w)
+		return
 	}
 
 	respondJSON(w, http.StatusOK, models.APIResponse{
@@ -448,85 +497,19 @@ func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 // GetActs handles GET /api/v1/acts
 func (h *Handler) GetActs(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
 	params := getPaginationParams(r)
 
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		countQuery := `MATCH (a:Act) RETURN count(a) as total`
-		countResult, err := tx.Run(ctx, countQuery, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		var total int64
-		if countResult.Next(ctx) {
-			if val, ok := countResult.Record().Get("total"); ok {
-				total = val.(int64)
-			}
-		}
-
-		skip := (params.Page - 1) * params.PerPage
-		query := `
-			MATCH (a:Act)
-			OPTIONAL MATCH (giver:User)-[:GAVE]->(a)
-			OPTIONAL MATCH (a)-[:RECEIVED_BY]->(receiver:User)
-			RETURN a, giver, receiver
-			ORDER BY a.createdAt DESC
-			SKIP $skip LIMIT $limit
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{
-			"skip":  skip,
-			"limit": params.PerPage,
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		var acts []models.Act
-		for result.Next(ctx) {
-			record := result.Record()
-			actNode, _ := record.Get("a")
-			node := actNode.(neo4j.Node)
-			props := node.Props
-
-			act := models.Act{
-				ID:          props["id"].(string),
-				Title:       props["title"].(string),
-				Description: props["description"].(string),
-				Type:        models.ActType(props["type"].(string)),
-				Status:      models.ActStatus(props["status"].(string)),
-				CreatedAt:   props["createdAt"].(time.Time),
-				UpdatedAt:   props["updatedAt"].(time.Time),
-			}
-
-			if category, ok := props["category"].(string); ok {
-				act.Category = category
-			}
-			if value, ok := props["value"].(float64); ok {
-				act.Value = value
-			}
-
-			acts = append(acts, act)
-		}
-
-		return map[string]interface{}{
-			"acts":  acts,
-			"total": total,
-		}, nil
-	})
-
+	acts, total, err := h.acts.List(r.Context(), params)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch acts")
+		respondDatabaseError(w, err, "Failed to fetch acts")
 		return
 	}
 
-	data := result.(map[string]interface{})
-	total := data["total"].(int64)
 	totalPages := (int(total) + params.PerPage - 1) / params.PerPage
 
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    data["acts"],
+		Data:    acts,
 		Meta: &models.APIMeta{
 			Page:       params.Page,
 			PerPage:    params.PerPage,
@@ -539,144 +522,104 @@ func (h *Handler) GetActs(w http.ResponseWriter, r *http.Request) {
 // CreateAct handles POST /api/v1/acts
 func (h *Handler) CreateAct(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateActRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	if !decodeAndValidate(w, r, &req) {
 		return
 	}
 
-	ctx := r.Context()
-	now := time.Now().UTC()
-	actID := uuid.New().String()
-
 	// Get user ID from context (should be set by auth middleware)
-	giverID := r.Header.Get("X-User-ID")
-	if giverID == "" {
+	giverID := currentUserID(r)
+	signedIn := giverID != ""
+	if !signedIn {
 		giverID = "anonymous"
 	}
 
-	result, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			CREATE (a:Act {
-				id: $id,
-				title: $title,
-				description: $description,
-				type: $type,
-				category: $category,
-				value: $value,
-				currency: $currency,
-				status: 'pending',
-				giverId: $giverId,
-				receiverId: $receiverId,
-				location: $location,
-				isAnonymous: $isAnonymous,
-				createdAt: $createdAt,
-				updatedAt: $updatedAt
-			})
-			WITH a
-			MATCH (giver:User {id: $giverId})
-			CREATE (giver)-[:GAVE]->(a)
-			RETURN a
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{
-			"id":          actID,
-			"title":       req.Title,
-			"description": req.Description,
-			"type":        string(req.Type),
-			"category":    req.Category,
-			"value":       req.Value,
-			"currency":    req.Currency,
-			"giverId":     giverID,
-			"receiverId":  nilIfEmpty(req.ReceiverID),
-			"location":    nilIfEmpty(req.Location),
-			"isAnonymous": req.IsAnonymous,
-			"createdAt":   now,
-			"updatedAt":   now,
-		})
-		if err != nil {
-			return nil, err
-		}
+	if req.CampaignID != "" && !h.checkCampaignRunning(w, r, req.CampaignID, signedIn) {
+		return
+	}
+	if req.OrganizationID != "" && !h.checkOrgGiver(w, r, req.OrganizationID, giverID, signedIn) {
+		return
+	}
 
-		if result.Next(ctx) {
-			return &models.Act{
-				ID:          actID,
-				Title:       req.Title,
-				Description: req.Description,
-				Type:        req.Type,
-				Category:    req.Category,
-				Value:       req.Value,
-				Status:      models.ActStatusPending,
-				GiverID:     giverID,
-				IsAnonymous: req.IsAnonymous,
-				CreatedAt:   now,
-				UpdatedAt:   now,
-			}, nil
+	var chain *models.Chain
+	if req.ChainID != "" {
+		var err error
+		chain, err = h.chains.Get(r.Context(), req.ChainID)
+		if errors.Is(err, repository.ErrChainNotFound) {
+			respondError(w, http.StatusNotFound, "NOT_FOUND", "Chain not found")
+			return
+		} else if err != nil {
+			respondDatabaseError(w, err, "Failed to fetch chain")
+			return
 		}
-		return nil, nil
-	})
+	}
+
+	screening := h.screener.ScreenContent(r.Context(), "act", req.Title, req.Description)
+	if screening.Verdict == moderation.Block {
+		respondContentBlocked(w, screening)
+		return
+	}
 
+	now := time.Now().UTC()
+	act := &models.Act{
+		ID:             uuid.New().String(),
+		Title:          req.Title,
+		Description:    req.Description,
+		Type:           req.Type,
+		Category:       req.Category,
+		Value:          req.Value,
+		Currency:       req.Currency,
+		Status:         models.ActStatusPending,
+		GiverID:        giverID,
+		ReceiverID:     req.ReceiverID,
+		Location:       req.Location,
+		IsAnonymous:    req.IsAnonymous,
+		ChainID:        req.ChainID,
+		CampaignID:     req.CampaignID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		OrganizationID: req.OrganizationID,
+	}
+
+	created, err := h.acts.Create(r.Context(), act)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to create act")
+		respondDatabaseError(w, err, "Failed to create act")
 		return
 	}
 
+	var data interface{}
+	if created {
+		if screening.Verdict == moderation.Flag {
+			h.recordActScreening(r.Context(), act.ID, screening)
+		}
+		h.publishActCreated(r.Context(), act, chain)
+		h.notifyActCreated(r.Context(), act, signedIn)
+		h.emailActReceived(r.Context(), act, signedIn)
+		h.geocodeLater(r.Context(), "act", act.ID, act.Location)
+		data = act
+	}
+
 	respondJSON(w, http.StatusCreated, models.APIResponse{
 		Success: true,
-		Data:    result,
+		Data:    data,
 	})
 }
 
 // GetAct handles GET /api/v1/acts/{id}
 func (h *Handler) GetAct(w http.ResponseWriter, r *http.Request) {
-	actID := r.PathValue("id")
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (a:Act {id: $id})
-			OPTIONAL MATCH (giver:User)-[:GAVE]->(a)
-			OPTIONAL MATCH (a)-[:RECEIVED_BY]->(receiver:User)
-			RETURN a, giver, receiver
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{"id": actID})
-		if err != nil {
-			return nil, err
-		}
-
-		if result.Next(ctx) {
-			record := result.Record()
-			actNode, _ := record.Get("a")
-			node := actNode.(neo4j.Node)
-			props := node.Props
-
-			act := &models.Act{
-				ID:          props["id"].(string),
-				Title:       props["title"].(string),
-				Description: props["description"].(string),
-				Type:        models.ActType(props["type"].(string)),
-				Status:      models.ActStatus(props["status"].(string)),
-				CreatedAt:   props["createdAt"].(time.Time),
-				UpdatedAt:   props["updatedAt"].(time.Time),
-			}
-
-			return act, nil
-		}
-
-		return nil, nil
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch act")
+	act, err := h.acts.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
 		return
 	}
-
-	if result == nil {
-		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch act")
 		return
 	}
 
+	setVersionETag(w, act.Version)
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    result,
+		Data:    act,
 	})
 }
 
@@ -684,54 +627,83 @@ func (h *Handler) GetAct(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) UpdateAct(w http.ResponseWriter, r *http.Request) {
 	actID := r.PathValue("id")
 	var req models.UpdateActRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+	if !decodeAndValidate(w, r, &req) {
 		return
 	}
+	expected, ok := expectedVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+	req.Version = expected
 
-	ctx := r.Context()
+	// Completing an act confirms it was received, so only its receiver can,
+	// or its giver when it has none
+	if req.Status == models.ActStatusCompleted {
+		act, err := h.acts.Get(r.Context(), actID)
+		if errors.Is(err, repository.ErrActNotFound) {
+			respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+			return
+		}
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to fetch act")
+			return
+		}
+		confirmer := act.ReceiverID
+		if confirmer == "" {
+			// The giver of an anonymous act is hidden from Get
+			if confirmer, err = h.acts.Giver(r.Context(), actID); err != nil {
+				respondDatabaseError(w, err, "Failed to fetch act")
+				return
+			}
+		}
+		if userID := currentUserID(r); userID == "" || userID != confirmer {
+			respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the act's receiver can mark it completed")
+			return
+		}
+	}
 
-	_, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (a:Act {id: $id})
-			SET a.title = COALESCE($title, a.title),
-				a.description = COALESCE($description, a.description),
-				a.status = COALESCE($status, a.status),
-				a.updatedAt = $updatedAt
-			RETURN a
-		`
-		return tx.Run(ctx, query, map[string]interface{}{
-			"id":          actID,
-			"title":       nilIfEmpty(req.Title),
-			"description": nilIfEmpty(req.Description),
-			"status":      nilIfEmpty(string(req.Status)),
-			"updatedAt":   time.Now().UTC(),
-		})
-	})
+	// Edited text is screened again, which may flag or clear the act
+	var screening *moderation.Result
+	if req.Title != "" || req.Description != "" {
+		result := h.screener.ScreenContent(r.Context(), "act", req.Title, req.Description)
+		if result.Verdict == moderation.Block {
+			respondContentBlocked(w, result)
+			return
+		}
+		screening = &result
+	}
 
+	version, err := h.acts.Update(r.Context(), actID, req)
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		return
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		respondVersionConflict(w, "Act")
+		return
+	}
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to update act")
+		respondDatabaseError(w, err, "Failed to update act")
 		return
 	}
+	if screening != nil {
+		h.recordActScreening(r.Context(), actID, *screening)
+	}
+	if req.Status == models.ActStatusCompleted {
+		h.publishActCompleted(r.Context(), actID, version)
+	}
 
+	setVersionETag(w, version)
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    map[string]string{"message": "Act updated successfully"},
+		Data:    map[string]interface{}{"message": "Act updated successfully", "version": version},
 	})
 }
 
 // DeleteAct handles DELETE /api/v1/acts/{id}
 func (h *Handler) DeleteAct(w http.ResponseWriter, r *http.Request) {
-	actID := r.PathValue("id")
-	ctx := r.Context()
-
-	_, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `MATCH (a:Act {id: $id}) DETACH DELETE a`
-		return tx.Run(ctx, query, map[string]interface{}{"id": actID})
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to delete act")
+	if err := h.acts.Delete(r.Context(), r.PathValue("id")); err != nil {
+		respondDatabaseError(w, err, "Failed to delete act")
 		return
 	}
 
@@ -743,101 +715,56 @@ func (h *Handler) DeleteAct(w http.ResponseWriter, r *http.Request) {
 
 // GetChain handles GET /api/v1/chains/{id}
 func (h *Handler) GetChain(w http.ResponseWriter, r *http.Request) {
-	chainID := r.PathValue("id")
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (c:Chain {id: $id})
-			OPTIONAL MATCH (c)-[:CONTAINS]->(a:Act)
-			OPTIONAL MATCH (starter:User)-[:STARTED]->(c)
-			RETURN c, collect(a) as acts, starter
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{"id": chainID})
-		if err != nil {
-			return nil, err
-		}
-
-		if result.Next(ctx) {
-			record := result.Record()
-			chainNode, _ := record.Get("c")
-			if chainNode == nil {
-				return nil, nil
-			}
-
-			node := chainNode.(neo4j.Node)
-			props := node.Props
-
-			chain := &models.Chain{
-				ID:        props["id"].(string),
-				Name:      props["name"].(string),
-				CreatedAt: props["createdAt"].(time.Time),
-				UpdatedAt: props["updatedAt"].(time.Time),
-			}
-
-			if desc, ok := props["description"].(string); ok {
-				chain.Description = desc
-			}
-
-			return chain, nil
-		}
-
-		return nil, nil
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch chain")
+	chain, err := h.chains.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrChainNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Chain not found")
 		return
 	}
-
-	if result == nil {
-		respondError(w, http.StatusNotFound, "NOT_FOUND", "Chain not found")
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch chain")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    result,
+		Data:    chain,
 	})
 }
 
 // GetUserChains handles GET /api/v1/users/{id}/chains
 func (h *Handler) GetUserChains(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("id")
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (u:User {id: $userId})-[:STARTED|PARTICIPATED_IN]->(c:Chain)
-			RETURN DISTINCT c
-			ORDER BY c.createdAt DESC
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{"userId": userID})
-		if err != nil {
-			return nil, err
-		}
-
-		var chains []models.Chain
-		for result.Next(ctx) {
-			record := result.Record()
-			chainNode, _ := record.Get("c")
-			node := chainNode.(neo4j.Node)
-			props := node.Props
-
-			chain := models.Chain{
-				ID:        props["id"].(string),
-				Name:      props["name"].(string),
-				CreatedAt: props["createdAt"].(time.Time),
-				UpdatedAt: props["updatedAt"].(time.Time),
-			}
-			chains = append(chains, chain)
-		}
+	chains, err := h.chains.ListByUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch chains")
+		return
+	}
 
-		return chains, nil
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    chains,
 	})
+}
 
+// GetGlobalStats handles GET /api/v1/stats/global
+func (h *Handler) GetGlobalStats(w http.ResponseWriter, r *http.Request) {
+	result, err := h.queryGlobalStats(r.Context())
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch chains")
+		respondDatabaseError(w, err, "Failed to fetch stats")
+		return
+	}
+
+	if wantsCSV(r) {
+		respondCSV(w, "global-stats.csv",
+			[]string{"totalActs", "totalUsers", "totalChains", "totalValue", "countriesReach", "activeThisMonth"},
+			[]string{
+				formatInt(result.TotalActs),
+				formatInt(result.TotalUsers),
+				formatInt(result.TotalChains),
+				formatFloat(result.TotalValue),
+				strconv.Itoa(result.CountriesReach),
+				formatInt(result.ActiveThisMonth),
+			},
+		)
 		return
 	}
 
@@ -847,20 +774,9 @@ func (h *Handler) GetUserChains(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetGlobalStats handles GET /api/v1/stats/global
-func (h *Handler) GetGlobalStats(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (a:Act)
-			WITH count(a) as totalActs, sum(COALESCE(a.value, 0)) as totalValue
-			MATCH (u:User)
-			WITH totalActs, totalValue, count(u) as totalUsers
-			MATCH (c:Chain)
-			RETURN totalActs, totalValue, totalUsers, count(c) as totalChains
-		`
-		result, err := tx.Run(ctx, query, nil)
+func (h *Handler) queryGlobalStats(ctx context.Context) (*models.GlobalStats, error) {
+	return database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) (*models.GlobalStats, error) {
+		result, err := queries.StatsGlobal.Run(ctx, tx, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -872,21 +788,12 @@ func (h *Handler) GetGlobalStats(w http.ResponseWriter, r *http.Request) {
 				TotalValue:     getFloat64(record, "totalValue"),
 				TotalUsers:     getInt64(record, "totalUsers"),
 				TotalChains:    getInt64(record, "totalChains"),
-				CountriesReach: 180, // Placeholder
+				Currency:       h.baseCurrency,
+				CountriesReach: int(getInt64(record, "countriesReach")),
 			}, nil
 		}
 
-		return &models.GlobalStats{}, nil
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch stats")
-		return
-	}
-
-	respondJSON(w, http.StatusOK, models.APIResponse{
-		Success: true,
-		Data:    result,
+		return &models.GlobalStats{}, result.Err()
 	})
 }
 
@@ -895,19 +802,8 @@ func (h *Handler) GetUserStats(w http.ResponseWriter, r *http.Request) {
 	userID := r.PathValue("id")
 	ctx := r.Context()
 
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (u:User {id: $userId})
-			OPTIONAL MATCH (u)-[:GAVE]->(given:Act)
-			OPTIONAL MATCH (u)-[:RECEIVED]->(received:Act)
-			OPTIONAL MATCH (u)-[:STARTED]->(chain:Chain)
-			RETURN 
-				count(DISTINCT given) as actsGiven,
-				count(DISTINCT received) as actsReceived,
-				count(DISTINCT chain) as chainsStarted,
-				sum(COALESCE(given.value, 0)) as totalImpact
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{"userId": userID})
+	stats, err := database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) (*models.UserStats, error) {
+		result, err := queries.StatsUser.Run(ctx, tx, map[string]interface{}{"userId": userID})
 		if err != nil {
 			return nil, err
 		}
@@ -922,143 +818,31 @@ func (h *Handler) GetUserStats(w http.ResponseWriter, r *http.Request) {
 			}, nil
 		}
 
-		return &models.UserStats{}, nil
+		return &models.UserStats{}, result.Err()
 	})
 
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch stats")
+		respondDatabaseError(w, err, "Failed to fetch stats")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, models.APIResponse{
-		Success: true,
-		Data:    result,
-	})
-}
-
-// GetTestimonials handles GET /api/v1/testimonials
-func (h *Handler) GetTestimonials(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	result, err := h.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			MATCH (t:Testimonial {isApproved: true})
-			OPTIONAL MATCH (u:User)-[:WROTE]->(t)
-			RETURN t, u
-			ORDER BY t.createdAt DESC
-			LIMIT 20
-		`
-		result, err := tx.Run(ctx, query, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		var testimonials []models.Testimonial
-		for result.Next(ctx) {
-			record := result.Record()
-			testNode, _ := record.Get("t")
-			node := testNode.(neo4j.Node)
-			props := node.Props
-
-			testimonial := models.Testimonial{
-				ID:         props["id"].(string),
-				Story:      props["story"].(string),
-				Impact:     props["impact"].(string),
-				IsApproved: props["isApproved"].(bool),
-				CreatedAt:  props["createdAt"].(time.Time),
-			}
-
-			if userNode, ok := record.Get("u"); ok && userNode != nil {
-				uNode := userNode.(neo4j.Node)
-				uProps := uNode.Props
-				testimonial.User = &models.User{
-					ID:   uProps["id"].(string),
-					Name: uProps["name"].(string),
-				}
-				if location, ok := uProps["location"].(string); ok {
-					testimonial.User.Location = location
-				}
-			}
-
-			testimonials = append(testimonials, testimonial)
-		}
-
-		return testimonials, nil
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to fetch testimonials")
+	if wantsCSV(r) {
+		respondCSV(w, "user-stats.csv",
+			[]string{"userId", "actsGiven", "actsReceived", "chainsStarted", "totalImpact"},
+			[]string{
+				userID,
+				strconv.Itoa(stats.ActsGiven),
+				strconv.Itoa(stats.ActsReceived),
+				strconv.Itoa(stats.ChainsStarted),
+				formatFloat(stats.TotalImpact),
+			},
+		)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    result,
-	})
-}
-
-// CreateTestimonial handles POST /api/v1/testimonials
-func (h *Handler) CreateTestimonial(w http.ResponseWriter, r *http.Request) {
-	var req models.CreateTestimonialRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
-		return
-	}
-
-	ctx := r.Context()
-	now := time.Now().UTC()
-	testID := uuid.New().String()
-	userID := r.Header.Get("X-User-ID")
-
-	result, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		query := `
-			CREATE (t:Testimonial {
-				id: $id,
-				userId: $userId,
-				story: $story,
-				impact: $impact,
-				isApproved: false,
-				isFeatured: false,
-				createdAt: $createdAt
-			})
-			WITH t
-			MATCH (u:User {id: $userId})
-			CREATE (u)-[:WROTE]->(t)
-			RETURN t
-		`
-		result, err := tx.Run(ctx, query, map[string]interface{}{
-			"id":        testID,
-			"userId":    userID,
-			"story":     req.Story,
-			"impact":    req.Impact,
-			"createdAt": now,
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		if result.Next(ctx) {
-			return &models.Testimonial{
-				ID:         testID,
-				UserID:     userID,
-				Story:      req.Story,
-				Impact:     req.Impact,
-				IsApproved: false,
-				IsFeatured: false,
-				CreatedAt:  now,
-			}, nil
-		}
-		return nil, nil
-	})
-
-	if err != nil {
-		respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", "Failed to create testimonial")
-		return
-	}
-
-	respondJSON(w, http.StatusCreated, models.APIResponse{
-		Success: true,
-		Data:    result,
+		Data:    stats,
 	})
 }
 
@@ -1069,16 +853,52 @@ func respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func respondError(w http.ResponseWriter, status int, code, message string) {
+func respondError(w http.ResponseWriter, status int, code, message string, args ...string) {
 	respondJSON(w, status, models.APIResponse{
 		Success: false,
 		Error: &models.APIError{
-			Code:    code,
-			Message: message,
+			Code:      code,
+			Message:   i18n.Localize(w, message, args...),
+			RequestID: w.Header().Get("X-Request-ID"),
 		},
 	})
 }
 
+// respondDatabaseError responds with 503 when the database circuit breaker
+// is open, 504 when the request deadline passed and a generic 500 otherwise
+func respondDatabaseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, database.ErrCircuitOpen):
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Database temporarily unavailable, please retry later")
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		respondError(w, http.StatusGatewayTimeout, "TIMEOUT", "The request took too long to complete")
+		return
+	}
+	respondError(w, http.StatusInternalServerError, "DATABASE_ERROR", message)
+}
+
+// currentUserID returns the authenticated user's ID, or "" for anonymous
+// requests. It only trusts the identity the auth middleware verified, never
+// headers the client can set.
+func currentUserID(r *http.Request) string {
+	return middleware.UserIDFromContext(r.Context())
+}
+
+// isAdmin reports whether the request was made by an administrator
+func isAdmin(r *http.Request) bool {
+	return middleware.HasRole(r.Context(), middleware.AdminRole)
+}
+
+// tenantCacheKey scopes a cache key to the tenant database selected for ctx,
+// so tenants never see each other's cached results
+func tenantCacheKey(ctx context.Context, key string) string {
+	if name := database.DatabaseFromContext(ctx); name != "" {
+		return name + "/" + key
+	}
+	return key
+}
+
 func nilIfEmpty(s string) interface{} {
 	if s == "" {
 		return nil
@@ -1086,6 +906,13 @@ func nilIfEmpty(s string) interface{} {
 	return s
 }
 
+func boolOrNil(b *bool) interface{} {
+	if b == nil {
+		return nil
+	}
+	return *b
+}
+
 func getPaginationParams(r *http.Request) models.PaginationParams {
 	params := models.DefaultPagination()
 
@@ -4,15 +4,28 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/middleware"
 	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// asUser returns r as made by the authenticated user userID
+func asUser(r *http.Request, userID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+}
+
 // MockDBClient is a mock implementation of the DBClient interface for testing
 type MockDBClient struct {
 	ExecuteReadFunc  func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error)
@@ -166,34 +179,75 @@ func TestLogout(t *testing.T) {
 	}
 }
 
-func TestRefreshToken(t *testing.T) {
+func TestLogout_RevokesToken(t *testing.T) {
 	handler := NewHandler(nil)
+	revocations := auth.NewRevocations(cache.NewLRU(10))
+	handler.SetRevocations(revocations)
 
-	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer opaque-token")
 	w := httptest.NewRecorder()
 
-	handler.RefreshToken(w, req)
+	handler.Logout(w, req)
 
 	if w.Code != http.StatusOK {
-		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
+	if revoked, _ := revocations.Revoked(context.Background(), "opaque-token"); !revoked {
+		t.Error("expected the token to be revoked")
+	}
+}
 
-	var response models.APIResponse
+func TestRefreshToken(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Email: "ann@example.org"}, "")
+	db.AddUser(models.User{ID: "u2", Email: "bob@example.org"}, "")
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	handler.SetTokenSecret("test-secret")
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.RefreshRequest{RefreshToken: token})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/refresh", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		handler.RefreshToken(w, req)
+		return w
+	}
+
+	tokens, err := handler.issueTokens("u1", "ann@example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := refresh(tokens.RefreshToken)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var response struct{ Data models.AuthTokens }
 	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
 		t.Fatalf("failed to decode response: %v", err)
 	}
-
-	if !response.Success {
-		t.Error("expected success to be true")
+	claims, err := middleware.ParseToken("test-secret", response.Data.AccessToken)
+	if err != nil || claims.UserID != "u1" || middleware.IsRefreshToken(claims) {
+		t.Errorf("expected a new access token for u1, got %+v and %v", claims, err)
 	}
 
-	tokens, ok := response.Data.(map[string]interface{})
-	if !ok {
-		t.Fatal("expected data to be tokens object")
+	if w := refresh(tokens.AccessToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an access token to be refused, got %d", w.Code)
+	}
+	if w := refresh("not-a-token"); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an invalid token to be refused, got %d", w.Code)
+	}
+	if w := refresh(""); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected a missing token to be rejected, got %d", w.Code)
 	}
 
-	if tokens["accessToken"] == nil || tokens["refreshToken"] == nil {
-		t.Error("expected tokens to be present")
+	db.Repositories().Users.Suspend(context.Background(), "u2", true, "spam", "admin", time.Now())
+	suspended, _ := handler.issueTokens("u2", "bob@example.org")
+	if w := refresh(suspended.RefreshToken); w.Code != http.StatusForbidden {
+		t.Errorf("expected a suspended user to be refused, got %d", w.Code)
+	}
+	deleted, _ := handler.issueTokens("gone", "gone@example.org")
+	if w := refresh(deleted.RefreshToken); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected a deleted user to be refused, got %d", w.Code)
 	}
 }
 
@@ -293,3 +347,166 @@ func TestGetPaginationParams(t *testing.T) {
 		})
 	}
 }
+
+func TestGetActs_CircuitOpen(t *testing.T) {
+	mockDB := &MockDBClient{
+		ExecuteReadFunc: func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+			return nil, database.ErrCircuitOpen
+		},
+	}
+	handler := NewHandler(mockDB)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetActs(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+
+	var response models.APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Error == nil || response.Error.Code != "SERVICE_UNAVAILABLE" {
+		t.Errorf("expected SERVICE_UNAVAILABLE error, got %+v", response.Error)
+	}
+}
+
+func TestHealthCheck_Draining(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	handler.Drain()
+
+	w := httptest.NewRecorder()
+	handler.HealthCheck(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+
+	var response map[string]interface{}
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusServiceUnavailable || response["status"] != "draining" {
+		t.Errorf("expected 503 draining, got %d %v", w.Code, response)
+	}
+}
+
+func TestRespondError_IncludesRequestID(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("X-Request-ID", "0190f1e2-7c3a-7def-8abc-0123456789ab")
+
+	respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+
+	var response models.APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Error == nil || response.Error.RequestID != "0190f1e2-7c3a-7def-8abc-0123456789ab" {
+		t.Errorf("expected request ID in error, got %+v", response.Error)
+	}
+}
+
+func TestCurrentUserID_IgnoresHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/testimonials/t1", nil)
+	req.Header.Set("X-User-ID", "u1")
+	if got := currentUserID(req); got != "" {
+		t.Errorf("expected a client-set header to be ignored, got %q", got)
+	}
+	if got := currentUserID(asUser(req, "u2")); got != "u2" {
+		t.Errorf("expected the authenticated user, got %q", got)
+	}
+}
+
+// stubUsers is a UserRepository returning canned results
+type stubUsers struct {
+	repository.UserRepository
+	user *models.User
+	err  error
+}
+
+func (s *stubUsers) Get(ctx context.Context, id string) (*models.User, error) {
+	return s.user, s.err
+}
+
+func TestGetUser_Repository(t *testing.T) {
+	tests := []struct {
+		name         string
+		users        *stubUsers
+		expectStatus int
+	}{
+		{"found", &stubUsers{user: &models.User{ID: "u1", Name: "Jane"}}, http.StatusOK},
+		{"not found", &stubUsers{err: repository.ErrUserNotFound}, http.StatusNotFound},
+		{"database error", &stubUsers{err: errors.New("boom")}, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockDBClient{})
+			handler.users = tt.users
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users/u1", nil)
+			req.SetPathValue("id", "u1")
+			w := httptest.NewRecorder()
+
+			handler.GetUser(w, req)
+
+			if w.Code != tt.expectStatus {
+				t.Errorf("expected status %d, got %d", tt.expectStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestRegisterLoginAndActFlow(t *testing.T) {
+	db := databasetest.New()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	post := func(h http.HandlerFunc, path, body, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if userID != "" {
+			req = asUser(req, userID)
+		}
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+
+	w := post(handler.Register, "/api/v1/auth/register", `{"email":"jane@example.org","password":"secret-password","name":"Jane"}`, "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+	if w := post(handler.Register, "/api/v1/auth/register", `{"email":"jane@example.org","password":"secret-password","name":"Jane"}`, ""); w.Code != http.StatusConflict {
+		t.Errorf("duplicate register: expected %d, got %d", http.StatusConflict, w.Code)
+	}
+	if w := post(handler.Login, "/api/v1/auth/login", `{"email":"jane@example.org","password":"wrong-password"}`, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("bad login: expected %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = post(handler.Login, "/api/v1/auth/login", `{"email":"jane@example.org","password":"secret-password"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: expected %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var login struct {
+		Data struct {
+			User models.User `json:"user"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&login); err != nil {
+		t.Fatal(err)
+	}
+	userID := login.Data.User.ID
+
+	w = post(handler.CreateAct, "/api/v1/acts", `{"title":"Paid for coffee","description":"Bought a stranger a coffee","type":"goods","category":"food"}`, userID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create act: expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+userID, nil)
+	req.SetPathValue("id", userID)
+	w = httptest.NewRecorder()
+	handler.GetUser(w, req)
+
+	var user struct {
+		Data models.User `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Data.Stats.ActsGiven != 1 {
+		t.Errorf("expected the new act to count towards the user's stats, got %+v", user.Data.Stats)
+	}
+}
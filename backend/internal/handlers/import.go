@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// ImportUsers handles POST /api/v1/admin/import/users. Users are written in
+// batches and merged on their IDs, so an import that fails part way can be
+// sent again.
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	var req models.ImportUsersRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	now := time.Now().UTC()
+	users := make([]repository.BatchUser, len(req.Users))
+	for i, u := range req.Users {
+		users[i] = repository.BatchUser{
+			User: models.User{
+				ID:         u.ID,
+				Email:      u.Email,
+				Name:       u.Name,
+				Avatar:     u.Avatar,
+				Bio:        u.Bio,
+				Location:   u.Location,
+				IsVerified: u.IsVerified,
+				CreatedAt:  importTime(u.CreatedAt, now),
+				UpdatedAt:  now,
+			},
+			PasswordHash: u.PasswordHash,
+		}
+	}
+
+	written, err := h.users.CreateBatch(r.Context(), users)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to import users")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.ImportResult{Imported: written, Skipped: len(users) - written},
+	})
+}
+
+// ImportActs handles POST /api/v1/admin/import/acts. Acts whose giver does
+// not exist are skipped and counted in the response.
+func (h *Handler) ImportActs(w http.ResponseWriter, r *http.Request) {
+	var req models.ImportActsRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	now := time.Now().UTC()
+	acts := make([]models.Act, len(req.Acts))
+	for i, a := range req.Acts {
+		status := a.Status
+		if status == "" {
+			status = models.ActStatusPending
+		}
+		acts[i] = models.Act{
+			ID:          a.ID,
+			Title:       a.Title,
+			Description: a.Description,
+			Type:        a.Type,
+			Category:    a.Category,
+			Value:       a.Value,
+			Currency:    a.Currency,
+			Status:      status,
+			GiverID:     a.GiverID,
+			ReceiverID:  a.ReceiverID,
+			Location:    a.Location,
+			IsAnonymous: a.IsAnonymous,
+			CreatedAt:   importTime(a.CreatedAt, now),
+			UpdatedAt:   now,
+			CompletedAt: a.CompletedAt,
+		}
+	}
+
+	written, err := h.acts.CreateBatch(r.Context(), acts)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to import acts")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.ImportResult{Imported: written, Skipped: len(acts) - written},
+	})
+}
+
+// importTime keeps an imported timestamp, defaulting to now when it is unset
+func importTime(t, now time.Time) time.Time {
+	if t.IsZero() {
+		return now
+	}
+	return t.UTC()
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestImport_UsersAndActs(t *testing.T) {
+	db := databasetest.New()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	post := func(h http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/import", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+	result := func(w *httptest.ResponseRecorder) models.ImportResult {
+		var resp struct {
+			Data models.ImportResult `json:"data"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data
+	}
+
+	w := post(handler.ImportUsers, `{"users":[
+		{"id":"u1","email":"ann@example.org","passwordHash":"hash","name":"Ann"},
+		{"id":"u2","email":"bob@example.org","passwordHash":"hash","name":"Bob"}
+	]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import users: expected %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if got := result(w); got.Imported != 2 || got.Skipped != 0 {
+		t.Errorf("import users: unexpected result %+v", got)
+	}
+
+	w = post(handler.ImportActs, `{"acts":[
+		{"id":"a1","title":"Paid for lunch","type":"goods","category":"food","giverId":"u1","receiverId":"u2"},
+		{"id":"a2","title":"Fixed a bike","type":"service","category":"repair","giverId":"missing"}
+	]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("import acts: expected %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	if got := result(w); got.Imported != 1 || got.Skipped != 1 {
+		t.Errorf("import acts: unexpected result %+v", got)
+	}
+
+	act, err := db.Repositories().Acts.Get(t.Context(), "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Status != models.ActStatusPending || act.CreatedAt.IsZero() {
+		t.Errorf("expected defaults on the imported act, got %+v", act)
+	}
+}
+
+func TestImport_Validation(t *testing.T) {
+	handler := NewHandlerWithRepositories(&MockDBClient{}, databasetest.New().Repositories())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/import/users",
+		bytes.NewBufferString(`{"users":[{"id":"u1","email":"not-an-email","passwordHash":"hash","name":"Ann"}]}`))
+	w := httptest.NewRecorder()
+	handler.ImportUsers(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"payforwardnow/internal/jobs"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// JobExport is the type of the jobs generating the exports requested with
+// CreateExport
+const JobExport = "exports.generate"
+
+// jobStatuses are the statuses jobs can be filtered by
+var jobStatuses = []models.JobStatus{models.JobQueued, models.JobRunning, models.JobSucceeded, models.JobFailed}
+
+// SetJobs sets the queue background jobs are enqueued on, and registers
+// the export job, which writes its files to exportDir. Without a queue
+// exports can't be requested.
+func (h *Handler) SetJobs(q *jobs.Queue, exportDir string) {
+	h.queue = q
+	h.exportDir = exportDir
+	q.Register(JobExport, h.generateExport)
+}
+
+// ExpireActs marks the acts left pending for longer than after expired,
+// for the act expiration job
+func (h *Handler) ExpireActs(ctx context.Context, after time.Duration) error {
+	now := time.Now().UTC()
+	expired, err := h.acts.Expire(ctx, now.Add(-after), now)
+	if expired > 0 {
+		slog.InfoContext(ctx, "Expired pending acts", "count", expired)
+	}
+	return err
+}
+
+// PruneJobs deletes the jobs that finished more than retention ago and the
+// export files written before then
+func (h *Handler) PruneJobs(ctx context.Context, retention time.Duration) error {
+	before := time.Now().UTC().Add(-retention)
+	pruned, err := h.jobs.Prune(ctx, before)
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		slog.InfoContext(ctx, "Pruned finished jobs", "count", pruned)
+	}
+	if h.exportDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(h.exportDir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(h.exportDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetJobs handles GET /api/v1/admin/jobs, listing background jobs, most
+// recently updated first, optionally filtered by ?status= and ?type=
+func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	filter := repository.JobFilter{
+		Status: models.JobStatus(r.URL.Query().Get("status")),
+		Type:   r.URL.Query().Get("type"),
+	}
+	if filter.Status != "" && !slices.Contains(jobStatuses, filter.Status) {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "status must be one of queued, running, succeeded or failed")
+		return
+	}
+
+	params := getPaginationParams(r)
+	list, total, err := h.jobs.List(r.Context(), filter, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch jobs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    list,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// GetJobSchedules handles GET /api/v1/admin/jobs/schedules, the scheduled
+// job types with their cron expression and next occurrence
+func (h *Handler) GetJobSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules := []models.JobSchedule{}
+	if h.queue != nil {
+		schedules = h.queue.Schedules()
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    schedules,
+	})
+}
+
+// GetJob handles GET /api/v1/admin/jobs/{id}
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrJobNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Job not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch job")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// RetryJob handles POST /api/v1/admin/jobs/{id}/retry, queueing a failed
+// job to run again with its attempts reset
+func (h *Handler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Retry(r.Context(), r.PathValue("id"), time.Now().UTC())
+	switch {
+	case errors.Is(err, repository.ErrJobNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Job not found")
+		return
+	case errors.Is(err, repository.ErrJobNotFailed):
+		respondError(w, http.StatusConflict, "CONFLICT", "Only failed jobs can be retried")
+		return
+	case err != nil:
+		respondDatabaseError(w, err, "Failed to retry job")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// CreateExport handles POST /api/v1/admin/exports, enqueueing a job that
+// writes an export of users or acts, like ExportUsers and ExportActs, to a
+// file. The export is downloaded with GetExport once the job succeeds.
+func (h *Handler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateExportRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	if req.Format == "" {
+		req.Format = "ndjson"
+	}
+	if h.queue == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Background jobs are disabled")
+		return
+	}
+
+	job, err := h.queue.Enqueue(r.Context(), JobExport, req)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to enqueue export")
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/admin/exports/"+job.ID)
+	respondJSON(w, http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// GetExport handles GET /api/v1/admin/exports/{id}, downloading the file
+// written by an export job. A job still queued or running answers 202.
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrJobNotFound) || (err == nil && job.Type != JobExport) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Export not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch export")
+		return
+	}
+
+	switch job.Status {
+	case models.JobQueued, models.JobRunning:
+		w.Header().Set("Retry-After", "5")
+		respondJSON(w, http.StatusAccepted, models.APIResponse{
+			Success: true,
+			Data:    map[string]string{"status": string(job.Status)},
+		})
+		return
+	case models.JobFailed:
+		respondError(w, http.StatusInternalServerError, "EXPORT_FAILED", "Failed to generate export")
+		return
+	}
+
+	var result models.ExportResult
+	if err := json.Unmarshal(job.Result, &result); err != nil || result.File == "" {
+		respondError(w, http.StatusInternalServerError, "EXPORT_FAILED", "Failed to generate export")
+		return
+	}
+	f, err := os.Open(filepath.Join(h.exportDir, filepath.Base(result.File)))
+	if err != nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Export file no longer available")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read export")
+		return
+	}
+
+	contentType := "application/x-ndjson"
+	if result.Format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, result.Kind, result.Format))
+	// Large files take longer to send than the server's write deadline
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to read export")
+		return
+	}
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+// generateExport runs an export job, writing the rows to a temporary file
+// in the export directory that is renamed once complete
+func (h *Handler) generateExport(ctx context.Context, job *models.Job) (any, error) {
+	var req models.CreateExportRequest
+	if err := json.Unmarshal(job.Payload, &req); err != nil {
+		return nil, jobs.Permanent(fmt.Errorf("decoding export request: %w", err))
+	}
+	if _, ok := exportHeaders[req.Kind]; !ok {
+		return nil, jobs.Permanent(fmt.Errorf("unknown export %q", req.Kind))
+	}
+	if req.Format != "csv" {
+		req.Format = "ndjson"
+	}
+	if err := os.MkdirAll(h.exportDir, 0o750); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s.%s", req.Kind, job.ID, req.Format)
+	f, err := os.CreateTemp(h.exportDir, name+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	buffered := bufio.NewWriter(f)
+	rows := 0
+	var write func(value any, row []string) error
+	var csvOut *csv.Writer
+	if req.Format == "csv" {
+		csvOut = csv.NewWriter(buffered)
+		if err := csvOut.Write(exportHeaders[req.Kind]); err != nil {
+			return nil, err
+		}
+		write = func(_ any, row []string) error {
+			rows++
+			return csvOut.Write(row)
+		}
+	} else {
+		encoder := json.NewEncoder(buffered)
+		write = func(value any, _ []string) error {
+			rows++
+			return encoder.Encode(value)
+		}
+	}
+	if err := h.streamExport(ctx, req.Kind, write); err != nil {
+		return nil, err
+	}
+	if csvOut != nil {
+		csvOut.Flush()
+		if err := csvOut.Error(); err != nil {
+			return nil, err
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(f.Name(), filepath.Join(h.exportDir, name)); err != nil {
+		return nil, err
+	}
+	return models.ExportResult{Kind: req.Kind, Format: req.Format, File: name, Rows: rows}, nil
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/jobs"
+	"payforwardnow/internal/models"
+)
+
+func TestExportJobs(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Email: "ada@example.com", Name: "Ada"}, "")
+	db.AddUser(models.User{ID: "u2", Email: "grace@example.com", Name: "Grace"}, "")
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+	queue := jobs.New(repos.Jobs, jobs.Options{})
+	handler.SetJobs(queue, t.TempDir())
+
+	w := httptest.NewRecorder()
+	handler.CreateExport(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/exports", strings.NewReader(`{"kind":"chains"}`)))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected %d for an unknown export, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.CreateExport(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/exports", strings.NewReader(`{"kind":"users","format":"csv"}`)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var created struct{ Data models.Job }
+	json.Unmarshal(w.Body.Bytes(), &created)
+	id := created.Data.ID
+
+	download := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/exports/"+id, nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.GetExport(w, req)
+		return w
+	}
+	if w := download(); w.Code != http.StatusAccepted || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected %d while the export is queued, got %d", http.StatusAccepted, w.Code)
+	}
+
+	if ran, err := queue.RunNext(ctx); !ran || err != nil {
+		t.Fatalf("expected the export to run, got %v and %v", ran, err)
+	}
+	w = download()
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("expected the CSV export, got %d: %s", w.Code, w.Body.String())
+	}
+	if lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "id,email") {
+		t.Errorf("expected a header and 2 rows, got %q", w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/jobs?status=succeeded&type="+JobExport, nil)
+	w = httptest.NewRecorder()
+	handler.GetJobs(w, req)
+	var list struct {
+		Data []models.Job
+		Meta models.APIMeta
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if list.Meta.Total != 1 || list.Data[0].ID != id || !strings.Contains(string(list.Data[0].Result), `"rows":2`) {
+		t.Errorf("expected the succeeded export job, got %s", w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	handler.GetJobs(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/jobs?status=done", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an unknown status, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	for path, expected := range map[string]int{id: http.StatusConflict, "missing": http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/jobs/"+path+"/retry", nil)
+		req.SetPathValue("id", path)
+		w := httptest.NewRecorder()
+		handler.RetryJob(w, req)
+		if w.Code != expected {
+			t.Errorf("retrying %s: expected %d, got %d", path, expected, w.Code)
+		}
+	}
+
+	// Finished jobs and their files are pruned after the retention period
+	if err := handler.PruneJobs(ctx, -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if w := download(); w.Code != http.StatusNotFound {
+		t.Errorf("expected the pruned export to be gone, got %d", w.Code)
+	}
+}
+
+func TestExpireActs(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1"}, "")
+	now := time.Now().UTC()
+	db.AddAct(models.Act{ID: "stale", GiverID: "u1", Status: models.ActStatusPending, CreatedAt: now.Add(-48 * time.Hour)})
+	db.AddAct(models.Act{ID: "fresh", GiverID: "u1", Status: models.ActStatusPending, CreatedAt: now})
+	db.AddAct(models.Act{ID: "done", GiverID: "u1", Status: models.ActStatusCompleted, CreatedAt: now.Add(-48 * time.Hour)})
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+
+	if err := handler.ExpireActs(ctx, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for id, expected := range map[string]models.ActStatus{
+		"stale": models.ActStatusExpired,
+		"fresh": models.ActStatusPending,
+		"done":  models.ActStatusCompleted,
+	} {
+		if act, _ := repos.Acts.Get(ctx, id); act.Status != expected {
+			t.Errorf("expected %s to be %s, got %s", id, expected, act.Status)
+		}
+	}
+	events, _ := db.Outbox().Pending(ctx, 10)
+	if len(events) != 1 || events[0].Type != models.DomainActExpired || events[0].AggregateID != "stale" {
+		t.Errorf("expected an act.expired event, got %+v", events)
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/stream"
+	"payforwardnow/internal/webhooks"
+
+	"golang.org/x/net/websocket"
+)
+
+// Topic prefixes of live updates, followed by a chain or user ID
+const (
+	chainTopicPrefix = "chain:"
+	userTopicPrefix  = "user:"
+)
+
+const (
+	// liveMaxTopics bounds the topics one connection may subscribe to
+	liveMaxTopics = 50
+	// liveMaxMessageSize bounds the messages clients send, in bytes
+	liveMaxMessageSize = 4096
+	// livePingInterval is how often idle connections are pinged so proxies
+	// don't close them and dead clients are noticed
+	livePingInterval = 30 * time.Second
+	// liveWriteTimeout bounds each write, so a stalled client can't hold
+	// its connection open
+	liveWriteTimeout = 10 * time.Second
+)
+
+// chainMilestones are the numbers of acts in a chain that are pushed as
+// milestones; past the last one, every multiple of it is
+var chainMilestones = []int{10, 25, 50, 100, 250, 500, 1000}
+
+func chainTopic(chainID string) string { return chainTopicPrefix + chainID }
+
+func userTopic(userID string) string { return userTopicPrefix + userID }
+
+// isChainMilestone reports whether a chain reaching actsCount acts is a
+// milestone
+func isChainMilestone(actsCount int) bool {
+	last := chainMilestones[len(chainMilestones)-1]
+	if actsCount >= last {
+		return actsCount%last == 0
+	}
+	return slices.Contains(chainMilestones, actsCount)
+}
+
+// publishActCreated publishes a new act on the topics of its chain, its
+// receiver and, unless it is anonymous, its giver, along with a milestone
+// if it brings chain, as read before it was created, to one, and emits the
+// act.created and chain.extended webhook events. The giver of an anonymous
+// act is left out of what is published.
+func (h *Handler) publishActCreated(ctx context.Context, act *models.Act, chain *models.Chain) {
+	published := act
+	var topics []string
+	if act.ChainID != "" {
+		topics = append(topics, chainTopic(act.ChainID))
+	}
+	if act.IsAnonymous {
+		hidden := *act
+		hidden.GiverID = ""
+		published = &hidden
+	} else {
+		topics = append(topics, userTopic(act.GiverID))
+	}
+	if act.ReceiverID != "" && act.ReceiverID != act.GiverID {
+		topics = append(topics, userTopic(act.ReceiverID))
+	}
+	h.events.Publish(stream.Event{Type: EventActCreated, Topics: topics, Data: published})
+	h.emitWebhook(ctx, webhooks.EventActCreated, published)
+
+	if chain == nil {
+		return
+	}
+	actsCount := chain.ActsCount + 1
+	if isChainMilestone(actsCount) {
+		h.events.Publish(stream.Event{
+			Type:   EventChainMilestone,
+			Topics: []string{chainTopic(chain.ID)},
+			Data:   models.ChainMilestone{ChainID: chain.ID, ActID: act.ID, ActsCount: actsCount},
+		})
+	}
+	h.emitWebhook(ctx, webhooks.EventChainExtended, models.ChainExtension{ChainID: chain.ID, ActID: act.ID, ActsCount: actsCount})
+}
+
+// LiveUpdates handles GET /api/v1/ws, a WebSocket pushing new acts,
+// reactions and chain milestones on the topics the client subscribes to:
+// "chain:<id>" for a chain and "user:<id>" for the acts a user gave or
+// received and the reactions to their testimonials. Topics can be given as
+// a comma-separated ?topics= and changed with {"type":"subscribe"} and
+// {"type":"unsubscribe"} messages listing topics; each is answered with a
+// "subscribed" message listing the connection's topics. Everything pushed
+// is public, so any origin may connect.
+func (h *Handler) LiveUpdates(w http.ResponseWriter, r *http.Request) {
+	var initial []string
+	if raw := r.URL.Query().Get("topics"); raw != "" {
+		initial = strings.Split(raw, ",")
+	}
+	topics := make(map[string]bool)
+	if err := subscribeLive(topics, initial); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+		return
+	}
+	if r.ProtoMajor != 1 || !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		respondError(w, http.StatusUpgradeRequired, "UPGRADE_REQUIRED", "This endpoint requires a WebSocket connection")
+		return
+	}
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		h.serveLive(r.Context(), ws, topics)
+	}}
+	server.ServeHTTP(hijacker{w}, r)
+}
+
+// serveLive pushes the events on topics to ws and applies the client's
+// subscription changes until either side closes the connection
+func (h *Handler) serveLive(ctx context.Context, ws *websocket.Conn, topics map[string]bool) {
+	metrics.LiveConnections.Inc()
+	defer metrics.LiveConnections.Dec()
+
+	// The server's read and write timeouts don't apply to a connection
+	// that stays open
+	ws.SetDeadline(time.Time{})
+	ws.MaxPayloadBytes = liveMaxMessageSize
+
+	events := h.events.Subscribe()
+	defer h.events.Unsubscribe(events)
+
+	done := make(chan struct{})
+	defer close(done)
+	requests := make(chan liveInput)
+	go readLive(ws, requests, done)
+
+	send := func(msg models.LiveMessage) error {
+		ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		return websocket.JSON.Send(ws, msg)
+	}
+	if err := send(liveSubscriptions(topics)); err != nil {
+		return
+	}
+
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			ws.PayloadType = websocket.PingFrame
+			_, err = ws.Write(nil)
+		case in, ok := <-requests:
+			if !ok {
+				return
+			}
+			err = send(applyLiveRequest(topics, in))
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			var matched []string
+			for _, topic := range event.Topics {
+				if topics[topic] {
+					matched = append(matched, topic)
+				}
+			}
+			if len(matched) > 0 {
+				err = send(models.LiveMessage{Type: event.Type, Topics: matched, Data: event.Data})
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.DebugContext(ctx, "Closing live updates connection", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// liveInput is a message read from a client, or the reason it couldn't be
+// decoded
+type liveInput struct {
+	request models.LiveRequest
+	err     error
+}
+
+// readLive passes the messages read from ws to requests until the
+// connection fails or done is closed, then closes requests
+func readLive(ws *websocket.Conn, requests chan<- liveInput, done <-chan struct{}) {
+	defer close(requests)
+	for {
+		var in liveInput
+		err := websocket.JSON.Receive(ws, &in.request)
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr) || errors.As(err, &typeErr):
+			in.err = errors.New("messages must be JSON objects with a type and topics")
+		case err != nil:
+			return
+		}
+		select {
+		case requests <- in:
+		case <-done:
+			return
+		}
+	}
+}
+
+// applyLiveRequest changes topics as the client asked and returns the
+// answer: the resulting subscriptions, or an error leaving them unchanged
+func applyLiveRequest(topics map[string]bool, in liveInput) models.LiveMessage {
+	err := in.err
+	if err == nil {
+		switch in.request.Type {
+		case "subscribe":
+			err = subscribeLive(topics, in.request.Topics)
+		case "unsubscribe":
+			for _, topic := range in.request.Topics {
+				delete(topics, topic)
+			}
+		default:
+			err = fmt.Errorf("unknown message type %q, expected subscribe or unsubscribe", in.request.Type)
+		}
+	}
+	if err != nil {
+		return models.LiveMessage{Type: "error", Error: err.Error()}
+	}
+	return liveSubscriptions(topics)
+}
+
+// subscribeLive adds requested to topics if they are all valid and fit
+// within the limit, and otherwise leaves topics unchanged
+func subscribeLive(topics map[string]bool, requested []string) error {
+	added := 0
+	for i, topic := range requested {
+		id, ok := strings.CutPrefix(topic, chainTopicPrefix)
+		if !ok {
+			id, ok = strings.CutPrefix(topic, userTopicPrefix)
+		}
+		if !ok || id == "" || len(id) > 100 {
+			return fmt.Errorf("invalid topic %q, expected chain:<id> or user:<id>", topic)
+		}
+		if !topics[topic] && !slices.Contains(requested[:i], topic) {
+			added++
+		}
+	}
+	if len(topics)+added > liveMaxTopics {
+		return fmt.Errorf("a connection can subscribe to at most %d topics", liveMaxTopics)
+	}
+	for _, topic := range requested {
+		topics[topic] = true
+	}
+	return nil
+}
+
+// liveSubscriptions returns the message listing topics, sorted
+func liveSubscriptions(topics map[string]bool) models.LiveMessage {
+	list := make([]string, 0, len(topics))
+	for topic := range topics {
+		list = append(list, topic)
+	}
+	slices.Sort(list)
+	return models.LiveMessage{Type: "subscribed", Topics: list}
+}
+
+// hijacker lets the WebSocket server take over connections whose response
+// writer is wrapped by middleware, which only exposes Hijack through Unwrap
+type hijacker struct {
+	http.ResponseWriter
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
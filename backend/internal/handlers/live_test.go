@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+
+	"golang.org/x/net/websocket"
+)
+
+// liveMessage is a models.LiveMessage with its data left encoded
+type liveMessage struct {
+	Type   string          `json:"type"`
+	Topics []string        `json:"topics"`
+	Data   json.RawMessage `json:"data"`
+	Error  string          `json:"error"`
+}
+
+func TestLiveUpdates(t *testing.T) {
+	db := databasetest.New()
+	for _, id := range []string{"giver", "receiver", "fan"} {
+		db.AddUser(models.User{ID: id, Name: id}, "")
+	}
+	// The chain is one act short of its first milestone
+	var actIDs []string
+	for i := range 9 {
+		id := fmt.Sprintf("a%d", i)
+		db.AddAct(models.Act{ID: id, GiverID: "giver", ChainID: "c1"})
+		actIDs = append(actIDs, id)
+	}
+	db.AddChain(models.Chain{ID: "c1", StarterID: "giver"}, actIDs...)
+	db.AddTestimonial(models.Testimonial{ID: "t1", UserID: "receiver", Story: "A story", IsApproved: true})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	server := httptest.NewServer(http.HandlerFunc(handler.LiveUpdates))
+	defer server.Close()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/v1/ws?topics=chain:c1", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	receive := func(expected string) liveMessage {
+		t.Helper()
+		var msg liveMessage
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Type != expected {
+			t.Fatalf("expected a %s message, got %+v", expected, msg)
+		}
+		return msg
+	}
+	if msg := receive("subscribed"); strings.Join(msg.Topics, ",") != "chain:c1" {
+		t.Errorf("expected to be subscribed to chain:c1, got %v", msg.Topics)
+	}
+
+	body := `{"title":"Fixed a bike","description":"Replaced the chain","type":"service","category":"other","receiverId":"receiver","chainId":"c1","isAnonymous":true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(body))
+	req = asUser(req, "giver")
+	w := httptest.NewRecorder()
+	handler.CreateAct(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var act models.Act
+	json.Unmarshal(receive(EventActCreated).Data, &act)
+	if act.ChainID != "c1" || act.GiverID != "" {
+		t.Errorf("expected the anonymous act without its giver, got %+v", act)
+	}
+	var milestone models.ChainMilestone
+	json.Unmarshal(receive(EventChainMilestone).Data, &milestone)
+	if milestone.ActsCount != 10 || milestone.ActID != act.ID {
+		t.Errorf("expected the tenth act to be a milestone, got %+v", milestone)
+	}
+
+	websocket.JSON.Send(ws, models.LiveRequest{Type: "subscribe", Topics: []string{"chain:", "user:receiver"}})
+	if msg := receive("error"); !strings.Contains(msg.Error, `"chain:"`) {
+		t.Errorf("expected the invalid topic to be rejected, got %q", msg.Error)
+	}
+	websocket.JSON.Send(ws, models.LiveRequest{Type: "subscribe", Topics: []string{"user:receiver"}})
+	receive("subscribed")
+	websocket.JSON.Send(ws, models.LiveRequest{Type: "unsubscribe", Topics: []string{"chain:c1"}})
+	if msg := receive("subscribed"); strings.Join(msg.Topics, ",") != "user:receiver" {
+		t.Errorf("expected to be subscribed to user:receiver, got %v", msg.Topics)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/testimonials/t1/reactions", nil)
+	req.SetPathValue("id", "t1")
+	req = asUser(req, "fan")
+	handler.ToggleReaction(httptest.NewRecorder(), req)
+
+	msg := receive(EventReactionToggled)
+	if string(msg.Data) != `{"testimonialId":"t1","reactionCount":1}` || strings.Join(msg.Topics, ",") != "user:receiver" {
+		t.Errorf("unexpected reaction update %+v", msg)
+	}
+}
+
+func TestLiveUpdatesRejectsPlainRequests(t *testing.T) {
+	handler := NewHandlerWithRepositories(&MockDBClient{}, databasetest.New().Repositories())
+
+	w := httptest.NewRecorder()
+	handler.LiveUpdates(w, httptest.NewRequest(http.MethodGet, "/api/v1/ws", nil))
+	if w.Code != http.StatusUpgradeRequired {
+		t.Errorf("expected %d, got %d", http.StatusUpgradeRequired, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.LiveUpdates(w, httptest.NewRequest(http.MethodGet, "/api/v1/ws?topics=acts", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an invalid topic, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestIsChainMilestone(t *testing.T) {
+	for n, expected := range map[int]bool{1: false, 10: true, 11: false, 250: true, 1000: true, 1500: false, 3000: true} {
+		if got := isChainMilestone(n); got != expected {
+			t.Errorf("isChainMilestone(%d) = %v, expected %v", n, got, expected)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	netmail "net/mail"
+	"time"
+
+	"payforwardnow/internal/mail"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// digestInterval is how often users with unread notifications get a digest
+const digestInterval = 7 * 24 * time.Hour
+
+// digestBatchSize bounds the digests queued per query
+const digestBatchSize = 100
+
+// SetMailer sets the mailer transactional emails are sent with. Without
+// one no email is sent.
+func (h *Handler) SetMailer(m *mail.Mailer) {
+	h.mailer = m
+}
+
+// emailActReceived emails the receiver of act in the background, naming
+// the giver unless the act is anonymous or they weren't signed in
+func (h *Handler) emailActReceived(ctx context.Context, act *models.Act, signedIn bool) {
+	if h.mailer == nil || act.ReceiverID == "" || act.ReceiverID == act.GiverID {
+		return
+	}
+	err := h.runInBackground(ctx, "act email", func(ctx context.Context) error {
+		receiver, err := h.users.Get(ctx, act.ReceiverID)
+		if errors.Is(err, repository.ErrUserNotFound) || (err == nil && !emailable(receiver.Email)) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		data := mail.ActReceivedData{Name: receiver.Name, ActTitle: act.Title, URL: h.mailer.Link("/acts/" + act.ID)}
+		if signedIn && !act.IsAnonymous {
+			if giver, err := h.users.Get(ctx, act.GiverID); err == nil {
+				data.GiverName = giver.Name
+			}
+		}
+
+		msg, err := h.mailer.Render(mail.TemplateActReceived, receiver.Email, data)
+		if err != nil {
+			return err
+		}
+		if err := h.mailer.Deliver(ctx, mail.TemplateActReceived, msg); err != nil && !errors.Is(err, mail.ErrSuppressed) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Dropped act received email", "act_id", act.ID, "error", err)
+	}
+}
+
+// emailable reports whether email can be sent to, which erased users'
+// placeholder addresses can't
+func emailable(email string) bool {
+	return email != "" && !repository.IsErasedEmail(email)
+}
+
+// SendWeeklyDigests queues a digest email for every user with unread
+// notifications who hasn't had one in the past week, for the digest job.
+// Users are marked as sent once their digest is queued, so a digest that
+// later fails to deliver isn't retried until the next week.
+func (h *Handler) SendWeeklyDigests(ctx context.Context) error {
+	if h.mailer == nil {
+		return nil
+	}
+	queued := 0
+	defer func() {
+		if queued > 0 {
+			slog.InfoContext(ctx, "Queued weekly digests", "count", queued)
+		}
+	}()
+
+	for {
+		now := time.Now().UTC()
+		due, err := h.notifications.DigestsDue(ctx, now.Add(-digestInterval), digestBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, d := range due {
+			if emailable(d.Email) {
+				err := h.mailer.Send(ctx, mail.TemplateWeeklyDigest, d.Email, mail.WeeklyDigestData{
+					Name:                 d.Name,
+					ActsReceived:         d.ActsReceived,
+					ChainsGrown:          d.ChainsGrown,
+					TestimonialsApproved: d.TestimonialsApproved,
+					URL:                  h.mailer.Link("/notifications"),
+				})
+				if err != nil {
+					// The queue is full or the server is stopping; the
+					// rest get theirs on the next run
+					return fmt.Errorf("queueing digest: %w", err)
+				}
+				queued++
+			}
+			if err := h.notifications.DigestSent(ctx, d.UserID, now); err != nil {
+				return err
+			}
+		}
+		if len(due) < digestBatchSize {
+			return nil
+		}
+	}
+}
+
+// GetEmailSuppressions handles GET /api/v1/admin/email/suppressions,
+// listing the addresses no email is sent to, newest first
+func (h *Handler) GetEmailSuppressions(w http.ResponseWriter, r *http.Request) {
+	params := getPaginationParams(r)
+	suppressions, total, err := h.suppressions.List(r.Context(), params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch suppressions")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    suppressions,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// SuppressEmail handles PUT /api/v1/admin/email/suppressions/{email},
+// stopping every email to the address
+func (h *Handler) SuppressEmail(w http.ResponseWriter, r *http.Request) {
+	email := r.PathValue("email")
+	if addr, err := netmail.ParseAddress(email); err != nil || addr.Address != email {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "Invalid email address")
+		return
+	}
+	var req models.SuppressEmailRequest
+	if r.ContentLength != 0 && !decodeAndValidate(w, r, &req) {
+		return
+	}
+	if req.Reason == "" {
+		req.Reason = "admin"
+	}
+
+	if err := h.suppressions.Suppress(r.Context(), email, req.Reason); err != nil {
+		respondDatabaseError(w, err, "Failed to suppress address")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Address suppressed"},
+	})
+}
+
+// UnsuppressEmail handles DELETE /api/v1/admin/email/suppressions/{email},
+// allowing emails to the address again
+func (h *Handler) UnsuppressEmail(w http.ResponseWriter, r *http.Request) {
+	removed, err := h.suppressions.Unsuppress(r.Context(), r.PathValue("email"))
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to remove suppression")
+		return
+	}
+	if !removed {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Address is not suppressed")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Suppression removed"},
+	})
+}
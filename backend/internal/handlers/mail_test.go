@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/mail"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/workers"
+)
+
+// outbox is a mail.Driver keeping what it sends
+type outbox struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (o *outbox) Send(_ context.Context, msg mail.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func (o *outbox) Name() string { return "outbox" }
+
+func (o *outbox) messages() []mail.Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]mail.Message(nil), o.sent...)
+}
+
+func newMailTestHandler(t *testing.T, db *databasetest.DB) (*Handler, *outbox, *workers.Pool) {
+	t.Helper()
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+	pool := workers.New("test", 1, 100)
+	handler.SetWorkers(pool)
+
+	driver := &outbox{}
+	mailer, err := mail.New(driver, repos.Suppressions, mail.Options{BaseURL: "https://payforward.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mailer.SetPool(pool)
+	handler.SetMailer(mailer)
+	return handler, driver, pool
+}
+
+func TestActReceivedEmail(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver", Name: "Grace", Email: "grace@example.com"}, "")
+	db.AddUser(models.User{ID: "receiver", Name: "Ada", Email: "ada@example.com"}, "")
+	handler, driver, pool := newMailTestHandler(t, db)
+
+	body := `{"title":"Fixed a bike","description":"Replaced the chain","type":"service","category":"other","receiverId":"receiver"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(body))
+	req = asUser(req, "giver")
+	w := httptest.NewRecorder()
+	handler.CreateAct(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	pool.Stop(context.Background())
+
+	sent := driver.messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected one email, got %d", len(sent))
+	}
+	if sent[0].To != "ada@example.com" || sent[0].Subject != "Grace did something kind for you" || !strings.Contains(sent[0].Text, "https://payforward.example/acts/") {
+		t.Errorf("unexpected email %+v", sent[0])
+	}
+}
+
+func TestSendWeeklyDigests(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Name: "Ada", Email: "ada@example.com"}, "")
+	db.AddUser(models.User{ID: "u2", Name: "Grace", Email: "grace@example.com"}, "")
+	handler, driver, pool := newMailTestHandler(t, db)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	handler.notifications.Create(ctx, []models.Notification{
+		{ID: "n1", UserID: "u1", Type: models.NotificationActReceived, CreatedAt: now},
+		{ID: "n2", UserID: "u1", Type: models.NotificationChainGrew, CreatedAt: now},
+		{ID: "n3", UserID: "u2", Type: models.NotificationActReceived, CreatedAt: now.Add(-8 * 24 * time.Hour)},
+	})
+	handler.suppressions.Suppress(ctx, "nobody@example.com", "admin")
+
+	if err := handler.SendWeeklyDigests(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// A second run within the week sends nothing more
+	if err := handler.SendWeeklyDigests(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pool.Stop(ctx)
+
+	sent := driver.messages()
+	if len(sent) != 1 {
+		t.Fatalf("expected one digest, got %d", len(sent))
+	}
+	if sent[0].To != "ada@example.com" || !strings.Contains(sent[0].Text, "1 act of kindness") || !strings.Contains(sent[0].Text, "1 time") {
+		t.Errorf("unexpected digest %+v", sent[0])
+	}
+}
+
+func TestEmailSuppressions(t *testing.T) {
+	db := databasetest.New()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	call := func(method, email, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/admin/email/suppressions/"+email, strings.NewReader(body))
+		req.SetPathValue("email", email)
+		w := httptest.NewRecorder()
+		switch method {
+		case http.MethodPut:
+			handler.SuppressEmail(w, req)
+		case http.MethodDelete:
+			handler.UnsuppressEmail(w, req)
+		}
+		return w
+	}
+
+	if w := call(http.MethodPut, "not-an-address", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an invalid address, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := call(http.MethodPut, "Ada@Example.com", `{"reason":"asked to stop"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	handler.GetEmailSuppressions(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/email/suppressions", nil))
+	if !strings.Contains(w.Body.String(), `"email":"ada@example.com","reason":"asked to stop"`) {
+		t.Errorf("expected the lowercased address in the list, got %s", w.Body.String())
+	}
+
+	if w := call(http.MethodDelete, "ada@example.com", ""); w.Code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := call(http.MethodDelete, "ada@example.com", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d removing an address that isn't suppressed, got %d", http.StatusNotFound, w.Code)
+	}
+}
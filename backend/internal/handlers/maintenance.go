@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/models"
+)
+
+// SetMaintenance attaches the maintenance toggle controlled through the
+// admin maintenance endpoints
+func (h *Handler) SetMaintenance(m *middleware.Maintenance) {
+	h.maintenance = m
+}
+
+// GetMaintenance handles GET /api/v1/admin/maintenance
+func (h *Handler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	if h.maintenance == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Maintenance mode is not available")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    maintenanceStatus(h.maintenance.State()),
+	})
+}
+
+// SetMaintenanceMode handles PUT /api/v1/admin/maintenance
+func (h *Handler) SetMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	if h.maintenance == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Maintenance mode is not available")
+		return
+	}
+
+	var req models.SetMaintenanceRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	h.maintenance.Set(*req.Enabled, req.Message, time.Duration(req.RetryAfterSeconds)*time.Second)
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    maintenanceStatus(h.maintenance.State()),
+	})
+}
+
+func maintenanceStatus(state middleware.MaintenanceState) models.MaintenanceStatus {
+	status := models.MaintenanceStatus{
+		Enabled:           state.Enabled,
+		Message:           state.Message,
+		RetryAfterSeconds: int(state.RetryAfter.Seconds()),
+	}
+	if state.Enabled {
+		since := state.Since
+		status.Since = &since
+	}
+	return status
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/models"
+)
+
+func TestSetMaintenanceMode(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	maintenance := middleware.NewMaintenance(false)
+	handler.SetMaintenance(maintenance)
+
+	body := `{"enabled": true, "message": "Upgrading", "retryAfterSeconds": 600}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/maintenance", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handler.SetMaintenanceMode(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var response struct {
+		Data models.MaintenanceStatus `json:"data"`
+	}
+	json.NewDecoder(w.Body).Decode(&response)
+	if !response.Data.Enabled || response.Data.RetryAfterSeconds != 600 || response.Data.Since == nil {
+		t.Errorf("unexpected status %+v", response.Data)
+	}
+	if !maintenance.State().Enabled {
+		t.Error("expected maintenance mode to be enabled")
+	}
+}
+
+func TestSetMaintenanceMode_RequiresEnabled(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	handler.SetMaintenance(middleware.NewMaintenance(false))
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/maintenance", bytes.NewBufferString(`{"message": "x"}`))
+	w := httptest.NewRecorder()
+
+	handler.SetMaintenanceMode(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", w.Code)
+	}
+}
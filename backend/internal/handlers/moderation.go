@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/webhooks"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const maxModerationNoteLength = 2000
+
+// moderationPage is one page of the moderation queue and the number of
+// testimonials matching the filters
+type moderationPage struct {
+	testimonials []models.Testimonial
+	total        int64
+}
+
+// GetModerationQueue handles GET /api/v1/admin/testimonials. It lists
+// testimonials with their review state, oldest first unless order=desc.
+func (h *Handler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params := getPaginationParams(r)
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = string(models.ModerationPending)
+	}
+	switch models.ModerationStatus(status) {
+	case models.ModerationPending, models.ModerationApproved, models.ModerationRejected, "all":
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "status must be one of pending, approved, rejected, all")
+		return
+	}
+
+	var flagged *bool
+	if v := r.URL.Query().Get("flagged"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "flagged must be true or false")
+			return
+		}
+		flagged = &parsed
+	}
+
+	direction := "asc"
+	if r.URL.Query().Get("order") == "desc" {
+		direction = "desc"
+	}
+
+	// Highest screening score first when triaging flagged content
+	sortBy := "created"
+	if params.SortBy == "score" {
+		sortBy = "score"
+	}
+
+	queryParams := map[string]interface{}{
+		"status":     status,
+		"flagged":    boolOrNil(flagged),
+		"reviewerId": nilIfEmpty(r.URL.Query().Get("reviewerId")),
+		"skip":       (params.Page - 1) * params.PerPage,
+		"limit":      params.PerPage,
+	}
+
+	page, err := database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) (moderationPage, error) {
+		countResult, err := queries.ModerationCount.Run(ctx, tx, queryParams)
+		if err != nil {
+			return moderationPage{}, err
+		}
+
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.ModerationQueue.Get(sortBy+"_"+direction).Run(ctx, tx, queryParams)
+		if err != nil {
+			return moderationPage{}, err
+		}
+
+		page := moderationPage{testimonials: []models.Testimonial{}, total: total}
+		for result.Next(ctx) {
+			testimonial, err := moderatedTestimonialFromRecord(result.Record())
+			if err != nil {
+				return moderationPage{}, err
+			}
+			page.testimonials = append(page.testimonials, testimonial)
+		}
+		return page, result.Err()
+	})
+
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch moderation queue")
+		return
+	}
+
+	totalPages := (int(page.total) + params.PerPage - 1) / params.PerPage
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    page.testimonials,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      page.total,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+// AssignReviewer handles PUT /api/v1/admin/testimonials/{id}/reviewer
+func (h *Handler) AssignReviewer(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	var req models.AssignReviewerRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	ctx := r.Context()
+
+	found, err := database.ExecuteWrite(ctx, h.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.ModerationAssign.Run(ctx, tx, map[string]interface{}{
+			"id":         testimonialID,
+			"reviewerId": nilIfEmpty(req.ReviewerID),
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), nil
+	})
+
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to assign reviewer")
+		return
+	}
+
+	if !found {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Testimonial not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Reviewer assigned successfully"},
+	})
+}
+
+// AddModerationNote handles POST /api/v1/admin/testimonials/{id}/notes
+func (h *Handler) AddModerationNote(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	var req models.AddModerationNoteRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" || len(req.Body) > maxModerationNoteLength {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("body must be between 1 and %d characters", maxModerationNoteLength))
+		return
+	}
+
+	ctx := r.Context()
+	note := models.ModerationNote{
+		ID:        uuid.New().String(),
+		AuthorID:  currentUserID(r),
+		Body:      req.Body,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	created, err := database.ExecuteWrite(ctx, h.db, func(tx neo4j.ManagedTransaction) (*models.ModerationNote, error) {
+		created, err := createModerationNote(ctx, tx, testimonialID, note)
+		if err != nil || !created {
+			return nil, err
+		}
+		return &note, nil
+	})
+
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to add note")
+		return
+	}
+
+	if created == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Testimonial not found")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    created,
+	})
+}
+
+// ReviewTestimonial handles PUT /api/v1/admin/testimonials/{id}/review. It
+// approves or rejects a testimonial, recording the reviewer and, for
+// rejections, the reason. Rejecting also removes it from the featured list.
+func (h *Handler) ReviewTestimonial(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	var req models.ReviewTestimonialRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if err := validateReview(req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	reviewerID := currentUserID(r)
+	now := time.Now().UTC()
+
+	reviewed, err := database.ExecuteWrite(ctx, h.db, func(tx neo4j.ManagedTransaction) (*models.Testimonial, error) {
+		approved := req.Decision == models.ModerationApproved
+		var reason interface{}
+		if !approved {
+			reason = string(req.Reason)
+		}
+
+		result, err := queries.ModerationReview.Run(ctx, tx, map[string]interface{}{
+			"id":         testimonialID,
+			"approved":   approved,
+			"reason":     reason,
+			"reviewerId": nilIfEmpty(reviewerID),
+			"reviewedAt": now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, nil
+		}
+		testimonial, err := repository.TestimonialFromRecord(result.Record())
+		if err != nil {
+			return nil, err
+		}
+		eventType := models.DomainTestimonialRejected
+		if approved {
+			eventType = models.DomainTestimonialApproved
+		}
+		review := models.TestimonialReview{ReviewerID: reviewerID, Reason: string(req.Reason)}
+		if err := repository.RecordEvent(ctx, tx, eventType, testimonialID, review); err != nil {
+			return nil, err
+		}
+
+		if note := strings.TrimSpace(req.Note); note != "" {
+			_, err := createModerationNote(ctx, tx, testimonialID, models.ModerationNote{
+				ID:        uuid.New().String(),
+				AuthorID:  reviewerID,
+				Body:      note,
+				CreatedAt: now,
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return &testimonial, nil
+	})
+
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to review testimonial")
+		return
+	}
+
+	if reviewed == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Testimonial not found")
+		return
+	}
+	if reviewed.IsApproved {
+		h.notify(ctx, models.Notification{
+			UserID:      reviewed.UserID,
+			Type:        models.NotificationTestimonialApproved,
+			SubjectType: models.SubjectTestimonial,
+			SubjectID:   reviewed.ID,
+		})
+		approved := *reviewed
+		approved.Moderation = nil
+		approved.User = nil
+		h.emitWebhook(ctx, webhooks.EventTestimonialApproved, &approved)
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    reviewed,
+	})
+}
+
+func validateReview(req models.ReviewTestimonialRequest) error {
+	if len(req.Note) > maxModerationNoteLength {
+		return fmt.Errorf("note must be at most %d characters", maxModerationNoteLength)
+	}
+
+	switch req.Decision {
+	case models.ModerationApproved:
+		if req.Reason != "" {
+			return fmt.Errorf("reason is only valid when rejecting")
+		}
+		return nil
+	case models.ModerationRejected:
+		for _, reason := range models.RejectionReasons {
+			if req.Reason == reason {
+				return nil
+			}
+		}
+		reasons := make([]string, len(models.RejectionReasons))
+		for i, reason := range models.RejectionReasons {
+			reasons[i] = string(reason)
+		}
+		return fmt.Errorf("reason must be one of %s", strings.Join(reasons, ", "))
+	default:
+		return fmt.Errorf("decision must be 'approved' or 'rejected'")
+	}
+}
+
+// createModerationNote attaches a note to a testimonial, reporting false if
+// the testimonial doesn't exist
+func createModerationNote(ctx context.Context, tx neo4j.ManagedTransaction, testimonialID string, note models.ModerationNote) (bool, error) {
+	result, err := queries.ModerationNoteCreate.Run(ctx, tx, map[string]interface{}{
+		"testimonialId": testimonialID,
+		"id":            note.ID,
+		"authorId":      note.AuthorID,
+		"body":          note.Body,
+		"createdAt":     note.CreatedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.Next(ctx), nil
+}
+
+// moderatedTestimonialFromRecord maps a testimonial along with its review
+// state and the notes collected under the "notes" key
+func moderatedTestimonialFromRecord(record *neo4j.Record) (models.Testimonial, error) {
+	testimonial, err := repository.TestimonialFromRecord(record)
+	if err != nil {
+		return models.Testimonial{}, err
+	}
+
+	testNode, _ := record.Get("t")
+	props := testNode.(neo4j.Node).Props
+
+	moderation := &models.Moderation{
+		Status: models.ModerationPending,
+		Notes:  []models.ModerationNote{},
+	}
+	if testimonial.IsApproved {
+		moderation.Status = models.ModerationApproved
+	} else if reason, ok := props["rejectionReason"].(string); ok {
+		moderation.Status = models.ModerationRejected
+		moderation.RejectionReason = models.RejectionReason(reason)
+	}
+	if reviewerID, ok := props["reviewerId"].(string); ok {
+		moderation.ReviewerID = reviewerID
+	}
+	if reviewedAt, ok := props["reviewedAt"].(time.Time); ok {
+		moderation.ReviewedAt = &reviewedAt
+	}
+	if score, ok := props["screeningScore"].(float64); ok {
+		screening := &models.Screening{Score: score, Flags: []string{}}
+		if verdict, ok := props["screeningVerdict"].(string); ok {
+			screening.Verdict = verdict
+		}
+		if flags, ok := props["screeningFlags"].([]interface{}); ok {
+			for _, f := range flags {
+				if flag, ok := f.(string); ok {
+					screening.Flags = append(screening.Flags, flag)
+				}
+			}
+		}
+		moderation.Screening = screening
+	}
+
+	if notes, ok := record.Get("notes"); ok {
+		for _, n := range notes.([]interface{}) {
+			note, err := database.DecodeNode[models.ModerationNote](n.(neo4j.Node))
+			if err != nil {
+				return models.Testimonial{}, err
+			}
+			moderation.Notes = append(moderation.Notes, note)
+		}
+	}
+
+	testimonial.Moderation = moderation
+	return testimonial, nil
+}
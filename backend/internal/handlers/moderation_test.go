@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestValidateReview(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       models.ReviewTestimonialRequest
+		expectErr bool
+	}{
+		{"approve", models.ReviewTestimonialRequest{Decision: models.ModerationApproved}, false},
+		{"reject with reason", models.ReviewTestimonialRequest{Decision: models.ModerationRejected, Reason: models.RejectionSpam}, false},
+		{"reject without reason", models.ReviewTestimonialRequest{Decision: models.ModerationRejected}, true},
+		{"reject with unknown reason", models.ReviewTestimonialRequest{Decision: models.ModerationRejected, Reason: "boring"}, true},
+		{"approve with reason", models.ReviewTestimonialRequest{Decision: models.ModerationApproved, Reason: models.RejectionOther}, true},
+		{"unknown decision", models.ReviewTestimonialRequest{Decision: models.ModerationPending}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReview(tt.req)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestGetModerationQueue_InvalidStatus(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/testimonials?status=archived", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetModerationQueue(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestAddModerationNote_EmptyBody(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/testimonials/t1/notes", bytes.NewBufferString(`{"body": "   "}`))
+	req.SetPathValue("id", "t1")
+	w := httptest.NewRecorder()
+
+	handler.AddModerationNote(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestModeratedTestimonialFromRecord(t *testing.T) {
+	reviewedAt := time.Now()
+	record := &neo4j.Record{
+		Keys: []string{"t", "notes"},
+		Values: []interface{}{
+			neo4j.Node{Props: map[string]interface{}{
+				"id":               "t1",
+				"story":            "A story",
+				"impact":           "Big",
+				"isApproved":       false,
+				"createdAt":        time.Now(),
+				"rejectionReason":  "spam",
+				"reviewerId":       "admin1",
+				"reviewedAt":       reviewedAt,
+				"screeningScore":   0.9,
+				"screeningVerdict": "block",
+				"screeningFlags":   []interface{}{"spam_phrase", "links"},
+			}},
+			[]interface{}{
+				neo4j.Node{Props: map[string]interface{}{
+					"id":        "n1",
+					"authorId":  "admin1",
+					"body":      "Looks automated",
+					"createdAt": time.Now(),
+				}},
+			},
+		},
+	}
+
+	testimonial, err := moderatedTestimonialFromRecord(record)
+	if err != nil {
+		t.Fatalf("moderatedTestimonialFromRecord failed: %v", err)
+	}
+
+	m := testimonial.Moderation
+	if m == nil {
+		t.Fatal("expected moderation state")
+	}
+	if m.Status != models.ModerationRejected || m.RejectionReason != models.RejectionSpam {
+		t.Errorf("unexpected status %s / reason %s", m.Status, m.RejectionReason)
+	}
+	if m.ReviewerID != "admin1" || m.ReviewedAt == nil {
+		t.Errorf("expected reviewer and review time, got %+v", m)
+	}
+	if m.Screening == nil || m.Screening.Verdict != "block" || len(m.Screening.Flags) != 2 {
+		t.Errorf("unexpected screening %+v", m.Screening)
+	}
+	if len(m.Notes) != 1 || m.Notes[0].Body != "Looks automated" {
+		t.Errorf("unexpected notes %+v", m.Notes)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payforwardnow/internal/i18n"
+	"payforwardnow/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// GetNotifications handles GET /api/v1/notifications, returning a page of
+// the current user's notifications, newest first. With ?unread=true only
+// the unread ones are listed.
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	unreadOnly := false
+	if v := r.URL.Query().Get("unread"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "unread must be true or false")
+			return
+		}
+		unreadOnly = parsed
+	}
+	params := getPaginationParams(r)
+
+	notifications, total, err := h.notifications.List(r.Context(), userID, unreadOnly, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch notifications")
+		return
+	}
+	locale := h.notificationLocale(w, r, userID)
+	for i := range notifications {
+		notifications[i].Text = i18n.Translate(locale, notificationTexts[notifications[i].Type])
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    notifications,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// GetUnreadNotificationCount handles GET
+// /api/v1/notifications/unread-count, for badges polled by the frontend
+func (h *Handler) GetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	unread, err := h.notifications.Unread(r.Context(), userID)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to count notifications")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.UnreadNotifications{Unread: unread},
+	})
+}
+
+// MarkNotificationsRead handles POST /api/v1/notifications/read, marking
+// the notifications listed in the body read, or all of the current user's
+// when the list is empty
+func (h *Handler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	var req models.MarkNotificationsReadRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	h.markNotificationsRead(w, r, userID, req.IDs, false)
+}
+
+// MarkNotificationRead handles POST /api/v1/notifications/{id}/read. It
+// answers 404 when the notification isn't the current user's unread one.
+func (h *Handler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	h.markNotificationsRead(w, r, userID, []string{r.PathValue("id")}, true)
+}
+
+// markNotificationsRead marks ids read and responds with how many were
+// marked and how many are left unread. With mustMark, marking none is a 404.
+func (h *Handler) markNotificationsRead(w http.ResponseWriter, r *http.Request, userID string, ids []string, mustMark bool) {
+	ctx := r.Context()
+	marked, err := h.notifications.MarkRead(ctx, userID, ids)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to mark notifications read")
+		return
+	}
+	if marked == 0 && mustMark {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Unread notification not found")
+		return
+	}
+	unread, err := h.notifications.Unread(ctx, userID)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to count notifications")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    models.MarkNotificationsReadResult{Marked: marked, Unread: unread},
+	})
+}
+
+// notificationTexts say what each type of notification is about, in
+// English and translated by i18n
+var notificationTexts = map[models.NotificationType]string{
+	models.NotificationActReceived:         "You received an act of kindness",
+	models.NotificationChainGrew:           "A chain you are part of grew",
+	models.NotificationTestimonialApproved: "Your testimonial was approved",
+}
+
+// notificationLocale returns the language notifications are listed in: the
+// one negotiated for the response or, when the request doesn't ask for any,
+// the one the user saved in their profile
+func (h *Handler) notificationLocale(w http.ResponseWriter, r *http.Request, userID string) string {
+	if locale := w.Header().Get("Content-Language"); locale != "" {
+		return locale
+	}
+	if preferred := i18n.Preferred(r); len(preferred) > 0 {
+		return i18n.Match(preferred)
+	}
+
+	user, err := h.users.Get(r.Context(), userID)
+	if err != nil || user.Locale == "" || !i18n.Supported(user.Locale) {
+		return i18n.DefaultLocale
+	}
+	w.Header().Set("Content-Language", user.Locale)
+	return user.Locale
+}
+
+// notify stores notifications in the background, so a slow or failing
+// write doesn't hold up the request that caused them. Notifications to the
+// actor themselves are dropped.
+func (h *Handler) notify(ctx context.Context, notifications ...models.Notification) {
+	now := time.Now().UTC()
+	kept := make([]models.Notification, 0, len(notifications))
+	for _, n := range notifications {
+		if n.UserID == "" || n.UserID == n.ActorID {
+			continue
+		}
+		n.ID = uuid.New().String()
+		n.CreatedAt = now
+		kept = append(kept, n)
+	}
+	if len(kept) == 0 || h.notifications == nil {
+		return
+	}
+
+	err := h.runInBackground(ctx, "notifications", func(ctx context.Context) error {
+		_, err := h.notifications.Create(ctx, kept)
+		return err
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Dropped notifications", "count", len(kept), "error", err)
+	}
+}
+
+// notifyActCreated tells the receiver of act they received it and the
+// other members of its chain that the chain grew. The giver is named as the
+// actor unless the act is anonymous or they weren't signed in.
+func (h *Handler) notifyActCreated(ctx context.Context, act *models.Act, signedIn bool) {
+	actorID := act.GiverID
+	if act.IsAnonymous || !signedIn {
+		actorID = ""
+	}
+	var notifications []models.Notification
+	if act.ReceiverID != act.GiverID {
+		notifications = append(notifications, models.Notification{
+			UserID:      act.ReceiverID,
+			Type:        models.NotificationActReceived,
+			ActorID:     actorID,
+			SubjectType: models.SubjectAct,
+			SubjectID:   act.ID,
+		})
+	}
+
+	if act.ChainID != "" {
+		members, err := h.chains.Members(ctx, act.ChainID)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to list chain members to notify", "chain_id", act.ChainID, "error", err)
+		}
+		for _, memberID := range members {
+			if memberID == act.GiverID || memberID == act.ReceiverID {
+				continue
+			}
+			notifications = append(notifications, models.Notification{
+				UserID:      memberID,
+				Type:        models.NotificationChainGrew,
+				ActorID:     actorID,
+				SubjectType: models.SubjectChain,
+				SubjectID:   act.ChainID,
+			})
+		}
+	}
+	h.notify(ctx, notifications...)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/workers"
+)
+
+func TestNotifications(t *testing.T) {
+	db := databasetest.New()
+	for _, id := range []string{"giver", "receiver", "starter"} {
+		db.AddUser(models.User{ID: id, Name: id}, "")
+	}
+	db.AddChain(models.Chain{ID: "c1", StarterID: "starter", CreatedAt: time.Now()})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	pool := workers.New("test", 1, 10)
+	handler.SetWorkers(pool)
+
+	body := `{"title":"Paid for groceries","description":"Covered a neighbour's shopping","type":"money","category":"food","receiverId":"receiver","chainId":"c1"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(body))
+	req = asUser(req, "giver")
+	w := httptest.NewRecorder()
+	handler.CreateAct(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	// Stopping the pool waits for the notifications to be stored
+	if err := pool.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(userID, query string) ([]models.Notification, int) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/notifications"+query, nil)
+		req = asUser(req, userID)
+		w := httptest.NewRecorder()
+		handler.GetNotifications(w, req)
+		var resp struct {
+			Data []models.Notification `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data, w.Code
+	}
+
+	received, code := list("receiver", "")
+	if code != http.StatusOK || len(received) != 1 {
+		t.Fatalf("expected one notification for the receiver, got %d: %+v", code, received)
+	}
+	if n := received[0]; n.Type != models.NotificationActReceived || n.ActorID != "giver" || n.SubjectType != models.SubjectAct {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	grown, _ := list("starter", "")
+	if len(grown) != 1 || grown[0].Type != models.NotificationChainGrew || grown[0].SubjectID != "c1" {
+		t.Errorf("expected the chain's starter to be told it grew, got %+v", grown)
+	}
+	if received[0].Text != "You received an act of kindness" {
+		t.Errorf("expected the notification's text in English, got %q", received[0].Text)
+	}
+	if translated, _ := list("receiver", "?locale=pt-BR"); len(translated) != 1 || translated[0].Text != "Você recebeu um ato de bondade" {
+		t.Errorf("expected the notification's text in Portuguese, got %+v", translated)
+	}
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/users/starter", strings.NewReader(`{"locale": "it_IT"}`))
+	req.SetPathValue("id", "starter")
+	w = httptest.NewRecorder()
+	handler.UpdateUser(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the locale saved, got %d: %s", w.Code, w.Body.String())
+	}
+	if saved, _ := list("starter", ""); len(saved) != 1 || saved[0].Text != "Una catena di cui fai parte è cresciuta" {
+		t.Errorf("expected the notification's text in the saved locale, got %+v", saved)
+	}
+	if own, _ := list("giver", ""); len(own) != 0 {
+		t.Errorf("expected no notifications for the giver, got %+v", own)
+	}
+	if _, code := list("", ""); code != http.StatusUnauthorized {
+		t.Errorf("expected %d without a user, got %d", http.StatusUnauthorized, code)
+	}
+
+	markOne := func(userID, id string) (models.MarkNotificationsReadResult, int) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/notifications/"+id+"/read", nil)
+		req.SetPathValue("id", id)
+		req = asUser(req, userID)
+		w := httptest.NewRecorder()
+		handler.MarkNotificationRead(w, req)
+		var resp struct {
+			Data models.MarkNotificationsReadResult `json:"data"`
+		}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp.Data, w.Code
+	}
+
+	if _, code := markOne("starter", received[0].ID); code != http.StatusNotFound {
+		t.Errorf("expected %d marking another user's notification, got %d", http.StatusNotFound, code)
+	}
+	result, code := markOne("receiver", received[0].ID)
+	if code != http.StatusOK || result.Marked != 1 || result.Unread != 0 {
+		t.Errorf("expected the notification to be marked read, got %d %+v", code, result)
+	}
+	if unread, _ := list("receiver", "?unread=true"); len(unread) != 0 {
+		t.Errorf("expected no unread notifications, got %+v", unread)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/notifications/read", strings.NewReader(`{}`))
+	req = asUser(req, "starter")
+	w = httptest.NewRecorder()
+	handler.MarkNotificationsRead(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"marked":1`) {
+		t.Errorf("expected every notification to be marked read, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestNotify_LeavesCallersSliceAlone(t *testing.T) {
+	handler := NewHandlerWithRepositories(&MockDBClient{}, databasetest.New().Repositories())
+	pool := workers.New("test", 1, 10)
+	handler.SetWorkers(pool)
+
+	notifications := []models.Notification{
+		{UserID: "actor", ActorID: "actor"},
+		{UserID: "receiver", ActorID: "actor"},
+	}
+	handler.notify(context.Background(), notifications...)
+	if err := pool.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if notifications[0].UserID != "actor" || notifications[1].ID != "" {
+		t.Errorf("expected the caller's notifications unchanged, got %+v", notifications)
+	}
+}
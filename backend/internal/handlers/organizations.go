@@ -0,0 +1,510 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/mail"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// invitationTTL is how long an invitation to an organization can be
+// accepted
+const invitationTTL = 7 * 24 * time.Hour
+
+// orgChainsLimit bounds the chains GetOrgChains returns
+const orgChainsLimit = 50
+
+// orgDashboardSize is how many top givers, recent acts and chains an
+// organization's dashboard shows
+const orgDashboardSize = 5
+
+// CreateOrganization handles POST /api/v1/orgs. The signed in user owns the
+// new organization.
+func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	var req models.CreateOrganizationRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	org := &models.Organization{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		Kind:        req.Kind,
+		CreatedBy:   userID,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if org.Kind == "" {
+		org.Kind = models.OrgKindOther
+	}
+	created, err := h.organizations.Create(r.Context(), org)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to create organization")
+		return
+	}
+	if !created {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+		return
+	}
+
+	org.Members, org.Role = 1, models.OrgRoleOwner
+	respondJSON(w, http.StatusCreated, models.APIResponse{Success: true, Data: org})
+}
+
+// GetOrganization handles GET /api/v1/orgs/{id}
+func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.findOrganization(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: org})
+}
+
+// GetUserOrganizations handles GET /api/v1/users/{id}/orgs, the
+// organizations a user is a member of with their role in each
+func (h *Handler) GetUserOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := h.organizations.ListByUser(r.Context(), r.PathValue("id"))
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch organizations")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: orgs})
+}
+
+// GetOrgMembers handles GET /api/v1/orgs/{id}/members, which only the
+// organization's members can list
+func (h *Handler) GetOrgMembers(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.orgRole(w, r)
+	if !ok || !checkOrgMember(w, role) {
+		return
+	}
+
+	params := getPaginationParams(r)
+	members, total, err := h.organizations.Members(r.Context(), r.PathValue("id"), params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch members")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    members,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// UpdateOrgMember handles PUT /api/v1/orgs/{id}/members/{userId}, changing
+// a member's role. Owners and admins manage members, but only owners can
+// make or unmake owners.
+func (h *Handler) UpdateOrgMember(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateMemberRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	role, ok := h.orgRole(w, r)
+	if !ok || !checkOrgManager(w, role) {
+		return
+	}
+
+	orgID, userID := r.PathValue("id"), r.PathValue("userId")
+	current, err := h.organizations.Role(r.Context(), orgID, userID)
+	if err != nil {
+		respondOrgError(w, err, "Failed to fetch member")
+		return
+	}
+	if current == "" {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Member not found")
+		return
+	}
+	if (current == models.OrgRoleOwner || req.Role == models.OrgRoleOwner) && role != models.OrgRoleOwner {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only owners can make or unmake owners")
+		return
+	}
+
+	if err := h.organizations.SetRole(r.Context(), orgID, userID, req.Role); err != nil {
+		respondOrgError(w, err, "Failed to update member")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"userId": userID, "role": string(req.Role)},
+	})
+}
+
+// RemoveOrgMember handles DELETE /api/v1/orgs/{id}/members/{userId}.
+// Members can leave, and owners and admins remove others, but only owners
+// can remove owners. The acts a member gave for the organization still
+// count towards it.
+func (h *Handler) RemoveOrgMember(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.orgRole(w, r)
+	if !ok {
+		return
+	}
+	orgID, userID := r.PathValue("id"), r.PathValue("userId")
+	if userID != currentUserID(r) {
+		if !checkOrgManager(w, role) {
+			return
+		}
+		current, err := h.organizations.Role(r.Context(), orgID, userID)
+		if err != nil {
+			respondOrgError(w, err, "Failed to fetch member")
+			return
+		}
+		if current == models.OrgRoleOwner && role != models.OrgRoleOwner {
+			respondError(w, http.StatusForbidden, "FORBIDDEN", "Only owners can make or unmake owners")
+			return
+		}
+	}
+
+	if err := h.organizations.RemoveMember(r.Context(), orgID, userID); err != nil {
+		respondOrgError(w, err, "Failed to remove member")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Member removed"},
+	})
+}
+
+// InviteOrgMember handles POST /api/v1/orgs/{id}/invitations. The invitee
+// is emailed a link to accept it; the response carries its token too, so
+// it can be shared another way. Only owners can invite owners.
+func (h *Handler) InviteOrgMember(w http.ResponseWriter, r *http.Request) {
+	var req models.InviteMemberRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	role, ok := h.orgRole(w, r)
+	if !ok || !checkOrgManager(w, role) {
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.OrgRoleMember
+	}
+	if req.Role == models.OrgRoleOwner && role != models.OrgRoleOwner {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only owners can make or unmake owners")
+		return
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create invitation")
+		return
+	}
+	now := time.Now().UTC()
+	invitation := &models.OrgInvitation{
+		ID:             uuid.New().String(),
+		OrganizationID: r.PathValue("id"),
+		Email:          strings.ToLower(strings.TrimSpace(req.Email)),
+		Role:           req.Role,
+		InvitedBy:      currentUserID(r),
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(invitationTTL),
+	}
+	if err := h.organizations.CreateInvitation(r.Context(), invitation, hashInvitationToken(token)); err != nil {
+		respondOrgError(w, err, "Failed to create invitation")
+		return
+	}
+
+	h.emailInvitation(r, invitation, token)
+	invitation.Token = token
+	respondJSON(w, http.StatusCreated, models.APIResponse{Success: true, Data: invitation})
+}
+
+// GetOrgInvitations handles GET /api/v1/orgs/{id}/invitations, the pending
+// invitations owners and admins sent
+func (h *Handler) GetOrgInvitations(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.orgRole(w, r)
+	if !ok || !checkOrgManager(w, role) {
+		return
+	}
+	invitations, err := h.organizations.Invitations(r.Context(), r.PathValue("id"), time.Now())
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch invitations")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: invitations})
+}
+
+// RevokeOrgInvitation handles DELETE
+// /api/v1/orgs/{id}/invitations/{invitationId}
+func (h *Handler) RevokeOrgInvitation(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.orgRole(w, r)
+	if !ok || !checkOrgManager(w, role) {
+		return
+	}
+	if err := h.organizations.RevokeInvitation(r.Context(), r.PathValue("id"), r.PathValue("invitationId")); err != nil {
+		respondOrgError(w, err, "Failed to revoke invitation")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Invitation revoked"},
+	})
+}
+
+// AcceptOrgInvitation handles POST /api/v1/invitations/accept. The signed
+// in user joins the organization if the invitation was sent to their email
+// address; members already keep their role.
+func (h *Handler) AcceptOrgInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	var req models.AcceptInvitationRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	user, err := h.users.Get(r.Context(), userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch user")
+		return
+	}
+
+	invitation, err := h.organizations.AcceptInvitation(r.Context(), hashInvitationToken(req.Token),
+		strings.ToLower(user.Email), userID, time.Now())
+	if err != nil {
+		respondOrgError(w, err, "Failed to accept invitation")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: invitation})
+}
+
+// GetOrgActs handles GET /api/v1/orgs/{id}/acts, the acts given for an
+// organization, newest first
+func (h *Handler) GetOrgActs(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.findOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	params := getPaginationParams(r)
+	acts, total, err := h.organizations.Acts(r.Context(), org.ID, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch acts")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    acts,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// GetOrgChains handles GET /api/v1/orgs/{id}/chains, the chains containing
+// acts given for an organization, most recently extended first
+func (h *Handler) GetOrgChains(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.findOrganization(w, r)
+	if !ok {
+		return
+	}
+	chains, err := h.organizations.Chains(r.Context(), org.ID, orgChainsLimit)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch chains")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: chains})
+}
+
+// GetOrgDashboard handles GET /api/v1/orgs/{id}/dashboard, which sums up an
+// organization's program for its members: its stats, top givers, recent
+// acts and chains, and for owners and admins its pending invitations
+func (h *Handler) GetOrgDashboard(w http.ResponseWriter, r *http.Request) {
+	role, ok := h.orgRole(w, r)
+	if !ok || !checkOrgMember(w, role) {
+		return
+	}
+	org, ok := h.findOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	dashboard := &models.OrgDashboard{Organization: org}
+	var err error
+	if dashboard.Stats, err = h.organizations.Stats(ctx, org.ID, time.Now()); err != nil {
+		respondOrgError(w, err, "Failed to fetch organization stats")
+		return
+	}
+	if dashboard.TopGivers, err = h.organizations.Leaderboard(ctx, org.ID, orgDashboardSize); err != nil {
+		respondDatabaseError(w, err, "Failed to fetch top givers")
+		return
+	}
+	page := models.PaginationParams{Page: 1, PerPage: orgDashboardSize}
+	if dashboard.RecentActs, _, err = h.organizations.Acts(ctx, org.ID, page); err != nil {
+		respondDatabaseError(w, err, "Failed to fetch acts")
+		return
+	}
+	if dashboard.Chains, err = h.organizations.Chains(ctx, org.ID, orgDashboardSize); err != nil {
+		respondDatabaseError(w, err, "Failed to fetch chains")
+		return
+	}
+	if role.Manages() {
+		invitations, err := h.organizations.Invitations(ctx, org.ID, time.Now())
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to fetch invitations")
+			return
+		}
+		pending := int64(len(invitations))
+		dashboard.PendingInvitations = &pending
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{Success: true, Data: dashboard})
+}
+
+// findOrganization fetches the organization named by the {id} path value,
+// or responds with an error and returns false
+func (h *Handler) findOrganization(w http.ResponseWriter, r *http.Request) (*models.Organization, bool) {
+	org, err := h.organizations.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		respondOrgError(w, err, "Failed to fetch organization")
+		return nil, false
+	}
+	return org, true
+}
+
+// orgRole returns the signed in user's role in the organization named by
+// the {id} path value, empty if they aren't a member. Site admins act as
+// owners. It responds with an error and returns false if nobody is signed
+// in or the organization doesn't exist.
+func (h *Handler) orgRole(w http.ResponseWriter, r *http.Request) (models.OrgRole, bool) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return "", false
+	}
+	role, err := h.organizations.Role(r.Context(), r.PathValue("id"), userID)
+	if err != nil {
+		respondOrgError(w, err, "Failed to fetch organization")
+		return "", false
+	}
+	if isAdmin(r) {
+		role = models.OrgRoleOwner
+	}
+	return role, true
+}
+
+// checkOrgMember responds with an error and returns false unless role is a
+// member's
+func checkOrgMember(w http.ResponseWriter, role models.OrgRole) bool {
+	if role == "" {
+		respondError(w, http.StatusForbidden, "NOT_ORG_MEMBER", "You are not a member of the organization")
+		return false
+	}
+	return true
+}
+
+// checkOrgManager responds with an error and returns false unless role
+// manages the organization's members
+func checkOrgManager(w http.ResponseWriter, role models.OrgRole) bool {
+	if !role.Manages() {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only owners and admins can manage the organization's members")
+		return false
+	}
+	return true
+}
+
+// checkOrgGiver checks that an act can be given for an organization: the
+// giver is signed in and a member of it. Otherwise it responds with an
+// error and returns false.
+func (h *Handler) checkOrgGiver(w http.ResponseWriter, r *http.Request, id, giverID string, signedIn bool) bool {
+	if !signedIn {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return false
+	}
+	role, err := h.organizations.Role(r.Context(), id, giverID)
+	if err != nil {
+		respondOrgError(w, err, "Failed to fetch organization")
+		return false
+	}
+	return checkOrgMember(w, role)
+}
+
+// respondOrgError responds to the errors of the organization repository
+func respondOrgError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrOrganizationNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Organization not found")
+	case errors.Is(err, repository.ErrNotOrgMember):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Member not found")
+	case errors.Is(err, repository.ErrLastOwner):
+		respondError(w, http.StatusConflict, "LAST_OWNER", "The organization must keep at least one owner")
+	case errors.Is(err, repository.ErrInvitationNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Invitation not found or expired")
+	default:
+		respondDatabaseError(w, err, message)
+	}
+}
+
+// emailInvitation emails an invitation's token to the invitee in the
+// background
+func (h *Handler) emailInvitation(r *http.Request, invitation *models.OrgInvitation, token string) {
+	if h.mailer == nil {
+		return
+	}
+	ctx := r.Context()
+	data := mail.OrgInvitationData{
+		Role:      string(invitation.Role),
+		URL:       h.mailer.Link("/invitations/accept?token=" + token),
+		ExpiresIn: invitationTTL,
+	}
+	if org, err := h.organizations.Get(ctx, invitation.OrganizationID); err == nil {
+		data.OrganizationName = org.Name
+	}
+	if inviter, err := h.users.Get(ctx, invitation.InvitedBy); err == nil {
+		data.InviterName = inviter.Name
+	} else {
+		data.InviterName = "Someone"
+	}
+	if err := h.mailer.Send(ctx, mail.TemplateOrgInvitation, invitation.Email, data); err != nil {
+		slog.WarnContext(ctx, "Dropped invitation email", "invitation_id", invitation.ID, "error", err)
+	}
+}
+
+// newInvitationToken returns a random token to accept an invitation with
+func newInvitationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashInvitationToken returns the hash invitations are stored with, so the
+// tokens themselves are never stored
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
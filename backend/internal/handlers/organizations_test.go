@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestOrganizations(t *testing.T) {
+	db := databasetest.New()
+	for _, id := range []string{"u1", "u2", "u3"} {
+		db.AddUser(models.User{ID: id, Name: id, Email: id + "@example.com"}, "")
+	}
+	db.AddChain(models.Chain{ID: "c1", Name: "Coffee", StarterID: "u3", CreatedAt: time.Now()})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	orgRequest := func(method, target, userID, body string, call func(http.ResponseWriter, *http.Request), path ...string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req := httptest.NewRequest(method, target, reader)
+		for i := 0; i+1 < len(path); i += 2 {
+			req.SetPathValue(path[i], path[i+1])
+		}
+		if userID != "" {
+			req = asUser(req, userID)
+		}
+		w := httptest.NewRecorder()
+		call(w, req)
+		return w
+	}
+
+	if w := orgRequest(http.MethodPost, "/api/v1/orgs", "", `{"name": "Hill School"}`, handler.CreateOrganization); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d signed out, got %d", http.StatusUnauthorized, w.Code)
+	}
+	w := orgRequest(http.MethodPost, "/api/v1/orgs", "u1", `{"name": "Hill School", "kind": "school"}`, handler.CreateOrganization)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected the organization created, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct{ Data models.Organization }
+	json.Unmarshal(w.Body.Bytes(), &created)
+	org := created.Data
+	if org.Role != models.OrgRoleOwner || org.Members != 1 || org.Kind != models.OrgKindSchool {
+		t.Errorf("expected u1 to own the school, got %+v", org)
+	}
+
+	invite := func(userID, body string) *httptest.ResponseRecorder {
+		return orgRequest(http.MethodPost, "/api/v1/orgs/"+org.ID+"/invitations", userID, body, handler.InviteOrgMember, "id", org.ID)
+	}
+	if w := invite("u3", `{"email": "u3@example.com"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected %d inviting as a non-member, got %d", http.StatusForbidden, w.Code)
+	}
+	w = invite("u1", `{"email": "U2@Example.com", "role": "admin"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected the invitation created, got %d: %s", w.Code, w.Body.String())
+	}
+	var invitation struct{ Data models.OrgInvitation }
+	json.Unmarshal(w.Body.Bytes(), &invitation)
+	if invitation.Data.Token == "" || invitation.Data.Email != "u2@example.com" {
+		t.Fatalf("expected a token for u2@example.com, got %+v", invitation.Data)
+	}
+
+	accept := func(userID, token string) int {
+		body := fmt.Sprintf(`{"token": %q}`, token)
+		return orgRequest(http.MethodPost, "/api/v1/invitations/accept", userID, body, handler.AcceptOrgInvitation).Code
+	}
+	if code := accept("u3", invitation.Data.Token); code != http.StatusNotFound {
+		t.Errorf("expected %d accepting someone else's invitation, got %d", http.StatusNotFound, code)
+	}
+	if code := accept("u2", "wrong"); code != http.StatusNotFound {
+		t.Errorf("expected %d for a wrong token, got %d", http.StatusNotFound, code)
+	}
+	if code := accept("u2", invitation.Data.Token); code != http.StatusOK {
+		t.Fatalf("expected u2 to accept, got %d", code)
+	}
+	if code := accept("u2", invitation.Data.Token); code != http.StatusNotFound {
+		t.Errorf("expected %d accepting twice, got %d", http.StatusNotFound, code)
+	}
+
+	// Admins manage members but not owners
+	if w := invite("u2", `{"email": "u3@example.com", "role": "owner"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected %d for an admin inviting an owner, got %d", http.StatusForbidden, w.Code)
+	}
+	member := func(method, userID, targetID, body string, call func(http.ResponseWriter, *http.Request)) int {
+		return orgRequest(method, "/api/v1/orgs/"+org.ID+"/members/"+targetID, userID, body, call, "id", org.ID, "userId", targetID).Code
+	}
+	if code := member(http.MethodPut, "u2", "u1", `{"role": "member"}`, handler.UpdateOrgMember); code != http.StatusForbidden {
+		t.Errorf("expected %d for an admin demoting an owner, got %d", http.StatusForbidden, code)
+	}
+	if code := member(http.MethodDelete, "u1", "u1", "", handler.RemoveOrgMember); code != http.StatusConflict {
+		t.Errorf("expected %d for the last owner leaving, got %d", http.StatusConflict, code)
+	}
+
+	createAct := func(userID string, value float64) int {
+		body := fmt.Sprintf(`{"title": "Tutored a classmate", "description": "Helped with maths homework", "type": "mentoring", "category": "education", "value": %v, "chainId": "c1", "organizationId": %q}`,
+			value, org.ID)
+		return orgRequest(http.MethodPost, "/api/v1/acts", userID, body, handler.CreateAct).Code
+	}
+	if code := createAct("u3", 5); code != http.StatusForbidden {
+		t.Errorf("expected %d giving for an organization as a non-member, got %d", http.StatusForbidden, code)
+	}
+	for _, giver := range []string{"u2", "u2", "u1"} {
+		if code := createAct(giver, 10); code != http.StatusCreated {
+			t.Fatalf("expected the act created, got %d", code)
+		}
+	}
+
+	dashboard := func(userID string) *httptest.ResponseRecorder {
+		return orgRequest(http.MethodGet, "/api/v1/orgs/"+org.ID+"/dashboard", userID, "", handler.GetOrgDashboard, "id", org.ID)
+	}
+	if w := dashboard("u3"); w.Code != http.StatusForbidden {
+		t.Errorf("expected %d for a non-member's dashboard, got %d", http.StatusForbidden, w.Code)
+	}
+	var got struct{ Data models.OrgDashboard }
+	json.Unmarshal(dashboard("u1").Body.Bytes(), &got)
+	d := got.Data
+	if d.Stats == nil || d.Stats.Members != 2 || d.Stats.ActsGiven != 3 || d.Stats.TotalValue != 30 {
+		t.Errorf("expected 2 members who gave 3 acts worth 30, got %+v", d.Stats)
+	}
+	if len(d.TopGivers) != 2 || d.TopGivers[0].User.ID != "u2" || d.TopGivers[0].ActsCount != 2 {
+		t.Errorf("expected u2 to top the givers, got %+v", d.TopGivers)
+	}
+	if len(d.RecentActs) != 3 || len(d.Chains) != 1 || d.Chains[0].ID != "c1" || d.Chains[0].ActsCount != 3 {
+		t.Errorf("expected 3 recent acts in chain c1, got %d acts and %+v", len(d.RecentActs), d.Chains)
+	}
+	if d.PendingInvitations == nil || *d.PendingInvitations != 0 {
+		t.Errorf("expected no pending invitations counted for the owner, got %v", d.PendingInvitations)
+	}
+
+	if code := member(http.MethodDelete, "u1", "u2", "", handler.RemoveOrgMember); code != http.StatusOK {
+		t.Fatalf("expected u1 to remove u2, got %d", code)
+	}
+	var members struct {
+		Data []models.OrgMember
+		Meta models.APIMeta
+	}
+	json.Unmarshal(orgRequest(http.MethodGet, "/api/v1/orgs/"+org.ID+"/members", "u1", "", handler.GetOrgMembers, "id", org.ID).Body.Bytes(), &members)
+	if members.Meta.Total != 1 || members.Data[0].UserID != "u1" || members.Data[0].Role != models.OrgRoleOwner {
+		t.Errorf("expected only the owner left, got %+v", members.Data)
+	}
+
+	var acts struct {
+		Data []models.Act
+		Meta models.APIMeta
+	}
+	json.Unmarshal(orgRequest(http.MethodGet, "/api/v1/orgs/"+org.ID+"/acts", "", "", handler.GetOrgActs, "id", org.ID).Body.Bytes(), &acts)
+	if acts.Meta.Total != 3 || acts.Data[0].OrganizationID != org.ID {
+		t.Errorf("expected the removed member's acts to still count, got %+v", acts.Meta)
+	}
+	if w := orgRequest(http.MethodGet, "/api/v1/orgs/missing", "", "", handler.GetOrganization, "id", "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing organization, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/payments"
+	"payforwardnow/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// pledgeSettleBatch is the number of due pledges SettlePledges settles per
+// run
+const pledgeSettleBatch = 100
+
+// pledgeStatuses are the statuses GetPledges filters by
+var pledgeStatuses = []models.PledgeStatus{models.PledgeHeld, models.PledgeSettling, models.PledgeReleased, models.PledgeRefunded, models.PledgeDisputed}
+
+// errPaymentProvider wraps the failures of the payments provider
+var errPaymentProvider = errors.New("payments provider failed")
+
+// SetPayments sets the provider pledges are held with and how long a held
+// pledge waits for the receiver to confirm the act before it's released
+// automatically. Without a provider, pledge endpoints answer 503.
+func (h *Handler) SetPayments(p payments.Provider, autoRelease time.Duration) {
+	h.payments = p
+	h.pledgeRelease = autoRelease
+}
+
+// pay runs operation op of the payments provider, counting its result
+func (h *Handler) pay(op string, fn func() error) error {
+	err := fn()
+	result := "ok"
+	switch {
+	case errors.Is(err, payments.ErrDeclined):
+		result = "declined"
+	case err != nil:
+		result = "error"
+	}
+	metrics.PaymentRequests.WithLabelValues(h.payments.Name(), op, result).Inc()
+	if err != nil && !errors.Is(err, payments.ErrDeclined) {
+		return fmt.Errorf("%w: %w", errPaymentProvider, err)
+	}
+	return err
+}
+
+// settlePledge captures or cancels the hold of pledge, as change.Status
+// says, if its status is one of from, then records the change. The pledge
+// is claimed as settling first, so a concurrent change can't move it while
+// the provider moves the funds. Releasing a pledge completes its act.
+func (h *Handler) settlePledge(ctx context.Context, pledge *models.Pledge, from []models.PledgeStatus, change repository.PledgeChange) (*models.Pledge, error) {
+	if !slices.Contains(from, pledge.Status) {
+		return nil, repository.ErrPledgeState
+	}
+	if pledge.Provider != h.payments.Name() {
+		return nil, fmt.Errorf("%w: pledge was held with %s", errPaymentProvider, pledge.Provider)
+	}
+	if err := h.pledges.Claim(ctx, pledge.ID, from, change.At); err != nil {
+		return nil, err
+	}
+
+	// Once claimed, the outcome is recorded even if the client goes away
+	ctx = context.WithoutCancel(ctx)
+	op, call := "capture", h.payments.Capture
+	if change.Status == models.PledgeRefunded {
+		op, call = "cancel", h.payments.Cancel
+	}
+	if err := h.pay(op, func() error { return call(ctx, pledge.ProviderRef) }); err != nil {
+		if unclaimErr := h.pledges.Unclaim(ctx, pledge.ID, time.Now().UTC()); unclaimErr != nil {
+			slog.ErrorContext(ctx, "Failed to unclaim pledge", "pledge_id", pledge.ID, "error", unclaimErr)
+		}
+		return nil, err
+	}
+
+	settled, err := h.pledges.Transition(ctx, pledge.ID, []models.PledgeStatus{models.PledgeSettling}, change)
+	if err != nil {
+		// The funds moved, so the pledge stays settling for an admin
+		slog.ErrorContext(ctx, "Failed to record a settled pledge", "pledge_id", pledge.ID, "status", change.Status, "error", err)
+		return nil, err
+	}
+	if settled.Status == models.PledgeReleased {
+		h.setPledgedActStatus(ctx, settled.ActID, models.ActStatusCompleted)
+	}
+	return settled, nil
+}
+
+// setPledgedActStatus moves a pledged act to status, unless it's already
+// there or was deleted. The pledge has been settled by then, so failures
+// are only logged.
+func (h *Handler) setPledgedActStatus(ctx context.Context, actID string, status models.ActStatus) {
+	act, err := h.acts.Get(ctx, actID)
+	if errors.Is(err, repository.ErrActNotFound) {
+		return
+	}
+	if err == nil && act.Status == status {
+		return
+	}
+	var version int64
+	if err == nil {
+		version, err = h.acts.Update(ctx, actID, models.UpdateActRequest{Status: status})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to update pledged act", "act_id", actID, "status", status, "error", err)
+		return
+	}
+	if status == models.ActStatusCompleted {
+		h.publishActCompleted(ctx, actID, version)
+	}
+}
+
+// respondPledgeError responds with the error a pledge change failed with
+func respondPledgeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrPledgeNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Pledge not found")
+	case errors.Is(err, repository.ErrPledgeState):
+		respondError(w, http.StatusConflict, "CONFLICT", "The pledge's status doesn't allow this")
+	case errors.Is(err, payments.ErrDeclined):
+		respondError(w, http.StatusPaymentRequired, "PAYMENT_DECLINED", "The payment was declined")
+	case errors.Is(err, errPaymentProvider):
+		slog.Error(message, "error", err)
+		respondError(w, http.StatusBadGateway, "PAYMENT_FAILED", "The payments provider failed, please retry later")
+	default:
+		respondDatabaseError(w, err, message)
+	}
+}
+
+// SettlePledges releases the held pledges whose automatic release is due
+// and refunds those whose act was cancelled, expired or deleted, up to
+// pledgeSettleBatch per run
+func (h *Handler) SettlePledges(ctx context.Context) error {
+	now := time.Now().UTC()
+	release, refund, err := h.pledges.Due(ctx, now, pledgeSettleBatch)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	settled := 0
+	settle := func(pledge models.Pledge, status models.PledgeStatus) {
+		_, err := h.settlePledge(ctx, &pledge, []models.PledgeStatus{models.PledgeHeld}, repository.PledgeChange{Status: status, At: now})
+		switch {
+		case err == nil:
+			settled++
+		case !errors.Is(err, repository.ErrPledgeState):
+			errs = append(errs, fmt.Errorf("settling pledge %s as %s: %w", pledge.ID, status, err))
+		}
+	}
+	for _, pledge := range release {
+		settle(pledge, models.PledgeReleased)
+	}
+	for _, pledge := range refund {
+		settle(pledge, models.PledgeRefunded)
+	}
+	if settled > 0 {
+		slog.InfoContext(ctx, "Settled pledges", "count", settled)
+	}
+	return errors.Join(errs...)
+}
+
+// actPledge returns the pledge of the act in the request's path, or
+// responds with an error and returns nil
+func (h *Handler) actPledge(w http.ResponseWriter, r *http.Request) *models.Pledge {
+	if h.payments == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Pledges are disabled")
+		return nil
+	}
+	pledge, err := h.pledges.ForAct(r.Context(), r.PathValue("id"))
+	if err != nil {
+		respondPledgeError(w, err, "Failed to fetch pledge")
+		return nil
+	}
+	return pledge
+}
+
+// CreatePledge handles POST /api/v1/acts/{id}/pledge. The giver of a
+// pending monetary act with a receiver pledges its value, which the
+// payments provider holds on their payment method until the receiver
+// confirms the act.
+func (h *Handler) CreatePledge(w http.ResponseWriter, r *http.Request) {
+	if h.payments == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Pledges are disabled")
+		return
+	}
+	var req models.CreatePledgeRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	ctx := r.Context()
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	act, err := h.acts.Get(ctx, r.PathValue("id"))
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch act")
+		return
+	}
+	// Check the giver before placing the hold, including for anonymous
+	// acts, whose giver Get leaves out
+	giverID, err := h.acts.Giver(ctx, act.ID)
+	if err != nil && !errors.Is(err, repository.ErrActNotFound) {
+		respondDatabaseError(w, err, "Failed to fetch act")
+		return
+	}
+	if giverID != userID {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the giver can pledge an act")
+		return
+	}
+	if act.Type != models.ActTypeMonetary || (act.Status != models.ActStatusPending && act.Status != models.ActStatusAccepted) ||
+		act.ReceiverID == "" || act.Value <= 0 || len(act.Currency) != 3 {
+		respondError(w, http.StatusConflict, "NOT_PLEDGEABLE", "Only pending monetary acts with a receiver, a value and a currency can be pledged")
+		return
+	}
+	if _, err := h.pledges.ForAct(ctx, act.ID); err == nil {
+		respondError(w, http.StatusConflict, "CONFLICT", "Act already pledged")
+		return
+	} else if !errors.Is(err, repository.ErrPledgeNotFound) {
+		respondDatabaseError(w, err, "Failed to fetch pledge")
+		return
+	}
+
+	now := time.Now().UTC()
+	pledge := models.Pledge{
+		ID:         uuid.New().String(),
+		ActID:      act.ID,
+		GiverID:    userID,
+		ReceiverID: act.ReceiverID,
+		Amount:     act.Value,
+		Currency:   strings.ToUpper(act.Currency),
+		Provider:   h.payments.Name(),
+		ReleaseAt:  now.Add(h.pledgeRelease),
+		CreatedAt:  now,
+	}
+	err = h.pay("hold", func() error {
+		var err error
+		pledge.ProviderRef, err = h.payments.Hold(ctx, payments.Hold{
+			Key:           pledge.ID,
+			Amount:        pledge.Amount,
+			Currency:      pledge.Currency,
+			PaymentMethod: req.PaymentMethod,
+			Description:   act.Title,
+		})
+		return err
+	})
+	if err != nil {
+		respondPledgeError(w, err, "Failed to hold pledge")
+		return
+	}
+
+	if err := h.pledges.Create(ctx, &pledge); err != nil {
+		// The pledge wasn't stored, so nothing would release the hold, even
+		// if the client goes away
+		cancelCtx := context.WithoutCancel(ctx)
+		if cancelErr := h.pay("cancel", func() error { return h.payments.Cancel(cancelCtx, pledge.ProviderRef) }); cancelErr != nil {
+			slog.ErrorContext(ctx, "Failed to cancel the hold of an unstored pledge", "pledge_id", pledge.ID, "error", cancelErr)
+		}
+		switch {
+		case errors.Is(err, repository.ErrActNotFound):
+			respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		case errors.Is(err, repository.ErrNotActGiver):
+			respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the giver can pledge an act")
+		case errors.Is(err, repository.ErrPledgeExists):
+			respondError(w, http.StatusConflict, "CONFLICT", "Act already pledged")
+		default:
+			respondDatabaseError(w, err, "Failed to create pledge")
+		}
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    pledge,
+	})
+}
+
+// GetPledge handles GET /api/v1/acts/{id}/pledge, for the act's giver and
+// receiver and admins. The receiver of an anonymous act doesn't see who
+// pledged it.
+func (h *Handler) GetPledge(w http.ResponseWriter, r *http.Request) {
+	pledge := h.actPledge(w, r)
+	if pledge == nil {
+		return
+	}
+	userID := currentUserID(r)
+	if userID != pledge.GiverID && userID != pledge.ReceiverID && !isAdmin(r) {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the giver and receiver can see the pledge of an act")
+		return
+	}
+	if userID != pledge.GiverID && !isAdmin(r) {
+		if act, err := h.acts.Get(r.Context(), pledge.ActID); err != nil || act.IsAnonymous {
+			pledge.GiverID = ""
+		}
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    pledge,
+	})
+}
+
+// ConfirmPledge handles POST /api/v1/acts/{id}/pledge/confirm. The
+// receiver confirms the act, which releases the held funds to them and
+// completes the act.
+func (h *Handler) ConfirmPledge(w http.ResponseWriter, r *http.Request) {
+	pledge := h.actPledge(w, r)
+	if pledge == nil {
+		return
+	}
+	if currentUserID(r) != pledge.ReceiverID {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the receiver can confirm a pledge")
+		return
+	}
+
+	released, err := h.settlePledge(r.Context(), pledge, []models.PledgeStatus{models.PledgeHeld},
+		repository.PledgeChange{Status: models.PledgeReleased, At: time.Now().UTC()})
+	if err != nil {
+		respondPledgeError(w, err, "Failed to release pledge")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    released,
+	})
+}
+
+// CancelPledge handles POST /api/v1/acts/{id}/pledge/cancel. The giver
+// cancels a held pledge, which refunds them and cancels the act.
+func (h *Handler) CancelPledge(w http.ResponseWriter, r *http.Request) {
+	pledge := h.actPledge(w, r)
+	if pledge == nil {
+		return
+	}
+	if currentUserID(r) != pledge.GiverID {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the giver can cancel a pledge")
+		return
+	}
+
+	refunded, err := h.settlePledge(r.Context(), pledge, []models.PledgeStatus{models.PledgeHeld},
+		repository.PledgeChange{Status: models.PledgeRefunded, At: time.Now().UTC()})
+	if err != nil {
+		respondPledgeError(w, err, "Failed to refund pledge")
+		return
+	}
+	h.setPledgedActStatus(r.Context(), refunded.ActID, models.ActStatusCancelled)
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    refunded,
+	})
+}
+
+// DisputePledge handles POST /api/v1/acts/{id}/pledge/dispute. The giver
+// or receiver disputes a held pledge, which keeps the funds held, without
+// automatic release, until an admin resolves it.
+func (h *Handler) DisputePledge(w http.ResponseWriter, r *http.Request) {
+	pledge := h.actPledge(w, r)
+	if pledge == nil {
+		return
+	}
+	var req models.DisputePledgeRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	userID := currentUserID(r)
+	if userID != pledge.GiverID && userID != pledge.ReceiverID {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the giver and receiver can dispute a pledge")
+		return
+	}
+
+	disputed, err := h.pledges.Transition(r.Context(), pledge.ID, []models.PledgeStatus{models.PledgeHeld}, repository.PledgeChange{
+		Status:        models.PledgeDisputed,
+		At:            time.Now().UTC(),
+		DisputedBy:    userID,
+		DisputeReason: req.Reason,
+	})
+	if err != nil {
+		respondPledgeError(w, err, "Failed to dispute pledge")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    disputed,
+	})
+}
+
+// GetPledges handles GET /api/v1/admin/pledges, listing pledges most
+// recently updated first, optionally filtered by ?status=
+func (h *Handler) GetPledges(w http.ResponseWriter, r *http.Request) {
+	status := models.PledgeStatus(r.URL.Query().Get("status"))
+	if status != "" && !slices.Contains(pledgeStatuses, status) {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "status must be held, settling, released, refunded or disputed")
+		return
+	}
+	params := getPaginationParams(r)
+	pledges, total, err := h.pledges.List(r.Context(), status, params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch pledges")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    pledges,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
+
+// ResolvePledge handles POST /api/v1/admin/pledges/{id}/resolve, settling
+// a disputed pledge by releasing it to the receiver, which completes the
+// act, or refunding the giver, which cancels it
+func (h *Handler) ResolvePledge(w http.ResponseWriter, r *http.Request) {
+	if h.payments == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Pledges are disabled")
+		return
+	}
+	var req models.ResolvePledgeRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	ctx := r.Context()
+	pledge, err := h.pledges.Get(ctx, r.PathValue("id"))
+	if err != nil {
+		respondPledgeError(w, err, "Failed to fetch pledge")
+		return
+	}
+
+	change := repository.PledgeChange{Status: models.PledgeReleased, At: time.Now().UTC(), Resolution: req.Outcome}
+	if req.Outcome == "refund" {
+		change.Status = models.PledgeRefunded
+	}
+	if req.Note != "" {
+		change.Resolution += ": " + req.Note
+	}
+	settled, err := h.settlePledge(ctx, pledge, []models.PledgeStatus{models.PledgeDisputed}, change)
+	if err != nil {
+		respondPledgeError(w, err, "Failed to resolve pledge")
+		return
+	}
+	if settled.Status == models.PledgeRefunded {
+		h.setPledgedActStatus(ctx, settled.ActID, models.ActStatusCancelled)
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    settled,
+	})
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/payments"
+	"payforwardnow/internal/repository"
+)
+
+// fakePayments is a payments.Provider recording the holds it placed,
+// captured and cancelled
+type fakePayments struct {
+	mu        sync.Mutex
+	decline   bool
+	fail      bool
+	held      []string
+	captured  []string
+	cancelled []string
+}
+
+func (p *fakePayments) Hold(_ context.Context, h payments.Hold) (string, error) {
+	if p.decline {
+		return "", payments.ErrDeclined
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.held = append(p.held, h.Key)
+	return "ref-" + h.Key, nil
+}
+
+func (p *fakePayments) Capture(_ context.Context, ref string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail {
+		return errors.New("provider unavailable")
+	}
+	p.captured = append(p.captured, ref)
+	return nil
+}
+
+func (p *fakePayments) Cancel(_ context.Context, ref string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cancelled = append(p.cancelled, ref)
+	return nil
+}
+
+func (p *fakePayments) Name() string { return "fake" }
+
+func TestPledges(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver"}, "")
+	db.AddUser(models.User{ID: "receiver"}, "")
+	db.AddUser(models.User{ID: "stranger"}, "")
+	for _, act := range []models.Act{
+		{ID: "a1", Title: "Paid a month of rent", Type: models.ActTypeMonetary, Value: 500, Currency: "eur", Status: models.ActStatusPending, GiverID: "giver", ReceiverID: "receiver"},
+		{ID: "a2", Title: "Bought school books", Type: models.ActTypeMonetary, Value: 80, Currency: "EUR", Status: models.ActStatusPending, GiverID: "giver", ReceiverID: "receiver"},
+		{ID: "a3", Title: "Covered a vet bill", Type: models.ActTypeMonetary, Value: 120, Currency: "EUR", Status: models.ActStatusPending, GiverID: "giver", ReceiverID: "receiver"},
+		{ID: "a4", Title: "Fixed a bike", Type: models.ActTypeService, Status: models.ActStatusPending, GiverID: "giver", ReceiverID: "receiver"},
+	} {
+		db.AddAct(act)
+	}
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+
+	call := func(fn http.HandlerFunc, method, path, id, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.SetPathValue("id", id)
+		req = asUser(req, userID)
+		if userID == "admin" {
+			req = req.WithContext(context.WithValue(req.Context(), middleware.RolesKey, []string{middleware.AdminRole}))
+		}
+		w := httptest.NewRecorder()
+		fn(w, req)
+		return w
+	}
+	pledge := func(actID, userID string) *httptest.ResponseRecorder {
+		return call(handler.CreatePledge, http.MethodPost, "/api/v1/acts/"+actID+"/pledge", actID, userID, `{"paymentMethod":"pm_card"}`)
+	}
+	decode := func(w *httptest.ResponseRecorder) models.Pledge {
+		var body struct{ Data models.Pledge }
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body.Data
+	}
+
+	if w := pledge("a1", "giver"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d without a payments provider, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	provider := &fakePayments{}
+	handler.SetPayments(provider, time.Hour)
+
+	for _, c := range []struct {
+		actID, userID string
+		status        int
+	}{
+		{"a1", "stranger", http.StatusForbidden},
+		{"a4", "giver", http.StatusConflict},
+		{"missing", "giver", http.StatusNotFound},
+		{"a1", "", http.StatusUnauthorized},
+	} {
+		if w := pledge(c.actID, c.userID); w.Code != c.status {
+			t.Errorf("%s by %s: expected %d, got %d", c.actID, c.userID, c.status, w.Code)
+		}
+	}
+	if len(provider.held) != 0 {
+		t.Errorf("expected no hold for a rejected pledge, got %v", provider.held)
+	}
+	provider.decline = true
+	if w := pledge("a1", "giver"); w.Code != http.StatusPaymentRequired {
+		t.Errorf("expected %d for a declined payment, got %d", http.StatusPaymentRequired, w.Code)
+	}
+	provider.decline = false
+
+	w := pledge("a1", "giver")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	held := decode(w)
+	if held.Status != models.PledgeHeld || held.Amount != 500 || held.Currency != "EUR" || held.ReceiverID != "receiver" {
+		t.Errorf("unexpected pledge %+v", held)
+	}
+	if w := pledge("a1", "giver"); w.Code != http.StatusConflict {
+		t.Errorf("expected %d for a second pledge, got %d", http.StatusConflict, w.Code)
+	}
+	if w := call(handler.GetPledge, http.MethodGet, "/api/v1/acts/a1/pledge", "a1", "stranger", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected %d for a stranger, got %d", http.StatusForbidden, w.Code)
+	}
+
+	confirm := func(actID, userID string) *httptest.ResponseRecorder {
+		return call(handler.ConfirmPledge, http.MethodPost, "/api/v1/acts/"+actID+"/pledge/confirm", actID, userID, "")
+	}
+	if w := confirm("a1", "giver"); w.Code != http.StatusForbidden {
+		t.Errorf("expected only the receiver to confirm, got %d", w.Code)
+	}
+	if w := confirm("a1", "receiver"); w.Code != http.StatusOK || decode(w).Status != models.PledgeReleased {
+		t.Fatalf("expected the pledge to be released, got %d: %s", w.Code, w.Body.String())
+	}
+	if act, _ := repos.Acts.Get(context.Background(), "a1"); act.Status != models.ActStatusCompleted {
+		t.Errorf("expected the confirmed act to be completed, got %s", act.Status)
+	}
+	if len(provider.captured) != 1 || provider.captured[0] != "ref-"+held.ID {
+		t.Errorf("expected the hold to be captured, got %v", provider.captured)
+	}
+	if w := confirm("a1", "receiver"); w.Code != http.StatusConflict {
+		t.Errorf("expected %d for a released pledge, got %d", http.StatusConflict, w.Code)
+	}
+
+	// Cancelling refunds the giver and cancels the act
+	pledge("a2", "giver")
+	if w := call(handler.CancelPledge, http.MethodPost, "/api/v1/acts/a2/pledge/cancel", "a2", "giver", ""); w.Code != http.StatusOK || decode(w).Status != models.PledgeRefunded {
+		t.Fatalf("expected the pledge to be refunded, got %d: %s", w.Code, w.Body.String())
+	}
+	if act, _ := repos.Acts.Get(context.Background(), "a2"); act.Status != models.ActStatusCancelled {
+		t.Errorf("expected the act to be cancelled, got %s", act.Status)
+	}
+
+	// A disputed pledge waits for an admin
+	disputed := decode(pledge("a3", "giver"))
+	dispute := `{"reason":"The vet bill was paid twice"}`
+	if w := call(handler.DisputePledge, http.MethodPost, "/api/v1/acts/a3/pledge/dispute", "a3", "stranger", dispute); w.Code != http.StatusForbidden {
+		t.Errorf("expected %d for a stranger's dispute, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := call(handler.DisputePledge, http.MethodPost, "/api/v1/acts/a3/pledge/dispute", "a3", "giver", dispute); w.Code != http.StatusOK || decode(w).DisputedBy != "giver" {
+		t.Fatalf("expected the pledge to be disputed, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := confirm("a3", "receiver"); w.Code != http.StatusConflict {
+		t.Errorf("expected a disputed pledge not to be confirmed, got %d", w.Code)
+	}
+
+	w = call(handler.GetPledges, http.MethodGet, "/api/v1/admin/pledges?status=disputed", "", "admin", "")
+	var list struct {
+		Data []models.Pledge
+		Meta models.APIMeta
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if list.Meta.Total != 1 || list.Data[0].ID != disputed.ID {
+		t.Errorf("expected the disputed pledge, got %s", w.Body.String())
+	}
+
+	w = call(handler.ResolvePledge, http.MethodPost, "/api/v1/admin/pledges/"+disputed.ID+"/resolve", disputed.ID, "admin", `{"outcome":"refund","note":"Duplicate payment"}`)
+	if resolved := decode(w); resolved.Status != models.PledgeRefunded || resolved.Resolution != "refund: Duplicate payment" {
+		t.Errorf("expected the dispute to be resolved with a refund, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(provider.cancelled) != 2 {
+		t.Errorf("expected 2 cancelled holds, got %v", provider.cancelled)
+	}
+}
+
+func TestSettlePledges(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver"}, "")
+	db.AddUser(models.User{ID: "receiver"}, "")
+	for _, id := range []string{"a1", "a2", "a3"} {
+		db.AddAct(models.Act{ID: id, Title: "Paid a bill", Type: models.ActTypeMonetary, Value: 10, Currency: "USD",
+			Status: models.ActStatusPending, GiverID: "giver", ReceiverID: "receiver"})
+	}
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+	provider := &fakePayments{}
+	handler.SetPayments(provider, time.Hour)
+
+	now := time.Now().UTC()
+	for id, releaseAt := range map[string]time.Time{"a1": now.Add(-time.Minute), "a2": now.Add(time.Hour), "a3": now.Add(time.Hour)} {
+		p := models.Pledge{ID: "p-" + id, ActID: id, GiverID: "giver", ReceiverID: "receiver", Amount: 10, Currency: "USD",
+			Provider: "fake", ProviderRef: "ref-" + id, ReleaseAt: releaseAt, CreatedAt: now}
+		if err := repos.Pledges.Create(ctx, &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repos.Acts.Update(ctx, "a3", models.UpdateActRequest{Status: models.ActStatusCancelled})
+
+	if err := handler.SettlePledges(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for id, status := range map[string]models.PledgeStatus{"a1": models.PledgeReleased, "a2": models.PledgeHeld, "a3": models.PledgeRefunded} {
+		if p, _ := repos.Pledges.ForAct(ctx, id); p.Status != status {
+			t.Errorf("%s: expected the pledge to be %s, got %s", id, status, p.Status)
+		}
+	}
+	if act, _ := repos.Acts.Get(ctx, "a1"); act.Status != models.ActStatusCompleted {
+		t.Errorf("expected the released act to be completed, got %s", act.Status)
+	}
+	if len(provider.captured) != 1 || len(provider.cancelled) != 1 {
+		t.Errorf("expected one capture and one cancellation, got %v and %v", provider.captured, provider.cancelled)
+	}
+}
+
+func TestSettlePledge_Claim(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver"}, "")
+	db.AddUser(models.User{ID: "receiver"}, "")
+	db.AddAct(models.Act{ID: "a1", Title: "Paid a bill", Type: models.ActTypeMonetary, Value: 10, Currency: "USD",
+		Status: models.ActStatusPending, GiverID: "giver", ReceiverID: "receiver"})
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+	provider := &fakePayments{fail: true}
+	handler.SetPayments(provider, time.Hour)
+
+	p := models.Pledge{ID: "p1", ActID: "a1", GiverID: "giver", ReceiverID: "receiver", Amount: 10, Currency: "USD",
+		Provider: "fake", ProviderRef: "ref-p1", ReleaseAt: time.Now().Add(time.Hour), CreatedAt: time.Now()}
+	if err := repos.Pledges.Create(ctx, &p); err != nil {
+		t.Fatal(err)
+	}
+	release := repository.PledgeChange{Status: models.PledgeReleased, At: time.Now().UTC()}
+
+	// A failed capture hands the pledge back for a retry
+	if _, err := handler.settlePledge(ctx, &p, []models.PledgeStatus{models.PledgeHeld}, release); !errors.Is(err, errPaymentProvider) {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	if got, _ := repos.Pledges.ForAct(ctx, "a1"); got.Status != models.PledgeHeld {
+		t.Fatalf("expected the pledge to be held again, got %s", got.Status)
+	}
+
+	// A claimed pledge can't be settled a second time
+	if err := repos.Pledges.Claim(ctx, "p1", []models.PledgeStatus{models.PledgeHeld}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	provider.fail = false
+	refund := repository.PledgeChange{Status: models.PledgeRefunded, At: time.Now().UTC()}
+	if _, err := handler.settlePledge(ctx, &p, []models.PledgeStatus{models.PledgeHeld}, refund); !errors.Is(err, repository.ErrPledgeState) {
+		t.Errorf("expected a claimed pledge to conflict, got %v", err)
+	}
+	if len(provider.captured) != 0 || len(provider.cancelled) != 0 {
+		t.Errorf("expected no funds to move, got %v and %v", provider.captured, provider.cancelled)
+	}
+}
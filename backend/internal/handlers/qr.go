@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"payforwardnow/internal/qrcode"
+	"payforwardnow/internal/repository"
+)
+
+const (
+	defaultLinkBase = "http://localhost:3000"
+
+	defaultQRSize = 512
+	minQRSize     = 64
+	maxQRSize     = 2048
+
+	// QR codes only change if the link base does, so they can be cached
+	// for a day
+	qrCacheControl = "public, max-age=86400"
+)
+
+// SetLinkBase sets the frontend address links in QR codes start with.
+// Empty keeps the default, http://localhost:3000.
+func (h *Handler) SetLinkBase(baseURL string) {
+	if baseURL != "" {
+		h.linkBase = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// GetChainQR handles GET /api/v1/chains/{id}/qr. It returns a QR code of
+// the chain's link, for printing on pay-it-forward cards passed on with
+// each act.
+func (h *Handler) GetChainQR(w http.ResponseWriter, r *http.Request) {
+	chain, err := h.chains.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrChainNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Chain not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch chain")
+		return
+	}
+	h.respondQR(w, r, "/chains/"+chain.ID, "chain-"+chain.ID)
+}
+
+// GetActQR handles GET /api/v1/acts/{id}/qr. It returns a QR code of the
+// act's link.
+func (h *Handler) GetActQR(w http.ResponseWriter, r *http.Request) {
+	act, err := h.acts.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch act")
+		return
+	}
+	h.respondQR(w, r, "/acts/"+act.ID, "act-"+act.ID)
+}
+
+// respondQR writes a QR code of the frontend link to path as a PNG or, with
+// ?format=svg, an SVG image ?size= pixels wide, with ?ecc= error correction
+func (h *Handler) respondQR(w http.ResponseWriter, r *http.Request, path, filename string) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "format must be 'png' or 'svg'")
+		return
+	}
+
+	size := defaultQRSize
+	if s := query.Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < minQRSize || n > maxQRSize {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("size must be between %d and %d", minQRSize, maxQRSize))
+			return
+		}
+		size = n
+	}
+
+	// Medium survives the wear of a card passed from hand to hand while
+	// keeping short links in small codes
+	level := qrcode.Medium
+	if s := query.Get("ecc"); s != "" {
+		var err error
+		if level, err = qrcode.ParseLevel(s); err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "ecc must be L, M, Q or H")
+			return
+		}
+	}
+
+	code, err := qrcode.Encode(h.linkBase+path, level)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "QR_FAILED", "Failed to generate QR code")
+		return
+	}
+
+	var body []byte
+	contentType := "image/svg+xml"
+	if format == "png" {
+		if body, err = code.PNG(size); err != nil {
+			respondError(w, http.StatusInternalServerError, "QR_FAILED", "Failed to generate QR code")
+			return
+		}
+		contentType = "image/png"
+	} else {
+		body = code.SVG(size)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s-qr.%s"`, filename, format))
+	w.Header().Set("Cache-Control", qrCacheControl)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
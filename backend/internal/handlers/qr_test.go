@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestGetQR(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Name: "Ada"}, "")
+	db.AddAct(models.Act{ID: "a1", GiverID: "u1", Title: "Bought a coffee", CreatedAt: time.Now()})
+	db.AddChain(models.Chain{ID: "c1", Name: "Coffee", StarterID: "u1", CreatedAt: time.Now()}, "a1")
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	handler.SetLinkBase("https://payforward.example/")
+
+	qr := func(target string, call func(http.ResponseWriter, *http.Request), id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		call(w, req)
+		return w
+	}
+
+	w := qr("/api/v1/chains/c1/qr?size=300", handler.GetChainQR, "c1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("expected a PNG by default, got %s", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "chain-c1-qr.png") {
+		t.Errorf("expected the chain in the file name, got %q", cd)
+	}
+	img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("expected a valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Errorf("expected a 300x300 image, got %v", b)
+	}
+
+	// A higher error correction level needs a larger code for the same link
+	low := qr("/api/v1/acts/a1/qr?format=svg&ecc=l", handler.GetActQR, "a1")
+	high := qr("/api/v1/acts/a1/qr?format=svg&ecc=H", handler.GetActQR, "a1")
+	if low.Code != http.StatusOK || low.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("expected an SVG, got %d %s", low.Code, low.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(low.Body.String(), `viewBox="0 0 37 37"`) || !strings.Contains(high.Body.String(), `viewBox="0 0 41 41"`) {
+		t.Errorf("expected a version 3 code at L and a version 4 code at H, got %.120s and %.120s", low.Body.String(), high.Body.String())
+	}
+
+	for _, query := range []string{"format=gif", "size=32", "size=4096", "size=big", "ecc=X"} {
+		if w := qr("/api/v1/chains/c1/qr?"+query, handler.GetChainQR, "c1"); w.Code != http.StatusBadRequest {
+			t.Errorf("expected %d for %s, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+	if w := qr("/api/v1/chains/missing/qr", handler.GetChainQR, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing chain, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := qr("/api/v1/acts/missing/qr", handler.GetActQR, "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a missing act, got %d", http.StatusNotFound, w.Code)
+	}
+}
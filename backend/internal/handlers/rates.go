@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/rates"
+	"payforwardnow/internal/repository"
+)
+
+// JobRefreshRates is the type of the job fetching the latest exchange
+// rates, scheduled daily and requested with RequestRatesRefresh
+const JobRefreshRates = "rates.refresh"
+
+// rateNormalizeBatch is the number of acts RefreshRates converts per
+// transaction
+const rateNormalizeBatch = 500
+
+// SetRates sets the provider exchange rates are fetched from and the base
+// currency act values are converted to for stats and leaderboards. Without
+// a provider, values are summed as given, whatever their currency.
+func (h *Handler) SetRates(p rates.Provider, base string) {
+	h.ratesProvider = p
+	h.baseCurrency = base
+}
+
+// RefreshRates fetches the latest exchange rates, stores them against the
+// base currency, then converts the values of the acts that weren't
+// converted yet, such as those created before their currency had a rate
+func (h *Handler) RefreshRates(ctx context.Context) error {
+	latest, err := h.ratesProvider.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	rebased, err := rates.Rebase(latest, h.baseCurrency)
+	if err != nil {
+		return err
+	}
+	rebased.Provider = h.ratesProvider.Name()
+	rebased.UpdatedAt = time.Now().UTC()
+	if err := h.rates.Replace(ctx, rebased); err != nil {
+		return fmt.Errorf("storing exchange rates: %w", err)
+	}
+
+	converted := 0
+	for {
+		n, err := h.rates.Normalize(ctx, rateNormalizeBatch)
+		if err != nil {
+			return fmt.Errorf("converting act values: %w", err)
+		}
+		converted += n
+		if n < rateNormalizeBatch {
+			break
+		}
+	}
+	slog.InfoContext(ctx, "Refreshed exchange rates", "provider", rebased.Provider, "base", rebased.Base,
+		"currencies", len(rebased.Rates), "date", rebased.Date.Format(time.DateOnly), "converted", converted)
+	return nil
+}
+
+// GetExchangeRates handles GET /api/v1/rates, the stored exchange rates
+// act values are converted to the base currency with
+func (h *Handler) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	if h.ratesProvider == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Exchange rates are disabled")
+		return
+	}
+	current, err := h.rates.Current(r.Context())
+	if errors.Is(err, repository.ErrNoRates) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "No exchange rates fetched yet")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch exchange rates")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    current,
+	})
+}
+
+// RequestRatesRefresh handles POST /api/v1/admin/rates/refresh, enqueueing
+// a refresh of the exchange rates ahead of the daily one
+func (h *Handler) RequestRatesRefresh(w http.ResponseWriter, r *http.Request) {
+	if h.ratesProvider == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Exchange rates are disabled")
+		return
+	}
+	if h.queue == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Background jobs are disabled")
+		return
+	}
+
+	job, err := h.queue.Enqueue(r.Context(), JobRefreshRates, nil)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to enqueue exchange rates refresh")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
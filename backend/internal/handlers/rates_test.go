@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+// fakeRates is a rates.Provider returning euro rates
+type fakeRates struct{}
+
+func (fakeRates) Fetch(context.Context) (*models.ExchangeRates, error) {
+	return &models.ExchangeRates{Base: "EUR", Rates: map[string]float64{"USD": 1.25, "GBP": 0.5}}, nil
+}
+
+func (fakeRates) Name() string { return "fake" }
+
+func TestExchangeRates(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver"}, "")
+	db.AddAct(models.Act{ID: "a1", Title: "Paid for groceries", Value: 10, Currency: "gbp", GiverID: "giver"})
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+
+	w := httptest.NewRecorder()
+	handler.GetExchangeRates(w, httptest.NewRequest(http.MethodGet, "/api/v1/rates", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d without a provider, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	handler.SetRates(fakeRates{}, "USD")
+	w = httptest.NewRecorder()
+	handler.GetExchangeRates(w, httptest.NewRequest(http.MethodGet, "/api/v1/rates", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d before the first refresh, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if err := handler.RefreshRates(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	handler.GetExchangeRates(w, httptest.NewRequest(http.MethodGet, "/api/v1/rates", nil))
+	var current struct{ Data models.ExchangeRates }
+	json.Unmarshal(w.Body.Bytes(), &current)
+	if current.Data.Base != "USD" || current.Data.Provider != "fake" || current.Data.Rates["USD"] != 1 || current.Data.Rates["EUR"] != 0.8 {
+		t.Errorf("expected the rates against the base currency, got %s", w.Body.String())
+	}
+
+	act, _ := repos.Acts.Get(context.Background(), "a1")
+	if act.BaseValue == nil || math.Abs(*act.BaseValue-25) > 1e-9 || act.BaseCurrency != "USD" {
+		t.Errorf("expected the existing act to be converted to 25 USD, got %v %s", act.BaseValue, act.BaseCurrency)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(
+		`{"title":"Paid a bus pass","description":"A month of commuting","type":"monetary","category":"other","value":8,"currency":"EUR"}`))
+	req = asUser(req, "giver")
+	w = httptest.NewRecorder()
+	handler.CreateAct(w, req)
+	var created struct{ Data models.Act }
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Data.BaseValue == nil || math.Abs(*created.Data.BaseValue-10) > 1e-9 {
+		t.Errorf("expected the new act to be converted to 10 USD, got %s", w.Body.String())
+	}
+}
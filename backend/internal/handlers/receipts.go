@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/reports"
+	"payforwardnow/internal/repository"
+)
+
+const (
+	defaultReceiptPrefix = "PF"
+	defaultReceiptIssuer = "PayForward Now"
+
+	// receiptStatement is printed on every receipt: acts are gifts, so the
+	// donor gets nothing in return
+	receiptStatement = "No goods or services were provided in exchange for this donation."
+)
+
+// SetReceipts sets the prefix of donation receipt numbers and the
+// organization named as their issuer. Empty values keep the defaults.
+func (h *Handler) SetReceipts(prefix string, issuer models.ReceiptIssuer) {
+	if prefix != "" {
+		h.receiptPrefix = prefix
+	}
+	if issuer.Name == "" {
+		issuer.Name = defaultReceiptIssuer
+	}
+	h.issuer = issuer
+}
+
+// completeReceipt fills in the number, issuer and statement of receipt
+func (h *Handler) completeReceipt(receipt *models.DonationReceipt) {
+	receipt.Number = fmt.Sprintf("%s-%d-%06d", h.receiptPrefix, receipt.TaxYear, receipt.Sequence)
+	receipt.Issuer = h.issuer
+	receipt.Statement = receiptStatement
+}
+
+// GetActReceipt handles GET /api/v1/acts/{id}/receipt, the donation
+// receipt of a completed monetary act as JSON or, with ?format=pdf, a PDF.
+// Only the giver and admins can see it, as it holds the giver's contact
+// details, and it's only issued for them.
+func (h *Handler) GetActReceipt(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "pdf" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "format must be 'json' or 'pdf'")
+		return
+	}
+
+	giverID, err := h.acts.Giver(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch act")
+		return
+	}
+	if giverID != currentUserID(r) && !isAdmin(r) {
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the giver can see the receipt of an act")
+		return
+	}
+
+	receipt, err := h.receipts.ForAct(r.Context(), r.PathValue("id"), time.Now().UTC())
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		return
+	}
+	if errors.Is(err, repository.ErrNoReceipt) {
+		respondError(w, http.StatusNotFound, "NO_RECEIPT", "Receipts are only issued for completed monetary acts")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch receipt")
+		return
+	}
+	h.completeReceipt(receipt)
+
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="receipt-%s.pdf"`, receipt.Number))
+		w.WriteHeader(http.StatusOK)
+		w.Write(renderReceiptPDF(receipt))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    receipt,
+	})
+}
+
+func renderReceiptPDF(receipt *models.DonationReceipt) []byte {
+	lines := []string{
+		"Receipt number: " + receipt.Number,
+		fmt.Sprintf("Tax year: %d", receipt.TaxYear),
+		"Issued: " + receipt.IssuedAt.Format("2 January 2006"),
+		"",
+		"Issued by: " + receipt.Issuer.Name,
+	}
+	if receipt.Issuer.TaxID != "" {
+		lines = append(lines, "Tax ID: "+receipt.Issuer.TaxID)
+	}
+	if receipt.Issuer.Address != "" {
+		lines = append(lines, receipt.Issuer.Address)
+	}
+	lines = append(lines, "", "Donor: "+receipt.Donor.Name)
+	if receipt.Donor.Email != "" {
+		lines = append(lines, receipt.Donor.Email)
+	}
+	if receipt.Donor.Location != "" {
+		lines = append(lines, receipt.Donor.Location)
+	}
+	lines = append(lines, "")
+	if receipt.Recipient != nil {
+		lines = append(lines, "Recipient: "+receipt.Recipient.Name)
+	}
+	lines = append(lines,
+		"Donation: "+receipt.Description,
+		"Date of donation: "+receipt.DonatedAt.Format("2 January 2006"),
+		"Amount: "+formatAmount(receipt.Amount, receipt.Currency),
+		"",
+		receipt.Statement,
+	)
+	return reports.RenderTextPDF("Donation Receipt", lines)
+}
+
+// formatAmount formats amount with its currency code, if any
+func formatAmount(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", amount)
+	}
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/models"
+)
+
+func TestActReceipts(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver", Name: "Ada", Email: "ada@example.com", Location: "London"}, "")
+	db.AddUser(models.User{ID: "receiver", Name: "Grace"}, "")
+	march := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	june := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	for _, act := range []models.Act{
+		{ID: "a1", Title: "Paid for groceries", Type: models.ActTypeMonetary, Value: 40, Currency: "EUR", Status: models.ActStatusCompleted, GiverID: "giver", ReceiverID: "receiver", CompletedAt: &march},
+		{ID: "a2", Title: "Covered a bus fare", Type: models.ActTypeMonetary, Value: 2.5, Currency: "EUR", Status: models.ActStatusCompleted, GiverID: "giver", ReceiverID: "receiver", CompletedAt: &june},
+		{ID: "a5", Title: "Sent some money", Type: models.ActTypeMonetary, Value: 5, Currency: "EUR", Status: models.ActStatusCompleted, GiverID: "giver", CompletedAt: &june},
+		{ID: "a3", Title: "Fixed a bike", Type: models.ActTypeService, Status: models.ActStatusCompleted, GiverID: "giver", CompletedAt: &june},
+		{ID: "a4", Title: "Pledged a donation", Type: models.ActTypeMonetary, Value: 10, Status: models.ActStatusPending, GiverID: "giver"},
+	} {
+		db.AddAct(act)
+	}
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	handler.SetReceipts("PFN", models.ReceiptIssuer{TaxID: "GB123"})
+
+	get := func(id, userID, format string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/acts/"+id+"/receipt?format="+format, nil)
+		req.SetPathValue("id", id)
+		req = asUser(req, userID)
+		if admin {
+			req = req.WithContext(context.WithValue(req.Context(), middleware.RolesKey, []string{middleware.AdminRole}))
+		}
+		w := httptest.NewRecorder()
+		handler.GetActReceipt(w, req)
+		return w
+	}
+
+	w := get("a2", "giver", "", false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var got struct{ Data models.DonationReceipt }
+	json.Unmarshal(w.Body.Bytes(), &got)
+	receipt := got.Data
+	if receipt.Number != "PFN-2025-000001" || receipt.Amount != 2.5 || receipt.Currency != "EUR" || !receipt.DonatedAt.Equal(june) {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+	if receipt.Donor.Email != "ada@example.com" || receipt.Issuer.Name != defaultReceiptIssuer || receipt.Issuer.TaxID != "GB123" || receipt.Statement == "" {
+		t.Errorf("expected the donor, issuer and statement on the receipt, got %+v", receipt)
+	}
+
+	// Receipts aren't issued for users who can't see them
+	if w := get("a1", "receiver", "", false); w.Code != http.StatusForbidden {
+		t.Errorf("expected %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	json.Unmarshal(get("a1", "", "", true).Body.Bytes(), &got)
+	if got.Data.Number != "PFN-2025-000002" || got.Data.Recipient == nil || got.Data.Recipient.Name != "Grace" {
+		t.Errorf("expected admins to get the next receipt with its recipient, got %+v", got.Data)
+	}
+	json.Unmarshal(get("a2", "giver", "", false).Body.Bytes(), &got)
+	if got.Data.Number != receipt.Number || !got.Data.IssuedAt.Equal(receipt.IssuedAt) {
+		t.Errorf("expected the receipt to be issued once, got %+v", got.Data)
+	}
+
+	if w := get("a2", "receiver", "", false); w.Code != http.StatusForbidden {
+		t.Errorf("expected %d for another user, got %d", http.StatusForbidden, w.Code)
+	}
+	for _, id := range []string{"a3", "a4", "a5", "missing"} {
+		if w := get(id, "giver", "", false); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected %d, got %d", id, http.StatusNotFound, w.Code)
+		}
+	}
+	if w := get("a2", "giver", "pdf", false); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("expected a PDF receipt, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if w := get("a2", "giver", "xml", false); w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	receipts, err := handler.receipts.ForGiver(context.Background(), "giver", march.AddDate(0, -2, 0), june.AddDate(0, 1, 0), time.Now())
+	if err != nil || len(receipts) != 2 || receipts[0].ActID != "a2" || receipts[1].ActID != "a1" {
+		t.Errorf("expected the impact report's receipts in number order, got %+v and %v", receipts, err)
+	}
+}
+
+func TestUpdateAct_OnlyReceiverCompletes(t *testing.T) {
+	db := databasetest.New()
+	db.AddAct(models.Act{ID: "a1", Title: "Paid for groceries", Type: models.ActTypeMonetary, Value: 40, GiverID: "giver", ReceiverID: "receiver"})
+	db.AddAct(models.Act{ID: "a2", Title: "Paid for a coffee", Type: models.ActTypeMonetary, Value: 3, GiverID: "giver", IsAnonymous: true})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	complete := func(id, userID string) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/acts/"+id, strings.NewReader(`{"status":"completed"}`))
+		req.SetPathValue("id", id)
+		req = asUser(req, userID)
+		w := httptest.NewRecorder()
+		handler.UpdateAct(w, req)
+		return w.Code
+	}
+
+	for _, userID := range []string{"", "giver", "someone"} {
+		if code := complete("a1", userID); code != http.StatusForbidden {
+			t.Errorf("%q completing a received act: expected %d, got %d", userID, http.StatusForbidden, code)
+		}
+	}
+	if code := complete("a1", "receiver"); code != http.StatusOK {
+		t.Errorf("expected the receiver to complete the act, got %d", code)
+	}
+	if code := complete("a2", "giver"); code != http.StatusOK {
+		t.Errorf("expected the giver to complete an act without a receiver, got %d", code)
+	}
+
+	// Confirming the act issues its receipt
+	later := time.Now().Add(time.Hour)
+	receipt, err := db.Repositories().Receipts.ForAct(context.Background(), "a1", later)
+	if err != nil || receipt.IssuedAt.Equal(later) || !receipt.IssuedAt.Equal(receipt.DonatedAt) {
+		t.Errorf("expected the receipt issued when the act was completed, got %+v and %v", receipt, err)
+	}
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sort"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+const (
+	// recommendationSeeds is the number of the user's latest acts whose
+	// similar acts are recommended
+	recommendationSeeds = 3
+	// recommendationCandidates is how many more acts than asked for are
+	// read from the graph, so similarity can reorder them
+	recommendationCandidates = 3
+)
+
+// recommendationWeights score each reason a recommendation is given for.
+// Similarity, from 0 to 1, is scaled by the weight of ReasonSimilar.
+var recommendationWeights = map[models.RecommendationReason]float64{
+	models.ReasonConnection:     3,
+	models.ReasonFriendOfFriend: 1,
+	models.ReasonCategory:       1,
+	models.ReasonSimilar:        2,
+}
+
+// GetRecommendations handles GET /api/v1/recommendations?limit=, suggesting
+// acts to join, chains to follow and needs to fulfill to the signed in
+// user. Suggestions come from the graph around the user, acts and chains
+// of the people they gave to, received from or shared a chain with and of
+// their connections, or in the categories they gave and received in, and
+// from the acts most similar to their latest ones in the embedding index,
+// when there is one.
+func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+	limit, ok := similarLimit(w, r)
+	if !ok {
+		return
+	}
+
+	recommendations, err := h.recommend(r.Context(), userID, limit)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch recommendations")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    recommendations,
+	})
+}
+
+// recommend returns up to limit recommendations of each kind for a user
+func (h *Handler) recommend(ctx context.Context, userID string, limit int) (*models.Recommendations, error) {
+	network, err := h.recommendations.Network(ctx, userID, recommendationSeeds)
+	if err != nil {
+		return nil, err
+	}
+	acts, err := h.recommendations.Acts(ctx, "acts", userID, network, limit*recommendationCandidates)
+	if err != nil {
+		return nil, err
+	}
+	needs, err := h.recommendations.Acts(ctx, "needs", userID, network, limit*recommendationCandidates)
+	if err != nil {
+		return nil, err
+	}
+	chains, err := h.recommendations.Chains(ctx, userID, network, limit*recommendationCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	similar, err := h.similarToLatest(ctx, network.RecentActs, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range similar {
+		act := s.Act
+		if act.GiverID == userID || act.ReceiverID == userID {
+			continue
+		}
+		if isNeed(&act) {
+			needs = addSimilarAct(needs, s)
+		}
+		if act.ChainID == "" {
+			continue
+		}
+		members, err := h.chains.Members(ctx, act.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(members, userID) || act.Status == models.ActStatusCancelled || act.Status == models.ActStatusExpired {
+			continue
+		}
+		acts = addSimilarAct(acts, s)
+		if chains, err = h.addSimilarChain(ctx, chains, act.ChainID, s.Score); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.Recommendations{
+		Acts:   rankActs(acts, limit),
+		Chains: rankChains(chains, limit),
+		Needs:  rankActs(needs, limit),
+	}, nil
+}
+
+// similarToLatest returns the acts most similar to any of the latest acts
+// of a user, with their best similarity, skipping those deleted since they
+// were indexed
+func (h *Handler) similarToLatest(ctx context.Context, latest []string, limit int) ([]models.SimilarAct, error) {
+	if h.similar == nil {
+		return nil, nil
+	}
+	best := map[string]float64{}
+	var ids []string
+	for _, id := range latest {
+		matches, err := h.similar.Similar(ctx, id, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if slices.Contains(latest, m.ID) {
+				continue
+			}
+			if score, seen := best[m.ID]; !seen {
+				ids = append(ids, m.ID)
+				best[m.ID] = m.Score
+			} else if m.Score > score {
+				best[m.ID] = m.Score
+			}
+		}
+	}
+
+	similar := make([]models.SimilarAct, 0, len(ids))
+	for _, id := range ids {
+		act, err := h.acts.Get(ctx, id)
+		if errors.Is(err, repository.ErrActNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		similar = append(similar, models.SimilarAct{Act: *act, Score: best[id]})
+	}
+	return similar, nil
+}
+
+// addSimilarChain marks the chain with id as similar, fetching it if it
+// isn't among chains yet
+func (h *Handler) addSimilarChain(ctx context.Context, chains []models.RecommendedChain, id string, score float64) ([]models.RecommendedChain, error) {
+	for i := range chains {
+		if chains[i].Chain.ID == id {
+			chains[i].Reasons = addReason(chains[i].Reasons, models.ReasonSimilar)
+			chains[i].Score = max(chains[i].Score, score)
+			return chains, nil
+		}
+	}
+	chain, err := h.chains.Get(ctx, id)
+	if errors.Is(err, repository.ErrChainNotFound) {
+		return chains, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(chains, models.RecommendedChain{
+		Chain:   *chain,
+		Score:   score,
+		Reasons: []models.RecommendationReason{models.ReasonSimilar},
+	}), nil
+}
+
+// addSimilarAct marks a similar act as such among acts, adding it if it
+// isn't there. Until ranked, Score holds the similarity.
+func addSimilarAct(acts []models.RecommendedAct, similar models.SimilarAct) []models.RecommendedAct {
+	for i := range acts {
+		if acts[i].Act.ID == similar.Act.ID {
+			acts[i].Reasons = addReason(acts[i].Reasons, models.ReasonSimilar)
+			acts[i].Score = max(acts[i].Score, similar.Score)
+			return acts
+		}
+	}
+	return append(acts, models.RecommendedAct{
+		Act:     similar.Act,
+		Score:   similar.Score,
+		Reasons: []models.RecommendationReason{models.ReasonSimilar},
+	})
+}
+
+// isNeed reports whether an act is still waiting for a receiver
+func isNeed(act *models.Act) bool {
+	return (act.Status == "" || act.Status == models.ActStatusPending) && act.ReceiverID == ""
+}
+
+func addReason(reasons []models.RecommendationReason, reason models.RecommendationReason) []models.RecommendationReason {
+	if slices.Contains(reasons, reason) {
+		return reasons
+	}
+	return append(reasons, reason)
+}
+
+// recommendationScore weighs the reasons of a recommendation, with
+// similarity its similarity if it is similar
+func recommendationScore(reasons []models.RecommendationReason, similarity float64) float64 {
+	score := 0.0
+	for _, reason := range reasons {
+		if reason == models.ReasonSimilar {
+			score += recommendationWeights[reason] * similarity
+		} else {
+			score += recommendationWeights[reason]
+		}
+	}
+	return score
+}
+
+// rankActs scores acts, whose Score holds their similarity, and returns
+// the best limit, keeping the order they were found in for equal scores
+func rankActs(acts []models.RecommendedAct, limit int) []models.RecommendedAct {
+	for i := range acts {
+		acts[i].Score = recommendationScore(acts[i].Reasons, acts[i].Score)
+	}
+	sort.SliceStable(acts, func(i, j int) bool { return acts[i].Score > acts[j].Score })
+	if acts == nil {
+		acts = []models.RecommendedAct{}
+	}
+	return acts[:min(limit, len(acts))]
+}
+
+// rankChains scores and returns the best limit chains, like rankActs
+func rankChains(chains []models.RecommendedChain, limit int) []models.RecommendedChain {
+	for i := range chains {
+		chains[i].Score = recommendationScore(chains[i].Reasons, chains[i].Score)
+	}
+	sort.SliceStable(chains, func(i, j int) bool { return chains[i].Score > chains[j].Score })
+	if chains == nil {
+		chains = []models.RecommendedChain{}
+	}
+	return chains[:min(limit, len(chains))]
+}
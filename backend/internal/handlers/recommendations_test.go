@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/models"
+)
+
+func TestGetRecommendations(t *testing.T) {
+	db := databasetest.New()
+	for _, id := range []string{"me", "friend", "fof", "stranger"} {
+		db.AddUser(models.User{ID: id}, "")
+	}
+	now := time.Now().UTC()
+	db.AddAct(models.Act{ID: "m1", Title: "Cooked dinner", Category: "food", GiverID: "me", ReceiverID: "friend", CreatedAt: now})
+	db.AddAct(models.Act{ID: "f1", Title: "Fixed a laptop", Category: "tech", GiverID: "friend", ReceiverID: "fof", CreatedAt: now})
+	db.AddAct(models.Act{ID: "c1a", Title: "Taught coding", Category: "tech", Status: models.ActStatusPending,
+		GiverID: "fof", ReceiverID: "stranger", ChainID: "c1", CreatedAt: now})
+	db.AddChain(models.Chain{ID: "c1", Name: "Code forward", StarterID: "fof"}, "c1a")
+	db.AddAct(models.Act{ID: "n1", Title: "Groceries to share", Category: "food", Status: models.ActStatusPending, GiverID: "stranger", CreatedAt: now})
+	db.AddAct(models.Act{ID: "n2", Title: "Books to give away", Category: "books", Status: models.ActStatusPending,
+		GiverID: "friend", IsAnonymous: true, CreatedAt: now})
+	db.AddAct(models.Act{ID: "n3", Title: "Paint to give away", Category: "art", Status: models.ActStatusPending, GiverID: "stranger", CreatedAt: now})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	handler.SetEmbeddings(fakeSearcher{matches: []embeddings.Match{{ID: "n3", Score: 0.9}, {ID: "m1", Score: 0.8}}})
+
+	get := func(userID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/recommendations"+query, nil)
+		if userID != "" {
+			req = asUser(req, userID)
+		}
+		w := httptest.NewRecorder()
+		handler.GetRecommendations(w, req)
+		return w
+	}
+	if w := get("", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d without a user, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := get("me", "?limit=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for limit=0, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w := get("me", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct{ Data models.Recommendations }
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	ids := func(acts []models.RecommendedAct) []string {
+		var ids []string
+		for _, a := range acts {
+			ids = append(ids, a.Act.ID)
+		}
+		return ids
+	}
+	if got := ids(resp.Data.Acts); !slices.Equal(got, []string{"c1a"}) ||
+		!slices.Equal(resp.Data.Acts[0].Reasons, []models.RecommendationReason{models.ReasonFriendOfFriend}) {
+		t.Errorf("expected the act in the chain of a friend of a friend, got %+v", resp.Data.Acts)
+	}
+	if len(resp.Data.Chains) != 1 || resp.Data.Chains[0].Chain.ID != "c1" || resp.Data.Chains[0].Chain.ActsCount != 1 {
+		t.Errorf("expected the chain of a friend of a friend, got %+v", resp.Data.Chains)
+	}
+	// n3 is similar to the user's latest act and outranks n1, sharing its
+	// category; the giver of the anonymous n2 doesn't count as a connection
+	if got := ids(resp.Data.Needs); !slices.Equal(got, []string{"n3", "n1"}) {
+		t.Errorf("expected the similar then same-category needs, got %v", got)
+	}
+	if needs := resp.Data.Needs; len(needs) == 2 && (needs[0].Score != 1.8 || needs[1].Score != 1) {
+		t.Errorf("expected scores 1.8 and 1, got %v and %v", needs[0].Score, needs[1].Score)
+	}
+
+	w = get("stranger", "?limit=1")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Data.Needs) > 1 || len(resp.Data.Acts) > 1 || len(resp.Data.Chains) > 1 {
+		t.Errorf("expected at most one recommendation of each kind, got %s", w.Body.String())
+	}
+}
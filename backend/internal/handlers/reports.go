@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+	"payforwardnow/internal/reports"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// heavyReportThreshold is the number of acts above which an impact report is
+// generated in the background instead of inline with the request
+const heavyReportThreshold = 500
+
+// GetImpactReport handles GET /api/v1/users/{id}/impact-report
+func (h *Handler) GetImpactReport(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+	ctx := r.Context()
+
+	year := time.Now().UTC().Year()
+	if y := r.URL.Query().Get("year"); y != "" {
+		parsed, err := strconv.Atoi(y)
+		if err != nil || parsed < 2000 || parsed > year {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "Invalid year")
+			return
+		}
+		year = parsed
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "pdf" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "format must be 'json' or 'pdf'")
+		return
+	}
+
+	key := tenantCacheKey(ctx, fmt.Sprintf("impact:%s:%d", userID, year))
+
+	entry := h.reports.Get(key)
+	if entry == nil {
+		actCount, err := h.countUserActs(ctx, userID, year)
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to generate impact report")
+			return
+		}
+		if actCount < 0 {
+			respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+			return
+		}
+
+		if actCount > heavyReportThreshold {
+			if h.reports.MarkPending(key) {
+				err := h.runInBackground(ctx, "impact_report", func(ctx context.Context) error {
+					return h.generateImpactReportAsync(ctx, key, userID, year)
+				})
+				if err != nil {
+					h.reports.Delete(key)
+					w.Header().Set("Retry-After", "30")
+					respondError(w, http.StatusServiceUnavailable, "SERVICE_BUSY", "Too many reports are being generated, please retry later")
+					return
+				}
+			}
+			entry = &reports.Entry{Status: reports.StatusPending}
+		} else {
+			report, err := h.buildImpactReport(ctx, userID, year)
+			if err != nil {
+				respondDatabaseError(w, err, "Failed to generate impact report")
+				return
+			}
+			h.reports.SetReady(key, report)
+			entry = &reports.Entry{Status: reports.StatusReady, Report: report}
+		}
+	}
+
+	switch entry.Status {
+	case reports.StatusPending:
+		w.Header().Set("Retry-After", "5")
+		respondJSON(w, http.StatusAccepted, models.APIResponse{
+			Success: true,
+			Data:    map[string]string{"status": string(reports.StatusPending)},
+		})
+		return
+	case reports.StatusFailed:
+		respondError(w, http.StatusInternalServerError, "REPORT_FAILED", "Failed to generate impact report")
+		return
+	}
+
+	report := entry.Report.(*models.ImpactReport)
+	if userID == currentUserID(r) {
+		// Receipts are the user's own business, so they're added to the
+		// shared report for them alone
+		own := *report
+		if err := h.addReceipts(ctx, &own); err != nil {
+			respondDatabaseError(w, err, "Failed to fetch receipts")
+			return
+		}
+		report = &own
+	}
+
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="impact-report-%d.pdf"`, year))
+		w.WriteHeader(http.StatusOK)
+		w.Write(renderImpactReportPDF(report))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// generateImpactReportAsync builds a report after its request has returned.
+// ctx keeps the request's values, such as its tenant database, but not its
+// cancellation.
+func (h *Handler) generateImpactReportAsync(ctx context.Context, key, userID string, year int) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	report, err := h.buildImpactReport(ctx, userID, year)
+	if err != nil {
+		h.reports.SetFailed(key, err)
+		return fmt.Errorf("impact report for %s in %d: %w", userID, year, err)
+	}
+	h.reports.SetReady(key, report)
+	return nil
+}
+
+// countUserActs returns the number of acts a user gave in the given year, or
+// -1 if the user does not exist
+func (h *Handler) countUserActs(ctx context.Context, userID string, year int) (int64, error) {
+	from, to := yearBounds(year)
+
+	return database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) (int64, error) {
+		result, err := queries.ReportActCount.Run(ctx, tx, map[string]interface{}{
+			"userId": userID,
+			"from":   from,
+			"to":     to,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		if result.Next(ctx) {
+			return getInt64(result.Record(), "total"), nil
+		}
+		return -1, result.Err()
+	})
+}
+
+func (h *Handler) buildImpactReport(ctx context.Context, userID string, year int) (*models.ImpactReport, error) {
+	from, to := yearBounds(year)
+	params := map[string]interface{}{
+		"userId": userID,
+		"from":   from,
+		"to":     to,
+	}
+
+	return database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) (*models.ImpactReport, error) {
+		report := &models.ImpactReport{
+			UserID:        userID,
+			Year:          year,
+			TopCategories: []models.CategoryStats{},
+			GeneratedAt:   time.Now().UTC(),
+		}
+
+		givenResult, err := queries.ReportGiven.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		if givenResult.Next(ctx) {
+			record := givenResult.Record()
+			report.ActsGiven = getInt64(record, "actsGiven")
+			report.ChainsReached = getInt64(record, "chainsReached")
+			report.PeopleTouched = getInt64(record, "peopleTouched")
+		}
+
+		receivedResult, err := queries.ReportReceived.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		if receivedResult.Next(ctx) {
+			report.ActsReceived = getInt64(receivedResult.Record(), "actsReceived")
+		}
+
+		categoryResult, err := queries.ReportCategories.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		for categoryResult.Next(ctx) {
+			record := categoryResult.Record()
+			key, _ := record.Get("key")
+			keyStr, _ := key.(string)
+			stats := models.CategoryStats{
+				Key:        keyStr,
+				ActsCount:  getInt64(record, "actsCount"),
+				TotalValue: getFloat64(record, "totalValue"),
+			}
+			report.TotalValue += stats.TotalValue
+			if len(report.TopCategories) < 5 {
+				report.TopCategories = append(report.TopCategories, stats)
+			}
+		}
+
+		return report, categoryResult.Err()
+	})
+}
+
+// addReceipts lists the receipts of the acts the report's user completed
+// in its year
+func (h *Handler) addReceipts(ctx context.Context, report *models.ImpactReport) error {
+	from, to := yearBounds(report.Year)
+	receipts, err := h.receipts.ForGiver(ctx, report.UserID, from, to, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	report.Receipts = make([]models.ReceiptSummary, 0, len(receipts))
+	for _, receipt := range receipts {
+		h.completeReceipt(&receipt)
+		report.Receipts = append(report.Receipts, models.ReceiptSummary{
+			Number:    receipt.Number,
+			ActID:     receipt.ActID,
+			Amount:    receipt.Amount,
+			Currency:  receipt.Currency,
+			DonatedAt: receipt.DonatedAt,
+		})
+	}
+	return nil
+}
+
+func renderImpactReportPDF(report *models.ImpactReport) []byte {
+	lines := []string{
+		fmt.Sprintf("Acts of kindness given: %d", report.ActsGiven),
+		fmt.Sprintf("Acts of kindness received: %d", report.ActsReceived),
+		fmt.Sprintf("Chains reached: %d", report.ChainsReached),
+		fmt.Sprintf("People touched: %d", report.PeopleTouched),
+		fmt.Sprintf("Total value given: %.2f", report.TotalValue),
+		"",
+		"Top categories:",
+	}
+	for _, category := range report.TopCategories {
+		lines = append(lines, fmt.Sprintf("  %s - %d acts", category.Key, category.ActsCount))
+	}
+	if len(report.Receipts) > 0 {
+		lines = append(lines, "", "Donation receipts:")
+		for _, receipt := range report.Receipts {
+			lines = append(lines, fmt.Sprintf("  %s - %s - %s", receipt.Number,
+				receipt.DonatedAt.Format("2 Jan 2006"), formatAmount(receipt.Amount, receipt.Currency)))
+		}
+	}
+	lines = append(lines, "", "Generated "+report.GeneratedAt.Format(time.RFC1123))
+
+	return reports.RenderTextPDF(fmt.Sprintf("Your %d Pay It Forward Impact", report.Year), lines)
+}
+
+func yearBounds(year int) (time.Time, time.Time) {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(1, 0, 0)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestGetImpactReport_InvalidYear(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/user-1/impact-report?year=1999", nil)
+	req.SetPathValue("id", "user-1")
+	w := httptest.NewRecorder()
+
+	handler.GetImpactReport(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestGetImpactReport_Pending(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	handler.reports.MarkPending("impact:user-1:2024")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/user-1/impact-report?year=2024", nil)
+	req.SetPathValue("id", "user-1")
+	w := httptest.NewRecorder()
+
+	handler.GetImpactReport(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on pending report")
+	}
+}
+
+func TestGetImpactReport_Cached(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	handler.reports.SetReady("impact:user-1:2024", &models.ImpactReport{
+		UserID:    "user-1",
+		Year:      2024,
+		ActsGiven: 12,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/user-1/impact-report?year=2024", nil)
+	req.SetPathValue("id", "user-1")
+	w := httptest.NewRecorder()
+
+	handler.GetImpactReport(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Success bool                `json:"success"`
+		Data    models.ImpactReport `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.ActsGiven != 12 {
+		t.Errorf("expected actsGiven 12, got %d", response.Data.ActsGiven)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/user-1/impact-report?year=2024&format=pdf", nil)
+	req.SetPathValue("id", "user-1")
+	w = httptest.NewRecorder()
+
+	handler.GetImpactReport(w, req)
+
+	if w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("expected application/pdf, got %s", w.Header().Get("Content-Type"))
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")) {
+		t.Error("expected PDF body")
+	}
+}
+
+func TestGetImpactReport_Receipts(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "user-1"}, "")
+	march := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	for _, id := range []string{"a1", "a2"} {
+		db.AddAct(models.Act{ID: id, Title: "Paid a bill", Type: models.ActTypeMonetary, Value: 10, Currency: "EUR",
+			Status: models.ActStatusCompleted, GiverID: "user-1", ReceiverID: "user-2", CompletedAt: &march})
+	}
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+	handler.reports.SetReady("impact:user-1:2024", &models.ImpactReport{UserID: "user-1", Year: 2024, ActsGiven: 2})
+
+	report := func(userID string) models.ImpactReport {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/user-1/impact-report?year=2024", nil)
+		req.SetPathValue("id", "user-1")
+		req = asUser(req, userID)
+		w := httptest.NewRecorder()
+		handler.GetImpactReport(w, req)
+		var response struct{ Data models.ImpactReport }
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data
+	}
+
+	if got := report("user-1"); len(got.Receipts) != 2 || got.Receipts[0].ActID != "a1" || got.Receipts[1].ActID != "a2" {
+		t.Errorf("expected the user's receipts in the order the acts were completed, got %+v", got.Receipts)
+	}
+	if got := report("user-2"); got.Receipts != nil {
+		t.Errorf("expected no receipts for another user, got %+v", got.Receipts)
+	}
+	if got := report(""); got.Receipts != nil || got.ActsGiven != 2 {
+		t.Errorf("expected the public report without receipts, got %+v", got)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/migrations"
+	"payforwardnow/internal/models"
+)
+
+// SetSchemaReadiness attaches the outcome of schema setup at startup, which
+// the health check reports
+func (h *Handler) SetSchemaReadiness(r *migrations.Readiness) {
+	h.schema = r
+}
+
+// GetSchemaDrift handles GET /api/v1/admin/schema/drift. It compares the
+// constraints and indexes of the request's database with the ones the
+// migrations define, without changing either.
+func (h *Handler) GetSchemaDrift(w http.ResponseWriter, r *http.Request) {
+	schema, err := migrations.Embedded()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Invalid migrations")
+		return
+	}
+
+	drift, err := migrations.New(h.db, schema).Drift(r.Context(), embeddings.IndexName)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to compare schema")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    drift,
+	})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/migrations"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestGetSchemaDrift(t *testing.T) {
+	schema, err := migrations.Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := migrations.Expected(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied := make(map[int]time.Time, len(schema))
+	for _, m := range schema {
+		applied[m.Version] = time.Now()
+	}
+	actual := append(expected[1:], migrations.SchemaObject{
+		Kind: migrations.KindIndex, Name: "act_embedding", Type: "VECTOR",
+		Labels: []string{"Act"}, Properties: []string{"embedding"},
+	})
+
+	results := []interface{}{applied, actual}
+	handler := NewHandler(&MockDBClient{
+		ExecuteReadFunc: func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+			result := results[0]
+			results = results[1:]
+			return result, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	handler.GetSchemaDrift(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/schema/drift", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Data migrations.Drift `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Missing) != 1 || resp.Data.Missing[0].Name != expected[0].Name {
+		t.Errorf("expected %s missing, got %v", expected[0].Name, resp.Data.Missing)
+	}
+	if len(resp.Data.Unexpected) != 0 || len(resp.Data.Changed) != 0 || len(resp.Data.Pending) != 0 {
+		t.Errorf("expected only a missing object, got %+v", resp.Data)
+	}
+}
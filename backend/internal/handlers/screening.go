@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/repository"
+)
+
+// SetModerationProvider sets the provider content is screened with along
+// with the word lists, such as a moderation API
+func (h *Handler) SetModerationProvider(p moderation.Provider) {
+	h.screener.SetProvider(p)
+}
+
+// respondContentBlocked responds that screening blocked the submitted
+// text, naming what it found
+func respondContentBlocked(w http.ResponseWriter, screening moderation.Result) {
+	respondError(w, http.StatusUnprocessableEntity, "CONTENT_BLOCKED",
+		"The content was blocked by automated screening: {flags}", "flags", strings.Join(screening.Flags, ", "))
+}
+
+// recordActScreening stores the screening of an act's title and
+// description, so flagged acts reach the moderators. A failure is logged
+// rather than failing the write the act was screened for.
+func (h *Handler) recordActScreening(ctx context.Context, actID string, screening moderation.Result) {
+	if err := h.acts.Screen(ctx, actID, screening, time.Now()); err != nil {
+		slog.WarnContext(ctx, "Failed to record act screening", "actId", actID, "error", err)
+	}
+}
+
+// GetFlaggedActs handles GET /api/v1/admin/acts/flagged, the acts whose
+// title or description screening flagged, highest score first
+func (h *Handler) GetFlaggedActs(w http.ResponseWriter, r *http.Request) {
+	params := getPaginationParams(r)
+
+	acts, total, err := h.acts.Flagged(r.Context(), params)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch flagged acts")
+		return
+	}
+
+	totalPages := (int(total) + params.PerPage - 1) / params.PerPage
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    acts,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+// DismissActFlag handles DELETE /api/v1/admin/acts/{id}/flag, clearing
+// the flag of an act a moderator found acceptable
+func (h *Handler) DismissActFlag(w http.ResponseWriter, r *http.Request) {
+	err := h.acts.DismissFlag(r.Context(), r.PathValue("id"), currentUserID(r), time.Now())
+	if errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Flagged act not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to dismiss flag")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Flag dismissed"},
+	})
+}
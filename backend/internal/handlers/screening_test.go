@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestActScreening(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1"}, "")
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	create := func(title, description string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.CreateActRequest{Title: title, Description: description, Type: "help", Category: "community"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(string(body)))
+		req = asUser(req, "u1")
+		w := httptest.NewRecorder()
+		handler.CreateAct(w, req)
+		return w
+	}
+	flagged := func() []models.FlaggedAct {
+		w := httptest.NewRecorder()
+		handler.GetFlaggedActs(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/acts/flagged", nil))
+		var resp struct{ Data []models.FlaggedAct }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+
+	w := create("CLICK HERE to EARN MONEY", "Fast cash!!! https://a.example https://b.example https://c.example")
+	if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), "CONTENT_BLOCKED") {
+		t.Fatalf("expected spam blocked, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := create("Groceries for a neighbour", "Carried the shopping up four floors every week"); w.Code != http.StatusCreated {
+		t.Fatalf("expected a clean act created, got %d: %s", w.Code, w.Body.String())
+	}
+	w = create("Paid for a shit week of coffee", "A stranger covered my coffee all week long")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected a flagged act created, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct{ Data models.Act }
+	json.Unmarshal(w.Body.Bytes(), &resp)
+
+	acts := flagged()
+	if len(acts) != 1 || acts[0].ID != resp.Data.ID || acts[0].Screening.Verdict != "flag" {
+		t.Fatalf("expected only the profane act flagged, got %+v", acts)
+	}
+
+	dismiss := func() int {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/acts/"+resp.Data.ID+"/flag", nil)
+		req.SetPathValue("id", resp.Data.ID)
+		w := httptest.NewRecorder()
+		handler.DismissActFlag(w, req)
+		return w.Code
+	}
+	if code := dismiss(); code != http.StatusOK {
+		t.Errorf("expected the flag dismissed, got %d", code)
+	}
+	if code := dismiss(); code != http.StatusNotFound {
+		t.Errorf("expected %d once dismissed, got %d", http.StatusNotFound, code)
+	}
+	if acts := flagged(); len(acts) != 0 {
+		t.Errorf("expected no flagged acts left, got %+v", acts)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"payforwardnow/internal/jobs"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/search"
+)
+
+// JobReindexSearch is the type of the job rebuilding the search index
+const JobReindexSearch = "search.reindex"
+
+// maxSearchLength bounds the length of the text searched
+const maxSearchLength = 200
+
+// SetSearch sets the engine searches run on. With an indexer, the engine
+// holds its own index, which the indexer keeps in sync and which can be
+// rebuilt with a JobReindexSearch job.
+func (h *Handler) SetSearch(engine search.Engine, indexer *search.Indexer) {
+	h.search = engine
+	h.searchIndexer = indexer
+}
+
+// RegisterSearchJobs registers the job rebuilding the search index on q
+func (h *Handler) RegisterSearchJobs(q *jobs.Queue) {
+	q.Register(JobReindexSearch, func(ctx context.Context, _ *models.Job) (any, error) {
+		indexed, err := h.searchIndexer.Rebuild(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("rebuilding the search index: %w", err)
+		}
+		slog.InfoContext(ctx, "Rebuilt the search index", "engine", h.search.Name(), "documents", indexed)
+		return map[string]int{"indexed": indexed}, nil
+	})
+}
+
+// SyncSearchIndex applies the changes recorded since its last run to the
+// search index
+func (h *Handler) SyncSearchIndex(ctx context.Context) error {
+	updated, err := h.searchIndexer.Run(ctx)
+	if updated > 0 {
+		slog.DebugContext(ctx, "Updated the search index", "engine", h.search.Name(), "documents", updated)
+	}
+	return err
+}
+
+// Search handles GET /api/v1/search?q=&kinds=, the acts, chains, users
+// and approved testimonials matching q, best first. kinds is a comma
+// separated subset of models.SearchKinds and defaults to all of them.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	text := strings.TrimSpace(query.Get("q"))
+	if text == "" || len(text) > maxSearchLength {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER",
+			fmt.Sprintf("q must be between 1 and %d characters", maxSearchLength))
+		return
+	}
+	kinds := models.SearchKinds
+	if raw := query.Get("kinds"); raw != "" {
+		kinds = nil
+		for _, kind := range strings.Split(raw, ",") {
+			kind = strings.TrimSpace(kind)
+			if !slices.Contains(models.SearchKinds, kind) {
+				respondError(w, http.StatusBadRequest, "INVALID_PARAMETER",
+					fmt.Sprintf("kinds must be among %s", strings.Join(models.SearchKinds, ", ")))
+				return
+			}
+			if !slices.Contains(kinds, kind) {
+				kinds = append(kinds, kind)
+			}
+		}
+	}
+	params := getPaginationParams(r)
+
+	hits, total, err := h.search.Search(r.Context(), search.Query{Text: text, Kinds: kinds, Page: params})
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to search")
+		return
+	}
+
+	totalPages := (int(total) + params.PerPage - 1) / params.PerPage
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    hits,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+// RequestSearchReindex handles POST /api/v1/admin/search/reindex,
+// enqueueing a rebuild of the search index, such as after changing the
+// search driver or to pick up new chains
+func (h *Handler) RequestSearchReindex(w http.ResponseWriter, r *http.Request) {
+	if h.searchIndexer == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE",
+			"Search runs on the database's full-text indexes, which need no rebuilding")
+		return
+	}
+	if h.queue == nil {
+		respondError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Background jobs are disabled")
+		return
+	}
+
+	job, err := h.queue.Enqueue(r.Context(), JobReindexSearch, nil)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to enqueue search reindex")
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestSearch(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Name: "Garden Ada", Bio: "Grows vegetables"}, "")
+	db.AddAct(models.Act{ID: "a1", Title: "Shared garden tools", Description: "Lent my spade", GiverID: "u1"})
+	db.AddAct(models.Act{ID: "a2", Title: "Fixed a bike", Description: "In the community garden", GiverID: "u1"})
+	db.AddChain(models.Chain{ID: "c1", Name: "Bikes forward", StarterID: "u1"})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/search"+query, nil)
+		w := httptest.NewRecorder()
+		handler.Search(w, req)
+		return w
+	}
+	for _, query := range []string{"", "?q=+", "?q=garden&kinds=act,need"} {
+		if w := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("expected %d for %q, got %d", http.StatusBadRequest, query, w.Code)
+		}
+	}
+
+	w := get("?q=garden")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data []models.SearchHit
+		Meta models.APIMeta
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Meta.Total != 3 || len(resp.Data) != 3 {
+		t.Fatalf("expected the 2 acts and the user, got %s", w.Body.String())
+	}
+	// Matches in the title rank first
+	if last := resp.Data[2]; last.Kind != "act" || last.ID != "a2" {
+		t.Errorf("expected the act matching in its description last, got %+v", last)
+	}
+
+	w = get("?q=bike&kinds=chain&per_page=1")
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Meta.Total != 1 || len(resp.Data) != 1 || resp.Data[0].ID != "c1" {
+		t.Errorf("expected only the chain, got %s", w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/search/reindex", nil)
+	w = httptest.NewRecorder()
+	handler.RequestSearchReindex(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected %d without an external index, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// Bounds of the limit parameter of the similarity endpoints
+const (
+	defaultSimilarLimit = 10
+	maxSimilarLimit     = 50
+)
+
+// SetEmbeddings attaches the embedding index behind the similarity
+// endpoints, which return 404 without one
+func (h *Handler) SetEmbeddings(s embeddings.Searcher) {
+	h.similar = s
+}
+
+// GetSimilarActs handles GET /api/v1/acts/{id}/similar
+func (h *Handler) GetSimilarActs(w http.ResponseWriter, r *http.Request) {
+	if h.similar == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Similarity search is not available")
+		return
+	}
+	limit, ok := similarLimit(w, r)
+	if !ok {
+		return
+	}
+
+	actID := r.PathValue("id")
+	if _, err := h.acts.Get(r.Context(), actID); errors.Is(err, repository.ErrActNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Act not found")
+		return
+	} else if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch act")
+		return
+	}
+
+	matches, err := h.similar.Similar(r.Context(), actID, limit)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to find similar acts")
+		return
+	}
+	h.respondMatches(r.Context(), w, matches)
+}
+
+// MatchActs handles GET /api/v1/acts/match?q=..., returning the acts
+// closest in meaning to a free-text description, such as a need
+func (h *Handler) MatchActs(w http.ResponseWriter, r *http.Request) {
+	if h.similar == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Similarity search is not available")
+		return
+	}
+	limit, ok := similarLimit(w, r)
+	if !ok {
+		return
+	}
+
+	text := r.URL.Query().Get("q")
+	if text == "" || len(text) > 2000 {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "q must be between 1 and 2000 characters")
+		return
+	}
+
+	matches, err := h.similar.Search(r.Context(), text, limit)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to match acts")
+		return
+	}
+	h.respondMatches(r.Context(), w, matches)
+}
+
+// respondMatches loads the matched acts, skipping any deleted since they
+// were indexed
+func (h *Handler) respondMatches(ctx context.Context, w http.ResponseWriter, matches []embeddings.Match) {
+	similar := make([]models.SimilarAct, 0, len(matches))
+	for _, m := range matches {
+		act, err := h.acts.Get(ctx, m.ID)
+		if errors.Is(err, repository.ErrActNotFound) {
+			continue
+		}
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to fetch act")
+			return
+		}
+		similar = append(similar, models.SimilarAct{Act: *act, Score: m.Score})
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    similar,
+	})
+}
+
+func similarLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultSimilarLimit, true
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > maxSimilarLimit {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("limit must be between 1 and %d", maxSimilarLimit))
+		return 0, false
+	}
+	return limit, true
+}
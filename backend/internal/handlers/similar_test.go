@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/embeddings"
+	"payforwardnow/internal/models"
+)
+
+// fakeSearcher returns the same matches for every query
+type fakeSearcher struct {
+	matches []embeddings.Match
+}
+
+func (f fakeSearcher) Similar(_ context.Context, _ string, limit int) ([]embeddings.Match, error) {
+	return f.matches[:min(limit, len(f.matches))], nil
+}
+
+func (f fakeSearcher) Search(_ context.Context, _ string, limit int) ([]embeddings.Match, error) {
+	return f.matches[:min(limit, len(f.matches))], nil
+}
+
+func TestGetSimilarActs(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Email: "ann@example.org", Name: "Ann"}, "hash")
+	for _, id := range []string{"a1", "a2", "a3"} {
+		db.AddAct(models.Act{ID: id, Title: "Act " + id, GiverID: "u1"})
+	}
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	get := func(path, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		if id != "" {
+			handler.GetSimilarActs(w, req)
+		} else {
+			handler.MatchActs(w, req)
+		}
+		return w
+	}
+
+	if w := get("/api/v1/acts/a1/similar", "a1"); w.Code != http.StatusNotFound {
+		t.Errorf("without embeddings: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	// a9 was deleted after it was indexed
+	handler.SetEmbeddings(fakeSearcher{matches: []embeddings.Match{{ID: "a2", Score: 0.9}, {ID: "a9", Score: 0.8}, {ID: "a3", Score: 0.7}}})
+
+	w := get("/api/v1/acts/a1/similar?limit=3", "a1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body)
+	}
+	var resp struct {
+		Data []models.SimilarAct `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || resp.Data[0].Act.ID != "a2" || resp.Data[0].Score != 0.9 || resp.Data[1].Act.ID != "a3" {
+		t.Errorf("unexpected similar acts: %+v", resp.Data)
+	}
+
+	if w := get("/api/v1/acts/missing/similar", "missing"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown act: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := get("/api/v1/acts/a1/similar?limit=500", "a1"); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid limit: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := get("/api/v1/acts/match?q=groceries", ""); w.Code != http.StatusOK {
+		t.Errorf("match: expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := get("/api/v1/acts/match", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("match without q: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetCategoryStats handles GET /api/v1/stats/categories
+func (h *Handler) GetCategoryStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	groupBy := r.URL.Query().Get("groupBy")
+	switch groupBy {
+	case "":
+		groupBy = "category"
+	case "category", "type":
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "groupBy must be 'category' or 'type'")
+		return
+	}
+
+	from, to, err := getDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+		return
+	}
+
+	// Rows are collected before any is written, since the driver may retry
+	// the transaction and there is a row per category or type at most
+	stats, err := database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) ([]models.CategoryStats, error) {
+		result, err := queries.StatsCategories.Run(ctx, tx, map[string]interface{}{
+			"groupBy": groupBy,
+			"from":    timeOrNil(from),
+			"to":      timeOrNil(to),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		stats := []models.CategoryStats{}
+		for result.Next(ctx) {
+			record := result.Record()
+			key, _ := record.Get("key")
+			keyStr, _ := key.(string)
+			stats = append(stats, models.CategoryStats{
+				Key:        keyStr,
+				ActsCount:  getInt64(record, "actsCount"),
+				TotalValue: getFloat64(record, "totalValue"),
+			})
+		}
+
+		return stats, result.Err()
+	})
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch category stats")
+		return
+	}
+
+	if wantsCSV(r) {
+		rows := make([][]string, 0, len(stats))
+		for _, row := range stats {
+			rows = append(rows, []string{row.Key, formatInt(row.ActsCount), formatFloat(row.TotalValue)})
+		}
+		respondCSV(w, "category-stats.csv", []string{groupBy, "actsCount", "totalValue"}, rows...)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// StreamStats handles GET /api/v1/stats/stream (Server-Sent Events). It sends
+// a snapshot of the global counters on connect and an incremental update for
+// every act created afterwards.
+func (h *Handler) StreamStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	snapshot, err := h.queryGlobalStats(ctx)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch stats")
+		return
+	}
+
+	events := h.events.Subscribe()
+	defer h.events.Unsubscribe(events)
+
+	sse, err := newSSEWriter(w)
+	if err != nil {
+		return
+	}
+
+	live := models.LiveStats{
+		TotalActs:  snapshot.TotalActs,
+		TotalValue: snapshot.TotalValue,
+		Timestamp:  time.Now().UTC(),
+	}
+	if err := sse.Send("", "stats", live); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			if err := sse.Heartbeat(); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			act, isAct := event.Data.(*models.Act)
+			if event.Type != EventActCreated || !isAct {
+				continue
+			}
+
+			value := act.Value
+			if act.BaseValue != nil {
+				value = *act.BaseValue
+			}
+			live.TotalActs++
+			live.TotalValue += value
+			live.NewActs = 1
+			live.NewValue = value
+			live.Timestamp = time.Now().UTC()
+			if err := sse.Send("", "stats", live); err != nil {
+				return
+			}
+		}
+	}
+}
+
+const (
+	leaderboardSize = 50
+	leaderboardTTL  = 30 * time.Minute
+)
+
+// LeaderboardPeriods are the periods precomputed by the leaderboard job
+var LeaderboardPeriods = []string{"week", "month", "year", "all"}
+
+// LeaderboardRoles are the roles a leaderboard can rank
+var LeaderboardRoles = []string{"giver", "receiver"}
+
+// GetTopUsers handles GET /api/v1/stats/top
+func (h *Handler) GetTopUsers(w http.ResponseWriter, r *http.Request) {
+	role := r.URL.Query().Get("role")
+	if role == "" {
+		role = "giver"
+	}
+	if role != "giver" && role != "receiver" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "role must be 'giver' or 'receiver'")
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "month"
+	}
+	if _, ok := periodStart(period, time.Now()); !ok {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "period must be one of week, month, year, all")
+		return
+	}
+
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > leaderboardSize {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("limit must be between 1 and %d", leaderboardSize))
+			return
+		}
+		limit = parsed
+	}
+
+	entries, ok := cachedValue[[]models.LeaderboardEntry](r.Context(), h.cache, leaderboardCacheKey(r.Context(), role, period))
+	if !ok {
+		var err error
+		entries, err = h.RefreshLeaderboard(r.Context(), role, period)
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to fetch top users")
+			return
+		}
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	if wantsCSV(r) {
+		rows := make([][]string, 0, len(entries))
+		for _, entry := range entries {
+			rows = append(rows, []string{
+				strconv.Itoa(entry.Rank),
+				entry.User.ID,
+				entry.User.Name,
+				formatInt(entry.ActsCount),
+				formatFloat(entry.TotalValue),
+			})
+		}
+		respondCSV(w, fmt.Sprintf("top-%ss-%s.csv", role, period),
+			[]string{"rank", "userId", "name", "actsCount", "totalValue"}, rows...)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// RefreshLeaderboard recomputes and caches the top users for a role and
+// period. Anonymous acts and users who opted out of leaderboards are excluded.
+func (h *Handler) RefreshLeaderboard(ctx context.Context, role, period string) ([]models.LeaderboardEntry, error) {
+	since, ok := periodStart(period, time.Now().UTC())
+	if !ok {
+		return nil, fmt.Errorf("unknown period %q", period)
+	}
+	query, ok := queries.StatsLeaderboard[role]
+	if !ok {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+
+	entries, err := database.ExecuteRead(ctx, h.db, func(tx neo4j.ManagedTransaction) ([]models.LeaderboardEntry, error) {
+		result, err := query.Run(ctx, tx, map[string]interface{}{
+			"since": timeOrNil(since),
+			"limit": leaderboardSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		entries := []models.LeaderboardEntry{}
+		for result.Next(ctx) {
+			record := result.Record()
+			user := models.LeaderboardUser{}
+			if id, ok := record.Get("id"); ok {
+				user.ID, _ = id.(string)
+			}
+			if name, ok := record.Get("name"); ok {
+				user.Name, _ = name.(string)
+			}
+			if avatar, ok := record.Get("avatar"); ok {
+				user.Avatar, _ = avatar.(string)
+			}
+
+			entries = append(entries, models.LeaderboardEntry{
+				Rank:       len(entries) + 1,
+				User:       user,
+				ActsCount:  getInt64(record, "actsCount"),
+				TotalValue: getFloat64(record, "totalValue"),
+			})
+		}
+
+		return entries, result.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cacheValue(ctx, h.cache, leaderboardCacheKey(ctx, role, period), entries, leaderboardTTL)
+	return entries, nil
+}
+
+func leaderboardCacheKey(ctx context.Context, role, period string) string {
+	return tenantCacheKey(ctx, "leaderboard:"+role+":"+period)
+}
+
+// periodStart returns the start of a named period relative to now, or nil
+// for "all"
+func periodStart(period string, now time.Time) (*time.Time, bool) {
+	var since time.Time
+	switch period {
+	case "all":
+		return nil, true
+	case "week":
+		since = now.AddDate(0, 0, -7)
+	case "month":
+		since = now.AddDate(0, -1, 0)
+	case "year":
+		since = now.AddDate(-1, 0, 0)
+	default:
+		return nil, false
+	}
+	return &since, true
+}
+
+// orgStatsTTL controls how long aggregated organization stats are cached
+const orgStatsTTL = 5 * time.Minute
+
+// GetOrgStats handles GET /api/v1/orgs/{id}/stats
+func (h *Handler) GetOrgStats(w http.ResponseWriter, r *http.Request) {
+	orgID := r.PathValue("id")
+	ctx := r.Context()
+	cacheKey := tenantCacheKey(ctx, "orgstats:"+orgID)
+
+	if cached, ok := cachedValue[*models.OrgStats](ctx, h.cache, cacheKey); ok {
+		if wantsCSV(r) {
+			respondOrgStatsCSV(w, cached)
+			return
+		}
+		respondJSON(w, http.StatusOK, models.APIResponse{
+			Success: true,
+			Data:    cached,
+		})
+		return
+	}
+
+	stats, err := h.organizations.Stats(ctx, orgID, time.Now())
+	if err != nil {
+		respondOrgError(w, err, "Failed to fetch organization stats")
+		return
+	}
+
+	cacheValue(ctx, h.cache, cacheKey, stats, orgStatsTTL)
+
+	if wantsCSV(r) {
+		respondOrgStatsCSV(w, stats)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+func respondOrgStatsCSV(w http.ResponseWriter, stats *models.OrgStats) {
+	respondCSV(w, "org-stats.csv",
+		[]string{"organizationId", "members", "activeMembers", "actsGiven", "chainsStarted", "totalValue"},
+		[]string{
+			stats.OrganizationID,
+			formatInt(stats.Members),
+			formatInt(stats.ActiveMembers),
+			formatInt(stats.ActsGiven),
+			formatInt(stats.ChainsStarted),
+			formatFloat(stats.TotalValue),
+		},
+	)
+}
+
+// getDateRange parses the optional from/to query parameters. Both accept
+// either a date (2006-01-02) or an RFC 3339 timestamp; a date-only "to" is
+// treated as inclusive of that whole day.
+func getDateRange(r *http.Request) (*time.Time, *time.Time, error) {
+	from, err := parseDateParam(r.URL.Query().Get("from"), false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid 'from' date: %w", err)
+	}
+
+	to, err := parseDateParam(r.URL.Query().Get("to"), true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid 'to' date: %w", err)
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, fmt.Errorf("'from' must be before 'to'")
+	}
+
+	return from, to, nil
+}
+
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		t = t.UTC()
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24 * time.Hour)
+	}
+	return &t, nil
+}
+
+func timeOrNil(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/stream"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestGetCategoryStats_InvalidGroupBy(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/categories?groupBy=location", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetCategoryStats(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestStreamStats(t *testing.T) {
+	mockDB := &MockDBClient{
+		ExecuteReadFunc: func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+			return &models.GlobalStats{TotalActs: 10, TotalValue: 100}, nil
+		},
+	}
+	handler := NewHandler(mockDB)
+
+	server := httptest.NewServer(http.HandlerFunc(handler.StreamStats))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %s", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() models.LiveStats {
+		t.Helper()
+		var live models.LiveStats
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read stream: %v", err)
+			}
+			if strings.HasPrefix(line, "data: ") {
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &live); err != nil {
+					t.Fatalf("failed to decode event: %v", err)
+				}
+				return live
+			}
+		}
+	}
+
+	first := readEvent()
+	if first.TotalActs != 10 {
+		t.Errorf("expected snapshot of 10 acts, got %d", first.TotalActs)
+	}
+
+	for handler.events.SubscriberCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	handler.events.Publish(stream.Event{Type: EventActCreated, Data: &models.Act{Value: 5}})
+
+	second := readEvent()
+	if second.TotalActs != 11 || second.TotalValue != 105 || second.NewActs != 1 {
+		t.Errorf("unexpected update %+v", second)
+	}
+}
+
+func TestGetOrgStats_Cached(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	cacheValue(context.Background(), handler.cache, "orgstats:org-1", &models.OrgStats{OrganizationID: "org-1", Members: 7}, time.Minute)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orgs/org-1/stats", nil)
+	req.SetPathValue("id", "org-1")
+	w := httptest.NewRecorder()
+
+	handler.GetOrgStats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Data models.OrgStats `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Data.Members != 7 {
+		t.Errorf("expected 7 members, got %d", response.Data.Members)
+	}
+}
+
+func TestGetOrgStats_NotFound(t *testing.T) {
+	handler := NewHandlerWithRepositories(&MockDBClient{}, databasetest.New().Repositories())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orgs/missing/stats", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+
+	handler.GetOrgStats(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if _, ok := cachedValue[*models.OrgStats](context.Background(), handler.cache, "orgstats:missing"); ok {
+		t.Error("expected nothing cached for a missing organization")
+	}
+}
+
+func TestGetDateRange(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		expectErr  bool
+		expectFrom *time.Time
+		expectTo   *time.Time
+	}{
+		{
+			name: "no range",
+			url:  "/api/v1/stats/categories",
+		},
+		{
+			name:       "date only range is inclusive of end day",
+			url:        "/api/v1/stats/categories?from=2024-01-01&to=2024-01-31",
+			expectFrom: ptrTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+			expectTo:   ptrTime(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:       "rfc3339 timestamps",
+			url:        "/api/v1/stats/categories?from=2024-01-01T10:00:00Z",
+			expectFrom: ptrTime(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)),
+		},
+		{
+			name:      "invalid date",
+			url:       "/api/v1/stats/categories?from=yesterday",
+			expectErr: true,
+		},
+		{
+			name:      "from after to",
+			url:       "/api/v1/stats/categories?from=2024-02-01&to=2024-01-01",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			from, to, err := getDateRange(req)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !equalTimePtr(from, tt.expectFrom) {
+				t.Errorf("expected from %v, got %v", tt.expectFrom, from)
+			}
+			if !equalTimePtr(to, tt.expectTo) {
+				t.Errorf("expected to %v, got %v", tt.expectTo, to)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func TestGetTopUsers_InvalidParams(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	urls := []string{
+		"/api/v1/stats/top?role=admin",
+		"/api/v1/stats/top?period=decade",
+		"/api/v1/stats/top?limit=500",
+	}
+
+	for _, url := range urls {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+
+		handler.GetTopUsers(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", url, http.StatusBadRequest, w.Code)
+		}
+	}
+}
+
+func TestGetTopUsers_UsesPrecomputedLeaderboard(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	cacheValue(context.Background(), handler.cache, leaderboardCacheKey(context.Background(), "giver", "week"), []models.LeaderboardEntry{
+		{Rank: 1, User: models.LeaderboardUser{ID: "u1", Name: "Jane"}, ActsCount: 5},
+		{Rank: 2, User: models.LeaderboardUser{ID: "u2", Name: "Sam"}, ActsCount: 3},
+	}, time.Minute)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/top?role=giver&period=week&limit=1", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetTopUsers(w, req)
+
+	var raw struct {
+		Data []struct {
+			User map[string]any `json:"user"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(raw.Data) == 1 && len(raw.Data[0].User) != 2 {
+		t.Errorf("expected only the user's id and name, got %v", raw.Data[0].User)
+	}
+
+	var response struct {
+		Data []models.LeaderboardEntry `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Data) != 1 || response.Data[0].User.ID != "u1" {
+		t.Errorf("unexpected leaderboard %+v", response.Data)
+	}
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/i18n"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/stream"
+
+	"github.com/google/uuid"
+)
+
+const maxVideoDurationSeconds = 60
+
+// mediaExtensions lists the file extensions accepted for each media type
+var mediaExtensions = map[models.MediaType][]string{
+	models.MediaTypePhoto: {".jpg", ".jpeg", ".png", ".webp", ".gif"},
+	models.MediaTypeVideo: {".mp4", ".webm", ".mov"},
+}
+
+// SetMediaHosts sets the hosts testimonial media may be served from, such as
+// the upload bucket's CDN. With none, media attachments are rejected.
+func (h *Handler) SetMediaHosts(hosts []string) {
+	h.mediaHosts = hosts
+}
+
+// GetTestimonials handles GET /api/v1/testimonials
+func (h *Handler) GetTestimonials(w http.ResponseWriter, r *http.Request) {
+	params := getPaginationParams(r)
+
+	filter, status, message := getTestimonialFilter(r)
+	if status != 0 {
+		respondError(w, status, "INVALID_PARAMETER", message)
+		return
+	}
+
+	testimonials, total, err := h.testimonials.List(r.Context(), filter, params, i18n.Preferred(r))
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch testimonials")
+		return
+	}
+
+	totalPages := (int(total) + params.PerPage - 1) / params.PerPage
+
+	w.Header().Add("Vary", "Accept-Language")
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    testimonials,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	})
+}
+
+// getTestimonialFilter parses the listing filters. Only admins may list
+// testimonials that are not approved. A non-zero status is returned along
+// with an error message when the filters are invalid.
+func getTestimonialFilter(r *http.Request) (repository.TestimonialFilter, int, string) {
+	query := r.URL.Query()
+	approved := true
+	filter := repository.TestimonialFilter{
+		Approved: &approved,
+		UserID:   query.Get("userId"),
+	}
+
+	if v := query.Get("featured"); v != "" {
+		featured, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, http.StatusBadRequest, "featured must be true or false"
+		}
+		filter.Featured = &featured
+	}
+
+	switch query.Get("status") {
+	case "", "approved":
+	case "pending":
+		if !isAdmin(r) {
+			return filter, http.StatusForbidden, "Only admins can list unapproved testimonials"
+		}
+		pending := false
+		filter.Approved = &pending
+	case "all":
+		if !isAdmin(r) {
+			return filter, http.StatusForbidden, "Only admins can list unapproved testimonials"
+		}
+		filter.Approved = nil
+	default:
+		return filter, http.StatusBadRequest, "status must be one of approved, pending, all"
+	}
+
+	return filter, 0, ""
+}
+
+// GetFeaturedTestimonials handles GET /api/v1/testimonials/featured. Featured
+// testimonials are returned in their explicit order, then newest first.
+func (h *Handler) GetFeaturedTestimonials(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 50 {
+			limit = parsed
+		}
+	}
+
+	testimonials, err := h.testimonials.Featured(r.Context(), limit, i18n.Preferred(r))
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch testimonials")
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Language")
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    testimonials,
+	})
+}
+
+// FeatureTestimonial handles PUT /api/v1/admin/testimonials/{id}/featured
+func (h *Handler) FeatureTestimonial(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	var req models.FeatureTestimonialRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if req.Order != nil && *req.Order < 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "order must not be negative")
+		return
+	}
+
+	testimonial, err := h.testimonials.SetFeatured(r.Context(), testimonialID, req.Featured, req.Order)
+	switch {
+	case errors.Is(err, repository.ErrNotApproved):
+		respondError(w, http.StatusConflict, "NOT_APPROVED", "Only approved testimonials can be featured")
+		return
+	case errors.Is(err, repository.ErrTestimonialNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Testimonial not found")
+		return
+	case err != nil:
+		respondDatabaseError(w, err, "Failed to update testimonial")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    testimonial,
+	})
+}
+
+// CreateTestimonial handles POST /api/v1/testimonials
+func (h *Handler) CreateTestimonial(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateTestimonialRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if err := validateMedia(req.Media, h.mediaHosts); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_MEDIA", err.Error())
+		return
+	}
+
+	testimonial := &models.Testimonial{
+		ID:        uuid.New().String(),
+		UserID:    currentUserID(r),
+		Story:     req.Story,
+		Impact:    req.Impact,
+		Media:     req.Media,
+		CreatedAt: time.Now().UTC(),
+	}
+	screening := h.screener.ScreenContent(r.Context(), "testimonial", req.Story, req.Impact)
+
+	created, err := h.testimonials.Create(r.Context(), testimonial, screening)
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to create testimonial")
+		return
+	}
+
+	var data interface{}
+	if created {
+		data = testimonial
+	}
+
+	respondJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// UpdateTestimonial handles PUT /api/v1/testimonials/{id}. Only the author
+// or an admin may edit a testimonial. Edits by the author send an approved
+// or rejected testimonial back to the moderation queue.
+func (h *Handler) UpdateTestimonial(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	var req models.UpdateTestimonialRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if req.Media != nil && req.RemoveMedia {
+		respondError(w, http.StatusBadRequest, "INVALID_MEDIA", "media and removeMedia are mutually exclusive")
+		return
+	}
+	if err := validateMedia(req.Media, h.mediaHosts); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_MEDIA", err.Error())
+		return
+	}
+	expected, ok := expectedVersion(w, r, req.Version)
+	if !ok {
+		return
+	}
+
+	update := repository.TestimonialUpdate{
+		Story:       req.Story,
+		Impact:      req.Impact,
+		Media:       req.Media,
+		RemoveMedia: req.RemoveMedia,
+		UserID:      userID,
+		Admin:       isAdmin(r),
+		Version:     expected,
+	}
+	screen := func(story, impact string) moderation.Result {
+		return h.screener.ScreenContent(r.Context(), "testimonial", story, impact)
+	}
+
+	testimonial, err := h.testimonials.Update(r.Context(), testimonialID, update, screen)
+	if err != nil {
+		respondTestimonialWriteError(w, err, "Failed to update testimonial")
+		return
+	}
+
+	setVersionETag(w, testimonial.Version)
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    testimonial,
+	})
+}
+
+// DeleteTestimonial handles DELETE /api/v1/testimonials/{id}
+func (h *Handler) DeleteTestimonial(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	if err := h.testimonials.Delete(r.Context(), testimonialID, userID, isAdmin(r)); err != nil {
+		respondTestimonialWriteError(w, err, "Failed to delete testimonial")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Testimonial deleted successfully"},
+	})
+}
+
+// ToggleReaction handles POST /api/v1/testimonials/{id}/reactions. It adds
+// the caller's "this moved me" reaction to an approved testimonial, or
+// removes it if already present.
+func (h *Handler) ToggleReaction(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	userID := currentUserID(r)
+	if userID == "" {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Authentication required")
+		return
+	}
+
+	result, err := h.testimonials.ToggleReaction(r.Context(), testimonialID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTestimonialNotFound):
+			respondError(w, http.StatusNotFound, "NOT_FOUND", "Testimonial not found")
+		case errors.Is(err, repository.ErrUserNotFound):
+			respondError(w, http.StatusNotFound, "NOT_FOUND", "User not found")
+		default:
+			respondDatabaseError(w, err, "Failed to update reaction")
+		}
+		return
+	}
+	h.events.Publish(stream.Event{
+		Type:   EventReactionToggled,
+		Topics: []string{userTopic(result.AuthorID)},
+		Data:   models.ReactionUpdate{TestimonialID: testimonialID, ReactionCount: result.ReactionCount},
+	})
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+func respondTestimonialWriteError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrTestimonialNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Testimonial not found")
+	case errors.Is(err, repository.ErrNotTestimonialOwner):
+		respondError(w, http.StatusForbidden, "FORBIDDEN", "Only the author can modify this testimonial")
+	case errors.Is(err, repository.ErrVersionConflict):
+		respondVersionConflict(w, "Testimonial")
+	default:
+		respondDatabaseError(w, err, message)
+	}
+}
+
+// validateMedia checks that an attachment is an https URL on one of hosts
+// with a file extension matching its type, and that videos are short. A nil
+// media is valid.
+func validateMedia(m *models.Media, hosts []string) error {
+	if m == nil {
+		return nil
+	}
+	if len(hosts) == 0 {
+		return errors.New("media attachments are not enabled")
+	}
+
+	extensions, ok := mediaExtensions[m.Type]
+	if !ok {
+		return fmt.Errorf("media type must be %q or %q", models.MediaTypePhoto, models.MediaTypeVideo)
+	}
+
+	u, err := url.Parse(m.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return errors.New("media url must be an absolute https URL")
+	}
+	if !slices.ContainsFunc(hosts, func(host string) bool { return strings.EqualFold(host, u.Host) }) {
+		return fmt.Errorf("media url must be on %s", strings.Join(hosts, ", "))
+	}
+
+	ext := strings.ToLower(path.Ext(u.Path))
+	valid := false
+	for _, e := range extensions {
+		if ext == e {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%s url must end in one of %s", m.Type, strings.Join(extensions, ", "))
+	}
+
+	if m.Type == models.MediaTypeVideo && (m.DurationSeconds < 0 || m.DurationSeconds > maxVideoDurationSeconds) {
+		return fmt.Errorf("video duration must be at most %d seconds", maxVideoDurationSeconds)
+	}
+	if m.Type == models.MediaTypePhoto && m.DurationSeconds != 0 {
+		return errors.New("durationSeconds is only valid for videos")
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestFeatureTestimonial_InvalidRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid json", "not json"},
+		{"negative order", `{"featured": true, "order": -1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockDBClient{})
+
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/admin/testimonials/t1/featured", bytes.NewBufferString(tt.body))
+			req.SetPathValue("id", "t1")
+			w := httptest.NewRecorder()
+
+			handler.FeatureTestimonial(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestGetTestimonialFilter(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		admin          bool
+		expectStatus   int
+		expectApproved *bool
+		expectFeatured *bool
+	}{
+		{
+			name:           "defaults to approved",
+			url:            "/api/v1/testimonials",
+			expectApproved: boolPtr(true),
+		},
+		{
+			name:           "featured filter",
+			url:            "/api/v1/testimonials?featured=true",
+			expectApproved: boolPtr(true),
+			expectFeatured: boolPtr(true),
+		},
+		{
+			name:         "invalid featured",
+			url:          "/api/v1/testimonials?featured=maybe",
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "pending requires admin",
+			url:          "/api/v1/testimonials?status=pending",
+			expectStatus: http.StatusForbidden,
+		},
+		{
+			name:           "admin can list pending",
+			url:            "/api/v1/testimonials?status=pending",
+			admin:          true,
+			expectApproved: boolPtr(false),
+		},
+		{
+			name:  "admin can list all",
+			url:   "/api/v1/testimonials?status=all",
+			admin: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.admin {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.RolesKey, []string{middleware.AdminRole}))
+			}
+
+			filter, status, _ := getTestimonialFilter(req)
+
+			if status != tt.expectStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectStatus, status)
+			}
+			if tt.expectStatus != 0 {
+				return
+			}
+			if !equalBoolPtr(filter.Approved, tt.expectApproved) {
+				t.Errorf("expected approved %v, got %v", tt.expectApproved, filter.Approved)
+			}
+			if !equalBoolPtr(filter.Featured, tt.expectFeatured) {
+				t.Errorf("expected featured %v, got %v", tt.expectFeatured, filter.Featured)
+			}
+		})
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func equalBoolPtr(a, b *bool) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func TestDeleteTestimonial_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       string
+		dbErr        error
+		expectStatus int
+	}{
+		{"unauthenticated", "", nil, http.StatusUnauthorized},
+		{"not found", "u1", repository.ErrTestimonialNotFound, http.StatusNotFound},
+		{"not the author", "u2", repository.ErrNotTestimonialOwner, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockDBClient{
+				ExecuteWriteFunc: func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+					return nil, tt.dbErr
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/v1/testimonials/t1", nil)
+			req.SetPathValue("id", "t1")
+			if tt.userID != "" {
+				req = asUser(req, tt.userID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.DeleteTestimonial(w, req)
+
+			if w.Code != tt.expectStatus {
+				t.Errorf("expected status %d, got %d", tt.expectStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateTestimonial_InvalidJSON(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/testimonials/t1", bytes.NewBufferString("not json"))
+	req.SetPathValue("id", "t1")
+	req = asUser(req, "u1")
+	w := httptest.NewRecorder()
+
+	handler.UpdateTestimonial(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestValidateMedia(t *testing.T) {
+	tests := []struct {
+		name      string
+		media     *models.Media
+		expectErr bool
+	}{
+		{"no media", nil, false},
+		{"photo", &models.Media{Type: models.MediaTypePhoto, URL: "https://cdn.example.com/a/photo.JPG"}, false},
+		{"short video", &models.Media{Type: models.MediaTypeVideo, URL: "https://cdn.example.com/clip.mp4?v=1", DurationSeconds: 30}, false},
+		{"unknown type", &models.Media{Type: "audio", URL: "https://cdn.example.com/a.mp3"}, true},
+		{"plain http", &models.Media{Type: models.MediaTypePhoto, URL: "http://cdn.example.com/a.png"}, true},
+		{"relative url", &models.Media{Type: models.MediaTypePhoto, URL: "/a.png"}, true},
+		{"wrong extension", &models.Media{Type: models.MediaTypePhoto, URL: "https://cdn.example.com/a.mp4"}, true},
+		{"long video", &models.Media{Type: models.MediaTypeVideo, URL: "https://cdn.example.com/a.webm", DurationSeconds: 300}, true},
+		{"photo with duration", &models.Media{Type: models.MediaTypePhoto, URL: "https://cdn.example.com/a.png", DurationSeconds: 5}, true},
+		{"host case", &models.Media{Type: models.MediaTypePhoto, URL: "https://CDN.example.com/a.png"}, false},
+		{"other host", &models.Media{Type: models.MediaTypePhoto, URL: "https://evil.example.net/a.png"}, true},
+		{"subdomain", &models.Media{Type: models.MediaTypePhoto, URL: "https://x.cdn.example.com/a.png"}, true},
+		{"credentials", &models.Media{Type: models.MediaTypePhoto, URL: "https://cdn.example.com@evil.example.net/a.png"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMedia(tt.media, []string{"cdn.example.com"})
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+
+	photo := &models.Media{Type: models.MediaTypePhoto, URL: "https://cdn.example.com/a.png"}
+	if err := validateMedia(photo, nil); err == nil {
+		t.Error("expected media to be rejected without media hosts")
+	}
+}
+
+func TestToggleReaction(t *testing.T) {
+	tests := []struct {
+		name         string
+		userID       string
+		result       interface{}
+		dbErr        error
+		expectStatus int
+	}{
+		{"unauthenticated", "", nil, nil, http.StatusUnauthorized},
+		{"testimonial not found", "u1", nil, repository.ErrTestimonialNotFound, http.StatusNotFound},
+		{"user not found", "u1", nil, repository.ErrUserNotFound, http.StatusNotFound},
+		{"toggled", "u1", &models.ReactionResult{Reacted: true, ReactionCount: 3}, nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler(&MockDBClient{
+				ExecuteWriteFunc: func(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+					return tt.result, tt.dbErr
+				},
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/testimonials/t1/reactions", nil)
+			req.SetPathValue("id", "t1")
+			if tt.userID != "" {
+				req = asUser(req, tt.userID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ToggleReaction(w, req)
+
+			if w.Code != tt.expectStatus {
+				t.Errorf("expected status %d, got %d", tt.expectStatus, w.Code)
+			}
+		})
+	}
+}
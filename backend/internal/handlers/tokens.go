@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/middleware"
+	"payforwardnow/internal/models"
+)
+
+const (
+	// accessTokenTTL is how long the access tokens issued by Login last
+	accessTokenTTL = time.Hour
+	// refreshTokenTTL is how long their refresh tokens last
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// SetTokenSecret sets the key Login, Register and RefreshToken sign their
+// tokens with. The tokens identify callers when Keycloak isn't configured.
+func (h *Handler) SetTokenSecret(secret string) {
+	h.tokenSecret = secret
+}
+
+// issueTokens signs an access and a refresh token for user
+func (h *Handler) issueTokens(userID, email string) (models.AuthTokens, error) {
+	access, err := middleware.GenerateToken(h.tokenSecret, userID, email, accessTokenTTL)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	refresh, err := middleware.GenerateRefreshToken(h.tokenSecret, userID, email, refreshTokenTTL)
+	if err != nil {
+		return models.AuthTokens{}, err
+	}
+	return models.AuthTokens{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(accessTokenTTL / time.Second),
+	}, nil
+}
+
+// respondTokenError reports a failure to sign tokens
+func respondTokenError(w http.ResponseWriter) {
+	respondError(w, http.StatusInternalServerError, "TOKEN_ERROR", "Failed to issue tokens")
+}
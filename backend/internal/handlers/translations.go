@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/i18n"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const (
+	maxTranslatedStoryLength  = 4000
+	maxTranslatedImpactLength = 400
+)
+
+// PutTestimonialTranslation handles PUT /api/v1/admin/testimonials/{id}/translations/{locale}
+func (h *Handler) PutTestimonialTranslation(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	locale := i18n.Normalize(r.PathValue("locale"))
+	if locale == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "locale must be a language tag such as 'it' or 'pt-br'")
+		return
+	}
+
+	var req models.TranslateTestimonialRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+
+	req.Story = strings.TrimSpace(req.Story)
+	req.Impact = strings.TrimSpace(req.Impact)
+	if req.Story == "" || len(req.Story) > maxTranslatedStoryLength {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("story must be between 1 and %d characters", maxTranslatedStoryLength))
+		return
+	}
+	if req.Impact == "" || len(req.Impact) > maxTranslatedImpactLength {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", fmt.Sprintf("impact must be between 1 and %d characters", maxTranslatedImpactLength))
+		return
+	}
+
+	ctx := r.Context()
+
+	found, err := database.ExecuteWrite(ctx, h.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.TranslationSave.Run(ctx, tx, map[string]interface{}{
+			"id":        testimonialID,
+			"locale":    locale,
+			"story":     req.Story,
+			"impact":    req.Impact,
+			"updatedAt": time.Now().UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), nil
+	})
+
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to save translation")
+		return
+	}
+
+	if !found {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Testimonial not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Translation saved successfully"},
+	})
+}
+
+// DeleteTestimonialTranslation handles DELETE /api/v1/admin/testimonials/{id}/translations/{locale}
+func (h *Handler) DeleteTestimonialTranslation(w http.ResponseWriter, r *http.Request) {
+	testimonialID := r.PathValue("id")
+	locale := i18n.Normalize(r.PathValue("locale"))
+	ctx := r.Context()
+
+	_, err := h.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return queries.TranslationDelete.Run(ctx, tx, map[string]interface{}{"id": testimonialID, "locale": locale})
+	})
+
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to delete translation")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Translation deleted successfully"},
+	})
+}
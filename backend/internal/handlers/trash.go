@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// RestoreDeleted handles POST /api/v1/admin/{kind}/{id}/restore, undoing
+// the deletion of a user, act or testimonial that hasn't been purged yet
+func (h *Handler) RestoreDeleted(w http.ResponseWriter, r *http.Request) {
+	kind := r.PathValue("kind")
+	if !slices.Contains(repository.TrashKinds, kind) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Nothing to restore")
+		return
+	}
+
+	err := h.trash.Restore(r.Context(), kind, r.PathValue("id"))
+	if errors.Is(err, repository.ErrNotDeleted) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "No deleted entity with this ID")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to restore")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Restored successfully"},
+	})
+}
+
+// PurgeDeleted permanently removes entities deleted more than retention ago,
+// for the retention job
+func (h *Handler) PurgeDeleted(ctx context.Context, retention time.Duration) error {
+	purged, err := h.trash.Purge(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+	if total := purged["users"] + purged["acts"] + purged["testimonials"]; total > 0 {
+		slog.InfoContext(ctx, "Purged deleted entities", "users", purged["users"], "acts", purged["acts"], "testimonials", purged["testimonials"])
+	}
+	return nil
+}
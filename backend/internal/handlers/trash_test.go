@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestRestoreDeleted(t *testing.T) {
+	db := databasetest.New()
+	db.AddAct(models.Act{ID: "a1", Title: "Fixed a bike"})
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+	ctx := context.Background()
+
+	restore := func(kind, id string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/"+kind+"/"+id+"/restore", nil)
+		req.SetPathValue("kind", kind)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		handler.RestoreDeleted(w, req)
+		return w.Code
+	}
+
+	if code := restore("acts", "a1"); code != http.StatusNotFound {
+		t.Errorf("expected %d restoring a live act, got %d", http.StatusNotFound, code)
+	}
+	if code := restore("chains", "c1"); code != http.StatusNotFound {
+		t.Errorf("expected %d for a kind that isn't soft deleted, got %d", http.StatusNotFound, code)
+	}
+
+	handler.acts.Delete(ctx, "a1")
+	if code := restore("acts", "a1"); code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, code)
+	}
+	if _, err := handler.acts.Get(ctx, "a1"); err != nil {
+		t.Errorf("expected the act to be back, got %v", err)
+	}
+
+	handler.acts.Delete(ctx, "a1")
+	if err := handler.PurgeDeleted(ctx, -time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if code := restore("acts", "a1"); code != http.StatusNotFound {
+		t.Errorf("expected a purged act not to be restorable, got %d", code)
+	}
+}
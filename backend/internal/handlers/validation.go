@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"payforwardnow/internal/i18n"
+	"payforwardnow/internal/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate enforces the `validate` struct tags on request models, reporting
+// fields by their JSON names
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// decodeAndValidate decodes the JSON request body into dst and validates
+// it. On failure it responds with 400 for malformed JSON, 413 for a body
+// over the route's size limit or 422 with per-field details, and returns
+// false.
+func decodeAndValidate(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE",
+				"The request body must not exceed {limit} bytes", "limit", strconv.FormatInt(tooLarge.Limit, 10))
+			return false
+		}
+		respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return false
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			respondError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+			return false
+		}
+
+		respondJSON(w, http.StatusUnprocessableEntity, models.APIResponse{
+			Success: false,
+			Error: &models.APIError{
+				Code:      "VALIDATION_ERROR",
+				Message:   i18n.Localize(w, "Request validation failed"),
+				Details:   fieldErrors(w, validationErrs),
+				RequestID: w.Header().Get("X-Request-ID"),
+			},
+		})
+		return false
+	}
+
+	return true
+}
+
+// fieldErrors describes each failed rule in the language of the response
+func fieldErrors(w http.ResponseWriter, errs validator.ValidationErrors) []models.FieldError {
+	details := make([]models.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		// Drop the struct name, keeping nested paths such as "media.url"
+		field := fe.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+
+		details = append(details, models.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: validationMessage(w, fe),
+		})
+	}
+	return details
+}
+
+func validationMessage(w http.ResponseWriter, fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return i18n.Localize(w, "is required")
+	case "email":
+		return i18n.Localize(w, "must be a valid email address")
+	case "url":
+		return i18n.Localize(w, "must be a valid URL")
+	case "min":
+		return i18n.Localize(w, "must be at least {min}"+unit, "min", fe.Param())
+	case "max":
+		return i18n.Localize(w, "must be at most {max}"+unit, "max", fe.Param())
+	case "oneof":
+		return i18n.Localize(w, "must be one of: {values}", "values", strings.ReplaceAll(fe.Param(), " ", ", "))
+	default:
+		return i18n.Localize(w, "is invalid")
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/models"
+)
+
+func TestCreateAct_ValidationError(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	body := `{"title": "", "description": "short", "type": "help", "category": "community"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+
+	handler.CreateAct(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", w.Code)
+	}
+
+	var response models.APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Error == nil || response.Error.Code != "VALIDATION_ERROR" {
+		t.Fatalf("expected VALIDATION_ERROR, got %+v", response.Error)
+	}
+
+	fields := map[string]models.FieldError{}
+	for _, detail := range response.Error.Details {
+		fields[detail.Field] = detail
+	}
+	if fields["title"].Rule != "required" {
+		t.Errorf("expected title to be required, got %+v", fields["title"])
+	}
+	if got := fields["description"]; got.Rule != "min" || got.Message != "must be at least 10 characters" {
+		t.Errorf("unexpected description error %+v", got)
+	}
+}
+
+func TestCreateAct_LocalizedValidationError(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	body := `{"title": "Helped", "description": "short", "type": "help", "category": "community"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	w.Header().Set("Content-Language", "it")
+
+	handler.CreateAct(w, req)
+
+	var response models.APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Error == nil || response.Error.Message != "Convalida della richiesta non riuscita" {
+		t.Fatalf("expected the error in Italian, got %+v", response.Error)
+	}
+	if len(response.Error.Details) != 1 || response.Error.Details[0].Message != "deve contenere almeno 10 caratteri" {
+		t.Errorf("expected the field error in Italian, got %+v", response.Error.Details)
+	}
+}
+
+func TestCreateTestimonial_NestedValidationError(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+
+	body, _ := json.Marshal(models.CreateTestimonialRequest{
+		Story:  "Someone paid for my groceries when my card was declined and I will never forget it.",
+		Impact: "I now do the same for others.",
+		Media:  &models.Media{Type: "audio", URL: "https://example.com/a.mp3"},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/testimonials", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	handler.CreateTestimonial(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d", w.Code)
+	}
+
+	var response models.APIResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Error == nil || len(response.Error.Details) != 1 {
+		t.Fatalf("expected a single field error, got %+v", response.Error)
+	}
+	if detail := response.Error.Details[0]; detail.Field != "media.type" || detail.Message != "must be one of: photo, video" {
+		t.Errorf("unexpected field error %+v", detail)
+	}
+}
+
+func TestDecodeAndValidate_InvalidJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", bytes.NewBufferString("{"))
+	w := httptest.NewRecorder()
+
+	var dst models.CreateActRequest
+	if decodeAndValidate(w, req, &dst) {
+		t.Fatal("expected malformed JSON to fail")
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setVersionETag advertises an entity's version as its ETag, so clients can
+// send it back in If-Match to make their next update conditional
+func setVersionETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// expectedVersion returns the version a conditional update must match: the
+// one in If-Match, which takes precedence, or body, the version in the
+// request body. Neither, or If-Match: *, makes the update unconditional. It
+// responds with a 400 and returns false if If-Match isn't a single version.
+func expectedVersion(w http.ResponseWriter, r *http.Request, body *int64) (*int64, bool) {
+	match := strings.TrimSpace(r.Header.Get("If-Match"))
+	if match == "" {
+		return body, true
+	}
+	if match == "*" {
+		return nil, true
+	}
+
+	tag := strings.TrimPrefix(match, "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil {
+		unquoted = tag
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version < 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_IF_MATCH", "If-Match must be a single entity version, such as \"3\"")
+		return nil, false
+	}
+	return &version, true
+}
+
+// respondVersionConflict rejects an update based on a stale version
+func respondVersionConflict(w http.ResponseWriter, entity string) {
+	respondError(w, http.StatusConflict, "VERSION_CONFLICT",
+		entity+" was changed by another request; fetch it again and reapply your changes")
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+func TestUpdateUser_OptimisticLocking(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Email: "ann@example.org", Name: "Ann", Version: 3}, "hash")
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	update := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/users/u1", bytes.NewBufferString(body))
+		req.SetPathValue("id", "u1")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		handler.UpdateUser(w, req)
+		return w
+	}
+
+	get := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/u1", nil)
+	req.SetPathValue("id", "u1")
+	handler.GetUser(get, req)
+	if etag := get.Header().Get("ETag"); etag != `"3"` {
+		t.Errorf("expected ETag \"3\", got %q", etag)
+	}
+
+	if w := update(`"3"`, `{"name": "Anna"}`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("expected update of the current version to succeed with ETag \"4\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := update(`"3"`, `{"name": "Annie"}`); w.Code != http.StatusConflict {
+		t.Errorf("expected a stale If-Match to conflict, got %d", w.Code)
+	}
+	if w := update("", `{"name": "Annie", "version": 2}`); w.Code != http.StatusConflict {
+		t.Errorf("expected a stale body version to conflict, got %d", w.Code)
+	}
+	if w := update(`W/"4"`, `{"name": "Annie", "version": 2}`); w.Code != http.StatusOK {
+		t.Errorf("expected If-Match to take precedence over the body, got %d", w.Code)
+	}
+	if w := update("", `{"bio": "Unconditional"}`); w.Code != http.StatusOK {
+		t.Errorf("expected an update without a version to succeed, got %d", w.Code)
+	}
+	if w := update(`"4", "5"`, `{"name": "Annie"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a list of versions in If-Match to be rejected, got %d", w.Code)
+	}
+
+	user, err := db.Repositories().Users.Get(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "Annie" || user.Version != 6 {
+		t.Errorf("expected Annie at version 6, got %s at %d", user.Name, user.Version)
+	}
+}
+
+func TestUpdateUser_ConcurrentVersions(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Email: "ann@example.org", Name: "Ann", Version: 3}, "hash")
+	handler := NewHandlerWithRepositories(&MockDBClient{}, db.Repositories())
+
+	// Edits of the same version race, and only one of them may win
+	codes := make([]int, 8)
+	var wg sync.WaitGroup
+	for i := range codes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPut, "/api/v1/users/u1", bytes.NewBufferString(`{"name": "Anna"}`))
+			req.SetPathValue("id", "u1")
+			req.Header.Set("If-Match", `"3"`)
+			w := httptest.NewRecorder()
+			handler.UpdateUser(w, req)
+			codes[i] = w.Code
+		}()
+	}
+	wg.Wait()
+
+	won := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			won++
+		case http.StatusConflict:
+		default:
+			t.Errorf("expected %d or %d, got %d", http.StatusOK, http.StatusConflict, code)
+		}
+	}
+	if won != 1 {
+		t.Errorf("expected exactly one update to win, got %d", won)
+	}
+	if user, _ := db.Repositories().Users.Get(context.Background(), "u1"); user.Version != 4 {
+		t.Errorf("expected version 4, got %d", user.Version)
+	}
+}
+
+func TestUpdateAct_NotFound(t *testing.T) {
+	handler := NewHandlerWithRepositories(&MockDBClient{}, databasetest.New().Repositories())
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/acts/missing", bytes.NewBufferString(`{"status": "completed"}`))
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+	handler.UpdateAct(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
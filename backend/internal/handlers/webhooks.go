@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/webhooks"
+
+	"github.com/google/uuid"
+)
+
+// SetWebhooks sets the dispatcher events are delivered to webhooks with.
+// Without one no webhook is called.
+func (h *Handler) SetWebhooks(d *webhooks.Dispatcher) {
+	h.dispatcher = d
+}
+
+// emitWebhook queues the delivery of event with data to the webhooks
+// subscribed to it
+func (h *Handler) emitWebhook(ctx context.Context, event string, data any) {
+	if h.dispatcher == nil {
+		return
+	}
+	if err := h.dispatcher.Emit(ctx, event, data); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue webhook event", "event", event, "error", err)
+	}
+}
+
+// PruneWebhookDeliveries deletes the delivery log entries older than
+// retention
+func (h *Handler) PruneWebhookDeliveries(ctx context.Context, retention time.Duration) error {
+	pruned, err := h.webhooks.PruneDeliveries(ctx, time.Now().UTC().Add(-retention))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		slog.InfoContext(ctx, "Pruned webhook deliveries", "count", pruned)
+	}
+	return nil
+}
+
+// GetWebhooks handles GET /api/v1/admin/webhooks, listing the webhook
+// subscriptions without their secrets
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.webhooks.List(r.Context())
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch webhooks")
+		return
+	}
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    hooks,
+	})
+}
+
+// CreateWebhook handles POST /api/v1/admin/webhooks, subscribing a URL to
+// events. The response holds the secret deliveries are signed with, which
+// isn't shown again.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateWebhookRequest
+	if !decodeAndValidate(w, r, &req) {
+		return
+	}
+	if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		respondError(w, http.StatusBadRequest, "VALIDATION_ERROR", "url must be an absolute http or https URL")
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create webhook")
+		return
+	}
+	hook := models.Webhook{
+		ID:        uuid.New().String(),
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.webhooks.Create(r.Context(), &hook); err != nil {
+		respondDatabaseError(w, err, "Failed to create webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    hook,
+	})
+}
+
+// DeleteWebhook handles DELETE /api/v1/admin/webhooks/{id}, removing the
+// subscription and its delivery log
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := h.webhooks.Delete(r.Context(), id)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Webhook not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to delete webhook")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    map[string]string{"message": "Webhook deleted"},
+	})
+}
+
+// GetWebhookDeliveries handles GET /api/v1/admin/webhooks/{id}/deliveries,
+// the webhook's delivery attempts, newest first
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	params := getPaginationParams(r)
+	deliveries, total, err := h.webhooks.Deliveries(r.Context(), r.PathValue("id"), params)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "Webhook not found")
+		return
+	}
+	if err != nil {
+		respondDatabaseError(w, err, "Failed to fetch webhook deliveries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    deliveries,
+		Meta: &models.APIMeta{
+			Page:       params.Page,
+			PerPage:    params.PerPage,
+			Total:      total,
+			TotalPages: (int(total) + params.PerPage - 1) / params.PerPage,
+		},
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/webhooks"
+	"payforwardnow/internal/workers"
+)
+
+func TestWebhooks(t *testing.T) {
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "giver"}, "")
+	db.AddUser(models.User{ID: "receiver"}, "")
+	repos := db.Repositories()
+	handler := NewHandlerWithRepositories(&MockDBClient{}, repos)
+	pool := workers.New("test", 1, 10)
+	dispatcher := webhooks.New(repos.Webhooks, webhooks.Options{})
+	dispatcher.SetPool(pool)
+	handler.SetWebhooks(dispatcher)
+
+	var secret string
+	var payloads []webhooks.Payload
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := webhooks.Verify(secret, r.Header.Get(webhooks.SignatureHeader), body, time.Minute, time.Now()); err != nil {
+			t.Errorf("invalid signature: %v", err)
+		}
+		var p webhooks.Payload
+		json.Unmarshal(body, &p)
+		payloads = append(payloads, p)
+	}))
+	defer receiver.Close()
+
+	w := httptest.NewRecorder()
+	handler.CreateWebhook(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks",
+		strings.NewReader(`{"url":"ftp://example.com","events":["act.created"]}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected %d for a non-HTTP URL, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = httptest.NewRecorder()
+	handler.CreateWebhook(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks",
+		strings.NewReader(`{"url":"`+receiver.URL+`","events":["act.deleted"]}`)))
+	if w.Code == http.StatusCreated {
+		t.Errorf("expected an unknown event to be rejected")
+	}
+
+	w = httptest.NewRecorder()
+	handler.CreateWebhook(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/webhooks",
+		strings.NewReader(`{"url":"`+receiver.URL+`","events":["act.created","chain.extended"]}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created struct{ Data models.Webhook }
+	json.Unmarshal(w.Body.Bytes(), &created)
+	secret = created.Data.Secret
+	if !strings.HasPrefix(secret, "whsec_") {
+		t.Errorf("expected the secret to be returned on creation, got %q", secret)
+	}
+
+	w = httptest.NewRecorder()
+	handler.GetWebhooks(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks", nil))
+	if strings.Contains(w.Body.String(), secret) {
+		t.Errorf("expected the secret to be left out of the list, got %s", w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(
+		`{"title":"Fixed a bike","description":"Replaced the chain","type":"service","category":"other","receiverId":"receiver","isAnonymous":true}`))
+	req = asUser(req, "giver")
+	w = httptest.NewRecorder()
+	handler.CreateAct(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	pool.Stop(context.Background())
+
+	if len(payloads) != 1 || payloads[0].Type != webhooks.EventActCreated {
+		t.Fatalf("expected an act.created delivery, got %+v", payloads)
+	}
+	if act := payloads[0].Data.(map[string]any); act["giverId"] != "" {
+		t.Errorf("expected the anonymous act without its giver, got %v", act)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/"+created.Data.ID+"/deliveries", nil)
+	req.SetPathValue("id", created.Data.ID)
+	w = httptest.NewRecorder()
+	handler.GetWebhookDeliveries(w, req)
+	var deliveries struct {
+		Data []models.WebhookDelivery
+		Meta models.APIMeta
+	}
+	json.Unmarshal(w.Body.Bytes(), &deliveries)
+	if deliveries.Meta.Total != 1 || !deliveries.Data[0].Succeeded || deliveries.Data[0].EventID != payloads[0].ID {
+		t.Errorf("expected the delivery to be logged, got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/admin/webhooks/"+created.Data.ID, nil)
+	req.SetPathValue("id", created.Data.ID)
+	handler.DeleteWebhook(httptest.NewRecorder(), req)
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/webhooks/"+created.Data.ID+"/deliveries", nil)
+	req.SetPathValue("id", created.Data.ID)
+	w = httptest.NewRecorder()
+	handler.GetWebhookDeliveries(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected %d for a deleted webhook, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+const (
+	widgetStatsTTL     = 10 * time.Minute
+	widgetCacheControl = "public, max-age=3600, stale-while-revalidate=86400"
+)
+
+// GetStatsWidget handles GET /api/v1/stats/widget. It returns a tiny JSON
+// payload or an SVG badge meant to be embedded on third-party sites, with
+// caching headers so embeds don't hit the database on every page view.
+func (h *Handler) GetStatsWidget(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "svg"
+	}
+	if format != "svg" && format != "json" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAMETER", "format must be 'svg' or 'json'")
+		return
+	}
+
+	cacheKey := tenantCacheKey(r.Context(), "widget:stats")
+	stats, ok := cachedValue[*models.GlobalStats](r.Context(), h.cache, cacheKey)
+	if !ok {
+		var err error
+		stats, err = h.queryGlobalStats(r.Context())
+		if err != nil {
+			respondDatabaseError(w, err, "Failed to fetch stats")
+			return
+		}
+		cacheValue(r.Context(), h.cache, cacheKey, stats, widgetStatsTTL)
+	}
+
+	label := "pay it forward"
+	message := formatThousands(stats.TotalActs) + " acts of kindness"
+
+	var body []byte
+	var contentType string
+	if format == "json" {
+		body, _ = json.Marshal(map[string]interface{}{
+			"totalActs": stats.TotalActs,
+			"label":     label,
+			"message":   message,
+		})
+		contentType = "application/json"
+	} else {
+		body = []byte(renderBadgeSVG(label, message))
+		contentType = "image/svg+xml"
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("Cache-Control", widgetCacheControl)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if match := r.Header.Get("If-None-Match"); match != "" && etagMatches(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// renderBadgeSVG renders a flat two-part badge. Text widths are estimated,
+// which is good enough for the short, ASCII-only strings used here.
+func renderBadgeSVG(label, message string) string {
+	labelWidth := 10 + 7*len(label)
+	messageWidth := 10 + 7*len(message)
+	total := labelWidth + messageWidth
+
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`+
+		`<rect width="%d" height="20" fill="#555"/>`+
+		`<rect x="%d" width="%d" height="20" fill="#e0567a"/>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,sans-serif" font-size="11">`+
+		`<text x="%d" y="14">%s</text>`+
+		`<text x="%d" y="14">%s</text>`+
+		`</g></svg>`,
+		total, label, message,
+		labelWidth,
+		labelWidth, messageWidth,
+		labelWidth/2, label,
+		labelWidth+messageWidth/2, message,
+	)
+}
+
+// formatThousands formats n with comma thousands separators
+func formatThousands(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+
+	if negative {
+		return "-" + b.String()
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+func TestFormatThousands(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0",
+		999:     "999",
+		1000:    "1,000",
+		12402:   "12,402",
+		1234567: "1,234,567",
+		-4500:   "-4,500",
+	}
+
+	for input, expected := range tests {
+		if got := formatThousands(input); got != expected {
+			t.Errorf("formatThousands(%d): expected %s, got %s", input, expected, got)
+		}
+	}
+}
+
+func TestGetStatsWidget(t *testing.T) {
+	handler := NewHandler(&MockDBClient{})
+	cacheValue(context.Background(), handler.cache, "widget:stats", &models.GlobalStats{TotalActs: 12402}, time.Minute)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/widget", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetStatsWidget(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("expected image/svg+xml, got %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), "12,402 acts of kindness") {
+		t.Error("expected badge to contain the act count")
+	}
+	if !strings.Contains(w.Header().Get("Cache-Control"), "max-age=") {
+		t.Error("expected long Cache-Control header")
+	}
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/stats/widget", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+
+	handler.GetStatsWidget(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Error("expected empty body on 304")
+	}
+}
@@ -0,0 +1,122 @@
+// Package i18n translates the API's messages. They are written in English
+// in the code and looked up by that text in the catalogs of other languages,
+// embedded in the binary. A message missing from a catalog falls back to the
+// base language's catalog, so "pt-br" uses "pt", and then to English.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// DefaultLocale is the language messages are written in
+const DefaultLocale = "en"
+
+//go:embed catalogs/*.json
+var catalogFS embed.FS
+
+// catalogs maps a locale to its translations, keyed by the English message
+var catalogs = mustLoad(catalogFS)
+
+func mustLoad(fsys fs.FS) map[string]map[string]string {
+	loaded, err := load(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return loaded
+}
+
+// load reads catalogs/<locale>.json files, each a JSON object of English
+// messages to their translations
+func load(fsys fs.FS) (map[string]map[string]string, error) {
+	files, err := fs.Glob(fsys, "catalogs/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]map[string]string, len(files))
+	for _, file := range files {
+		locale := strings.TrimSuffix(path.Base(file), ".json")
+		if Normalize(locale) != locale {
+			return nil, fmt.Errorf("catalog %s: %q is not a normalized locale", file, locale)
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", file, err)
+		}
+		loaded[locale] = messages
+	}
+	return loaded, nil
+}
+
+// Locales returns the supported locales, English first and the others in
+// alphabetical order
+func Locales() []string {
+	locales := []string{DefaultLocale}
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	slices.Sort(locales[1:])
+	return locales
+}
+
+// Supported reports whether messages can be translated to locale, itself
+// or through its base language
+func Supported(locale string) bool {
+	if locale == DefaultLocale {
+		return true
+	}
+	if _, ok := catalogs[locale]; ok {
+		return true
+	}
+	base, _, found := strings.Cut(locale, "-")
+	_, ok := catalogs[base]
+	return found && ok
+}
+
+// Match returns the first of the preferred locales that is supported, or
+// DefaultLocale if none is
+func Match(preferred []string) string {
+	for _, locale := range preferred {
+		if Supported(locale) {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// Translate returns message in locale, falling back to its base language
+// and then to English. Placeholders such as {limit} are then replaced by
+// args, given in name, value pairs.
+func Translate(locale, message string, args ...string) string {
+	translated := message
+	if text, ok := catalogs[locale][message]; ok {
+		translated = text
+	} else if base, _, found := strings.Cut(locale, "-"); found {
+		if text, ok := catalogs[base][message]; ok {
+			translated = text
+		}
+	}
+
+	for i := 0; i+1 < len(args); i += 2 {
+		translated = strings.ReplaceAll(translated, "{"+args[i]+"}", args[i+1])
+	}
+	return translated
+}
+
+// Localize translates message to the language of the response, the
+// Content-Language set by middleware.Language. Responses without one are in
+// English.
+func Localize(w http.ResponseWriter, message string, args ...string) string {
+	return Translate(w.Header().Get("Content-Language"), message, args...)
+}
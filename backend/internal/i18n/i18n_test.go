@@ -0,0 +1,93 @@
+package i18n
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{[a-z]+\}`)
+
+func TestCatalogs(t *testing.T) {
+	if len(catalogs) == 0 {
+		t.Fatal("expected catalogs to be embedded")
+	}
+
+	// Every catalog translates the same messages, keeping their placeholders
+	var reference []string
+	for _, locale := range Locales()[1:] {
+		messages := catalogs[locale]
+		keys := slices.Sorted(maps.Keys(messages))
+		if reference == nil {
+			reference = keys
+		} else if !slices.Equal(keys, reference) {
+			t.Errorf("catalog %s translates different messages than the others", locale)
+		}
+
+		for message, translated := range messages {
+			want := placeholderPattern.FindAllString(message, -1)
+			got := placeholderPattern.FindAllString(translated, -1)
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("catalog %s: %q has placeholders %v, expected %v", locale, translated, got, want)
+			}
+		}
+	}
+}
+
+func TestLoadRejectsInvalidCatalogs(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"invalid JSON":    {"catalogs/it.json": {Data: []byte(`{"a":`)}},
+		"invalid locale":  {"catalogs/Italian.json": {Data: []byte(`{}`)}},
+		"not normalized":  {"catalogs/pt_BR.json": {Data: []byte(`{}`)}},
+		"non-string text": {"catalogs/it.json": {Data: []byte(`{"a": 1}`)}},
+	}
+	for name, fsys := range tests {
+		if _, err := load(fsys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		locale, message string
+		args            []string
+		expected        string
+	}{
+		{"", "User not found", nil, "User not found"},
+		{"en", "User not found", nil, "User not found"},
+		{"it", "User not found", nil, "Utente non trovato"},
+		{"pt-br", "User not found", nil, "Usuário não encontrado"},
+		{"nl", "User not found", nil, "User not found"},
+		{"it", "Some message no catalog has", nil, "Some message no catalog has"},
+		{"de", "must be at most {max} characters", []string{"max", "100"}, "darf höchstens 100 Zeichen lang sein"},
+		{"en", "must be at most {max} characters", []string{"max", "100"}, "must be at most 100 characters"},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.locale, tt.message, tt.args...); got != tt.expected {
+			t.Errorf("Translate(%q, %q) = %q, expected %q", tt.locale, tt.message, got, tt.expected)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		preferred []string
+		expected  string
+	}{
+		{nil, DefaultLocale},
+		{[]string{"nl", "ja"}, DefaultLocale},
+		{[]string{"nl", "fr", "it"}, "fr"},
+		{[]string{"pt-br", "pt"}, "pt-br"},
+		{[]string{"en", "it"}, "en"},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.preferred); got != tt.expected {
+			t.Errorf("Match(%v) = %q, expected %q", tt.preferred, got, tt.expected)
+		}
+	}
+}
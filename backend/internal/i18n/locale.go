@@ -0,0 +1,87 @@
+package i18n
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// localePattern matches a simplified BCP 47 tag such as "it" or "pt-br"
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})*$`)
+
+// Normalize lowercases a locale tag and converts underscores to
+// dashes. It returns an empty string if the tag is not valid.
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if !localePattern.MatchString(tag) {
+		return ""
+	}
+	return tag
+}
+
+// Preferred returns the caller's locales in order of preference. An
+// explicit ?locale= parameter wins over Accept-Language. Region-specific tags
+// are followed by their base language, so "pt-BR" also matches "pt".
+func Preferred(r *http.Request) []string {
+	if locale := Normalize(r.URL.Query().Get("locale")); locale != "" {
+		return withBaseLanguages([]string{locale})
+	}
+
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		fields := strings.Split(part, ";")
+		tag := Normalize(fields[0])
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag, q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	locales := make([]string, len(tags))
+	for i, t := range tags {
+		locales[i] = t.tag
+	}
+	return withBaseLanguages(locales)
+}
+
+func withBaseLanguages(locales []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+
+	for _, tag := range locales {
+		add(tag)
+	}
+	for _, tag := range locales {
+		if base, _, found := strings.Cut(tag, "-"); found {
+			add(base)
+		}
+	}
+	return result
+}
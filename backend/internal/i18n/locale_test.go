@@ -0,0 +1,38 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestPreferred(t *testing.T) {
+	tests := []struct {
+		name           string
+		url            string
+		acceptLanguage string
+		expected       []string
+	}{
+		{"none", "/", "", nil},
+		{"single", "/", "it", []string{"it"}},
+		{"weighted", "/", "en;q=0.5, pt-BR, fr;q=0.8", []string{"pt-br", "fr", "en", "pt"}},
+		{"zero weight dropped", "/", "de;q=0, es", []string{"es"}},
+		{"wildcard ignored", "/", "*, nl", []string{"nl"}},
+		{"query overrides header", "/?locale=pt_BR", "it", []string{"pt-br", "pt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			got := Preferred(req)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
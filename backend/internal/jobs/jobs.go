@@ -0,0 +1,383 @@
+// Package jobs runs background work from a queue persisted in the
+// database, so jobs survive restarts and are shared by every instance of
+// the server. Jobs are enqueued on demand, such as exports requested by
+// admins, or on cron schedules. Each scheduled occurrence has an ID derived
+// from its time, so instances enqueueing it together create one job, which
+// a single instance claims. Failed attempts are retried with exponential
+// backoff; a job that runs out of attempts stays failed until retried.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Defaults for Options left zero
+const (
+	DefaultConcurrency  = 2
+	DefaultPollInterval = time.Second
+	DefaultLease        = 10 * time.Minute
+	DefaultMaxAttempts  = 5
+	DefaultBackoff      = 30 * time.Second
+)
+
+// maxBackoff caps the delay before a retry
+const maxBackoff = time.Hour
+
+// Handler runs a job. Its result is stored with the job as JSON. Returning
+// an error queues the job again, unless it was the last attempt or the
+// error is Permanent.
+type Handler func(ctx context.Context, job *models.Job) (any, error)
+
+// Store persists the queue
+type Store interface {
+	// Enqueue stores job unless a job with its ID exists, and returns
+	// whether it did
+	Enqueue(ctx context.Context, job *models.Job) (bool, error)
+	// Claim marks the due job of one of types that has waited longest as
+	// running until now plus lease and returns it, or nil if none is due
+	Claim(ctx context.Context, types []string, now time.Time, lease time.Duration) (*models.Job, error)
+	// Finish records the outcome of an attempt at job, unless the lease
+	// claimed until claim expired and the job was claimed again since
+	Finish(ctx context.Context, job *models.Job, claim time.Time) error
+}
+
+// Options tunes a Queue
+type Options struct {
+	// Concurrency is the number of jobs an instance runs at once
+	Concurrency int
+	// PollInterval is how often idle workers look for due jobs and
+	// schedules are checked
+	PollInterval time.Duration
+	// Lease bounds an attempt. A job still running when its lease expires,
+	// because the instance running it died, is claimed again.
+	Lease time.Duration
+	// MaxAttempts is the number of attempts made at a job before it fails
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for each one
+	// after it
+	Backoff time.Duration
+}
+
+// Queue enqueues jobs and runs the ones due of the types it has handlers
+// for. The zero value is not usable; create one with New.
+type Queue struct {
+	store Store
+	opts  Options
+	now   func() time.Time
+
+	mu        sync.Mutex
+	handlers  map[string]Handler
+	schedules []*scheduled
+}
+
+type scheduled struct {
+	jobType  string
+	spec     string
+	schedule *Schedule
+	next     time.Time
+}
+
+// New creates a Queue persisted in store
+func New(store Store, opts Options) *Queue {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = DefaultLease
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = DefaultBackoff
+	}
+	return &Queue{
+		store:    store,
+		opts:     opts,
+		now:      func() time.Time { return time.Now().UTC() },
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register sets the handler running jobs of jobType
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// Schedule enqueues a job of jobType, which must have a handler, at every
+// time matching the cron expression spec. Occurrences missed while no
+// instance was running aren't made up.
+func (q *Queue) Schedule(jobType, spec string) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.handlers[jobType]; !ok {
+		return fmt.Errorf("no handler for job type %q", jobType)
+	}
+	q.schedules = append(q.schedules, &scheduled{
+		jobType:  jobType,
+		spec:     spec,
+		schedule: schedule,
+		next:     schedule.Next(q.now()),
+	})
+	return nil
+}
+
+// Schedules returns the scheduled job types with their next occurrence,
+// sorted by type
+func (q *Queue) Schedules() []models.JobSchedule {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	schedules := make([]models.JobSchedule, len(q.schedules))
+	for i, s := range q.schedules {
+		schedules[i] = models.JobSchedule{Type: s.jobType, Schedule: s.spec, Next: s.next}
+	}
+	sort.Slice(schedules, func(i, j int) bool { return schedules[i].Type < schedules[j].Type })
+	return schedules
+}
+
+// Enqueue adds a job of jobType with payload, encoded as JSON, to run as
+// soon as possible
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any) (*models.Job, error) {
+	q.mu.Lock()
+	_, ok := q.handlers[jobType]
+	q.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no handler for job type %q", jobType)
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s job payload: %w", jobType, err)
+	}
+	job := q.newJob(uuid.New().String(), jobType, q.now())
+	job.Payload = encoded
+	if _, err := q.store.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	metrics.JobsEnqueued.WithLabelValues(jobType).Inc()
+	return job, nil
+}
+
+func (q *Queue) newJob(id, jobType string, runAt time.Time) *models.Job {
+	now := q.now()
+	return &models.Job{
+		ID:          id,
+		Type:        jobType,
+		Status:      models.JobQueued,
+		MaxAttempts: q.opts.MaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// EnqueueDue enqueues the scheduled jobs whose occurrence has come. An
+// occurrence's job is identified by its type and time, so another instance
+// having enqueued it already is fine.
+func (q *Queue) EnqueueDue(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	var errs []error
+	for _, s := range q.schedules {
+		if s.next.IsZero() || now.Before(s.next) {
+			continue
+		}
+		id := s.jobType + "@" + s.next.Format(time.RFC3339)
+		created, err := q.store.Enqueue(ctx, q.newJob(id, s.jobType, s.next))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("enqueueing %s: %w", id, err))
+			continue
+		}
+		if created {
+			metrics.JobsEnqueued.WithLabelValues(s.jobType).Inc()
+		}
+		s.next = s.schedule.Next(now)
+	}
+	return errors.Join(errs...)
+}
+
+// Run enqueues scheduled jobs and runs due ones, Concurrency at a time,
+// until ctx is cancelled. Jobs interrupted then are queued again.
+func (q *Queue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range q.opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+	defer wg.Wait()
+
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := q.EnqueueDue(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to enqueue scheduled jobs", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// work runs due jobs one after the other, waiting for the poll interval
+// whenever none is due
+func (q *Queue) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		ran, err := q.RunNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to run background job", "error", err)
+		}
+		if ran && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.opts.PollInterval):
+		}
+	}
+}
+
+// RunNext claims the due job that has waited longest and runs it. It
+// returns false if no job was due.
+func (q *Queue) RunNext(ctx context.Context) (bool, error) {
+	q.mu.Lock()
+	types := make([]string, 0, len(q.handlers))
+	for t := range q.handlers {
+		types = append(types, t)
+	}
+	q.mu.Unlock()
+	if len(types) == 0 {
+		return false, nil
+	}
+
+	job, err := q.store.Claim(ctx, types, q.now(), q.opts.Lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+	claim := *job.LockedUntil
+	q.run(ctx, job)
+	// The outcome is recorded even when shutting down
+	return true, q.store.Finish(context.WithoutCancel(ctx), job, claim)
+}
+
+// run makes an attempt at job and sets its outcome
+func (q *Queue) run(ctx context.Context, job *models.Job) {
+	start := time.Now()
+	var result any
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		// The instance making the last attempt stopped before finishing it
+		err = Permanent(errors.New("lease expired on the last attempt"))
+	} else {
+		result, err = q.call(ctx, job)
+	}
+	metrics.JobSeconds.WithLabelValues(job.Type).Observe(time.Since(start).Seconds())
+
+	now := q.now()
+	job.UpdatedAt = now
+	job.LockedUntil = nil
+	if err == nil {
+		job.Result, err = json.Marshal(result)
+		if err != nil {
+			err = Permanent(fmt.Errorf("encoding result: %w", err))
+		}
+	}
+
+	switch {
+	case err == nil:
+		job.Status = models.JobSucceeded
+		job.LastError = ""
+		job.FinishedAt = &now
+		metrics.JobRuns.WithLabelValues(job.Type, "succeeded").Inc()
+		return
+	case ctx.Err() != nil:
+		// Interrupted by shutdown; the attempt doesn't count
+		job.Status = models.JobQueued
+		job.Attempts--
+		job.RunAt = now
+	case job.Attempts < job.MaxAttempts && !IsPermanent(err):
+		job.Status = models.JobQueued
+		job.RunAt = now.Add(q.backoff(job.Attempts))
+		metrics.JobRuns.WithLabelValues(job.Type, "retried").Inc()
+		slog.WarnContext(ctx, "Background job failed, retrying", "job", job.ID, "type", job.Type,
+			"attempt", job.Attempts, "retryAt", job.RunAt, "error", err)
+	default:
+		job.Status = models.JobFailed
+		job.FinishedAt = &now
+		metrics.JobRuns.WithLabelValues(job.Type, "failed").Inc()
+		slog.ErrorContext(ctx, "Background job failed", "job", job.ID, "type", job.Type,
+			"attempts", job.Attempts, "error", err)
+	}
+	job.Result = nil
+	job.LastError = err.Error()
+}
+
+// call runs job's handler, bounded by the lease, turning a panic into an
+// error
+func (q *Queue) call(ctx context.Context, job *models.Job) (result any, err error) {
+	q.mu.Lock()
+	handler := q.handlers[job.Type]
+	q.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, q.opts.Lease)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff returns the delay before retrying after attempt
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.Backoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one retrying won't fix, failing the job at once
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
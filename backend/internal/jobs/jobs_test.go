@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// clock is a time set by the test
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestQueue(store Store, opts Options) (*Queue, *clock) {
+	c := &clock{t: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	q := New(store, opts)
+	q.now = c.now
+	return q, c
+}
+
+func TestQueueRetries(t *testing.T) {
+	ctx := context.Background()
+	store := databasetest.New().Repositories().Jobs
+	q, clock := newTestQueue(store, Options{MaxAttempts: 3, Backoff: time.Minute})
+
+	calls := 0
+	q.Register("flaky", func(_ context.Context, job *models.Job) (any, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("unavailable")
+		}
+		var payload map[string]string
+		json.Unmarshal(job.Payload, &payload)
+		return map[string]string{"echo": payload["name"]}, nil
+	})
+	job, err := q.Enqueue(ctx, "flaky", map[string]string{"name": "report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(ctx, "unknown", nil); err == nil {
+		t.Error("expected a job without a handler to be rejected")
+	}
+
+	for i, delay := range []time.Duration{0, time.Minute, 2 * time.Minute} {
+		clock.advance(delay - time.Second)
+		if ran, _ := q.RunNext(ctx); ran && delay > 0 {
+			t.Fatalf("attempt %d: expected the retry to wait for the backoff", i+1)
+		}
+		clock.advance(time.Second)
+		if ran, err := q.RunNext(ctx); !ran || err != nil {
+			t.Fatalf("attempt %d: expected the job to run, got %v and %v", i+1, ran, err)
+		}
+	}
+
+	stored, _ := store.Get(ctx, job.ID)
+	if stored.Status != models.JobSucceeded || stored.Attempts != 3 || string(stored.Result) != `{"echo":"report"}` {
+		t.Errorf("expected the third attempt to succeed, got %+v", stored)
+	}
+	if ran, _ := q.RunNext(ctx); ran {
+		t.Error("expected nothing left to run")
+	}
+}
+
+func TestQueueFails(t *testing.T) {
+	ctx := context.Background()
+	store := databasetest.New().Repositories().Jobs
+	q, clock := newTestQueue(store, Options{MaxAttempts: 2, Backoff: time.Minute})
+	q.Register("broken", func(context.Context, *models.Job) (any, error) { return nil, errors.New("boom") })
+	q.Register("invalid", func(context.Context, *models.Job) (any, error) { return nil, Permanent(errors.New("bad payload")) })
+	q.Register("panicking", func(context.Context, *models.Job) (any, error) { panic("nil map") })
+
+	broken, _ := q.Enqueue(ctx, "broken", nil)
+	invalid, _ := q.Enqueue(ctx, "invalid", nil)
+	panicking, _ := q.Enqueue(ctx, "panicking", nil)
+	for range 2 {
+		for {
+			if ran, _ := q.RunNext(ctx); !ran {
+				break
+			}
+		}
+		clock.advance(time.Hour)
+	}
+
+	for _, check := range []struct {
+		id       string
+		attempts int
+		err      string
+	}{
+		{broken.ID, 2, "boom"},
+		{invalid.ID, 1, "bad payload"},
+		{panicking.ID, 2, "panic: nil map"},
+	} {
+		job, _ := store.Get(ctx, check.id)
+		if job.Status != models.JobFailed || job.Attempts != check.attempts || job.LastError != check.err || job.FinishedAt == nil {
+			t.Errorf("expected %s to fail after %d attempts with %q, got %+v", job.Type, check.attempts, check.err, job)
+		}
+	}
+
+	if _, err := store.Retry(ctx, broken.ID, clock.now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Retry(ctx, broken.ID, clock.now()); !errors.Is(err, repository.ErrJobNotFailed) {
+		t.Errorf("expected a queued job not to be retried, got %v", err)
+	}
+	if ran, _ := q.RunNext(ctx); !ran {
+		t.Error("expected the retried job to run")
+	}
+}
+
+func TestQueueLeaseExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := databasetest.New().Repositories().Jobs
+	q, clock := newTestQueue(store, Options{Lease: time.Minute})
+	q.Register("export", func(context.Context, *models.Job) (any, error) { return "done", nil })
+	job, _ := q.Enqueue(ctx, "export", nil)
+
+	// Another instance claims the job and dies
+	abandoned, _ := store.Claim(ctx, []string{"export"}, clock.now(), time.Minute)
+	if ran, _ := q.RunNext(ctx); ran {
+		t.Fatal("expected a job with a live lease not to be claimed")
+	}
+	clock.advance(time.Minute)
+	if ran, err := q.RunNext(ctx); !ran || err != nil {
+		t.Fatalf("expected the abandoned job to be claimed again, got %v and %v", ran, err)
+	}
+
+	abandoned.Status = models.JobFailed
+	store.Finish(ctx, abandoned, *abandoned.LockedUntil)
+	stored, _ := store.Get(ctx, job.ID)
+	if stored.Status != models.JobSucceeded || stored.Attempts != 2 {
+		t.Errorf("expected the stale attempt to be ignored, got %+v", stored)
+	}
+}
+
+func TestQueueSchedule(t *testing.T) {
+	ctx := context.Background()
+	store := databasetest.New().Repositories().Jobs
+	runs := 0
+	var instances []*Queue
+	var clocks []*clock
+	for range 2 {
+		q, clock := newTestQueue(store, Options{})
+		q.Register("digest", func(context.Context, *models.Job) (any, error) {
+			runs++
+			return nil, nil
+		})
+		if err := q.Schedule("digest", "*/5 * * * *"); err != nil {
+			t.Fatal(err)
+		}
+		instances = append(instances, q)
+		clocks = append(clocks, clock)
+	}
+	if err := instances[0].Schedule("unknown", "@daily"); err == nil {
+		t.Error("expected a schedule without a handler to be rejected")
+	}
+	if next := instances[0].Schedules()[0].Next; !next.Equal(clocks[0].now().Add(5 * time.Minute)) {
+		t.Errorf("expected the next occurrence at 12:05, got %v", next)
+	}
+
+	for i, q := range instances {
+		clocks[i].advance(5 * time.Minute)
+		if err := q.EnqueueDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, q := range instances {
+		for {
+			if ran, _ := q.RunNext(ctx); !ran {
+				break
+			}
+		}
+	}
+	if runs != 1 {
+		t.Errorf("expected the occurrence to run once across instances, got %d", runs)
+	}
+	if _, total, _ := store.List(ctx, repository.JobFilter{Type: "digest"}, models.PaginationParams{Page: 1, PerPage: 10}); total != 1 {
+		t.Errorf("expected a single job for the occurrence, got %d", total)
+	}
+}
+
+func TestQueueRun(t *testing.T) {
+	store := databasetest.New().Repositories().Jobs
+	q := New(store, Options{PollInterval: 10 * time.Millisecond})
+	done := make(chan string, 1)
+	q.Register("export", func(_ context.Context, job *models.Job) (any, error) {
+		done <- job.ID
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(stopped)
+	}()
+	job, _ := q.Enqueue(ctx, "export", nil)
+	select {
+	case id := <-done:
+		if id != job.ID {
+			t.Errorf("expected %s to run, got %s", job.ID, id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the job to run")
+	}
+	cancel()
+	<-stopped
+}
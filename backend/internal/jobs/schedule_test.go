@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC) // a Wednesday
+	for spec, expected := range map[string]string{
+		"* * * * *":        "2024-01-31T10:08:00Z",
+		"*/15 * * * *":     "2024-01-31T10:15:00Z",
+		"5 * * * *":        "2024-01-31T11:05:00Z",
+		"@hourly":          "2024-01-31T11:00:00Z",
+		"@daily":           "2024-02-01T00:00:00Z",
+		"30 3 * * 1":       "2024-02-05T03:30:00Z",
+		"0 9 * * 7":        "2024-02-04T09:00:00Z",
+		"0 0 29 2 *":       "2024-02-29T00:00:00Z",
+		"0 8-10,20 * *":    "",
+		"0 12 1 * 3":       "2024-01-31T12:00:00Z",
+		"0 0 31 * *":       "2024-03-31T00:00:00Z",
+		"10-20/5 22 * * *": "2024-01-31T22:10:00Z",
+	} {
+		s, err := ParseSchedule(spec)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error", spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", spec, err)
+			continue
+		}
+		if got := s.Next(from).Format(time.RFC3339); got != expected {
+			t.Errorf("%s: expected %s, got %s", spec, expected, got)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@yearly"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+	if s, _ := ParseSchedule("0 0 31 2 *"); !s.Next(time.Now()).IsZero() {
+		t.Error("expected a schedule matching no day to have no next occurrence")
+	}
+}
@@ -0,0 +1,104 @@
+// Package lifecycle stops the server's background components in order when
+// it shuts down, so jobs finish their batch and pending writes are flushed
+// before the connections they need are closed.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Manager keeps the components started by the server. Shutdown stops them
+// in the reverse order of their registration, like deferred calls, so a
+// component registered after the database is stopped before it.
+type Manager struct {
+	mu         sync.Mutex
+	timeout    time.Duration
+	components []component
+	stopped    bool
+}
+
+type component struct {
+	name string
+	stop func(context.Context) error
+}
+
+// New returns a Manager that gives each component at most timeout to stop
+func New(timeout time.Duration) *Manager {
+	return &Manager{timeout: timeout}
+}
+
+// OnStop registers a component stopped by calling stop. Its context is
+// done when the component's time to stop is up.
+func (m *Manager) OnStop(name string, stop func(context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, component{name: name, stop: stop})
+}
+
+// OnClose registers a component stopped by calling fn, such as a
+// connection pool's Close method
+func (m *Manager) OnClose(name string, fn func() error) {
+	m.OnStop(name, func(context.Context) error { return fn() })
+}
+
+// Go runs fn in a goroutine as a component. Stopping it cancels the
+// context fn was given and waits for fn to return.
+func (m *Manager) Go(ctx context.Context, name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn(ctx)
+	}()
+
+	m.OnStop(name, func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+}
+
+// Shutdown stops every component, last registered first. A component that
+// fails or runs out of time is logged and doesn't keep the others from
+// stopping; their errors are returned together. Shutdown only runs once.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	components := m.components
+	m.mu.Unlock()
+
+	var errs []error
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		start := time.Now()
+		if err := m.stopOne(ctx, c); err != nil {
+			slog.ErrorContext(ctx, "Component did not stop cleanly", "component", c.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			continue
+		}
+		slog.DebugContext(ctx, "Component stopped", "component", c.name, "duration", time.Since(start))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) stopOne(ctx context.Context, c component) error {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	return c.stop(ctx)
+}
@@ -0,0 +1,62 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	m := New(time.Second)
+	var mu sync.Mutex
+	var stopped []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = append(stopped, name)
+	}
+
+	m.OnClose("database", func() error { record("database"); return nil })
+	m.Go(context.Background(), "job", func(ctx context.Context) {
+		<-ctx.Done()
+		record("job")
+	})
+	m.OnStop("queue", func(context.Context) error { record("queue"); return nil })
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if want := []string{"queue", "job", "database"}; !slices.Equal(stopped, want) {
+		t.Errorf("stopped %v, want %v", stopped, want)
+	}
+
+	// A second shutdown does nothing
+	if err := m.Shutdown(context.Background()); err != nil || len(stopped) != 3 {
+		t.Errorf("expected a second Shutdown to do nothing")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	m := New(20 * time.Millisecond)
+	closed := false
+	m.OnClose("database", func() error { closed = true; return nil })
+	m.Go(context.Background(), "stuck", func(ctx context.Context) {
+		time.Sleep(time.Second)
+	})
+	m.OnStop("broken", func(context.Context) error { return errors.New("boom") })
+
+	start := time.Now()
+	err := m.Shutdown(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the stuck component to time out, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected Shutdown not to wait for the stuck component")
+	}
+	if !closed {
+		t.Error("expected the components after a failure to be stopped")
+	}
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Config holds logging configuration
+type Config struct {
+	// Level is one of debug, info, warn or error
+	Level string
+	// Format is either "json" or "text"
+	Format string
+	// LevelVar, when set, is given Level and controls the logger's level
+	// from then on, so it can be changed while the server runs
+	LevelVar *slog.LevelVar
+}
+
+// ParseLevel converts a level name into a slog.Level
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+// New creates a logger writing to w. Records logged with a context carrying
+// a request scope automatically include the scope's attributes.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if cfg.LevelVar != nil {
+		cfg.LevelVar.Set(level)
+		opts.Level = cfg.LevelVar
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "", "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", cfg.Format)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+type scopeKey struct{}
+
+// scope collects attributes for the lifetime of a request. It is shared by
+// pointer so attributes added by inner middleware (like the authenticated
+// user) are visible to outer middleware that logs after the request.
+type scope struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithScope returns a context carrying a new, empty request scope
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &scope{})
+}
+
+// AddAttrs adds attributes to the request scope in ctx. It does nothing if
+// ctx has no scope.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	s.attrs = append(s.attrs, attrs...)
+	s.mu.Unlock()
+}
+
+// Lookup returns the value of the last attribute named key in the request
+// scope in ctx, such as a user ID added by inner middleware
+func Lookup(ctx context.Context, key string) (slog.Value, bool) {
+	attrs := scopeAttrs(ctx)
+	for i := len(attrs) - 1; i >= 0; i-- {
+		if attrs[i].Key == key {
+			return attrs[i].Value, true
+		}
+	}
+	return slog.Value{}, false
+}
+
+func scopeAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	s, ok := ctx.Value(scopeKey{}).(*scope)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]slog.Attr(nil), s.attrs...)
+}
+
+// contextHandler adds the request scope attributes to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := scopeAttrs(ctx); len(attrs) > 0 {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"INFO":    slog.LevelInfo,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	}
+
+	for input, expected := range tests {
+		got, err := ParseLevel(input)
+		if err != nil || got != expected {
+			t.Errorf("ParseLevel(%q): expected %v, got %v (err %v)", input, expected, got, err)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestNew_InvalidFormat(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Config{Format: "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestNew_JSONWithScope(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Level: "info", Format: "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := WithScope(context.Background())
+	AddAttrs(ctx, slog.String("request_id", "req-1"))
+	AddAttrs(ctx, slog.String("user_id", "u1"))
+
+	logger.DebugContext(ctx, "hidden")
+	logger.InfoContext(ctx, "hello", "n", 1)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a single JSON line, got %q: %v", buf.String(), err)
+	}
+
+	if entry["msg"] != "hello" || entry["request_id"] != "req-1" || entry["user_id"] != "u1" {
+		t.Errorf("unexpected entry %v", entry)
+	}
+}
+
+func TestNew_LevelVar(t *testing.T) {
+	var buf bytes.Buffer
+	var level slog.LevelVar
+	logger, err := New(&buf, Config{Level: "warn", LevelVar: &level})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger.Info("hidden")
+	level.Set(slog.LevelDebug)
+	logger.Debug("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("expected the level to follow the LevelVar, got %q", buf.String())
+	}
+}
+
+func TestAddAttrs_WithoutScope(t *testing.T) {
+	// Must not panic
+	AddAttrs(context.Background(), slog.String("k", "v"))
+}
+
+func TestLookup(t *testing.T) {
+	ctx := WithScope(context.Background())
+	AddAttrs(ctx, slog.String("user_id", "u1"))
+	AddAttrs(ctx, slog.String("user_id", "u2"))
+
+	if value, ok := Lookup(ctx, "user_id"); !ok || value.String() != "u2" {
+		t.Errorf("expected the latest user_id, got %v (found %v)", value, ok)
+	}
+	if _, ok := Lookup(ctx, "missing"); ok {
+		t.Error("expected missing key not to be found")
+	}
+	if _, ok := Lookup(context.Background(), "user_id"); ok {
+		t.Error("expected nothing without a scope")
+	}
+}
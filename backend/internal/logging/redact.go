@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Redacted replaces the values of sensitive fields
+const Redacted = "[REDACTED]"
+
+// DefaultRedactFields are always redacted by a Redactor
+var DefaultRedactFields = []string{
+	"password",
+	"token",
+	"secret",
+	"authorization",
+	"cookie",
+	"apikey",
+}
+
+// Redactor hides sensitive values before they are logged. A field or header
+// is sensitive if its name, ignoring case, dashes and underscores, contains
+// an entry of the deny-list, so "token" also covers "refresh_token" and
+// "X-Api-Token".
+type Redactor struct {
+	deny []string
+}
+
+// NewRedactor creates a Redactor for DefaultRedactFields plus fields
+func NewRedactor(fields ...string) *Redactor {
+	r := &Redactor{}
+	for _, field := range append(append([]string(nil), DefaultRedactFields...), fields...) {
+		if field = normalizeField(field); field != "" {
+			r.deny = append(r.deny, field)
+		}
+	}
+	return r
+}
+
+func normalizeField(name string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// Sensitive reports whether values of the named field must be redacted
+func (r *Redactor) Sensitive(name string) bool {
+	name = normalizeField(name)
+	for _, deny := range r.deny {
+		if strings.Contains(name, deny) {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers returns h flattened for logging, with sensitive headers redacted
+func (r *Redactor) Headers(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for name, values := range h {
+		if r.Sensitive(name) {
+			headers[name] = Redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// JSON returns a JSON body for logging with the values of sensitive fields
+// redacted at any depth. Bodies that are not valid JSON could hold secrets
+// in any shape, so only their size is reported.
+func (r *Redactor) JSON(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Sprintf("[%d bytes, not JSON]", len(body))
+	}
+
+	redacted, err := json.Marshal(r.redact(value))
+	if err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	return string(redacted)
+}
+
+func (r *Redactor) redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.Sensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = r.redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.redact(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRedactor_JSON(t *testing.T) {
+	r := NewRedactor("iban")
+
+	body := `{"email":"a@b.c","password":"hunter2","profile":{"refresh_token":"t","IBAN":"DE00"},"items":[{"apiKey":"k","name":"n"}]}`
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(r.JSON([]byte(body))), &got); err != nil {
+		t.Fatalf("expected JSON output: %v", err)
+	}
+
+	profile := got["profile"].(map[string]interface{})
+	item := got["items"].([]interface{})[0].(map[string]interface{})
+	if got["password"] != Redacted || profile["refresh_token"] != Redacted || profile["IBAN"] != Redacted || item["apiKey"] != Redacted {
+		t.Errorf("expected sensitive fields redacted, got %v", got)
+	}
+	if got["email"] != "a@b.c" || item["name"] != "n" {
+		t.Errorf("expected other fields kept, got %v", got)
+	}
+}
+
+func TestRedactor_JSON_NotJSON(t *testing.T) {
+	got := NewRedactor().JSON([]byte("password=hunter2"))
+	if got != "[16 bytes, not JSON]" {
+		t.Errorf("expected body described by size, got %q", got)
+	}
+}
+
+func TestRedactor_Headers(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Api-Token", "abc")
+	h.Set("Content-Type", "application/json")
+
+	got := NewRedactor().Headers(h)
+	if got["Authorization"] != Redacted || got["X-Api-Token"] != Redacted {
+		t.Errorf("expected sensitive headers redacted, got %v", got)
+	}
+	if got["Content-Type"] != "application/json" {
+		t.Errorf("expected Content-Type kept, got %v", got)
+	}
+}
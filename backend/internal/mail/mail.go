@@ -0,0 +1,146 @@
+// Package mail sends transactional emails, such as account verification,
+// password resets, received acts and weekly digests, rendered from embedded
+// templates and delivered through a pluggable driver: SMTP, Amazon SES,
+// SendGrid or the log for development.
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+)
+
+// Message is a rendered email
+type Message struct {
+	To      string
+	Subject string
+	// Text is the plain text body, HTML the alternative for clients that
+	// show it
+	Text string
+	HTML string
+}
+
+// Driver delivers messages
+type Driver interface {
+	// Send delivers msg once. Errors marked with Permanent or Rejected
+	// aren't retried.
+	Send(ctx context.Context, msg Message) error
+	// Name names the driver in logs and metrics
+	Name() string
+}
+
+// Config selects and configures a driver
+type Config struct {
+	// Driver is "smtp", "ses", "sendgrid", "log" or empty to disable email
+	Driver string
+	// From is the sender, such as "PayForward <hello@payforward.example>"
+	From string
+
+	// SMTPAddr is the host:port of the SMTP server. STARTTLS is used when
+	// the server offers it; SMTPUsername and SMTPPassword enable PLAIN
+	// authentication, which Go only allows over TLS or to localhost.
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// SESRegion, SESAccessKeyID and SESSecretAccessKey configure the ses
+	// driver
+	SESRegion          string
+	SESAccessKeyID     string
+	SESSecretAccessKey string
+
+	// SendGridAPIKey configures the sendgrid driver
+	SendGridAPIKey string
+}
+
+// NewDriver returns the driver described by cfg, or nil if email is
+// disabled
+func NewDriver(cfg Config) (Driver, error) {
+	if cfg.Driver == "" {
+		return nil, nil
+	}
+	if cfg.Driver != "log" {
+		if cfg.From == "" {
+			return nil, fmt.Errorf("the %s mail driver needs a sender address", cfg.Driver)
+		}
+		if _, err := mail.ParseAddress(cfg.From); err != nil {
+			return nil, fmt.Errorf("invalid sender address %q: %w", cfg.From, err)
+		}
+	}
+
+	switch cfg.Driver {
+	case "log":
+		return Log{}, nil
+	case "smtp":
+		if cfg.SMTPAddr == "" {
+			return nil, errors.New("the smtp mail driver needs a server address")
+		}
+		return &SMTP{Addr: cfg.SMTPAddr, Username: cfg.SMTPUsername, Password: cfg.SMTPPassword, From: cfg.From}, nil
+	case "ses":
+		if cfg.SESRegion == "" || cfg.SESAccessKeyID == "" || cfg.SESSecretAccessKey == "" {
+			return nil, errors.New("the ses mail driver needs a region and an access key")
+		}
+		return &SES{Region: cfg.SESRegion, AccessKeyID: cfg.SESAccessKeyID, SecretAccessKey: cfg.SESSecretAccessKey, From: cfg.From}, nil
+	case "sendgrid":
+		if cfg.SendGridAPIKey == "" {
+			return nil, errors.New("the sendgrid mail driver needs an API key")
+		}
+		return &SendGrid{APIKey: cfg.SendGridAPIKey, From: cfg.From}, nil
+	}
+	return nil, fmt.Errorf("unknown mail driver %q; use smtp, ses, sendgrid or log", cfg.Driver)
+}
+
+// permanentError marks a failure that retrying won't fix
+type permanentError struct {
+	err error
+	// rejected is set when the recipient was refused
+	rejected bool
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure that retrying won't fix, such as a
+// message the provider refused or invalid credentials
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Rejected marks err as the recipient's address being refused, such as a
+// mailbox that doesn't exist. It is permanent, and the Mailer suppresses
+// the address.
+func Rejected(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err, rejected: true}
+}
+
+// IsPermanent reports whether err was marked with Permanent or Rejected
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// IsRejected reports whether err was marked with Rejected
+func IsRejected(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p) && p.rejected
+}
+
+// Log writes messages to the log instead of sending them, for development
+type Log struct{}
+
+// Send logs msg's recipient, subject and text body
+func (Log) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "Email", "to", msg.To, "subject", msg.Subject, "text", msg.Text)
+	return nil
+}
+
+// Name returns "log"
+func (Log) Name() string { return "log" }
@@ -0,0 +1,336 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder is a Driver failing with the queued errors before succeeding
+type recorder struct {
+	mu   sync.Mutex
+	errs []error
+	sent []Message
+	// attempts counts every call to Send
+	attempts int
+}
+
+func (r *recorder) Send(_ context.Context, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return err
+	}
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+// memorySuppressions is a Suppressions backed by a map
+type memorySuppressions map[string]string
+
+func (m memorySuppressions) Suppressed(_ context.Context, email string) (bool, error) {
+	_, ok := m[email]
+	return ok, nil
+}
+
+func (m memorySuppressions) Suppress(_ context.Context, email, reason string) error {
+	m[email] = reason
+	return nil
+}
+
+func TestRenderTemplates(t *testing.T) {
+	m, err := New(&recorder{}, nil, Options{BaseURL: "https://payforward.example/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[Template]any{
+		TemplateVerification:  VerificationData{Name: "Ada", URL: m.Link("/verify?token=t"), ExpiresIn: 24 * time.Hour},
+		TemplatePasswordReset: PasswordResetData{Name: "Ada", URL: m.Link("/reset?token=t"), ExpiresIn: time.Hour},
+		TemplateActReceived:   ActReceivedData{Name: "Ada", GiverName: "Grace", ActTitle: "Fixed <b>the</b> bike", URL: m.Link("/acts/a1")},
+		TemplateWeeklyDigest:  WeeklyDigestData{Name: "Ada", ActsReceived: 1, ChainsGrown: 3, URL: m.Link("/notifications")},
+		TemplateOrgInvitation: OrgInvitationData{InviterName: "Ada", OrganizationName: "Hill School", Role: "admin", URL: m.Link("/invitations?token=t"), ExpiresIn: 7 * 24 * time.Hour},
+	}
+	for _, template := range Templates {
+		msg, err := m.Render(template, "ada@example.com", data[template])
+		if err != nil {
+			t.Errorf("%s: %v", template, err)
+			continue
+		}
+		if msg.To != "ada@example.com" || msg.Subject == "" || !strings.Contains(msg.Text, "Ada") || !strings.Contains(msg.HTML, "<html>") {
+			t.Errorf("%s: unexpected message %+v", template, msg)
+		}
+	}
+
+	msg, _ := m.Render(TemplateActReceived, "ada@example.com", data[TemplateActReceived])
+	if msg.Subject != "Grace did something kind for you" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.HTML, "Fixed &lt;b&gt;the&lt;/b&gt; bike") || !strings.Contains(msg.Text, "Fixed <b>the</b> bike") {
+		t.Error("expected the HTML body, and only it, to be escaped")
+	}
+	if !strings.Contains(msg.Text, "https://payforward.example/acts/a1") {
+		t.Errorf("expected the link in the text body, got %q", msg.Text)
+	}
+
+	msg, _ = m.Render(TemplateWeeklyDigest, "ada@example.com", data[TemplateWeeklyDigest])
+	if !strings.Contains(msg.Text, "1 act of kindness") || !strings.Contains(msg.Text, "3 times") || strings.Contains(msg.Text, "testimonial") {
+		t.Errorf("unexpected digest %q", msg.Text)
+	}
+
+	if _, err := m.Render(TemplateVerification, "ada@example.com", ActReceivedData{}); err == nil {
+		t.Error("expected an error rendering with the wrong data")
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	driver := &recorder{errs: []error{errors.New("timeout"), errors.New("timeout")}}
+	m, _ := New(driver, nil, Options{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	if err := m.Deliver(context.Background(), TemplateActReceived, Message{To: "ada@example.com"}); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+	if driver.attempts != 3 || len(driver.sent) != 1 {
+		t.Errorf("expected 3 attempts and 1 message, got %d and %d", driver.attempts, len(driver.sent))
+	}
+
+	driver = &recorder{errs: []error{errors.New("a"), errors.New("b"), errors.New("c")}}
+	m, _ = New(driver, nil, Options{MaxAttempts: 2, Backoff: time.Millisecond})
+	if err := m.Deliver(context.Background(), TemplateActReceived, Message{To: "ada@example.com"}); err == nil || driver.attempts != 2 {
+		t.Errorf("expected to give up after 2 attempts, got %v after %d", err, driver.attempts)
+	}
+
+	driver = &recorder{errs: []error{Permanent(errors.New("bad credentials"))}}
+	m, _ = New(driver, nil, Options{Backoff: time.Millisecond})
+	if err := m.Deliver(context.Background(), TemplateActReceived, Message{To: "ada@example.com"}); !IsPermanent(err) || driver.attempts != 1 {
+		t.Errorf("expected a permanent failure not to be retried, got %v after %d attempts", err, driver.attempts)
+	}
+}
+
+func TestDeliverSuppression(t *testing.T) {
+	suppressions := memorySuppressions{"gone@example.com": "admin"}
+	driver := &recorder{errs: []error{Rejected(errors.New("no such mailbox"))}}
+	m, _ := New(driver, suppressions, Options{Backoff: time.Millisecond})
+	ctx := context.Background()
+
+	if err := m.Deliver(ctx, TemplateActReceived, Message{To: "gone@example.com"}); !errors.Is(err, ErrSuppressed) {
+		t.Errorf("expected ErrSuppressed, got %v", err)
+	}
+	if driver.attempts != 0 {
+		t.Error("expected no delivery to a suppressed address")
+	}
+
+	if err := m.Deliver(ctx, TemplateActReceived, Message{To: "bounce@example.com"}); !IsRejected(err) {
+		t.Errorf("expected the rejection, got %v", err)
+	}
+	if suppressions["bounce@example.com"] != "rejected" {
+		t.Errorf("expected the rejected address to be suppressed, got %v", suppressions)
+	}
+}
+
+func TestSendQueues(t *testing.T) {
+	driver := &recorder{}
+	m, _ := New(driver, nil, Options{})
+	if err := m.Send(context.Background(), TemplateVerification, "ada@example.com", ActReceivedData{}); err == nil {
+		t.Error("expected Send to return rendering errors")
+	}
+	if err := m.Send(context.Background(), TemplatePasswordReset, "ada@example.com", PasswordResetData{Name: "Ada", ExpiresIn: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		driver.mu.Lock()
+		sent := len(driver.sent)
+		driver.mu.Unlock()
+		if sent == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the message to be delivered in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewDriver(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    string
+		wantErr bool
+	}{
+		{name: "disabled", cfg: Config{}},
+		{name: "log", cfg: Config{Driver: "log"}, want: "log"},
+		{name: "smtp", cfg: Config{Driver: "smtp", From: "hello@payforward.example", SMTPAddr: "localhost:25"}, want: "smtp"},
+		{name: "smtp without address", cfg: Config{Driver: "smtp", From: "hello@payforward.example"}, wantErr: true},
+		{name: "ses", cfg: Config{Driver: "ses", From: "PayForward <hello@payforward.example>", SESRegion: "eu-west-1", SESAccessKeyID: "id", SESSecretAccessKey: "secret"}, want: "ses"},
+		{name: "ses without key", cfg: Config{Driver: "ses", From: "hello@payforward.example", SESRegion: "eu-west-1"}, wantErr: true},
+		{name: "sendgrid", cfg: Config{Driver: "sendgrid", From: "hello@payforward.example", SendGridAPIKey: "key"}, want: "sendgrid"},
+		{name: "missing sender", cfg: Config{Driver: "sendgrid", SendGridAPIKey: "key"}, wantErr: true},
+		{name: "invalid sender", cfg: Config{Driver: "sendgrid", From: "hello", SendGridAPIKey: "key"}, wantErr: true},
+		{name: "unknown", cfg: Config{Driver: "pigeon"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver, err := NewDriver(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDriver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want == "" {
+				if driver != nil && !tt.wantErr {
+					t.Errorf("expected no driver, got %s", driver.Name())
+				}
+				return
+			}
+			if driver == nil || driver.Name() != tt.want {
+				t.Errorf("expected the %s driver, got %v", tt.want, driver)
+			}
+		})
+	}
+}
+
+func TestSendGrid(t *testing.T) {
+	status := http.StatusAccepted
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	s := &SendGrid{APIKey: "key", From: "PayForward <hello@payforward.example>", URL: server.URL}
+	msg := Message{To: "ada@example.com", Subject: "Hi", Text: "Hello", HTML: "<p>Hello</p>"}
+	if err := s.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if from := body["from"].(map[string]any); from["email"] != "hello@payforward.example" || from["name"] != "PayForward" {
+		t.Errorf("unexpected sender %v", from)
+	}
+	if content := body["content"].([]any); len(content) != 2 {
+		t.Errorf("expected text and HTML content, got %v", content)
+	}
+
+	status = http.StatusBadRequest
+	if err := s.Send(context.Background(), msg); !IsPermanent(err) {
+		t.Errorf("expected a 400 to be permanent, got %v", err)
+	}
+	status = http.StatusTooManyRequests
+	if err := s.Send(context.Background(), msg); err == nil || IsPermanent(err) {
+		t.Errorf("expected a 429 to be retryable, got %v", err)
+	}
+}
+
+func TestSESSignsRequests(t *testing.T) {
+	var auth, path string
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"MessageId":"1"}`))
+	}))
+	defer server.Close()
+
+	s := &SES{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", From: "hello@payforward.example", Endpoint: server.URL}
+	if err := s.Send(context.Background(), Message{To: "ada@example.com", Subject: "Hi", Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v2/email/outbound-emails" {
+		t.Errorf("unexpected path %q", path)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/ses/aws4_request") || !strings.Contains(auth, "Signature=") {
+		t.Errorf("unexpected Authorization %q", auth)
+	}
+	if body["FromEmailAddress"] != "hello@payforward.example" {
+		t.Errorf("unexpected body %v", body)
+	}
+}
+
+// fakeSMTP serves one SMTP session, answering RCPT with rcptReply, and
+// sends the received DATA on the returned channel
+func fakeSMTP(t *testing.T, rcptReply string) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	data := make(chan string, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 fake ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				reply("250 fake")
+			case "MAIL":
+				reply("250 OK")
+			case "RCPT":
+				reply(rcptReply)
+			case "DATA":
+				reply("354 go ahead")
+				var b strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					b.WriteString(l)
+				}
+				data <- b.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), data
+}
+
+func TestSMTP(t *testing.T) {
+	addr, data := fakeSMTP(t, "250 OK")
+	s := &SMTP{Addr: addr, From: "PayForward <hello@payforward.example>"}
+	msg := Message{To: "ada@example.com", Subject: "Héllo", Text: "Hello Ada", HTML: "<p>Hello Ada</p>"}
+	if err := s.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	got := <-data
+	for _, want := range []string{"To: ada@example.com", "Subject: =?utf-8?q?H=C3=A9llo?=", "multipart/alternative", "text/plain", "text/html", "Hello Ada"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected the message to contain %q:\n%s", want, got)
+		}
+	}
+
+	addr, _ = fakeSMTP(t, "550 no such user")
+	s.Addr = addr
+	if err := s.Send(context.Background(), msg); !IsRejected(err) {
+		t.Errorf("expected a 550 to RCPT to reject the recipient, got %v", err)
+	}
+}
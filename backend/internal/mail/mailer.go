@@ -0,0 +1,164 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/workers"
+)
+
+// ErrSuppressed is returned by Deliver for addresses on the suppression
+// list
+var ErrSuppressed = errors.New("address is suppressed")
+
+// Suppressions is the list of addresses never to email, such as those that
+// bounced or asked not to be contacted
+type Suppressions interface {
+	// Suppressed reports whether email is on the list
+	Suppressed(ctx context.Context, email string) (bool, error)
+	// Suppress adds email to the list, recording why
+	Suppress(ctx context.Context, email, reason string) error
+}
+
+// Options tunes a Mailer
+type Options struct {
+	// BaseURL is the frontend's address, which links in emails start with
+	BaseURL string
+	// MaxAttempts bounds the deliveries of a message, the first included.
+	// Defaults to 5.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled before each of
+	// the next ones. Defaults to a second.
+	Backoff time.Duration
+}
+
+// Mailer renders templates and delivers them through a driver, retrying
+// transient failures and skipping suppressed addresses
+type Mailer struct {
+	driver       Driver
+	templates    map[Template]templateSet
+	suppressions Suppressions
+	pool         *workers.Pool
+	options      Options
+}
+
+// New creates a Mailer sending through driver. suppressions may be nil to
+// email every address.
+func New(driver Driver, suppressions Suppressions, options Options) (*Mailer, error) {
+	templates, err := parseTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("parsing email templates: %w", err)
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 5
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = time.Second
+	}
+	options.BaseURL = strings.TrimSuffix(options.BaseURL, "/")
+	return &Mailer{driver: driver, templates: templates, suppressions: suppressions, options: options}, nil
+}
+
+// SetPool sets the pool Send queues deliveries on. Without one, each
+// delivery runs in its own goroutine.
+func (m *Mailer) SetPool(pool *workers.Pool) {
+	m.pool = pool
+}
+
+// Link returns the frontend URL of path, such as "/acts/123"
+func (m *Mailer) Link(path string) string {
+	return m.options.BaseURL + path
+}
+
+// Render renders template for to with data, which must be of the type
+// documented with the template
+func (m *Mailer) Render(template Template, to string, data any) (Message, error) {
+	set, ok := m.templates[template]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", template)
+	}
+	msg, err := set.render(to, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("rendering email template %s: %w", template, err)
+	}
+	return msg, nil
+}
+
+// Send renders template for to and queues its delivery on the worker pool,
+// returning once it is queued. The delivery keeps ctx's values but not its
+// cancellation. Rendering errors and a full queue are returned; delivery
+// failures are logged and counted.
+func (m *Mailer) Send(ctx context.Context, template Template, to string, data any) error {
+	msg, err := m.Render(template, to, data)
+	if err != nil {
+		return err
+	}
+	task := func(ctx context.Context) error {
+		err := m.Deliver(ctx, template, msg)
+		if errors.Is(err, ErrSuppressed) {
+			return nil
+		}
+		return err
+	}
+	return m.pool.Go(ctx, "email", task)
+}
+
+// Deliver sends msg, rendered from template, now. Transient failures are
+// retried with exponential backoff up to the configured number of
+// attempts; permanent ones aren't, and rejected recipients are added to the
+// suppression list. Suppressed recipients return ErrSuppressed without a
+// delivery.
+func (m *Mailer) Deliver(ctx context.Context, template Template, msg Message) error {
+	driver := m.driver.Name()
+	if m.suppressions != nil {
+		suppressed, err := m.suppressions.Suppressed(ctx, msg.To)
+		if err != nil {
+			return fmt.Errorf("checking the suppression list: %w", err)
+		}
+		if suppressed {
+			metrics.MailMessages.WithLabelValues(driver, string(template), "suppressed").Inc()
+			return ErrSuppressed
+		}
+	}
+
+	wait := m.options.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = m.driver.Send(ctx, msg)
+		if err == nil {
+			metrics.MailAttempts.WithLabelValues(driver, "ok").Inc()
+			metrics.MailMessages.WithLabelValues(driver, string(template), "sent").Inc()
+			return nil
+		}
+		metrics.MailAttempts.WithLabelValues(driver, "error").Inc()
+		if IsPermanent(err) || attempt == m.options.MaxAttempts {
+			break
+		}
+
+		slog.WarnContext(ctx, "Email delivery failed, retrying", "driver", driver, "template", template, "attempt", attempt, "retry_in", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			metrics.MailMessages.WithLabelValues(driver, string(template), "failed").Inc()
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+
+	if IsRejected(err) {
+		metrics.MailMessages.WithLabelValues(driver, string(template), "rejected").Inc()
+		if m.suppressions != nil {
+			if serr := m.suppressions.Suppress(ctx, msg.To, "rejected"); serr != nil {
+				slog.ErrorContext(ctx, "Failed to suppress a rejected address", "error", serr)
+			}
+		}
+		return err
+	}
+	metrics.MailMessages.WithLabelValues(driver, string(template), "failed").Inc()
+	return err
+}
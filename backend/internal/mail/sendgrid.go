@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"payforwardnow/internal/version"
+)
+
+// DefaultSendGridURL is SendGrid's v3 mail send endpoint
+const DefaultSendGridURL = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGrid sends messages with the SendGrid v3 API
+type SendGrid struct {
+	APIKey string
+	From   string
+	// URL defaults to DefaultSendGridURL
+	URL string
+	// Client defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Send delivers msg in one API request. Rejected requests, other than for
+// rate limits, are permanent failures.
+func (s *SendGrid) Send(ctx context.Context, msg Message) error {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	content := []sendGridContent{{Type: "text/plain", Value: msg.Text}}
+	if msg.HTML != "" {
+		content = append(content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	body, err := json.Marshal(map[string]any{
+		"personalizations": []map[string]any{{"to": []sendGridAddress{{Email: msg.To}}}},
+		"from":             sendGridAddress{Email: from.Address, Name: from.Name},
+		"subject":          msg.Subject,
+		"content":          content,
+	})
+	if err != nil {
+		return err
+	}
+
+	url := s.URL
+	if url == "" {
+		url = DefaultSendGridURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	return doMailRequest(httpClient(s.Client), req, "sendgrid")
+}
+
+// Name returns "sendgrid"
+func (s *SendGrid) Name() string { return "sendgrid" }
+
+func httpClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: 30 * time.Second, Transport: version.NewTransport(nil)}
+}
+
+// doMailRequest sends an email API request. 4xx responses other than 408
+// and 429 are permanent failures; the rest can be retried.
+func doMailRequest(client *http.Client, req *http.Request, provider string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("%s request failed: status %d: %s", provider, resp.StatusCode, bytes.TrimSpace(detail))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return Permanent(err)
+	}
+	return err
+}
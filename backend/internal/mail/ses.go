@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SES sends messages with the Amazon SES v2 API, signing requests with
+// Signature Version 4
+type SES struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	From            string
+	// Endpoint defaults to the region's SES endpoint
+	Endpoint string
+	// Client defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+// Send delivers msg in one SendEmail request. Rejected requests, other
+// than for throttling, are permanent failures.
+func (s *SES) Send(ctx context.Context, msg Message) error {
+	body := map[string]any{"Text": sesContent{Data: msg.Text, Charset: "UTF-8"}}
+	if msg.HTML != "" {
+		body["Html"] = sesContent{Data: msg.HTML, Charset: "UTF-8"}
+	}
+	payload, err := json.Marshal(map[string]any{
+		"FromEmailAddress": s.From,
+		"Destination":      map[string]any{"ToAddresses": []string{msg.To}},
+		"Content": map[string]any{"Simple": map[string]any{
+			"Subject": sesContent{Data: msg.Subject, Charset: "UTF-8"},
+			"Body":    body,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://email." + s.Region + ".amazonaws.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	s.sign(req, payload, time.Now().UTC())
+	return doMailRequest(httpClient(s.Client), req, "ses")
+}
+
+// Name returns "ses"
+func (s *SES) Name() string { return "ses" }
+
+// sign adds the Signature Version 4 headers for the ses service to req,
+// whose body is payload
+func (s *SES) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	const signedHeaders = "content-type;host;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"content-type:" + req.Header.Get("Content-Type") + "\n" +
+			"host:" + req.URL.Host + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/ses/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	for _, part := range []string{s.Region, "ses", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,176 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// SMTP sends messages through an SMTP server
+type SMTP struct {
+	// Addr is the server's host:port
+	Addr string
+	// Username and Password enable PLAIN authentication when set
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers msg in one SMTP session, upgrading to TLS when the server
+// offers STARTTLS
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return Rejected(fmt.Errorf("invalid recipient address: %w", err))
+	}
+	body, err := msg.mime(s.From, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	return s.session(c, host, from.Address, to.Address, body)
+}
+
+// session runs the commands of one delivery. 5xx replies are permanent
+// failures, and those to RCPT mean the recipient was rejected.
+func (s *SMTP) session(c *smtp.Client, host, from, to string, body []byte) error {
+	fail := func(err error) error {
+		var reply *textproto.Error
+		if errors.As(err, &reply) && reply.Code >= 500 {
+			return Permanent(fmt.Errorf("smtp: %w", err))
+		}
+		return fmt.Errorf("smtp: %w", err)
+	}
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fail(err)
+		}
+	}
+	if s.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return fail(err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return fail(err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		var reply *textproto.Error
+		if errors.As(err, &reply) && reply.Code >= 500 {
+			return Rejected(fmt.Errorf("smtp: %w", err))
+		}
+		return fail(err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fail(err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fail(err)
+	}
+	if err := w.Close(); err != nil {
+		return fail(err)
+	}
+	if err := c.Quit(); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// Name returns "smtp"
+func (s *SMTP) Name() string { return "smtp" }
+
+// mime encodes msg as a multipart/alternative message from from, with a
+// plain text part and, when there is one, an HTML part
+func (m Message) mime(from string, date time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	header := []string{
+		"From: " + from,
+		"To: " + m.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"Message-ID: " + messageID(from),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + parts.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", m.Text},
+		{"text/html", m.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID returns a unique Message-ID in the sender's domain
+func messageID(from string) string {
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if at := strings.LastIndex(addr.Address, "@"); at >= 0 {
+			domain = addr.Address[at+1:]
+		}
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
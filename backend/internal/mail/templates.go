@@ -0,0 +1,153 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Template names an email template. Each is rendered with the data type
+// named alongside it.
+type Template string
+
+// The templates shipped with the server
+const (
+	// TemplateVerification asks a new user to confirm their address, with
+	// VerificationData
+	TemplateVerification Template = "verification"
+	// TemplatePasswordReset links to the password reset form, with
+	// PasswordResetData
+	TemplatePasswordReset Template = "password_reset"
+	// TemplateActReceived tells a user someone recorded an act for them,
+	// with ActReceivedData
+	TemplateActReceived Template = "act_received"
+	// TemplateWeeklyDigest sums up a user's unread notifications of the
+	// week, with WeeklyDigestData
+	TemplateWeeklyDigest Template = "weekly_digest"
+	// TemplateOrgInvitation invites someone to join an organization, with
+	// OrgInvitationData
+	TemplateOrgInvitation Template = "org_invitation"
+)
+
+// Templates lists every template
+var Templates = []Template{TemplateVerification, TemplatePasswordReset, TemplateActReceived, TemplateWeeklyDigest, TemplateOrgInvitation}
+
+// VerificationData fills TemplateVerification
+type VerificationData struct {
+	Name      string
+	URL       string
+	ExpiresIn time.Duration
+}
+
+// PasswordResetData fills TemplatePasswordReset
+type PasswordResetData struct {
+	Name      string
+	URL       string
+	ExpiresIn time.Duration
+}
+
+// ActReceivedData fills TemplateActReceived. GiverName is empty for
+// anonymous acts.
+type ActReceivedData struct {
+	Name      string
+	GiverName string
+	ActTitle  string
+	URL       string
+}
+
+// WeeklyDigestData fills TemplateWeeklyDigest
+type WeeklyDigestData struct {
+	Name                 string
+	ActsReceived         int
+	ChainsGrown          int
+	TestimonialsApproved int
+	URL                  string
+}
+
+// OrgInvitationData fills TemplateOrgInvitation. Role is the role the
+// invitee joins with: owner, admin or member.
+type OrgInvitationData struct {
+	InviterName      string
+	OrganizationName string
+	Role             string
+	URL              string
+	ExpiresIn        time.Duration
+}
+
+//go:embed templates
+var templateFS embed.FS
+
+var funcs = map[string]any{
+	"duration": formatDuration,
+	"plural": func(n int, one, many string) string {
+		if n == 1 {
+			return "1 " + one
+		}
+		return fmt.Sprintf("%d %s", n, many)
+	},
+}
+
+// templateSet is a template parsed for its subject and text body and,
+// with HTML escaping, for its HTML body
+type templateSet struct {
+	text *template.Template
+	html *htmltemplate.Template
+}
+
+// parseTemplates parses every template, so a broken one fails at startup
+func parseTemplates() (map[Template]templateSet, error) {
+	sets := make(map[Template]templateSet, len(Templates))
+	for _, name := range Templates {
+		file := "templates/" + string(name) + ".tmpl"
+		text, err := template.New(string(name)).Funcs(funcs).ParseFS(templateFS, file)
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.New(string(name)).Funcs(funcs).ParseFS(templateFS, "templates/layout.html", file)
+		if err != nil {
+			return nil, err
+		}
+		sets[name] = templateSet{text: text, html: html}
+	}
+	return sets, nil
+}
+
+// render renders the message to to from the set with data
+func (s templateSet) render(to string, data any) (Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := s.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := s.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := s.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// formatDuration formats d in the largest whole unit, such as "24 hours"
+// or "30 minutes"
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", d/(24*time.Hour))
+	case d == time.Hour:
+		return "1 hour"
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	case d == time.Minute:
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", d/time.Minute)
+}
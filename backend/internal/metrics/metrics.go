@@ -0,0 +1,261 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "payforward"
+
+// Registry holds all application metrics along with the Go runtime and
+// process collectors
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Database circuit breaker metrics
+var (
+	DBCircuitState = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "circuit_state",
+		Help:      "State of the database circuit breaker (0 closed, 1 half-open, 2 open).",
+	})
+	DBCircuitTransitions = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "circuit_transitions_total",
+		Help:      "Database circuit breaker state transitions by target state.",
+	}, []string{"state"})
+	DBCircuitRejections = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "circuit_rejections_total",
+		Help:      "Database calls rejected because the circuit breaker was open.",
+	})
+)
+
+// Database query metrics
+var (
+	DBSlowQueries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "slow_queries_total",
+		Help:      "Database transactions slower than the slow query threshold, by operation and query name.",
+	}, []string{"operation", "query"})
+	DBQueryTimeouts = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_timeouts_total",
+		Help:      "Database transactions cancelled by the query timeout, by operation and query name.",
+	}, []string{"operation", "query"})
+)
+
+// Database connection pool metrics. The driver doesn't expose its pool, so
+// these are measured around each transaction.
+var (
+	DBPoolMaxSize = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "pool_max_size",
+		Help:      "Maximum number of connections in the database connection pool.",
+	})
+	DBPoolInUse = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "pool_in_use",
+		Help:      "Database transactions in flight, each holding a pooled connection.",
+	})
+	DBPoolAcquisitionSeconds = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "pool_acquisition_seconds",
+		Help:      "Time from opening a session until its transaction starts, by operation.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"operation"})
+	DBPoolAcquisitionFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "pool_acquisition_failures_total",
+		Help:      "Database transactions that failed before starting, such as connection timeouts, by operation.",
+	}, []string{"operation"})
+)
+
+// Background worker pool metrics
+var (
+	WorkerQueueDepth = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "workers",
+		Name:      "queue_depth",
+		Help:      "Background tasks waiting for a worker, by pool.",
+	}, []string{"pool"})
+	WorkerBusy = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "workers",
+		Name:      "busy",
+		Help:      "Workers running a background task, by pool.",
+	}, []string{"pool"})
+	WorkerTasks = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "workers",
+		Name:      "tasks_total",
+		Help:      "Background tasks by pool, task and outcome (succeeded, failed, panicked, rejected or dropped).",
+	}, []string{"pool", "task", "outcome"})
+	WorkerWaitSeconds = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "workers",
+		Name:      "wait_seconds",
+		Help:      "Time background tasks spent queued before a worker picked them up, by pool.",
+		Buckets:   []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 30, 60},
+	}, []string{"pool"})
+	WorkerTaskSeconds = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "workers",
+		Name:      "task_seconds",
+		Help:      "Time taken by background tasks, by pool and task.",
+		Buckets:   []float64{.005, .01, .05, .1, .5, 1, 5, 10, 30, 60, 120},
+	}, []string{"pool", "task"})
+)
+
+// Email metrics
+var (
+	MailMessages = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mail",
+		Name:      "messages_total",
+		Help:      "Emails by driver, template and outcome (sent, failed, rejected or suppressed).",
+	}, []string{"driver", "template", "outcome"})
+	MailAttempts = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mail",
+		Name:      "attempts_total",
+		Help:      "Delivery attempts by driver and result (ok or error), retries included.",
+	}, []string{"driver", "result"})
+)
+
+// Webhook metrics
+var (
+	WebhookDeliveries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "deliveries_total",
+		Help:      "Events delivered to a webhook, by event and outcome (delivered or failed).",
+	}, []string{"event", "outcome"})
+	WebhookAttempts = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "webhooks",
+		Name:      "attempts_total",
+		Help:      "Delivery attempts by event and result (ok or error), retries included.",
+	}, []string{"event", "result"})
+)
+
+// Live update metrics
+var LiveConnections = factory.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Subsystem: "live",
+	Name:      "connections",
+	Help:      "Open WebSocket connections receiving live updates.",
+})
+
+// Outbox metrics
+var (
+	OutboxPublished = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_published_total",
+		Help:      "Domain events published, by publisher and event type.",
+	}, []string{"publisher", "type"})
+	OutboxFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "publish_failures_total",
+		Help:      "Batches of domain events a publisher failed to publish.",
+	}, []string{"publisher"})
+)
+
+// Payment metrics
+var PaymentRequests = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "payments",
+	Name:      "requests_total",
+	Help:      "Requests to the payments provider, by provider, operation (hold, capture or cancel) and result (ok, declined or error).",
+}, []string{"provider", "operation", "result"})
+
+// Geocoding metrics
+var GeocodeLookups = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "geocode",
+	Name:      "lookups_total",
+	Help:      "Locations looked up, by geocoder and result (cached, ok, not_found or error).",
+}, []string{"provider", "result"})
+
+// Moderation metrics
+var (
+	ModerationScreenings = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "moderation",
+		Name:      "screenings_total",
+		Help:      "Content screened, by content type (act or testimonial) and verdict (allow, flag or block).",
+	}, []string{"content", "verdict"})
+	ModerationProviderRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "moderation",
+		Name:      "provider_requests_total",
+		Help:      "Requests to the moderation provider, by provider and result (ok or error).",
+	}, []string{"provider", "result"})
+)
+
+// Search metrics
+var (
+	SearchQueries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "search",
+		Name:      "queries_total",
+		Help:      "Searches run, by engine and result (ok or error).",
+	}, []string{"engine", "result"})
+	SearchIndexed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "search",
+		Name:      "indexed_documents_total",
+		Help:      "Documents written to an external search index, by engine and action (put or delete).",
+	}, []string{"engine", "action"})
+)
+
+// Job queue metrics
+var (
+	JobRuns = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "runs_total",
+		Help:      "Attempts at background jobs, by type and outcome (succeeded, retried or failed).",
+	}, []string{"type", "outcome"})
+	JobSeconds = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "run_seconds",
+		Help:      "Time taken by attempts at background jobs, by type.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 600},
+	}, []string{"type"})
+	JobsEnqueued = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "jobs",
+		Name:      "enqueued_total",
+		Help:      "Background jobs enqueued, by type, scheduled occurrences included.",
+	}, []string{"type"})
+)
+
+// Handler serves the registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
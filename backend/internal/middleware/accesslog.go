@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/logging"
+)
+
+// AccessLogFormat selects how LoggerWithConfig writes access log lines
+type AccessLogFormat string
+
+const (
+	// AccessLogCombined is the Apache/NGINX Combined Log Format
+	AccessLogCombined AccessLogFormat = "combined"
+	// AccessLogJSON writes one JSON object per request
+	AccessLogJSON AccessLogFormat = "json"
+)
+
+// ParseAccessLogFormat validates an access log format name
+func ParseAccessLogFormat(format string) (AccessLogFormat, error) {
+	switch f := AccessLogFormat(strings.ToLower(strings.TrimSpace(format))); f {
+	case "", AccessLogCombined:
+		return AccessLogCombined, nil
+	case AccessLogJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown access log format %q", format)
+	}
+}
+
+// LoggerConfig configures LoggerWithConfig
+type LoggerConfig struct {
+	// AccessLog, if set, receives one line per request in AccessLogFormat,
+	// in addition to the structured application log
+	AccessLog       io.Writer
+	AccessLogFormat AccessLogFormat
+}
+
+// accessLogEntry is the JSON access log line
+type accessLogEntry struct {
+	Time      string  `json:"time"`
+	RemoteIP  string  `json:"remote_ip"`
+	UserID    string  `json:"user_id,omitempty"`
+	Method    string  `json:"method"`
+	URI       string  `json:"uri"`
+	Protocol  string  `json:"protocol"`
+	Status    int     `json:"status"`
+	Bytes     int64   `json:"bytes"`
+	LatencyMS float64 `json:"latency_ms"`
+	Referer   string  `json:"referer,omitempty"`
+	UserAgent string  `json:"user_agent,omitempty"`
+	RequestID string  `json:"request_id,omitempty"`
+}
+
+// accessLogger serializes access log lines to a shared writer
+type accessLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format AccessLogFormat
+}
+
+func (l *accessLogger) log(r *http.Request, rw *accessLogWriter, start time.Time) {
+	entry := accessLogEntry{
+		Time:      start.Format(time.RFC3339Nano),
+		RemoteIP:  remoteHost(r),
+		Method:    r.Method,
+		URI:       r.URL.RequestURI(),
+		Protocol:  r.Proto,
+		Status:    rw.statusCode,
+		Bytes:     rw.bytes,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		Referer:   r.Referer(),
+		UserAgent: r.UserAgent(),
+		RequestID: rw.Header().Get("X-Request-ID"),
+	}
+	if userID, ok := logging.Lookup(r.Context(), "user_id"); ok {
+		entry.UserID = userID.String()
+	}
+
+	var line []byte
+	if l.format == AccessLogJSON {
+		line, _ = json.Marshal(entry)
+	} else {
+		line = []byte(combinedLine(entry, start))
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	l.w.Write(line)
+	l.mu.Unlock()
+}
+
+// combinedLine formats entry in the Combined Log Format:
+// host ident user [time] "request" status bytes "referer" "user-agent"
+func combinedLine(entry accessLogEntry, start time.Time) string {
+	bytes := "-"
+	if entry.Bytes > 0 {
+		bytes = strconv.FormatInt(entry.Bytes, 10)
+	}
+
+	return fmt.Sprintf(`%s - %s [%s] "%s %s %s" %d %s "%s" "%s"`,
+		orDash(entry.RemoteIP),
+		orDash(entry.UserID),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		entry.Method, escapeCombined(entry.URI), entry.Protocol,
+		entry.Status,
+		bytes,
+		orDash(escapeCombined(entry.Referer)),
+		orDash(escapeCombined(entry.UserAgent)),
+	)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// escapeCombined escapes quotes, backslashes and control characters so a
+// client can't break out of a quoted field
+func escapeCombined(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// remoteHost returns the client IP without its port
+func remoteHost(r *http.Request) string {
+	ip := clientIP(r)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		return host
+	}
+	return ip
+}
+
+// accessLogWriter captures the status code and response size
+type accessLogWriter struct {
+	responseWrapper
+	bytes int64
+}
+
+func (rw *accessLogWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += int64(n)
+	return n, err
+}
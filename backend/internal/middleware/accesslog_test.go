@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"payforwardnow/internal/logging"
+)
+
+func serveWithAccessLog(t *testing.T, format AccessLogFormat) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logging.AddAttrs(r.Context(), slog.String("user_id", "u1"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+
+	chained := Chain(handler, LoggerWithConfig(LoggerConfig{AccessLog: &buf, AccessLogFormat: format}), RequestID)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts?x=1", nil)
+	req.RemoteAddr = "192.0.2.10:43210"
+	req.Header.Set("User-Agent", `agent "quoted"`)
+	req.Header.Set("Referer", "https://payforward.app/")
+	req.Header.Set("X-Request-ID", "req-7")
+	chained.ServeHTTP(httptest.NewRecorder(), req)
+
+	return buf.String()
+}
+
+func TestLoggerWithConfig_CombinedAccessLog(t *testing.T) {
+	line := serveWithAccessLog(t, AccessLogCombined)
+
+	pattern := `^192\.0\.2\.10 - u1 \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /api/v1/acts\?x=1 HTTP/1\.1" 201 5 "https://payforward\.app/" "agent \\"quoted\\""\n$`
+	if !regexp.MustCompile(pattern).MatchString(line) {
+		t.Errorf("unexpected combined log line %q", line)
+	}
+}
+
+func TestLoggerWithConfig_JSONAccessLog(t *testing.T) {
+	line := serveWithAccessLog(t, AccessLogJSON)
+
+	var entry accessLogEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("expected a JSON line, got %q: %v", line, err)
+	}
+
+	if entry.RemoteIP != "192.0.2.10" || entry.UserID != "u1" || entry.URI != "/api/v1/acts?x=1" ||
+		entry.Status != http.StatusCreated || entry.Bytes != 5 || entry.RequestID != "req-7" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestParseAccessLogFormat(t *testing.T) {
+	if format, err := ParseAccessLogFormat("JSON"); err != nil || format != AccessLogJSON {
+		t.Errorf("expected json, got %q (%v)", format, err)
+	}
+	if _, err := ParseAccessLogFormat("common"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/workers"
+
+	"github.com/google/uuid"
+)
+
+// maxAuditBodySize bounds how much of a request or response body is read
+// to summarize changes and find created entity IDs
+const maxAuditBodySize = 64 << 10
+
+// AuditRecorder stores audit events
+type AuditRecorder interface {
+	RecordAudit(ctx context.Context, event models.AuditEvent) error
+}
+
+// Audit records every request other than GET, HEAD and OPTIONS as an
+// AuditEvent. Only the names of the fields set in the request body are
+// kept, never their values, so passwords and tokens don't end up in the
+// audit trail. Events are stored in the background once the response has
+// been written.
+//
+// Audit must wrap the ServeMux directly so the matched route pattern and
+// path values are available after the request is served.
+func Audit(recorder AuditRecorder) Middleware {
+	return NewAuditor(recorder).Middleware
+}
+
+// Auditor records audit events like Audit, and can wait for the events
+// still being stored when the server shuts down
+type Auditor struct {
+	recorder AuditRecorder
+	pool     *workers.Pool
+	pending  sync.WaitGroup
+}
+
+// NewAuditor creates an Auditor storing events with recorder
+func NewAuditor(recorder AuditRecorder) *Auditor {
+	return &Auditor{recorder: recorder}
+}
+
+// SetPool stores events on pool instead of a goroutine each. Events the
+// pool can't take, because its queue is full or it is stopping, are stored
+// before the request returns rather than lost.
+func (a *Auditor) SetPool(pool *workers.Pool) {
+	a.pool = pool
+}
+
+// Wait blocks until the events being stored are stored or ctx is done. It
+// must only be called once requests have stopped.
+func (a *Auditor) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		a.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Middleware records requests as described by Audit
+func (a *Auditor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		changes := auditChanges(r)
+
+		// Creates return the new entity's ID in the response body
+		aw := &auditWriter{
+			responseWrapper: responseWrapper{ResponseWriter: w, statusCode: http.StatusOK},
+			capture:         r.Method == http.MethodPost,
+		}
+		next.ServeHTTP(aw, r)
+
+		actorID := UserIDFromContext(r.Context())
+
+		entityID := r.PathValue("id")
+		if entityID == "" && aw.statusCode == http.StatusCreated {
+			entityID = createdEntityID(aw.body.Bytes())
+		}
+
+		event := models.AuditEvent{
+			ID:        uuid.New().String(),
+			ActorID:   actorID,
+			Method:    r.Method,
+			Route:     r.Pattern,
+			Path:      r.URL.Path,
+			EntityID:  entityID,
+			Status:    aw.statusCode,
+			Changes:   changes,
+			RequestID: RequestIDFromContext(r.Context()),
+			CreatedAt: time.Now().UTC(),
+		}
+		if event.Route == "" {
+			event.Route = r.Method + " " + r.URL.Path
+		}
+
+		a.store(r.Context(), event)
+	})
+}
+
+// store records event in the background
+func (a *Auditor) store(ctx context.Context, event models.AuditEvent) {
+	a.pending.Add(1)
+	record := func(ctx context.Context) error {
+		defer a.pending.Done()
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		if err := a.recorder.RecordAudit(ctx, event); err != nil {
+			slog.ErrorContext(ctx, "failed to record audit event", "error", err, "route", event.Route)
+		}
+		return nil
+	}
+
+	if a.pool == nil {
+		go record(context.WithoutCancel(ctx))
+		return
+	}
+	if err := a.pool.Submit(ctx, "audit_event", record); err != nil {
+		record(context.WithoutCancel(ctx))
+	}
+}
+
+// auditChanges returns the sorted top-level field names of a JSON request
+// body, restoring the body for the handler
+func auditChanges(r *http.Request) []string {
+	changes := []string{}
+	if r.Body == nil {
+		return changes
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodySize))
+	rest := r.Body
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), rest), rest}
+	if err != nil {
+		return changes
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return changes
+	}
+	for field := range fields {
+		changes = append(changes, field)
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// createdEntityID extracts data.id from an API response
+func createdEntityID(body []byte) string {
+	var response struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return ""
+	}
+	return response.Data.ID
+}
+
+// auditWriter records the status code and, when capture is set, the start
+// of the response body
+type auditWriter struct {
+	responseWrapper
+	capture bool
+	body    bytes.Buffer
+}
+
+func (aw *auditWriter) Write(p []byte) (int, error) {
+	if aw.capture && aw.body.Len() < maxAuditBodySize {
+		aw.body.Write(p[:min(len(p), maxAuditBodySize-aw.body.Len())])
+	}
+	return aw.ResponseWriter.Write(p)
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/workers"
+)
+
+type auditRecorderFunc func(ctx context.Context, event models.AuditEvent) error
+
+func (f auditRecorderFunc) RecordAudit(ctx context.Context, event models.AuditEvent) error {
+	return f(ctx, event)
+}
+
+func recordAudit(t *testing.T, mux *http.ServeMux, req *http.Request) (models.AuditEvent, bool) {
+	t.Helper()
+
+	events := make(chan models.AuditEvent, 1)
+	recorder := auditRecorderFunc(func(ctx context.Context, event models.AuditEvent) error {
+		events <- event
+		return nil
+	})
+
+	Audit(recorder)(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	select {
+	case event := <-events:
+		return event, true
+	case <-time.After(time.Second):
+		return models.AuditEvent{}, false
+	}
+}
+
+func TestAudit_RecordsMutation(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /api/v1/acts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !bytes.Contains(body, []byte("New title")) {
+			t.Errorf("expected handler to receive the full body, got %q", body)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/acts/act-1", bytes.NewBufferString(`{"title": "New title", "status": "completed"}`))
+	req = req.WithContext(context.WithValue(req.Context(), UserIDKey, "user-1"))
+
+	event, ok := recordAudit(t, mux, req)
+	if !ok {
+		t.Fatal("expected an audit event")
+	}
+
+	if event.ActorID != "user-1" || event.Route != "PUT /api/v1/acts/{id}" || event.EntityID != "act-1" || event.Status != http.StatusOK {
+		t.Errorf("unexpected event %+v", event)
+	}
+	if !reflect.DeepEqual(event.Changes, []string{"status", "title"}) {
+		t.Errorf("expected changed field names only, got %v", event.Changes)
+	}
+}
+
+func TestAudit_CreatedEntityID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/acts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"success":true,"data":{"id":"act-9","title":"x"}}`))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", bytes.NewBufferString(`{"title": "x"}`))
+
+	event, ok := recordAudit(t, mux, req)
+	if !ok {
+		t.Fatal("expected an audit event")
+	}
+	if event.EntityID != "act-9" {
+		t.Errorf("expected entity ID from the response, got %q", event.EntityID)
+	}
+}
+
+func TestAudit_SkipsReads(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/acts", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil)
+
+	if _, ok := recordAudit(t, mux, req); ok {
+		t.Error("expected GET requests not to be audited")
+	}
+}
+
+func TestAuditor_WaitsForPendingEvents(t *testing.T) {
+	release := make(chan struct{})
+	stored := make(chan struct{})
+	auditor := NewAuditor(auditRecorderFunc(func(ctx context.Context, event models.AuditEvent) error {
+		<-release
+		close(stored)
+		return nil
+	}))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/v1/acts/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	auditor.Middleware(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/acts/act-1", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := auditor.Wait(ctx); err == nil {
+		t.Fatal("expected Wait to time out while the event is being stored")
+	}
+
+	close(release)
+	if err := auditor.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	select {
+	case <-stored:
+	default:
+		t.Error("expected Wait to return after the event was stored")
+	}
+}
+
+func TestAuditor_StoresOnPool(t *testing.T) {
+	events := make(chan models.AuditEvent, 2)
+	auditor := NewAuditor(auditRecorderFunc(func(ctx context.Context, event models.AuditEvent) error {
+		events <- event
+		return nil
+	}))
+	pool := workers.New("audit-test", 1, 1)
+	auditor.SetPool(pool)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /api/v1/acts/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	auditor.Middleware(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/acts/act-1", nil))
+
+	// Events the stopped pool rejects are stored with the request
+	pool.Stop(context.Background())
+	auditor.Middleware(mux).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/v1/acts/act-2", nil))
+
+	if err := auditor.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("expected both events to be stored, got %d", len(events))
+	}
+}
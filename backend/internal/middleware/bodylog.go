@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"payforwardnow/internal/logging"
+)
+
+// defaultMaxLoggedBody bounds how much of each body LogBodies reads
+const defaultMaxLoggedBody = 16 << 10
+
+// BodyLogConfig configures request and response body logging
+type BodyLogConfig struct {
+	// Redactor hides sensitive headers and fields. Defaults to a Redactor
+	// for logging.DefaultRedactFields.
+	Redactor *logging.Redactor
+	// MaxBytes bounds how much of each body is logged. Longer bodies are
+	// reported by size only, since a truncated body can't be redacted
+	// reliably. Defaults to 16 KiB.
+	MaxBytes int
+}
+
+// LogBodies logs the headers and bodies of every request and response,
+// with sensitive values redacted, to help debug client integrations. It is
+// meant to be enabled temporarily and must run inside Logger so the lines
+// carry the request scope.
+func LogBodies(cfg BodyLogConfig) Middleware {
+	if cfg.Redactor == nil {
+		cfg.Redactor = logging.NewRedactor()
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultMaxLoggedBody
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var requestBody []byte
+			if r.Body != nil {
+				// Read one byte past the limit to tell whether the body was cut
+				body, _ := io.ReadAll(io.LimitReader(r.Body, int64(cfg.MaxBytes)+1))
+				rest := r.Body
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(body), rest), rest}
+				requestBody = body
+			}
+
+			bw := &bodyLogWriter{
+				responseWrapper: responseWrapper{ResponseWriter: w, statusCode: http.StatusOK},
+				limit:           cfg.MaxBytes + 1,
+			}
+			next.ServeHTTP(bw, r)
+
+			slog.InfoContext(r.Context(), "http body",
+				slog.Any("request_headers", cfg.Redactor.Headers(r.Header)),
+				slog.String("request_body", loggedBody(cfg, requestBody)),
+				slog.Any("response_headers", cfg.Redactor.Headers(bw.Header())),
+				slog.String("response_body", loggedBody(cfg, bw.body.Bytes())),
+			)
+		})
+	}
+}
+
+// loggedBody redacts body, or describes it if it exceeded the limit
+func loggedBody(cfg BodyLogConfig, body []byte) string {
+	if len(body) > cfg.MaxBytes {
+		return "[body longer than limit, omitted]"
+	}
+	return cfg.Redactor.JSON(body)
+}
+
+// bodyLogWriter keeps the first limit bytes of the response body
+type bodyLogWriter struct {
+	responseWrapper
+	limit int
+	body  bytes.Buffer
+}
+
+func (bw *bodyLogWriter) Write(p []byte) (int, error) {
+	if bw.body.Len() < bw.limit {
+		bw.body.Write(p[:min(len(p), bw.limit-bw.body.Len())])
+	}
+	return bw.ResponseWriter.Write(p)
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/logging"
+)
+
+func TestLogBodies_Redacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Config{Format: "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "hunter2") {
+			t.Errorf("expected handler to receive the original body, got %q", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"accessToken":"jwt","id":"u1"}}`))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"a@b.c","password":"hunter2"}`))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	w := httptest.NewRecorder()
+
+	LogBodies(BodyLogConfig{})(handler).ServeHTTP(w, req)
+
+	if w.Body.String() != `{"data":{"accessToken":"jwt","id":"u1"}}` {
+		t.Errorf("expected response to pass through, got %q", w.Body.String())
+	}
+
+	line := buf.String()
+	for _, secret := range []string{"hunter2", "secret-token", `"jwt"`} {
+		if strings.Contains(line, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, line)
+		}
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q", line)
+	}
+	if !strings.Contains(entry["request_body"].(string), "a@b.c") || !strings.Contains(entry["response_body"].(string), "u1") {
+		t.Errorf("expected non-sensitive fields to be logged, got %v", entry)
+	}
+}
+
+func TestLogBodies_OmitsLongBodies(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := logging.New(&buf, logging.Config{Format: "json"})
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+
+	body := `{"password":"` + strings.Repeat("x", 100) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	LogBodies(BodyLogConfig{MaxBytes: 32})(handler).ServeHTTP(w, req)
+
+	if w.Body.String() != body {
+		t.Errorf("expected full body to reach the handler")
+	}
+	if strings.Contains(buf.String(), "xxxx") {
+		t.Errorf("expected long bodies to be omitted, got %s", buf.String())
+	}
+}
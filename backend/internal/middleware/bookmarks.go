@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"payforwardnow/internal/database"
+)
+
+// BookmarkHeader carries Neo4j causal consistency bookmarks between clients
+// and the API
+const BookmarkHeader = "X-Neo4j-Bookmark"
+
+// Limits on client-supplied bookmarks, which are opaque tokens of a few
+// dozen bytes each
+const (
+	maxBookmarks      = 16
+	maxBookmarkLength = 1024
+)
+
+// BookmarkConfig configures Bookmarks
+type BookmarkConfig struct {
+	// Expose returns the bookmarks of writes in BookmarkHeader and accepts
+	// them back on later requests, so a client reading right after its own
+	// write sees it even when the requests reach different cluster members
+	Expose bool
+}
+
+// Bookmarks gives each request its own database.Bookmarks, so every
+// transaction in the request sees the writes made earlier in it on a Neo4j
+// cluster.
+func Bookmarks(cfg BookmarkConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var initial []string
+			if cfg.Expose {
+				initial = parseBookmarks(r.Header.Values(BookmarkHeader))
+			}
+
+			ctx, bookmarks := database.WithBookmarks(r.Context(), initial...)
+			r = r.WithContext(ctx)
+
+			if !cfg.Expose || !isWrite(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(&bookmarkWriter{ResponseWriter: w, bookmarks: bookmarks}, r)
+		})
+	}
+}
+
+// parseBookmarks reads comma-separated bookmarks from header values,
+// ignoring any beyond the limits
+func parseBookmarks(values []string) []string {
+	var bookmarks []string
+	for _, value := range values {
+		for _, b := range strings.Split(value, ",") {
+			b = strings.TrimSpace(b)
+			if b == "" || len(b) > maxBookmarkLength {
+				continue
+			}
+			if len(bookmarks) == maxBookmarks {
+				return bookmarks
+			}
+			bookmarks = append(bookmarks, b)
+		}
+	}
+	return bookmarks
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// bookmarkWriter adds the request's latest bookmarks to the response headers
+// just before they are sent
+type bookmarkWriter struct {
+	http.ResponseWriter
+	bookmarks   *database.Bookmarks
+	wroteHeader bool
+}
+
+func (bw *bookmarkWriter) WriteHeader(code int) {
+	if !bw.wroteHeader {
+		bw.wroteHeader = true
+		if values := bw.bookmarks.Values(); len(values) > 0 {
+			bw.Header().Set(BookmarkHeader, strings.Join(values, ", "))
+		}
+	}
+	bw.ResponseWriter.WriteHeader(code)
+}
+
+func (bw *bookmarkWriter) Write(b []byte) (int, error) {
+	if !bw.wroteHeader {
+		bw.WriteHeader(http.StatusOK)
+	}
+	return bw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying ResponseWriter for http.ResponseController
+func (bw *bookmarkWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
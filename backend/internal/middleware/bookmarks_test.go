@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/database"
+)
+
+func TestBookmarks(t *testing.T) {
+	tests := []struct {
+		name         string
+		expose       bool
+		method       string
+		header       string
+		expectValues []string
+		expectHeader string
+	}{
+		{"not exposed", false, http.MethodPost, "bm:1", nil, ""},
+		{"write echoes bookmarks", true, http.MethodPost, "bm:1, bm:2", []string{"bm:1", "bm:2"}, "bm:1, bm:2"},
+		{"read accepts bookmarks", true, http.MethodGet, "bm:1", []string{"bm:1"}, ""},
+		{"no bookmarks", true, http.MethodPut, "", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values []string
+			handler := Bookmarks(BookmarkConfig{Expose: tt.expose})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b := database.BookmarksFromContext(r.Context())
+				if b == nil {
+					t.Fatal("expected bookmarks on the request context")
+				}
+				values = b.Values()
+				w.Write([]byte("ok"))
+			}))
+
+			req := httptest.NewRequest(tt.method, "/api/v1/acts", nil)
+			if tt.header != "" {
+				req.Header.Set(BookmarkHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if len(values) != 0 || len(tt.expectValues) != 0 {
+				if !reflect.DeepEqual(values, tt.expectValues) {
+					t.Errorf("expected bookmarks %v, got %v", tt.expectValues, values)
+				}
+			}
+			if got := w.Header().Get(BookmarkHeader); got != tt.expectHeader {
+				t.Errorf("expected header %q, got %q", tt.expectHeader, got)
+			}
+		})
+	}
+}
+
+func TestParseBookmarks_Limits(t *testing.T) {
+	long := strings.Repeat("x", maxBookmarkLength+1)
+	many := strings.Repeat("bm,", maxBookmarks+5)
+
+	got := parseBookmarks([]string{long + ", ok", many})
+	if len(got) != maxBookmarks || got[0] != "ok" {
+		t.Errorf("expected %d bookmarks starting with ok, got %d: %v", maxBookmarks, len(got), got[:1])
+	}
+}
@@ -0,0 +1,233 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBotGuardBodySize bounds how much of a request body is inspected
+const maxBotGuardBodySize = 64 << 10
+
+// BotRequest is the request under inspection by a BotRule
+type BotRequest struct {
+	*http.Request
+	// Fields holds the top-level fields of a JSON body, or nil
+	Fields map[string]json.RawMessage
+	// Received is when the guard saw the request
+	Received time.Time
+}
+
+// Field returns the string value of a top-level body field, or "" if it is
+// missing or not a string
+func (r *BotRequest) Field(name string) string {
+	var value string
+	if raw, ok := r.Fields[name]; ok {
+		json.Unmarshal(raw, &value)
+	}
+	return value
+}
+
+// BotRule scores how likely a request is to come from a bot. A score of 0
+// means no suspicion; scores from all rules are added up. The reason is
+// logged when the score is non-zero.
+type BotRule interface {
+	Score(r *BotRequest) (score int, reason string)
+}
+
+// BotRuleFunc adapts a function to a BotRule
+type BotRuleFunc func(r *BotRequest) (int, string)
+
+// Score calls f
+func (f BotRuleFunc) Score(r *BotRequest) (int, string) {
+	return f(r)
+}
+
+// HoneypotRule flags bodies that fill in field, a form input hidden from
+// humans that naive bots fill in anyway
+func HoneypotRule(field string, score int) BotRule {
+	return BotRuleFunc(func(r *BotRequest) (int, string) {
+		if r.Field(field) != "" {
+			return score, "honeypot field filled"
+		}
+		return 0, ""
+	})
+}
+
+// TimingRule flags forms submitted faster than a human could fill them in.
+// field holds the Unix time in milliseconds at which the form was rendered,
+// set by the frontend. Missing timestamps are not penalized, so API clients
+// that never render a form are unaffected; timestamps in the future are.
+func TimingRule(field string, minDuration time.Duration, score int) BotRule {
+	return BotRuleFunc(func(r *BotRequest) (int, string) {
+		raw, ok := r.Fields[field]
+		if !ok {
+			return 0, ""
+		}
+
+		var millis int64
+		if err := json.Unmarshal(raw, &millis); err != nil {
+			var s string
+			if json.Unmarshal(raw, &s) != nil {
+				return score, "invalid form timestamp"
+			}
+			if millis, err = strconv.ParseInt(s, 10, 64); err != nil {
+				return score, "invalid form timestamp"
+			}
+		}
+
+		elapsed := r.Received.Sub(time.UnixMilli(millis))
+		if elapsed < minDuration {
+			return score, "form submitted too quickly"
+		}
+		return 0, ""
+	})
+}
+
+// DefaultBadUserAgents are fragments of user agents sent by scripts and
+// headless browsers rather than real users
+var DefaultBadUserAgents = []string{
+	"curl/", "wget/", "python-requests", "python-urllib", "go-http-client",
+	"scrapy", "headlesschrome", "phantomjs", "httpclient", "libwww-perl",
+}
+
+// UserAgentRule flags requests without a user agent or whose user agent
+// contains one of fragments, compared case-insensitively
+func UserAgentRule(fragments []string, score int) BotRule {
+	lower := make([]string, len(fragments))
+	for i, fragment := range fragments {
+		lower[i] = strings.ToLower(fragment)
+	}
+
+	return BotRuleFunc(func(r *BotRequest) (int, string) {
+		ua := strings.ToLower(r.UserAgent())
+		if ua == "" {
+			return score, "missing user agent"
+		}
+		for _, fragment := range lower {
+			if strings.Contains(ua, fragment) {
+				return score, "automated user agent"
+			}
+		}
+		return 0, ""
+	})
+}
+
+// ChallengeVerifier checks the answer to a challenge such as a CAPTCHA
+type ChallengeVerifier interface {
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// BotGuardConfig configures BotGuard
+type BotGuardConfig struct {
+	// Routes lists the guarded routes as "METHOD /path"
+	Routes []string
+	// Rules score each guarded request
+	Rules []BotRule
+	// RejectScore is the total score at which requests are rejected
+	RejectScore int
+	// ChallengeScore is the total score at which requests must carry a
+	// verified X-Challenge-Token. It is ignored without a Verifier.
+	ChallengeScore int
+	// Verifier checks challenge tokens
+	Verifier ChallengeVerifier
+}
+
+// DefaultBotRules returns the built-in heuristics: a "website" honeypot
+// field, a "formRenderedAt" timestamp that must be at least two seconds old
+// and automated user agents
+func DefaultBotRules() []BotRule {
+	return []BotRule{
+		HoneypotRule("website", 100),
+		TimingRule("formRenderedAt", 2*time.Second, 60),
+		UserAgentRule(DefaultBadUserAgents, 40),
+	}
+}
+
+// BotGuard scores requests to the configured routes, such as registration
+// and act creation, and rejects or challenges suspicious ones before they
+// reach the handlers. Rejected requests get 403 BOT_DETECTED; challenged
+// requests without a valid token get 403 CHALLENGE_REQUIRED so the client
+// can present a CAPTCHA and retry with its token in X-Challenge-Token.
+func BotGuard(cfg BotGuardConfig) Middleware {
+	routes := make(map[string]bool, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes[route] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !routes[r.Method+" "+r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			req := &BotRequest{Request: r, Fields: botGuardFields(r), Received: time.Now()}
+
+			score := 0
+			var reasons []string
+			for _, rule := range cfg.Rules {
+				if s, reason := rule.Score(req); s > 0 {
+					score += s
+					reasons = append(reasons, reason)
+				}
+			}
+
+			switch {
+			case cfg.RejectScore > 0 && score >= cfg.RejectScore:
+				slog.WarnContext(r.Context(), "rejected suspected bot", "score", score, "reasons", reasons, "client", clientIP(r))
+				respondAPIError(w, http.StatusForbidden, "BOT_DETECTED", "Request rejected")
+				return
+			case cfg.Verifier != nil && cfg.ChallengeScore > 0 && score >= cfg.ChallengeScore:
+				token := r.Header.Get("X-Challenge-Token")
+				if token == "" {
+					respondAPIError(w, http.StatusForbidden, "CHALLENGE_REQUIRED", "Please complete the challenge and try again")
+					return
+				}
+				ok, err := cfg.Verifier.Verify(r.Context(), token, clientIP(r))
+				if err != nil {
+					slog.ErrorContext(r.Context(), "failed to verify challenge", "error", err)
+				}
+				if !ok {
+					slog.WarnContext(r.Context(), "failed bot challenge", "score", score, "reasons", reasons, "client", clientIP(r))
+					respondAPIError(w, http.StatusForbidden, "CHALLENGE_REQUIRED", "Please complete the challenge and try again")
+					return
+				}
+			case score > 0:
+				slog.InfoContext(r.Context(), "suspicious request allowed", "score", score, "reasons", reasons)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// botGuardFields returns the top-level fields of a JSON request body,
+// restoring the body for the handler
+func botGuardFields(r *http.Request) map[string]json.RawMessage {
+	if r.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBotGuardBodySize))
+	rest := r.Body
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), rest), rest}
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return nil
+	}
+	return fields
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type stubVerifier struct {
+	valid string
+}
+
+func (v stubVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	return token == v.valid, nil
+}
+
+func newTestBotGuard(verifier ChallengeVerifier) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+
+	return BotGuard(BotGuardConfig{
+		Routes:         []string{"POST /api/v1/acts"},
+		Rules:          DefaultBotRules(),
+		RejectScore:    100,
+		ChallengeScore: 60,
+		Verifier:       verifier,
+	})(handler)
+}
+
+func botRequest(path, body, userAgent string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("User-Agent", userAgent)
+	return req
+}
+
+const browserUA = "Mozilla/5.0 (X11; Linux x86_64) Firefox/130.0"
+
+func TestBotGuard(t *testing.T) {
+	oldForm := time.Now().Add(-time.Minute).UnixMilli()
+	freshForm := time.Now().UnixMilli()
+
+	tests := []struct {
+		name      string
+		path      string
+		body      string
+		userAgent string
+		expected  int
+	}{
+		{"human", "/api/v1/acts", fmt.Sprintf(`{"title":"t","formRenderedAt":%d}`, oldForm), browserUA, http.StatusCreated},
+		{"api client without form fields", "/api/v1/acts", `{"title":"t"}`, browserUA, http.StatusCreated},
+		{"honeypot", "/api/v1/acts", `{"title":"t","website":"http://spam"}`, browserUA, http.StatusForbidden},
+		{"fast script", "/api/v1/acts", fmt.Sprintf(`{"title":"t","formRenderedAt":%d}`, freshForm), "curl/8.0", http.StatusForbidden},
+		{"unguarded route", "/api/v1/testimonials", `{"website":"http://spam"}`, "", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newTestBotGuard(nil).ServeHTTP(w, botRequest(tt.path, tt.body, tt.userAgent))
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestBotGuard_RestoresBody(t *testing.T) {
+	body := `{"title":"t"}`
+	w := httptest.NewRecorder()
+	newTestBotGuard(nil).ServeHTTP(w, botRequest("/api/v1/acts", body, browserUA))
+
+	if w.Body.String() != body {
+		t.Errorf("expected handler to receive %q, got %q", body, w.Body.String())
+	}
+}
+
+func TestBotGuard_Challenge(t *testing.T) {
+	guard := newTestBotGuard(stubVerifier{valid: "solved"})
+	body := fmt.Sprintf(`{"title":"t","formRenderedAt":%d}`, time.Now().UnixMilli())
+
+	w := httptest.NewRecorder()
+	guard.ServeHTTP(w, botRequest("/api/v1/acts", body, browserUA))
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "CHALLENGE_REQUIRED") {
+		t.Fatalf("expected a challenge, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req := botRequest("/api/v1/acts", body, browserUA)
+	req.Header.Set("X-Challenge-Token", "wrong")
+	w = httptest.NewRecorder()
+	guard.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected an invalid token to be rejected, got %d", w.Code)
+	}
+
+	req = botRequest("/api/v1/acts", body, browserUA)
+	req.Header.Set("X-Challenge-Token", "solved")
+	w = httptest.NewRecorder()
+	guard.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected a solved challenge to pass, got %d", w.Code)
+	}
+}
+
+func TestSiteVerifier(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("secret") != "s3cret" || r.Form.Get("remoteip") != "10.0.0.1" {
+			t.Errorf("unexpected form %v", r.Form)
+		}
+		fmt.Fprintf(w, `{"success":%t}`, r.Form.Get("response") == "good")
+	}))
+	defer server.Close()
+
+	verifier := &SiteVerifier{URL: server.URL, Secret: "s3cret"}
+
+	if ok, err := verifier.Verify(context.Background(), "good", "10.0.0.1:5555"); err != nil || !ok {
+		t.Errorf("expected token to verify, got %v, %v", ok, err)
+	}
+	if ok, _ := verifier.Verify(context.Background(), "bad", "10.0.0.1"); ok {
+		t.Error("expected token to be rejected")
+	}
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/database"
+)
+
+// responseCacheKeyPrefix namespaces cached responses within the store
+const responseCacheKeyPrefix = "response:"
+
+// ResponseCacheConfig configures response caching
+type ResponseCacheConfig struct {
+	Store cache.Store
+	// Routes lists the cacheable GET routes. The first matching route wins.
+	Routes []CacheRoute
+	// Invalidations clear cached routes after successful writes
+	Invalidations []CacheInvalidation
+}
+
+// CacheRoute caches anonymous GET responses under PathPrefix for TTL
+type CacheRoute struct {
+	PathPrefix string
+	TTL        time.Duration
+}
+
+// CacheInvalidation clears the cached responses under each of the
+// Invalidates prefixes whenever a write under WritePrefix succeeds
+type CacheInvalidation struct {
+	WritePrefix string
+	Invalidates []string
+}
+
+// cachedResponse is the stored form of a response
+type cachedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// ResponseCache caches successful anonymous GET responses for configured
+// routes and invalidates them when related data changes
+type ResponseCache struct {
+	config ResponseCacheConfig
+}
+
+// NewResponseCache creates a response cache
+func NewResponseCache(cfg ResponseCacheConfig) *ResponseCache {
+	return &ResponseCache{config: cfg}
+}
+
+// Invalidate removes the cached responses for routes under each prefix.
+// Handlers and background jobs that change data outside a request can call
+// it directly.
+func (rc *ResponseCache) Invalidate(ctx context.Context, prefixes ...string) {
+	for _, prefix := range prefixes {
+		if err := rc.config.Store.DeletePrefix(ctx, responseCacheKeyPrefix+prefix); err != nil {
+			slog.ErrorContext(ctx, "failed to invalidate response cache", "prefix", prefix, "error", err)
+		}
+	}
+}
+
+// Middleware serves cached responses and stores cacheable ones. It must run
+// after authentication so responses for signed-in users are never shared.
+func (rc *ResponseCache) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			wrapped := &responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+			if wrapped.statusCode < 300 {
+				rc.invalidateFor(r)
+			}
+			return
+		}
+
+		route, ok := rc.route(r)
+		if !ok || !isAnonymous(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		key := responseCacheKey(r)
+
+		if data, found, err := rc.config.Store.Get(ctx, key); err != nil {
+			slog.WarnContext(ctx, "response cache read failed", "error", err)
+		} else if found {
+			var cached cachedResponse
+			if json.Unmarshal(data, &cached) == nil {
+				for name, values := range cached.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("X-Cache", "HIT")
+				w.WriteHeader(cached.Status)
+				w.Write(cached.Body)
+				return
+			}
+		}
+
+		// Only the headers the handler adds are stored, so ones set per
+		// request by earlier middleware, like X-Request-ID or the CORS
+		// headers for the caller's origin, are never replayed to others
+		before := w.Header().Clone()
+		w.Header().Set("X-Cache", "MISS")
+		cw := &cachingWriter{responseWrapper: responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}}
+		next.ServeHTTP(cw, r)
+
+		if cw.statusCode != http.StatusOK {
+			return
+		}
+
+		header := addedHeaders(before, w.Header())
+		header.Del("X-Cache")
+		data, err := json.Marshal(cachedResponse{Status: cw.statusCode, Header: header, Body: cw.body.Bytes()})
+		if err != nil {
+			return
+		}
+		if err := rc.config.Store.Set(ctx, key, data, route.TTL); err != nil {
+			slog.WarnContext(ctx, "response cache write failed", "error", err)
+		}
+	})
+}
+
+func (rc *ResponseCache) route(r *http.Request) (CacheRoute, bool) {
+	for _, route := range rc.config.Routes {
+		if strings.HasPrefix(r.URL.Path, route.PathPrefix) {
+			return route, true
+		}
+	}
+	return CacheRoute{}, false
+}
+
+func (rc *ResponseCache) invalidateFor(r *http.Request) {
+	for _, inv := range rc.config.Invalidations {
+		if strings.HasPrefix(r.URL.Path, inv.WritePrefix) {
+			rc.Invalidate(r.Context(), inv.Invalidates...)
+		}
+	}
+}
+
+// isAnonymous reports whether r carries no credentials or user identity
+func isAnonymous(r *http.Request) bool {
+	return UserIDFromContext(r.Context()) == "" &&
+		r.Header.Get("Authorization") == ""
+}
+
+// responseCacheKey identifies a response by path, query, the headers used
+// for content and language negotiation, and the tenant database
+func responseCacheKey(r *http.Request) string {
+	return responseCacheKeyPrefix + r.URL.Path + "?" + r.URL.RawQuery +
+		"|" + r.Header.Get("Accept") + "|" + r.Header.Get("Accept-Language") +
+		"|" + database.DatabaseFromContext(r.Context())
+}
+
+// cachingWriter keeps a copy of the response body
+type cachingWriter struct {
+	responseWrapper
+	body bytes.Buffer
+}
+
+func (cw *cachingWriter) Write(p []byte) (int, error) {
+	cw.body.Write(p)
+	return cw.ResponseWriter.Write(p)
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/cache"
+)
+
+func TestResponseCache(t *testing.T) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			calls++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"success":true}`))
+	})
+
+	rc := NewResponseCache(ResponseCacheConfig{
+		Store:  cache.NewLRU(10),
+		Routes: []CacheRoute{{PathPrefix: "/api/v1/stats/global", TTL: time.Minute}},
+		Invalidations: []CacheInvalidation{
+			{WritePrefix: "/api/v1/acts", Invalidates: []string{"/api/v1/stats/"}},
+		},
+	})
+	middleware := rc.Middleware(handler)
+
+	get := func(configure func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/global", nil)
+		if configure != nil {
+			configure(req)
+		}
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get(nil); w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected first request to miss, got %q", w.Header().Get("X-Cache"))
+	}
+	w := get(nil)
+	if w.Header().Get("X-Cache") != "HIT" || w.Body.String() != `{"success":true}` || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected cached response, got %q %q %v", w.Header().Get("X-Cache"), w.Body.String(), w.Header())
+	}
+	if calls != 1 {
+		t.Errorf("expected handler to run once, ran %d times", calls)
+	}
+
+	// Authenticated requests bypass the cache
+	get(func(r *http.Request) { r.Header.Set("Authorization", "Bearer token") })
+	get(func(r *http.Request) { *r = *r.WithContext(context.WithValue(r.Context(), UserIDKey, "u1")) })
+	if calls != 3 {
+		t.Errorf("expected authenticated requests to reach the handler, got %d calls", calls)
+	}
+
+	// A successful write invalidates related routes
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", nil)
+	middleware.ServeHTTP(httptest.NewRecorder(), req)
+
+	if w := get(nil); w.Header().Get("X-Cache") != "MISS" {
+		t.Errorf("expected cache to be invalidated after a write, got %q", w.Header().Get("X-Cache"))
+	}
+}
+
+func TestResponseCache_StoresOnlyHandlerHeaders(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"success":true}`))
+	})
+	rc := NewResponseCache(ResponseCacheConfig{
+		Store:  cache.NewLRU(10),
+		Routes: []CacheRoute{{PathPrefix: "/api/v1/stats/global", TTL: time.Minute}},
+	})
+	// Earlier middleware sets headers that belong to each request
+	middleware := func(origin string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			rc.Middleware(handler).ServeHTTP(w, r)
+		})
+	}
+
+	middleware("https://a.example").ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/stats/global", nil))
+	w := httptest.NewRecorder()
+	middleware("https://b.example").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/stats/global", nil))
+
+	if w.Header().Get("X-Cache") != "HIT" || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a cached response with the handler's headers, got %v", w.Header())
+	}
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "https://b.example" {
+		t.Errorf("expected the second caller's origin, got %q", origin)
+	}
+}
+
+func TestResponseCache_SkipsErrorsAndUncachedRoutes(t *testing.T) {
+	calls := 0
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	rc := NewResponseCache(ResponseCacheConfig{
+		Store:  cache.NewLRU(10),
+		Routes: []CacheRoute{{PathPrefix: "/api/v1/stats/global", TTL: time.Minute}},
+	})
+	middleware := rc.Middleware(handler)
+
+	for i := 0; i < 2; i++ {
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/stats/global", nil))
+		middleware.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/1", nil))
+	}
+
+	if calls != 4 {
+		t.Errorf("expected errors and uncached routes to always reach the handler, got %d calls", calls)
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// regexOriginPrefix marks an allowed origin pattern as a regular expression
+const regexOriginPrefix = "regex:"
+
+// OriginMatcher decides whether a request Origin is allowed. Patterns are
+// one of:
+//
+//   - "*", allowing any origin
+//   - an exact origin such as "https://payforward.app"
+//   - a wildcard subdomain such as "https://*.payforward.app", where "*"
+//     stands for a single DNS label, so it matches
+//     "https://pr-123.payforward.app" but not "https://payforward.app" or
+//     "https://a.b.payforward.app"
+//   - a regular expression prefixed with "regex:", such as
+//     `regex:^https://pr-\d+\.payforward\.app$`, matched against the
+//     whole origin
+//
+// Exact and wildcard patterns are compared case-insensitively. The patterns
+// can be replaced with Update while requests are matched.
+type OriginMatcher struct {
+	mu       sync.RWMutex
+	any      bool
+	exact    map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// NewOriginMatcher compiles the allowed origin patterns
+func NewOriginMatcher(patterns []string) (*OriginMatcher, error) {
+	m := &OriginMatcher{exact: make(map[string]bool)}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "":
+			continue
+		case pattern == "*":
+			m.any = true
+		case strings.HasPrefix(pattern, regexOriginPrefix):
+			expr := strings.TrimPrefix(pattern, regexOriginPrefix)
+			re, err := regexp.Compile(`^(?:` + expr + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid origin pattern %q: %w", pattern, err)
+			}
+			m.patterns = append(m.patterns, re)
+		case strings.Contains(pattern, "*"):
+			re, err := wildcardOrigin(pattern)
+			if err != nil {
+				return nil, err
+			}
+			m.patterns = append(m.patterns, re)
+		default:
+			if err := checkOrigin(pattern); err != nil {
+				return nil, err
+			}
+			m.exact[strings.ToLower(pattern)] = true
+		}
+	}
+
+	return m, nil
+}
+
+// Update replaces the allowed origin patterns. Invalid patterns leave the
+// current ones in place.
+func (m *OriginMatcher) Update(patterns []string) error {
+	updated, err := NewOriginMatcher(patterns)
+	if err != nil {
+		return err
+	}
+	m.Replace(updated)
+	return nil
+}
+
+// Replace makes m match the origins updated matches
+func (m *OriginMatcher) Replace(updated *OriginMatcher) {
+	updated.mu.RLock()
+	defer updated.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.any, m.exact, m.patterns = updated.any, updated.exact, updated.patterns
+}
+
+// wildcardOrigin converts a pattern like "https://*.example.com" into a
+// regular expression matching a single label in place of the "*"
+func wildcardOrigin(pattern string) (*regexp.Regexp, error) {
+	scheme, host, ok := strings.Cut(pattern, "://")
+	if !ok || scheme == "" || !strings.HasPrefix(host, "*.") || strings.Count(host, "*") != 1 {
+		return nil, fmt.Errorf("invalid origin pattern %q: wildcards must have the form scheme://*.domain", pattern)
+	}
+
+	expr := `^` + regexp.QuoteMeta(strings.ToLower(scheme)+"://") +
+		`[a-z0-9]([a-z0-9-]*[a-z0-9])?` +
+		regexp.QuoteMeta(strings.ToLower(strings.TrimPrefix(host, "*"))) + `$`
+	return regexp.Compile(expr)
+}
+
+// checkOrigin checks that an exact origin has the form browsers send in the
+// Origin header, a scheme and host with an optional port. One with a path,
+// even a trailing slash, would never match.
+func checkOrigin(origin string) error {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("invalid origin %q: must have the form scheme://host[:port]", origin)
+	}
+	return nil
+}
+
+// Match reports whether origin is allowed
+func (m *OriginMatcher) Match(origin string) bool {
+	if origin == "" {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.any {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if m.exact[lower] {
+		return true
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(lower) || re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
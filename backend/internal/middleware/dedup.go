@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/cache"
+)
+
+// maxDedupBodySize bounds the request bodies considered for deduplication;
+// larger requests are always passed through
+const maxDedupBodySize = 64 << 10
+
+// Deduplicator coalesces identical POST requests from the same client
+// within a short window, protecting against double-submits from clients
+// that don't send an Idempotency-Key. The first request is served
+// normally; duplicates arriving while it runs wait for it, and duplicates
+// arriving within the window after it completes receive a copy of its
+// response with X-Deduplicated: true instead of being executed again.
+//
+// Clients are identified by user ID when authenticated and by IP address
+// otherwise. Requests carrying an Idempotency-Key are left alone, as are
+// requests whose first attempt failed with a server error, so they can be
+// retried.
+type Deduplicator struct {
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+	window  time.Duration
+	now     func() time.Time
+	// store, when set, also shares completed responses with other instances
+	store cache.Store
+}
+
+type dedupEntry struct {
+	done    chan struct{}
+	expires time.Time
+	status  int
+	header  http.Header
+	body    []byte
+}
+
+// storedResponse is a completed response as kept in the shared store
+type storedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// NewDeduplicator creates a Deduplicator that remembers responses for
+// window. Expired responses are cleaned up until ctx is done.
+func NewDeduplicator(ctx context.Context, window time.Duration) *Deduplicator {
+	d := &Deduplicator{
+		entries: make(map[string]*dedupEntry),
+		window:  window,
+		now:     time.Now,
+	}
+
+	// Clean up expired responses periodically
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.cleanup()
+			}
+		}
+	}()
+
+	return d
+}
+
+// NewSharedDeduplicator creates a Deduplicator that also shares completed
+// responses through store, so duplicates routed to another instance are
+// replayed too. Requests still running are only coalesced per instance.
+func NewSharedDeduplicator(ctx context.Context, window time.Duration, store cache.Store) *Deduplicator {
+	d := NewDeduplicator(ctx, window)
+	d.store = store
+	return d
+}
+
+func (d *Deduplicator) cleanup() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for key, entry := range d.entries {
+		select {
+		case <-entry.done:
+			if now.After(entry.expires) {
+				delete(d.entries, key)
+			}
+		default:
+		}
+	}
+}
+
+// Middleware deduplicates POST requests
+func (d *Deduplicator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Idempotency-Key") != "" || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxDedupBodySize+1))
+		rest := r.Body
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), rest), rest}
+		if err != nil || len(body) > maxDedupBodySize {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := d.key(r, body)
+
+		d.mu.Lock()
+		entry, ok := d.entries[key]
+		if ok {
+			select {
+			case <-entry.done:
+				if d.now().After(entry.expires) {
+					ok = false
+				}
+			default:
+			}
+		}
+		if !ok {
+			entry = &dedupEntry{done: make(chan struct{})}
+			d.entries[key] = entry
+		}
+		d.mu.Unlock()
+
+		if ok {
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+			if entry.header != nil {
+				replayResponse(w, entry)
+				return
+			}
+			// The first attempt failed; run this one instead
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if stored, ok := d.loadShared(r.Context(), key); ok {
+			d.mu.Lock()
+			entry.status, entry.header, entry.body = stored.Status, stored.Header, stored.Body
+			entry.expires = d.now().Add(d.window)
+			d.mu.Unlock()
+			close(entry.done)
+			replayResponse(w, entry)
+			return
+		}
+
+		// Headers set by outer middleware, such as rate limits, belong to
+		// each request; only those set further in are replayed
+		before := w.Header().Clone()
+		recorder := &dedupWriter{responseWrapper: responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}}
+		completed := false
+		defer func() {
+			d.mu.Lock()
+			stored := completed && recorder.statusCode < 500
+			if stored {
+				entry.status = recorder.statusCode
+				entry.header = addedHeaders(before, w.Header())
+				entry.body = recorder.body.Bytes()
+				entry.expires = d.now().Add(d.window)
+			} else {
+				delete(d.entries, key)
+			}
+			d.mu.Unlock()
+			close(entry.done)
+
+			if stored {
+				d.storeShared(r.Context(), key, entry)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+		completed = true
+	})
+}
+
+// loadShared returns the response another instance recorded for key
+func (d *Deduplicator) loadShared(ctx context.Context, key string) (storedResponse, bool) {
+	if d.store == nil {
+		return storedResponse{}, false
+	}
+	stored, ok, err := cache.GetJSON[storedResponse](ctx, d.store, "dedup:"+key)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read deduplicated response", "error", err)
+	}
+	return stored, ok
+}
+
+// storeShared shares a completed response with other instances
+func (d *Deduplicator) storeShared(ctx context.Context, key string, entry *dedupEntry) {
+	if d.store == nil {
+		return
+	}
+	stored := storedResponse{Status: entry.status, Header: entry.header, Body: entry.body}
+	if err := cache.SetJSON(ctx, d.store, "dedup:"+key, stored, d.window); err != nil {
+		slog.WarnContext(ctx, "failed to share deduplicated response", "error", err)
+	}
+}
+
+// key identifies a request by client, route and body
+func (d *Deduplicator) key(r *http.Request, body []byte) string {
+	client := "ip:" + clientIP(r)
+	if userID := UserIDFromContext(r.Context()); userID != "" {
+		client = "user:" + userID
+	}
+
+	h := sha256.New()
+	for _, part := range []string{client, r.Method, r.URL.RequestURI()} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addedHeaders returns the headers in after that differ from before
+func addedHeaders(before, after http.Header) http.Header {
+	added := http.Header{}
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			added[name] = slices.Clone(values)
+		}
+	}
+	return added
+}
+
+// replayResponse writes a copy of a recorded response
+func replayResponse(w http.ResponseWriter, entry *dedupEntry) {
+	for name, values := range entry.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Deduplicated", "true")
+	w.WriteHeader(entry.status)
+	w.Write(entry.body)
+}
+
+// dedupWriter records the response so it can be replayed to duplicates
+type dedupWriter struct {
+	responseWrapper
+	body bytes.Buffer
+}
+
+func (dw *dedupWriter) Write(p []byte) (int, error) {
+	dw.body.Write(p)
+	return dw.ResponseWriter.Write(p)
+}
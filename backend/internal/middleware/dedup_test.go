@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/cache"
+)
+
+func dedupRequest(body, userID string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", strings.NewReader(body))
+	req.RemoteAddr = "192.0.2.1:1234"
+	if userID != "" {
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, userID))
+	}
+	return req
+}
+
+func TestDeduplicator_ReplaysDuplicates(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"n":%d}`, n)
+	})
+
+	d := NewDeduplicator(t.Context(), time.Minute)
+	h := d.Middleware(handler)
+
+	first := httptest.NewRecorder()
+	h.ServeHTTP(first, dedupRequest(`{"title":"t"}`, "u1"))
+
+	second := httptest.NewRecorder()
+	h.ServeHTTP(second, dedupRequest(`{"title":"t"}`, "u1"))
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls.Load())
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != `{"n":1}` || second.Header().Get("X-Deduplicated") != "true" {
+		t.Errorf("expected replayed response, got %d %q %v", second.Code, second.Body.String(), second.Header())
+	}
+	if second.Header().Get("Content-Type") != "application/json" {
+		t.Error("expected handler headers to be replayed")
+	}
+
+	// Different body, different user, or an Idempotency-Key all run again
+	h.ServeHTTP(httptest.NewRecorder(), dedupRequest(`{"title":"other"}`, "u1"))
+	h.ServeHTTP(httptest.NewRecorder(), dedupRequest(`{"title":"t"}`, "u2"))
+	req := dedupRequest(`{"title":"t"}`, "u1")
+	req.Header.Set("Idempotency-Key", "k1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if calls.Load() != 4 {
+		t.Errorf("expected 4 handler calls, got %d", calls.Load())
+	}
+}
+
+func TestDeduplicator_WindowExpires(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	})
+
+	now := time.Now()
+	d := NewDeduplicator(t.Context(), 5*time.Second)
+	d.now = func() time.Time { return now }
+	h := d.Middleware(handler)
+
+	h.ServeHTTP(httptest.NewRecorder(), dedupRequest(`{}`, ""))
+	now = now.Add(6 * time.Second)
+	h.ServeHTTP(httptest.NewRecorder(), dedupRequest(`{}`, ""))
+
+	if calls.Load() != 2 {
+		t.Errorf("expected handler to run again after the window, ran %d times", calls.Load())
+	}
+
+	d.cleanup()
+	now = now.Add(6 * time.Second)
+	d.cleanup()
+	if len(d.entries) != 0 {
+		t.Errorf("expected expired entries to be cleaned up, %d left", len(d.entries))
+	}
+}
+
+func TestDeduplicator_ServerErrorsAreNotReplayed(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	h := NewDeduplicator(t.Context(), time.Minute).Middleware(handler)
+
+	h.ServeHTTP(httptest.NewRecorder(), dedupRequest(`{}`, "u1"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, dedupRequest(`{}`, "u1"))
+
+	if calls.Load() != 2 || w.Code != http.StatusCreated {
+		t.Errorf("expected retry after a server error, got %d calls and status %d", calls.Load(), w.Code)
+	}
+}
+
+func TestDeduplicator_ConcurrentDuplicatesWait(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	h := NewDeduplicator(t.Context(), time.Minute).Middleware(handler)
+
+	first := httptest.NewRecorder()
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		close(started)
+		h.ServeHTTP(first, dedupRequest(`{}`, "u1"))
+	}()
+	<-started
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := httptest.NewRecorder()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(second, dedupRequest(`{}`, "u1"))
+	}()
+
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 || second.Code != http.StatusCreated {
+		t.Errorf("expected the duplicate to share the first response, got %d calls and status %d", calls.Load(), second.Code)
+	}
+}
+
+func TestDeduplicator_SharesResponsesThroughStore(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ok":true}`))
+	})
+
+	// Two instances sharing one store
+	store := cache.NewLRU(10)
+	first := NewSharedDeduplicator(t.Context(), time.Minute, store).Middleware(handler)
+	second := NewSharedDeduplicator(t.Context(), time.Minute, store).Middleware(handler)
+
+	first.ServeHTTP(httptest.NewRecorder(), dedupRequest(`{"title":"t"}`, "u1"))
+	w := httptest.NewRecorder()
+	second.ServeHTTP(w, dedupRequest(`{"title":"t"}`, "u1"))
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls.Load())
+	}
+	if w.Code != http.StatusCreated || w.Body.String() != `{"ok":true}` || w.Header().Get("X-Deduplicated") != "true" {
+		t.Errorf("expected the other instance to replay the response, got %d %q", w.Code, w.Body.String())
+	}
+}
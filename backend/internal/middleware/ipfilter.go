@@ -0,0 +1,258 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IPFilterConfig lists blocked addresses and the routes restricted to
+// specific networks. Entries are CIDR ranges or single IP addresses.
+type IPFilterConfig struct {
+	// Deny blocks clients on every route
+	Deny []string `json:"deny"`
+	// Allow restricts routes under a path prefix to the listed networks
+	Allow []IPAllowRule `json:"allow"`
+	// TrustedProxies lists the proxies whose X-Forwarded-For is honoured
+	TrustedProxies []string `json:"trustedProxies"`
+}
+
+// IPAllowRule limits routes under PathPrefix to clients in CIDRs
+type IPAllowRule struct {
+	PathPrefix string   `json:"pathPrefix"`
+	CIDRs      []string `json:"cidrs"`
+}
+
+type ipAllowRule struct {
+	pathPrefix string
+	prefixes   []netip.Prefix
+}
+
+// IPFilter rejects requests from denied networks and requests to restricted
+// routes from outside their allowed networks. Its configuration can be
+// replaced at runtime, for example by watching a file.
+//
+// The client address is the connection's, unless it comes from a trusted
+// proxy. Then it is the right-most X-Forwarded-For hop that isn't a trusted
+// proxy, since clients can put anything to the left of it.
+type IPFilter struct {
+	mu      sync.RWMutex
+	deny    []netip.Prefix
+	allow   []ipAllowRule
+	trusted []netip.Prefix
+}
+
+// NewIPFilter creates a filter from cfg
+func NewIPFilter(cfg IPFilterConfig) (*IPFilter, error) {
+	f := &IPFilter{}
+	if err := f.Update(cfg); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Update atomically replaces the filter configuration. The current
+// configuration is kept if cfg is invalid.
+func (f *IPFilter) Update(cfg IPFilterConfig) error {
+	deny, err := parsePrefixes(cfg.Deny)
+	if err != nil {
+		return fmt.Errorf("invalid deny list: %w", err)
+	}
+
+	allow := make([]ipAllowRule, 0, len(cfg.Allow))
+	for _, rule := range cfg.Allow {
+		if rule.PathPrefix == "" {
+			return fmt.Errorf("allow rule without pathPrefix")
+		}
+		prefixes, err := parsePrefixes(rule.CIDRs)
+		if err != nil {
+			return fmt.Errorf("invalid allow list for %s: %w", rule.PathPrefix, err)
+		}
+		allow = append(allow, ipAllowRule{pathPrefix: rule.PathPrefix, prefixes: prefixes})
+	}
+
+	trusted, err := parsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		return fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deny = deny
+	f.allow = allow
+	f.trusted = trusted
+	return nil
+}
+
+// Allowed reports whether a client at addr may access path
+func (f *IPFilter) Allowed(addr netip.Addr, path string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if containsAddr(f.deny, addr) {
+		return false
+	}
+	for _, rule := range f.allow {
+		if strings.HasPrefix(path, rule.pathPrefix) && !containsAddr(rule.prefixes, addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware responds with 403 to requests the filter does not allow.
+// Requests whose client address cannot be parsed are only let through when
+// no restriction could apply to them. The client address is passed on to
+// the rate limits, BotGuard and deduplication that follow.
+func (f *IPFilter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, err := f.clientAddr(r)
+		if err != nil {
+			addr = netip.IPv6Unspecified()
+		}
+
+		if !f.Allowed(addr, r.URL.Path) {
+			slog.WarnContext(r.Context(), "request blocked by IP filter", "client_ip", addr, "remote_addr", r.RemoteAddr)
+			respondAPIError(w, http.StatusForbidden, "FORBIDDEN", "Access denied")
+			return
+		}
+
+		if err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), clientAddrKey, addr))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientAddr returns the address of the client r came from, walking
+// X-Forwarded-For back from the connection while the hops are trusted
+// proxies
+func (f *IPFilter) clientAddr(r *http.Request) (netip.Addr, error) {
+	addr, err := parseClientAddr(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	f.mu.RLock()
+	trusted := f.trusted
+	f.mu.RUnlock()
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0 && containsAddr(trusted, addr); i-- {
+		if addr, err = parseClientAddr(strings.TrimSpace(hops[i])); err != nil {
+			return netip.Addr{}, err
+		}
+	}
+	return addr, nil
+}
+
+// LoadIPFilterConfig reads a JSON IPFilterConfig from path
+func LoadIPFilterConfig(path string) (IPFilterConfig, error) {
+	var cfg IPFilterConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// WatchFile starts reloading the filter from path in the background
+// whenever its modification time changes, checking every interval until ctx
+// is cancelled. Invalid files are logged and ignored, keeping the previous
+// configuration.
+func (f *IPFilter) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	go f.watchFile(ctx, path, interval, lastMod)
+}
+
+func (f *IPFilter) watchFile(ctx context.Context, path string, interval time.Duration, lastMod time.Time) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Equal(lastMod) {
+			continue
+		}
+		lastMod = info.ModTime()
+
+		cfg, err := LoadIPFilterConfig(path)
+		if err == nil {
+			err = f.Update(cfg)
+		}
+		if err != nil {
+			slog.Error("Failed to reload IP filter", "path", path, "error", err)
+			continue
+		}
+		slog.Info("IP filter reloaded", "path", path, "deny", len(cfg.Deny), "allow_rules", len(cfg.Allow))
+	}
+}
+
+// parsePrefixes parses CIDR ranges, treating bare addresses as single-host
+// ranges
+func parsePrefixes(entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return prefixes, nil
+}
+
+// parseClientAddr parses an address with or without a port
+func parseClientAddr(value string) (netip.Addr, error) {
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addr.Unmap(), nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
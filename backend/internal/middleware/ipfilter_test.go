@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIPFilter_Middleware(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	filter, err := NewIPFilter(IPFilterConfig{
+		Deny: []string{"203.0.113.0/24", "198.51.100.7"},
+		Allow: []IPAllowRule{
+			{PathPrefix: "/api/v1/admin/", CIDRs: []string{"10.0.0.0/8"}},
+		},
+		TrustedProxies: []string{"10.0.0.1", "10.0.0.2"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	middleware := filter.Middleware(handler)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		path       string
+		expected   int
+	}{
+		{"public route", "192.0.2.1:1234", "", "/api/v1/acts", http.StatusOK},
+		{"denied range", "203.0.113.50:1234", "", "/api/v1/acts", http.StatusForbidden},
+		{"denied address via proxy", "10.0.0.1:1234", "198.51.100.7", "/api/v1/acts", http.StatusForbidden},
+		{"denied address via proxy chain", "10.0.0.1:1234", "198.51.100.7, 10.0.0.2", "/api/v1/acts", http.StatusForbidden},
+		{"forwarded by untrusted client", "192.0.2.1:1234", "10.1.2.3", "/api/v1/admin/audit", http.StatusForbidden},
+		{"spoofed hop behind proxy", "10.0.0.1:1234", "10.1.2.3, 192.0.2.1", "/api/v1/admin/audit", http.StatusForbidden},
+		{"office behind proxy", "10.0.0.1:1234", "192.0.2.1, 10.1.2.3", "/api/v1/admin/audit", http.StatusOK},
+		{"denied proxy itself", "203.0.113.50:1234", "192.0.2.1", "/api/v1/acts", http.StatusForbidden},
+		{"admin from office", "10.1.2.3:1234", "", "/api/v1/admin/audit", http.StatusOK},
+		{"admin from outside", "192.0.2.1:1234", "", "/api/v1/admin/audit", http.StatusForbidden},
+		{"unparseable address", "garbage", "", "/api/v1/admin/audit", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			w := httptest.NewRecorder()
+
+			middleware.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestIPFilter_ClientIP(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(clientIP(r)))
+	})
+	filter, err := NewIPFilter(IPFilterConfig{TrustedProxies: []string{"10.0.0.1"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		{"via trusted proxy", filter.Middleware(echo), "10.0.0.1:1234", "203.0.113.9, 192.0.2.1", "192.0.2.1"},
+		{"spoofed by client", filter.Middleware(echo), "192.0.2.1:1234", "203.0.113.9", "192.0.2.1"},
+		{"without a filter", echo, "192.0.2.1:1234", "203.0.113.9", "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, req)
+			if got := w.Body.String(); got != tt.expected {
+				t.Errorf("expected client %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestIPFilter_InvalidConfig(t *testing.T) {
+	if _, err := NewIPFilter(IPFilterConfig{Deny: []string{"not-an-ip"}}); err == nil {
+		t.Error("expected invalid deny entry to be rejected")
+	}
+
+	filter, _ := NewIPFilter(IPFilterConfig{Deny: []string{"192.0.2.1"}})
+	if err := filter.Update(IPFilterConfig{Allow: []IPAllowRule{{CIDRs: []string{"10.0.0.0/8"}}}}); err == nil {
+		t.Error("expected allow rule without a path prefix to be rejected")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.0.2.1:1"
+	w := httptest.NewRecorder()
+	filter.Middleware(http.NotFoundHandler()).ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Error("expected previous configuration to be kept after a failed update")
+	}
+}
+
+func TestIPFilter_WatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ipfilter.json")
+	if err := os.WriteFile(path, []byte(`{"deny": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadIPFilterConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	filter, _ := NewIPFilter(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	filter.WatchFile(ctx, path, 10*time.Millisecond)
+
+	if err := os.WriteFile(path, []byte(`{"deny": ["192.0.2.1"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the modification time changes on coarse filesystems
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(path, future, future)
+
+	addr, _ := parseClientAddr("192.0.2.1")
+	deadline := time.Now().Add(2 * time.Second)
+	for filter.Allowed(addr, "/") {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the filter to be reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -2,15 +2,27 @@ package middleware
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"strings"
 
 	"payforwardnow/internal/auth"
+	"payforwardnow/internal/i18n"
 )
 
+// AdminRole is the realm or client role that grants access to admin endpoints
+const AdminRole = "admin"
+
 type KeycloakAuthMiddleware struct {
-	keycloak *auth.KeycloakAuth
+	keycloak    *auth.KeycloakAuth
+	revocations *auth.Revocations
+	suspensions SuspensionChecker
+}
+
+// SuspensionChecker reports whether an admin suspended a user
+type SuspensionChecker interface {
+	Suspended(ctx context.Context, userID string) (bool, error)
 }
 
 func NewKeycloakAuthMiddleware(keycloak *auth.KeycloakAuth) *KeycloakAuthMiddleware {
@@ -19,6 +31,43 @@ func NewKeycloakAuthMiddleware(keycloak *auth.KeycloakAuth) *KeycloakAuthMiddlew
 	}
 }
 
+// SetRevocations rejects tokens revoked by logging out
+func (k *KeycloakAuthMiddleware) SetRevocations(revocations *auth.Revocations) {
+	k.revocations = revocations
+}
+
+// revoked reports whether token was revoked. Lookup failures are logged and
+// let the token through, since it is still valid and expires on its own.
+func (k *KeycloakAuthMiddleware) revoked(ctx context.Context, token string) bool {
+	if k.revocations == nil {
+		return false
+	}
+	revoked, err := k.revocations.Revoked(ctx, token)
+	if err != nil {
+		slog.WarnContext(ctx, "token revocation check failed", "error", err)
+	}
+	return revoked
+}
+
+// SetSuspensions rejects the tokens of users an admin has suspended, who
+// may still hold tokens issued before the suspension
+func (k *KeycloakAuthMiddleware) SetSuspensions(suspensions SuspensionChecker) {
+	k.suspensions = suspensions
+}
+
+// suspended reports whether the user identified by claims is suspended.
+// Lookup failures are logged and let the user through, like revocations.
+func (k *KeycloakAuthMiddleware) suspended(ctx context.Context, claims *auth.KeycloakClaims) bool {
+	if k.suspensions == nil {
+		return false
+	}
+	suspended, err := k.suspensions.Suspended(ctx, claims.Subject)
+	if err != nil {
+		slog.WarnContext(ctx, "suspension check failed", "error", err)
+	}
+	return suspended
+}
+
 // KeycloakAuth is a middleware that validates Keycloak JWT tokens
 func (k *KeycloakAuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -30,17 +79,20 @@ func (k *KeycloakAuthMiddleware) Authenticate(next http.Handler) http.Handler {
 
 		claims, err := k.keycloak.ValidateToken(tokenString)
 		if err != nil {
-			log.Printf("Token validation failed: %v", err)
+			slog.WarnContext(r.Context(), "token validation failed", "error", err)
 			respondJSONError(w, http.StatusUnauthorized, "Invalid or expired token")
 			return
 		}
+		if k.revoked(r.Context(), tokenString) {
+			respondJSONError(w, http.StatusUnauthorized, "Token has been revoked")
+			return
+		}
+		if k.suspended(r.Context(), claims) {
+			respondJSONError(w, http.StatusForbidden, "This account is suspended")
+			return
+		}
 
-		// Add claims to context
-		ctx := context.WithValue(r.Context(), UserIDKey, claims.Subject)
-		ctx = context.WithValue(ctx, EmailKey, claims.Email)
-		ctx = context.WithValue(ctx, ContextKey("keycloak_claims"), claims)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(k.withClaims(r.Context(), claims)))
 	})
 }
 
@@ -92,6 +144,19 @@ func (k *KeycloakAuthMiddleware) RequireAnyRole(roles ...string) Middleware {
 	}
 }
 
+// RequireAdmin authenticates the request and requires the admin role. Admin
+// endpoints are disabled entirely when Keycloak is not configured.
+func RequireAdmin(k *KeycloakAuthMiddleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		if k == nil {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				respondJSONError(w, http.StatusForbidden, "Admin API requires Keycloak authentication")
+			})
+		}
+		return k.Authenticate(k.RequireRole(AdminRole)(next))
+	}
+}
+
 // OptionalAuth validates the token if present, but doesn't require it
 func (k *KeycloakAuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -108,22 +173,42 @@ func (k *KeycloakAuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
 		}
 
 		claims, err := k.keycloak.ValidateToken(parts[1])
-		if err != nil {
+		if err != nil || k.revoked(r.Context(), parts[1]) {
 			// Invalid token, but we don't reject the request
 			next.ServeHTTP(w, r)
 			return
 		}
+		// A valid token of a suspended user is rejected rather than
+		// ignored, so they don't mistake the errors that follow for a
+		// problem with signing in
+		if k.suspended(r.Context(), claims) {
+			respondJSONError(w, http.StatusForbidden, "This account is suspended")
+			return
+		}
 
-		ctx := context.WithValue(r.Context(), UserIDKey, claims.Subject)
-		ctx = context.WithValue(ctx, EmailKey, claims.Email)
-		ctx = context.WithValue(ctx, ContextKey("keycloak_claims"), claims)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(k.withClaims(r.Context(), claims)))
 	})
 }
 
+// withClaims adds the authenticated user's identity and roles to ctx
+func (k *KeycloakAuthMiddleware) withClaims(ctx context.Context, claims *auth.KeycloakClaims) context.Context {
+	roles := append([]string{}, claims.RealmAccess.Roles...)
+	if access, ok := claims.ResourceAccess[k.keycloak.ClientID()]; ok {
+		roles = append(roles, access.Roles...)
+	}
+
+	ctx = withUserID(ctx, claims.Subject)
+	ctx = context.WithValue(ctx, EmailKey, claims.Email)
+	ctx = context.WithValue(ctx, RolesKey, roles)
+	return context.WithValue(ctx, ContextKey("keycloak_claims"), claims)
+}
+
 func respondJSONError(w http.ResponseWriter, status int, message string) {
+	body, _ := json.Marshal(struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}{false, i18n.Localize(w, message)})
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	w.Write([]byte(`{"success":false,"error":"` + message + `"}`))
+	w.Write(body)
 }
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/auth"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type suspendedUsers map[string]bool
+
+func (s suspendedUsers) Suspended(_ context.Context, userID string) (bool, error) {
+	return s[userID], nil
+}
+
+// newTestKeycloak serves a signing key the way Keycloak does and returns
+// middleware trusting it, with a function issuing tokens for a subject
+func newTestKeycloak(t *testing.T) (*KeycloakAuthMiddleware, func(subject string) string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /realms/test/protocol/openid-connect/certs", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(auth.JWKSResponse{Keys: []auth.JWK{{
+			Kid: "sig", Kty: "RSA", Alg: "RS256", Use: "sig",
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	keycloak := auth.NewKeycloakAuth(server.URL, "test", "payforward", "")
+	t.Cleanup(func() { keycloak.Close() })
+
+	issue := func(subject string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, auth.KeycloakClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   subject,
+				Issuer:    server.URL + "/realms/test",
+				Audience:  jwt.ClaimStrings{"payforward"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		token.Header["kid"] = "sig"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	return NewKeycloakAuthMiddleware(keycloak), issue
+}
+
+func TestKeycloak_RejectsSuspendedUsers(t *testing.T) {
+	k, issue := newTestKeycloak(t)
+	k.SetSuspensions(suspendedUsers{"suspended": true})
+
+	created := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if UserIDFromContext(r.Context()) == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name     string
+		wrap     Middleware
+		subject  string
+		expected int
+	}{
+		{"active user", k.OptionalAuth, "active", http.StatusCreated},
+		{"suspended user", k.OptionalAuth, "suspended", http.StatusForbidden},
+		{"active user, authentication required", k.Authenticate, "active", http.StatusCreated},
+		{"suspended user, authentication required", k.Authenticate, "suspended", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", nil)
+			req.Header.Set("Authorization", "Bearer "+issue(tt.subject))
+			w := httptest.NewRecorder()
+			tt.wrap(created).ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("expected status %d, got %d: %s", tt.expected, w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"payforwardnow/internal/i18n"
+)
+
+// Language negotiates the language of the response's messages from an
+// explicit ?locale= parameter or Accept-Language. When a supported language
+// other than English is preferred, it is set as Content-Language, which
+// i18n.Localize translates error messages and notification texts to.
+func Language(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Language")
+		if locale := i18n.Match(i18n.Preferred(r)); locale != i18n.DefaultLocale {
+			w.Header().Set("Content-Language", locale)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
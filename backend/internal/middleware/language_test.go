@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	handler := Language(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respondAPIError(w, http.StatusForbidden, "FORBIDDEN", "Access denied")
+	}))
+
+	tests := []struct {
+		name           string
+		url            string
+		acceptLanguage string
+		language       string
+		message        string
+	}{
+		{"default", "/", "", "", "Access denied"},
+		{"unsupported", "/", "nl, ja", "", "Access denied"},
+		{"preferred", "/", "nl, fr;q=0.8, de;q=0.5", "fr", "Accès refusé"},
+		{"base language", "/?locale=es-MX", "", "es-mx", "Acceso denegado"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Language"); got != tt.language {
+				t.Errorf("expected Content-Language %q, got %q", tt.language, got)
+			}
+			if !strings.Contains(w.Body.String(), `"message":"`+tt.message+`"`) {
+				t.Errorf("expected %q, got %s", tt.message, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	m := NewMaintenance(false, "/api/health")
+	middleware := m.Middleware(handler)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := serve("/api/v1/acts"); w.Code != http.StatusOK {
+		t.Errorf("expected requests to pass while disabled, got %d", w.Code)
+	}
+
+	m.Set(true, "Migrating data", 2*time.Minute)
+
+	w := serve("/api/v1/acts")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "120" {
+		t.Errorf("expected Retry-After 120, got %q", got)
+	}
+
+	if w := serve("/api/health"); w.Code != http.StatusOK {
+		t.Errorf("expected exempt route to be served, got %d", w.Code)
+	}
+
+	if state := m.State(); !state.Enabled || state.Message != "Migrating data" || state.Since.IsZero() {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	m.Set(false, "", 0)
+	if w := serve("/api/v1/acts"); w.Code != http.StatusOK {
+		t.Errorf("expected requests to pass once disabled, got %d", w.Code)
+	}
+}
@@ -2,15 +2,31 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/netip"
 	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/errortracking"
+	"payforwardnow/internal/i18n"
+	"payforwardnow/internal/logging"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/requestid"
+
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // ContextKey is a custom type for context keys
@@ -19,8 +35,42 @@ type ContextKey string
 const (
 	UserIDKey ContextKey = "userID"
 	EmailKey  ContextKey = "email"
+	RolesKey  ContextKey = "roles"
+
+	// clientAddrKey holds the client address IPFilter resolved
+	clientAddrKey ContextKey = "clientAddr"
 )
 
+// UserIDFromContext returns the authenticated user ID, if any
+func UserIDFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(UserIDKey).(string)
+	return userID
+}
+
+// withUserID records the authenticated user in ctx for handlers, logs and
+// the metadata of database transactions
+func withUserID(ctx context.Context, userID string) context.Context {
+	ctx = context.WithValue(ctx, UserIDKey, userID)
+	logging.AddAttrs(ctx, slog.String("user_id", userID))
+	return database.WithUserID(ctx, userID)
+}
+
+// RequestIDFromContext returns the request ID set by RequestID, if any
+func RequestIDFromContext(ctx context.Context) string {
+	return requestid.FromContext(ctx)
+}
+
+// HasRole reports whether the authenticated user has the given role
+func HasRole(ctx context.Context, role string) bool {
+	roles, _ := ctx.Value(RolesKey).([]string)
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // Config holds middleware configuration
 type Config struct {
 	JWTSecret       string
@@ -39,27 +89,58 @@ func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
 	return h
 }
 
-// Logger logs HTTP requests with timing information
+// Logger logs HTTP requests with timing information. It opens a logging
+// scope so every log line written while serving the request carries the
+// method and path, plus the request and user IDs once they are known.
 func Logger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Create a response wrapper to capture status code
-		wrapped := &responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}
-
-		next.ServeHTTP(wrapped, r)
-
-		duration := time.Since(start)
-		log.Printf(
-			"[%s] %s %s %d %v | %s",
-			r.Method,
-			r.URL.Path,
-			r.RemoteAddr,
-			wrapped.statusCode,
-			duration,
-			r.UserAgent(),
-		)
-	})
+	return LoggerWithConfig(LoggerConfig{})(next)
+}
+
+// LoggerWithConfig logs requests like Logger and, when an access log is
+// configured, also writes a line per request in a standard format for log
+// ingestion pipelines.
+func LoggerWithConfig(cfg LoggerConfig) Middleware {
+	var access *accessLogger
+	if cfg.AccessLog != nil {
+		access = &accessLogger{w: cfg.AccessLog, format: cfg.AccessLogFormat}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			ctx := logging.WithScope(r.Context())
+			logging.AddAttrs(ctx,
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			)
+
+			// Create a response wrapper to capture status code and size
+			wrapped := &accessLogWriter{responseWrapper: responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}}
+
+			r = r.WithContext(ctx)
+			next.ServeHTTP(wrapped, r)
+
+			level := slog.LevelInfo
+			switch {
+			case wrapped.statusCode >= 500:
+				level = slog.LevelError
+			case wrapped.statusCode >= 400:
+				level = slog.LevelWarn
+			}
+
+			slog.LogAttrs(ctx, level, "http request",
+				slog.Int("status", wrapped.statusCode),
+				slog.Duration("latency", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("user_agent", r.UserAgent()),
+			)
+
+			if access != nil {
+				access.log(r, wrapped, start)
+			}
+		})
+	}
 }
 
 // responseWrapper captures the status code
@@ -73,27 +154,40 @@ func (rw *responseWrapper) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// CORS handles Cross-Origin Resource Sharing
+// Unwrap exposes the underlying ResponseWriter so http.ResponseController
+// can reach Flush and deadline controls for streaming responses
+func (rw *responseWrapper) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// CORS handles Cross-Origin Resource Sharing. See OriginMatcher for the
+// supported origin patterns; it panics if a pattern is invalid, so
+// configured origins should be checked with NewOriginMatcher first.
 func CORS(allowedOrigins []string) Middleware {
+	origins, err := NewOriginMatcher(allowedOrigins)
+	if err != nil {
+		panic(err)
+	}
+	return CORSWithMatcher(origins)
+}
+
+// CORSWithMatcher handles Cross-Origin Resource Sharing for the origins
+// allowed by origins
+func CORSWithMatcher(origins *OriginMatcher) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			origin := r.Header.Get("Origin")
 
-			// Check if origin is allowed
-			allowed := false
-			for _, o := range allowedOrigins {
-				if o == "*" || o == origin {
-					allowed = true
-					break
-				}
-			}
-
-			if allowed {
+			// The allowed origin is echoed back, so the response varies
+			// by Origin even when it is not allowed or not sent
+			w.Header().Add("Vary", "Origin")
+			if origins.Match(origin) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 			}
 
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token, X-Requested-With")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token, X-Requested-With, If-Match, "+BookmarkHeader+", "+TenantHeader+", "+ReadRoutingHeader)
+			w.Header().Set("Access-Control-Expose-Headers", "ETag, "+BookmarkHeader)
 			w.Header().Set("Access-Control-Allow-Credentials", "true")
 			w.Header().Set("Access-Control-Max-Age", "86400")
 
@@ -108,17 +202,43 @@ func CORS(allowedOrigins []string) Middleware {
 	}
 }
 
-// RateLimiter implements a simple token bucket rate limiter
+// RateLimiter implements a sliding-window rate limiter. It approximates a
+// true sliding window by weighting the previous fixed window's count by how
+// much of it still overlaps the sliding window, which avoids the burst of
+// up to twice the limit that a plain fixed window allows at its boundary.
 type RateLimiter struct {
 	mu       sync.Mutex
 	visitors map[string]*visitor
 	limit    int
 	window   time.Duration
+	now      func() time.Time
+
+	// store, when set, holds the window counts instead of visitors so all
+	// instances sharing it enforce one limit; name keeps the counts of
+	// different limiters apart
+	store cache.Store
+	name  string
+
+	stop      chan struct{}
+	closeOnce sync.Once
 }
 
 type visitor struct {
-	tokens    int
-	lastReset time.Time
+	windowStart time.Time
+	count       int
+	prevCount   int
+}
+
+// rateLimitResult describes the state of a visitor's quota after a request
+type rateLimitResult struct {
+	allowed   bool
+	limit     int
+	remaining int
+	// reset is the time until the current window ends
+	reset time.Duration
+	// retryAfter is how long a denied client must wait until a request
+	// would be allowed
+	retryAfter time.Duration
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -127,115 +247,485 @@ func NewRateLimiter(requestsPerMinute int) *RateLimiter {
 		visitors: make(map[string]*visitor),
 		limit:    requestsPerMinute,
 		window:   time.Minute,
+		now:      time.Now,
+		stop:     make(chan struct{}),
 	}
 
 	// Clean up old visitors periodically
 	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
 		for {
-			time.Sleep(5 * time.Minute)
-			rl.cleanup()
+			select {
+			case <-rl.stop:
+				return
+			case <-ticker.C:
+				rl.cleanup()
+			}
 		}
 	}()
 
 	return rl
 }
 
+// Close stops the periodic cleanup of an in-memory limiter. Shared limiters
+// have none.
+func (rl *RateLimiter) Close() error {
+	if rl.stop != nil {
+		rl.closeOnce.Do(func() { close(rl.stop) })
+	}
+	return nil
+}
+
+// NewSharedRateLimiter creates a rate limiter that keeps its counts in store.
+// Unlike NewRateLimiter, requests denied by a shared limiter still count
+// against the client's quota.
+func NewSharedRateLimiter(store cache.Store, name string, requestsPerMinute int) *RateLimiter {
+	return &RateLimiter{
+		limit:  requestsPerMinute,
+		window: time.Minute,
+		now:    time.Now,
+		store:  store,
+		name:   name,
+	}
+}
+
 func (rl *RateLimiter) cleanup() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	for ip, v := range rl.visitors {
-		if time.Since(v.lastReset) > 10*time.Minute {
-			delete(rl.visitors, ip)
+	now := rl.now()
+	for key, v := range rl.visitors {
+		if now.Sub(v.windowStart) > 10*time.Minute {
+			delete(rl.visitors, key)
 		}
 	}
 }
 
-func (rl *RateLimiter) getVisitor(ip string) *visitor {
+// take records a request for key if it is within the limit
+func (rl *RateLimiter) take(key string) rateLimitResult {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	v, exists := rl.visitors[ip]
+	now := rl.now()
+	v, exists := rl.visitors[key]
 	if !exists {
-		v = &visitor{
-			tokens:    rl.limit,
-			lastReset: time.Now(),
+		v = &visitor{windowStart: now.Truncate(rl.window)}
+		rl.visitors[key] = v
+	}
+
+	// Advance to the window containing now
+	if elapsed := now.Sub(v.windowStart); elapsed >= rl.window {
+		windows := int(elapsed / rl.window)
+		if windows == 1 {
+			v.prevCount = v.count
+		} else {
+			v.prevCount = 0
 		}
-		rl.visitors[ip] = v
+		v.count = 0
+		v.windowStart = v.windowStart.Add(time.Duration(windows) * rl.window)
+	}
+
+	return rl.decide(v, now.Sub(v.windowStart))
+}
+
+// check records a request for key in the limiter's store, or in memory if it
+// has none. A failing store lets requests through rather than taking the
+// API down with it.
+func (rl *RateLimiter) check(ctx context.Context, key string) rateLimitResult {
+	if rl.store == nil {
+		return rl.take(key)
 	}
 
-	// Reset tokens if window has passed
-	if time.Since(v.lastReset) > rl.window {
-		v.tokens = rl.limit
-		v.lastReset = time.Now()
+	now := rl.now()
+	windowStart := now.Truncate(rl.window)
+	index := windowStart.UnixNano() / int64(rl.window)
+	countKey := func(index int64) string {
+		return fmt.Sprintf("ratelimit:%s:%s:%d", rl.name, key, index)
 	}
 
-	return v
+	// The count must outlive its window, since the next one weighs it
+	count, err := rl.store.Incr(ctx, countKey(index), 2*rl.window)
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit store unavailable", "error", err)
+		limit := rl.Limit()
+		return rateLimitResult{allowed: true, limit: limit, remaining: limit, reset: rl.window - now.Sub(windowStart)}
+	}
+
+	v := &visitor{windowStart: windowStart, count: int(count) - 1}
+	if prev, ok, err := rl.store.Get(ctx, countKey(index-1)); err == nil && ok {
+		v.prevCount, _ = strconv.Atoi(string(prev))
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.decide(v, now.Sub(windowStart))
 }
 
-func (rl *RateLimiter) allow(ip string) bool {
-	v := rl.getVisitor(ip)
+// Limit returns the number of requests allowed per window
+func (rl *RateLimiter) Limit() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.limit
+}
 
+// SetLimit changes the number of requests allowed per window. Counts are
+// kept, so clients don't get a fresh quota.
+func (rl *RateLimiter) SetLimit(requestsPerMinute int) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	rl.limit = requestsPerMinute
+}
+
+// decide admits a request from v, elapsed into its current window, if the
+// weighted count is below the limit. rl.mu must be held.
+func (rl *RateLimiter) decide(v *visitor, elapsed time.Duration) rateLimitResult {
+	overlap := 1 - float64(elapsed)/float64(rl.window)
+	used := int(float64(v.prevCount)*overlap) + v.count
 
-	if v.tokens > 0 {
-		v.tokens--
-		return true
+	result := rateLimitResult{
+		limit: rl.limit,
+		reset: rl.window - elapsed,
 	}
 
-	return false
+	if used < rl.limit {
+		v.count++
+		used++
+		result.allowed = true
+	} else {
+		result.retryAfter = rl.retryAfter(v, elapsed)
+	}
+
+	result.remaining = rl.limit - used
+	if result.remaining < 0 {
+		result.remaining = 0
+	}
+	return result
+}
+
+// retryAfter returns how long after elapsed into the current window the
+// weighted count of v drops below the limit
+func (rl *RateLimiter) retryAfter(v *visitor, elapsed time.Duration) time.Duration {
+	if v.count >= rl.limit || v.prevCount == 0 {
+		// Only the next window frees up quota
+		return rl.window - elapsed
+	}
+
+	// The previous window's weight shrinks linearly: the request is allowed
+	// once prevCount * (1 - t/window) < limit - count
+	free := float64(rl.limit-v.count) / float64(v.prevCount)
+	at := time.Duration((1 - free) * float64(rl.window))
+	if at <= elapsed {
+		return 0
+	}
+	return at - elapsed
+}
+
+func (rl *RateLimiter) allow(key string) bool {
+	return rl.take(key).allowed
+}
+
+// RateLimitConfig configures rate limiting. Authenticated requests are
+// limited per user ID, anonymous ones per client IP, so users behind a
+// shared NAT or proxy don't exhaust each other's quota.
+type RateLimitConfig struct {
+	// PerMinute is the limit for anonymous clients
+	PerMinute int
+	// UserPerMinute is the limit for authenticated users
+	UserPerMinute int
+	// Classes override the limits for routes matching a path prefix and,
+	// optionally, a set of methods. The first matching class wins, so more
+	// specific classes must come first.
+	Classes []RateClass
+	// Store, when set, holds the request counts so that every instance
+	// sharing it enforces the same limits. Counts are kept in memory
+	// otherwise.
+	Store cache.Store
+}
+
+// RateClass is a group of routes with their own rate limits
+type RateClass struct {
+	Name       string
+	PathPrefix string
+	// Methods restricts the class to these HTTP methods; empty matches all
+	Methods       []string
+	PerMinute     int
+	UserPerMinute int
+}
+
+// matches reports whether r belongs to the class
+func (c RateClass) matches(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, c.PathPrefix) {
+		return false
+	}
+	return len(c.Methods) == 0 || slices.Contains(c.Methods, r.Method)
 }
 
 // RateLimit middleware limits requests per IP
 func RateLimit(requestsPerMinute int) Middleware {
-	limiter := NewRateLimiter(requestsPerMinute)
+	return RateLimitWithConfig(RateLimitConfig{
+		PerMinute:     requestsPerMinute,
+		UserPerMinute: requestsPerMinute,
+	})
+}
 
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Extract IP from request
-			ip := r.RemoteAddr
-			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-				ip = strings.Split(forwarded, ",")[0]
+// RateLimitWithConfig limits requests per user or IP according to cfg. It
+// must run after authentication for user limits to apply.
+func RateLimitWithConfig(cfg RateLimitConfig) Middleware {
+	return NewRateLimits(cfg).Middleware
+}
+
+// RateLimits limits requests per user or IP like RateLimitWithConfig, with
+// limits that can be changed while the server runs
+type RateLimits struct {
+	classes  []RateClass
+	defaults rateLimiters
+	byClass  []rateLimiters
+}
+
+type rateLimiters struct {
+	anonymous *RateLimiter
+	user      *RateLimiter
+}
+
+// NewRateLimits creates the limiters for cfg
+func NewRateLimits(cfg RateLimitConfig) *RateLimits {
+	newLimiter := func(name string, perMinute int) *RateLimiter {
+		if cfg.Store != nil {
+			return NewSharedRateLimiter(cfg.Store, name, perMinute)
+		}
+		return NewRateLimiter(perMinute)
+	}
+
+	l := &RateLimits{
+		classes: cfg.Classes,
+		defaults: rateLimiters{
+			anonymous: newLimiter("default:ip", cfg.PerMinute),
+			user:      newLimiter("default:user", cfg.UserPerMinute),
+		},
+		byClass: make([]rateLimiters, len(cfg.Classes)),
+	}
+	for i, class := range cfg.Classes {
+		l.byClass[i] = rateLimiters{
+			anonymous: newLimiter(class.Name+":ip", class.PerMinute),
+			user:      newLimiter(class.Name+":user", class.UserPerMinute),
+		}
+	}
+	return l
+}
+
+// SetLimits applies the limits of cfg. Classes are matched by name and keep
+// their routes; classes cfg doesn't name keep their limits, and the store
+// can't be changed.
+func (l *RateLimits) SetLimits(cfg RateLimitConfig) {
+	l.defaults.anonymous.SetLimit(cfg.PerMinute)
+	l.defaults.user.SetLimit(cfg.UserPerMinute)
+	for _, update := range cfg.Classes {
+		for i, class := range l.classes {
+			if class.Name == update.Name {
+				l.byClass[i].anonymous.SetLimit(update.PerMinute)
+				l.byClass[i].user.SetLimit(update.UserPerMinute)
 			}
+		}
+	}
+}
 
-			if !limiter.allow(ip) {
-				w.Header().Set("Content-Type", "application/json")
-				w.Header().Set("Retry-After", "60")
-				w.WriteHeader(http.StatusTooManyRequests)
-				w.Write([]byte(`{"success":false,"error":"Rate limit exceeded. Please try again later."}`))
-				return
+// Close stops the cleanup of every limiter
+func (l *RateLimits) Close() error {
+	for _, limiters := range append([]rateLimiters{l.defaults}, l.byClass...) {
+		limiters.anonymous.Close()
+		limiters.user.Close()
+	}
+	return nil
+}
+
+// Middleware rejects requests over their limit with 429
+func (l *RateLimits) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selected := l.defaults
+		for i, class := range l.classes {
+			if class.matches(r) {
+				selected = l.byClass[i]
+				break
 			}
+		}
 
-			next.ServeHTTP(w, r)
-		})
+		limiter, key := selected.anonymous, clientIP(r)
+		if userID := UserIDFromContext(r.Context()); userID != "" {
+			limiter, key = selected.user, userID
+		}
+
+		result := limiter.check(r.Context(), key)
+		setRateLimitHeaders(w, result, limiter.window)
+
+		if !result.allowed {
+			// Round up so clients retrying on time are not denied again
+			retryAfter := max(1, int(math.Ceil(result.retryAfter.Seconds())))
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(models.APIResponse{
+				Success: false,
+				Error: &models.APIError{
+					Code:       "RATE_LIMITED",
+					Message:    i18n.Localize(w, "Rate limit exceeded. Please try again later."),
+					RequestID:  w.Header().Get("X-Request-ID"),
+					RetryAfter: retryAfter,
+				},
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setRateLimitHeaders advertises the quota both with the widely used
+// X-RateLimit-* headers (reset as a Unix timestamp) and the IETF draft
+// RateLimit-* headers (reset in seconds)
+func setRateLimitHeaders(w http.ResponseWriter, result rateLimitResult, window time.Duration) {
+	resetSeconds := int(math.Ceil(result.reset.Seconds()))
+	limit := strconv.Itoa(result.limit)
+	remaining := strconv.Itoa(result.remaining)
+
+	h := w.Header()
+	h.Set("X-RateLimit-Limit", limit)
+	h.Set("X-RateLimit-Remaining", remaining)
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.reset).Unix(), 10))
+	h.Set("RateLimit-Limit", limit)
+	h.Set("RateLimit-Remaining", remaining)
+	h.Set("RateLimit-Reset", strconv.Itoa(resetSeconds))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", result.limit, int(window.Seconds())))
+}
+
+// clientIP returns the address of the client r came from: the one the IP
+// filter resolved through its trusted proxies, or the connection's.
+// X-Forwarded-For is never read here, since clients can set it to anything.
+func clientIP(r *http.Request) string {
+	if addr, ok := r.Context().Value(clientAddrKey).(netip.Addr); ok {
+		return addr.String()
+	}
+	if addr, err := parseClientAddr(r.RemoteAddr); err == nil {
+		return addr.String()
 	}
+	return r.RemoteAddr
 }
 
 // Recovery recovers from panics and returns a 500 error
 func Recovery(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v\n%s", err, debug.Stack())
+	return RecoveryWithReporter(errortracking.Nop{})(next)
+}
+
+// RecoveryWithReporter recovers from panics like Recovery and reports them,
+// along with 5xx responses, to an error tracker. 503 responses are not
+// reported since they are returned deliberately while the database circuit
+// breaker is open.
+func RecoveryWithReporter(reporter errortracking.Reporter) Middleware {
+	return recovery(reporter, false)
+}
+
+// DebugRecovery recovers from panics like RecoveryWithReporter and also
+// returns the panic and its stack trace in the response. It is meant for
+// development, since stack traces reveal the server's internals.
+func DebugRecovery(reporter errortracking.Reporter) Middleware {
+	return recovery(reporter, true)
+}
 
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusInternalServerError)
-				w.Write([]byte(`{"success":false,"error":"Internal server error"}`))
+func recovery(reporter errortracking.Reporter, exposePanics bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, route := withRouteCapture(r.Context())
+			r = r.WithContext(ctx)
+			wrapped := &responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}
+
+			event := func(status int, panicValue interface{}) errortracking.Event {
+				return errortracking.Event{
+					Panic:     panicValue,
+					Status:    status,
+					Request:   r,
+					RequestID: w.Header().Get("X-Request-ID"),
+					Route:     *route,
+					UserID:    UserIDFromContext(ctx),
+				}
 			}
-		}()
 
-		next.ServeHTTP(w, r)
-	})
+			defer func() {
+				if err := recover(); err != nil {
+					stack := string(debug.Stack())
+					slog.ErrorContext(ctx, "panic recovered",
+						"error", err,
+						"stack", stack,
+					)
+					reporter.Report(ctx, event(http.StatusInternalServerError, err))
+
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					if !exposePanics {
+						w.Write([]byte(`{"success":false,"error":"Internal server error"}`))
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]any{
+						"success": false,
+						"error":   "Internal server error",
+						"panic":   fmt.Sprint(err),
+						"stack":   strings.Split(strings.TrimSpace(stack), "\n"),
+					})
+				}
+			}()
+
+			next.ServeHTTP(wrapped, r)
+
+			if wrapped.statusCode >= 500 && wrapped.statusCode != http.StatusServiceUnavailable {
+				reporter.Report(ctx, event(wrapped.statusCode, nil))
+			}
+		})
+	}
 }
 
 // JWTClaims represents the JWT claims
 type JWTClaims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// TokenUse is "refresh" for refresh tokens, which aren't accepted in
+	// place of access tokens
+	TokenUse string `json:"token_use,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// refreshTokenUse marks refresh tokens
+const refreshTokenUse = "refresh"
+
+// ParseToken validates a token signed with secret and returns its claims
+func ParseToken(secret, tokenString string) (*JWTClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// parseAccessToken validates an access token signed with secret, rejecting
+// refresh tokens
+func parseAccessToken(secret, tokenString string) (*JWTClaims, error) {
+	claims, err := ParseToken(secret, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TokenUse == refreshTokenUse {
+		return nil, errors.New("refresh token used for access")
+	}
+	return claims, nil
+}
+
 // JWTAuth validates JWT tokens
 func JWTAuth(secret string) Middleware {
 	return func(next http.Handler) http.Handler {
@@ -254,31 +744,63 @@ func JWTAuth(secret string) Middleware {
 				return
 			}
 
-			tokenString := parts[1]
-
-			// Parse and validate token
-			token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-				return []byte(secret), nil
-			})
-
+			claims, err := parseAccessToken(secret, parts[1])
 			if err != nil {
 				http.Error(w, `{"success":false,"error":"Invalid token"}`, http.StatusUnauthorized)
 				return
 			}
 
-			claims, ok := token.Claims.(*JWTClaims)
-			if !ok || !token.Valid {
-				http.Error(w, `{"success":false,"error":"Invalid token claims"}`, http.StatusUnauthorized)
+			// Add user info to context
+			ctx := withUserID(r.Context(), claims.UserID)
+			ctx = context.WithValue(ctx, EmailKey, claims.Email)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// OptionalJWTAuth identifies the caller by a token issued by the server's
+// own login, signed with secret, when Keycloak isn't configured. Requests
+// without a valid token, or with a revoked one, go through anonymously;
+// suspended users are rejected like by KeycloakAuthMiddleware.OptionalAuth.
+func OptionalJWTAuth(secret string, revocations *auth.Revocations, suspensions SuspensionChecker) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			claims, err := parseAccessToken(secret, tokenString)
+			if err != nil || claims.UserID == "" {
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Add user info to context
-			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
-			ctx = context.WithValue(ctx, EmailKey, claims.Email)
+			ctx := r.Context()
+			if revocations != nil {
+				revoked, err := revocations.Revoked(ctx, tokenString)
+				if err != nil {
+					slog.WarnContext(ctx, "token revocation check failed", "error", err)
+				}
+				if revoked {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if suspensions != nil {
+				suspended, err := suspensions.Suspended(ctx, claims.UserID)
+				if err != nil {
+					slog.WarnContext(ctx, "suspension check failed", "error", err)
+				}
+				if suspended {
+					respondJSONError(w, http.StatusForbidden, "This account is suspended")
+					return
+				}
+			}
 
+			ctx = withUserID(ctx, claims.UserID)
+			ctx = context.WithValue(ctx, EmailKey, claims.Email)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -286,13 +808,31 @@ func JWTAuth(secret string) Middleware {
 
 // GenerateToken creates a new JWT token
 func GenerateToken(secret, userID, email string, duration time.Duration) (string, error) {
+	return generateToken(secret, userID, email, "", duration)
+}
+
+// GenerateRefreshToken creates a refresh token, which ParseToken accepts
+// but the authentication middleware doesn't
+func GenerateRefreshToken(secret, userID, email string, duration time.Duration) (string, error) {
+	return generateToken(secret, userID, email, refreshTokenUse, duration)
+}
+
+// IsRefreshToken reports whether claims belong to a refresh token
+func IsRefreshToken(claims *JWTClaims) bool {
+	return claims.TokenUse == refreshTokenUse
+}
+
+func generateToken(secret, userID, email, use string, duration time.Duration) (string, error) {
+	now := time.Now()
 	claims := &JWTClaims{
-		UserID: userID,
-		Email:  email,
+		UserID:   userID,
+		Email:    email,
+		TokenUse: use,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
@@ -300,29 +840,25 @@ func GenerateToken(secret, userID, email string, duration time.Duration) (string
 	return token.SignedString([]byte(secret))
 }
 
-// SecurityHeaders adds security headers to responses
+// SecurityHeaders adds security headers to responses using
+// DefaultSecurityHeadersConfig
 func SecurityHeaders(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "DENY")
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-		w.Header().Set("Content-Security-Policy", "default-src 'self'")
-
-		next.ServeHTTP(w, r)
-	})
+	return SecurityHeadersWithConfig(DefaultSecurityHeadersConfig())(next)
 }
 
-// RequestID adds a unique request ID to each request
+// RequestID adds a unique request ID to each request. A well-formed
+// X-Request-ID from an upstream proxy is kept; otherwise a UUIDv7 is
+// generated.
 func RequestID(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
-			requestID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), time.Now().Nanosecond())
+		if !requestid.Valid(requestID) {
+			requestID = requestid.New()
 		}
 
 		w.Header().Set("X-Request-ID", requestID)
-		ctx := context.WithValue(r.Context(), ContextKey("requestID"), requestID)
+		ctx := requestid.NewContext(r.Context(), requestID)
+		logging.AddAttrs(ctx, slog.String("request_id", requestID))
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -1,11 +1,23 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
+
+	"payforwardnow/internal/auth"
+	"payforwardnow/internal/cache"
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/errortracking"
+	"payforwardnow/internal/logging"
+	"payforwardnow/internal/models"
 )
 
 func TestChain(t *testing.T) {
@@ -68,6 +80,44 @@ func TestLogger(t *testing.T) {
 	}
 }
 
+func TestLogger_IncludesRequestScope(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.Config{Format: "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	chained := Chain(handler, Logger, RequestID)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	w := httptest.NewRecorder()
+
+	chained.ServeHTTP(w, req)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a JSON log line, got %q", buf.String())
+	}
+
+	if entry["request_id"] != "req-42" || entry["method"] != "GET" || entry["path"] != "/test" {
+		t.Errorf("expected request attributes, got %v", entry)
+	}
+	if entry["status"] != float64(http.StatusNotFound) || entry["level"] != "WARN" {
+		t.Errorf("expected a warning with status 404, got %v", entry)
+	}
+	if _, ok := entry["latency"]; !ok {
+		t.Error("expected latency")
+	}
+}
+
 func TestCORS(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -93,6 +143,36 @@ func TestCORS(t *testing.T) {
 			requestOrigin:  "https://evil.com",
 			expectOrigin:   "",
 		},
+		{
+			name:           "wildcard subdomain allowed",
+			allowedOrigins: []string{"https://*.payforward.app"},
+			requestOrigin:  "https://pr-123.payforward.app",
+			expectOrigin:   "https://pr-123.payforward.app",
+		},
+		{
+			name:           "wildcard subdomain does not match apex",
+			allowedOrigins: []string{"https://*.payforward.app"},
+			requestOrigin:  "https://payforward.app",
+			expectOrigin:   "",
+		},
+		{
+			name:           "wildcard subdomain does not match suffix",
+			allowedOrigins: []string{"https://*.payforward.app"},
+			requestOrigin:  "https://pr-1.payforward.app.evil.com",
+			expectOrigin:   "",
+		},
+		{
+			name:           "regex allowed",
+			allowedOrigins: []string{`regex:https://pr-\d+\.payforward\.app`},
+			requestOrigin:  "https://pr-42.payforward.app",
+			expectOrigin:   "https://pr-42.payforward.app",
+		},
+		{
+			name:           "regex is anchored",
+			allowedOrigins: []string{`regex:https://pr-\d+\.payforward\.app`},
+			requestOrigin:  "https://pr-42.payforward.app.evil.com",
+			expectOrigin:   "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -139,6 +219,53 @@ func TestCORS_PreflightRequest(t *testing.T) {
 	}
 }
 
+func TestCORS_VaryOrigin(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := CORS([]string{"https://example.com"})(handler)
+
+	for _, origin := range []string{"https://example.com", "https://evil.com", ""} {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+
+		middleware.ServeHTTP(w, req)
+
+		if w.Header().Get("Vary") != "Origin" {
+			t.Errorf("origin %q: expected Vary: Origin, got %q", origin, w.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestNewOriginMatcher_Invalid(t *testing.T) {
+	for _, pattern := range []string{"regex:(", "https://pr-*.payforward.app", "*.payforward.app", "payforward.app", "https://payforward.app/"} {
+		if _, err := NewOriginMatcher([]string{pattern}); err == nil {
+			t.Errorf("expected error for %q", pattern)
+		}
+	}
+}
+
+func TestOriginMatcher_Update(t *testing.T) {
+	m, _ := NewOriginMatcher([]string{"https://a.example"})
+	if err := m.Update([]string{"https://*.b.example"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if m.Match("https://a.example") || !m.Match("https://x.b.example") {
+		t.Error("expected the new patterns to replace the old ones")
+	}
+
+	if err := m.Update([]string{"regex:("}); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+	if !m.Match("https://x.b.example") {
+		t.Error("expected an invalid update to keep the current patterns")
+	}
+}
+
 func TestRateLimit(t *testing.T) {
 	requestsPerMinute := 2
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -170,6 +297,289 @@ func TestRateLimit(t *testing.T) {
 	if !strings.Contains(w.Body.String(), "Rate limit exceeded") {
 		t.Error("expected rate limit error message")
 	}
+
+	// The whole quota was used in this window, so only the next one frees it
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("expected Retry-After within the window, got %q", w.Header().Get("Retry-After"))
+	}
+
+	var response models.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error == nil {
+		t.Fatalf("expected an API error, got %q", w.Body.String())
+	}
+	if response.Error.Code != "RATE_LIMITED" || response.Error.RetryAfter != retryAfter {
+		t.Errorf("expected RATE_LIMITED with retryAfter %d, got %+v", retryAfter, response.Error)
+	}
+}
+
+func TestRateLimiter_RetryAfterFullWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 57, 0, time.UTC)
+	limiter := NewRateLimiter(2)
+	limiter.now = func() time.Time { return now }
+
+	limiter.take("client")
+	limiter.take("client")
+	result := limiter.take("client")
+
+	if result.allowed || result.retryAfter != 3*time.Second {
+		t.Errorf("expected retry when the window resets in 3s, got %+v", result)
+	}
+}
+
+func TestRateLimiter_SlidingWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(10)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		if !limiter.allow("client") {
+			t.Fatalf("request %d: expected to be allowed", i+1)
+		}
+	}
+	if limiter.allow("client") {
+		t.Fatal("expected the 11th request to be rejected")
+	}
+
+	// A quarter into the next window, 75% of the previous window still
+	// counts: 7 used, 3 remaining instead of a full reset
+	now = now.Add(75 * time.Second)
+	result := limiter.take("client")
+	if !result.allowed || result.remaining != 2 {
+		t.Errorf("expected allowed with 2 remaining, got %+v", result)
+	}
+	if result.reset != 45*time.Second {
+		t.Errorf("expected reset in 45s, got %v", result.reset)
+	}
+
+	limiter.take("client")
+	limiter.take("client")
+	result = limiter.take("client")
+	if result.allowed {
+		t.Error("expected the sliding window to reject")
+	}
+	// 3 requests in this window; the previous window's 10 must decay
+	// below 7, which happens 18s into the window
+	if result.retryAfter != 3*time.Second {
+		t.Errorf("expected retry after 3s, got %v", result.retryAfter)
+	}
+
+	// After two idle windows the previous count no longer applies
+	now = now.Add(2 * time.Minute)
+	if result := limiter.take("client"); result.remaining != 9 {
+		t.Errorf("expected a fresh quota, got %+v", result)
+	}
+}
+
+func TestRateLimiter_SharedStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := cache.NewLRU(100)
+
+	// Two instances sharing a store enforce a single limit
+	first := NewSharedRateLimiter(store, "test", 3)
+	second := NewSharedRateLimiter(store, "test", 3)
+	for _, l := range []*RateLimiter{first, second} {
+		l.now = func() time.Time { return now }
+	}
+
+	first.check(ctx, "client")
+	second.check(ctx, "client")
+	if result := first.check(ctx, "client"); !result.allowed || result.remaining != 0 {
+		t.Fatalf("expected the third request to use the last slot, got %+v", result)
+	}
+	if result := second.check(ctx, "client"); result.allowed {
+		t.Fatal("expected the shared limit to reject the fourth request")
+	}
+	if result := first.check(ctx, "other"); !result.allowed {
+		t.Error("expected other clients to have their own quota")
+	}
+
+	// Halfway into the next window, half of the previous 4 still count
+	now = now.Add(90 * time.Second)
+	if result := second.check(ctx, "client"); !result.allowed || result.remaining != 0 {
+		t.Errorf("expected the sliding window to leave one slot, got %+v", result)
+	}
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := RateLimit(5)(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "192.168.1.9:1234"
+	w := httptest.NewRecorder()
+
+	middleware.ServeHTTP(w, req)
+
+	expected := map[string]string{
+		"X-RateLimit-Limit":     "5",
+		"X-RateLimit-Remaining": "4",
+		"RateLimit-Limit":       "5",
+		"RateLimit-Remaining":   "4",
+		"RateLimit-Policy":      "5;w=60",
+	}
+	for header, value := range expected {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s: expected %q, got %q", header, value, got)
+		}
+	}
+
+	if reset := w.Header().Get("RateLimit-Reset"); reset == "" || reset == "0" {
+		t.Errorf("expected a RateLimit-Reset delta, got %q", reset)
+	}
+	if reset := w.Header().Get("X-RateLimit-Reset"); len(reset) < 10 {
+		t.Errorf("expected X-RateLimit-Reset as a Unix timestamp, got %q", reset)
+	}
+}
+
+func TestRateLimitWithConfig_KeysOnUser(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := RateLimitWithConfig(RateLimitConfig{PerMinute: 1, UserPerMinute: 2})(handler)
+
+	send := func(userID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey, userID))
+		}
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Two users behind the same IP each get their own, higher quota
+	for _, user := range []string{"alice", "bob"} {
+		for i := 0; i < 2; i++ {
+			if code := send(user); code != http.StatusOK {
+				t.Errorf("%s request %d: expected %d, got %d", user, i+1, http.StatusOK, code)
+			}
+		}
+		if code := send(user); code != http.StatusTooManyRequests {
+			t.Errorf("%s: expected %d, got %d", user, http.StatusTooManyRequests, code)
+		}
+	}
+
+	// Anonymous requests from that IP are limited separately
+	if code := send(""); code != http.StatusOK {
+		t.Errorf("anonymous: expected %d, got %d", http.StatusOK, code)
+	}
+	if code := send(""); code != http.StatusTooManyRequests {
+		t.Errorf("anonymous: expected %d, got %d", http.StatusTooManyRequests, code)
+	}
+}
+
+func TestRateLimitWithConfig_Classes(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := RateLimitWithConfig(RateLimitConfig{
+		PerMinute:     100,
+		UserPerMinute: 100,
+		Classes: []RateClass{
+			{Name: "auth", PathPrefix: "/api/v1/auth/", PerMinute: 1, UserPerMinute: 1},
+		},
+	})(handler)
+
+	send := func(path string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = "10.0.0.2:1234"
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send("/api/v1/auth/login"); code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, code)
+	}
+	if code := send("/api/v1/auth/login"); code != http.StatusTooManyRequests {
+		t.Errorf("expected auth class to be limited, got %d", code)
+	}
+	if code := send("/api/v1/acts"); code != http.StatusOK {
+		t.Errorf("expected other routes to use the default limit, got %d", code)
+	}
+}
+
+func TestRateLimits_SetLimits(t *testing.T) {
+	limits := NewRateLimits(RateLimitConfig{
+		PerMinute:     1,
+		UserPerMinute: 1,
+		Classes:       []RateClass{{Name: "auth", PathPrefix: "/api/v1/auth/", PerMinute: 1, UserPerMinute: 1}},
+	})
+	handler := limits.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "10.0.0.3:1234"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	send("/api/v1/acts")
+	send("/api/v1/auth/login")
+	if send("/api/v1/acts") != http.StatusTooManyRequests || send("/api/v1/auth/login") != http.StatusTooManyRequests {
+		t.Fatal("expected both limits to be used up")
+	}
+
+	// Raising the limits admits the client again without resetting counts
+	limits.SetLimits(RateLimitConfig{
+		PerMinute:     2,
+		UserPerMinute: 2,
+		Classes:       []RateClass{{Name: "auth", PerMinute: 3, UserPerMinute: 3}},
+	})
+	if code := send("/api/v1/acts"); code != http.StatusOK {
+		t.Errorf("expected the raised default limit to apply, got %d", code)
+	}
+	if code := send("/api/v1/acts"); code != http.StatusTooManyRequests {
+		t.Errorf("expected the earlier requests to still count, got %d", code)
+	}
+	if send("/api/v1/auth/login") != http.StatusOK || send("/api/v1/auth/login") != http.StatusOK {
+		t.Error("expected the raised class limit to apply")
+	}
+}
+
+func TestRateLimitWithConfig_MethodClasses(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	middleware := RateLimitWithConfig(RateLimitConfig{
+		PerMinute:     100,
+		UserPerMinute: 100,
+		Classes: []RateClass{
+			{Name: "writes", PathPrefix: "/api/", Methods: []string{http.MethodPost, http.MethodDelete}, PerMinute: 1, UserPerMinute: 1},
+			{Name: "reads", PathPrefix: "/api/", Methods: []string{http.MethodGet}, PerMinute: 2, UserPerMinute: 2},
+		},
+	})(handler)
+
+	send := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/acts", nil)
+		req.RemoteAddr = "10.0.0.3:1234"
+		w := httptest.NewRecorder()
+		middleware.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(http.MethodPost); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("expected the writes class to apply, got %d with limit %s", w.Code, w.Header().Get("X-RateLimit-Limit"))
+	}
+	if w := send(http.MethodDelete); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected writes to share a quota, got %d", w.Code)
+	}
+	if w := send(http.MethodGet); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("expected reads to have their own quota, got %d with limit %s", w.Code, w.Header().Get("X-RateLimit-Limit"))
+	}
+	if w := send(http.MethodPut); w.Header().Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("expected unmatched methods to use the default limit, got %s", w.Header().Get("X-RateLimit-Limit"))
+	}
 }
 
 func TestRecovery(t *testing.T) {
@@ -229,6 +639,9 @@ func TestJWTAuth_Valid(t *testing.T) {
 		if ctxEmail != email {
 			t.Errorf("expected email %s, got %v", email, ctxEmail)
 		}
+		if dbUserID := database.UserIDFromContext(r.Context()); dbUserID != userID {
+			t.Errorf("expected transactions to be tagged with %s, got %q", userID, dbUserID)
+		}
 
 		w.WriteHeader(http.StatusOK)
 	})
@@ -299,6 +712,63 @@ func TestJWTAuth_InvalidToken(t *testing.T) {
 	}
 }
 
+func TestOptionalJWTAuth(t *testing.T) {
+	secret := "test-secret"
+	token := func(userID string) string {
+		token, err := GenerateToken(secret, userID, userID+"@example.com", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	refresh, err := GenerateRefreshToken(secret, "user-1", "user-1@example.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := GenerateToken("other-secret", "user-1", "user-1@example.com", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked := token("user-2")
+	revocations := auth.NewRevocations(cache.NewLRU(10))
+	revocations.Revoke(context.Background(), revoked, time.Now().Add(time.Hour))
+
+	identify := OptionalJWTAuth(secret, revocations, suspendedUsers{"suspended": true})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(UserIDFromContext(r.Context())))
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantUser      string
+	}{
+		{"no token", "", http.StatusOK, ""},
+		{"valid token", "Bearer " + token("user-1"), http.StatusOK, "user-1"},
+		{"invalid token", "Bearer invalid.token.here", http.StatusOK, ""},
+		{"other secret", "Bearer " + foreign, http.StatusOK, ""},
+		{"refresh token", "Bearer " + refresh, http.StatusOK, ""},
+		{"revoked token", "Bearer " + revoked, http.StatusOK, ""},
+		{"suspended user", "Bearer " + token("suspended"), http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			identify.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.String() != tt.wantUser {
+				t.Errorf("expected user %q, got %q", tt.wantUser, w.Body.String())
+			}
+		})
+	}
+}
+
 func TestSecurityHeaders(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -328,8 +798,8 @@ func TestSecurityHeaders(t *testing.T) {
 
 func TestRequestID(t *testing.T) {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := r.Context().Value(ContextKey("requestID"))
-		if requestID == nil || requestID == "" {
+		requestID := RequestIDFromContext(r.Context())
+		if requestID == "" {
 			t.Error("expected request ID in context")
 		}
 		w.WriteHeader(http.StatusOK)
@@ -351,7 +821,7 @@ func TestRequestID_ExistingID(t *testing.T) {
 	existingID := "existing-request-id"
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := r.Context().Value(ContextKey("requestID"))
+		requestID := RequestIDFromContext(r.Context())
 		if requestID != existingID {
 			t.Errorf("expected request ID %s, got %v", existingID, requestID)
 		}
@@ -382,7 +852,7 @@ func TestRateLimiter_Cleanup(t *testing.T) {
 	}
 
 	for ip := range limiter.visitors {
-		limiter.visitors[ip].lastReset = time.Now().Add(-11 * time.Minute)
+		limiter.visitors[ip].windowStart = time.Now().Add(-11 * time.Minute)
 	}
 
 	limiter.cleanup()
@@ -392,6 +862,21 @@ func TestRateLimiter_Cleanup(t *testing.T) {
 	}
 }
 
+func TestRateLimits_Close(t *testing.T) {
+	limits := NewRateLimits(RateLimitConfig{PerMinute: 10, UserPerMinute: 10, Classes: []RateClass{{Name: "auth", PerMinute: 5, UserPerMinute: 5}}})
+	if err := limits.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case <-limits.byClass[0].user.stop:
+	default:
+		t.Error("expected the cleanup of every limiter to stop")
+	}
+	// Closing twice is harmless, as is closing a shared limiter
+	limits.Close()
+	NewSharedRateLimiter(cache.NewLRU(10), "test", 10).Close()
+}
+
 func TestResponseWrapper(t *testing.T) {
 	w := httptest.NewRecorder()
 	wrapper := &responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}
@@ -406,3 +891,89 @@ func TestResponseWrapper(t *testing.T) {
 		t.Errorf("expected underlying status code %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+func TestRequireAdmin_WithoutKeycloak(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called when admin API is disabled")
+	})
+
+	middleware := RequireAdmin(nil)(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats/retention", nil)
+	w := httptest.NewRecorder()
+
+	middleware.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+type recordingReporter struct {
+	events []errortracking.Event
+}
+
+func (r *recordingReporter) Report(ctx context.Context, event errortracking.Event) {
+	r.events = append(r.events, event)
+}
+
+func (r *recordingReporter) Flush(time.Duration) bool { return true }
+
+func TestRecoveryWithReporter(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /panic/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("GET /unavailable", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("GET /ok", func(w http.ResponseWriter, r *http.Request) {})
+
+	reporter := &recordingReporter{}
+	handler := Chain(TraceRoutes(mux), RecoveryWithReporter(reporter), RequestID)
+
+	for _, path := range []string{"/panic/1", "/fail", "/unavailable", "/ok"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, "user-1"))
+		req.Header.Set("X-Request-ID", "req-"+path)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+	}
+
+	if len(reporter.events) != 2 {
+		t.Fatalf("expected the panic and the 500 to be reported, got %d events", len(reporter.events))
+	}
+
+	panicEvent := reporter.events[0]
+	if panicEvent.Panic != "boom" || panicEvent.Route != "GET /panic/{id}" || panicEvent.UserID != "user-1" || panicEvent.RequestID != "req-/panic/1" {
+		t.Errorf("unexpected panic event %+v", panicEvent)
+	}
+
+	errorEvent := reporter.events[1]
+	if errorEvent.Panic != nil || errorEvent.Status != http.StatusInternalServerError || errorEvent.Route != "GET /fail" {
+		t.Errorf("unexpected error event %+v", errorEvent)
+	}
+}
+
+func TestDebugRecovery(t *testing.T) {
+	handler := DebugRecovery(errortracking.Nop{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var body struct {
+		Panic string   `json:"panic"`
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if w.Code != http.StatusInternalServerError || body.Panic != "boom" || len(body.Stack) == 0 {
+		t.Errorf("expected the panic and its stack trace, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"payforwardnow/internal/database"
+)
+
+// ReadRoutingHeader lets a client choose where the reads of its request go,
+// as "leader" or "replicas"
+const ReadRoutingHeader = "X-Read-Routing"
+
+// ReadRoutingConfig configures ReadRouting
+type ReadRoutingConfig struct {
+	// LeaderPaths are path prefixes whose reads go to the writer, for
+	// endpoints clients call right after a write and expect to reflect it
+	LeaderPaths []string
+	// AllowHeader honours ReadRoutingHeader, which takes precedence over
+	// LeaderPaths. Leave it off if clients can't be trusted not to send all
+	// their reads to the writer.
+	AllowHeader bool
+}
+
+// ReadRouting overrides the database client's read routing for requests
+// matching cfg. Other requests keep the client's default. Writes always go
+// to the writer.
+func ReadRouting(cfg ReadRoutingConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy := ""
+			for _, prefix := range cfg.LeaderPaths {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					policy = database.ReadFromLeader
+					break
+				}
+			}
+			if cfg.AllowHeader {
+				requested := strings.ToLower(strings.TrimSpace(r.Header.Get(ReadRoutingHeader)))
+				if database.ValidReadRouting(requested) == nil {
+					policy = requested
+				}
+			}
+
+			if policy != "" {
+				r = r.WithContext(database.WithReadRouting(r.Context(), policy))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
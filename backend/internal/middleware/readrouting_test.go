@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/database"
+)
+
+func TestReadRouting(t *testing.T) {
+	cfg := ReadRoutingConfig{LeaderPaths: []string{"/api/v1/auth/me"}, AllowHeader: true}
+	tests := []struct {
+		name   string
+		cfg    ReadRoutingConfig
+		path   string
+		header string
+		expect string
+	}{
+		{"default", cfg, "/api/v1/acts", "", ""},
+		{"leader path", cfg, "/api/v1/auth/me", "", database.ReadFromLeader},
+		{"header", cfg, "/api/v1/acts", "Leader", database.ReadFromLeader},
+		{"header overrides path", cfg, "/api/v1/auth/me", "replicas", database.ReadFromReplicas},
+		{"unknown header value", cfg, "/api/v1/acts", "primary", ""},
+		{"header not allowed", ReadRoutingConfig{}, "/api/v1/acts", "leader", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := ReadRouting(tt.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = database.ReadRoutingFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(ReadRoutingHeader, tt.header)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expect {
+				t.Errorf("expected %q, got %q", tt.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouteProfile holds the connection limits of a group of routes. Request
+// timeouts and rate limits, which other middleware enforce, are configured
+// with TimeoutConfig and RateLimitConfig.
+type RouteProfile struct {
+	Name       string
+	PathPrefix string
+	// Methods restricts the profile to these HTTP methods; empty matches all
+	Methods []string
+	// ReadTimeout and WriteTimeout replace the server's deadlines for
+	// reading the request body and writing the response; zero keeps them
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	// MaxBodyBytes rejects larger request bodies with 413; zero allows any
+	// size
+	MaxBodyBytes int64
+}
+
+// matches reports whether r belongs to the profile
+func (p RouteProfile) matches(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, p.PathPrefix) {
+		return false
+	}
+	return len(p.Methods) == 0 || slices.Contains(p.Methods, r.Method)
+}
+
+// RouteProfiles applies the first profile matching each request. Bodies
+// declared larger than the limit are rejected before they are read; others
+// fail once they pass it, which decoding handlers answer with 413.
+func RouteProfiles(profiles []RouteProfile) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			i := slices.IndexFunc(profiles, func(p RouteProfile) bool { return p.matches(r) })
+			if i < 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			profile := profiles[i]
+
+			// Not every ResponseWriter supports deadlines; those that
+			// don't keep the server's
+			rc := http.NewResponseController(w)
+			now := time.Now()
+			if profile.ReadTimeout > 0 {
+				rc.SetReadDeadline(now.Add(profile.ReadTimeout))
+			}
+			if profile.WriteTimeout > 0 {
+				rc.SetWriteDeadline(now.Add(profile.WriteTimeout))
+			}
+
+			if profile.MaxBodyBytes > 0 {
+				if r.ContentLength > profile.MaxBodyBytes {
+					respondAPIError(w, http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE",
+						"The request body must not exceed {limit} bytes", "limit", strconv.FormatInt(profile.MaxBodyBytes, 10))
+					return
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, profile.MaxBodyBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
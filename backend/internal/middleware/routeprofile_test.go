@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteProfiles_BodyLimit(t *testing.T) {
+	var readErr error
+	handler := RouteProfiles([]RouteProfile{
+		{Name: "auth", PathPrefix: "/api/v1/auth/", MaxBodyBytes: 8},
+		{Name: "write", PathPrefix: "/api/", Methods: []string{http.MethodPost}, MaxBodyBytes: 64},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	serve := func(path, body string, chunked bool) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		readErr = nil
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve("/api/v1/auth/login", strings.Repeat("x", 9), false); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected a declared oversized body to get 413, got %d", code)
+	}
+	if code := serve("/api/v1/acts", strings.Repeat("x", 9), false); code != http.StatusOK || readErr != nil {
+		t.Errorf("expected the write limit to apply to other routes, got %d, %v", code, readErr)
+	}
+
+	serve("/api/v1/auth/login", strings.Repeat("x", 9), true)
+	var tooLarge *http.MaxBytesError
+	if !errors.As(readErr, &tooLarge) {
+		t.Errorf("expected reading an undeclared oversized body to fail, got %v", readErr)
+	}
+}
+
+func TestRouteProfiles_Unmatched(t *testing.T) {
+	called := false
+	handler := RouteProfiles([]RouteProfile{
+		{Name: "write", PathPrefix: "/api/", Methods: []string{http.MethodPost}, MaxBodyBytes: 1},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/api/v1/acts/1", strings.NewReader("long body")))
+	if !called || w.Code != http.StatusOK {
+		t.Errorf("expected a request matching no profile to pass, got %d", w.Code)
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseCSP(t *testing.T) {
+	csp, err := ParseCSP("default-src 'self';  IMG-SRC 'self' data: ; upgrade-insecure-requests")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "default-src 'self'; img-src 'self' data:; upgrade-insecure-requests"
+	if got := csp.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if _, err := ParseCSP("'self' default-src"); err == nil {
+		t.Error("expected error for a source in place of a directive")
+	}
+}
+
+func TestCSP_Merge(t *testing.T) {
+	base := CSP{"default-src": {"'self'"}, "img-src": {"'self'"}}
+	merged := base.Merge(CSP{"img-src": {"*"}})
+
+	if got := merged.String(); got != "default-src 'self'; img-src *" {
+		t.Errorf("unexpected policy %q", got)
+	}
+	if base["img-src"][0] != "'self'" {
+		t.Error("expected Merge to leave the receiver unchanged")
+	}
+}
+
+func TestSecurityHeadersWithConfig(t *testing.T) {
+	cfg := DefaultSecurityHeadersConfig()
+	cfg.CSP = cfg.CSP.Merge(CSP{}.Set("script-src", "'self'", "https://cdn.example.com"))
+	cfg.FrameAncestors = []string{"'self'"}
+	cfg.HSTS = true
+	cfg.HSTSMaxAge = 24 * time.Hour
+	cfg.HSTSPreload = true
+
+	handler := SecurityHeadersWithConfig(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	expected := map[string]string{
+		"Content-Security-Policy":   "default-src 'self'; frame-ancestors 'self'; script-src 'self' https://cdn.example.com",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Strict-Transport-Security": "max-age=86400; includeSubDomains; preload",
+	}
+	for header, value := range expected {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("expected %s %q, got %q", header, value, got)
+		}
+	}
+}
+
+func TestSecurityHeaders_Defaults(t *testing.T) {
+	handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := w.Header().Get("Content-Security-Policy"); got != "default-src 'self'; frame-ancestors 'none'" {
+		t.Errorf("unexpected default policy %q", got)
+	}
+	if w.Header().Get("X-Frame-Options") != "DENY" {
+		t.Error("expected X-Frame-Options DENY")
+	}
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("expected HSTS to be off by default")
+	}
+}
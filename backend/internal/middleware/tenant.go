@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"payforwardnow/internal/database"
+)
+
+// TenantHeader selects the tenant of a request when no other header is
+// configured
+const TenantHeader = "X-Tenant"
+
+// TenantConfig configures Tenant
+type TenantConfig struct {
+	// Databases maps tenant IDs to the Neo4j database holding their data.
+	// Only listed tenants are served, so clients can't reach arbitrary
+	// databases.
+	Databases map[string]string
+	// Header carries the tenant ID; it defaults to TenantHeader
+	Header string
+	// BaseDomain, when set, also resolves the tenant from the subdomain of
+	// the request host, so acme.example.org selects tenant "acme" for a base
+	// domain of example.org. The header takes precedence.
+	BaseDomain string
+}
+
+// Tenant routes each request's database sessions to its tenant's database.
+// Requests naming no tenant use the default database; requests naming an
+// unknown one get a 404.
+func Tenant(cfg TenantConfig) Middleware {
+	header := cfg.Header
+	if header == "" {
+		header = TenantHeader
+	}
+	baseDomain := strings.ToLower(strings.Trim(cfg.BaseDomain, "."))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := strings.ToLower(strings.TrimSpace(r.Header.Get(header)))
+			if tenant == "" && baseDomain != "" {
+				tenant = subdomain(r.Host, baseDomain)
+			}
+			if tenant == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			name, ok := cfg.Databases[tenant]
+			if !ok {
+				respondAPIError(w, http.StatusNotFound, "UNKNOWN_TENANT", "Unknown tenant")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(database.WithDatabase(r.Context(), name)))
+		})
+	}
+}
+
+// subdomain returns the single label in front of baseDomain in host, or ""
+// if host is baseDomain itself or outside it
+func subdomain(host, baseDomain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	label, ok := strings.CutSuffix(host, "."+baseDomain)
+	if !ok || label == "" || strings.Contains(label, ".") || label == "www" {
+		return ""
+	}
+	return label
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"payforwardnow/internal/database"
+)
+
+func TestTenant(t *testing.T) {
+	cfg := TenantConfig{
+		Databases:  map[string]string{"acme": "acme_db", "oslo": "community_oslo"},
+		BaseDomain: "payforward.example",
+	}
+
+	tests := []struct {
+		name           string
+		host           string
+		header         string
+		expectStatus   int
+		expectDatabase string
+	}{
+		{"no tenant", "api.example.org", "", http.StatusOK, ""},
+		{"header", "api.example.org", "ACME", http.StatusOK, "acme_db"},
+		{"subdomain", "oslo.payforward.example:8080", "", http.StatusOK, "community_oslo"},
+		{"header wins over subdomain", "oslo.payforward.example", "acme", http.StatusOK, "acme_db"},
+		{"base domain", "payforward.example", "", http.StatusOK, ""},
+		{"www", "www.payforward.example", "", http.StatusOK, ""},
+		{"nested subdomain", "a.oslo.payforward.example", "", http.StatusOK, ""},
+		{"unknown header", "api.example.org", "evil", http.StatusNotFound, ""},
+		{"unknown subdomain", "evil.payforward.example", "", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := Tenant(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = database.DatabaseFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil)
+			req.Host = tt.host
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectStatus {
+				t.Fatalf("expected status %d, got %d", tt.expectStatus, w.Code)
+			}
+			if got != tt.expectDatabase {
+				t.Errorf("expected database %q, got %q", tt.expectDatabase, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/i18n"
+	"payforwardnow/internal/models"
+)
+
+// TimeoutConfig configures request timeouts
+type TimeoutConfig struct {
+	// Default applies to routes without a more specific timeout
+	Default time.Duration
+	// Routes override the timeout for routes matching a path prefix. The
+	// first matching route wins.
+	Routes []RouteTimeout
+}
+
+// RouteTimeout is the timeout for a group of routes. A zero Timeout
+// disables it, which streaming endpoints such as Server-Sent Events need.
+type RouteTimeout struct {
+	PathPrefix string
+	// Methods restricts the timeout to these HTTP methods; empty matches all
+	Methods []string
+	Timeout time.Duration
+}
+
+// matches reports whether r belongs to the group
+func (t RouteTimeout) matches(r *http.Request) bool {
+	if !strings.HasPrefix(r.URL.Path, t.PathPrefix) {
+		return false
+	}
+	return len(t.Methods) == 0 || slices.Contains(t.Methods, r.Method)
+}
+
+// Timeout puts a deadline on the request context, so database calls made
+// with it are cancelled once the route's timeout passes, and responds with
+// 504 if the handler has not finished by then. Responses are buffered until
+// the handler returns.
+func Timeout(cfg TimeoutConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := cfg.Default
+			for _, route := range cfg.Routes {
+				if route.matches(r) {
+					timeout = route.Timeout
+					break
+				}
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			// Handlers see the headers set by earlier middleware, such as
+			// X-Request-ID and Content-Language
+			tw := &timeoutWriter{header: w.Header().Clone()}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.flushTo(w)
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+
+				// Nobody is listening when the client went away
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return
+				}
+
+				slog.WarnContext(r.Context(), "request timed out", "timeout", timeout)
+				respondAPIError(w, http.StatusGatewayTimeout, "TIMEOUT",
+					"The request took too long to complete")
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers a response until the handler finishes, discarding
+// it if the request times out first
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	code        int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// flushTo copies the buffered response to w; tw.mu must be held
+func (tw *timeoutWriter) flushTo(w http.ResponseWriter) {
+	dst := w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	if !tw.wroteHeader {
+		tw.code = http.StatusOK
+	}
+	w.WriteHeader(tw.code)
+	w.Write(tw.buf.Bytes())
+}
+
+// respondAPIError writes err in the same envelope the handlers use
+func respondAPIError(w http.ResponseWriter, status int, code, message string, args ...string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: false,
+		Error: &models.APIError{
+			Code:      code,
+			Message:   i18n.Localize(w, message, args...),
+			RequestID: w.Header().Get("X-Request-ID"),
+		},
+	})
+}
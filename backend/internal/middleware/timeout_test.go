@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+func TestTimeout_Exceeded(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	middleware := Timeout(TimeoutConfig{Default: 10 * time.Millisecond})(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil)
+	w := httptest.NewRecorder()
+
+	middleware.ServeHTTP(w, req)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status 504, got %d", w.Code)
+	}
+
+	var response models.APIResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Success || response.Error == nil || response.Error.Code != "TIMEOUT" {
+		t.Errorf("expected TIMEOUT error, got %+v", response)
+	}
+}
+
+func TestTimeout_CompletesInTime(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("expected the request context to have a deadline")
+		}
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	middleware := Timeout(TimeoutConfig{Default: time.Second})(handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/acts", nil)
+	w := httptest.NewRecorder()
+
+	middleware.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated || w.Body.String() != "created" || w.Header().Get("X-Test") != "yes" {
+		t.Errorf("expected buffered response to be passed through, got %d %q %v", w.Code, w.Body.String(), w.Header())
+	}
+}
+
+func TestTimeout_RouteOverrides(t *testing.T) {
+	var hasDeadline bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasDeadline = r.Context().Deadline()
+	})
+
+	middleware := Timeout(TimeoutConfig{
+		Default: time.Second,
+		Routes:  []RouteTimeout{{PathPrefix: "/api/v1/stats/stream", Timeout: 0}},
+	})(handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/stream", nil)
+	middleware.ServeHTTP(httptest.NewRecorder(), req)
+
+	if hasDeadline {
+		t.Error("expected no deadline for a route with timeouts disabled")
+	}
+}
+
+func TestTimeout_PropagatesPanic(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	middleware := Recovery(Timeout(TimeoutConfig{Default: time.Second})(handler))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil)
+	w := httptest.NewRecorder()
+
+	middleware.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected panic to reach Recovery, got %d", w.Code)
+	}
+}
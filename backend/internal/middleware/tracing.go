@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"payforwardnow/internal/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "payforwardnow/internal/middleware"
+
+// Tracing starts a server span for each request, continuing any trace
+// propagated by the caller. The trace ID is added to the logging scope so
+// log lines can be correlated with traces.
+func Tracing(next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("user_agent.original", r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.HasTraceID() {
+			logging.AddAttrs(ctx, slog.String("trace_id", sc.TraceID().String()))
+		}
+
+		wrapped := &responseWrapper{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapped.statusCode))
+		if wrapped.statusCode >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
+	})
+}
+
+type routeKey struct{}
+
+// withRouteCapture returns a context in which TraceRoutes records the
+// matched route pattern, for middleware running outside the mux
+func withRouteCapture(ctx context.Context) (context.Context, *string) {
+	route := new(string)
+	return context.WithValue(ctx, routeKey{}, route), route
+}
+
+// TraceRoutes names the current request span after the matched route
+// pattern, such as "GET /api/v1/acts/{id}", instead of the raw path, and
+// records the pattern for outer middleware. It must wrap the ServeMux
+// directly, since the mux records the pattern on the request it receives.
+func TraceRoutes(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Deferred so the route is known even if the handler panics
+		defer func() {
+			if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+				*route = r.Pattern
+			}
+
+			if r.Pattern != "" {
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Pattern)
+				span.SetAttributes(attribute.String("http.route", r.Pattern))
+			}
+		}()
+
+		mux.ServeHTTP(w, r)
+	})
+}
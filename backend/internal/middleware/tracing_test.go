@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	var handlerSpan trace.SpanContext
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	handler := Tracing(TraceRoutes(mux))
+
+	req := httptest.NewRequest(http.MethodGet, "/items/42", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "GET /items/{id}" {
+		t.Errorf("expected span named after the route, got %q", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("expected server span, got %v", span.SpanKind())
+	}
+	if span.SpanContext().SpanID() != handlerSpan.SpanID() {
+		t.Error("expected the span to be available to the handler")
+	}
+
+	found := false
+	for _, attr := range span.Attributes() {
+		if attr.Key == attribute.Key("http.response.status_code") && attr.Value.AsInt64() == http.StatusInternalServerError {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected status code attribute, got %v", span.Attributes())
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// APIVersion describes one version of the API served under /api/{Name}/
+type APIVersion struct {
+	// Name is the path segment, such as "v1"
+	Name string
+	// Deprecated is when the version was deprecated; zero if it is not
+	Deprecated time.Time
+	// Sunset is when the version will stop being served; zero if unknown
+	Sunset time.Time
+	// Link points to migration documentation for deprecated versions
+	Link string
+}
+
+// vendorMediaType matches Accept values such as
+// "application/vnd.payforward.v2+json"
+var vendorMediaType = regexp.MustCompile(`application/vnd\.payforward\.(v\d+)\+json`)
+
+// APIVersions is a registry of API versions. Its middleware tags responses
+// with the version that served them, adds Deprecation, Sunset and Link
+// headers for deprecated versions, and routes unversioned /api/ paths to the
+// version requested in the Accept header, or to the default version.
+type APIVersions struct {
+	versions       map[string]APIVersion
+	defaultVersion string
+	unversioned    []string
+}
+
+// NewAPIVersions creates a registry. defaultVersion serves unversioned
+// requests that don't ask for a version. Paths under the unversioned
+// prefixes, such as "/api/health", are never rewritten.
+func NewAPIVersions(defaultVersion string, unversioned []string, versions ...APIVersion) *APIVersions {
+	registry := &APIVersions{
+		versions:       make(map[string]APIVersion, len(versions)),
+		defaultVersion: defaultVersion,
+		unversioned:    unversioned,
+	}
+	for _, version := range versions {
+		registry.versions[version.Name] = version
+	}
+	return registry
+}
+
+// Prefix returns the path prefix of a version, such as "/api/v2"
+func (v *APIVersions) Prefix(version string) string {
+	return "/api/" + version
+}
+
+// Middleware resolves the API version of each request
+func (v *APIVersions) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/")
+		if !ok || v.isUnversioned(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		segment, _, _ := strings.Cut(rest, "/")
+		version, known := v.versions[segment]
+		if !known {
+			// Unversioned path: negotiate through the Accept header
+			name := v.defaultVersion
+			if match := vendorMediaType.FindStringSubmatch(r.Header.Get("Accept")); match != nil {
+				name = match[1]
+			}
+			w.Header().Add("Vary", "Accept")
+
+			version, known = v.versions[name]
+			if !known {
+				respondAPIError(w, http.StatusNotAcceptable, "UNSUPPORTED_VERSION", "Unsupported API version {version}", "version", name)
+				return
+			}
+
+			r = r.Clone(r.Context())
+			r.URL.Path = v.Prefix(version.Name) + "/" + rest
+			r.URL.RawPath = ""
+		}
+
+		w.Header().Set("API-Version", version.Name)
+		if !version.Deprecated.IsZero() {
+			// RFC 9745 structured date
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(version.Deprecated.Unix(), 10))
+			if version.Link != "" {
+				w.Header().Add("Link", "<"+version.Link+`>; rel="deprecation"`)
+			}
+		}
+		if !version.Sunset.IsZero() {
+			w.Header().Set("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (v *APIVersions) isUnversioned(path string) bool {
+	for _, prefix := range v.unversioned {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
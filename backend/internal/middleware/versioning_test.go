@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIVersions_Middleware(t *testing.T) {
+	var servedPath string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		servedPath = r.URL.Path
+	})
+
+	deprecated := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	versions := NewAPIVersions("v1", []string{"/api/health"},
+		APIVersion{Name: "v1", Deprecated: deprecated, Sunset: sunset, Link: "https://docs.example.com/v2"},
+		APIVersion{Name: "v2"},
+	)
+	middleware := versions.Middleware(handler)
+
+	tests := []struct {
+		name       string
+		path       string
+		accept     string
+		expected   string
+		version    string
+		deprecated bool
+	}{
+		{"explicit v1", "/api/v1/acts", "", "/api/v1/acts", "v1", true},
+		{"explicit v2", "/api/v2/acts", "", "/api/v2/acts", "v2", false},
+		{"unversioned default", "/api/acts/1", "", "/api/v1/acts/1", "v1", true},
+		{"negotiated", "/api/acts", "application/vnd.payforward.v2+json", "/api/v2/acts", "v2", false},
+		{"path wins over accept", "/api/v1/acts", "application/vnd.payforward.v2+json", "/api/v1/acts", "v1", true},
+		{"unversioned route", "/api/health", "", "/api/health", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			middleware.ServeHTTP(w, req)
+
+			if servedPath != tt.expected {
+				t.Errorf("expected path %s, got %s", tt.expected, servedPath)
+			}
+			if got := w.Header().Get("API-Version"); got != tt.version {
+				t.Errorf("expected API-Version %q, got %q", tt.version, got)
+			}
+			if got := w.Header().Get("Deprecation") != ""; got != tt.deprecated {
+				t.Errorf("expected deprecated %v, got headers %v", tt.deprecated, w.Header())
+			}
+		})
+	}
+}
+
+func TestAPIVersions_DeprecationHeaders(t *testing.T) {
+	deprecated := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	versions := NewAPIVersions("v1", nil,
+		APIVersion{Name: "v1", Deprecated: deprecated, Sunset: sunset, Link: "https://docs.example.com/v2"},
+	)
+
+	w := httptest.NewRecorder()
+	versions.Middleware(http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/acts", nil))
+
+	if got := w.Header().Get("Deprecation"); got != "@1735689600" {
+		t.Errorf("unexpected Deprecation header %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Tue, 01 Jul 2025 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `<https://docs.example.com/v2>; rel="deprecation"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+}
+
+func TestAPIVersions_UnsupportedVersion(t *testing.T) {
+	versions := NewAPIVersions("v1", nil, APIVersion{Name: "v1"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/acts", nil)
+	req.Header.Set("Accept", "application/vnd.payforward.v9+json")
+	w := httptest.NewRecorder()
+
+	versions.Middleware(http.NotFoundHandler()).ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("expected status 406, got %d", w.Code)
+	}
+}
@@ -0,0 +1,16 @@
+DROP INDEX act_search IF EXISTS;
+DROP INDEX audit_entity_id IF EXISTS;
+DROP INDEX audit_actor_id IF EXISTS;
+DROP INDEX audit_created_at IF EXISTS;
+DROP INDEX chain_created_at IF EXISTS;
+DROP INDEX act_status IF EXISTS;
+DROP INDEX act_type IF EXISTS;
+DROP INDEX act_created_at IF EXISTS;
+DROP INDEX user_location IF EXISTS;
+DROP INDEX user_created_at IF EXISTS;
+
+DROP CONSTRAINT testimonial_id IF EXISTS;
+DROP CONSTRAINT chain_id IF EXISTS;
+DROP CONSTRAINT act_id IF EXISTS;
+DROP CONSTRAINT user_email IF EXISTS;
+DROP CONSTRAINT user_id IF EXISTS;
@@ -0,0 +1,26 @@
+// Uniqueness constraints for entity IDs and user emails
+CREATE CONSTRAINT user_id IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE;
+CREATE CONSTRAINT user_email IF NOT EXISTS FOR (u:User) REQUIRE u.email IS UNIQUE;
+CREATE CONSTRAINT act_id IF NOT EXISTS FOR (a:Act) REQUIRE a.id IS UNIQUE;
+CREATE CONSTRAINT chain_id IF NOT EXISTS FOR (c:Chain) REQUIRE c.id IS UNIQUE;
+CREATE CONSTRAINT testimonial_id IF NOT EXISTS FOR (t:Testimonial) REQUIRE t.id IS UNIQUE;
+
+// User indexes
+CREATE INDEX user_created_at IF NOT EXISTS FOR (u:User) ON (u.createdAt);
+CREATE INDEX user_location IF NOT EXISTS FOR (u:User) ON (u.location);
+
+// Act indexes
+CREATE INDEX act_created_at IF NOT EXISTS FOR (a:Act) ON (a.createdAt);
+CREATE INDEX act_type IF NOT EXISTS FOR (a:Act) ON (a.type);
+CREATE INDEX act_status IF NOT EXISTS FOR (a:Act) ON (a.status);
+
+// Chain indexes
+CREATE INDEX chain_created_at IF NOT EXISTS FOR (c:Chain) ON (c.createdAt);
+
+// Audit indexes
+CREATE INDEX audit_created_at IF NOT EXISTS FOR (e:AuditEvent) ON (e.createdAt);
+CREATE INDEX audit_actor_id IF NOT EXISTS FOR (e:AuditEvent) ON (e.actorId);
+CREATE INDEX audit_entity_id IF NOT EXISTS FOR (e:AuditEvent) ON (e.entityId);
+
+// Full-text search
+CREATE FULLTEXT INDEX act_search IF NOT EXISTS FOR (a:Act) ON EACH [a.title, a.description];
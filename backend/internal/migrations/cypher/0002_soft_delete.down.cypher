@@ -0,0 +1,3 @@
+DROP INDEX testimonial_deleted_at IF EXISTS;
+DROP INDEX act_deleted_at IF EXISTS;
+DROP INDEX user_deleted_at IF EXISTS;
@@ -0,0 +1,4 @@
+// Soft delete: the purge job looks up entities by deletion time
+CREATE INDEX user_deleted_at IF NOT EXISTS FOR (u:User) ON (u.deletedAt);
+CREATE INDEX act_deleted_at IF NOT EXISTS FOR (a:Act) ON (a.deletedAt);
+CREATE INDEX testimonial_deleted_at IF NOT EXISTS FOR (t:Testimonial) ON (t.deletedAt);
@@ -0,0 +1,2 @@
+DROP INDEX notification_created_at IF EXISTS;
+DROP CONSTRAINT notification_id IF EXISTS;
@@ -0,0 +1,3 @@
+// Notifications are listed per user, newest first
+CREATE CONSTRAINT notification_id IF NOT EXISTS FOR (n:Notification) REQUIRE n.id IS UNIQUE;
+CREATE INDEX notification_created_at IF NOT EXISTS FOR (n:Notification) ON (n.createdAt);
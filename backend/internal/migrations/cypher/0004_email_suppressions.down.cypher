@@ -0,0 +1 @@
+DROP CONSTRAINT email_suppression_email IF EXISTS;
@@ -0,0 +1,2 @@
+// Addresses that bounced or asked not to be emailed, looked up before every send
+CREATE CONSTRAINT email_suppression_email IF NOT EXISTS FOR (s:EmailSuppression) REQUIRE s.email IS UNIQUE;
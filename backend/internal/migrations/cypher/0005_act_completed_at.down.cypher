@@ -0,0 +1 @@
+DROP INDEX act_completed_at IF EXISTS;
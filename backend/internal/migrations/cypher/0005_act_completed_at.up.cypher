@@ -0,0 +1,2 @@
+// The activity stream replays acts in the order they were completed
+CREATE INDEX act_completed_at IF NOT EXISTS FOR (a:Act) ON (a.completedAt);
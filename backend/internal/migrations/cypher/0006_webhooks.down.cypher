@@ -0,0 +1,2 @@
+DROP INDEX webhook_delivery_created_at IF EXISTS;
+DROP CONSTRAINT webhook_id IF EXISTS;
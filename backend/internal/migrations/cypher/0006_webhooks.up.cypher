@@ -0,0 +1,4 @@
+// Webhook subscriptions and their delivery log, listed newest first and
+// pruned by age
+CREATE CONSTRAINT webhook_id IF NOT EXISTS FOR (w:Webhook) REQUIRE w.id IS UNIQUE;
+CREATE INDEX webhook_delivery_created_at IF NOT EXISTS FOR (d:WebhookDelivery) ON (d.createdAt);
@@ -0,0 +1,4 @@
+DROP CONSTRAINT outbox_sequence_name IF EXISTS;
+DROP INDEX outbox_event_created_at IF EXISTS;
+DROP INDEX outbox_event_sequence IF EXISTS;
+DROP CONSTRAINT outbox_event_id IF EXISTS;
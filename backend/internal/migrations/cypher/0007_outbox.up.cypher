@@ -0,0 +1,6 @@
+// The domain event outbox, read in sequence order by the relay and pruned
+// by age, and the counter numbering its events
+CREATE CONSTRAINT outbox_event_id IF NOT EXISTS FOR (e:OutboxEvent) REQUIRE e.id IS UNIQUE;
+CREATE INDEX outbox_event_sequence IF NOT EXISTS FOR (e:OutboxEvent) ON (e.sequence);
+CREATE INDEX outbox_event_created_at IF NOT EXISTS FOR (e:OutboxEvent) ON (e.createdAt);
+CREATE CONSTRAINT outbox_sequence_name IF NOT EXISTS FOR (c:OutboxSequence) REQUIRE c.name IS UNIQUE;
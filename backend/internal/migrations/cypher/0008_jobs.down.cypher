@@ -0,0 +1,4 @@
+DROP CONSTRAINT job_lock_name IF EXISTS;
+DROP INDEX job_finished_at IF EXISTS;
+DROP INDEX job_status_run_at IF EXISTS;
+DROP CONSTRAINT job_id IF EXISTS;
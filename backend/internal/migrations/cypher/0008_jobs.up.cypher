@@ -0,0 +1,6 @@
+// The background job queue, claimed by status and due time and pruned once
+// finished, and the node serializing claims
+CREATE CONSTRAINT job_id IF NOT EXISTS FOR (j:Job) REQUIRE j.id IS UNIQUE;
+CREATE INDEX job_status_run_at IF NOT EXISTS FOR (j:Job) ON (j.status, j.runAt);
+CREATE INDEX job_finished_at IF NOT EXISTS FOR (j:Job) ON (j.finishedAt);
+CREATE CONSTRAINT job_lock_name IF NOT EXISTS FOR (l:JobLock) REQUIRE l.name IS UNIQUE;
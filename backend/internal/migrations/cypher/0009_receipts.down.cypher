@@ -0,0 +1,3 @@
+DROP CONSTRAINT receipt_sequence_year IF EXISTS;
+DROP INDEX receipt_donor_id IF EXISTS;
+DROP CONSTRAINT receipt_act_id IF EXISTS;
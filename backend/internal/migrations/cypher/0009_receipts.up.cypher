@@ -0,0 +1,5 @@
+// Donation receipts, one per act, and the counters numbering them per tax
+// year
+CREATE CONSTRAINT receipt_act_id IF NOT EXISTS FOR (r:Receipt) REQUIRE r.actId IS UNIQUE;
+CREATE INDEX receipt_donor_id IF NOT EXISTS FOR (r:Receipt) ON (r.donorId);
+CREATE CONSTRAINT receipt_sequence_year IF NOT EXISTS FOR (c:ReceiptSequence) REQUIRE c.year IS UNIQUE;
@@ -0,0 +1,3 @@
+DROP INDEX pledge_status_release_at IF EXISTS;
+DROP CONSTRAINT pledge_act_id IF EXISTS;
+DROP CONSTRAINT pledge_id IF EXISTS;
@@ -0,0 +1,5 @@
+// Pledges, one per act, looked up by status for the admin list and the
+// settlement sweep
+CREATE CONSTRAINT pledge_id IF NOT EXISTS FOR (p:Pledge) REQUIRE p.id IS UNIQUE;
+CREATE CONSTRAINT pledge_act_id IF NOT EXISTS FOR (p:Pledge) REQUIRE p.actId IS UNIQUE;
+CREATE INDEX pledge_status_release_at IF NOT EXISTS FOR (p:Pledge) ON (p.status, p.releaseAt);
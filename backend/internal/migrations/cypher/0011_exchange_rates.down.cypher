@@ -0,0 +1 @@
+DROP CONSTRAINT exchange_rate_currency IF EXISTS;
@@ -0,0 +1,3 @@
+// Exchange rates, one per currency, matched by an act's currency when its
+// value is converted to the base currency
+CREATE CONSTRAINT exchange_rate_currency IF NOT EXISTS FOR (x:ExchangeRate) REQUIRE x.currency IS UNIQUE;
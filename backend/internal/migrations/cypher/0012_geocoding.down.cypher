@@ -0,0 +1 @@
+DROP INDEX act_position IF EXISTS;
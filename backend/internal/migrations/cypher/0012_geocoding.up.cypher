@@ -0,0 +1,2 @@
+// Geocoded positions of acts, searched by distance for nearby acts
+CREATE POINT INDEX act_position IF NOT EXISTS FOR (a:Act) ON (a.position);
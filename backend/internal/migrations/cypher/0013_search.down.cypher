@@ -0,0 +1,3 @@
+DROP INDEX testimonial_search IF EXISTS;
+DROP INDEX user_search IF EXISTS;
+DROP INDEX chain_search IF EXISTS;
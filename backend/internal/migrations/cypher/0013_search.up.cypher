@@ -0,0 +1,4 @@
+// Full-text indexes searched along with act_search by /api/v1/search
+CREATE FULLTEXT INDEX chain_search IF NOT EXISTS FOR (c:Chain) ON EACH [c.name, c.description];
+CREATE FULLTEXT INDEX user_search IF NOT EXISTS FOR (u:User) ON EACH [u.name, u.bio];
+CREATE FULLTEXT INDEX testimonial_search IF NOT EXISTS FOR (t:Testimonial) ON EACH [t.impact, t.story];
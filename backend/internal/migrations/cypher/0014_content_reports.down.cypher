@@ -0,0 +1,2 @@
+DROP INDEX content_report_status_created_at IF EXISTS;
+DROP CONSTRAINT content_report_id IF EXISTS;
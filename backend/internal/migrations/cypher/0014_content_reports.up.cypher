@@ -0,0 +1,3 @@
+// Content reports filed by users, listed by status for the admin queue
+CREATE CONSTRAINT content_report_id IF NOT EXISTS FOR (r:ContentReport) REQUIRE r.id IS UNIQUE;
+CREATE INDEX content_report_status_created_at IF NOT EXISTS FOR (r:ContentReport) ON (r.status, r.createdAt);
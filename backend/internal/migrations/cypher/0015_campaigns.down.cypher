@@ -0,0 +1,2 @@
+DROP INDEX campaign_starts_at IF EXISTS;
+DROP CONSTRAINT campaign_id IF EXISTS;
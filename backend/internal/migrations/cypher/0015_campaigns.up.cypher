@@ -0,0 +1,3 @@
+// Campaigns, listed by when they start
+CREATE CONSTRAINT campaign_id IF NOT EXISTS FOR (c:Campaign) REQUIRE c.id IS UNIQUE;
+CREATE INDEX campaign_starts_at IF NOT EXISTS FOR (c:Campaign) ON (c.startsAt);
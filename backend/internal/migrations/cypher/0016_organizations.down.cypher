@@ -0,0 +1,4 @@
+DROP INDEX org_invitation_organization_id IF EXISTS;
+DROP CONSTRAINT org_invitation_token_hash IF EXISTS;
+DROP CONSTRAINT org_invitation_id IF EXISTS;
+DROP CONSTRAINT organization_id IF EXISTS;
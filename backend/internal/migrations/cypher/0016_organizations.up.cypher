@@ -0,0 +1,6 @@
+// Organizations, and the invitations to join them, looked up by the hash of
+// their token when accepted and listed per organization
+CREATE CONSTRAINT organization_id IF NOT EXISTS FOR (o:Organization) REQUIRE o.id IS UNIQUE;
+CREATE CONSTRAINT org_invitation_id IF NOT EXISTS FOR (i:OrgInvitation) REQUIRE i.id IS UNIQUE;
+CREATE CONSTRAINT org_invitation_token_hash IF NOT EXISTS FOR (i:OrgInvitation) REQUIRE i.tokenHash IS UNIQUE;
+CREATE INDEX org_invitation_organization_id IF NOT EXISTS FOR (i:OrgInvitation) ON (i.organizationId);
@@ -0,0 +1,326 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Kinds of schema object
+const (
+	KindConstraint = "constraint"
+	KindIndex      = "index"
+)
+
+// SchemaObject is a constraint or index, as created by a migration or
+// listed by SHOW CONSTRAINTS and SHOW INDEXES
+type SchemaObject struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Type is Neo4j's name for the kind of constraint or index, such as
+	// UNIQUENESS, RANGE or FULLTEXT
+	Type       string   `json:"type"`
+	Labels     []string `json:"labels"`
+	Properties []string `json:"properties"`
+}
+
+func (o SchemaObject) String() string {
+	return fmt.Sprintf("%s %s (%s on %s(%s))", o.Kind, o.Name, o.Type,
+		strings.Join(o.Labels, "|"), strings.Join(o.Properties, ", "))
+}
+
+func (o SchemaObject) sameDefinition(other SchemaObject) bool {
+	return o.Kind == other.Kind && o.Type == other.Type &&
+		slices.Equal(o.Labels, other.Labels) && slices.Equal(o.Properties, other.Properties)
+}
+
+// SchemaChange is an object that exists under its expected name but with a
+// different definition
+type SchemaChange struct {
+	Expected SchemaObject `json:"expected"`
+	Actual   SchemaObject `json:"actual"`
+}
+
+// Drift is the difference between the schema the migrations define and the
+// one a database has
+type Drift struct {
+	// Pending lists the versions of the migrations not applied yet, which
+	// usually explain the missing objects
+	Pending []int `json:"pending"`
+	// Missing objects are defined by the migrations but absent
+	Missing []SchemaObject `json:"missing"`
+	// Unexpected objects exist but no migration defines them
+	Unexpected []SchemaObject `json:"unexpected"`
+	Changed    []SchemaChange `json:"changed"`
+}
+
+// Clean reports whether the database matches the migrations
+func (d *Drift) Clean() bool {
+	return len(d.Pending) == 0 && len(d.Missing) == 0 && len(d.Unexpected) == 0 && len(d.Changed) == 0
+}
+
+var (
+	constraintPattern = regexp.MustCompile(`(?is)^CREATE\s+CONSTRAINT\s+(\w+)\s+(?:IF\s+NOT\s+EXISTS\s+)?FOR\s+(.+?)\s+REQUIRE\s+(.+?)\s+IS\s+(UNIQUE|NOT\s+NULL|NODE\s+KEY|RELATIONSHIP\s+KEY)$`)
+	indexPattern      = regexp.MustCompile(`(?is)^CREATE\s+(?:(RANGE|TEXT|POINT|FULLTEXT|VECTOR)\s+)?INDEX\s+(\w+)\s+(?:IF\s+NOT\s+EXISTS\s+)?FOR\s+(.+?)\s+ON\s+(?:EACH\s+)?(\[.+?\]|\(.+?\))(?:\s+OPTIONS\s+.*)?$`)
+	dropPattern       = regexp.MustCompile(`(?is)^DROP\s+(CONSTRAINT|INDEX)\s+(\w+)`)
+	nodePattern       = regexp.MustCompile(`^\(\w*:([\w|]+)\)$`)
+	relPattern        = regexp.MustCompile(`^\(\)-\[\w*:([\w|]+)\]-[>]?\(\)$`)
+)
+
+// Expected returns the constraints and indexes that applying every
+// migration in order leaves behind, sorted by name. Statements other than
+// CREATE and DROP of a constraint or index are skipped.
+func Expected(migrations []Migration) ([]SchemaObject, error) {
+	objects := make(map[string]SchemaObject)
+	statements := []string{versionConstraint, lockConstraint}
+	for _, m := range migrations {
+		statements = append(statements, m.Up...)
+	}
+
+	for _, stmt := range statements {
+		stmt = strings.TrimSpace(stmt)
+		if m := dropPattern.FindStringSubmatch(stmt); m != nil {
+			delete(objects, m[2])
+			continue
+		}
+
+		upper := strings.ToUpper(stmt)
+		if !strings.HasPrefix(upper, "CREATE") || !strings.Contains(upper, "CONSTRAINT") && !strings.Contains(upper, "INDEX") {
+			continue
+		}
+		obj, err := parseCreate(stmt)
+		if err != nil {
+			return nil, err
+		}
+		objects[obj.Name] = obj
+	}
+
+	expected := make([]SchemaObject, 0, len(objects))
+	for _, obj := range objects {
+		expected = append(expected, obj)
+	}
+	sort.Slice(expected, func(i, j int) bool { return expected[i].Name < expected[j].Name })
+	return expected, nil
+}
+
+// parseCreate parses a CREATE CONSTRAINT or CREATE INDEX statement
+func parseCreate(stmt string) (SchemaObject, error) {
+	if m := constraintPattern.FindStringSubmatch(stmt); m != nil {
+		labels, rel, err := parsePattern(m[2])
+		if err != nil {
+			return SchemaObject{}, fmt.Errorf("%w in %q", err, stmt)
+		}
+		constraintType := strings.Join(strings.Fields(strings.ToUpper(m[4])), " ")
+		return SchemaObject{
+			Kind:       KindConstraint,
+			Name:       m[1],
+			Type:       constraintTypeName(constraintType, rel),
+			Labels:     labels,
+			Properties: parseProperties(m[3]),
+		}, nil
+	}
+
+	if m := indexPattern.FindStringSubmatch(stmt); m != nil {
+		labels, _, err := parsePattern(m[3])
+		if err != nil {
+			return SchemaObject{}, fmt.Errorf("%w in %q", err, stmt)
+		}
+		indexType := strings.ToUpper(m[1])
+		if indexType == "" {
+			indexType = "RANGE"
+		}
+		return SchemaObject{
+			Kind:       KindIndex,
+			Name:       m[2],
+			Type:       indexType,
+			Labels:     labels,
+			Properties: parseProperties(m[4]),
+		}, nil
+	}
+
+	return SchemaObject{}, fmt.Errorf("can't parse schema statement %q; name the object and use the FOR ... REQUIRE/ON form", stmt)
+}
+
+// parsePattern returns the labels or relationship types of a FOR pattern
+// such as (u:User) or ()-[r:GAVE]-(), and whether it is a relationship
+func parsePattern(pattern string) ([]string, bool, error) {
+	pattern = strings.Join(strings.Fields(pattern), "")
+	if m := nodePattern.FindStringSubmatch(pattern); m != nil {
+		return sortedLabels(strings.Split(m[1], "|")), false, nil
+	}
+	if m := relPattern.FindStringSubmatch(pattern); m != nil {
+		return sortedLabels(strings.Split(m[1], "|")), true, nil
+	}
+	return nil, false, fmt.Errorf("unsupported pattern %q", pattern)
+}
+
+// parseProperties turns "u.id", "(a.x, a.y)" or "[a.title, a.description]"
+// into the property names
+func parseProperties(list string) []string {
+	list = strings.Trim(strings.TrimSpace(list), "()[]")
+	var props []string
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if _, prop, ok := strings.Cut(part, "."); ok {
+			part = prop
+		}
+		props = append(props, strings.Trim(part, "`"))
+	}
+	return props
+}
+
+// constraintTypeName returns the type SHOW CONSTRAINTS reports for a
+// constraint declared as IS <predicate>
+func constraintTypeName(predicate string, rel bool) string {
+	entity := "NODE"
+	if rel {
+		entity = "RELATIONSHIP"
+	}
+	switch predicate {
+	case "UNIQUE":
+		if rel {
+			return "RELATIONSHIP_UNIQUENESS"
+		}
+		return "UNIQUENESS"
+	case "NOT NULL":
+		return entity + "_PROPERTY_EXISTENCE"
+	default:
+		return strings.ReplaceAll(predicate, " ", "_")
+	}
+}
+
+func sortedLabels(labels []string) []string {
+	sort.Strings(labels)
+	return labels
+}
+
+// Drift compares the constraints and indexes of the database with the ones
+// the migrations define, without changing anything. Token lookup indexes,
+// the indexes backing constraints and the objects named in ignore, which
+// are managed outside migrations, are left out.
+func (m *Migrator) Drift(ctx context.Context, ignore ...string) (*Drift, error) {
+	expected, err := Expected(m.migrations)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actual, err := m.actualSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	drift := &Drift{
+		Pending:    []int{},
+		Missing:    []SchemaObject{},
+		Unexpected: []SchemaObject{},
+		Changed:    []SchemaChange{},
+	}
+	for _, migration := range pending {
+		drift.Pending = append(drift.Pending, migration.Version)
+	}
+
+	byName := make(map[string]SchemaObject, len(actual))
+	for _, obj := range actual {
+		byName[obj.Name] = obj
+	}
+	defined := make(map[string]bool, len(expected))
+	for _, want := range expected {
+		defined[want.Name] = true
+		got, ok := byName[want.Name]
+		switch {
+		case !ok:
+			drift.Missing = append(drift.Missing, want)
+		case !want.sameDefinition(got):
+			drift.Changed = append(drift.Changed, SchemaChange{Expected: want, Actual: got})
+		}
+	}
+	for _, obj := range actual {
+		if !defined[obj.Name] && !slices.Contains(ignore, obj.Name) {
+			drift.Unexpected = append(drift.Unexpected, obj)
+		}
+	}
+	return drift, nil
+}
+
+// actualSchema lists the database's constraints and indexes, sorted by name
+func (m *Migrator) actualSchema(ctx context.Context) ([]SchemaObject, error) {
+	result, err := m.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		var objects []SchemaObject
+
+		constraints, err := queries.SchemaConstraints.Run(ctx, tx, nil)
+		if err != nil {
+			return nil, err
+		}
+		for constraints.Next(ctx) {
+			obj := schemaObjectFromRecord(KindConstraint, constraints.Record())
+			if obj.Type == "NODE_PROPERTY_UNIQUENESS" {
+				obj.Type = "UNIQUENESS"
+			}
+			objects = append(objects, obj)
+		}
+		if err := constraints.Err(); err != nil {
+			return nil, err
+		}
+
+		indexes, err := queries.SchemaIndexes.Run(ctx, tx, nil)
+		if err != nil {
+			return nil, err
+		}
+		for indexes.Next(ctx) {
+			record := indexes.Record()
+			if owner, _ := record.Get("owningConstraint"); owner != nil {
+				continue
+			}
+			obj := schemaObjectFromRecord(KindIndex, record)
+			if obj.Type == "LOOKUP" {
+				continue
+			}
+			objects = append(objects, obj)
+		}
+		return objects, indexes.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objects, _ := result.([]SchemaObject)
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+func schemaObjectFromRecord(kind string, record *neo4j.Record) SchemaObject {
+	name, _ := record.Get("name")
+	objectType, _ := record.Get("type")
+	labels, _ := record.Get("labelsOrTypes")
+	props, _ := record.Get("properties")
+
+	obj := SchemaObject{Kind: kind}
+	obj.Name, _ = name.(string)
+	obj.Type, _ = objectType.(string)
+	obj.Labels = sortedLabels(stringList(labels))
+	obj.Properties = stringList(props)
+	return obj
+}
+
+// stringList converts a list returned by the driver to strings. A null list
+// becomes an empty one.
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestExpected(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "init", Up: []string{
+			"CREATE CONSTRAINT user_id IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE",
+			"CREATE INDEX act_created_at IF NOT EXISTS FOR (a:Act) ON (a.createdAt)",
+			"CREATE FULLTEXT INDEX act_search IF NOT EXISTS FOR (a:Act) ON EACH [a.title, a.description]",
+			"CREATE INDEX gave_at FOR ()-[g:GAVE]-() ON (g.at)",
+			"MATCH (n:Act) SET n.status = 'pending'",
+		}},
+		{Version: 2, Name: "drop", Up: []string{
+			"DROP INDEX act_created_at IF EXISTS",
+			"CREATE CONSTRAINT user_email_key FOR (u:User) REQUIRE (u.email, u.tenant) IS NODE KEY",
+		}},
+	}
+
+	expected, err := Expected(migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []SchemaObject{
+		{Kind: KindIndex, Name: "act_search", Type: "FULLTEXT", Labels: []string{"Act"}, Properties: []string{"title", "description"}},
+		{Kind: KindIndex, Name: "gave_at", Type: "RANGE", Labels: []string{"GAVE"}, Properties: []string{"at"}},
+		{Kind: KindConstraint, Name: "migration_lock", Type: "UNIQUENESS", Labels: []string{"MigrationLock"}, Properties: []string{"name"}},
+		{Kind: KindConstraint, Name: "schema_version", Type: "UNIQUENESS", Labels: []string{"SchemaVersion"}, Properties: []string{"version"}},
+		{Kind: KindConstraint, Name: "user_email_key", Type: "NODE_KEY", Labels: []string{"User"}, Properties: []string{"email", "tenant"}},
+		{Kind: KindConstraint, Name: "user_id", Type: "UNIQUENESS", Labels: []string{"User"}, Properties: []string{"id"}},
+	}
+	if !reflect.DeepEqual(expected, want) {
+		t.Errorf("expected\n%v\ngot\n%v", want, expected)
+	}
+}
+
+func TestExpected_Embedded(t *testing.T) {
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Expected(migrations); err != nil {
+		t.Errorf("embedded migrations can't be checked for drift: %v", err)
+	}
+}
+
+func TestExpected_UnnamedObject(t *testing.T) {
+	_, err := Expected([]Migration{{Version: 1, Up: []string{"CREATE INDEX FOR (a:Act) ON (a.x)"}}})
+	if err == nil {
+		t.Error("expected an error for an unnamed index")
+	}
+}
+
+// queuedDB returns one canned result per transaction, in order. An error
+// result fails its transaction.
+type queuedDB struct {
+	results []interface{}
+}
+
+func (q *queuedDB) next() (interface{}, error) {
+	result := q.results[0]
+	q.results = q.results[1:]
+	if err, ok := result.(error); ok {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (q *queuedDB) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	return q.next()
+}
+
+func (q *queuedDB) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	return q.next()
+}
+
+func TestDrift(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "init", Up: []string{
+			"CREATE CONSTRAINT user_id IF NOT EXISTS FOR (u:User) REQUIRE u.id IS UNIQUE",
+			"CREATE INDEX act_status IF NOT EXISTS FOR (a:Act) ON (a.status)",
+			"CREATE INDEX act_type IF NOT EXISTS FOR (a:Act) ON (a.type)",
+		}},
+		{Version: 2, Name: "later", Up: []string{"MATCH (n) RETURN n"}},
+	}
+	applied := map[int]time.Time{1: time.Now()}
+	actual := []SchemaObject{
+		{Kind: KindIndex, Name: "act_embedding", Type: "VECTOR", Labels: []string{"Act"}, Properties: []string{"embedding"}},
+		{Kind: KindIndex, Name: "act_type", Type: "TEXT", Labels: []string{"Act"}, Properties: []string{"type"}},
+		{Kind: KindIndex, Name: "manual", Type: "RANGE", Labels: []string{"User"}, Properties: []string{"name"}},
+		{Kind: KindConstraint, Name: "migration_lock", Type: "UNIQUENESS", Labels: []string{"MigrationLock"}, Properties: []string{"name"}},
+		{Kind: KindConstraint, Name: "schema_version", Type: "UNIQUENESS", Labels: []string{"SchemaVersion"}, Properties: []string{"version"}},
+		{Kind: KindConstraint, Name: "user_id", Type: "UNIQUENESS", Labels: []string{"User"}, Properties: []string{"id"}},
+	}
+
+	db := &queuedDB{results: []interface{}{applied, actual}}
+	drift, err := New(db, migrations).Drift(context.Background(), "act_embedding")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(drift.Pending, []int{2}) {
+		t.Errorf("expected version 2 pending, got %v", drift.Pending)
+	}
+	if len(drift.Missing) != 1 || drift.Missing[0].Name != "act_status" {
+		t.Errorf("expected act_status missing, got %v", drift.Missing)
+	}
+	if len(drift.Unexpected) != 1 || drift.Unexpected[0].Name != "manual" {
+		t.Errorf("expected only manual unexpected, got %v", drift.Unexpected)
+	}
+	if len(drift.Changed) != 1 || drift.Changed[0].Actual.Type != "TEXT" {
+		t.Errorf("expected act_type changed, got %v", drift.Changed)
+	}
+	if drift.Clean() {
+		t.Error("expected drift")
+	}
+}
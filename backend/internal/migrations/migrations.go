@@ -0,0 +1,397 @@
+// Package migrations applies versioned Cypher schema changes. Migrations are
+// numbered files in cypher/, named NNNN_description.up.cypher with an
+// optional matching .down.cypher. Each applied version is recorded as a
+// SchemaVersion node, so a migration runs once per database rather than at
+// every boot. Migrators take a lock, a MigrationLock node kept unique by a
+// constraint, so instances starting together don't apply the same
+// migrations at once.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/queries"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+//go:embed cypher/*.cypher
+var files embed.FS
+
+// Executor runs managed transactions. database.DBClient satisfies it.
+type Executor interface {
+	ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error)
+	ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error)
+}
+
+// Migration is one schema version
+type Migration struct {
+	Version int
+	Name    string
+	// Up and Down hold the statements to apply and revert the migration.
+	// Down is empty when the migration can't be reverted.
+	Up   []string
+	Down []string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Embedded returns the migrations shipped with the binary
+func Embedded() ([]Migration, error) {
+	sub, err := fs.Sub(files, "cypher")
+	if err != nil {
+		return nil, err
+	}
+	return Load(sub)
+}
+
+// Load reads migrations from the .cypher files in fsys, sorted by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".cypher" {
+			continue
+		}
+
+		version, name, direction, err := parseFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, name)
+		}
+
+		statements := splitStatements(string(data))
+		if direction == "up" {
+			m.Up = statements
+		} else {
+			m.Down = statements
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if len(m.Up) == 0 {
+			return nil, fmt.Errorf("migration %d (%s) has no up statements", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// parseFilename splits "0002_add_index.up.cypher" into its version, name
+// and direction
+func parseFilename(filename string) (int, string, string, error) {
+	base := strings.TrimSuffix(filename, ".cypher")
+
+	direction := path.Ext(base)
+	if direction != ".up" && direction != ".down" {
+		return 0, "", "", fmt.Errorf("migration %s must end in .up.cypher or .down.cypher", filename)
+	}
+	base = strings.TrimSuffix(base, direction)
+
+	number, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("migration %s must be named NNNN_description", filename)
+	}
+	version, err := strconv.Atoi(number)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration %s must start with a positive version number", filename)
+	}
+
+	return version, name, direction[1:], nil
+}
+
+// splitStatements splits a script into statements terminated by semicolons,
+// dropping blank lines and // comments
+func splitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
+
+// Migrator applies and reverts migrations against a database
+type Migrator struct {
+	db         Executor
+	migrations []Migration
+	// lockRetry is how long to wait before trying again for a lock another
+	// migrator holds
+	lockRetry time.Duration
+}
+
+// New creates a Migrator for migrations, which must be sorted by version
+func New(db Executor, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations, lockRetry: time.Second}
+}
+
+// Status returns every known migration with the time it was applied, if it
+// was
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Migration: migration}
+		if at, ok := applied[migration.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that haven't been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in version order and returns the
+// versions it applied. It stops at the first failure. While another
+// migrator holds the lock, it waits for it until ctx is done.
+func (m *Migrator) Up(ctx context.Context) ([]int, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := m.run(ctx, versionConstraint); err != nil {
+		return nil, fmt.Errorf("failed to create schema version constraint: %w", err)
+	}
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	for _, migration := range pending {
+		for _, stmt := range migration.Up {
+			if err := m.run(ctx, stmt); err != nil {
+				return done, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+			}
+		}
+		if err := m.record(ctx, migration); err != nil {
+			return done, fmt.Errorf("migration %d (%s): failed to record version: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration.Version)
+	}
+	return done, nil
+}
+
+// Down reverts the most recently applied steps migrations, newest first, and
+// returns the versions it reverted. Like Up, it holds the lock while it
+// runs.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []int
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if len(migration.Down) == 0 {
+			return done, fmt.Errorf("migration %d (%s) can't be reverted", migration.Version, migration.Name)
+		}
+
+		for _, stmt := range migration.Down {
+			if err := m.run(ctx, stmt); err != nil {
+				return done, fmt.Errorf("reverting migration %d (%s): %w", migration.Version, migration.Name, err)
+			}
+		}
+		if err := m.forget(ctx, migration.Version); err != nil {
+			return done, fmt.Errorf("reverting migration %d (%s): failed to remove version: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration.Version)
+	}
+	return done, nil
+}
+
+const versionConstraint = `CREATE CONSTRAINT schema_version IF NOT EXISTS FOR (v:SchemaVersion) REQUIRE v.version IS UNIQUE`
+
+const lockConstraint = `CREATE CONSTRAINT migration_lock IF NOT EXISTS FOR (l:MigrationLock) REQUIRE l.name IS UNIQUE`
+
+// staleLock is how old a lock must be to be taken over, its holder being
+// presumed dead. No migration should run this long.
+const staleLock = time.Hour
+
+// lock takes the migration lock, waiting while another migrator holds it
+// until ctx is done, and returns a func releasing it
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	if err := m.run(ctx, lockConstraint); err != nil {
+		return nil, fmt.Errorf("failed to create migration lock constraint: %w", err)
+	}
+
+	owner := uuid.NewString()
+	for {
+		now := time.Now().UTC()
+		_, err := m.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			_, err := queries.MigrationLockAcquire.Run(ctx, tx, map[string]interface{}{
+				"owner":       owner,
+				"staleBefore": now.Add(-staleLock),
+				"acquiredAt":  now,
+			})
+			return nil, err
+		})
+		if err == nil {
+			break
+		}
+		var neoErr *neo4j.Neo4jError
+		if !errors.As(err, &neoErr) || neoErr.Code != "Neo.ClientError.Schema.ConstraintValidationFailed" {
+			return nil, fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Waiting for another migrator to release the migration lock")
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for the migration lock: %w", ctx.Err())
+		case <-time.After(m.lockRetry):
+		}
+	}
+
+	return func() {
+		// Release the lock even if ctx was canceled mid-migration
+		ctx := context.WithoutCancel(ctx)
+		_, err := m.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			_, err := queries.MigrationLockRelease.Run(ctx, tx, map[string]interface{}{"owner": owner})
+			return nil, err
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to release the migration lock", "error", err)
+		}
+	}, nil
+}
+
+// applied returns the applied versions and when they were applied
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	result, err := m.db.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := queries.MigrationsApplied.Run(ctx, tx, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		applied := make(map[int]time.Time)
+		for result.Next(ctx) {
+			record := result.Record()
+			version, _ := record.Get("version")
+			appliedAt, _ := record.Get("appliedAt")
+			v, _ := version.(int64)
+			at, _ := appliedAt.(time.Time)
+			applied[int(v)] = at
+		}
+		return applied, result.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema versions: %w", err)
+	}
+
+	applied, _ := result.(map[int]time.Time)
+	if applied == nil {
+		applied = make(map[int]time.Time)
+	}
+	return applied, nil
+}
+
+// run executes a statement in its own transaction, since Neo4j doesn't allow
+// schema changes to share a transaction with other writes
+func (m *Migrator) run(ctx context.Context, stmt string) error {
+	_, err := m.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := tx.Run(ctx, stmt, nil)
+		if err != nil {
+			return nil, err
+		}
+		_, err = result.Consume(ctx)
+		return nil, err
+	})
+	return err
+}
+
+func (m *Migrator) record(ctx context.Context, migration Migration) error {
+	_, err := m.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.MigrationRecord.Run(ctx, tx, map[string]interface{}{
+			"version":   migration.Version,
+			"name":      migration.Name,
+			"appliedAt": time.Now().UTC(),
+		})
+		return nil, err
+	})
+	return err
+}
+
+func (m *Migrator) forget(ctx context.Context, version int) error {
+	_, err := m.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.MigrationForget.Run(ctx, tx, map[string]interface{}{
+			"version": version,
+		})
+		return nil, err
+	})
+	return err
+}
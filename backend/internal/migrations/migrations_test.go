@@ -0,0 +1,112 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_act_index.up.cypher":        {Data: []byte("CREATE INDEX a IF NOT EXISTS FOR (a:Act) ON (a.x);\n")},
+		"0002_act_index.down.cypher":      {Data: []byte("DROP INDEX a IF EXISTS;")},
+		"0001_initial_schema.up.cypher":   {Data: []byte("// users\nCREATE CONSTRAINT u;\n\nCREATE INDEX\n  b;\n")},
+		"0001_initial_schema.down.cypher": {Data: []byte("DROP INDEX b;\nDROP CONSTRAINT u;")},
+		"README.md":                       {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(migrations))
+	}
+	first := migrations[0]
+	if first.Version != 1 || first.Name != "initial_schema" {
+		t.Errorf("unexpected first migration %d %q", first.Version, first.Name)
+	}
+	if want := []string{"CREATE CONSTRAINT u", "CREATE INDEX\n  b"}; !reflect.DeepEqual(first.Up, want) {
+		t.Errorf("expected up %q, got %q", want, first.Up)
+	}
+	if want := []string{"DROP INDEX b", "DROP CONSTRAINT u"}; !reflect.DeepEqual(first.Down, want) {
+		t.Errorf("expected down %q, got %q", want, first.Down)
+	}
+	if migrations[1].Version != 2 {
+		t.Errorf("expected migrations sorted by version, got %d second", migrations[1].Version)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"no direction", fstest.MapFS{"0001_init.cypher": {Data: []byte("RETURN 1;")}}},
+		{"no version", fstest.MapFS{"init.up.cypher": {Data: []byte("RETURN 1;")}}},
+		{"bad version", fstest.MapFS{"v1_init.up.cypher": {Data: []byte("RETURN 1;")}}},
+		{"down only", fstest.MapFS{"0001_init.down.cypher": {Data: []byte("RETURN 1;")}}},
+		{"conflicting names", fstest.MapFS{
+			"0001_init.up.cypher":  {Data: []byte("RETURN 1;")},
+			"0001_other.up.cypher": {Data: []byte("RETURN 1;")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.fsys); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	if err != nil {
+		t.Fatalf("embedded migrations are invalid: %v", err)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("expected version %d, got %d (%s)", i+1, m.Version, m.Name)
+		}
+	}
+}
+
+func TestUp_WaitsForLock(t *testing.T) {
+	held := &neo4j.Neo4jError{Code: "Neo.ClientError.Schema.ConstraintValidationFailed"}
+	db := &queuedDB{results: []interface{}{
+		nil,                 // lock constraint
+		held,                // lock held by another migrator
+		nil,                 // lock taken
+		nil,                 // version constraint
+		map[int]time.Time{}, // applied versions
+		nil,                 // migration statement
+		nil,                 // version recorded
+		nil,                 // lock released
+	}}
+	m := New(db, []Migration{{Version: 1, Name: "init", Up: []string{"RETURN 1"}}})
+	m.lockRetry = time.Millisecond
+
+	applied, err := m.Up(context.Background())
+	if err != nil || !reflect.DeepEqual(applied, []int{1}) {
+		t.Fatalf("Up = %v, %v", applied, err)
+	}
+	if len(db.results) != 0 {
+		t.Errorf("expected the lock to be released, %d transactions left", len(db.results))
+	}
+
+	db = &queuedDB{results: []interface{}{nil, held}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := New(db, nil).Up(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected giving up on the lock with ctx, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// Setup modes select what the server does with migrations at startup
+const (
+	// SetupApply applies pending migrations
+	SetupApply = "apply"
+	// SetupCheck only counts pending migrations, for deployments where the
+	// migrate command runs separately
+	SetupCheck = "check"
+	// SetupSkip leaves the schema alone, for databases the server's user
+	// can't even read the schema version of
+	SetupSkip = "skip"
+)
+
+// Schema states
+const (
+	SchemaReady   = "ready"
+	SchemaPending = "pending"
+	SchemaSkipped = "skipped"
+	SchemaFailed  = "failed"
+)
+
+// SchemaState is the outcome of schema setup for one database
+type SchemaState struct {
+	Status string `json:"status"`
+	// Applied lists the versions setup applied
+	Applied []int `json:"applied,omitempty"`
+	// Pending counts the migrations still to apply
+	Pending int    `json:"pending,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Ready reports whether the database has every migration applied. A
+// skipped setup isn't ready, since nothing says it is.
+func (s SchemaState) Ready() bool {
+	return s.Status == SchemaReady
+}
+
+// Setup brings the schema up to date according to mode and reports the
+// result. The error is also recorded in the state, so callers can decide
+// whether a failure stops the server or only degrades it.
+func (m *Migrator) Setup(ctx context.Context, mode string) (SchemaState, error) {
+	switch mode {
+	case SetupSkip:
+		return SchemaState{Status: SchemaSkipped}, nil
+	case SetupApply:
+		applied, err := m.Up(ctx)
+		state := SchemaState{Status: SchemaReady, Applied: applied}
+		if err != nil {
+			state.Status, state.Error = SchemaFailed, err.Error()
+			if pending, err := m.Pending(ctx); err == nil {
+				state.Pending = len(pending)
+			}
+		}
+		return state, err
+	case SetupCheck:
+		pending, err := m.Pending(ctx)
+		if err != nil {
+			return SchemaState{Status: SchemaFailed, Error: err.Error()}, err
+		}
+		if len(pending) > 0 {
+			return SchemaState{Status: SchemaPending, Pending: len(pending)}, nil
+		}
+		return SchemaState{Status: SchemaReady}, nil
+	default:
+		err := fmt.Errorf("unknown schema setup mode %q; use %s, %s or %s", mode, SetupApply, SetupCheck, SetupSkip)
+		return SchemaState{Status: SchemaFailed, Error: err.Error()}, err
+	}
+}
+
+// Readiness holds the schema state of each database the server uses, keyed
+// by name, for health checks. It is safe for concurrent use.
+type Readiness struct {
+	mu     sync.RWMutex
+	states map[string]SchemaState
+}
+
+// NewReadiness creates an empty Readiness
+func NewReadiness() *Readiness {
+	return &Readiness{states: make(map[string]SchemaState)}
+}
+
+// Set records the state of database
+func (r *Readiness) Set(database string, state SchemaState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states[database] = state
+}
+
+// States returns a copy of every recorded state
+func (r *Readiness) States() map[string]SchemaState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.states)
+}
+
+// Ready reports whether every recorded database is ready
+func (r *Readiness) Ready() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, state := range r.states {
+		if !state.Ready() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetup(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "init"}, {Version: 2, Name: "later"}}
+	ctx := context.Background()
+
+	db := &queuedDB{results: []interface{}{map[int]time.Time{1: time.Now()}}}
+	state, err := New(db, migrations).Setup(ctx, SetupCheck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Status != SchemaPending || state.Pending != 1 || state.Ready() {
+		t.Errorf("expected one pending migration, got %+v", state)
+	}
+
+	db = &queuedDB{results: []interface{}{map[int]time.Time{1: time.Now(), 2: time.Now()}}}
+	if state, _ := New(db, migrations).Setup(ctx, SetupCheck); !state.Ready() {
+		t.Errorf("expected ready, got %+v", state)
+	}
+
+	if state, err := New(nil, migrations).Setup(ctx, SetupSkip); err != nil || state.Status != SchemaSkipped {
+		t.Errorf("expected skipped, got %+v, %v", state, err)
+	}
+	if state, err := New(nil, migrations).Setup(ctx, "sometimes"); err == nil || state.Status != SchemaFailed {
+		t.Errorf("expected an unknown mode to fail, got %+v", state)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	r := NewReadiness()
+	if !r.Ready() {
+		t.Error("expected ready with nothing recorded")
+	}
+
+	r.Set("", SchemaState{Status: SchemaReady})
+	r.Set("oslo", SchemaState{Status: SchemaFailed, Error: "permission denied"})
+	if r.Ready() {
+		t.Error("expected a failed database to make readiness fail")
+	}
+
+	states := r.States()
+	states["oslo"] = SchemaState{Status: SchemaReady}
+	if r.Ready() {
+		t.Error("expected States to return a copy")
+	}
+}
@@ -1,22 +1,38 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // User represents a user in the system
 type User struct {
-	ID           string    `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Name         string    `json:"name"`
-	Avatar       string    `json:"avatar,omitempty"`
-	Bio          string    `json:"bio,omitempty"`
-	Location     string    `json:"location,omitempty"`
-	IsVerified   bool      `json:"isVerified"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
-	Stats        UserStats `json:"stats,omitempty"`
+	ID           string `json:"id" neo4j:"id"`
+	Email        string `json:"email" neo4j:"email"`
+	PasswordHash string `json:"-"`
+	Name         string `json:"name" neo4j:"name"`
+	Avatar       string `json:"avatar,omitempty" neo4j:"avatar"`
+	Bio          string `json:"bio,omitempty" neo4j:"bio"`
+	Location     string `json:"location,omitempty" neo4j:"location"`
+	// Latitude, Longitude and CountryCode are resolved from Location by
+	// the geocoder shortly after it is set
+	Latitude    *float64  `json:"latitude,omitempty" neo4j:"latitude"`
+	Longitude   *float64  `json:"longitude,omitempty" neo4j:"longitude"`
+	CountryCode string    `json:"countryCode,omitempty" neo4j:"countryCode"`
+	IsVerified  bool      `json:"isVerified" neo4j:"isVerified"`
+	CreatedAt   time.Time `json:"createdAt" neo4j:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" neo4j:"updatedAt"`
+	Version     int64     `json:"version" neo4j:"version"`
+	Stats       UserStats `json:"stats,omitempty"`
+
+	HideFromLeaderboards bool `json:"hideFromLeaderboards" neo4j:"hideFromLeaderboards"`
+	// SuspendedAt is when an admin suspended the user, who can't sign in
+	// until reinstated
+	SuspendedAt      *time.Time `json:"suspendedAt,omitempty" neo4j:"suspendedAt"`
+	SuspensionReason string     `json:"suspensionReason,omitempty" neo4j:"suspensionReason"`
+	// Locale is the language the user prefers messages in, used when a
+	// request doesn't ask for one
+	Locale string `json:"locale,omitempty" neo4j:"locale"`
 }
 
 // UserStats holds user statistics
@@ -42,28 +58,49 @@ type UpdateUserRequest struct {
 	Avatar   string `json:"avatar,omitempty"`
 	Bio      string `json:"bio,omitempty" validate:"omitempty,max=500"`
 	Location string `json:"location,omitempty" validate:"omitempty,max=100"`
+	Locale   string `json:"locale,omitempty" validate:"omitempty,max=35"`
+
+	HideFromLeaderboards *bool `json:"hideFromLeaderboards,omitempty"`
+
+	// Version, when set, is the version the client last read; the update
+	// fails with a conflict if the user has changed since
+	Version *int64 `json:"version,omitempty" validate:"omitempty,min=0"`
 }
 
 // Act represents an act of kindness
 type Act struct {
-	ID          string     `json:"id"`
-	Title       string     `json:"title"`
-	Description string     `json:"description"`
-	Type        ActType    `json:"type"`
-	Category    string     `json:"category"`
-	Value       float64    `json:"value,omitempty"`
-	Currency    string     `json:"currency,omitempty"`
-	Status      ActStatus  `json:"status"`
-	GiverID     string     `json:"giverId"`
-	ReceiverID  string     `json:"receiverId,omitempty"`
-	ChainID     string     `json:"chainId,omitempty"`
-	Location    string     `json:"location,omitempty"`
-	IsAnonymous bool       `json:"isAnonymous"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
-	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ID          string  `json:"id" neo4j:"id"`
+	Title       string  `json:"title" neo4j:"title"`
+	Description string  `json:"description" neo4j:"description"`
+	Type        ActType `json:"type" neo4j:"type,default=other"`
+	Category    string  `json:"category" neo4j:"category"`
+	Value       float64 `json:"value,omitempty" neo4j:"value"`
+	Currency    string  `json:"currency,omitempty" neo4j:"currency"`
+	// BaseValue is Value converted to BaseCurrency, the platform's base
+	// currency, with the rates stored when the act was created, or when it
+	// was first converted if there were none for its currency yet
+	BaseValue    *float64  `json:"baseValue,omitempty" neo4j:"baseValue"`
+	BaseCurrency string    `json:"baseCurrency,omitempty" neo4j:"baseCurrency"`
+	Status       ActStatus `json:"status" neo4j:"status,default=pending"`
+	GiverID      string    `json:"giverId" neo4j:"giverId"`
+	ReceiverID   string    `json:"receiverId,omitempty" neo4j:"receiverId"`
+	ChainID      string    `json:"chainId,omitempty" neo4j:"chainId"`
+	CampaignID   string    `json:"campaignId,omitempty" neo4j:"campaignId"`
+	Location     string    `json:"location,omitempty" neo4j:"location"`
+	// Latitude, Longitude and CountryCode are resolved from Location by
+	// the geocoder shortly after the act is created
+	Latitude    *float64   `json:"latitude,omitempty" neo4j:"latitude"`
+	Longitude   *float64   `json:"longitude,omitempty" neo4j:"longitude"`
+	CountryCode string     `json:"countryCode,omitempty" neo4j:"countryCode"`
+	IsAnonymous bool       `json:"isAnonymous" neo4j:"isAnonymous"`
+	CreatedAt   time.Time  `json:"createdAt" neo4j:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" neo4j:"updatedAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" neo4j:"completedAt"`
+	Version     int64      `json:"version" neo4j:"version"`
 	Giver       *User      `json:"giver,omitempty"`
 	Receiver    *User      `json:"receiver,omitempty"`
+	// OrganizationID is the organization the act was given on behalf of
+	OrganizationID string `json:"organizationId,omitempty" neo4j:"organizationId"`
 }
 
 // ActType represents the type of act
@@ -85,9 +122,13 @@ const (
 	ActStatusAccepted  ActStatus = "accepted"
 	ActStatusCompleted ActStatus = "completed"
 	ActStatusCancelled ActStatus = "cancelled"
+	// ActStatusExpired is set by the expiration sweep on acts left pending
+	// for too long
+	ActStatusExpired ActStatus = "expired"
 )
 
-// CreateActRequest represents a request to create an act
+// CreateActRequest represents a request to create an act. ChainID adds it
+// to an existing chain, making its giver a participant.
 type CreateActRequest struct {
 	Title       string  `json:"title" validate:"required,min=5,max=200"`
 	Description string  `json:"description" validate:"required,min=10,max=2000"`
@@ -96,8 +137,14 @@ type CreateActRequest struct {
 	Value       float64 `json:"value,omitempty"`
 	Currency    string  `json:"currency,omitempty"`
 	ReceiverID  string  `json:"receiverId,omitempty"`
+	ChainID     string  `json:"chainId,omitempty"`
 	Location    string  `json:"location,omitempty"`
 	IsAnonymous bool    `json:"isAnonymous"`
+	// CampaignID tags the act to a running campaign, which the giver joins
+	CampaignID string `json:"campaignId,omitempty"`
+	// OrganizationID gives the act on behalf of an organization the giver
+	// is a member of
+	OrganizationID string `json:"organizationId,omitempty"`
 }
 
 // UpdateActRequest represents a request to update an act
@@ -106,49 +153,1090 @@ type UpdateActRequest struct {
 	Description string    `json:"description,omitempty" validate:"omitempty,min=10,max=2000"`
 	Status      ActStatus `json:"status,omitempty"`
 	ReceiverID  string    `json:"receiverId,omitempty"`
+	// Version, when set, is the version the client last read; the update
+	// fails with a conflict if the act has changed since
+	Version *int64 `json:"version,omitempty" validate:"omitempty,min=0"`
 }
 
 // Chain represents a chain of kindness
 type Chain struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
+	ID          string    `json:"id" neo4j:"id"`
+	Name        string    `json:"name" neo4j:"name"`
+	Description string    `json:"description" neo4j:"description"`
 	StarterID   string    `json:"starterId"`
 	ActsCount   int       `json:"actsCount"`
 	TotalValue  float64   `json:"totalValue"`
 	Reach       int       `json:"reach"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	CreatedAt   time.Time `json:"createdAt" neo4j:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" neo4j:"updatedAt"`
 	Acts        []Act     `json:"acts,omitempty"`
 	Starter     *User     `json:"starter,omitempty"`
 }
 
+// Campaign is a drive, such as "Kindness Week 2025", running between two
+// dates. Users join it and tag the acts they give to it, working towards its
+// goals for the number and value of acts.
+type Campaign struct {
+	ID          string    `json:"id" neo4j:"id"`
+	Name        string    `json:"name" neo4j:"name"`
+	Description string    `json:"description,omitempty" neo4j:"description"`
+	StartsAt    time.Time `json:"startsAt" neo4j:"startsAt"`
+	EndsAt      time.Time `json:"endsAt" neo4j:"endsAt"`
+	// GoalActs and GoalValue are the number of acts and their total value,
+	// in the base currency, the campaign aims for; zero sets no goal
+	GoalActs  int64     `json:"goalActs,omitempty" neo4j:"goalActs"`
+	GoalValue float64   `json:"goalValue,omitempty" neo4j:"goalValue"`
+	CreatedBy string    `json:"createdBy,omitempty" neo4j:"createdBy"`
+	CreatedAt time.Time `json:"createdAt" neo4j:"createdAt"`
+	// Status and Progress are computed when the campaign is read
+	Status   CampaignStatus    `json:"status,omitempty"`
+	Progress *CampaignProgress `json:"progress,omitempty"`
+}
+
+// CampaignStatus is where the current time falls in a campaign's dates
+type CampaignStatus string
+
+const (
+	CampaignUpcoming CampaignStatus = "upcoming"
+	CampaignActive   CampaignStatus = "active"
+	CampaignEnded    CampaignStatus = "ended"
+)
+
+// StatusAt returns where now falls in the campaign's dates. A campaign is
+// active from its start up to, but not including, its end.
+func (c *Campaign) StatusAt(now time.Time) CampaignStatus {
+	switch {
+	case now.Before(c.StartsAt):
+		return CampaignUpcoming
+	case now.Before(c.EndsAt):
+		return CampaignActive
+	default:
+		return CampaignEnded
+	}
+}
+
+// CampaignProgress is how far a campaign got, counting the acts tagged to
+// it that weren't deleted
+type CampaignProgress struct {
+	Acts         int64   `json:"acts"`
+	Value        float64 `json:"value"`
+	Participants int64   `json:"participants"`
+	// ActsPercent and ValuePercent are how much of each goal was reached,
+	// possibly over 100, or nil when the campaign doesn't set the goal
+	ActsPercent  *float64 `json:"actsPercent,omitempty"`
+	ValuePercent *float64 `json:"valuePercent,omitempty"`
+}
+
+// CreateCampaignRequest creates a campaign
+type CreateCampaignRequest struct {
+	Name        string    `json:"name" validate:"required,min=3,max=100"`
+	Description string    `json:"description,omitempty" validate:"max=2000"`
+	StartsAt    time.Time `json:"startsAt" validate:"required"`
+	EndsAt      time.Time `json:"endsAt" validate:"required,gtfield=StartsAt"`
+	GoalActs    int64     `json:"goalActs,omitempty" validate:"min=0"`
+	GoalValue   float64   `json:"goalValue,omitempty" validate:"min=0"`
+}
+
+// Organization is a school, company or other group running a pay-it-forward
+// program. Its members give acts on its behalf, and their activity is
+// summed up on its dashboard.
+type Organization struct {
+	ID          string    `json:"id" neo4j:"id"`
+	Name        string    `json:"name" neo4j:"name"`
+	Description string    `json:"description,omitempty" neo4j:"description"`
+	Kind        OrgKind   `json:"kind" neo4j:"kind,default=other"`
+	CreatedBy   string    `json:"createdBy,omitempty" neo4j:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt" neo4j:"createdAt"`
+	// Members is counted when the organization is read
+	Members int64 `json:"members"`
+	// Role is the signed in user's role, when listing their organizations
+	Role OrgRole `json:"role,omitempty"`
+}
+
+// OrgKind is the kind of group an organization is
+type OrgKind string
+
+const (
+	OrgKindSchool    OrgKind = "school"
+	OrgKindCompany   OrgKind = "company"
+	OrgKindNonprofit OrgKind = "nonprofit"
+	OrgKindOther     OrgKind = "other"
+)
+
+// OrgRole is a member's role in an organization. Owners and admins manage
+// its members and invitations; only owners can make or unmake owners.
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"
+	OrgRoleAdmin  OrgRole = "admin"
+	OrgRoleMember OrgRole = "member"
+)
+
+// Manages reports whether the role can manage an organization's members
+// and invitations
+func (r OrgRole) Manages() bool {
+	return r == OrgRoleOwner || r == OrgRoleAdmin
+}
+
+// OrgMember is a user's membership of an organization
+type OrgMember struct {
+	UserID   string    `json:"userId"`
+	Name     string    `json:"name"`
+	Avatar   string    `json:"avatar,omitempty"`
+	Role     OrgRole   `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// OrgInvitation invites whoever signs in with Email to join an
+// organization with Role. It is accepted with the token emailed to them,
+// which is only returned when the invitation is created.
+type OrgInvitation struct {
+	ID             string     `json:"id" neo4j:"id"`
+	OrganizationID string     `json:"organizationId" neo4j:"organizationId"`
+	Email          string     `json:"email" neo4j:"email"`
+	Role           OrgRole    `json:"role" neo4j:"role"`
+	InvitedBy      string     `json:"invitedBy" neo4j:"invitedBy"`
+	CreatedAt      time.Time  `json:"createdAt" neo4j:"createdAt"`
+	ExpiresAt      time.Time  `json:"expiresAt" neo4j:"expiresAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt,omitempty" neo4j:"acceptedAt"`
+	AcceptedBy     string     `json:"acceptedBy,omitempty" neo4j:"acceptedBy"`
+	Token          string     `json:"token,omitempty"`
+}
+
+// OrgDashboard sums up an organization's program for its members
+type OrgDashboard struct {
+	Organization *Organization `json:"organization"`
+	Stats        *OrgStats     `json:"stats"`
+	// TopGivers ranks the members by the acts they gave for the
+	// organization
+	TopGivers  []LeaderboardEntry `json:"topGivers"`
+	RecentActs []Act              `json:"recentActs"`
+	Chains     []Chain            `json:"chains"`
+	// PendingInvitations is only counted for owners and admins
+	PendingInvitations *int64 `json:"pendingInvitations,omitempty"`
+}
+
+// CreateOrganizationRequest creates an organization, owned by its creator
+type CreateOrganizationRequest struct {
+	Name        string  `json:"name" validate:"required,min=2,max=100"`
+	Description string  `json:"description,omitempty" validate:"max=2000"`
+	Kind        OrgKind `json:"kind,omitempty" validate:"omitempty,oneof=school company nonprofit other"`
+}
+
+// InviteMemberRequest invites someone to an organization by email
+type InviteMemberRequest struct {
+	Email string  `json:"email" validate:"required,email"`
+	Role  OrgRole `json:"role,omitempty" validate:"omitempty,oneof=owner admin member"`
+}
+
+// AcceptInvitationRequest accepts an invitation to an organization with the
+// token emailed to the invitee
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+// UpdateMemberRequest changes a member's role
+type UpdateMemberRequest struct {
+	Role OrgRole `json:"role" validate:"required,oneof=owner admin member"`
+}
+
 // Testimonial represents a user testimonial
 type Testimonial struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"userId"`
-	Story      string    `json:"story"`
-	Impact     string    `json:"impact"`
-	IsApproved bool      `json:"isApproved"`
-	IsFeatured bool      `json:"isFeatured"`
-	CreatedAt  time.Time `json:"createdAt"`
-	User       *User     `json:"user,omitempty"`
+	ID            string      `json:"id" neo4j:"id"`
+	UserID        string      `json:"userId" neo4j:"userId"`
+	Story         string      `json:"story" neo4j:"story"`
+	Impact        string      `json:"impact" neo4j:"impact"`
+	IsApproved    bool        `json:"isApproved" neo4j:"isApproved"`
+	IsFeatured    bool        `json:"isFeatured" neo4j:"isFeatured"`
+	FeaturedOrder *int        `json:"featuredOrder,omitempty" neo4j:"featuredOrder"`
+	Media         *Media      `json:"media,omitempty"`
+	ReactionCount int64       `json:"reactionCount" neo4j:"reactionCount"`
+	Locale        string      `json:"locale,omitempty"`
+	Moderation    *Moderation `json:"moderation,omitempty"`
+	CreatedAt     time.Time   `json:"createdAt" neo4j:"createdAt"`
+	UpdatedAt     *time.Time  `json:"updatedAt,omitempty" neo4j:"updatedAt"`
+	Version       int64       `json:"version" neo4j:"version"`
+	User          *User       `json:"user,omitempty"`
+}
+
+// MediaType represents the kind of media attached to a testimonial
+type MediaType string
+
+const (
+	MediaTypePhoto MediaType = "photo"
+	MediaTypeVideo MediaType = "video"
+)
+
+// Media is a photo or short video attached to a testimonial
+type Media struct {
+	Type            MediaType `json:"type" validate:"required,oneof=photo video"`
+	URL             string    `json:"url" validate:"required,url"`
+	DurationSeconds int       `json:"durationSeconds,omitempty" validate:"omitempty,min=1,max=60"`
 }
 
 // CreateTestimonialRequest represents a request to create a testimonial
 type CreateTestimonialRequest struct {
 	Story  string `json:"story" validate:"required,min=50,max=2000"`
 	Impact string `json:"impact" validate:"required,min=10,max=200"`
+	Media  *Media `json:"media,omitempty"`
+}
+
+// UpdateTestimonialRequest represents a request to edit a testimonial.
+// Setting RemoveMedia drops any attached media.
+type UpdateTestimonialRequest struct {
+	Story       string `json:"story,omitempty" validate:"omitempty,min=50,max=2000"`
+	Impact      string `json:"impact,omitempty" validate:"omitempty,min=10,max=200"`
+	Media       *Media `json:"media,omitempty"`
+	RemoveMedia bool   `json:"removeMedia,omitempty"`
+	// Version, when set, is the version the client last read; the edit
+	// fails with a conflict if the testimonial has changed since
+	Version *int64 `json:"version,omitempty" validate:"omitempty,min=0"`
+}
+
+// ModerationStatus represents where a testimonial is in the review process
+type ModerationStatus string
+
+const (
+	ModerationPending  ModerationStatus = "pending"
+	ModerationApproved ModerationStatus = "approved"
+	ModerationRejected ModerationStatus = "rejected"
+)
+
+// RejectionReason categorizes why a testimonial was rejected
+type RejectionReason string
+
+const (
+	RejectionSpam         RejectionReason = "spam"
+	RejectionOffensive    RejectionReason = "offensive"
+	RejectionPersonalInfo RejectionReason = "personal_info"
+	RejectionOffTopic     RejectionReason = "off_topic"
+	RejectionDuplicate    RejectionReason = "duplicate"
+	RejectionOther        RejectionReason = "other"
+)
+
+// RejectionReasons lists the valid rejection reasons
+var RejectionReasons = []RejectionReason{
+	RejectionSpam,
+	RejectionOffensive,
+	RejectionPersonalInfo,
+	RejectionOffTopic,
+	RejectionDuplicate,
+	RejectionOther,
+}
+
+// Moderation holds the internal review state of a testimonial. It is only
+// included in admin responses.
+type Moderation struct {
+	Status          ModerationStatus `json:"status"`
+	ReviewerID      string           `json:"reviewerId,omitempty"`
+	RejectionReason RejectionReason  `json:"rejectionReason,omitempty"`
+	ReviewedAt      *time.Time       `json:"reviewedAt,omitempty"`
+	Screening       *Screening       `json:"screening,omitempty"`
+	Notes           []ModerationNote `json:"notes"`
+}
+
+// Screening is the automated spam and abuse assessment of a testimonial
+// or act. Score ranges from 0 (clean) to 1.
+type Screening struct {
+	Score   float64  `json:"score" neo4j:"screeningScore"`
+	Flags   []string `json:"flags" neo4j:"screeningFlags"`
+	Verdict string   `json:"verdict" neo4j:"screeningVerdict"`
+}
+
+// ModerationNote is an internal note left by a reviewer
+type ModerationNote struct {
+	ID        string    `json:"id" neo4j:"id"`
+	AuthorID  string    `json:"authorId" neo4j:"authorId"`
+	Body      string    `json:"body" neo4j:"body"`
+	CreatedAt time.Time `json:"createdAt" neo4j:"createdAt"`
+}
+
+// AssignReviewerRequest assigns a testimonial to a reviewer; an empty
+// ReviewerID unassigns it
+type AssignReviewerRequest struct {
+	ReviewerID string `json:"reviewerId"`
+}
+
+// AddModerationNoteRequest represents a request to add an internal note
+type AddModerationNoteRequest struct {
+	Body string `json:"body" validate:"required,max=2000"`
+}
+
+// ReviewTestimonialRequest approves or rejects a testimonial. Reason is
+// required when rejecting.
+type ReviewTestimonialRequest struct {
+	Decision ModerationStatus `json:"decision" validate:"required,oneof=approved rejected"`
+	Reason   RejectionReason  `json:"reason,omitempty"`
+	Note     string           `json:"note,omitempty" validate:"max=2000"`
+}
+
+// ReactionResult is the state of the caller's "this moved me" reaction on a
+// testimonial after toggling it
+type ReactionResult struct {
+	Reacted       bool  `json:"reacted"`
+	ReactionCount int64 `json:"reactionCount"`
+	// AuthorID is the testimonial's author, whose live update topic the
+	// new count is pushed to
+	AuthorID string `json:"-"`
+}
+
+// TranslateTestimonialRequest represents a translated story and impact for
+// one locale
+type TranslateTestimonialRequest struct {
+	Story  string `json:"story" validate:"required,max=4000"`
+	Impact string `json:"impact" validate:"required,max=400"`
+}
+
+// AuditEvent records a mutating API request: who made it, which route and
+// entity it touched and which fields the request body set
+type AuditEvent struct {
+	ID        string    `json:"id"`
+	ActorID   string    `json:"actorId,omitempty"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Path      string    `json:"path"`
+	EntityID  string    `json:"entityId,omitempty"`
+	Status    int       `json:"status"`
+	Changes   []string  `json:"changes"`
+	RequestID string    `json:"requestId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// MaintenanceStatus describes whether the API is in maintenance mode
+type MaintenanceStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Message           string     `json:"message"`
+	RetryAfterSeconds int        `json:"retryAfterSeconds"`
+	Since             *time.Time `json:"since,omitempty"`
+}
+
+// SetMaintenanceRequest turns maintenance mode on or off
+type SetMaintenanceRequest struct {
+	Enabled           *bool  `json:"enabled" validate:"required"`
+	Message           string `json:"message,omitempty" validate:"max=500"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds,omitempty" validate:"omitempty,min=1,max=86400"`
+}
+
+// SuspendUserRequest suspends or reinstates a user. Reason is required
+// when suspending.
+type SuspendUserRequest struct {
+	Suspended *bool  `json:"suspended" validate:"required"`
+	Reason    string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// UserSuspension is the data of a user.suspended or user.reinstated event
+type UserSuspension struct {
+	AdminID string `json:"adminId,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// TakeDownActRequest removes an act for breaking the rules
+type TakeDownActRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ActTakedown is the data of the act.deleted event of an act taken down
+// by a moderator
+type ActTakedown struct {
+	DeletedAt   time.Time `json:"deletedAt"`
+	ModeratorID string    `json:"moderatorId,omitempty"`
+	Reason      string    `json:"reason"`
+}
+
+// ReportStatus is where a content report is in its handling
+type ReportStatus string
+
+const (
+	ReportOpen      ReportStatus = "open"
+	ReportResolved  ReportStatus = "resolved"
+	ReportDismissed ReportStatus = "dismissed"
+)
+
+// ContentReport is a user's report of an act, testimonial or user that
+// breaks the rules, waiting for or handled by an admin
+type ContentReport struct {
+	ID         string       `json:"id" neo4j:"id"`
+	Kind       string       `json:"kind" neo4j:"kind"`
+	TargetID   string       `json:"targetId" neo4j:"targetId"`
+	ReporterID string       `json:"reporterId" neo4j:"reporterId"`
+	Reason     string       `json:"reason" neo4j:"reason"`
+	Details    string       `json:"details,omitempty" neo4j:"details"`
+	Status     ReportStatus `json:"status" neo4j:"status"`
+	HandlerID  string       `json:"handlerId,omitempty" neo4j:"handlerId"`
+	Note       string       `json:"note,omitempty" neo4j:"note"`
+	CreatedAt  time.Time    `json:"createdAt" neo4j:"createdAt"`
+	HandledAt  *time.Time   `json:"handledAt,omitempty" neo4j:"handledAt"`
+}
+
+// CreateReportRequest reports content that breaks the rules
+type CreateReportRequest struct {
+	Kind     string `json:"kind" validate:"required,oneof=act testimonial user"`
+	TargetID string `json:"targetId" validate:"required,max=100"`
+	Reason   string `json:"reason" validate:"required,oneof=spam offensive personal_info misleading other"`
+	Details  string `json:"details,omitempty" validate:"max=1000"`
+}
+
+// HandleReportRequest closes a content report
+type HandleReportRequest struct {
+	Status ReportStatus `json:"status" validate:"required,oneof=resolved dismissed"`
+	Note   string       `json:"note,omitempty" validate:"max=2000"`
+}
+
+// SetFeatureRequest overrides a feature flag
+type SetFeatureRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+// ErasureReport lists what erasing a person's data changed. The user node
+// itself is kept, stripped of personal data, so chains and statistics
+// that include their acts stay consistent. NotificationsScrubbed counts the
+// person's notifications, which are deleted, and others' naming them as the
+// actor.
+type ErasureReport struct {
+	SubjectID             string    `json:"subjectId"`
+	ActsRedacted          int       `json:"actsRedacted"`
+	TestimonialsDeleted   int       `json:"testimonialsDeleted"`
+	ReactionsRemoved      int       `json:"reactionsRemoved"`
+	ModerationScrubbed    int       `json:"moderationScrubbed"`
+	AuditEventsScrubbed   int       `json:"auditEventsScrubbed"`
+	NotificationsScrubbed int       `json:"notificationsScrubbed"`
+	ErasedAt              time.Time `json:"erasedAt"`
+}
+
+// NotificationType is what a notification is about
+type NotificationType string
+
+const (
+	// NotificationActReceived tells a user they received an act
+	NotificationActReceived NotificationType = "act_received"
+	// NotificationChainGrew tells a chain's members an act was added to it
+	NotificationChainGrew NotificationType = "chain_grew"
+	// NotificationTestimonialApproved tells an author their testimonial
+	// was approved
+	NotificationTestimonialApproved NotificationType = "testimonial_approved"
+)
+
+// Notification tells a user about something that happened to them: who
+// did it, when anyone did, and to which act, chain or testimonial
+type Notification struct {
+	ID          string           `json:"id" neo4j:"id"`
+	UserID      string           `json:"userId" neo4j:"userId"`
+	Type        NotificationType `json:"type" neo4j:"type"`
+	ActorID     string           `json:"actorId,omitempty" neo4j:"actorId"`
+	SubjectType string           `json:"subjectType" neo4j:"subjectType"`
+	SubjectID   string           `json:"subjectId" neo4j:"subjectId"`
+	// ReadAt is when the user marked the notification read, or nil while
+	// it is unread
+	ReadAt    *time.Time `json:"readAt" neo4j:"readAt"`
+	CreatedAt time.Time  `json:"createdAt" neo4j:"createdAt"`
+	// Text says what happened in the language of the response; it isn't
+	// stored
+	Text string `json:"text,omitempty"`
+}
+
+// NotificationSubject types
+const (
+	SubjectAct         = "act"
+	SubjectChain       = "chain"
+	SubjectTestimonial = "testimonial"
+)
+
+// UnreadNotifications is the number of a user's unread notifications
+type UnreadNotifications struct {
+	Unread int64 `json:"unread"`
+}
+
+// MarkNotificationsReadRequest marks notifications read: those listed in
+// IDs, or all of the caller's when IDs is empty
+type MarkNotificationsReadRequest struct {
+	IDs []string `json:"ids,omitempty" validate:"max=100"`
+}
+
+// MarkNotificationsReadResult reports how many notifications were marked
+// read and how many are left unread
+type MarkNotificationsReadResult struct {
+	Marked int   `json:"marked"`
+	Unread int64 `json:"unread"`
+}
+
+// NotificationDigest sums up the unread notifications of a user due a
+// weekly digest email
+type NotificationDigest struct {
+	UserID               string
+	Email                string
+	Name                 string
+	ActsReceived         int
+	ChainsGrown          int
+	TestimonialsApproved int
+}
+
+// EmailSuppression is an address no email is sent to, because it was
+// rejected or an admin added it
+type EmailSuppression struct {
+	Email     string    `json:"email" neo4j:"email"`
+	Reason    string    `json:"reason" neo4j:"reason"`
+	CreatedAt time.Time `json:"createdAt" neo4j:"createdAt"`
+}
+
+// SuppressEmailRequest represents a request to stop emailing an address
+type SuppressEmailRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=200"`
+}
+
+// Webhook is a subscription delivering events to a URL. Its secret signs
+// the deliveries and is only returned when the webhook is created.
+type Webhook struct {
+	ID        string    `json:"id" neo4j:"id"`
+	URL       string    `json:"url" neo4j:"url"`
+	Events    []string  `json:"events" neo4j:"events"`
+	Secret    string    `json:"secret,omitempty" neo4j:"secret"`
+	CreatedAt time.Time `json:"createdAt" neo4j:"createdAt"`
+}
+
+// CreateWebhookRequest represents a request to subscribe a URL to events
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,max=2000"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=act.created act.completed chain.extended testimonial.approved"`
+}
+
+// WebhookDelivery is one attempt at delivering an event to a webhook
+type WebhookDelivery struct {
+	ID        string `json:"id" neo4j:"id"`
+	WebhookID string `json:"webhookId" neo4j:"webhookId"`
+	// EventID is the same for every attempt at delivering an event
+	EventID    string    `json:"eventId" neo4j:"eventId"`
+	Event      string    `json:"event" neo4j:"event"`
+	Attempt    int       `json:"attempt" neo4j:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty" neo4j:"statusCode"`
+	Error      string    `json:"error,omitempty" neo4j:"error"`
+	Succeeded  bool      `json:"succeeded" neo4j:"succeeded"`
+	DurationMS int64     `json:"durationMs" neo4j:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt" neo4j:"createdAt"`
+}
+
+// ChainExtension is the webhook payload of an act added to a chain
+type ChainExtension struct {
+	ChainID   string `json:"chainId"`
+	ActID     string `json:"actId"`
+	ActsCount int    `json:"actsCount"`
+}
+
+// Domain event types, named after the aggregate they change: the user, act,
+// testimonial, campaign or organization whose ID is the event's aggregate ID
+const (
+	DomainUserCreated          = "user.created"
+	DomainUserUpdated          = "user.updated"
+	DomainUserDeleted          = "user.deleted"
+	DomainUserRestored         = "user.restored"
+	DomainUserErased           = "user.erased"
+	DomainUserSuspended        = "user.suspended"
+	DomainUserReinstated       = "user.reinstated"
+	DomainActCreated           = "act.created"
+	DomainActUpdated           = "act.updated"
+	DomainActCompleted         = "act.completed"
+	DomainActDeleted           = "act.deleted"
+	DomainActRestored          = "act.restored"
+	DomainActExpired           = "act.expired"
+	DomainActRedacted          = "act.redacted"
+	DomainTestimonialCreated   = "testimonial.created"
+	DomainTestimonialUpdated   = "testimonial.updated"
+	DomainTestimonialApproved  = "testimonial.approved"
+	DomainTestimonialRejected  = "testimonial.rejected"
+	DomainTestimonialDeleted   = "testimonial.deleted"
+	DomainTestimonialRestored  = "testimonial.restored"
+	DomainTestimonialReacted   = "testimonial.reacted"
+	DomainTestimonialUnreacted = "testimonial.unreacted"
+	DomainPledgeHeld           = "pledge.held"
+	DomainPledgeReleased       = "pledge.released"
+	DomainPledgeRefunded       = "pledge.refunded"
+	DomainPledgeDisputed       = "pledge.disputed"
+	DomainCampaignCreated      = "campaign.created"
+	DomainOrganizationCreated  = "organization.created"
+	DomainOrganizationJoined   = "organization.joined"
+)
+
+// DomainEvent is a change to an aggregate, stored in the outbox by the
+// transaction making it and published from there. Sequence orders events
+// the way their transactions committed.
+type DomainEvent struct {
+	ID            string          `json:"id" neo4j:"id"`
+	Sequence      int64           `json:"sequence" neo4j:"sequence"`
+	Type          string          `json:"type" neo4j:"type"`
+	AggregateType string          `json:"aggregateType" neo4j:"aggregateType"`
+	AggregateID   string          `json:"aggregateId" neo4j:"aggregateId"`
+	Data          json.RawMessage `json:"data"`
+	CreatedAt     time.Time       `json:"createdAt" neo4j:"createdAt"`
+}
+
+// ReactionChange is the data of a testimonial.reacted or
+// testimonial.unreacted event
+type ReactionChange struct {
+	UserID        string `json:"userId"`
+	ReactionCount int64  `json:"reactionCount"`
+}
+
+// TestimonialReview is the data of a testimonial.approved or
+// testimonial.rejected event
+type TestimonialReview struct {
+	ReviewerID string `json:"reviewerId,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// PledgeStatus is the state of a pledge
+type PledgeStatus string
+
+const (
+	// PledgeHeld funds are authorized on the giver's payment method
+	PledgeHeld PledgeStatus = "held"
+	// PledgeReleased funds were captured for the receiver
+	PledgeReleased PledgeStatus = "released"
+	// PledgeRefunded holds were cancelled, so the giver isn't charged
+	PledgeRefunded PledgeStatus = "refunded"
+	// PledgeDisputed funds stay held until an admin resolves the dispute
+	PledgeDisputed PledgeStatus = "disputed"
+	// PledgeSettling funds are being captured or released by the payments
+	// provider. A pledge left settling, because the server stopped before
+	// recording the outcome, needs an admin to check it with the provider.
+	PledgeSettling PledgeStatus = "settling"
+)
+
+// Pledge is an amount a giver committed to a monetary act, held by the
+// payments provider until the receiver confirms the act. A held pledge is
+// released automatically at ReleaseAt unless it was disputed, and refunded
+// if the act is cancelled.
+type Pledge struct {
+	ID          string       `json:"id" neo4j:"id"`
+	ActID       string       `json:"actId" neo4j:"actId"`
+	GiverID     string       `json:"giverId" neo4j:"giverId"`
+	ReceiverID  string       `json:"receiverId" neo4j:"receiverId"`
+	Amount      float64      `json:"amount" neo4j:"amount"`
+	Currency    string       `json:"currency" neo4j:"currency"`
+	Status      PledgeStatus `json:"status" neo4j:"status"`
+	Provider    string       `json:"provider" neo4j:"provider"`
+	ProviderRef string       `json:"-" neo4j:"providerRef"`
+	ReleaseAt   time.Time    `json:"releaseAt" neo4j:"releaseAt"`
+	// DisputedBy and DisputeReason are set when the giver or receiver
+	// disputes the pledge, Resolution when an admin resolves it
+	DisputedBy    string     `json:"disputedBy,omitempty" neo4j:"disputedBy"`
+	DisputeReason string     `json:"disputeReason,omitempty" neo4j:"disputeReason"`
+	Resolution    string     `json:"resolution,omitempty" neo4j:"resolution"`
+	CreatedAt     time.Time  `json:"createdAt" neo4j:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt" neo4j:"updatedAt"`
+	SettledAt     *time.Time `json:"settledAt,omitempty" neo4j:"settledAt"`
+}
+
+// CreatePledgeRequest represents a request to pledge the value of an act
+type CreatePledgeRequest struct {
+	// PaymentMethod is the provider's reference to the giver's payment
+	// method, such as a Stripe PaymentMethod ID
+	PaymentMethod string `json:"paymentMethod" validate:"required,max=255"`
+}
+
+// DisputePledgeRequest represents a request to dispute a held pledge
+type DisputePledgeRequest struct {
+	Reason string `json:"reason" validate:"required,min=10,max=2000"`
+}
+
+// ResolvePledgeRequest represents an admin's resolution of a dispute
+type ResolvePledgeRequest struct {
+	Outcome string `json:"outcome" validate:"required,oneof=release refund"`
+	Note    string `json:"note,omitempty" validate:"max=2000"`
+}
+
+// Place is where a free-text location was resolved to by the geocoder
+type Place struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the place's country,
+	// in uppercase
+	CountryCode string `json:"countryCode"`
+}
+
+// NearbyAct is an act found by a nearby search, with its distance from
+// the searched point
+type NearbyAct struct {
+	Act
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// FlaggedAct is an act whose title or description screening flagged, for
+// moderators to review
+type FlaggedAct struct {
+	Act
+	Screening  Screening `json:"screening"`
+	ScreenedAt time.Time `json:"screenedAt"`
+}
+
+// ExchangeRates are the units of each currency one unit of Base buys on
+// Date, as published by the rates provider
+type ExchangeRates struct {
+	Base      string             `json:"base"`
+	Date      time.Time          `json:"date"`
+	Rates     map[string]float64 `json:"rates"`
+	Provider  string             `json:"provider,omitempty"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+}
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is a unit of background work in the persisted job queue. A failed
+// attempt is queued again at RunAt until MaxAttempts have been made, after
+// which the job stays failed until an admin retries it. Payload and Result
+// are JSON.
+type Job struct {
+	ID          string          `json:"id" neo4j:"id"`
+	Type        string          `json:"type" neo4j:"type"`
+	Status      JobStatus       `json:"status" neo4j:"status"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Attempts    int             `json:"attempts" neo4j:"attempts"`
+	MaxAttempts int             `json:"maxAttempts" neo4j:"maxAttempts"`
+	LastError   string          `json:"lastError,omitempty" neo4j:"lastError"`
+	RunAt       time.Time       `json:"runAt" neo4j:"runAt"`
+	// LockedUntil is when the lease of a running job expires, after which
+	// it is considered abandoned and claimed again
+	LockedUntil *time.Time `json:"lockedUntil,omitempty" neo4j:"lockedUntil"`
+	CreatedAt   time.Time  `json:"createdAt" neo4j:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt" neo4j:"updatedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty" neo4j:"finishedAt"`
+}
+
+// JobSchedule is a job enqueued on a cron schedule
+type JobSchedule struct {
+	Type     string    `json:"type"`
+	Schedule string    `json:"schedule"`
+	Next     time.Time `json:"next"`
+}
+
+// CreateExportRequest represents a request to generate an export in the
+// background
+type CreateExportRequest struct {
+	Kind   string `json:"kind" validate:"required,oneof=users acts"`
+	Format string `json:"format,omitempty" validate:"omitempty,oneof=csv ndjson"`
+}
+
+// ExportResult is the result of an export job: the file it wrote to the
+// export directory and how many rows it holds
+type ExportResult struct {
+	Kind   string `json:"kind"`
+	Format string `json:"format"`
+	File   string `json:"file"`
+	Rows   int    `json:"rows"`
+}
+
+// SimilarAct is an act found by embedding similarity. Score ranges from 0
+// to 1, higher meaning more similar.
+type SimilarAct struct {
+	Act   Act     `json:"act"`
+	Score float64 `json:"score"`
+}
+
+// SearchKinds are the kinds of documents searched
+var SearchKinds = []string{"act", "chain", "user", "testimonial"}
+
+// SearchDocument is the searchable text of an act, chain, user or
+// testimonial, whose kind it names: the title and description of an act,
+// the name and description of a chain, the name and bio of a user, and the
+// impact and story of an approved testimonial
+type SearchDocument struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Text  string `json:"text,omitempty"`
+}
+
+// SearchHit is a document matching a search. Score is the search
+// engine's relevance and only compares hits of one search.
+type SearchHit struct {
+	SearchDocument
+	Score float64 `json:"score"`
+}
+
+// RecommendationReason says why an act or chain was recommended to a user
+type RecommendationReason string
+
+const (
+	// ReasonConnection is given when someone the user gave to, received
+	// from or shared a chain with gave the act or took part in the chain
+	ReasonConnection RecommendationReason = "connection"
+	// ReasonFriendOfFriend is given when a connection of a connection did
+	ReasonFriendOfFriend RecommendationReason = "friend_of_friend"
+	// ReasonCategory is given for acts, or chains holding acts, in a
+	// category the user gave or received in
+	ReasonCategory RecommendationReason = "category"
+	// ReasonSimilar is given for acts similar in meaning to the user's
+	// latest acts
+	ReasonSimilar RecommendationReason = "similar"
+)
+
+// RecommendedAct is an act recommended to a user. Score is higher for
+// better recommendations and only compares recommendations of a response.
+type RecommendedAct struct {
+	Act     Act                    `json:"act"`
+	Score   float64                `json:"score"`
+	Reasons []RecommendationReason `json:"reasons"`
+}
+
+// RecommendedChain is a chain recommended to a user, scored like
+// RecommendedAct
+type RecommendedChain struct {
+	Chain   Chain                  `json:"chain"`
+	Score   float64                `json:"score"`
+	Reasons []RecommendationReason `json:"reasons"`
+}
+
+// Recommendations are the suggestions for a user: acts in chains they
+// aren't part of, which they can join by continuing the chain; chains to
+// follow; and needs, pending acts still waiting for a receiver
+type Recommendations struct {
+	Acts   []RecommendedAct   `json:"acts"`
+	Chains []RecommendedChain `json:"chains"`
+	Needs  []RecommendedAct   `json:"needs"`
+}
+
+// ImportUsersRequest bulk-loads users, merging on their IDs
+type ImportUsersRequest struct {
+	Users []ImportUser `json:"users" validate:"required,min=1,max=10000,dive"`
+}
+
+// ImportUser is a user to import with their existing password hash, so
+// migrated accounts keep their passwords
+type ImportUser struct {
+	ID           string    `json:"id" validate:"required"`
+	Email        string    `json:"email" validate:"required,email"`
+	PasswordHash string    `json:"passwordHash" validate:"required"`
+	Name         string    `json:"name" validate:"required,max=100"`
+	Avatar       string    `json:"avatar,omitempty"`
+	Bio          string    `json:"bio,omitempty" validate:"max=500"`
+	Location     string    `json:"location,omitempty"`
+	IsVerified   bool      `json:"isVerified"`
+	CreatedAt    time.Time `json:"createdAt,omitempty"`
+}
+
+// ImportActsRequest bulk-loads acts, merging on their IDs
+type ImportActsRequest struct {
+	Acts []ImportAct `json:"acts" validate:"required,min=1,max=10000,dive"`
+}
+
+// ImportAct is an act to import, linked to existing users
+type ImportAct struct {
+	ID          string     `json:"id" validate:"required"`
+	Title       string     `json:"title" validate:"required,max=200"`
+	Description string     `json:"description" validate:"max=2000"`
+	Type        ActType    `json:"type" validate:"required"`
+	Category    string     `json:"category" validate:"required"`
+	Value       float64    `json:"value,omitempty"`
+	Currency    string     `json:"currency,omitempty"`
+	Status      ActStatus  `json:"status,omitempty"`
+	GiverID     string     `json:"giverId" validate:"required"`
+	ReceiverID  string     `json:"receiverId,omitempty"`
+	Location    string     `json:"location,omitempty"`
+	IsAnonymous bool       `json:"isAnonymous"`
+	CreatedAt   time.Time  `json:"createdAt,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
+
+// ImportResult reports how many records an import wrote and how many it
+// skipped, such as acts whose giver does not exist
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// FeatureTestimonialRequest represents a request to feature or unfeature a
+// testimonial; Order controls its position in the featured list
+type FeatureTestimonialRequest struct {
+	Featured bool `json:"featured"`
+	Order    *int `json:"order,omitempty"`
 }
 
 // GlobalStats represents global platform statistics
 type GlobalStats struct {
-	TotalActs       int64   `json:"totalActs"`
-	TotalUsers      int64   `json:"totalUsers"`
-	TotalChains     int64   `json:"totalChains"`
-	TotalValue      float64 `json:"totalValue"`
-	CountriesReach  int     `json:"countriesReach"`
-	ActiveThisMonth int64   `json:"activeThisMonth"`
+	TotalActs   int64   `json:"totalActs"`
+	TotalUsers  int64   `json:"totalUsers"`
+	TotalChains int64   `json:"totalChains"`
+	TotalValue  float64 `json:"totalValue"`
+	// Currency is the base currency TotalValue is given in
+	Currency        string `json:"currency,omitempty"`
+	CountriesReach  int    `json:"countriesReach"`
+	ActiveThisMonth int64  `json:"activeThisMonth"`
+}
+
+// LiveStats represents a running platform counter pushed over the stats stream
+type LiveStats struct {
+	TotalActs  int64     `json:"totalActs"`
+	TotalValue float64   `json:"totalValue"`
+	NewActs    int64     `json:"newActs"`
+	NewValue   float64   `json:"newValue"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// CompletedAct is an act on the public activity stream, sent once it is
+// completed. The giver of an anonymous act is left out.
+type CompletedAct struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Type        ActType   `json:"type"`
+	Category    string    `json:"category"`
+	Value       float64   `json:"value,omitempty"`
+	Currency    string    `json:"currency,omitempty"`
+	GiverID     string    `json:"giverId,omitempty"`
+	ReceiverID  string    `json:"receiverId,omitempty"`
+	ChainID     string    `json:"chainId,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// LiveRequest is a message from a WebSocket client changing the topics it
+// is subscribed to
+type LiveRequest struct {
+	// Type is "subscribe" or "unsubscribe"
+	Type   string   `json:"type"`
+	Topics []string `json:"topics"`
+}
+
+// LiveMessage is a message pushed to WebSocket clients: an update with the
+// subscribed topics it was published on, the client's subscriptions after a
+// request, or an error
+type LiveMessage struct {
+	Type   string      `json:"type"`
+	Topics []string    `json:"topics,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ReactionUpdate is the new reaction count of a testimonial, pushed live to
+// its author's topic
+type ReactionUpdate struct {
+	TestimonialID string `json:"testimonialId"`
+	ReactionCount int64  `json:"reactionCount"`
+}
+
+// ChainMilestone is pushed live when an act brings a chain to a milestone
+// number of acts
+type ChainMilestone struct {
+	ChainID   string `json:"chainId"`
+	ActID     string `json:"actId"`
+	ActsCount int    `json:"actsCount"`
+}
+
+// LeaderboardEntry represents a ranked user in a top givers/receivers list
+type LeaderboardEntry struct {
+	Rank       int             `json:"rank"`
+	User       LeaderboardUser `json:"user"`
+	ActsCount  int64           `json:"actsCount"`
+	TotalValue float64         `json:"totalValue"`
+}
+
+// LeaderboardUser is the public part of a user shown on a leaderboard
+type LeaderboardUser struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+// CategoryStats represents act statistics for a single category or type
+type CategoryStats struct {
+	Key        string  `json:"key"`
+	ActsCount  int64   `json:"actsCount"`
+	TotalValue float64 `json:"totalValue"`
+}
+
+// OrgStats represents aggregated activity for an organization's members
+type OrgStats struct {
+	OrganizationID string    `json:"organizationId"`
+	Members        int64     `json:"members"`
+	ActiveMembers  int64     `json:"activeMembers"`
+	ActsGiven      int64     `json:"actsGiven"`
+	ChainsStarted  int64     `json:"chainsStarted"`
+	TotalValue     float64   `json:"totalValue"`
+	GeneratedAt    time.Time `json:"generatedAt"`
+}
+
+// ImpactReport represents a user's year-in-review impact summary
+type ImpactReport struct {
+	UserID        string          `json:"userId"`
+	Year          int             `json:"year"`
+	ActsGiven     int64           `json:"actsGiven"`
+	ActsReceived  int64           `json:"actsReceived"`
+	ChainsReached int64           `json:"chainsReached"`
+	PeopleTouched int64           `json:"peopleTouched"`
+	TotalValue    float64         `json:"totalValue"`
+	TopCategories []CategoryStats `json:"topCategories"`
+	// Receipts lists the donation receipts issued for the monetary acts the
+	// user completed in the year, only in the user's own report
+	Receipts    []ReceiptSummary `json:"receipts,omitempty"`
+	GeneratedAt time.Time        `json:"generatedAt"`
+}
+
+// DonationReceipt is a numbered receipt for a completed monetary act, for
+// the giver's tax records. Receipts are numbered per tax year, the year of
+// the donation, in the order they are issued. The donor's and recipient's
+// details are read from their profiles, so erasing a user scrubs them.
+type DonationReceipt struct {
+	Number      string        `json:"number"`
+	TaxYear     int           `json:"taxYear" neo4j:"taxYear"`
+	Sequence    int64         `json:"sequence" neo4j:"sequence"`
+	ActID       string        `json:"actId" neo4j:"actId"`
+	Description string        `json:"description"`
+	Amount      float64       `json:"amount" neo4j:"amount"`
+	Currency    string        `json:"currency" neo4j:"currency"`
+	DonatedAt   time.Time     `json:"donatedAt" neo4j:"donatedAt"`
+	IssuedAt    time.Time     `json:"issuedAt" neo4j:"issuedAt"`
+	Donor       ReceiptParty  `json:"donor"`
+	Recipient   *ReceiptParty `json:"recipient,omitempty"`
+	Issuer      ReceiptIssuer `json:"issuer"`
+	// Statement declares that the donor received nothing in return
+	Statement string `json:"statement"`
+}
+
+// ReceiptParty is the donor or recipient named on a receipt
+type ReceiptParty struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// ReceiptIssuer is the organization issuing receipts
+type ReceiptIssuer struct {
+	Name    string `json:"name"`
+	TaxID   string `json:"taxId,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// ReceiptSummary is a receipt as listed in an impact report, without the
+// donor's details
+type ReceiptSummary struct {
+	Number    string    `json:"number"`
+	ActID     string    `json:"actId"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	DonatedAt time.Time `json:"donatedAt"`
+}
+
+// RetentionCohort represents users who signed up in the same week and the
+// fraction of them active in each following week
+type RetentionCohort struct {
+	WeekStart time.Time `json:"weekStart"`
+	Size      int       `json:"size"`
+	Retention []float64 `json:"retention"`
+}
+
+// RetentionReport represents a cohort retention matrix
+type RetentionReport struct {
+	Weeks       int               `json:"weeks"`
+	Cohorts     []RetentionCohort `json:"cohorts"`
+	GeneratedAt time.Time         `json:"generatedAt"`
 }
 
 // AuthTokens represents authentication tokens
@@ -164,6 +1252,11 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// RefreshRequest asks for new tokens in exchange for a refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
 // RegisterRequest represents a registration request
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -181,9 +1274,19 @@ type APIResponse struct {
 
 // APIError represents an API error
 type APIError struct {
-	Code    string `json:"code"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+	// RetryAfter is the number of seconds to wait before retrying
+	RetryAfter int `json:"retryAfter,omitempty"`
+}
+
+// FieldError describes why a request field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
 	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
 }
 
 // APIMeta represents API metadata
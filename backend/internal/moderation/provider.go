@@ -0,0 +1,128 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"payforwardnow/internal/version"
+)
+
+// DefaultOpenAIURL is the moderation endpoint of the OpenAI API
+const DefaultOpenAIURL = "https://api.openai.com/v1/moderations"
+
+// Classification is a provider's assessment of some text. Score ranges
+// from 0 (clean) to 1, and Flags name the categories it matched.
+type Classification struct {
+	Score float64
+	Flags []string
+}
+
+// Provider classifies text with a machine learning model or moderation
+// API, complementing the word lists of a Screener
+type Provider interface {
+	Classify(ctx context.Context, text string) (Classification, error)
+	// Name names the provider in logs and metrics
+	Name() string
+}
+
+// Config selects and configures a provider
+type Config struct {
+	// Provider is "openai" or empty to screen with the word lists alone
+	Provider string
+	// APIURL is the moderation endpoint, DefaultOpenAIURL by default, so
+	// compatible self-hosted models can be used. APIKey authenticates to it.
+	APIURL string
+	APIKey string
+}
+
+// NewProvider returns the provider described by cfg, or nil if screening
+// uses the word lists alone
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "openai":
+		endpoint := cfg.APIURL
+		if endpoint == "" {
+			endpoint = DefaultOpenAIURL
+		}
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid moderation API URL %q", endpoint)
+		}
+		if cfg.APIKey == "" && endpoint == DefaultOpenAIURL {
+			return nil, errors.New("the openai moderation provider needs an API key")
+		}
+		return &OpenAI{URL: endpoint, APIKey: cfg.APIKey}, nil
+	}
+	return nil, fmt.Errorf("unknown moderation provider %q; use openai", cfg.Provider)
+}
+
+// OpenAI classifies text with the OpenAI moderation API, or a compatible
+// endpoint. The score is the highest category score and the flags are the
+// categories the model flagged, such as "harassment" or "self-harm".
+type OpenAI struct {
+	URL    string
+	APIKey string
+	// Client defaults to a client with a 5 second timeout
+	Client *http.Client
+}
+
+// Classify sends text to the moderation endpoint
+func (o *OpenAI) Classify(ctx context.Context, text string) (Classification, error) {
+	body, _ := json.Marshal(map[string]string{"input": text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.URL, bytes.NewReader(body))
+	if err != nil {
+		return Classification{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+	client := o.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Classification{}, fmt.Errorf("moderation API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		return Classification{}, fmt.Errorf("moderation API: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Results []struct {
+			Categories     map[string]bool    `json:"categories"`
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return Classification{}, fmt.Errorf("moderation API: invalid response: %w", err)
+	}
+	if len(result.Results) == 0 {
+		return Classification{}, errors.New("moderation API: no result")
+	}
+
+	c := Classification{Flags: []string{}}
+	for category, score := range result.Results[0].CategoryScores {
+		c.Score = max(c.Score, min(score, 1))
+		if result.Results[0].Categories[category] {
+			c.Flags = append(c.Flags, category)
+		}
+	}
+	sort.Strings(c.Flags)
+	return c, nil
+}
+
+// Name returns "openai"
+func (o *OpenAI) Name() string { return "openai" }
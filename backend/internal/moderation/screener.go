@@ -0,0 +1,269 @@
+package moderation
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"payforwardnow/internal/metrics"
+)
+
+// Verdict is the outcome of screening a piece of content
+type Verdict string
+
+const (
+	// Allow means the content looks fine and goes through normal review
+	Allow Verdict = "allow"
+	// Flag means the content is borderline and should get a closer look
+	Flag Verdict = "flag"
+	// Block means the content is obvious spam or abuse
+	Block Verdict = "block"
+)
+
+// Flags describing why content scored as it did
+const (
+	FlagProfanity   = "profanity"
+	FlagSpamPhrase  = "spam_phrase"
+	FlagLinks       = "links"
+	FlagShouting    = "shouting"
+	FlagRepetition  = "repetition"
+	FlagContactInfo = "contact_info"
+	// FlagPII marks personal data that shouldn't be published, such as
+	// card or social security numbers
+	FlagPII = "pii"
+)
+
+// Result is the outcome of screening content. Score ranges from 0 (clean)
+// to 1 (certainly spam or abuse).
+type Result struct {
+	Score   float64  `json:"score"`
+	Flags   []string `json:"flags"`
+	Verdict Verdict  `json:"verdict"`
+}
+
+// HasFlag reports whether the result carries the given flag
+func (r Result) HasFlag(flag string) bool {
+	for _, f := range r.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	urlPattern   = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+`)
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().-]{8,}\d`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	ssnPattern   = regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)
+	ibanPattern  = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){3,7}(?: ?[A-Z0-9]{1,3})?\b`)
+)
+
+// Screener scores free text for profanity, personal data and spam using
+// word lists and simple heuristics, and optionally a Provider
+type Screener struct {
+	profanity      map[string]bool
+	spamPhrases    []string
+	provider       Provider
+	FlagThreshold  float64
+	BlockThreshold float64
+}
+
+// NewScreener creates a Screener with the built-in word lists
+func NewScreener() *Screener {
+	profanity := make(map[string]bool, len(defaultProfanity))
+	for _, w := range defaultProfanity {
+		profanity[w] = true
+	}
+
+	return &Screener{
+		profanity:      profanity,
+		spamPhrases:    defaultSpamPhrases,
+		FlagThreshold:  0.3,
+		BlockThreshold: 0.8,
+	}
+}
+
+// SetProvider sets the provider ScreenContent consults along with the word
+// lists
+func (s *Screener) SetProvider(p Provider) {
+	s.provider = p
+}
+
+// ScreenContent scores the given texts of a piece of content, such as
+// "act" or "testimonial", together. With a provider, the score is the
+// higher of the word lists' and the provider's, and the flags are both
+// sets. If the provider fails, the word lists' result stands, so content
+// is never held up by an unavailable provider.
+func (s *Screener) ScreenContent(ctx context.Context, content string, texts ...string) Result {
+	result := s.Screen(texts...)
+	if s.provider != nil {
+		c, err := s.provider.Classify(ctx, strings.Join(texts, "\n"))
+		status := "ok"
+		if err != nil {
+			status = "error"
+			slog.WarnContext(ctx, "Moderation provider failed, screening with word lists only",
+				"provider", s.provider.Name(), "content", content, "error", err)
+		} else {
+			result.Score = max(result.Score, c.Score)
+			for _, flag := range c.Flags {
+				if !slices.Contains(result.Flags, flag) {
+					result.Flags = append(result.Flags, flag)
+				}
+			}
+			result.Verdict = s.verdict(result.Score)
+		}
+		metrics.ModerationProviderRequests.WithLabelValues(s.provider.Name(), status).Inc()
+	}
+	metrics.ModerationScreenings.WithLabelValues(content, string(result.Verdict)).Inc()
+	return result
+}
+
+// Screen scores the given texts together with the word lists and
+// heuristics alone
+func (s *Screener) Screen(texts ...string) Result {
+	text := strings.Join(texts, "\n")
+	lower := strings.ToLower(text)
+
+	var score float64
+	var flags []string
+	add := func(flag string, weight float64) {
+		score += weight
+		flags = append(flags, flag)
+	}
+
+	profane := 0
+	for _, word := range strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		if s.profanity[word] {
+			profane++
+		}
+	}
+	if profane > 0 {
+		add(FlagProfanity, min(0.4*float64(profane), 0.8))
+	}
+
+	phrases := 0
+	for _, phrase := range s.spamPhrases {
+		if strings.Contains(lower, phrase) {
+			phrases++
+		}
+	}
+	if phrases > 0 {
+		add(FlagSpamPhrase, min(0.3*float64(phrases), 0.6))
+	}
+
+	if links := len(urlPattern.FindAllString(text, -1)); links >= 3 {
+		add(FlagLinks, 0.6)
+	} else if links > 0 {
+		add(FlagLinks, 0.2)
+	}
+
+	if isShouting(text) {
+		add(FlagShouting, 0.2)
+	}
+
+	if hasRepeatedRun(lower, 6) {
+		add(FlagRepetition, 0.2)
+	}
+
+	if hasPII(text) {
+		add(FlagPII, 0.4)
+	} else if emailPattern.MatchString(text) || phonePattern.MatchString(text) {
+		add(FlagContactInfo, 0.2)
+	}
+
+	result := Result{
+		Score: min(score, 1),
+		Flags: flags,
+	}
+	if result.Flags == nil {
+		result.Flags = []string{}
+	}
+	result.Verdict = s.verdict(result.Score)
+	return result
+}
+
+// verdict returns the verdict for score
+func (s *Screener) verdict(score float64) Verdict {
+	switch {
+	case score >= s.BlockThreshold:
+		return Block
+	case score >= s.FlagThreshold:
+		return Flag
+	}
+	return Allow
+}
+
+// hasPII reports whether text contains a payment card number passing the
+// Luhn check, a US social security number or an IBAN
+func hasPII(text string) bool {
+	if ssnPattern.MatchString(text) || ibanPattern.MatchString(text) {
+		return true
+	}
+	for _, match := range cardPattern.FindAllString(text, -1) {
+		if luhn(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhn reports whether the digits of number pass the Luhn checksum used by
+// payment card numbers
+func luhn(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// isShouting reports whether most letters in a reasonably long text are
+// uppercase
+func isShouting(text string) bool {
+	var letters, upper int
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 20 && float64(upper)/float64(letters) > 0.6
+}
+
+// hasRepeatedRun reports whether text contains the same character n or more
+// times in a row, as in "!!!!!!" or "sooooooo"
+func hasRepeatedRun(text string, n int) bool {
+	var prev rune
+	run := 0
+	for _, r := range text {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run >= n && !unicode.IsSpace(r) {
+			return true
+		}
+	}
+	return false
+}
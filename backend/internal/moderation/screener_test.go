@@ -0,0 +1,158 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestScreen(t *testing.T) {
+	s := NewScreener()
+
+	tests := []struct {
+		name          string
+		text          string
+		expectVerdict Verdict
+		expectFlag    string
+	}{
+		{
+			name:          "clean story",
+			text:          "A neighbour helped me carry groceries every week while I recovered from surgery.",
+			expectVerdict: Allow,
+		},
+		{
+			name:          "single link is allowed",
+			text:          "I wrote more about it at https://example.com/story if you want to read it.",
+			expectVerdict: Allow,
+			expectFlag:    FlagLinks,
+		},
+		{
+			name:          "borderline profanity",
+			text:          "This was a shit week until a stranger paid for my coffee.",
+			expectVerdict: Flag,
+			expectFlag:    FlagProfanity,
+		},
+		{
+			name:          "obvious spam",
+			text:          "CLICK HERE to EARN MONEY fast!!! https://a.example https://b.example https://c.example",
+			expectVerdict: Block,
+			expectFlag:    FlagSpamPhrase,
+		},
+		{
+			name:          "card number",
+			text:          "Send the refund to my card 4111 1111 1111 1111 please.",
+			expectVerdict: Flag,
+			expectFlag:    FlagPII,
+		},
+		{
+			name:          "contact info and repetition",
+			text:          "Amazing!!!!!!!! whatsapp me at +1 555 123 4567",
+			expectVerdict: Flag,
+			expectFlag:    FlagContactInfo,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.Screen(tt.text)
+
+			if result.Verdict != tt.expectVerdict {
+				t.Errorf("expected verdict %s, got %s (score %.2f, flags %v)", tt.expectVerdict, result.Verdict, result.Score, result.Flags)
+			}
+			if tt.expectFlag != "" && !result.HasFlag(tt.expectFlag) {
+				t.Errorf("expected flag %s, got %v", tt.expectFlag, result.Flags)
+			}
+			if result.Score < 0 || result.Score > 1 {
+				t.Errorf("score out of range: %f", result.Score)
+			}
+		})
+	}
+}
+
+func TestScreen_ProfanityMatchesWholeWords(t *testing.T) {
+	s := NewScreener()
+
+	result := s.Screen("We stayed at a lovely inn in Scunthorpe and the owner gave us dinner for free.")
+	if result.HasFlag(FlagProfanity) {
+		t.Errorf("expected no profanity flag, got %v", result.Flags)
+	}
+}
+
+func TestScreen_CardNumbersNeedValidChecksum(t *testing.T) {
+	s := NewScreener()
+
+	result := s.Screen("Order 4111 1111 1111 1112 arrived and the volunteers shared it out.")
+	if result.HasFlag(FlagPII) {
+		t.Errorf("expected no PII flag, got %v", result.Flags)
+	}
+}
+
+// fakeProvider classifies every text the same way
+type fakeProvider struct {
+	classification Classification
+	err            error
+}
+
+func (f fakeProvider) Classify(context.Context, string) (Classification, error) {
+	return f.classification, f.err
+}
+
+func (f fakeProvider) Name() string { return "fake" }
+
+func TestScreenContent(t *testing.T) {
+	ctx := context.Background()
+	s := NewScreener()
+	text := "This was a shit week until a stranger paid for my coffee."
+
+	s.SetProvider(fakeProvider{classification: Classification{Score: 0.9, Flags: []string{"harassment"}}})
+	result := s.ScreenContent(ctx, "act", text)
+	if result.Verdict != Block || result.Score != 0.9 || !slices.Equal(result.Flags, []string{FlagProfanity, "harassment"}) {
+		t.Errorf("expected the provider's score and both flags, got %+v", result)
+	}
+
+	s.SetProvider(fakeProvider{err: errors.New("unavailable")})
+	if result := s.ScreenContent(ctx, "act", text); result.Verdict != Flag || !slices.Equal(result.Flags, []string{FlagProfanity}) {
+		t.Errorf("expected the word lists' result when the provider fails, got %+v", result)
+	}
+}
+
+func TestOpenAI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Input string }
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get("Authorization") != "Bearer key" || req.Input != "some text" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"results": [{"flagged": true,
+			"categories": {"harassment": true, "violence": false, "hate": true},
+			"category_scores": {"harassment": 0.7, "violence": 0.1, "hate": 0.55}}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(Config{Provider: "openai", APIURL: server.URL, APIKey: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := provider.Classify(context.Background(), "some text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Score != 0.7 || !slices.Equal(c.Flags, []string{"harassment", "hate"}) {
+		t.Errorf("expected score 0.7 flagged harassment and hate, got %+v", c)
+	}
+	if _, err := provider.Classify(context.Background(), "other text"); err == nil {
+		t.Error("expected a rejected request to fail")
+	}
+
+	if _, err := NewProvider(Config{Provider: "openai"}); err == nil {
+		t.Error("expected the OpenAI API to need a key")
+	}
+	if _, err := NewProvider(Config{Provider: "perspective"}); err == nil {
+		t.Error("expected an unknown provider rejected")
+	}
+}
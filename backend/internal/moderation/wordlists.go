@@ -0,0 +1,40 @@
+package moderation
+
+// defaultProfanity is a deliberately small list of unambiguous slurs and
+// obscenities. Words that are commonly used innocently are left out to avoid
+// false positives; borderline content is for human moderators.
+var defaultProfanity = []string{
+	"asshole",
+	"bastard",
+	"bitch",
+	"bullshit",
+	"cunt",
+	"dickhead",
+	"fuck",
+	"fucker",
+	"fucking",
+	"motherfucker",
+	"shit",
+	"slut",
+	"twat",
+	"whore",
+}
+
+// defaultSpamPhrases are phrases typical of promotional spam
+var defaultSpamPhrases = []string{
+	"buy now",
+	"click here",
+	"free money",
+	"earn money",
+	"make money fast",
+	"work from home",
+	"limited time offer",
+	"act now",
+	"100% free",
+	"casino",
+	"viagra",
+	"crypto giveaway",
+	"double your bitcoin",
+	"dm me",
+	"whatsapp me",
+}
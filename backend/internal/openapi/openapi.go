@@ -0,0 +1,343 @@
+// Package openapi builds OpenAPI 3 documents in code. Operations are added
+// one by one, and schemas are generated from Go types: their json tags name
+// the properties and their validate tags add the constraints the server
+// enforces.
+package openapi
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version is the OpenAPI version documents declare
+const Version = "3.0.3"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	types      map[reflect.Type]string
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lowercase HTTP methods to the operations of a path
+type PathItem map[string]*Operation
+
+// Operation describes one method on one path
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path, query or header parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body in one content type
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is a JSON schema as OpenAPI 3.0 uses it
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Components holds the schemas operations refer to
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how clients authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// New returns an empty document
+func New(info Info) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]SecurityScheme),
+		},
+		types: make(map[reflect.Type]string),
+	}
+}
+
+// Add documents the route pattern, such as "GET /api/v1/acts/{id}", as the
+// ServeMux understands it. Wildcards in the path become required path
+// parameters, ahead of any parameters op already has.
+func (d *Document) Add(pattern string, op *Operation) error {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || method == "" || !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("openapi: pattern %q needs a method and a path", pattern)
+	}
+	path = strings.ReplaceAll(path, "...}", "}")
+	path = strings.TrimSuffix(path, "{$}")
+
+	var params []Parameter
+	for _, name := range PathParams(path) {
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+	}
+	op.Parameters = append(params, op.Parameters...)
+	if op.Responses == nil {
+		op.Responses = make(map[string]*Response)
+	}
+
+	item := d.Paths[path]
+	if item == nil {
+		item = make(PathItem)
+		d.Paths[path] = item
+	}
+	method = strings.ToLower(method)
+	if _, exists := item[method]; exists {
+		return fmt.Errorf("openapi: %s is documented twice", pattern)
+	}
+	item[method] = op
+	return nil
+}
+
+// Operation returns the operation documented for pattern, or nil
+func (d *Document) Operation(pattern string) *Operation {
+	method, path, _ := strings.Cut(pattern, " ")
+	path = strings.ReplaceAll(path, "...}", "}")
+	path = strings.TrimSuffix(path, "{$}")
+	return d.Paths[path][strings.ToLower(method)]
+}
+
+// PathParams returns the names of the wildcards in path, in order
+func PathParams(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			names = append(names, strings.TrimSuffix(segment[1:len(segment)-1], "..."))
+		}
+	}
+	return names
+}
+
+// Schema returns the schema of v's type. Named struct types are added to
+// the components once and referred to, so recursive types terminate.
+func (d *Document) Schema(v any) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return &Schema{Ref: "#/components/schemas/" + d.component(t)}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Uint:
+		return &Schema{Type: "integer"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return d.structSchema(t)
+	default:
+		// Interfaces and anything else can hold any value
+		return &Schema{}
+	}
+}
+
+// component adds the named struct type t to the components and returns the
+// name it is stored under. Types from different packages that share a name
+// are told apart by their package's name.
+func (d *Document) component(t reflect.Type) string {
+	if name, ok := d.types[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := d.Components.Schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "." + name
+	}
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.types[t] = name
+	d.Components.Schemas[name] = s
+	d.addFields(s, t)
+	return name
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.addFields(s, t)
+	return s
+}
+
+// addFields adds the exported fields of t to s, flattening embedded structs
+// as encoding/json does
+func (d *Document) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.addFields(s, ft)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := d.schemaOf(field.Type)
+		if applyValidation(prop, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+}
+
+// applyValidation adds the constraints of a validate tag to s, which must
+// not be a reference, and reports whether the field is required
+func applyValidation(s *Schema, tag string) (required bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			// Later rules apply to the items
+			return required
+		case "email":
+			s.Format = "email"
+		case "url":
+			s.Format = "uri"
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil || s.Ref != "" {
+				continue
+			}
+			setBound(s, name == "min", n)
+		}
+	}
+	return required
+}
+
+// setBound sets the lower or upper bound that a min or max rule puts on s
+func setBound(s *Schema, lower bool, n int) {
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case "array":
+		if lower {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case "integer", "number":
+		f := float64(n)
+		if lower {
+			s.Minimum = &f
+		} else {
+			s.Maximum = &f
+		}
+	}
+}
+
+// JSON returns a body of application/json with schema s
+func JSON(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
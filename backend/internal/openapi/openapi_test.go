@@ -0,0 +1,92 @@
+package openapi
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+type address struct {
+	City string `json:"city" validate:"required,max=50"`
+}
+
+type person struct {
+	Name     string     `json:"name" validate:"required,min=2,max=100"`
+	Email    string     `json:"email,omitempty" validate:"omitempty,email"`
+	Kind     string     `json:"kind" validate:"oneof=friend stranger"`
+	Secret   string     `json:"-"`
+	Born     time.Time  `json:"born"`
+	Died     *time.Time `json:"died,omitempty"`
+	Tags     []string   `json:"tags" validate:"max=5"`
+	Home     *address   `json:"home,omitempty"`
+	Friends  []person   `json:"friends"`
+	Extra    map[string]int
+	internal string
+}
+
+func TestSchema(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	ref := d.Schema(&person{})
+	if ref.Ref != "#/components/schemas/person" {
+		t.Fatalf("ref = %q", ref.Ref)
+	}
+
+	s := d.Components.Schemas["person"]
+	if !slices.Equal(s.Required, []string{"name"}) {
+		t.Errorf("required = %v, want [name]", s.Required)
+	}
+	if p := s.Properties["name"]; p.Type != "string" || *p.MinLength != 2 || *p.MaxLength != 100 {
+		t.Errorf("name = %+v", p)
+	}
+	if p := s.Properties["email"]; p.Format != "email" {
+		t.Errorf("email format = %q", p.Format)
+	}
+	if p := s.Properties["kind"]; !slices.Equal(p.Enum, []string{"friend", "stranger"}) {
+		t.Errorf("kind enum = %v", p.Enum)
+	}
+	if p := s.Properties["born"]; p.Format != "date-time" || s.Properties["died"].Format != "date-time" {
+		t.Errorf("times should be date-time strings")
+	}
+	if p := s.Properties["tags"]; p.Type != "array" || *p.MaxItems != 5 {
+		t.Errorf("tags = %+v", p)
+	}
+	if p := s.Properties["friends"]; p.Items.Ref != ref.Ref {
+		t.Errorf("friends should refer back to person, got %+v", p.Items)
+	}
+	if p := s.Properties["Extra"]; p.Type != "object" || p.AdditionalProperties.Type != "integer" {
+		t.Errorf("Extra = %+v", p)
+	}
+	for _, name := range []string{"Secret", "internal", "-"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("%s should not be documented", name)
+		}
+	}
+	if _, ok := d.Components.Schemas["address"]; !ok {
+		t.Error("expected the address schema")
+	}
+}
+
+func TestAdd(t *testing.T) {
+	d := New(Info{Title: "test", Version: "1"})
+	op := &Operation{Parameters: []Parameter{{Name: "q", In: "query"}}}
+	if err := d.Add("GET /items/{id}/parts/{part...}", op); err != nil {
+		t.Fatal(err)
+	}
+	if d.Operation("GET /items/{id}/parts/{part...}") != op || d.Paths["/items/{id}/parts/{part}"]["get"] != op {
+		t.Fatal("expected the operation under its path")
+	}
+	var names []string
+	for _, p := range op.Parameters {
+		names = append(names, p.In+":"+p.Name)
+	}
+	if !slices.Equal(names, []string{"path:id", "path:part", "query:q"}) {
+		t.Errorf("parameters = %v", names)
+	}
+
+	if err := d.Add("GET /items/{id}/parts/{part}", &Operation{}); err == nil {
+		t.Error("expected an error for a duplicate operation")
+	}
+	if err := d.Add("/items", &Operation{}); err == nil {
+		t.Error("expected an error for a pattern without a method")
+	}
+}
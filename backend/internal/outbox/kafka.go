@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/version"
+)
+
+// Kafka produces events to a topic through a Kafka REST Proxy
+type Kafka struct {
+	// URL is the REST Proxy's base URL
+	URL      string
+	Topic    string
+	Username string
+	Password string
+	// Client defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string             `json:"key"`
+	Value models.DomainEvent `json:"value"`
+}
+
+type kafkaOffsets struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Publish produces events in one request, keyed by aggregate ID. It fails
+// if the proxy rejects the request or any record.
+func (k *Kafka) Publish(ctx context.Context, events []models.DomainEvent) error {
+	records := make([]kafkaRecord, len(events))
+	for i, e := range events {
+		records[i] = kafkaRecord{Key: e.AggregateID, Value: e}
+	}
+	body, err := json.Marshal(map[string]any{"records": records})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(k.URL, "/") + "/topics/" + url.PathEscape(k.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if k.Username != "" {
+		req.SetBasicAuth(k.Username, k.Password)
+	}
+
+	client := k.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kafka request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka request failed: status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	var offsets kafkaOffsets
+	if err := json.NewDecoder(resp.Body).Decode(&offsets); err != nil {
+		return fmt.Errorf("decoding kafka response: %w", err)
+	}
+	if len(offsets.Offsets) != len(events) {
+		return fmt.Errorf("kafka acknowledged %d of %d events", len(offsets.Offsets), len(events))
+	}
+	for i, o := range offsets.Offsets {
+		if o.ErrorCode != nil || o.Error != "" {
+			return fmt.Errorf("kafka rejected event %s: %s", events[i].ID, o.Error)
+		}
+	}
+	return nil
+}
+
+// Name returns "kafka"
+func (k *Kafka) Name() string { return "kafka" }
@@ -0,0 +1,114 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/models"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATS publishes events to JetStream, waiting for the stream to
+// acknowledge each one. Every event carries its ID as Nats-Msg-Id, so
+// JetStream drops those published again within its duplicate window. The
+// connection is opened by the first Publish and kept, reconnecting as
+// needed, until Close.
+type NATS struct {
+	// URL is nats://host:port or, to require TLS, tls://host:port, with
+	// optional user:password@ or token@ credentials
+	URL string
+	// Subject is followed by the event type to make each event's subject
+	Subject string
+	// Timeout bounds a Publish call. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// Publish sends events and returns once JetStream has stored them all
+func (n *NATS) Publish(ctx context.Context, events []models.DomainEvent) error {
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	js, err := n.jetStream(timeout)
+	if err != nil {
+		return err
+	}
+
+	acks := make([]jetstream.PubAckFuture, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		msg := &nats.Msg{Subject: n.Subject + "." + e.Type, Data: payload}
+		ack, err := js.PublishMsgAsync(msg, jetstream.WithMsgID(e.ID))
+		if err != nil {
+			return fmt.Errorf("publishing to nats: %w", err)
+		}
+		acks = append(acks, ack)
+	}
+
+	for _, ack := range acks {
+		select {
+		case <-ack.Ok():
+		case err := <-ack.Err():
+			return fmt.Errorf("publishing to jetstream on %s.*: %w", n.Subject, err)
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for jetstream acknowledgements: %w", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// jetStream returns the JetStream context of the connection, connecting
+// first if needed
+func (n *NATS) jetStream(timeout time.Duration) (jetstream.JetStream, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn != nil && !n.conn.IsClosed() {
+		return n.js, nil
+	}
+	conn, err := nats.Connect(n.URL, nats.Name("payforward"), nats.Timeout(timeout), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to nats: %w", err)
+	}
+	if !conn.HeadersSupported() {
+		conn.Close()
+		return nil, fmt.Errorf("the nats server doesn't support headers; NATS 2.2 or later is required")
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	n.conn, n.js = conn, js
+	return js, nil
+}
+
+// Close closes the connection, if one was opened
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn, n.js = nil, nil
+	}
+	return nil
+}
+
+// Name returns "nats"
+func (n *NATS) Name() string { return "nats" }
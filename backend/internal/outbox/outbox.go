@@ -0,0 +1,149 @@
+// Package outbox publishes the domain events repositories record in the
+// outbox, in the order their transactions committed, to a message broker:
+// Kafka through its REST Proxy, NATS JetStream or the log for development.
+// Events are published at least once; consumers drop the ones whose ID they
+// have already seen.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+)
+
+// DefaultBatchSize bounds the events read from the outbox and published at
+// once
+const DefaultBatchSize = 100
+
+// Publisher sends events to a broker
+type Publisher interface {
+	// Publish sends events, in order, returning once the broker has
+	// accepted them all. On error some may have been sent anyway.
+	Publish(ctx context.Context, events []models.DomainEvent) error
+	// Name names the publisher in logs and metrics
+	Name() string
+}
+
+// Config selects and configures a publisher
+type Config struct {
+	// Driver is "kafka", "nats", "log" or empty to publish nothing
+	Driver string
+
+	// KafkaRESTURL is the base URL of a Kafka REST Proxy (v2 API), which
+	// the kafka driver produces to KafkaTopic through. Events are keyed by
+	// aggregate ID, so each aggregate's events stay in order in one
+	// partition. KafkaUsername and KafkaPassword enable basic auth.
+	KafkaRESTURL  string
+	KafkaTopic    string
+	KafkaUsername string
+	KafkaPassword string
+
+	// NATSURL is the nats:// or tls:// address of a NATS server, with
+	// credentials as user:password@ or token@. Events are published on
+	// NATSSubject followed by their type, such as
+	// "payforward.events.act.created", which a JetStream stream must
+	// capture.
+	NATSURL     string
+	NATSSubject string
+}
+
+// NewPublisher returns the publisher described by cfg, or nil if publishing
+// is disabled
+func NewPublisher(cfg Config) (Publisher, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case "log":
+		return Log{}, nil
+	case "kafka":
+		if cfg.KafkaRESTURL == "" || cfg.KafkaTopic == "" {
+			return nil, errors.New("the kafka outbox driver needs a REST Proxy URL and a topic")
+		}
+		if u, err := url.Parse(cfg.KafkaRESTURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid Kafka REST Proxy URL %q", cfg.KafkaRESTURL)
+		}
+		return &Kafka{URL: cfg.KafkaRESTURL, Topic: cfg.KafkaTopic, Username: cfg.KafkaUsername, Password: cfg.KafkaPassword}, nil
+	case "nats":
+		if cfg.NATSURL == "" || cfg.NATSSubject == "" {
+			return nil, errors.New("the nats outbox driver needs a server URL and a subject")
+		}
+		if u, err := url.Parse(cfg.NATSURL); err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+			return nil, fmt.Errorf("invalid NATS URL %q, expected nats://host:port or tls://host:port", cfg.NATSURL)
+		}
+		return &NATS{URL: cfg.NATSURL, Subject: cfg.NATSSubject}, nil
+	}
+	return nil, fmt.Errorf("unknown outbox driver %q; use kafka, nats or log", cfg.Driver)
+}
+
+// Store is the outbox the relay reads from
+type Store interface {
+	// Pending returns up to limit unpublished events, in sequence order
+	Pending(ctx context.Context, limit int) ([]models.DomainEvent, error)
+	// MarkPublished records the events with ids as published at now
+	MarkPublished(ctx context.Context, ids []string, now time.Time) error
+}
+
+// Relay moves events from the outbox to a publisher
+type Relay struct {
+	store     Store
+	publisher Publisher
+	// BatchSize bounds the events published at once. Defaults to
+	// DefaultBatchSize.
+	BatchSize int
+}
+
+// NewRelay creates a relay publishing the events in store with publisher
+func NewRelay(store Store, publisher Publisher) *Relay {
+	return &Relay{store: store, publisher: publisher, BatchSize: DefaultBatchSize}
+}
+
+// Run publishes the pending events in batches, in order, until none is left
+// or a batch fails, and returns how many it published. A failed batch is
+// published again, whole, by the next run, so the order holds.
+func (r *Relay) Run(ctx context.Context) (int, error) {
+	published := 0
+	for {
+		events, err := r.store.Pending(ctx, r.BatchSize)
+		if err != nil || len(events) == 0 {
+			return published, err
+		}
+		if err := r.publisher.Publish(ctx, events); err != nil {
+			metrics.OutboxFailures.WithLabelValues(r.publisher.Name()).Inc()
+			return published, fmt.Errorf("publishing to %s: %w", r.publisher.Name(), err)
+		}
+
+		ids := make([]string, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+			metrics.OutboxPublished.WithLabelValues(r.publisher.Name(), e.Type).Inc()
+		}
+		if err := r.store.MarkPublished(ctx, ids, time.Now().UTC()); err != nil {
+			return published, err
+		}
+		published += len(events)
+		if len(events) < r.BatchSize {
+			return published, nil
+		}
+	}
+}
+
+// Log writes events to the log instead of publishing them, for development
+type Log struct{}
+
+// Publish logs each event
+func (Log) Publish(ctx context.Context, events []models.DomainEvent) error {
+	for _, e := range events {
+		slog.InfoContext(ctx, "Domain event", "sequence", e.Sequence, "type", e.Type,
+			"aggregate_id", e.AggregateID, "data", string(e.Data))
+	}
+	return nil
+}
+
+// Name returns "log"
+func (Log) Name() string { return "log" }
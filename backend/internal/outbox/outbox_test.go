@@ -0,0 +1,228 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+)
+
+// recorder is a Publisher failing with the queued errors before
+// succeeding
+type recorder struct {
+	errs      []error
+	published []models.DomainEvent
+}
+
+func (r *recorder) Publish(_ context.Context, events []models.DomainEvent) error {
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return err
+	}
+	r.published = append(r.published, events...)
+	return nil
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func testEvents(n int) []models.DomainEvent {
+	events := make([]models.DomainEvent, n)
+	for i := range events {
+		events[i] = models.DomainEvent{
+			ID:            fmt.Sprintf("e%d", i+1),
+			Sequence:      int64(i + 1),
+			Type:          models.DomainActCreated,
+			AggregateType: "act",
+			AggregateID:   fmt.Sprintf("a%d", i%2),
+			Data:          json.RawMessage(`{}`),
+		}
+	}
+	return events
+}
+
+func TestRelay(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1"}, "")
+	acts := db.Repositories().Acts
+	for i := range 5 {
+		acts.Create(ctx, &models.Act{ID: fmt.Sprintf("a%d", i), GiverID: "u1"})
+	}
+
+	publisher := &recorder{errs: []error{errors.New("broker unavailable")}}
+	relay := NewRelay(db.Outbox(), publisher)
+	relay.BatchSize = 2
+
+	if n, err := relay.Run(ctx); err == nil || n != 0 {
+		t.Fatalf("expected the first batch to fail, got %d published and %v", n, err)
+	}
+	n, err := relay.Run(ctx)
+	if err != nil || n != 5 {
+		t.Fatalf("expected the 5 events to be published, got %d and %v", n, err)
+	}
+	for i, e := range publisher.published {
+		if e.AggregateID != fmt.Sprintf("a%d", i) {
+			t.Errorf("expected the events in order, got %s at %d", e.AggregateID, i)
+		}
+	}
+	if n, _ := relay.Run(ctx); n != 0 {
+		t.Errorf("expected nothing left to publish, got %d", n)
+	}
+}
+
+func TestKafka(t *testing.T) {
+	var body struct {
+		Records []struct {
+			Key   string             `json:"key"`
+			Value models.DomainEvent `json:"value"`
+		} `json:"records"`
+	}
+	reject := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/payforward.events" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		if user, pass, _ := r.BasicAuth(); user != "producer" || pass != "secret" {
+			t.Errorf("expected basic auth, got %q %q", user, pass)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if reject {
+			fmt.Fprint(w, `{"offsets":[{"partition":0,"offset":1},{"partition":null,"offset":null,"error_code":40403,"error":"not authorized"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"offsets":[{"partition":0,"offset":1},{"partition":1,"offset":1}]}`)
+	}))
+	defer server.Close()
+
+	k := &Kafka{URL: server.URL + "/", Topic: "payforward.events", Username: "producer", Password: "secret"}
+	if err := k.Publish(context.Background(), testEvents(2)); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Records) != 2 || body.Records[1].Key != "a1" || body.Records[1].Value.ID != "e2" {
+		t.Errorf("unexpected records %+v", body.Records)
+	}
+
+	reject = true
+	if err := k.Publish(context.Background(), testEvents(2)); err == nil || !strings.Contains(err.Error(), "not authorized") {
+		t.Errorf("expected a rejected record to fail the batch, got %v", err)
+	}
+}
+
+// fakeJetStream accepts one NATS connection and acknowledges each HPUB
+// with ack, sending the published subjects and headers to published
+func fakeJetStream(t *testing.T, ack string, published chan<- string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"proto\":1,\"headers\":true,\"max_payload\":1048576}\r\n")
+		r := bufio.NewReader(conn)
+		sids := map[string]string{}
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+			switch fields[0] {
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+				continue
+			case "SUB":
+				sids[strings.TrimSuffix(fields[1], "*")] = fields[len(fields)-1]
+				continue
+			case "HPUB":
+			default:
+				continue
+			}
+			subject, reply := fields[1], fields[2]
+			total, _ := strconv.Atoi(fields[4])
+			msg := make([]byte, total+2)
+			io.ReadFull(r, msg)
+			published <- subject + " " + strings.Split(string(msg), "\r\n")[1]
+			sid := sids[reply[:strings.LastIndex(reply, ".")+1]]
+			if ack == "503" {
+				fmt.Fprintf(conn, "HMSG %s %s 16 16\r\nNATS/1.0 503\r\n\r\n\r\n", reply, sid)
+				continue
+			}
+			fmt.Fprintf(conn, "MSG %s %s %d\r\n%s\r\n", reply, sid, len(ack), ack)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestNATS(t *testing.T) {
+	published := make(chan string, 10)
+	addr := fakeJetStream(t, `{"stream":"EVENTS","seq":1}`, published)
+
+	n := &NATS{URL: "nats://token@" + addr, Subject: "payforward.events"}
+	defer n.Close()
+	if err := n.Publish(context.Background(), testEvents(2)); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"payforward.events.act.created Nats-Msg-Id: e1",
+		"payforward.events.act.created Nats-Msg-Id: e2",
+	} {
+		if got := <-published; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestNATSFailures(t *testing.T) {
+	for name, ack := range map[string]string{
+		"no stream":       "503",
+		"jetstream error": `{"error":{"code":503,"err_code":10077,"description":"insufficient resources"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			addr := fakeJetStream(t, ack, make(chan string, 10))
+			n := &NATS{URL: "nats://" + addr, Subject: "payforward.events", Timeout: 5 * time.Second}
+			defer n.Close()
+			if err := n.Publish(context.Background(), testEvents(1)); err == nil {
+				t.Error("expected the publish to fail")
+			}
+		})
+	}
+}
+
+func TestNewPublisher(t *testing.T) {
+	for _, cfg := range []Config{
+		{Driver: "kafka", KafkaTopic: "events"},
+		{Driver: "kafka", KafkaRESTURL: "kafka:9092", KafkaTopic: "events"},
+		{Driver: "nats", NATSURL: "http://localhost:4222", NATSSubject: "events"},
+		{Driver: "rabbitmq"},
+	} {
+		if _, err := NewPublisher(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	if p, err := NewPublisher(Config{}); p != nil || err != nil {
+		t.Errorf("expected no publisher without a driver, got %v and %v", p, err)
+	}
+}
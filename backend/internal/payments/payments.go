@@ -0,0 +1,92 @@
+// Package payments holds funds for pledged acts through a payment
+// provider: an amount is authorized on the giver's payment method, then
+// captured when the act is confirmed or cancelled to refund the giver.
+// Providers are pluggable: Stripe, or the log for development.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// Hold describes funds to authorize without capturing them
+type Hold struct {
+	// Key identifies the hold to the provider, so retrying a request
+	// doesn't place a second hold
+	Key      string
+	Amount   float64
+	Currency string
+	// PaymentMethod is the provider's reference to the giver's payment
+	// method, created by the client with the provider's SDK
+	PaymentMethod string
+	Description   string
+}
+
+// Provider places, captures and cancels holds
+type Provider interface {
+	// Hold authorizes h and returns the provider's reference to it
+	Hold(ctx context.Context, h Hold) (string, error)
+	// Capture collects the held funds. Capturing a captured hold again
+	// succeeds.
+	Capture(ctx context.Context, ref string) error
+	// Cancel releases a hold that wasn't captured, so the giver isn't
+	// charged. Cancelling a cancelled hold again succeeds.
+	Cancel(ctx context.Context, ref string) error
+	// Name names the provider in logs and metrics
+	Name() string
+}
+
+// ErrDeclined is returned when the provider refuses a hold, such as for a
+// declined card or one that needs the giver to authenticate
+var ErrDeclined = errors.New("payment declined")
+
+// Config selects and configures a provider
+type Config struct {
+	// Driver is "stripe", "log" or empty to disable pledges
+	Driver string
+	// StripeSecretKey configures the stripe driver
+	StripeSecretKey string
+}
+
+// NewProvider returns the provider described by cfg, or nil if pledges are
+// disabled
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case "log":
+		return Log{}, nil
+	case "stripe":
+		if cfg.StripeSecretKey == "" {
+			return nil, errors.New("the stripe payments driver needs a secret key")
+		}
+		return &Stripe{SecretKey: cfg.StripeSecretKey}, nil
+	}
+	return nil, fmt.Errorf("unknown payments driver %q; use stripe or log", cfg.Driver)
+}
+
+// Log accepts every hold without moving money, for development
+type Log struct{}
+
+// Hold logs h and returns its key as the reference
+func (Log) Hold(ctx context.Context, h Hold) (string, error) {
+	slog.InfoContext(ctx, "Payment held", "key", h.Key, "amount", h.Amount, "currency", h.Currency)
+	return "log_" + h.Key, nil
+}
+
+// Capture logs the capture
+func (Log) Capture(ctx context.Context, ref string) error {
+	slog.InfoContext(ctx, "Payment captured", "ref", ref)
+	return nil
+}
+
+// Cancel logs the cancellation
+func (Log) Cancel(ctx context.Context, ref string) error {
+	slog.InfoContext(ctx, "Payment hold cancelled", "ref", ref)
+	return nil
+}
+
+// Name returns "log"
+func (Log) Name() string { return "log" }
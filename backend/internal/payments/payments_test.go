@@ -0,0 +1,93 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripeHold(t *testing.T) {
+	var requests []*http.Request
+	status := "requires_capture"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r)
+		if user, _, _ := r.BasicAuth(); user != "sk_test" {
+			t.Errorf("expected the secret key as basic auth, got %q", user)
+		}
+		switch r.URL.Path {
+		case "/v1/payment_intents":
+			fmt.Fprintf(w, `{"id":"pi_1","status":%q}`, status)
+		case "/v1/payment_intents/pi_1/cancel", "/v1/payment_intents/pi_1/capture":
+			fmt.Fprint(w, `{"id":"pi_1"}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	s := &Stripe{SecretKey: "sk_test", URL: server.URL}
+	ref, err := s.Hold(context.Background(), Hold{Key: "p1", Amount: 12.345, Currency: "EUR", PaymentMethod: "pm_card"})
+	if err != nil || ref != "pi_1" {
+		t.Fatalf("expected the payment intent, got %q and %v", ref, err)
+	}
+	form := requests[0].PostForm
+	if form.Get("amount") != "1235" || form.Get("currency") != "eur" || form.Get("capture_method") != "manual" || form.Get("payment_method") != "pm_card" {
+		t.Errorf("unexpected payment intent %v", form)
+	}
+	if requests[0].Header.Get("Idempotency-Key") != "p1:hold" {
+		t.Errorf("expected an idempotency key, got %q", requests[0].Header.Get("Idempotency-Key"))
+	}
+
+	if _, err := s.Hold(context.Background(), Hold{Key: "p2", Amount: 500, Currency: "JPY"}); err != nil {
+		t.Fatal(err)
+	}
+	if amount := requests[1].PostForm.Get("amount"); amount != "500" {
+		t.Errorf("expected a zero-decimal currency in whole units, got %s", amount)
+	}
+
+	status = "requires_action"
+	requests = nil
+	if _, err := s.Hold(context.Background(), Hold{Key: "p3", Amount: 10, Currency: "USD"}); !errors.Is(err, ErrDeclined) {
+		t.Errorf("expected a hold needing authentication to be declined, got %v", err)
+	}
+	if len(requests) != 2 || requests[1].URL.Path != "/v1/payment_intents/pi_1/cancel" {
+		t.Errorf("expected the incomplete payment intent to be cancelled")
+	}
+}
+
+func TestStripeErrors(t *testing.T) {
+	code := http.StatusPaymentRequired
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+		if code == http.StatusPaymentRequired {
+			fmt.Fprint(w, `{"error":{"type":"card_error","message":"Your card was declined."}}`)
+			return
+		}
+		fmt.Fprint(w, `{"error":{"type":"api_error","message":"Something went wrong."}}`)
+	}))
+	defer server.Close()
+
+	s := &Stripe{SecretKey: "sk_test", URL: server.URL}
+	if _, err := s.Hold(context.Background(), Hold{Key: "p1", Amount: 1, Currency: "USD"}); !errors.Is(err, ErrDeclined) {
+		t.Errorf("expected a declined card, got %v", err)
+	}
+	code = http.StatusInternalServerError
+	if err := s.Capture(context.Background(), "pi_1"); err == nil || errors.Is(err, ErrDeclined) {
+		t.Errorf("expected a provider failure, got %v", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, cfg := range []Config{{Driver: "stripe"}, {Driver: "paypal"}} {
+		if _, err := NewProvider(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	if p, err := NewProvider(Config{}); p != nil || err != nil {
+		t.Errorf("expected no provider without a driver, got %v and %v", p, err)
+	}
+}
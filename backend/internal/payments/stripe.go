@@ -0,0 +1,140 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/version"
+)
+
+// DefaultStripeURL is the base URL of Stripe's API
+const DefaultStripeURL = "https://api.stripe.com"
+
+// zeroDecimalCurrencies are the currencies Stripe takes in whole units
+// rather than hundredths
+var zeroDecimalCurrencies = map[string]bool{
+	"bif": true, "clp": true, "djf": true, "gnf": true, "jpy": true, "kmf": true, "krw": true, "mga": true,
+	"pyg": true, "rwf": true, "ugx": true, "vnd": true, "vuv": true, "xaf": true, "xof": true, "xpf": true,
+}
+
+// Stripe holds funds with PaymentIntents captured manually. Card
+// authorizations expire after 7 days, so holds should be captured or
+// cancelled sooner.
+type Stripe struct {
+	SecretKey string
+	// URL defaults to DefaultStripeURL
+	URL string
+	// Client defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+type stripeIntent struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Hold creates and confirms a PaymentIntent for h with manual capture. A
+// card that is declined or needs the giver to authenticate returns
+// ErrDeclined.
+func (s *Stripe) Hold(ctx context.Context, h Hold) (string, error) {
+	currency := strings.ToLower(h.Currency)
+	amount := h.Amount
+	if !zeroDecimalCurrencies[currency] {
+		amount *= 100
+	}
+	form := url.Values{
+		"amount":                 {strconv.FormatInt(int64(math.Round(amount)), 10)},
+		"currency":               {currency},
+		"payment_method":         {h.PaymentMethod},
+		"payment_method_types[]": {"card"},
+		"capture_method":         {"manual"},
+		"confirm":                {"true"},
+		"description":            {h.Description},
+		"metadata[pledge_id]":    {h.Key},
+	}
+	var intent stripeIntent
+	if err := s.post(ctx, "/v1/payment_intents", h.Key+":hold", form, &intent); err != nil {
+		return "", err
+	}
+	if intent.Status != "requires_capture" {
+		if err := s.Cancel(ctx, intent.ID); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: payment intent is %s", ErrDeclined, intent.Status)
+	}
+	return intent.ID, nil
+}
+
+// Capture captures a PaymentIntent
+func (s *Stripe) Capture(ctx context.Context, ref string) error {
+	return s.post(ctx, "/v1/payment_intents/"+url.PathEscape(ref)+"/capture", ref+":capture", nil, nil)
+}
+
+// Cancel cancels a PaymentIntent, releasing its authorization
+func (s *Stripe) Cancel(ctx context.Context, ref string) error {
+	return s.post(ctx, "/v1/payment_intents/"+url.PathEscape(ref)+"/cancel", ref+":cancel", nil, nil)
+}
+
+// Name returns "stripe"
+func (s *Stripe) Name() string { return "stripe" }
+
+// post sends a form to Stripe with an idempotency key, so retries of the
+// same operation are answered with the first response, and decodes the
+// response into out, if any
+func (s *Stripe) post(ctx context.Context, path, key string, form url.Values, out any) error {
+	base := s.URL
+	if base == "" {
+		base = DefaultStripeURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(base, "/")+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.SecretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Idempotency-Key", key)
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("stripe request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(body, out)
+	}
+	var failure stripeError
+	json.Unmarshal(body, &failure)
+	err = fmt.Errorf("stripe request failed: status %d: %s", resp.StatusCode, failure.Error.Message)
+	if resp.StatusCode == http.StatusPaymentRequired || failure.Error.Type == "card_error" {
+		return fmt.Errorf("%w: %s", ErrDeclined, failure.Error.Message)
+	}
+	return err
+}
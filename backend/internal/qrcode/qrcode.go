@@ -0,0 +1,96 @@
+// Package qrcode encodes text as QR codes (ISO/IEC 18004, model 2), using
+// the boombuler/barcode encoder, and renders them as PNG or SVG images
+// sized for printed cards
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+)
+
+// Level is an error correction level. Higher levels survive more damage
+// to the printed code, at the cost of a denser symbol.
+type Level int
+
+const (
+	// Low recovers about 7% of the codewords
+	Low Level = iota
+	// Medium recovers about 15% of the codewords
+	Medium
+	// Quartile recovers about 25% of the codewords
+	Quartile
+	// High recovers about 30% of the codewords
+	High
+)
+
+// ErrTooLong is returned when the text doesn't fit in the largest QR code
+// at the requested error correction level
+var ErrTooLong = errors.New("text too long for a QR code")
+
+// ParseLevel parses an error correction level from its letter: L, M, Q or
+// H, in either case
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return Low, nil
+	case "M":
+		return Medium, nil
+	case "Q":
+		return Quartile, nil
+	case "H":
+		return High, nil
+	}
+	return 0, fmt.Errorf("unknown error correction level %q", s)
+}
+
+// String returns the level's letter
+func (l Level) String() string {
+	return [...]string{"L", "M", "Q", "H"}[l]
+}
+
+// encoderLevel returns the encoder's equivalent of the level
+func (l Level) encoderLevel() qr.ErrorCorrectionLevel {
+	return [...]qr.ErrorCorrectionLevel{qr.L, qr.M, qr.Q, qr.H}[l]
+}
+
+// Code is an encoded QR code: a square grid of dark and light modules,
+// without the quiet zone around it
+type Code struct {
+	Version int
+	Level   Level
+	Size    int
+
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x and row y is dark. Modules
+// outside the grid, in the quiet zone, are light.
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.modules[y][x]
+}
+
+// Encode encodes text in byte mode in the smallest QR code that holds it at
+// level
+func Encode(text string, level Level) (*Code, error) {
+	symbol, err := qr.Encode(text, level.encoderLevel(), qr.Unicode)
+	if err != nil {
+		// Byte mode accepts any text, so the encoder only fails when it
+		// doesn't fit
+		return nil, fmt.Errorf("%w: %v", ErrTooLong, err)
+	}
+
+	size := symbol.Bounds().Dx()
+	code := &Code{Version: (size - 17) / 4, Level: level, Size: size, modules: make([][]bool, size)}
+	for y := range code.modules {
+		code.modules[y] = make([]bool, size)
+		for x := range code.modules[y] {
+			gray := color.GrayModel.Convert(symbol.At(x, y)).(color.Gray)
+			code.modules[y][x] = gray.Y < 128
+		}
+	}
+	return code, nil
+}
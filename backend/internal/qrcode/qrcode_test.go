@@ -0,0 +1,188 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncode_Versions(t *testing.T) {
+	tests := []struct {
+		text    string
+		level   Level
+		version int
+	}{
+		{"https://payforward.example/chains/c1", Low, 3},
+		{"https://payforward.example/chains/c1", High, 5},
+		{"https://payforward.example/acts/5f0c2a9e-3d1b-4c8e-9a7f-1b2c3d4e5f60", Medium, 5},
+		{strings.Repeat("pay it forward ", 20), Quartile, 16},
+		{strings.Repeat("kindness ", 300), Low, 39},
+	}
+
+	for _, tt := range tests {
+		code, err := Encode(tt.text, tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes, %s): %v", len(tt.text), tt.level, err)
+		}
+		if code.Version != tt.version || code.Size != tt.version*4+17 {
+			t.Errorf("expected version %d for %d bytes at %s, got %d", tt.version, len(tt.text), tt.level, code.Version)
+		}
+	}
+}
+
+func TestEncode_FunctionPatterns(t *testing.T) {
+	code, err := Encode("https://payforward.example/chains/c1", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	finder := []string{
+		"#######",
+		"#.....#",
+		"#.###.#",
+		"#.###.#",
+		"#.###.#",
+		"#.....#",
+		"#######",
+	}
+	for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+		for y, row := range finder {
+			for x, c := range row {
+				if code.Dark(corner[0]+x, corner[1]+y) != (c == '#') {
+					t.Fatalf("expected a finder pattern at %v, wrong module at %d,%d", corner, x, y)
+				}
+			}
+		}
+	}
+	for i := 8; i < code.Size-8; i++ {
+		if code.Dark(i, 6) != (i%2 == 0) || code.Dark(6, i) != (i%2 == 0) {
+			t.Fatalf("expected alternating timing patterns, wrong module at %d", i)
+		}
+	}
+	if !code.Dark(8, code.Size-8) {
+		t.Error("expected the dark module next to the bottom finder pattern")
+	}
+	if code.Dark(-1, 0) || code.Dark(code.Size, 0) {
+		t.Error("expected the quiet zone light")
+	}
+}
+
+func TestEncode_FormatAndVersionBits(t *testing.T) {
+	// Format information for level L and each mask, from the standard
+	low := []int{0x77C4, 0x72F3, 0x7DAA, 0x789D, 0x662F, 0x6318, 0x6C41, 0x6976}
+	code, err := Encode("https://payforward.example/chains/c1", Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both copies of the format information must hold the same valid word
+	format, copied := 0, 0
+	for i := 0; i <= 5; i++ {
+		format |= b2i(code.Dark(8, i)) << i
+	}
+	format |= b2i(code.Dark(8, 7))<<6 | b2i(code.Dark(8, 8))<<7 | b2i(code.Dark(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		format |= b2i(code.Dark(14-i, 8)) << i
+	}
+	for i := 0; i < 8; i++ {
+		copied |= b2i(code.Dark(code.Size-1-i, 8)) << i
+	}
+	for i := 8; i < 15; i++ {
+		copied |= b2i(code.Dark(8, code.Size-15+i)) << i
+	}
+	if !slices.Contains(low, format) || copied != format {
+		t.Errorf("expected level L format bits in both copies, got %#x and %#x", format, copied)
+	}
+
+	code, err = Encode(strings.Repeat("x", 150), Low)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Version != 7 {
+		t.Fatalf("expected version 7, got %d", code.Version)
+	}
+	version := 0
+	for i := 0; i < 18; i++ {
+		version |= b2i(code.Dark(code.Size-11+i%3, i/3)) << i
+	}
+	if version != 0x07C94 {
+		t.Errorf("expected version bits 0x07c94, got %#x", version)
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("x", 2954), Low); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected ErrTooLong past the largest code, got %v", err)
+	}
+	if _, err := Encode(strings.Repeat("x", 2953), High); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected ErrTooLong at level H, got %v", err)
+	}
+	if _, err := Encode(strings.Repeat("x", 2953), Low); err != nil {
+		t.Errorf("expected the largest code to hold 2953 bytes, got %v", err)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, expected := range map[string]Level{"L": Low, "m": Medium, "Q": Quartile, "h": High} {
+		if got, err := ParseLevel(s); err != nil || got != expected {
+			t.Errorf("ParseLevel(%q): expected %s, got %s, %v", s, expected, got, err)
+		}
+	}
+	if _, err := ParseLevel("X"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestRender(t *testing.T) {
+	code, err := Encode("https://payforward.example/chains/c1", Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := code.PNG(300)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a valid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Errorf("expected a 300x300 image, got %v", b)
+	}
+
+	// 29 modules plus the quiet zone at 8 pixels each, centered
+	scale, offset := 8, (300-37*8)/2+QuietZone*8
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			r, _, _, _ := img.At(offset+x*scale+scale/2, offset+y*scale+scale/2).RGBA()
+			if (r == 0) != code.Dark(x, y) {
+				t.Fatalf("expected the pixels of module %d,%d to match it", x, y)
+			}
+		}
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r == 0 {
+		t.Error("expected a light quiet zone")
+	}
+
+	if data, _ := code.PNG(10); len(data) == 0 {
+		t.Error("expected a PNG even below one pixel per module")
+	}
+
+	svg := string(code.SVG(300))
+	if !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, `width="300"`) || !strings.Contains(svg, `viewBox="0 0 37 37"`) {
+		t.Errorf("expected a 300 pixel SVG of 37 modules, got %.120s", svg)
+	}
+	if !strings.Contains(svg, "M4 4h1v1h-1z") {
+		t.Error("expected the top left finder module past the quiet zone")
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
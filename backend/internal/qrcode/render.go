@@ -0,0 +1,76 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+)
+
+// QuietZone is the width, in modules, of the light border scanners need
+// around a code
+const QuietZone = 4
+
+// modulesWide returns the width of the code with its quiet zone, in modules
+func (c *Code) modulesWide() int {
+	return c.Size + 2*QuietZone
+}
+
+// PNG renders the code as a black and white PNG size pixels wide. Modules
+// are drawn with a whole number of pixels each, so they stay sharp when
+// printed, and centered on the image, which grows when size is too small
+// for one pixel per module.
+func (c *Code) PNG(size int) ([]byte, error) {
+	wide := c.modulesWide()
+	scale := max(size/wide, 1)
+	size = max(size, wide*scale)
+	offset := (size-wide*scale)/2 + QuietZone*scale
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.modules[y][x] {
+				continue
+			}
+			for py := 0; py < scale; py++ {
+				row := img.Pix[(offset+y*scale+py)*img.Stride:]
+				for px := 0; px < scale; px++ {
+					row[offset+x*scale+px] = 1
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding QR code PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG renders the code as an SVG image size pixels wide. Its dark modules
+// are a single path in a viewBox one unit per module, so it scales to any
+// print size.
+func (c *Code) SVG(size int) []byte {
+	wide := c.modulesWide()
+
+	var path strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/>`+
+		`<path d="%s" fill="#000"/>`+
+		`</svg>`,
+		size, size, wide, wide,
+		wide, wide,
+		path.String(),
+	))
+}
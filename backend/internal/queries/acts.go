@@ -0,0 +1,265 @@
+package queries
+
+// ActCount returns the total number of acts that aren't deleted
+var ActCount = register("acts.count", `
+	MATCH (a:Act) WHERE a.deletedAt IS NULL RETURN count(a) as total
+`)
+
+// ActList returns a page of acts a, newest first, with their giver and
+// receiver
+var ActList = register("acts.list", `
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL
+	OPTIONAL MATCH (giver:User)-[:GAVE]->(a)
+	WHERE giver.deletedAt IS NULL
+	OPTIONAL MATCH (a)-[:RECEIVED_BY]->(receiver:User)
+	WHERE receiver.deletedAt IS NULL
+	RETURN a, giver, receiver
+	ORDER BY a.createdAt DESC
+	SKIP $skip LIMIT $limit
+`, "skip", "limit")
+
+// ActNearbyCount returns the number of acts placed within $radius meters
+// of $latitude, $longitude
+var ActNearbyCount = register("acts.nearby_count", `
+	WITH point({latitude: $latitude, longitude: $longitude}) AS origin
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL AND point.distance(a.position, origin) <= $radius
+	RETURN count(a) as total
+`, "latitude", "longitude", "radius")
+
+// ActNearby returns a page of the acts a placed within $radius meters of
+// $latitude, $longitude, nearest first, with their distance in meters
+var ActNearby = register("acts.nearby", `
+	WITH point({latitude: $latitude, longitude: $longitude}) AS origin
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL AND point.distance(a.position, origin) <= $radius
+	WITH a, point.distance(a.position, origin) AS distance
+	RETURN a, distance
+	ORDER BY distance, a.id
+	SKIP $skip LIMIT $limit
+`, "latitude", "longitude", "radius", "skip", "limit")
+
+// ActStream returns every act a that isn't deleted, oldest first, for
+// exports
+var ActStream = register("acts.stream", `
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL
+	RETURN a
+	ORDER BY a.createdAt, a.id
+`)
+
+// ActGet returns act a with its giver and receiver, or no rows if it
+// doesn't exist or was deleted
+var ActGet = register("acts.get", `
+	MATCH (a:Act {id: $id})
+	WHERE a.deletedAt IS NULL
+	OPTIONAL MATCH (giver:User)-[:GAVE]->(a)
+	WHERE giver.deletedAt IS NULL
+	OPTIONAL MATCH (a)-[:RECEIVED_BY]->(receiver:User)
+	WHERE receiver.deletedAt IS NULL
+	RETURN a, giver, receiver
+`, "id")
+
+// ActGiver returns the ID of the user who gave act $id as giverId, even if
+// the act is anonymous, or no rows if it doesn't exist or was deleted
+var ActGiver = register("acts.giver", `
+	MATCH (giver:User)-[:GAVE]->(a:Act {id: $id})
+	WHERE a.deletedAt IS NULL
+	RETURN giver.id AS giverId
+`, "id")
+
+// ActCreate creates an act linked to its giver and, when $chainId names a
+// chain, adds it to the chain with the giver as a participant. When
+// $campaignId names a campaign, the act is tagged to it and the giver joins
+// it. When $organizationId names an organization the giver is a member of,
+// the act is GIVEN_FOR it. It returns no rows, and creates nothing, when the
+// giver doesn't exist or was deleted.
+// The act's value is converted to the base currency with the stored rate
+// for its currency, if there is one.
+var ActCreate = register("acts.create", `
+	CREATE (a:Act {
+		id: $id,
+		title: $title,
+		description: $description,
+		type: $type,
+		category: $category,
+		value: $value,
+		currency: $currency,
+		status: $status,
+		giverId: $giverId,
+		receiverId: $receiverId,
+		chainId: $chainId,
+		campaignId: $campaignId,
+		organizationId: $organizationId,
+		location: $location,
+		isAnonymous: $isAnonymous,
+		version: 1,
+		createdAt: $createdAt,
+		updatedAt: $updatedAt
+	})
+	WITH a
+	OPTIONAL MATCH (x:ExchangeRate {currency: toUpper(a.currency)})
+	SET a.baseValue = a.value / x.rate, a.baseCurrency = x.base
+	WITH a
+	MATCH (giver:User {id: $giverId})
+	WHERE giver.deletedAt IS NULL
+	CREATE (giver)-[:GAVE]->(a)
+	WITH a, giver
+	OPTIONAL MATCH (c:Chain {id: $chainId})
+	FOREACH (chain IN CASE WHEN c IS NULL THEN [] ELSE [c] END |
+		CREATE (chain)-[:CONTAINS]->(a)
+		MERGE (giver)-[:PARTICIPATED_IN]->(chain)
+		SET chain.updatedAt = $createdAt
+	)
+	WITH a, giver
+	OPTIONAL MATCH (cp:Campaign {id: $campaignId})
+	FOREACH (campaign IN CASE WHEN cp IS NULL THEN [] ELSE [cp] END |
+		CREATE (a)-[:PART_OF]->(campaign)
+		MERGE (giver)-[j:JOINED]->(campaign)
+		ON CREATE SET j.joinedAt = $createdAt
+	)
+	WITH a, giver
+	OPTIONAL MATCH (giver)-[:MEMBER_OF]->(o:Organization {id: $organizationId})
+	FOREACH (org IN CASE WHEN o IS NULL THEN [] ELSE [o] END |
+		CREATE (a)-[:GIVEN_FOR]->(org)
+	)
+	RETURN a
+`, "id", "title", "description", "type", "category", "value", "currency", "status",
+	"giverId", "receiverId", "chainId", "campaignId", "organizationId", "location", "isAnonymous", "createdAt", "updatedAt")
+
+// ActUpdate sets the fields of act a that aren't null and bumps its version,
+// unless a non-null $version isn't the current one. The act is write locked
+// before its version is compared, so concurrent updates can't both match.
+// Completing an act records when as completedAt. It returns a and whether it
+// was updated as current, or no rows if it doesn't exist.
+var ActUpdate = register("acts.update", `
+	MATCH (a:Act {id: $id})
+	WHERE a.deletedAt IS NULL
+	SET a._lock = true
+	WITH a, $version IS NULL OR COALESCE(a.version, 0) = $version AS current
+	FOREACH (_ IN CASE WHEN current THEN [1] ELSE [] END |
+		SET a.completedAt = CASE
+				WHEN $status = 'completed' AND COALESCE(a.status, '') <> 'completed' THEN $updatedAt
+				ELSE a.completedAt
+			END,
+			a.title = COALESCE($title, a.title),
+			a.description = COALESCE($description, a.description),
+			a.status = COALESCE($status, a.status),
+			a.updatedAt = $updatedAt,
+			a.version = COALESCE(a.version, 0) + 1
+	)
+	REMOVE a._lock
+	RETURN a, current
+`, "id", "version", "title", "description", "status", "updatedAt")
+
+// ActsCompleted returns up to $limit acts a completed after $after, or at
+// $after with an ID after $afterId, in the order they were completed,
+// leaving out deleted ones
+var ActsCompleted = register("acts.completed", `
+	MATCH (a:Act)
+	WHERE a.completedAt > $after
+		OR (a.completedAt = $after AND a.id > $afterId)
+	WITH a
+	WHERE a.deletedAt IS NULL
+	RETURN a
+	ORDER BY a.completedAt, a.id
+	LIMIT $limit
+`, "after", "afterId", "limit")
+
+// ActDelete marks an act deleted, keeping it and its relationships until it
+// is purged. It returns the act's ID, or no rows if there was none to delete.
+var ActDelete = register("acts.delete", `
+	MATCH (a:Act {id: $id})
+	WHERE a.deletedAt IS NULL
+	SET a.deletedAt = $now,
+		a.version = COALESCE(a.version, 0) + 1
+	RETURN a.id AS id
+`, "id", "now")
+
+// ActTakeDown marks an act deleted, as ActDelete does, recording that
+// moderator $moderatorId took it down and why. It returns the act's ID, or
+// no rows if there was none to take down.
+var ActTakeDown = register("acts.take_down", `
+	MATCH (a:Act {id: $id})
+	WHERE a.deletedAt IS NULL
+	SET a.deletedAt = $now,
+		a.takedownReason = $reason,
+		a.takenDownBy = $moderatorId,
+		a.version = COALESCE(a.version, 0) + 1
+	RETURN a.id AS id
+`, "id", "reason", "moderatorId", "now")
+
+// ActBatch creates or refreshes the acts in $rows by ID, linked to their
+// givers and receivers, and returns how many it wrote. Rows whose giver
+// doesn't exist are skipped.
+var ActBatch = register("acts.batch", `
+	UNWIND $rows AS row
+	MATCH (giver:User {id: row.giverId})
+	MERGE (a:Act {id: row.id})
+	SET a += row,
+		a.version = COALESCE(a.version, 0) + 1
+	MERGE (giver)-[:GAVE]->(a)
+	WITH a, row
+	OPTIONAL MATCH (receiver:User {id: row.receiverId})
+	FOREACH (r IN CASE WHEN receiver IS NULL THEN [] ELSE [receiver] END |
+		MERGE (a)-[:RECEIVED_BY]->(r)
+		MERGE (r)-[:RECEIVED]->(a)
+	)
+	RETURN count(DISTINCT a) AS written
+`, "rows")
+
+// ActsExpire marks up to $limit acts left pending since before $before
+// expired and returns their IDs as id
+var ActsExpire = register("acts.expire", `
+	MATCH (a:Act)
+	WHERE COALESCE(a.status, 'pending') = 'pending'
+		AND a.createdAt < $before
+		AND a.deletedAt IS NULL
+	WITH a LIMIT $limit
+	SET a.status = 'expired',
+		a.updatedAt = $now,
+		a.version = COALESCE(a.version, 0) + 1
+	RETURN a.id AS id
+`, "before", "now", "limit")
+
+// ActScreening records the automated screening of an act's title and
+// description, returning no rows if the act doesn't exist or was deleted
+var ActScreening = register("acts.screening", `
+	MATCH (a:Act {id: $id})
+	WHERE a.deletedAt IS NULL
+	SET a.screeningScore = $score,
+		a.screeningFlags = $flags,
+		a.screeningVerdict = $verdict,
+		a.screenedAt = $now
+	RETURN a.id AS id
+`, "id", "score", "flags", "verdict", "now")
+
+// ActsFlaggedCount returns the number of acts screening flagged as total
+var ActsFlaggedCount = register("acts.flagged_count", `
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL AND a.screeningVerdict = 'flag'
+	RETURN count(a) AS total
+`)
+
+// ActsFlagged returns a page of the acts a screening flagged, highest
+// score first
+var ActsFlagged = register("acts.flagged", `
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL AND a.screeningVerdict = 'flag'
+	RETURN a
+	ORDER BY a.screeningScore DESC, a.screenedAt, a.id
+	SKIP $skip LIMIT $limit
+`, "skip", "limit")
+
+// ActDismissFlag clears the flag of an act a moderator found acceptable,
+// keeping its score and flags. It returns no rows unless the act is
+// flagged.
+var ActDismissFlag = register("acts.dismiss_flag", `
+	MATCH (a:Act {id: $id})
+	WHERE a.deletedAt IS NULL AND a.screeningVerdict = 'flag'
+	SET a.screeningVerdict = 'allow',
+		a.screeningReviewerId = $reviewerId,
+		a.screeningReviewedAt = $now
+	RETURN a.id AS id
+`, "id", "reviewerId", "now")
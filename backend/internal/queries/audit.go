@@ -0,0 +1,41 @@
+package queries
+
+// auditFilter matches audit events e by the optional $actorId, $entityId
+// and $from-$to date range
+const auditFilter = `
+	WHERE ($actorId IS NULL OR e.actorId = $actorId)
+	AND ($entityId IS NULL OR e.entityId = $entityId)
+	AND ($from IS NULL OR e.createdAt >= $from)
+	AND ($to IS NULL OR e.createdAt <= $to)
+`
+
+// AuditCreate stores an audit event
+var AuditCreate = register("audit.create", `
+	CREATE (e:AuditEvent {
+		id: $id,
+		actorId: $actorId,
+		method: $method,
+		route: $route,
+		path: $path,
+		entityId: $entityId,
+		status: $status,
+		changes: $changes,
+		requestId: $requestId,
+		createdAt: $createdAt
+	})
+`, "id", "actorId", "method", "route", "path", "entityId", "status", "changes", "requestId", "createdAt")
+
+// AuditCount returns the number of audit events matching the filter as total
+var AuditCount = register("audit.count", `
+	MATCH (e:AuditEvent) `+auditFilter+`
+	RETURN count(e) as total
+`, "actorId", "entityId", "from", "to")
+
+// AuditList returns a page of audit events e matching the filter, newest
+// first
+var AuditList = register("audit.list", `
+	MATCH (e:AuditEvent) `+auditFilter+`
+	RETURN e
+	ORDER BY e.createdAt DESC
+	SKIP $skip LIMIT $limit
+`, "actorId", "entityId", "from", "to", "skip", "limit")
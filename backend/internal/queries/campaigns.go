@@ -0,0 +1,99 @@
+package queries
+
+// campaignProgress follows a WITH c, counting the acts tagged to campaign c
+// that weren't deleted, their value in the base currency, and its
+// participants
+const campaignProgress = `
+	OPTIONAL MATCH (a:Act)-[:PART_OF]->(c)
+	WHERE a.deletedAt IS NULL
+	WITH c, count(a) AS acts, sum(COALESCE(a.baseValue, a.value, 0)) AS value
+	OPTIONAL MATCH (u:User)-[:JOINED]->(c)
+	WHERE u.deletedAt IS NULL
+	WITH c, acts, value, count(u) AS participants
+`
+
+// campaignStatus matches campaigns c in $status at $now, upcoming, active
+// or ended, or all of them if it is null
+const campaignStatus = `
+	WHERE $status IS NULL
+		OR ($status = 'upcoming' AND c.startsAt > $now)
+		OR ($status = 'active' AND c.startsAt <= $now AND c.endsAt > $now)
+		OR ($status = 'ended' AND c.endsAt <= $now)
+`
+
+// CampaignCreate stores campaign c
+var CampaignCreate = register("campaigns.create", `
+	CREATE (c:Campaign {
+		id: $id,
+		name: $name,
+		description: $description,
+		startsAt: $startsAt,
+		endsAt: $endsAt,
+		goalActs: $goalActs,
+		goalValue: $goalValue,
+		createdBy: $createdBy,
+		createdAt: $createdAt
+	})
+	RETURN c
+`, "id", "name", "description", "startsAt", "endsAt", "goalActs", "goalValue", "createdBy", "createdAt")
+
+// CampaignGet returns campaign c with its progress as acts, value and
+// participants, or no rows if it doesn't exist
+var CampaignGet = register("campaigns.get", `
+	MATCH (c:Campaign {id: $id})
+	WITH c`+campaignProgress+`
+	RETURN c, acts, value, participants
+`, "id")
+
+// CampaignCount returns the number of campaigns in $status at $now as total
+var CampaignCount = register("campaigns.count", `
+	MATCH (c:Campaign)`+campaignStatus+`
+	RETURN count(c) AS total
+`, "status", "now")
+
+// CampaignList returns a page of the campaigns c in $status at $now, latest
+// starting first, with their progress as acts, value and participants
+var CampaignList = register("campaigns.list", `
+	MATCH (c:Campaign)`+campaignStatus+`
+	WITH c
+	ORDER BY c.startsAt DESC, c.id
+	SKIP $skip LIMIT $limit`+campaignProgress+`
+	RETURN c, acts, value, participants
+	ORDER BY c.startsAt DESC, c.id
+`, "status", "now", "skip", "limit")
+
+// CampaignJoin adds user $userId to the participants of campaign c, keeping
+// when they first joined. It returns c, or no rows if the campaign or the
+// user doesn't exist.
+var CampaignJoin = register("campaigns.join", `
+	MATCH (c:Campaign {id: $id})
+	MATCH (u:User {id: $userId})
+	WHERE u.deletedAt IS NULL
+	MERGE (u)-[j:JOINED]->(c)
+	ON CREATE SET j.joinedAt = $now
+	RETURN c
+`, "id", "userId", "now")
+
+// CampaignLeave removes user $userId from the participants of a campaign.
+// It returns the campaign's id, or no rows if it doesn't exist.
+var CampaignLeave = register("campaigns.leave", `
+	MATCH (c:Campaign {id: $id})
+	OPTIONAL MATCH (:User {id: $userId})-[j:JOINED]->(c)
+	DELETE j
+	RETURN c.id AS id
+`, "id", "userId")
+
+// CampaignLeaderboard ranks the users who tagged acts to a campaign by how
+// many, leaving out anonymous or deleted acts and users who opted out of
+// leaderboards or were deleted
+var CampaignLeaderboard = register("campaigns.leaderboard", `
+	MATCH (u:User)-[:GAVE]->(a:Act)-[:PART_OF]->(:Campaign {id: $id})
+	WHERE a.deletedAt IS NULL AND u.deletedAt IS NULL
+	  AND COALESCE(a.isAnonymous, false) = false
+	  AND COALESCE(u.hideFromLeaderboards, false) = false
+	RETURN u.id as id, u.name as name, u.avatar as avatar,
+		   count(a) as actsCount,
+		   sum(COALESCE(a.baseValue, a.value, 0)) as totalValue
+	ORDER BY actsCount DESC, totalValue DESC, id ASC
+	LIMIT $limit
+`, "id", "limit")
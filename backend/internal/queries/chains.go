@@ -0,0 +1,29 @@
+package queries
+
+// ChainGet returns chain c with the acts in it and the user who started it,
+// leaving out deleted ones
+var ChainGet = register("chains.get", `
+	MATCH (c:Chain {id: $id})
+	OPTIONAL MATCH (c)-[:CONTAINS]->(a:Act)
+	WHERE a.deletedAt IS NULL
+	OPTIONAL MATCH (starter:User)-[:STARTED]->(c)
+	WHERE starter.deletedAt IS NULL
+	RETURN c, collect(a) as acts, starter
+`, "id")
+
+// ChainsByUser returns the chains c a user started or took part in, newest
+// first
+var ChainsByUser = register("chains.by_user", `
+	MATCH (u:User {id: $userId})-[:STARTED|PARTICIPATED_IN]->(c:Chain)
+	WHERE u.deletedAt IS NULL
+	RETURN DISTINCT c
+	ORDER BY c.createdAt DESC
+`, "userId")
+
+// ChainMembers returns the IDs of the users who started or took part in a
+// chain as userId, leaving out deleted ones
+var ChainMembers = register("chains.members", `
+	MATCH (u:User)-[:STARTED|PARTICIPATED_IN]->(:Chain {id: $id})
+	WHERE u.deletedAt IS NULL
+	RETURN DISTINCT u.id AS userId
+`, "id")
@@ -0,0 +1,56 @@
+package queries
+
+// ContentReportCreate stores an open report of the $kind with ID
+// $targetId and returns it as r, or no rows if it doesn't exist or was
+// deleted. The variants are the kinds, act, testimonial and user.
+var ContentReportCreate = registerVariants("content_reports.create", `
+	MATCH (target:{{variant}} {id: $targetId})
+	WHERE target.deletedAt IS NULL
+	CREATE (r:ContentReport {
+		id: $id,
+		kind: $kind,
+		targetId: $targetId,
+		reporterId: $reporterId,
+		reason: $reason,
+		details: $details,
+		status: 'open',
+		createdAt: $createdAt
+	})
+	RETURN r
+`, map[string]string{
+	"act":         "Act",
+	"testimonial": "Testimonial",
+	"user":        "User",
+}, "id", "kind", "targetId", "reporterId", "reason", "details", "createdAt")
+
+// ContentReportCount returns the number of reports with status $status,
+// or of all reports if it is null, as total
+var ContentReportCount = register("content_reports.count", `
+	MATCH (r:ContentReport)
+	WHERE $status IS NULL OR r.status = $status
+	RETURN count(r) AS total
+`, "status")
+
+// ContentReportList returns a page of the reports r with status $status,
+// or all of them if it is null, oldest first so the queue is worked in
+// order
+var ContentReportList = register("content_reports.list", `
+	MATCH (r:ContentReport)
+	WHERE $status IS NULL OR r.status = $status
+	RETURN r
+	ORDER BY r.createdAt, r.id
+	SKIP $skip LIMIT $limit
+`, "status", "skip", "limit")
+
+// ContentReportHandle closes open report r with $status, recording who
+// handled it, when and their note. It returns r, or no rows if there is
+// no open report with $id.
+var ContentReportHandle = register("content_reports.handle", `
+	MATCH (r:ContentReport {id: $id})
+	WHERE r.status = 'open'
+	SET r.status = $status,
+		r.handlerId = $handlerId,
+		r.note = $note,
+		r.handledAt = $handledAt
+	RETURN r
+`, "id", "status", "handlerId", "note", "handledAt")
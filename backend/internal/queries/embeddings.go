@@ -0,0 +1,48 @@
+package queries
+
+// IndexDimensions returns the dimensions of the vector index named $name,
+// or no rows if it doesn't exist
+var IndexDimensions = register("embeddings.index_dimensions", `
+	SHOW INDEXES YIELD name, options
+	WHERE name = $name
+	RETURN options.indexConfig['vector.dimensions'] AS dimensions
+`, "name")
+
+// EmbeddingStale returns up to $limit acts that aren't deleted with no
+// embedding from $model or changed since they were embedded
+var EmbeddingStale = register("embeddings.stale", `
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL
+	  AND (a.embeddingModel IS NULL OR a.embeddingModel <> $model OR a.embeddedAt < a.updatedAt)
+	RETURN a.id AS id, a.title AS title, a.description AS description,
+		a.category AS category, a.updatedAt AS updatedAt
+	LIMIT $limit
+`, "model", "limit")
+
+// EmbeddingStore sets the embedding vectors in $rows on their acts
+var EmbeddingStore = register("embeddings.store", `
+	UNWIND $rows AS row
+	MATCH (a:Act {id: row.id})
+	SET a.embedding = row.vector,
+		a.embeddingModel = $model,
+		a.embeddedAt = coalesce(row.updatedAt, $now)
+`, "rows", "model", "now")
+
+// EmbeddingSimilar returns up to $limit acts nearest to an act in the vector
+// index named $index, with their scores, leaving out deleted acts
+var EmbeddingSimilar = register("embeddings.similar", `
+	MATCH (a:Act {id: $id})
+	WHERE a.embedding IS NOT NULL AND a.embeddingModel = $model AND a.deletedAt IS NULL
+	CALL db.index.vector.queryNodes($index, $k, a.embedding) YIELD node, score
+	WITH node, score WHERE node <> a AND node.deletedAt IS NULL
+	RETURN node.id AS id, score
+	LIMIT $limit
+`, "id", "model", "index", "k", "limit")
+
+// EmbeddingSearch returns up to $limit acts nearest to $vector in the vector
+// index named $index, with their scores, leaving out deleted acts
+var EmbeddingSearch = register("embeddings.search", `
+	CALL db.index.vector.queryNodes($index, $limit, $vector) YIELD node, score
+	WHERE node.embeddingModel = $model AND node.deletedAt IS NULL
+	RETURN node.id AS id, score
+`, "index", "limit", "vector", "model")
@@ -0,0 +1,105 @@
+package queries
+
+// ErasureUser replaces a user's profile with placeholders and returns 1 as
+// n, or 0 if they don't exist
+var ErasureUser = register("erasure.user", `
+	MATCH (u:User {id: $id})
+	SET u.name = $name,
+		u.email = $email,
+		u.hideFromLeaderboards = true,
+		u.erasedAt = COALESCE(u.erasedAt, $now),
+		u.updatedAt = $now,
+		u.version = COALESCE(u.version, 0) + 1
+	REMOVE u.passwordHash, u.avatar, u.bio, u.location,
+		u.geocodedLocation, u.latitude, u.longitude, u.position, u.countryCode
+	RETURN count(*) AS n
+`, "id", "name", "email", "now")
+
+// ErasureReactions removes a user's reactions, keeping the reaction counts
+// of the testimonials right, and returns how many as n
+var ErasureReactions = register("erasure.reactions", `
+	MATCH (:User {id: $id})-[m:MOVED]->(t:Testimonial)
+	SET t.reactionCount = CASE WHEN COALESCE(t.reactionCount, 0) > 0 THEN t.reactionCount - 1 ELSE 0 END
+	DELETE m
+	RETURN count(*) AS n
+`, "id")
+
+// ErasureTestimonials deletes a user's testimonials with their translations
+// and moderation notes, and returns how many as n and their IDs as ids
+var ErasureTestimonials = register("erasure.testimonials", `
+	MATCH (:User {id: $id})-[:WROTE]->(t:Testimonial)
+	OPTIONAL MATCH (t)-[:TRANSLATED_AS|HAS_NOTE]->(owned)
+	WITH t, t.id AS id, collect(owned) AS owned
+	FOREACH (o IN owned | DETACH DELETE o)
+	DETACH DELETE t
+	RETURN count(*) AS n, collect(id) AS ids
+`, "id")
+
+// ErasureActs makes a user's acts anonymous and strips their text, location
+// and embeddings, and returns how many as n and their IDs as ids. Only the
+// country the location was placed in is kept, for the stats.
+var ErasureActs = register("erasure.acts", `
+	MATCH (:User {id: $id})-[:GAVE]->(a:Act)
+	WHERE a.redactedAt IS NULL
+	SET a.title = $actTitle,
+		a.description = '',
+		a.isAnonymous = true,
+		a.redactedAt = $now,
+		a.updatedAt = $now,
+		a.version = COALESCE(a.version, 0) + 1
+	REMOVE a.location, a.geocodedLocation, a.latitude, a.longitude, a.position,
+		a.embedding, a.embeddingModel, a.embeddedAt
+	RETURN count(*) AS n, collect(a.id) AS ids
+`, "id", "actTitle", "now")
+
+// ErasureModeration removes a user's ID from the moderation notes they
+// wrote, testimonials they reviewed and content reports they filed or
+// handled, along with the details they gave, and returns how many as n
+var ErasureModeration = register("erasure.moderation", `
+	CALL {
+		MATCH (n:ModerationNote {authorId: $id})
+		REMOVE n.authorId
+		RETURN count(*) AS notes
+	}
+	CALL {
+		MATCH (t:Testimonial {reviewerId: $id})
+		REMOVE t.reviewerId
+		RETURN count(*) AS reviews
+	}
+	CALL {
+		MATCH (r:ContentReport)
+		WHERE r.reporterId = $id OR r.handlerId = $id
+		SET r.reporterId = CASE WHEN r.reporterId = $id THEN null ELSE r.reporterId END,
+			r.details = CASE WHEN r.reporterId = $id THEN null ELSE r.details END,
+			r.handlerId = CASE WHEN r.handlerId = $id THEN null ELSE r.handlerId END
+		RETURN count(*) AS reports
+	}
+	RETURN notes + reviews + reports AS n
+`, "id")
+
+// ErasureAudit removes a user's ID, and the concrete paths that may contain
+// it, from the audit events about them, and returns how many as n
+var ErasureAudit = register("erasure.audit", `
+	MATCH (e:AuditEvent)
+	WHERE e.actorId = $id OR e.entityId = $id
+	SET e.actorId = CASE WHEN e.actorId = $id THEN null ELSE e.actorId END,
+		e.entityId = CASE WHEN e.entityId = $id THEN null ELSE e.entityId END,
+		e.path = e.route
+	RETURN count(*) AS n
+`, "id")
+
+// ErasureNotifications deletes a user's notifications and removes their ID
+// from the notifications they caused, and returns how many as n
+var ErasureNotifications = register("erasure.notifications", `
+	CALL {
+		MATCH (:User {id: $id})-[:NOTIFIED]->(n:Notification)
+		DETACH DELETE n
+		RETURN count(*) AS received
+	}
+	CALL {
+		MATCH (n:Notification {actorId: $id})
+		REMOVE n.actorId
+		RETURN count(*) AS caused
+	}
+	RETURN received + caused AS n
+`, "id")
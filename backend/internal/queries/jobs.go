@@ -0,0 +1,113 @@
+package queries
+
+// JobEnqueue creates job j unless one with $id exists, and returns n, 1 if
+// it created it and 0 otherwise. Scheduled jobs have an ID per occurrence,
+// so instances enqueueing the same occurrence create it once.
+var JobEnqueue = register("jobs.enqueue", `
+	MERGE (j:Job {id: $id})
+	ON CREATE SET
+		j.type = $type,
+		j.status = 'queued',
+		j.payload = $payload,
+		j.attempts = 0,
+		j.maxAttempts = $maxAttempts,
+		j.runAt = $runAt,
+		j.createdAt = $createdAt,
+		j.updatedAt = $createdAt,
+		j.enqueued = true
+	WITH j, COALESCE(j.enqueued, false) AS created
+	REMOVE j.enqueued
+	RETURN CASE WHEN created THEN 1 ELSE 0 END AS n
+`, "id", "type", "payload", "maxAttempts", "runAt", "createdAt")
+
+// JobClaim marks the due job j of one of $types that has waited longest as
+// running until $lockedUntil, counting the attempt, and returns it, or no
+// rows if none is due. Queued jobs are due at their runAt and running ones
+// once their lease has expired. Setting the lock node first serializes
+// claims, so a job is claimed by one instance at a time.
+var JobClaim = register("jobs.claim", `
+	MERGE (l:JobLock {name: 'queue'})
+	SET l.claimedAt = $now
+	WITH l
+	MATCH (j:Job)
+	WHERE j.type IN $types
+		AND ((j.status = 'queued' AND j.runAt <= $now)
+			OR (j.status = 'running' AND j.lockedUntil <= $now))
+	WITH j
+	ORDER BY j.runAt, j.id
+	LIMIT 1
+	SET j.status = 'running',
+		j.attempts = j.attempts + 1,
+		j.lockedUntil = $lockedUntil,
+		j.updatedAt = $now
+	RETURN j
+`, "types", "now", "lockedUntil")
+
+// JobFinish records the outcome of the attempt at job $id claimed until
+// $claim. An attempt whose lease expired and was claimed again since is
+// ignored.
+var JobFinish = register("jobs.finish", `
+	MATCH (j:Job {id: $id})
+	WHERE j.status = 'running' AND j.lockedUntil = $claim
+	SET j.status = $status,
+		j.attempts = $attempts,
+		j.result = $result,
+		j.lastError = $lastError,
+		j.runAt = $runAt,
+		j.lockedUntil = null,
+		j.finishedAt = $finishedAt,
+		j.updatedAt = $updatedAt
+`, "id", "claim", "status", "attempts", "result", "lastError", "runAt", "finishedAt", "updatedAt")
+
+// JobGet returns job j with $id
+var JobGet = register("jobs.get", `
+	MATCH (j:Job {id: $id})
+	RETURN j
+`, "id")
+
+// JobCount returns the number of jobs with $status and $type, either
+// ignored when null, as total
+var JobCount = register("jobs.count", `
+	MATCH (j:Job)
+	WHERE ($status IS NULL OR j.status = $status)
+		AND ($type IS NULL OR j.type = $type)
+	RETURN count(j) AS total
+`, "status", "type")
+
+// JobList returns a page of the jobs j with $status and $type, either
+// ignored when null, most recently updated first
+var JobList = register("jobs.list", `
+	MATCH (j:Job)
+	WHERE ($status IS NULL OR j.status = $status)
+		AND ($type IS NULL OR j.type = $type)
+	RETURN j
+	ORDER BY j.updatedAt DESC, j.id
+	SKIP $skip LIMIT $limit
+`, "status", "type", "skip", "limit")
+
+// JobRetry queues failed job $id to run again at $now with its attempts
+// reset. It returns the job j and whether it was failed, or no rows if it
+// doesn't exist.
+var JobRetry = register("jobs.retry", `
+	MATCH (j:Job {id: $id})
+	WITH j, j.status = 'failed' AS failed
+	FOREACH (_ IN CASE WHEN failed THEN [1] ELSE [] END |
+		SET j.status = 'queued',
+			j.attempts = 0,
+			j.runAt = $now,
+			j.lastError = null,
+			j.finishedAt = null,
+			j.updatedAt = $now
+	)
+	RETURN j, failed
+`, "id", "now")
+
+// JobPrune deletes up to $limit jobs that succeeded or failed before
+// $before and returns how many as n
+var JobPrune = register("jobs.prune", `
+	MATCH (j:Job)
+	WHERE j.status IN ['succeeded', 'failed'] AND j.finishedAt < $before
+	WITH j LIMIT $limit
+	DELETE j
+	RETURN count(*) AS n
+`, "before", "limit")
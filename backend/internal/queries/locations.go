@@ -0,0 +1,37 @@
+package queries
+
+// LocationPending returns up to $limit users and acts, as kind, id and
+// location, whose location was set or changed since it was last geocoded
+var LocationPending = register("locations.pending", `
+	CALL {
+		MATCH (u:User)
+		WHERE u.deletedAt IS NULL AND u.location IS NOT NULL AND u.location <> ''
+		  AND (u.geocodedLocation IS NULL OR u.geocodedLocation <> u.location)
+		RETURN 'user' AS kind, u.id AS id, u.location AS location
+		UNION ALL
+		MATCH (a:Act)
+		WHERE a.deletedAt IS NULL AND a.location IS NOT NULL AND a.location <> ''
+		  AND (a.geocodedLocation IS NULL OR a.geocodedLocation <> a.location)
+		RETURN 'act' AS kind, a.id AS id, a.location AS location
+	}
+	RETURN kind, id, location
+	LIMIT $limit
+`, "limit")
+
+// LocationSet records the place the location of the user or act $id was
+// geocoded to, with null coordinates and country for a location that
+// couldn't be placed, unless its location is no longer $location. The
+// variants are the kinds, user and act.
+var LocationSet = registerVariants("locations.set", `
+	MATCH (n:{{variant}} {id: $id})
+	WHERE n.location = $location
+	SET n.geocodedLocation = $location,
+		n.latitude = $latitude,
+		n.longitude = $longitude,
+		n.countryCode = $countryCode,
+		n.position = CASE WHEN $latitude IS NULL THEN null
+			ELSE point({latitude: $latitude, longitude: $longitude}) END
+`, map[string]string{
+	"user": "User",
+	"act":  "Act",
+}, "id", "location", "latitude", "longitude", "countryCode")
@@ -0,0 +1,39 @@
+package queries
+
+// MailSuppressed returns n, 1 if $email is on the suppression list and 0
+// otherwise
+var MailSuppressed = register("mail.suppressed", `
+	OPTIONAL MATCH (s:EmailSuppression {email: $email})
+	RETURN count(s) AS n
+`, "email")
+
+// MailSuppress adds $email to the suppression list, keeping the reason and
+// time of an existing entry, and returns it as s
+var MailSuppress = register("mail.suppress", `
+	MERGE (s:EmailSuppression {email: $email})
+	ON CREATE SET s.reason = $reason, s.createdAt = $now
+	RETURN s
+`, "email", "reason", "now")
+
+// MailUnsuppress removes $email from the suppression list and returns how
+// many entries it removed as n
+var MailUnsuppress = register("mail.unsuppress", `
+	OPTIONAL MATCH (s:EmailSuppression {email: $email})
+	DELETE s
+	RETURN count(s) AS n
+`, "email")
+
+// MailSuppressionCount returns the size of the suppression list as total
+var MailSuppressionCount = register("mail.suppression_count", `
+	MATCH (s:EmailSuppression)
+	RETURN count(s) AS total
+`)
+
+// MailSuppressionList returns a page of the suppression list s, newest
+// first
+var MailSuppressionList = register("mail.suppression_list", `
+	MATCH (s:EmailSuppression)
+	RETURN s
+	ORDER BY s.createdAt DESC, s.email
+	SKIP $skip LIMIT $limit
+`, "skip", "limit")
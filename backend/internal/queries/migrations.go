@@ -0,0 +1,45 @@
+package queries
+
+// MigrationsApplied returns every applied schema version and when it was
+// applied
+var MigrationsApplied = register("migrations.applied", `
+	MATCH (v:SchemaVersion) RETURN v.version as version, v.appliedAt as appliedAt
+`)
+
+// MigrationRecord records a schema version as applied
+var MigrationRecord = register("migrations.record", `
+	CREATE (:SchemaVersion {version: $version, name: $name, appliedAt: $appliedAt})
+`, "version", "name", "appliedAt")
+
+// MigrationForget removes the record of a schema version
+var MigrationForget = register("migrations.forget", `
+	MATCH (v:SchemaVersion {version: $version}) DELETE v
+`, "version")
+
+// MigrationLockAcquire takes the migration lock for $owner, first removing
+// a lock acquired before $staleBefore. It fails the lock's uniqueness
+// constraint while another migrator holds it.
+var MigrationLockAcquire = register("migrations.lock_acquire", `
+	OPTIONAL MATCH (stale:MigrationLock {name: 'migrations'})
+	WHERE stale.acquiredAt < $staleBefore
+	DELETE stale
+	CREATE (:MigrationLock {name: 'migrations', owner: $owner, acquiredAt: $acquiredAt})
+`, "owner", "staleBefore", "acquiredAt")
+
+// MigrationLockRelease releases the migration lock if $owner holds it
+var MigrationLockRelease = register("migrations.lock_release", `
+	MATCH (l:MigrationLock {name: 'migrations', owner: $owner}) DELETE l
+`, "owner")
+
+// SchemaConstraints lists the database's constraints
+var SchemaConstraints = register("schema.constraints", `
+	SHOW CONSTRAINTS YIELD name, type, labelsOrTypes, properties
+	RETURN name, type, labelsOrTypes, properties
+`)
+
+// SchemaIndexes lists the database's indexes with the constraint each
+// belongs to, if any
+var SchemaIndexes = register("schema.indexes", `
+	SHOW INDEXES YIELD name, type, labelsOrTypes, properties, owningConstraint
+	RETURN name, type, labelsOrTypes, properties, owningConstraint
+`)
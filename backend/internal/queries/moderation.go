@@ -0,0 +1,84 @@
+package queries
+
+// moderationFilter matches testimonials t that aren't deleted by their
+// derived moderation
+// $status, their $reviewerId and whether screening $flagged them. A
+// testimonial is rejected when it carries a rejection reason and pending
+// when it is neither approved nor rejected.
+const moderationFilter = `
+	WHERE t.deletedAt IS NULL
+	AND ($status = 'all'
+		OR ($status = 'approved' AND t.isApproved = true)
+		OR ($status = 'rejected' AND t.isApproved = false AND t.rejectionReason IS NOT NULL)
+		OR ($status = 'pending' AND t.isApproved = false AND t.rejectionReason IS NULL))
+	AND ($reviewerId IS NULL OR t.reviewerId = $reviewerId)
+	AND ($flagged IS NULL OR (COALESCE(t.screeningVerdict, '') = 'flag') = $flagged)
+`
+
+// ModerationCount returns the number of testimonials in the moderation
+// queue matching the filter as total
+var ModerationCount = register("moderation.count", `
+	MATCH (t:Testimonial) `+moderationFilter+`
+	RETURN count(t) as total
+`, "status", "reviewerId", "flagged")
+
+// ModerationQueue returns a page of testimonials t matching the filter with
+// their author u and moderation notes, oldest note first. The variants order
+// them by creation time or, highest first, by screening score.
+var ModerationQueue = registerVariants("moderation.queue", `
+	MATCH (t:Testimonial) `+moderationFilter+`
+	WITH t
+	ORDER BY {{variant}}
+	SKIP $skip LIMIT $limit`+authorMatch+`
+	OPTIONAL MATCH (t)-[:HAS_NOTE]->(n:ModerationNote)
+	WITH t, u, n
+	ORDER BY n.createdAt
+	WITH t, u, collect(n) as notes
+	RETURN t, u, notes
+	ORDER BY {{variant}}
+`, map[string]string{
+	"created_asc":  "t.createdAt ASC",
+	"created_desc": "t.createdAt DESC",
+	"score_asc":    "COALESCE(t.screeningScore, 0) DESC, t.createdAt ASC",
+	"score_desc":   "COALESCE(t.screeningScore, 0) DESC, t.createdAt DESC",
+}, "status", "reviewerId", "flagged", "skip", "limit")
+
+// ModerationAssign sets or clears the reviewer of testimonial t
+var ModerationAssign = register("moderation.assign", `
+	MATCH (t:Testimonial {id: $id})
+	WHERE t.deletedAt IS NULL
+	SET t.reviewerId = $reviewerId,
+		t.version = COALESCE(t.version, 0) + 1
+	RETURN t
+`, "id", "reviewerId")
+
+// ModerationReview approves or rejects testimonial t, unfeaturing it when
+// rejected
+var ModerationReview = register("moderation.review", `
+	MATCH (t:Testimonial {id: $id})
+	WHERE t.deletedAt IS NULL
+	SET t.isApproved = $approved,
+		t.rejectionReason = $reason,
+		t.reviewerId = COALESCE($reviewerId, t.reviewerId),
+		t.reviewedAt = $reviewedAt,
+		t.version = COALESCE(t.version, 0) + 1
+	FOREACH (_ IN CASE WHEN $approved THEN [] ELSE [1] END |
+		SET t.isFeatured = false,
+			t.featuredOrder = null
+	)
+	RETURN t
+`, "id", "approved", "reason", "reviewerId", "reviewedAt")
+
+// ModerationNoteCreate attaches a note to a testimonial, returning no rows
+// if it doesn't exist
+var ModerationNoteCreate = register("moderation.note_create", `
+	MATCH (t:Testimonial {id: $testimonialId})
+	WHERE t.deletedAt IS NULL
+	CREATE (t)-[:HAS_NOTE]->(n:ModerationNote {
+		id: $id,
+		authorId: $authorId,
+		body: $body,
+		createdAt: $createdAt
+	})
+	RETURN n.id
+`, "testimonialId", "id", "authorId", "body", "createdAt")
@@ -0,0 +1,63 @@
+package queries
+
+// NotificationCreate creates the notifications in $rows, each linked to the
+// user it is addressed to, and returns how many as n. Rows addressed to
+// users that don't exist or were deleted are skipped.
+var NotificationCreate = register("notifications.create", `
+	UNWIND $rows AS row
+	MATCH (u:User {id: row.userId})
+	WHERE u.deletedAt IS NULL
+	CREATE (u)-[:NOTIFIED]->(n:Notification)
+	SET n = row
+	RETURN count(n) AS n
+`, "rows")
+
+// NotificationCount returns the number of a user's notifications as total
+// and of those unread as unread
+var NotificationCount = register("notifications.count", `
+	MATCH (:User {id: $userId})-[:NOTIFIED]->(n:Notification)
+	RETURN count(n) AS total, count(CASE WHEN n.readAt IS NULL THEN 1 END) AS unread
+`, "userId")
+
+// NotificationList returns a page of a user's notifications n, newest
+// first, only the unread ones when $unreadOnly is set
+var NotificationList = register("notifications.list", `
+	MATCH (:User {id: $userId})-[:NOTIFIED]->(n:Notification)
+	WHERE NOT $unreadOnly OR n.readAt IS NULL
+	RETURN n
+	ORDER BY n.createdAt DESC, n.id
+	SKIP $skip LIMIT $limit
+`, "userId", "unreadOnly", "skip", "limit")
+
+// NotificationMarkRead marks a user's unread notifications with the IDs in
+// $ids read, or all of them when $ids is null, and returns how many as n
+var NotificationMarkRead = register("notifications.mark_read", `
+	MATCH (:User {id: $userId})-[:NOTIFIED]->(n:Notification)
+	WHERE n.readAt IS NULL AND ($ids IS NULL OR n.id IN $ids)
+	SET n.readAt = $now
+	RETURN count(n) AS n
+`, "userId", "ids", "now")
+
+// NotificationDigestDue returns up to $limit users due a digest, who
+// haven't had one since $since and have unread notifications created since
+// then, with their id, email, name and the count of each notification type
+var NotificationDigestDue = register("notifications.digest_due", `
+	MATCH (u:User)-[:NOTIFIED]->(n:Notification)
+	WHERE u.deletedAt IS NULL
+		AND (u.digestSentAt IS NULL OR u.digestSentAt < $since)
+		AND n.readAt IS NULL AND n.createdAt >= $since
+	WITH u,
+		count(CASE n.type WHEN 'act_received' THEN 1 END) AS actsReceived,
+		count(CASE n.type WHEN 'chain_grew' THEN 1 END) AS chainsGrown,
+		count(CASE n.type WHEN 'testimonial_approved' THEN 1 END) AS testimonialsApproved
+	RETURN u.id AS userId, u.email AS email, u.name AS name,
+		actsReceived, chainsGrown, testimonialsApproved
+	ORDER BY u.id
+	LIMIT $limit
+`, "since", "limit")
+
+// NotificationDigestSent records that a user was sent a digest at $now
+var NotificationDigestSent = register("notifications.digest_sent", `
+	MATCH (u:User {id: $userId})
+	SET u.digestSentAt = $now
+`, "userId", "now")
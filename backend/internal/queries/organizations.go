@@ -0,0 +1,180 @@
+package queries
+
+// OrgCreate stores organization o with user $userId as its owner. It
+// returns o, or no rows, and creates nothing, if the user doesn't exist or
+// was deleted.
+var OrgCreate = register("organizations.create", `
+	MATCH (u:User {id: $userId})
+	WHERE u.deletedAt IS NULL
+	CREATE (o:Organization {
+		id: $id,
+		name: $name,
+		description: $description,
+		kind: $kind,
+		createdBy: $userId,
+		createdAt: $createdAt
+	})
+	CREATE (u)-[:MEMBER_OF {role: 'owner', joinedAt: $createdAt}]->(o)
+	RETURN o
+`, "id", "name", "description", "kind", "userId", "createdAt")
+
+// OrgGet returns organization o with its number of members, or no rows if
+// it doesn't exist
+var OrgGet = register("organizations.get", `
+	MATCH (o:Organization {id: $id})
+	OPTIONAL MATCH (m:User)-[:MEMBER_OF]->(o)
+	WHERE m.deletedAt IS NULL
+	RETURN o, count(m) AS members
+`, "id")
+
+// OrgsByUser returns the organizations o a user is a member of, by name,
+// with their number of members and the user's role
+var OrgsByUser = register("organizations.by_user", `
+	MATCH (u:User {id: $userId})-[r:MEMBER_OF]->(o:Organization)
+	WHERE u.deletedAt IS NULL
+	OPTIONAL MATCH (m:User)-[:MEMBER_OF]->(o)
+	WHERE m.deletedAt IS NULL
+	RETURN o, r.role AS role, count(m) AS members
+	ORDER BY o.name, o.id
+`, "userId")
+
+// OrgMembership returns a user's role in an organization, null if they
+// aren't a member, and how many other owners it has. It returns no rows if
+// the organization doesn't exist.
+var OrgMembership = register("organizations.membership", `
+	MATCH (o:Organization {id: $id})
+	OPTIONAL MATCH (u:User {id: $userId})-[r:MEMBER_OF]->(o)
+	WHERE u.deletedAt IS NULL
+	OPTIONAL MATCH (owner:User)-[x:MEMBER_OF {role: 'owner'}]->(o)
+	WHERE owner.deletedAt IS NULL AND owner.id <> $userId
+	RETURN r.role AS role, count(owner) AS otherOwners
+`, "id", "userId")
+
+// OrgMemberCount returns the number of an organization's members as total
+var OrgMemberCount = register("organizations.member_count", `
+	MATCH (u:User)-[:MEMBER_OF]->(:Organization {id: $id})
+	WHERE u.deletedAt IS NULL
+	RETURN count(u) AS total
+`, "id")
+
+// OrgMembers returns a page of an organization's members, owners first,
+// then admins, then by name
+var OrgMembers = register("organizations.members", `
+	MATCH (u:User)-[r:MEMBER_OF]->(:Organization {id: $id})
+	WHERE u.deletedAt IS NULL
+	RETURN u.id AS userId, u.name AS name, u.avatar AS avatar,
+		   r.role AS role, r.joinedAt AS joinedAt
+	ORDER BY CASE r.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, u.name, u.id
+	SKIP $skip LIMIT $limit
+`, "id", "skip", "limit")
+
+// OrgSetRole changes a member's role
+var OrgSetRole = register("organizations.set_role", `
+	MATCH (:User {id: $userId})-[r:MEMBER_OF]->(:Organization {id: $id})
+	SET r.role = $role
+`, "id", "userId", "role")
+
+// OrgRemoveMember removes a user from an organization's members
+var OrgRemoveMember = register("organizations.remove_member", `
+	MATCH (:User {id: $userId})-[r:MEMBER_OF]->(:Organization {id: $id})
+	DELETE r
+`, "id", "userId")
+
+// OrgInvitationCreate stores invitation i to organization $organizationId.
+// It returns i, or no rows if the organization doesn't exist.
+var OrgInvitationCreate = register("organizations.invitation_create", `
+	MATCH (o:Organization {id: $organizationId})
+	CREATE (i:OrgInvitation {
+		id: $id,
+		organizationId: o.id,
+		email: $email,
+		role: $role,
+		tokenHash: $tokenHash,
+		invitedBy: $invitedBy,
+		createdAt: $createdAt,
+		expiresAt: $expiresAt
+	})
+	RETURN i
+`, "id", "organizationId", "email", "role", "tokenHash", "invitedBy", "createdAt", "expiresAt")
+
+// OrgInvitationsPending returns the invitations i to an organization that
+// weren't accepted and haven't expired by $now, newest first
+var OrgInvitationsPending = register("organizations.invitations_pending", `
+	MATCH (i:OrgInvitation {organizationId: $id})
+	WHERE i.acceptedAt IS NULL AND i.expiresAt > $now
+	RETURN i
+	ORDER BY i.createdAt DESC, i.id
+`, "id", "now")
+
+// OrgInvitationRevoke deletes an invitation to an organization that wasn't
+// accepted. It returns its id, or no rows if there is none.
+var OrgInvitationRevoke = register("organizations.invitation_revoke", `
+	MATCH (i:OrgInvitation {id: $invitationId, organizationId: $id})
+	WHERE i.acceptedAt IS NULL
+	WITH i, i.id AS id
+	DELETE i
+	RETURN id
+`, "id", "invitationId")
+
+// OrgInvitationAccept accepts the pending invitation i with $tokenHash for
+// $email on behalf of user $userId, who joins its organization with its
+// role unless they are a member already. It returns i, or no rows if there
+// is no such invitation or it expired by $now.
+var OrgInvitationAccept = register("organizations.invitation_accept", `
+	MATCH (i:OrgInvitation {tokenHash: $tokenHash})
+	WHERE i.acceptedAt IS NULL AND i.expiresAt > $now AND i.email = $email
+	MATCH (o:Organization {id: i.organizationId})
+	MATCH (u:User {id: $userId})
+	WHERE u.deletedAt IS NULL
+	SET i.acceptedAt = $now, i.acceptedBy = $userId
+	MERGE (u)-[r:MEMBER_OF]->(o)
+	ON CREATE SET r.role = i.role, r.joinedAt = $now
+	RETURN i
+`, "tokenHash", "email", "userId", "now")
+
+// OrgActCount returns the number of acts given for an organization as total
+var OrgActCount = register("organizations.act_count", `
+	MATCH (a:Act)-[:GIVEN_FOR]->(:Organization {id: $id})
+	WHERE a.deletedAt IS NULL
+	RETURN count(a) AS total
+`, "id")
+
+// OrgActs returns a page of the acts a given for an organization, newest
+// first
+var OrgActs = register("organizations.acts", `
+	MATCH (a:Act)-[:GIVEN_FOR]->(:Organization {id: $id})
+	WHERE a.deletedAt IS NULL
+	RETURN a
+	ORDER BY a.createdAt DESC, a.id
+	SKIP $skip LIMIT $limit
+`, "id", "skip", "limit")
+
+// OrgChains returns up to $limit chains c containing acts given for an
+// organization, most recently extended first, with the number and value of
+// all the acts in them
+var OrgChains = register("organizations.chains", `
+	MATCH (:Organization {id: $id})<-[:GIVEN_FOR]-(a:Act)<-[:CONTAINS]-(c:Chain)
+	WHERE a.deletedAt IS NULL
+	WITH DISTINCT c
+	OPTIONAL MATCH (c)-[:CONTAINS]->(x:Act)
+	WHERE x.deletedAt IS NULL
+	RETURN c, count(x) AS actsCount, sum(COALESCE(x.baseValue, x.value, 0)) AS totalValue
+	ORDER BY c.updatedAt DESC, c.id
+	LIMIT $limit
+`, "id", "limit")
+
+// OrgLeaderboard ranks an organization's members by the acts they gave for
+// it, leaving out anonymous or deleted acts and users who opted out of
+// leaderboards
+var OrgLeaderboard = register("organizations.leaderboard", `
+	MATCH (u:User)-[:GAVE]->(a:Act)-[:GIVEN_FOR]->(o:Organization {id: $id})
+	WHERE a.deletedAt IS NULL AND u.deletedAt IS NULL
+	  AND (u)-[:MEMBER_OF]->(o)
+	  AND COALESCE(a.isAnonymous, false) = false
+	  AND COALESCE(u.hideFromLeaderboards, false) = false
+	RETURN u.id as id, u.name as name, u.avatar as avatar,
+		   count(a) as actsCount,
+		   sum(COALESCE(a.baseValue, a.value, 0)) as totalValue
+	ORDER BY actsCount DESC, totalValue DESC, id ASC
+	LIMIT $limit
+`, "id", "limit")
@@ -0,0 +1,77 @@
+package queries
+
+// OutboxAppend stores a domain event in the outbox, numbered after the last
+// one. Incrementing the counter locks it until the transaction commits, so
+// events are numbered in the order their transactions commit.
+var OutboxAppend = register("outbox.append", `
+	MERGE (c:OutboxSequence {name: 'events'})
+	SET c.value = COALESCE(c.value, 0) + 1
+	CREATE (e:OutboxEvent {
+		id: $id,
+		sequence: c.value,
+		type: $type,
+		aggregateType: $aggregateType,
+		aggregateId: $aggregateId,
+		data: $data,
+		createdAt: $createdAt
+	})
+`, "id", "type", "aggregateType", "aggregateId", "data", "createdAt")
+
+// OutboxEraseUser deletes the events about user $id, which carry their
+// profile
+var OutboxEraseUser = register("outbox.erase_user", `
+	MATCH (e:OutboxEvent {aggregateType: 'user', aggregateId: $id})
+	DELETE e
+`, "id")
+
+// OutboxEraseAggregates deletes the events about the $aggregateType
+// aggregates in $ids, whose data may carry an erased user's text
+var OutboxEraseAggregates = register("outbox.erase_aggregates", `
+	MATCH (e:OutboxEvent {aggregateType: $aggregateType})
+	WHERE e.aggregateId IN $ids
+	DELETE e
+`, "aggregateType", "ids")
+
+// OutboxPending returns up to $limit events e not yet published, in order
+var OutboxPending = register("outbox.pending", `
+	MATCH (e:OutboxEvent)
+	WHERE e.publishedAt IS NULL
+	RETURN e
+	ORDER BY e.sequence
+	LIMIT $limit
+`, "limit")
+
+// OutboxMarkPublished records the events in $ids as published at $now
+var OutboxMarkPublished = register("outbox.mark_published", `
+	UNWIND $ids AS id
+	MATCH (e:OutboxEvent {id: id})
+	SET e.publishedAt = $now
+`, "ids", "now")
+
+// OutboxPrune deletes up to $limit events created before $before, only the
+// published ones with $publishedOnly, and returns how many as n
+var OutboxPrune = register("outbox.prune", `
+	MATCH (e:OutboxEvent)
+	WHERE e.createdAt < $before
+		AND (NOT $publishedOnly OR e.publishedAt IS NOT NULL)
+	WITH e LIMIT $limit
+	DELETE e
+	RETURN count(*) AS n
+`, "before", "publishedOnly", "limit")
+
+// OutboxAfter returns up to $limit events e numbered after $sequence,
+// published or not, in order
+var OutboxAfter = register("outbox.after", `
+	MATCH (e:OutboxEvent)
+	WHERE e.sequence > $sequence
+	RETURN e
+	ORDER BY e.sequence
+	LIMIT $limit
+`, "sequence", "limit")
+
+// OutboxLatest returns the number of the last event recorded as sequence,
+// 0 if none was
+var OutboxLatest = register("outbox.latest", `
+	OPTIONAL MATCH (c:OutboxSequence {name: 'events'})
+	RETURN coalesce(c.value, 0) AS sequence
+`)
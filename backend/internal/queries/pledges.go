@@ -0,0 +1,117 @@
+package queries
+
+// PledgeCreate creates pledge $id for act $actId if user $giverId gave it
+// and it has no pledge yet. It returns the pledge p, null if it wasn't
+// created, whether the user is the giver and whether the act was already
+// pledged, or no rows if the act doesn't exist or was deleted.
+var PledgeCreate = register("pledges.create", `
+	MATCH (giver:User)-[:GAVE]->(a:Act {id: $actId})
+	WHERE a.deletedAt IS NULL
+	WITH a, giver.id = $giverId AS isGiver, EXISTS { (a)-[:HAS_PLEDGE]->(:Pledge) } AS pledged
+	FOREACH (_ IN CASE WHEN isGiver AND NOT pledged THEN [1] ELSE [] END |
+		CREATE (a)-[:HAS_PLEDGE]->(:Pledge {
+			id: $id,
+			actId: a.id,
+			giverId: $giverId,
+			receiverId: $receiverId,
+			amount: $amount,
+			currency: $currency,
+			status: 'held',
+			provider: $provider,
+			providerRef: $providerRef,
+			releaseAt: $releaseAt,
+			createdAt: $createdAt,
+			updatedAt: $createdAt
+		})
+	)
+	WITH a, isGiver, pledged
+	OPTIONAL MATCH (a)-[:HAS_PLEDGE]->(p:Pledge {id: $id})
+	RETURN p, isGiver, pledged
+`, "id", "actId", "giverId", "receiverId", "amount", "currency", "provider", "providerRef", "releaseAt", "createdAt")
+
+// PledgeForAct returns the pledge p of act $actId
+var PledgeForAct = register("pledges.for_act", `
+	MATCH (:Act {id: $actId})-[:HAS_PLEDGE]->(p:Pledge)
+	RETURN p
+`, "actId")
+
+// PledgeGet returns pledge p with $id
+var PledgeGet = register("pledges.get", `
+	MATCH (p:Pledge {id: $id})
+	RETURN p
+`, "id")
+
+// PledgeTransition moves pledge $id to $status at $now if its status is
+// one of $from, setting the dispute and resolution fields that aren't null.
+// It takes the pledge's write lock before reading its status, so concurrent
+// transitions from the same status can't both succeed. It returns the
+// pledge p and whether it moved, or no rows if it doesn't exist.
+var PledgeTransition = register("pledges.transition", `
+	MATCH (p:Pledge {id: $id})
+	SET p._lock = true
+	WITH p, p.status IN $from AS current
+	FOREACH (_ IN CASE WHEN current THEN [1] ELSE [] END |
+		SET p.status = $status,
+			p.disputedBy = COALESCE($disputedBy, p.disputedBy),
+			p.disputeReason = COALESCE($disputeReason, p.disputeReason),
+			p.resolution = COALESCE($resolution, p.resolution),
+			p.settledAt = CASE WHEN $status IN ['released', 'refunded'] THEN $now ELSE p.settledAt END,
+			p.settlingFrom = null,
+			p.updatedAt = $now
+	)
+	REMOVE p._lock
+	RETURN p, current
+`, "id", "from", "status", "disputedBy", "disputeReason", "resolution", "now")
+
+// PledgeClaim moves pledge $id to settling at $now if its status is one of
+// $from, remembering that status, with the same write lock as
+// PledgeTransition. It returns the pledge p and whether it moved, or no
+// rows if it doesn't exist.
+var PledgeClaim = register("pledges.claim", `
+	MATCH (p:Pledge {id: $id})
+	SET p._lock = true
+	WITH p, p.status IN $from AS current
+	FOREACH (_ IN CASE WHEN current THEN [1] ELSE [] END |
+		SET p.settlingFrom = p.status, p.status = 'settling', p.updatedAt = $now
+	)
+	REMOVE p._lock
+	RETURN p, current
+`, "id", "from", "now")
+
+// PledgeUnclaim moves settling pledge $id back to the status it was claimed
+// from at $now
+var PledgeUnclaim = register("pledges.unclaim", `
+	MATCH (p:Pledge {id: $id, status: 'settling'})
+	SET p.status = p.settlingFrom, p.updatedAt = $now
+	REMOVE p.settlingFrom
+`, "id", "now")
+
+// PledgeCount returns the number of pledges with $status, ignored when
+// null, as total
+var PledgeCount = register("pledges.count", `
+	MATCH (p:Pledge)
+	WHERE $status IS NULL OR p.status = $status
+	RETURN count(p) AS total
+`, "status")
+
+// PledgeList returns a page of the pledges p with $status, ignored when
+// null, most recently updated first
+var PledgeList = register("pledges.list", `
+	MATCH (p:Pledge)
+	WHERE $status IS NULL OR p.status = $status
+	RETURN p
+	ORDER BY p.updatedAt DESC, p.id
+	SKIP $skip LIMIT $limit
+`, "status", "skip", "limit")
+
+// PledgesDue returns up to $limit held pledges p to settle, with refund set
+// for those whose act was cancelled, expired or deleted and unset for those
+// due for automatic release at $now
+var PledgesDue = register("pledges.due", `
+	MATCH (a:Act)-[:HAS_PLEDGE]->(p:Pledge {status: 'held'})
+	WITH p, a.status IN ['cancelled', 'expired'] OR a.deletedAt IS NOT NULL AS refund
+	WHERE refund OR p.releaseAt <= $now
+	RETURN p, refund
+	ORDER BY p.releaseAt, p.id
+	LIMIT $limit
+`, "now", "limit")
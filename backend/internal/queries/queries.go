@@ -0,0 +1,169 @@
+// Package queries is the registry of the Cypher statements the application
+// runs. Each statement is registered once under a dotted name with the
+// parameters it takes, so queries can be reviewed in one place, slow
+// transactions report their statements by name, and Check can validate the
+// whole set at startup.
+//
+// Schema changes aren't registered: migrations keep theirs in .cypher files,
+// and the embeddings vector index is sized at runtime. Neither are the
+// statements the backup package builds from the labels it exports.
+package queries
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Query is a registered Cypher statement
+type Query struct {
+	// Name identifies the query, as "area.action"
+	Name string
+	// Cypher is the statement text
+	Cypher string
+	// Params lists the parameters the statement references, without $
+	Params []string
+}
+
+// Run runs q in tx with params, which must hold every declared parameter.
+// Extra parameters are allowed, so one map can serve related queries.
+func (q *Query) Run(ctx context.Context, tx neo4j.ManagedTransaction, params map[string]interface{}) (neo4j.ResultWithContext, error) {
+	for _, name := range q.Params {
+		if _, ok := params[name]; !ok {
+			return nil, fmt.Errorf("query %s: missing parameter $%s", q.Name, name)
+		}
+	}
+	return tx.Run(ctx, q.Cypher, params)
+}
+
+// Variants is a family of queries that differ only in a fragment Cypher
+// can't take as a parameter, such as a sort order or a relationship
+// direction. Each variant is registered as "name.key".
+type Variants map[string]*Query
+
+// Get returns the variant for key. Keys are fixed at registration, so an
+// unknown key is a programming error and panics.
+func (v Variants) Get(key string) *Query {
+	q, ok := v[key]
+	if !ok {
+		panic(fmt.Sprintf("queries: unknown variant %q", key))
+	}
+	return q
+}
+
+var (
+	mu       sync.RWMutex
+	byName   = make(map[string]*Query)
+	byCypher = make(map[string]string)
+	errs     []error
+)
+
+// register adds a query to the registry. Problems are collected for Check
+// rather than panicking, so they are all reported together.
+func register(name, cypher string, params ...string) *Query {
+	q := &Query{Name: name, Cypher: strings.TrimSpace(cypher), Params: params}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := byName[name]; ok {
+		errs = append(errs, fmt.Errorf("query %s is registered twice", name))
+	}
+	byName[name] = q
+	byCypher[normalize(q.Cypher)] = name
+	return q
+}
+
+// registerVariants registers one query per fragment, replacing every
+// {{variant}} in cypher with it
+func registerVariants(name, cypher string, fragments map[string]string, params ...string) Variants {
+	variants := make(Variants, len(fragments))
+	for key, fragment := range fragments {
+		variants[key] = register(name+"."+key, strings.ReplaceAll(cypher, "{{variant}}", fragment), params...)
+	}
+	return variants
+}
+
+// Lookup returns the query registered as name
+func Lookup(name string) (*Query, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	q, ok := byName[name]
+	return q, ok
+}
+
+// NameOf returns the name of the registered query with the text cypher,
+// ignoring differences in whitespace
+func NameOf(cypher string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	name, ok := byCypher[normalize(cypher)]
+	return name, ok
+}
+
+// All returns every registered query, sorted by name
+func All() []*Query {
+	mu.RLock()
+	defer mu.RUnlock()
+	all := make([]*Query, 0, len(byName))
+	for _, q := range byName {
+		all = append(all, q)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// paramPattern matches $name parameter references
+var paramPattern = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// Check validates the registry: names must be unique, and the parameters
+// each statement references must match the ones it declares
+func Check() error {
+	mu.RLock()
+	found := append([]error(nil), errs...)
+	mu.RUnlock()
+
+	for _, q := range All() {
+		if q.Cypher == "" {
+			found = append(found, fmt.Errorf("query %s has no Cypher", q.Name))
+			continue
+		}
+
+		referenced := make(map[string]bool)
+		for _, m := range paramPattern.FindAllStringSubmatch(q.Cypher, -1) {
+			referenced[m[1]] = true
+		}
+		declared := make(map[string]bool, len(q.Params))
+		for _, p := range q.Params {
+			if declared[p] {
+				found = append(found, fmt.Errorf("query %s declares $%s twice", q.Name, p))
+			}
+			declared[p] = true
+			if !referenced[p] {
+				found = append(found, fmt.Errorf("query %s declares $%s but doesn't use it", q.Name, p))
+			}
+		}
+		var undeclared []string
+		for p := range referenced {
+			if !declared[p] {
+				undeclared = append(undeclared, p)
+			}
+		}
+		sort.Strings(undeclared)
+		for _, p := range undeclared {
+			found = append(found, fmt.Errorf("query %s uses undeclared $%s", q.Name, p))
+		}
+	}
+	return errors.Join(found...)
+}
+
+// normalize collapses runs of whitespace so queries match however they
+// were indented
+func normalize(cypher string) string {
+	return strings.Join(strings.Fields(cypher), " ")
+}
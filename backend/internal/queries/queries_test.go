@@ -0,0 +1,113 @@
+package queries
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestCheck(t *testing.T) {
+	if err := Check(); err != nil {
+		t.Fatalf("registry is invalid:\n%v", err)
+	}
+}
+
+func TestCheck_ReportsParameterMismatches(t *testing.T) {
+	q := register("test.mismatch", `MATCH (n {id: $id}) RETURN n LIMIT $limit`, "id", "skip")
+	defer unregister(q)
+
+	err := Check()
+	if err == nil {
+		t.Fatal("expected an error for mismatched parameters")
+	}
+	for _, want := range []string{"declares $skip but doesn't use it", "uses undeclared $limit"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+}
+
+func TestVersionChecksAreLocked(t *testing.T) {
+	// A version compared before the node is write locked can be compared
+	// by two transactions at once, and both updates then apply
+	lock := regexp.MustCompile(`SET (\w+)\._lock = true`)
+	for _, q := range All() {
+		check := strings.Index(q.Cypher, "= $version")
+		if check < 0 {
+			continue
+		}
+		m := lock.FindStringSubmatchIndex(q.Cypher)
+		if m == nil || m[0] > check {
+			t.Errorf("%s compares $version before taking a write lock", q.Name)
+			continue
+		}
+		if node := q.Cypher[m[2]:m[3]]; !strings.Contains(q.Cypher, "REMOVE "+node+"._lock") {
+			t.Errorf("%s doesn't remove the lock on %s", q.Name, node)
+		}
+	}
+}
+
+func TestRegisterVariants(t *testing.T) {
+	for key, want := range map[string]string{
+		"giver":    "MATCH (u:User)-[:GAVE]->(a:Act)",
+		"receiver": "MATCH (a:Act)-[:RECEIVED_BY]->(u:User)",
+	} {
+		q, ok := Lookup("stats.leaderboard." + key)
+		if !ok {
+			t.Fatalf("variant %s is not registered", key)
+		}
+		if q != StatsLeaderboard.Get(key) || !strings.HasPrefix(q.Cypher, want) {
+			t.Errorf("unexpected %s variant: %s", key, q.Cypher)
+		}
+	}
+}
+
+func TestNameOf(t *testing.T) {
+	reindented := strings.ReplaceAll(UserGet.Cypher, "\n\t", "\n\t\t\t")
+	if name, ok := NameOf(reindented); !ok || name != "users.get" {
+		t.Errorf("expected users.get, got %q (found %v)", name, ok)
+	}
+	if _, ok := NameOf("MATCH (n) RETURN n"); ok {
+		t.Error("expected an unregistered statement not to be found")
+	}
+}
+
+// recordingTx is a ManagedTransaction that records what it is asked to run
+type recordingTx struct {
+	neo4j.ManagedTransaction
+	cypher string
+}
+
+func (tx *recordingTx) Run(_ context.Context, cypher string, _ map[string]interface{}) (neo4j.ResultWithContext, error) {
+	tx.cypher = cypher
+	return nil, nil
+}
+
+func TestRun_RequiresDeclaredParams(t *testing.T) {
+	tx := &recordingTx{}
+	_, err := UserGet.Run(context.Background(), tx, map[string]interface{}{"userId": "u1"})
+	if err == nil || !strings.Contains(err.Error(), "missing parameter $id") {
+		t.Errorf("expected a missing parameter error, got %v", err)
+	}
+	if tx.cypher != "" {
+		t.Error("expected the query not to run")
+	}
+
+	if _, err := UserGet.Run(context.Background(), tx, map[string]interface{}{"id": "u1", "extra": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if tx.cypher != UserGet.Cypher {
+		t.Errorf("expected the registered Cypher to run, got %q", tx.cypher)
+	}
+}
+
+// unregister removes a query registered by a test
+func unregister(q *Query) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(byName, q.Name)
+	delete(byCypher, normalize(q.Cypher))
+}
@@ -0,0 +1,42 @@
+package queries
+
+// RateUpsert stores the $rates, a list of {currency, rate} maps giving the
+// units of each currency one unit of $base buys, as ExchangeRate nodes
+var RateUpsert = register("rates.upsert", `
+	UNWIND $rates AS r
+	MERGE (x:ExchangeRate {currency: r.currency})
+	SET x.rate = r.rate,
+		x.base = $base,
+		x.date = $date,
+		x.provider = $provider,
+		x.updatedAt = $now
+`, "rates", "base", "date", "provider", "now")
+
+// RatePrune deletes the rates of currencies that aren't in $currencies
+var RatePrune = register("rates.prune", `
+	MATCH (x:ExchangeRate)
+	WHERE NOT x.currency IN $currencies
+	DELETE x
+`, "currencies")
+
+// RateList returns the stored rates x by currency
+var RateList = register("rates.list", `
+	MATCH (x:ExchangeRate)
+	RETURN x
+	ORDER BY x.currency
+`)
+
+// RateNormalize converts the values of up to $limit acts to the base
+// currency: those with a rate for their currency that have no base value
+// yet, such as acts created before the rate was stored, or one in a former
+// base currency. It returns how many it converted.
+var RateNormalize = register("rates.normalize", `
+	MATCH (a:Act)
+	WHERE a.value IS NOT NULL AND a.currency IS NOT NULL
+	MATCH (x:ExchangeRate {currency: toUpper(a.currency)})
+	WHERE a.baseValue IS NULL OR a.baseCurrency <> x.base
+	WITH a, x
+	LIMIT $limit
+	SET a.baseValue = a.value / x.rate, a.baseCurrency = x.base
+	RETURN count(a) AS n
+`, "limit")
@@ -0,0 +1,67 @@
+package queries
+
+// receiptEligible matches the acts a, given by giver, that receipts are
+// issued for: monetary acts that weren't deleted, completed by their
+// receiver's confirmation. Acts without a receiver are completed by their
+// giver alone, so they get none.
+const receiptEligible = `
+	a.deletedAt IS NULL
+		AND a.type = 'monetary'
+		AND a.status = 'completed'
+		AND a.completedAt IS NOT NULL
+		AND EXISTS { (a)-[:RECEIVED_BY]->(:User) }`
+
+// ReceiptIssue issues the receipt of act $id, numbered after the last one
+// of the year it was completed in, unless it isn't eligible or already has
+// one. Incrementing the counter locks it until the transaction commits, so
+// numbers aren't reused.
+var ReceiptIssue = register("receipts.issue", `
+	MATCH (giver:User)-[:GAVE]->(a:Act {id: $id})
+	WHERE`+receiptEligible+`
+		AND NOT EXISTS { (a)-[:HAS_RECEIPT]->(:Receipt) }
+	MERGE (c:ReceiptSequence {year: a.completedAt.year})
+	SET c.value = COALESCE(c.value, 0) + 1
+	CREATE (a)-[:HAS_RECEIPT]->(:Receipt {
+		actId: a.id,
+		donorId: giver.id,
+		taxYear: c.year,
+		sequence: c.value,
+		amount: COALESCE(a.value, 0.0),
+		currency: COALESCE(a.currency, ''),
+		donatedAt: a.completedAt,
+		issuedAt: $now
+	})
+`, "id", "now")
+
+// ReceiptsUnissued returns the IDs of the acts user $giverId completed from
+// $from until $to that are eligible for a receipt but have none, in the
+// order they were completed
+var ReceiptsUnissued = register("receipts.unissued", `
+	MATCH (giver:User {id: $giverId})-[:GAVE]->(a:Act)
+	WHERE`+receiptEligible+`
+		AND a.completedAt >= $from AND a.completedAt < $to
+		AND NOT EXISTS { (a)-[:HAS_RECEIPT]->(:Receipt) }
+	RETURN a.id AS id
+	ORDER BY a.completedAt, a.id
+`, "giverId", "from", "to")
+
+// ReceiptForAct returns the receipt r of act $id, if any, with the act's
+// title as description, its giver and its receiver, or no rows if the act
+// doesn't exist or was deleted
+var ReceiptForAct = register("receipts.for_act", `
+	MATCH (giver:User)-[:GAVE]->(a:Act {id: $id})
+	WHERE a.deletedAt IS NULL
+	OPTIONAL MATCH (a)-[:HAS_RECEIPT]->(r:Receipt)
+	OPTIONAL MATCH (a)-[:RECEIVED_BY]->(receiver:User)
+	RETURN r, a.title AS description, giver, receiver
+`, "id")
+
+// ReceiptsForGiver returns the receipts r for the acts user $giverId
+// completed from $from until $to, like ReceiptForAct, in number order
+var ReceiptsForGiver = register("receipts.for_giver", `
+	MATCH (giver:User {id: $giverId})-[:GAVE]->(a:Act)-[:HAS_RECEIPT]->(r:Receipt)
+	WHERE a.deletedAt IS NULL AND r.donatedAt >= $from AND r.donatedAt < $to
+	OPTIONAL MATCH (a)-[:RECEIVED_BY]->(receiver:User)
+	RETURN r, a.title AS description, giver, receiver
+	ORDER BY r.taxYear, r.sequence
+`, "giverId", "from", "to")
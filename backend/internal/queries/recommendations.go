@@ -0,0 +1,76 @@
+package queries
+
+// RecommendationNetwork returns the neighbourhood of user $userId that
+// recommendations are drawn from: the connections, the users they gave to,
+// received from or shared a chain with; friendsOfFriends, the connections
+// of those; the categories of the acts they gave or received; and the IDs
+// of their latest acts as recentActs, newest first
+var RecommendationNetwork = register("recommendations.network", `
+	MATCH (u:User {id: $userId})
+	WHERE u.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:GAVE|RECEIVED_BY|STARTED|PARTICIPATED_IN]-(x)-[:GAVE|RECEIVED_BY|STARTED|PARTICIPATED_IN]-(friend:User)
+	WHERE (x:Act OR x:Chain) AND x.deletedAt IS NULL AND friend.deletedAt IS NULL AND friend <> u
+	WITH u, collect(DISTINCT friend) AS friends
+	UNWIND CASE friends WHEN [] THEN [null] ELSE friends END AS f
+	OPTIONAL MATCH (f)-[:GAVE|RECEIVED_BY|STARTED|PARTICIPATED_IN]-(y)-[:GAVE|RECEIVED_BY|STARTED|PARTICIPATED_IN]-(fof:User)
+	WHERE (y:Act OR y:Chain) AND y.deletedAt IS NULL AND fof.deletedAt IS NULL
+	  AND fof <> u AND NOT fof IN friends
+	WITH u, friends, collect(DISTINCT fof.id) AS friendsOfFriends
+	OPTIONAL MATCH (u)-[:GAVE|RECEIVED_BY]-(a:Act)
+	WHERE a.deletedAt IS NULL
+	WITH u, friends, friendsOfFriends, a
+	ORDER BY a.createdAt DESC
+	RETURN [f IN friends | f.id] AS connections,
+		friendsOfFriends,
+		[c IN collect(DISTINCT a.category) WHERE c IS NOT NULL AND c <> ''] AS categories,
+		collect(a.id)[..$recent] AS recentActs
+`, "userId", "recent")
+
+// RecommendationActs returns up to $limit acts a, given by someone other
+// than user $userId and not received by them, whose giver is among
+// $connections or $friendsOfFriends or whose category is among
+// $categories, with a flag for each, best first. The giver of an anonymous
+// act isn't used. The variants are the kinds of recommended acts: acts, in
+// a chain the user isn't part of, and needs, pending acts with no receiver.
+var RecommendationActs = registerVariants("recommendations.acts", `
+	MATCH (giver:User)-[:GAVE]->(a:Act)
+	WHERE a.deletedAt IS NULL AND giver.deletedAt IS NULL AND giver.id <> $userId
+	  AND NOT (a)-[:RECEIVED_BY]->(:User {id: $userId})
+	  AND {{variant}}
+	WITH a, NOT coalesce(a.isAnonymous, false) AS known, giver
+	WITH a,
+		known AND giver.id IN $connections AS connection,
+		known AND giver.id IN $friendsOfFriends AS friendOfFriend,
+		a.category IN $categories AS category
+	WHERE connection OR friendOfFriend OR category
+	RETURN a, connection, friendOfFriend, category
+	ORDER BY connection DESC, friendOfFriend DESC, category DESC, a.createdAt DESC, a.id
+	LIMIT $limit
+`, map[string]string{
+	"acts": `(:Chain)-[:CONTAINS]->(a) AND NOT coalesce(a.status, 'pending') IN ['cancelled', 'expired']
+	  AND NOT EXISTS { (:User {id: $userId})-[:STARTED|PARTICIPATED_IN]->(:Chain)-[:CONTAINS]->(a) }`,
+	"needs": `coalesce(a.status, 'pending') = 'pending' AND NOT (a)-[:RECEIVED_BY]->(:User)`,
+}, "userId", "connections", "friendsOfFriends", "categories", "limit")
+
+// RecommendationChains returns up to $limit chains c user $userId isn't
+// part of, with the number of acts in them as acts, that someone among
+// $connections or $friendsOfFriends takes part in or that hold acts in
+// $categories, with a flag for each, best first
+var RecommendationChains = register("recommendations.chains", `
+	MATCH (c:Chain)
+	WHERE NOT (:User {id: $userId})-[:STARTED|PARTICIPATED_IN]->(c)
+	OPTIONAL MATCH (m:User)-[:STARTED|PARTICIPATED_IN]->(c)
+	WHERE m.deletedAt IS NULL
+	WITH c, collect(DISTINCT m.id) AS members
+	OPTIONAL MATCH (c)-[:CONTAINS]->(a:Act)
+	WHERE a.deletedAt IS NULL
+	WITH c, members, collect(DISTINCT a.category) AS categories, count(DISTINCT a) AS acts
+	WITH c, acts,
+		any(id IN members WHERE id IN $connections) AS connection,
+		any(id IN members WHERE id IN $friendsOfFriends) AS friendOfFriend,
+		any(category IN categories WHERE category IN $categories) AS category
+	WHERE connection OR friendOfFriend OR category
+	RETURN c, acts, connection, friendOfFriend, category
+	ORDER BY connection DESC, friendOfFriend DESC, category DESC, c.createdAt DESC, c.id
+	LIMIT $limit
+`, "userId", "connections", "friendsOfFriends", "categories", "limit")
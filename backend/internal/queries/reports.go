@@ -0,0 +1,46 @@
+package queries
+
+// ReportActCount returns the number of acts a user gave between $from and
+// $to as total, or no rows if they don't exist or were deleted. Grouping by
+// the user is what makes a missing user return no rows rather than a zero
+// count.
+var ReportActCount = register("reports.act_count", `
+	MATCH (u:User {id: $userId})
+	WHERE u.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:GAVE]->(a:Act)
+	WHERE a.createdAt >= $from AND a.createdAt < $to AND a.deletedAt IS NULL
+	RETURN u.id AS userId, count(a) as total
+`, "userId", "from", "to")
+
+// ReportGiven returns the acts a user gave between $from and $to, the
+// chains they reached and the people who received them
+var ReportGiven = register("reports.given", `
+	MATCH (u:User {id: $userId})-[:GAVE]->(a:Act)
+	WHERE a.createdAt >= $from AND a.createdAt < $to AND a.deletedAt IS NULL
+	OPTIONAL MATCH (c:Chain)-[:CONTAINS]->(a)
+	OPTIONAL MATCH (a)-[:RECEIVED_BY]->(p:User)
+	WHERE p.deletedAt IS NULL
+	RETURN count(DISTINCT a) as actsGiven,
+		   count(DISTINCT c) as chainsReached,
+		   count(DISTINCT p) as peopleTouched
+`, "userId", "from", "to")
+
+// ReportReceived returns the number of acts a user received between $from
+// and $to
+var ReportReceived = register("reports.received", `
+	MATCH (u:User {id: $userId})-[:RECEIVED]->(a:Act)
+	WHERE a.createdAt >= $from AND a.createdAt < $to AND a.deletedAt IS NULL
+	RETURN count(a) as actsReceived
+`, "userId", "from", "to")
+
+// ReportCategories counts the acts a user gave between $from and $to and
+// sums their value by category
+var ReportCategories = register("reports.categories", `
+	MATCH (u:User {id: $userId})-[:GAVE]->(a:Act)
+	WHERE a.createdAt >= $from AND a.createdAt < $to AND a.deletedAt IS NULL
+	WITH COALESCE(a.category, 'uncategorized') as key, a
+	RETURN key,
+		   count(a) as actsCount,
+		   sum(COALESCE(a.baseValue, a.value, 0)) as totalValue
+	ORDER BY actsCount DESC, key ASC
+`, "userId", "from", "to")
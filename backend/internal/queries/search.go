@@ -0,0 +1,63 @@
+package queries
+
+// searchFullText queries the full-text index of each kind in $kinds with
+// $query, leaving out deleted nodes and testimonials that aren't approved
+const searchFullText = `
+	CALL {
+		CALL db.index.fulltext.queryNodes('act_search', $query) YIELD node, score
+		WHERE 'act' IN $kinds AND node.deletedAt IS NULL
+		RETURN 'act' AS kind, node.id AS id, node.title AS title, node.description AS text, score
+		UNION ALL
+		CALL db.index.fulltext.queryNodes('chain_search', $query) YIELD node, score
+		WHERE 'chain' IN $kinds AND node.deletedAt IS NULL
+		RETURN 'chain' AS kind, node.id AS id, node.name AS title, node.description AS text, score
+		UNION ALL
+		CALL db.index.fulltext.queryNodes('user_search', $query) YIELD node, score
+		WHERE 'user' IN $kinds AND node.deletedAt IS NULL
+		RETURN 'user' AS kind, node.id AS id, node.name AS title, node.bio AS text, score
+		UNION ALL
+		CALL db.index.fulltext.queryNodes('testimonial_search', $query) YIELD node, score
+		WHERE 'testimonial' IN $kinds AND node.deletedAt IS NULL AND node.isApproved = true
+		RETURN 'testimonial' AS kind, node.id AS id, node.impact AS title, node.story AS text, score
+	}
+`
+
+// SearchCount returns the number of documents matching a full-text search
+var SearchCount = register("search.count", searchFullText+`
+	RETURN count(*) AS total
+`, "query", "kinds")
+
+// SearchHits returns a page of the documents matching a full-text search,
+// as kind, id, title, text and score, best first
+var SearchHits = register("search.hits", searchFullText+`
+	RETURN kind, id, title, text, score
+	ORDER BY score DESC, kind, id
+	SKIP $skip LIMIT $limit
+`, "query", "kinds", "skip", "limit")
+
+// SearchDocuments returns the nodes n with $ids of a kind that are
+// searchable, leaving out deleted ones and testimonials that aren't
+// approved. The variants are the kinds: act, chain, user and testimonial.
+var SearchDocuments = registerVariants("search.documents", `
+	MATCH (n:{{variant}})
+	WHERE n.id IN $ids AND n.deletedAt IS NULL AND coalesce(n.isApproved, true)
+	RETURN n
+`, searchLabels, "ids")
+
+// SearchList returns up to $limit searchable nodes n of a kind with IDs
+// after $after, in ID order, like SearchDocuments
+var SearchList = registerVariants("search.list", `
+	MATCH (n:{{variant}})
+	WHERE n.id > $after AND n.deletedAt IS NULL AND coalesce(n.isApproved, true)
+	RETURN n
+	ORDER BY n.id
+	LIMIT $limit
+`, searchLabels, "after", "limit")
+
+// searchLabels are the labels of the nodes of each kind searched
+var searchLabels = map[string]string{
+	"act":         "Act",
+	"chain":       "Chain",
+	"user":        "User",
+	"testimonial": "Testimonial",
+}
@@ -0,0 +1,45 @@
+package queries
+
+// SeedChains creates or refreshes the chains in $rows by ID, linked to their
+// starters, acts and participants
+var SeedChains = register("seed.chains", `
+	UNWIND $rows AS row
+	MERGE (c:Chain {id: row.chain.id})
+	SET c += row.chain
+	WITH c, row
+	MATCH (starter:User {id: row.chain.starterId})
+	MERGE (starter)-[:STARTED]->(c)
+	WITH c, row
+	CALL {
+		WITH c, row
+		UNWIND row.actIds AS actId
+		MATCH (a:Act {id: actId})
+		MERGE (c)-[:CONTAINS]->(a)
+	}
+	CALL {
+		WITH c, row
+		UNWIND row.participantIds AS userId
+		MATCH (u:User {id: userId})
+		MERGE (u)-[:PARTICIPATED_IN]->(c)
+	}
+`, "rows")
+
+// SeedTestimonials creates or refreshes the testimonials in $rows by ID,
+// linked to their authors
+var SeedTestimonials = register("seed.testimonials", `
+	UNWIND $rows AS row
+	MERGE (t:Testimonial {id: row.id})
+	SET t += row,
+		t.version = COALESCE(t.version, 0) + 1
+	WITH t, row
+	MATCH (u:User {id: row.userId})
+	MERGE (u)-[:WROTE]->(t)
+`, "rows")
+
+// SeedReactions creates the reactions in $rows
+var SeedReactions = register("seed.reactions", `
+	UNWIND $rows AS row
+	MATCH (u:User {id: row.userId}), (t:Testimonial {id: row.testimonialId})
+	MERGE (u)-[m:MOVED]->(t)
+	SET m.createdAt = row.createdAt
+`, "rows")
@@ -0,0 +1,117 @@
+package queries
+
+// HealthPing checks that the database answers queries
+var HealthPing = register("health.ping", `RETURN 1`)
+
+// StatsGlobal returns the platform totals of acts, their value, users and
+// chains, and the number of countries acts and users were placed in by the
+// geocoder. Deleted acts and users aren't counted. Here and in the other
+// stats, values are summed as converted to the base currency, falling back
+// to the value as given for acts without a rate for their currency.
+var StatsGlobal = register("stats.global", `
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL
+	WITH count(a) as totalActs, sum(COALESCE(a.baseValue, a.value, 0)) as totalValue,
+		 collect(DISTINCT a.countryCode) as actCountries
+	MATCH (u:User)
+	WHERE u.deletedAt IS NULL
+	WITH totalActs, totalValue, actCountries, count(u) as totalUsers,
+		 collect(DISTINCT u.countryCode) as userCountries
+	MATCH (c:Chain)
+	RETURN totalActs, totalValue, totalUsers, count(c) as totalChains,
+		   size(reduce(countries = actCountries, code IN userCountries |
+			   CASE WHEN code IN countries THEN countries ELSE countries + code END)) as countriesReach
+`)
+
+// StatsUser returns the acts a user gave and received, the chains they
+// started and the value they gave
+var StatsUser = register("stats.user", `
+	MATCH (u:User {id: $userId})
+	WHERE u.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:GAVE]->(given:Act)
+	WHERE given.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:RECEIVED]->(received:Act)
+	WHERE received.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:STARTED]->(chain:Chain)
+	RETURN
+		count(DISTINCT given) as actsGiven,
+		count(DISTINCT received) as actsReceived,
+		count(DISTINCT chain) as chainsStarted,
+		sum(COALESCE(given.baseValue, given.value, 0)) as totalImpact
+`, "userId")
+
+// StatsCategories counts acts created between the optional $from and $to
+// and sums their value, grouped by the act property named $groupBy
+var StatsCategories = register("stats.categories", `
+	MATCH (a:Act)
+	WHERE a.deletedAt IS NULL
+	  AND ($from IS NULL OR a.createdAt >= $from)
+	  AND ($to IS NULL OR a.createdAt < $to)
+	WITH COALESCE(a[$groupBy], 'uncategorized') as key, a
+	RETURN key,
+		   count(a) as actsCount,
+		   sum(COALESCE(a.baseValue, a.value, 0)) as totalValue
+	ORDER BY actsCount DESC, key ASC
+`, "groupBy", "from", "to")
+
+// StatsLeaderboard ranks users by the acts they gave or received since the
+// optional $since, leaving out anonymous or deleted acts and users who opted
+// out or were deleted. The variants are the roles, giver and receiver.
+var StatsLeaderboard = registerVariants("stats.leaderboard", `
+	{{variant}}
+	WHERE a.deletedAt IS NULL AND u.deletedAt IS NULL
+	  AND ($since IS NULL OR a.createdAt >= $since)
+	  AND COALESCE(a.isAnonymous, false) = false
+	  AND COALESCE(u.hideFromLeaderboards, false) = false
+	RETURN u.id as id, u.name as name, u.avatar as avatar,
+		   count(a) as actsCount,
+		   sum(COALESCE(a.baseValue, a.value, 0)) as totalValue
+	ORDER BY actsCount DESC, totalValue DESC, id ASC
+	LIMIT $limit
+`, map[string]string{
+	"giver":    `MATCH (u:User)-[:GAVE]->(a:Act)`,
+	"receiver": `MATCH (a:Act)-[:RECEIVED_BY]->(u:User)`,
+}, "since", "limit")
+
+// StatsOrg returns an organization's member counts, the acts its
+// members gave and their total value, and the chains they started, or no
+// rows if it doesn't exist. Each part is counted in a subquery of its own,
+// so members' acts and chains aren't multiplied together.
+var StatsOrg = register("stats.org", `
+	MATCH (o:Organization {id: $orgId})
+	CALL {
+		WITH o
+		MATCH (m:User)-[:MEMBER_OF]->(o)
+		WHERE m.deletedAt IS NULL
+		RETURN count(DISTINCT m) as members
+	}
+	CALL {
+		WITH o
+		MATCH (m:User)-[:MEMBER_OF]->(o)
+		WHERE m.deletedAt IS NULL
+		MATCH (m)-[:GAVE]->(a:Act)
+		WHERE a.deletedAt IS NULL
+		WITH DISTINCT m, a
+		RETURN count(DISTINCT m) as activeMembers,
+			   count(DISTINCT a) as actsGiven,
+			   sum(COALESCE(a.baseValue, a.value, 0)) as totalValue
+	}
+	CALL {
+		WITH o
+		MATCH (m:User)-[:MEMBER_OF]->(o)
+		WHERE m.deletedAt IS NULL
+		MATCH (m)-[:STARTED]->(c:Chain)
+		RETURN count(DISTINCT c) as chainsStarted
+	}
+	RETURN members, activeMembers, actsGiven, chainsStarted, totalValue
+`, "orgId")
+
+// StatsRetention returns when each user who signed up since $since did so
+// and when they gave each of their acts
+var StatsRetention = register("stats.retention", `
+	MATCH (u:User)
+	WHERE u.createdAt >= $since AND u.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:GAVE]->(a:Act)
+	WHERE a.deletedAt IS NULL
+	RETURN u.createdAt as createdAt, collect(a.createdAt) as actTimes
+`, "since")
@@ -0,0 +1,216 @@
+package queries
+
+// authorMatch binds the author u of testimonial t, or null if they were
+// deleted
+const authorMatch = `
+	OPTIONAL MATCH (u:User)-[:WROTE]->(t)
+	WHERE u.deletedAt IS NULL
+`
+
+// translationsMatch collects the translations of t available in any of the
+// caller's $locales. It expects t and u to be bound.
+const translationsMatch = `
+	OPTIONAL MATCH (t)-[:TRANSLATED_AS]->(tr:TestimonialTranslation)
+	WHERE tr.locale IN $locales
+	WITH t, u, collect(tr) as translations
+`
+
+// testimonialFilter matches testimonials t that aren't deleted by the
+// optional $approved, $featured and $userId
+const testimonialFilter = `
+	WHERE t.deletedAt IS NULL
+	  AND ($approved IS NULL OR t.isApproved = $approved)
+	  AND ($featured IS NULL OR t.isFeatured = $featured)
+	  AND ($userId IS NULL OR t.userId = $userId)
+`
+
+// TestimonialCount returns the number of testimonials matching the filter
+// as total
+var TestimonialCount = register("testimonials.count", `
+	MATCH (t:Testimonial)`+testimonialFilter+`
+	RETURN count(t) as total
+`, "approved", "featured", "userId")
+
+// TestimonialList returns a page of testimonials t matching the filter with
+// their author u and translations. The variants order them by creation time
+// or reactions, ascending or descending.
+var TestimonialList = registerVariants("testimonials.list", `
+	MATCH (t:Testimonial)`+testimonialFilter+authorMatch+translationsMatch+`
+	RETURN t, u, translations
+	ORDER BY {{variant}}
+	SKIP $skip LIMIT $limit
+`, map[string]string{
+	"created_asc":    "t.createdAt ASC",
+	"created_desc":   "t.createdAt DESC",
+	"reactions_asc":  "COALESCE(t.reactionCount, 0) ASC, t.createdAt DESC",
+	"reactions_desc": "COALESCE(t.reactionCount, 0) DESC, t.createdAt DESC",
+}, "approved", "featured", "userId", "locales", "skip", "limit")
+
+// TestimonialFeatured returns approved, featured testimonials t with their
+// author u and translations, in their explicit order, then newest first
+var TestimonialFeatured = register("testimonials.featured", `
+	MATCH (t:Testimonial {isApproved: true, isFeatured: true})
+	WHERE t.deletedAt IS NULL`+authorMatch+translationsMatch+`
+	RETURN t, u, translations
+	ORDER BY COALESCE(t.featuredOrder, 2147483647) ASC, t.createdAt DESC
+	LIMIT $limit
+`, "limit", "locales")
+
+// TestimonialSetFeatured features or unfeatures testimonial t. It returns no
+// rows when featuring a testimonial that isn't approved.
+var TestimonialSetFeatured = register("testimonials.set_featured", `
+	MATCH (t:Testimonial {id: $id})
+	WHERE t.deletedAt IS NULL AND (NOT $featured OR t.isApproved = true)
+	SET t.isFeatured = $featured,
+		t.featuredOrder = CASE WHEN $featured THEN $order ELSE null END,
+		t.version = COALESCE(t.version, 0) + 1
+	RETURN t
+`, "id", "featured", "order")
+
+// TestimonialOwner returns the ID of the author of a testimonial as userId,
+// or no rows if it doesn't exist or was deleted
+var TestimonialOwner = register("testimonials.owner", `
+	MATCH (t:Testimonial {id: $id}) WHERE t.deletedAt IS NULL RETURN t.userId as userId
+`, "id")
+
+// TestimonialCreate creates a testimonial pending review, written by its
+// author. It returns no rows, and creates nothing, when the author doesn't
+// exist or was deleted.
+var TestimonialCreate = register("testimonials.create", `
+	CREATE (t:Testimonial {
+		id: $id,
+		userId: $userId,
+		story: $story,
+		impact: $impact,
+		isApproved: false,
+		isFeatured: false,
+		version: 1,
+		mediaType: $mediaType,
+		mediaUrl: $mediaUrl,
+		mediaDuration: $mediaDuration,
+		createdAt: $createdAt
+	})
+	WITH t
+	MATCH (u:User {id: $userId})
+	WHERE u.deletedAt IS NULL
+	CREATE (u)-[:WROTE]->(t)
+	RETURN t
+`, "id", "userId", "story", "impact", "mediaType", "mediaUrl", "mediaDuration", "createdAt")
+
+// TestimonialDropTranslations deletes the translations of a testimonial
+var TestimonialDropTranslations = register("testimonials.drop_translations", `
+	MATCH (:Testimonial {id: $id})-[:TRANSLATED_AS]->(tr:TestimonialTranslation)
+	DETACH DELETE tr
+`, "id")
+
+// TestimonialUpdate applies an edit to testimonial t and bumps its version,
+// unless a non-null $version isn't the current one, which is compared under
+// a write lock. It returns t with its author u and whether it was updated as
+// current. With $resetApproval it goes back to the moderation queue.
+var TestimonialUpdate = register("testimonials.update", `
+	MATCH (t:Testimonial {id: $id})
+	WHERE t.deletedAt IS NULL
+	SET t._lock = true
+	WITH t`+authorMatch+`
+	WITH t, u, $version IS NULL OR COALESCE(t.version, 0) = $version AS current
+	FOREACH (_ IN CASE WHEN current THEN [1] ELSE [] END |
+		SET t.story = COALESCE($story, t.story),
+			t.impact = COALESCE($impact, t.impact),
+			t.mediaType = CASE WHEN $removeMedia THEN null ELSE COALESCE($mediaType, t.mediaType) END,
+			t.mediaUrl = CASE WHEN $removeMedia THEN null ELSE COALESCE($mediaUrl, t.mediaUrl) END,
+			t.mediaDuration = CASE WHEN $removeMedia THEN null WHEN $mediaType IS NULL THEN t.mediaDuration ELSE $mediaDuration END,
+			t.updatedAt = $updatedAt,
+			t.version = COALESCE(t.version, 0) + 1
+	)
+	FOREACH (_ IN CASE WHEN current AND $resetApproval THEN [1] ELSE [] END |
+		SET t.isApproved = false,
+			t.isFeatured = false,
+			t.featuredOrder = null,
+			t.rejectionReason = null,
+			t.reviewedAt = null
+	)
+	REMOVE t._lock
+	RETURN t, u, current
+`, "id", "version", "story", "impact", "removeMedia", "mediaType", "mediaUrl", "mediaDuration", "updatedAt", "resetApproval")
+
+// TestimonialDelete marks a testimonial deleted and deletes its
+// translations and moderation notes, keeping its reactions until it is
+// purged. It returns the testimonial's ID, or no rows if there was none to
+// delete.
+var TestimonialDelete = register("testimonials.delete", `
+	MATCH (t:Testimonial {id: $id})
+	WHERE t.deletedAt IS NULL
+	SET t.deletedAt = $now,
+		t.isFeatured = false,
+		t.featuredOrder = null,
+		t.version = COALESCE(t.version, 0) + 1
+	WITH t
+	OPTIONAL MATCH (t)-[:TRANSLATED_AS|HAS_NOTE]->(owned)
+	WITH t, collect(owned) AS owned
+	FOREACH (o IN owned | DETACH DELETE o)
+	RETURN t.id AS id
+`, "id", "now")
+
+// TestimonialReacted returns whether a user reacted to an approved
+// testimonial as reacted and its author as authorId, or no rows if it isn't
+// approved
+var TestimonialReacted = register("testimonials.reacted", `
+	MATCH (t:Testimonial {id: $id, isApproved: true})
+	WHERE t.deletedAt IS NULL
+	OPTIONAL MATCH (:User {id: $userId})-[m:MOVED]->(t)
+	RETURN m IS NOT NULL as reacted, t.userId as authorId
+`, "id", "userId")
+
+// TestimonialUnreact removes a user's reaction and returns the new reaction
+// count as count
+var TestimonialUnreact = register("testimonials.unreact", `
+	MATCH (:User {id: $userId})-[m:MOVED]->(t:Testimonial {id: $id})
+	DELETE m
+	SET t.reactionCount = CASE WHEN COALESCE(t.reactionCount, 0) > 0 THEN t.reactionCount - 1 ELSE 0 END
+	RETURN t.reactionCount as count
+`, "id", "userId")
+
+// TestimonialReact adds a user's reaction and returns the new reaction count
+// as count, or no rows if the user doesn't exist or was deleted
+var TestimonialReact = register("testimonials.react", `
+	MATCH (u:User {id: $userId}), (t:Testimonial {id: $id})
+	WHERE u.deletedAt IS NULL
+	MERGE (u)-[m:MOVED]->(t)
+	ON CREATE SET m.createdAt = $now,
+		t.reactionCount = COALESCE(t.reactionCount, 0) + 1
+	RETURN t.reactionCount as count
+`, "id", "userId", "now")
+
+// TestimonialScreening records an automated screening result. A non-null
+// $reason rejects the testimonial.
+var TestimonialScreening = register("testimonials.screening", `
+	MATCH (t:Testimonial {id: $id})
+	SET t.screeningScore = $score,
+		t.screeningFlags = $flags,
+		t.screeningVerdict = $verdict
+	FOREACH (_ IN CASE WHEN $reason IS NULL THEN [] ELSE [1] END |
+		SET t.isApproved = false,
+			t.isFeatured = false,
+			t.featuredOrder = null,
+			t.rejectionReason = $reason,
+			t.reviewedAt = $now
+	)
+`, "id", "score", "flags", "verdict", "reason", "now")
+
+// TranslationSave creates or replaces the translation of a testimonial into
+// $locale, returning no rows if the testimonial doesn't exist or was deleted
+var TranslationSave = register("translations.save", `
+	MATCH (t:Testimonial {id: $id})
+	WHERE t.deletedAt IS NULL
+	MERGE (t)-[:TRANSLATED_AS]->(tr:TestimonialTranslation {locale: $locale})
+	SET tr.story = $story,
+		tr.impact = $impact,
+		tr.updatedAt = $updatedAt
+	RETURN tr
+`, "id", "locale", "story", "impact", "updatedAt")
+
+// TranslationDelete deletes the translation of a testimonial into $locale
+var TranslationDelete = register("translations.delete", `
+	MATCH (:Testimonial {id: $id})-[:TRANSLATED_AS]->(tr:TestimonialTranslation {locale: $locale})
+	DETACH DELETE tr
+`, "id", "locale")
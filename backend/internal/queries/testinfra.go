@@ -0,0 +1,9 @@
+package queries
+
+// TestReset deletes every node but the schema version records, so
+// integration tests sharing a database each start from an empty graph
+var TestReset = register("test.reset", `
+	MATCH (n)
+	WHERE NOT n:SchemaVersion
+	DETACH DELETE n
+`)
@@ -0,0 +1,32 @@
+package queries
+
+// trashLabels maps the kinds of soft deleted entity to their labels
+var trashLabels = map[string]string{
+	"users":        "User",
+	"acts":         "Act",
+	"testimonials": "Testimonial",
+}
+
+// TrashRestore clears the deletion mark of an entity and returns it as n,
+// or no rows if it doesn't exist or isn't deleted
+var TrashRestore = registerVariants("trash.restore", `
+	MATCH (n:{{variant}} {id: $id})
+	WHERE n.deletedAt IS NOT NULL
+	SET n.deletedAt = null,
+		n.version = COALESCE(n.version, 0) + 1
+	RETURN n
+`, trashLabels, "id")
+
+// TrashPurge permanently deletes up to $limit entities deleted before
+// $before, with the translations, moderation notes and notifications they
+// own, and returns how many as n
+var TrashPurge = registerVariants("trash.purge", `
+	MATCH (n:{{variant}})
+	WHERE n.deletedAt < $before
+	WITH n LIMIT $limit
+	OPTIONAL MATCH (n)-[:TRANSLATED_AS|HAS_NOTE|NOTIFIED]->(owned)
+	WITH n, collect(owned) AS owned
+	FOREACH (o IN owned | DETACH DELETE o)
+	DETACH DELETE n
+	RETURN count(*) AS n
+`, trashLabels, "before", "limit")
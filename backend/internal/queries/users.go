@@ -0,0 +1,134 @@
+package queries
+
+// UserGet returns user u with the number of acts they gave and received and
+// chains they started, or no rows if they don't exist or were deleted
+var UserGet = register("users.get", `
+	MATCH (u:User {id: $id})
+	WHERE u.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:GAVE]->(given:Act)
+	WHERE given.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:RECEIVED]->(received:Act)
+	WHERE received.deletedAt IS NULL
+	OPTIONAL MATCH (u)-[:STARTED]->(chain:Chain)
+	RETURN u,
+		   count(DISTINCT given) as actsGiven,
+		   count(DISTINCT received) as actsReceived,
+		   count(DISTINCT chain) as chainsStarted
+`, "id")
+
+// UserStream returns every user u that isn't deleted, oldest first, for
+// exports
+var UserStream = register("users.stream", `
+	MATCH (u:User)
+	WHERE u.deletedAt IS NULL
+	RETURN u
+	ORDER BY u.createdAt, u.id
+`)
+
+// UserByEmail returns the user u registered with $email, even if deleted:
+// their email stays taken until they are purged
+var UserByEmail = register("users.by_email", `
+	MATCH (u:User {email: $email}) RETURN u
+`, "email")
+
+// UserCreate creates an unverified user
+var UserCreate = register("users.create", `
+	CREATE (u:User {
+		id: $id,
+		email: $email,
+		passwordHash: $passwordHash,
+		name: $name,
+		isVerified: false,
+		version: 1,
+		createdAt: $createdAt,
+		updatedAt: $updatedAt
+	})
+	RETURN u
+`, "id", "email", "passwordHash", "name", "createdAt", "updatedAt")
+
+// UserUpdate sets the profile fields that aren't null and bumps the version
+// of user u, unless a non-null $version isn't the current one, which is
+// compared under a write lock. It returns u and whether it was updated as
+// current, or no rows if they don't exist.
+var UserUpdate = register("users.update", `
+	MATCH (u:User {id: $id})
+	WHERE u.deletedAt IS NULL
+	SET u._lock = true
+	WITH u, $version IS NULL OR COALESCE(u.version, 0) = $version AS current
+	FOREACH (_ IN CASE WHEN current THEN [1] ELSE [] END |
+		SET u.name = COALESCE($name, u.name),
+			u.avatar = COALESCE($avatar, u.avatar),
+			u.bio = COALESCE($bio, u.bio),
+			u.location = COALESCE($location, u.location),
+			u.locale = COALESCE($locale, u.locale),
+			u.hideFromLeaderboards = COALESCE($hideFromLeaderboards, u.hideFromLeaderboards),
+			u.updatedAt = $updatedAt,
+			u.version = COALESCE(u.version, 0) + 1
+	)
+	REMOVE u._lock
+	RETURN u, current
+`, "id", "version", "name", "avatar", "bio", "location", "locale", "hideFromLeaderboards", "updatedAt")
+
+// UserDelete marks a user deleted, keeping them and their relationships
+// until they are purged. It returns the user's ID, or no rows if there was
+// none to delete.
+var UserDelete = register("users.delete", `
+	MATCH (u:User {id: $id})
+	WHERE u.deletedAt IS NULL
+	SET u.deletedAt = $now,
+		u.version = COALESCE(u.version, 0) + 1
+	RETURN u.id AS id
+`, "id", "now")
+
+// UserBatch creates or refreshes the users in $rows by ID and returns how
+// many it wrote
+var UserBatch = register("users.batch", `
+	UNWIND $rows AS row
+	MERGE (u:User {id: row.id})
+	SET u += row,
+		u.version = COALESCE(u.version, 0) + 1
+	RETURN count(u) AS written
+`, "rows")
+
+// userFilter matches the users u that aren't deleted, whose name or email
+// contains $query, if set, and who are suspended or not as $suspended says,
+// if set
+const userFilter = `
+	MATCH (u:User)
+	WHERE u.deletedAt IS NULL
+	  AND ($query IS NULL OR toLower(u.name) CONTAINS $query OR toLower(u.email) CONTAINS $query)
+	  AND ($suspended IS NULL OR (u.suspendedAt IS NOT NULL) = $suspended)
+`
+
+// UserListCount returns the number of users matching the filter as total
+var UserListCount = register("users.list_count", userFilter+`
+	RETURN count(u) AS total
+`, "query", "suspended")
+
+// UserList returns a page of the users u matching the filter, newest first
+var UserList = register("users.list", userFilter+`
+	RETURN u
+	ORDER BY u.createdAt DESC, u.id
+	SKIP $skip LIMIT $limit
+`, "query", "suspended", "skip", "limit")
+
+// UserSuspended returns a row for user id only if an admin suspended them,
+// or they were erased, deleted or not
+var UserSuspended = register("users.suspended", `
+	MATCH (u:User {id: $id})
+	WHERE u.erasedAt IS NOT NULL OR (u.suspendedAt IS NOT NULL AND u.deletedAt IS NULL)
+	RETURN u.id AS id
+`, "id")
+
+// UserSuspend sets when user u was suspended and why, clearing both when
+// $suspendedAt is null, and bumps their version. It returns u, or no rows
+// if they don't exist or were deleted.
+var UserSuspend = register("users.suspend", `
+	MATCH (u:User {id: $id})
+	WHERE u.deletedAt IS NULL
+	SET u.suspendedAt = $suspendedAt,
+		u.suspensionReason = $reason,
+		u.updatedAt = $now,
+		u.version = COALESCE(u.version, 0) + 1
+	RETURN u
+`, "id", "suspendedAt", "reason", "now")
@@ -0,0 +1,87 @@
+package queries
+
+// WebhookCreate creates webhook w
+var WebhookCreate = register("webhooks.create", `
+	CREATE (w:Webhook {
+		id: $id,
+		url: $url,
+		events: $events,
+		secret: $secret,
+		createdAt: $createdAt
+	})
+`, "id", "url", "events", "secret", "createdAt")
+
+// WebhookList returns every webhook w, newest first
+var WebhookList = register("webhooks.list", `
+	MATCH (w:Webhook)
+	RETURN w
+	ORDER BY w.createdAt DESC, w.id
+`)
+
+// WebhookSubscribed returns the webhooks w subscribed to $event
+var WebhookSubscribed = register("webhooks.subscribed", `
+	MATCH (w:Webhook)
+	WHERE $event IN w.events
+	RETURN w
+`, "event")
+
+// WebhookExists returns n, 1 if webhook $id exists and 0 otherwise
+var WebhookExists = register("webhooks.exists", `
+	OPTIONAL MATCH (w:Webhook {id: $id})
+	RETURN count(w) AS n
+`, "id")
+
+// WebhookDelete deletes a webhook with its delivery log and returns how many
+// webhooks it deleted as n
+var WebhookDelete = register("webhooks.delete", `
+	OPTIONAL MATCH (w:Webhook {id: $id})
+	OPTIONAL MATCH (w)-[:DELIVERED]->(d:WebhookDelivery)
+	WITH w, collect(d) AS deliveries
+	FOREACH (d IN deliveries | DELETE d)
+	DETACH DELETE w
+	RETURN count(w) AS n
+`, "id")
+
+// WebhookRecordDelivery adds delivery d to the log of webhook $webhookId, if
+// it still exists
+var WebhookRecordDelivery = register("webhooks.record_delivery", `
+	MATCH (w:Webhook {id: $webhookId})
+	CREATE (w)-[:DELIVERED]->(d:WebhookDelivery {
+		id: $id,
+		webhookId: $webhookId,
+		eventId: $eventId,
+		event: $event,
+		attempt: $attempt,
+		statusCode: $statusCode,
+		error: $error,
+		succeeded: $succeeded,
+		durationMs: $durationMs,
+		createdAt: $createdAt
+	})
+`, "id", "webhookId", "eventId", "event", "attempt", "statusCode", "error", "succeeded", "durationMs", "createdAt")
+
+// WebhookDeliveryCount returns the size of webhook $id's delivery log as
+// total
+var WebhookDeliveryCount = register("webhooks.delivery_count", `
+	MATCH (:Webhook {id: $id})-[:DELIVERED]->(d:WebhookDelivery)
+	RETURN count(d) AS total
+`, "id")
+
+// WebhookDeliveryList returns a page of webhook $id's delivery log d,
+// newest first
+var WebhookDeliveryList = register("webhooks.delivery_list", `
+	MATCH (:Webhook {id: $id})-[:DELIVERED]->(d:WebhookDelivery)
+	RETURN d
+	ORDER BY d.createdAt DESC, d.attempt DESC
+	SKIP $skip LIMIT $limit
+`, "id", "skip", "limit")
+
+// WebhookPruneDeliveries deletes up to $limit delivery log entries created
+// before $before and returns how many as n
+var WebhookPruneDeliveries = register("webhooks.prune_deliveries", `
+	MATCH (d:WebhookDelivery)
+	WHERE d.createdAt < $before
+	WITH d LIMIT $limit
+	DETACH DELETE d
+	RETURN count(*) AS n
+`, "before", "limit")
@@ -0,0 +1,71 @@
+package rates
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+// DefaultECBURL is the European Central Bank's daily reference rates feed
+const DefaultECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECB fetches the euro reference rates the European Central Bank publishes
+// each working day around 16:00 CET. It covers about 30 currencies and
+// needs no account.
+type ECB struct {
+	// URL defaults to DefaultECBURL
+	URL string
+	// Client defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Fetch returns the latest reference rates against the euro
+func (e *ECB) Fetch(ctx context.Context) (*models.ExchangeRates, error) {
+	url := e.URL
+	if url == "" {
+		url = DefaultECBURL
+	}
+	body, err := get(ctx, e.Client, url)
+	if err != nil {
+		return nil, fmt.Errorf("ecb rates request failed: %w", err)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid ecb rates: %w", err)
+	}
+	day := envelope.Cube.Cube
+	date, err := time.Parse(time.DateOnly, day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ecb rates date %q", day.Time)
+	}
+	if len(day.Rates) == 0 {
+		return nil, errors.New("no rates in the ecb feed")
+	}
+
+	rates := &models.ExchangeRates{Base: "EUR", Date: date, Rates: make(map[string]float64, len(day.Rates))}
+	for _, r := range day.Rates {
+		rates.Rates[r.Currency] = r.Rate
+	}
+	return rates, nil
+}
+
+// Name returns "ecb"
+func (e *ECB) Name() string { return "ecb" }
@@ -0,0 +1,65 @@
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+// DefaultOpenExchangeRatesURL is the base URL of Open Exchange Rates' API
+const DefaultOpenExchangeRatesURL = "https://openexchangerates.org/api"
+
+// OpenExchangeRates fetches the latest rates against the US dollar from
+// Open Exchange Rates, covering about 170 currencies. The free plan
+// refreshes them hourly, more than the daily refresh needs.
+type OpenExchangeRates struct {
+	AppID string
+	// URL defaults to DefaultOpenExchangeRatesURL
+	URL string
+	// Client defaults to a client with a 30 second timeout
+	Client *http.Client
+}
+
+type openExchangeRatesResponse struct {
+	Timestamp   int64              `json:"timestamp"`
+	Base        string             `json:"base"`
+	Rates       map[string]float64 `json:"rates"`
+	Description string             `json:"description"`
+}
+
+// Fetch returns the latest rates against the US dollar
+func (o *OpenExchangeRates) Fetch(ctx context.Context) (*models.ExchangeRates, error) {
+	base := o.URL
+	if base == "" {
+		base = DefaultOpenExchangeRatesURL
+	}
+	body, err := get(ctx, o.Client, strings.TrimSuffix(base, "/")+"/latest.json?app_id="+url.QueryEscape(o.AppID))
+
+	var latest openExchangeRatesResponse
+	json.Unmarshal(body, &latest)
+	if err != nil {
+		if latest.Description != "" {
+			err = fmt.Errorf("%w: %s", err, latest.Description)
+		}
+		return nil, fmt.Errorf("openexchangerates request failed: %w", err)
+	}
+	if latest.Base == "" || len(latest.Rates) == 0 {
+		return nil, errors.New("no rates in the openexchangerates response")
+	}
+
+	return &models.ExchangeRates{
+		Base:  latest.Base,
+		Date:  time.Unix(latest.Timestamp, 0).UTC(),
+		Rates: latest.Rates,
+	}, nil
+}
+
+// Name returns "openexchangerates"
+func (o *OpenExchangeRates) Name() string { return "openexchangerates" }
@@ -0,0 +1,102 @@
+// Package rates fetches the daily exchange rates act values are converted
+// with, so stats and leaderboards can sum acts given in different
+// currencies in the platform's base currency. Providers are pluggable: the
+// European Central Bank's reference rates, or Open Exchange Rates.
+package rates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/version"
+)
+
+// Provider fetches the latest exchange rates
+type Provider interface {
+	// Fetch returns the latest rates against the provider's own base
+	// currency
+	Fetch(ctx context.Context) (*models.ExchangeRates, error)
+	// Name names the provider in logs and the stored rates
+	Name() string
+}
+
+// Config selects and configures a provider
+type Config struct {
+	// Driver is "ecb", "openexchangerates" or empty to leave act values
+	// unconverted
+	Driver string
+	// OpenExchangeRatesAppID configures the openexchangerates driver
+	OpenExchangeRatesAppID string
+}
+
+// NewProvider returns the provider described by cfg, or nil if conversion
+// is disabled
+func NewProvider(cfg Config) (Provider, error) {
+	switch cfg.Driver {
+	case "":
+		return nil, nil
+	case "ecb":
+		return &ECB{}, nil
+	case "openexchangerates":
+		if cfg.OpenExchangeRatesAppID == "" {
+			return nil, errors.New("the openexchangerates driver needs an app ID")
+		}
+		return &OpenExchangeRates{AppID: cfg.OpenExchangeRatesAppID}, nil
+	}
+	return nil, fmt.Errorf("unknown rates driver %q; use ecb or openexchangerates", cfg.Driver)
+}
+
+// Rebase returns rates expressed against base, which must be rates' base
+// or one of its currencies. The result includes base itself at 1.
+func Rebase(rates *models.ExchangeRates, base string) (*models.ExchangeRates, error) {
+	base = strings.ToUpper(base)
+	pivot := 1.0
+	if base != rates.Base {
+		var ok bool
+		if pivot, ok = rates.Rates[base]; !ok || pivot <= 0 {
+			return nil, fmt.Errorf("no %s rate in the rates against %s", base, rates.Base)
+		}
+	}
+
+	rebased := *rates
+	rebased.Base = base
+	rebased.Rates = make(map[string]float64, len(rates.Rates)+1)
+	rebased.Rates[rates.Base] = 1 / pivot
+	for currency, rate := range rates.Rates {
+		if rate > 0 {
+			rebased.Rates[currency] = rate / pivot
+		}
+	}
+	rebased.Rates[base] = 1
+	return &rebased, nil
+}
+
+// get fetches url and returns its body, failing on a non-2xx status
+func get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return body, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return body, nil
+}
@@ -0,0 +1,105 @@
+package rates
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"payforwardnow/internal/models"
+)
+
+const ecbFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-10-15">
+			<Cube currency="USD" rate="1.25"/>
+			<Cube currency="GBP" rate="0.5"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestECB(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ecbFeed)
+	}))
+	defer server.Close()
+
+	rates, err := (&ECB{URL: server.URL}).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates.Base != "EUR" || rates.Date.Format("2006-01-02") != "2026-10-15" || rates.Rates["USD"] != 1.25 || len(rates.Rates) != 2 {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+}
+
+func TestOpenExchangeRates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/latest.json" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("app_id") != "app" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":true,"status":401,"message":"invalid_app_id","description":"Invalid App ID provided."}`)
+			return
+		}
+		fmt.Fprint(w, `{"timestamp":1760572800,"base":"USD","rates":{"EUR":0.8,"JPY":150}}`)
+	}))
+	defer server.Close()
+
+	rates, err := (&OpenExchangeRates{AppID: "app", URL: server.URL}).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates.Base != "USD" || rates.Rates["JPY"] != 150 || rates.Date.Unix() != 1760572800 {
+		t.Errorf("unexpected rates %+v", rates)
+	}
+
+	_, err = (&OpenExchangeRates{AppID: "wrong", URL: server.URL}).Fetch(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "Invalid App ID") {
+		t.Errorf("expected the provider's error, got %v", err)
+	}
+}
+
+func TestRebase(t *testing.T) {
+	rates := &models.ExchangeRates{Base: "EUR", Rates: map[string]float64{"USD": 1.25, "GBP": 0.5}}
+
+	rebased, err := Rebase(rates, "usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for currency, expected := range map[string]float64{"USD": 1, "EUR": 0.8, "GBP": 0.4} {
+		if math.Abs(rebased.Rates[currency]-expected) > 1e-9 {
+			t.Errorf("expected %s at %v, got %v", currency, expected, rebased.Rates[currency])
+		}
+	}
+	if rates.Base != "EUR" || len(rates.Rates) != 2 {
+		t.Errorf("expected the original rates to be left alone, got %+v", rates)
+	}
+
+	if same, _ := Rebase(rates, "EUR"); same.Rates["EUR"] != 1 || same.Rates["USD"] != 1.25 {
+		t.Errorf("unexpected rates against their own base %+v", same)
+	}
+	if _, err := Rebase(rates, "CHF"); err == nil {
+		t.Error("expected a base without a rate to be rejected")
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, cfg := range []Config{
+		{Driver: "openexchangerates"},
+		{Driver: "fixer"},
+	} {
+		if _, err := NewProvider(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+	if p, err := NewProvider(Config{}); p != nil || err != nil {
+		t.Errorf("expected no provider without a driver, got %v and %v", p, err)
+	}
+}
@@ -0,0 +1,52 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// RenderTextPDF renders a single-page PDF containing a title followed by
+// plain text lines. It only needs the built-in Helvetica font, which keeps
+// report downloads free of external rendering dependencies.
+func RenderTextPDF(title string, lines []string) []byte {
+	var content bytes.Buffer
+	content.WriteString("BT\n/F1 18 Tf\n50 790 Td\n")
+	fmt.Fprintf(&content, "(%s) Tj\n", escapePDFText(title))
+	content.WriteString("/F1 11 Tf\n0 -30 Td\n14 TL\n")
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) '\n", escapePDFText(line))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+
+	return buf.Bytes()
+}
+
+func escapePDFText(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`, "\r", "", "\n", " ")
+	return replacer.Replace(s)
+}
@@ -0,0 +1,88 @@
+package reports
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStore_Lifecycle(t *testing.T) {
+	store := NewStore(time.Hour)
+
+	if store.Get("key") != nil {
+		t.Fatal("expected missing entry")
+	}
+
+	if !store.MarkPending("key") {
+		t.Fatal("expected first MarkPending to succeed")
+	}
+	if store.MarkPending("key") {
+		t.Error("expected second MarkPending to fail while pending")
+	}
+
+	entry := store.Get("key")
+	if entry == nil || entry.Status != StatusPending {
+		t.Fatalf("expected pending entry, got %+v", entry)
+	}
+
+	store.SetReady("key", "report")
+	entry = store.Get("key")
+	if entry == nil || entry.Status != StatusReady || entry.Report != "report" {
+		t.Fatalf("expected ready entry, got %+v", entry)
+	}
+
+	store.SetFailed("key", errors.New("boom"))
+	entry = store.Get("key")
+	if entry == nil || entry.Status != StatusFailed || entry.Error != "boom" {
+		t.Fatalf("expected failed entry, got %+v", entry)
+	}
+}
+
+func TestStore_Expiry(t *testing.T) {
+	store := NewStore(time.Minute)
+	store.SetReady("key", "report")
+	store.entries["key"].UpdatedAt = time.Now().Add(-2 * time.Minute)
+
+	if store.Get("key") != nil {
+		t.Error("expected expired entry to be ignored")
+	}
+}
+
+func TestStore_EvictsExpiredEntries(t *testing.T) {
+	store := NewStore(time.Minute)
+	store.SetReady("old", "report")
+	store.MarkPending("abandoned")
+	store.entries["old"].UpdatedAt = time.Now().Add(-2 * time.Minute)
+	store.entries["abandoned"].UpdatedAt = time.Now().Add(-2 * time.Minute)
+	store.swept = time.Time{}
+
+	store.SetReady("new", "report")
+
+	if _, ok := store.entries["old"]; ok {
+		t.Error("expected the expired report to be evicted")
+	}
+	if _, ok := store.entries["abandoned"]; ok {
+		t.Error("expected the abandoned generation to be evicted")
+	}
+	if store.Get("new") == nil {
+		t.Error("expected the new report to be kept")
+	}
+}
+
+func TestRenderTextPDF(t *testing.T) {
+	pdf := RenderTextPDF("Impact (2024)", []string{"Acts given: 3", `Path \ test`})
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) {
+		t.Error("expected PDF header")
+	}
+	if !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Error("expected PDF trailer")
+	}
+	if !bytes.Contains(pdf, []byte(`Impact \(2024\)`)) {
+		t.Error("expected escaped title in content stream")
+	}
+	if !bytes.Contains(pdf, []byte(`Path \\ test`)) {
+		t.Error("expected escaped backslash in content stream")
+	}
+}
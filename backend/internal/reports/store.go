@@ -0,0 +1,130 @@
+package reports
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status represents the generation state of a report
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusReady   Status = "ready"
+	StatusFailed  Status = "failed"
+)
+
+// Entry holds a report and its generation state
+type Entry struct {
+	Status    Status
+	Report    interface{}
+	Error     string
+	UpdatedAt time.Time
+}
+
+// Store keeps generated reports in memory so heavy reports can be built in
+// the background and picked up by a later request. Expired entries are
+// evicted as new ones are written, so the store only grows with the reports
+// requested within ttl.
+type Store struct {
+	mu      sync.RWMutex
+	entries map[string]*Entry
+	ttl     time.Duration
+	swept   time.Time
+}
+
+// NewStore creates a new report store; ready reports expire after ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		entries: make(map[string]*Entry),
+		ttl:     ttl,
+	}
+}
+
+// Get returns the entry for key, or nil if it is missing or expired
+func (s *Store) Get(key string) *Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if entry.Status != StatusPending && time.Since(entry.UpdatedAt) > s.ttl {
+		return nil
+	}
+
+	copied := *entry
+	return &copied
+}
+
+// MarkPending records that generation for key has started. It returns false
+// if another generation for the same key is already in flight.
+func (s *Store) MarkPending(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.Status == StatusPending {
+		return false
+	}
+
+	s.sweep()
+	s.entries[key] = &Entry{Status: StatusPending, UpdatedAt: time.Now()}
+	return true
+}
+
+// SetReady stores a finished report
+func (s *Store) SetReady(key string, report interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	s.entries[key] = &Entry{Status: StatusReady, Report: report, UpdatedAt: time.Now()}
+}
+
+// SetFailed records a generation failure
+func (s *Store) SetFailed(key string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep()
+	s.entries[key] = &Entry{Status: StatusFailed, Error: err.Error(), UpdatedAt: time.Now()}
+}
+
+// Delete forgets the entry for key, so its report is generated again by the
+// next request
+func (s *Store) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+// DeletePrefix forgets the entries whose keys start with prefix
+func (s *Store) DeletePrefix(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// sweep evicts the entries older than ttl, at most once per ttl so writes
+// stay cheap. Pending entries that old belong to generations that never
+// finished, and are evicted too. s.mu must be held.
+func (s *Store) sweep() {
+	now := time.Now()
+	if now.Sub(s.swept) < s.ttl {
+		return
+	}
+	s.swept = now
+	for key, entry := range s.entries {
+		if now.Sub(entry.UpdatedAt) > s.ttl {
+			delete(s.entries, key)
+		}
+	}
+}
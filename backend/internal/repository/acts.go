@@ -0,0 +1,464 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jActRepository stores acts as Act nodes linked to their giver
+type Neo4jActRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jActs creates an ActRepository backed by db
+func NewNeo4jActs(db database.DBClient) *Neo4jActRepository {
+	return &Neo4jActRepository{db: db}
+}
+
+type actPage struct {
+	acts  []models.Act
+	total int64
+}
+
+// List returns a page of acts, newest first, and the total count
+func (r *Neo4jActRepository) List(ctx context.Context, page models.PaginationParams) ([]models.Act, int64, error) {
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (actPage, error) {
+		countResult, err := queries.ActCount.Run(ctx, tx, nil)
+		if err != nil {
+			return actPage{}, err
+		}
+
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.ActList.Run(ctx, tx, map[string]interface{}{
+			"skip":  (page.Page - 1) * page.PerPage,
+			"limit": page.PerPage,
+		})
+		if err != nil {
+			return actPage{}, err
+		}
+
+		acts, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.Act, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "a")
+			if err != nil {
+				return models.Act{}, err
+			}
+			act, err := actFromNode(node)
+			if err != nil {
+				return models.Act{}, err
+			}
+			return *act, nil
+		})
+		return actPage{acts: acts, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.acts, p.total, nil
+}
+
+type nearbyPage struct {
+	acts  []models.NearbyAct
+	total int64
+}
+
+// Nearby returns a page of the acts placed within radiusKm of latitude,
+// longitude, nearest first, and how many there are
+func (r *Neo4jActRepository) Nearby(ctx context.Context, latitude, longitude, radiusKm float64, page models.PaginationParams) ([]models.NearbyAct, int64, error) {
+	params := map[string]interface{}{
+		"latitude":  latitude,
+		"longitude": longitude,
+		"radius":    radiusKm * 1000,
+		"skip":      (page.Page - 1) * page.PerPage,
+		"limit":     page.PerPage,
+	}
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (nearbyPage, error) {
+		countResult, err := queries.ActNearbyCount.Run(ctx, tx, params)
+		if err != nil {
+			return nearbyPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.ActNearby.Run(ctx, tx, params)
+		if err != nil {
+			return nearbyPage{}, err
+		}
+		acts, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.NearbyAct, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "a")
+			if err != nil {
+				return models.NearbyAct{}, err
+			}
+			act, err := actFromNode(node)
+			if err != nil {
+				return models.NearbyAct{}, err
+			}
+			distance, err := database.RecordValue[float64](record, "distance")
+			return models.NearbyAct{Act: *act, DistanceKm: distance / 1000}, err
+		})
+		return nearbyPage{acts: acts, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.acts, p.total, nil
+}
+
+// Screen records the screening of an act's title and description at now,
+// or returns ErrActNotFound
+func (r *Neo4jActRepository) Screen(ctx context.Context, id string, screening moderation.Result, now time.Time) error {
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.ActScreening.Run(ctx, tx, map[string]interface{}{
+			"id":      id,
+			"score":   screening.Score,
+			"flags":   screening.Flags,
+			"verdict": string(screening.Verdict),
+			"now":     now.UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+	if err == nil && !found {
+		return ErrActNotFound
+	}
+	return err
+}
+
+// flaggedPage is a page of flagged acts and how many there are
+type flaggedPage struct {
+	acts  []models.FlaggedAct
+	total int64
+}
+
+// Flagged returns a page of the acts screening flagged, highest score
+// first, and how many there are
+func (r *Neo4jActRepository) Flagged(ctx context.Context, page models.PaginationParams) ([]models.FlaggedAct, int64, error) {
+	params := map[string]interface{}{
+		"skip":  (page.Page - 1) * page.PerPage,
+		"limit": page.PerPage,
+	}
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (flaggedPage, error) {
+		countResult, err := queries.ActsFlaggedCount.Run(ctx, tx, nil)
+		if err != nil {
+			return flaggedPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.ActsFlagged.Run(ctx, tx, params)
+		if err != nil {
+			return flaggedPage{}, err
+		}
+		acts, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.FlaggedAct, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "a")
+			if err != nil {
+				return models.FlaggedAct{}, err
+			}
+			act, err := actFromNode(node)
+			if err != nil {
+				return models.FlaggedAct{}, err
+			}
+			screening, err := database.DecodeNode[models.Screening](node)
+			if screening.Flags == nil {
+				screening.Flags = []string{}
+			}
+			screenedAt, _ := node.Props["screenedAt"].(time.Time)
+			return models.FlaggedAct{Act: *act, Screening: screening, ScreenedAt: screenedAt}, err
+		})
+		return flaggedPage{acts: acts, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.acts, p.total, nil
+}
+
+// DismissFlag clears the flag of an act reviewerID found acceptable, or
+// returns ErrActNotFound if it isn't flagged
+func (r *Neo4jActRepository) DismissFlag(ctx context.Context, id, reviewerID string, now time.Time) error {
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.ActDismissFlag.Run(ctx, tx, map[string]interface{}{
+			"id":         id,
+			"reviewerId": nilIfEmpty(reviewerID),
+			"now":        now.UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+	if err == nil && !found {
+		return ErrActNotFound
+	}
+	return err
+}
+
+// Completed returns up to limit acts completed after after, or at after with
+// an ID after afterID, in the order they were completed
+func (r *Neo4jActRepository) Completed(ctx context.Context, after time.Time, afterID string, limit int) ([]models.Act, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.Act, error) {
+		result, err := queries.ActsCompleted.Run(ctx, tx, map[string]interface{}{
+			"after":   after.UTC(),
+			"afterId": afterID,
+			"limit":   limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.Act, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "a")
+			if err != nil {
+				return models.Act{}, err
+			}
+			act, err := actFromNode(node)
+			if err != nil {
+				return models.Act{}, err
+			}
+			return *act, nil
+		})
+	})
+}
+
+// Stream passes every act, oldest first, to fn as they are read
+func (r *Neo4jActRepository) Stream(ctx context.Context, fn func(*models.Act) error) error {
+	return database.Stream(ctx, r.db, func(tx neo4j.ManagedTransaction) (neo4j.ResultWithContext, error) {
+		return queries.ActStream.Run(ctx, tx, nil)
+	}, func(record *neo4j.Record) (*models.Act, error) {
+		node, err := database.RecordValue[neo4j.Node](record, "a")
+		if err != nil {
+			return nil, err
+		}
+		return actFromNode(node)
+	}, fn)
+}
+
+// Get returns an act, or ErrActNotFound
+func (r *Neo4jActRepository) Get(ctx context.Context, id string) (*models.Act, error) {
+	act, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Act, error) {
+		result, err := queries.ActGet.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Next(ctx) {
+			actNode, _ := result.Record().Get("a")
+			return actFromNode(actNode.(neo4j.Node))
+		}
+
+		return nil, result.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if act == nil {
+		return nil, ErrActNotFound
+	}
+	return act, nil
+}
+
+// Giver returns the ID of the user who gave an act, even an anonymous one,
+// or ErrActNotFound
+func (r *Neo4jActRepository) Giver(ctx context.Context, id string) (string, error) {
+	giverID, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*string, error) {
+		result, err := queries.ActGiver.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, result.Err()
+		}
+		giverID, _ := database.RecordValue[string](result.Record(), "giverId")
+		return &giverID, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if giverID == nil {
+		return "", ErrActNotFound
+	}
+	return *giverID, nil
+}
+
+// Create stores act and links it to its giver, setting act.BaseValue and
+// act.BaseCurrency if its value could be converted. It returns false if the giver does not exist,
+// in which case nothing is stored.
+func (r *Neo4jActRepository) Create(ctx context.Context, act *models.Act) (bool, error) {
+	created, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.ActCreate.Run(ctx, tx, map[string]interface{}{
+			"id":             act.ID,
+			"title":          act.Title,
+			"description":    act.Description,
+			"type":           string(act.Type),
+			"category":       act.Category,
+			"value":          act.Value,
+			"currency":       act.Currency,
+			"status":         string(act.Status),
+			"giverId":        act.GiverID,
+			"receiverId":     nilIfEmpty(act.ReceiverID),
+			"chainId":        nilIfEmpty(act.ChainID),
+			"campaignId":     nilIfEmpty(act.CampaignID),
+			"organizationId": nilIfEmpty(act.OrganizationID),
+			"location":       nilIfEmpty(act.Location),
+			"isAnonymous":    act.IsAnonymous,
+			"createdAt":      act.CreatedAt,
+			"updatedAt":      act.UpdatedAt,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if !result.Next(ctx) {
+			return false, result.Err()
+		}
+		if node, err := database.RecordValue[neo4j.Node](result.Record(), "a"); err == nil {
+			if baseValue, ok := node.Props["baseValue"].(float64); ok {
+				act.BaseValue = &baseValue
+				act.BaseCurrency, _ = node.Props["baseCurrency"].(string)
+			}
+		}
+		return true, RecordEvent(ctx, tx, models.DomainActCreated, act.ID, act)
+	})
+	return created, err
+}
+
+// Update changes the fields set in req and returns the act's new version,
+// or returns ErrActNotFound. A stale req.Version fails with
+// ErrVersionConflict.
+func (r *Neo4jActRepository) Update(ctx context.Context, id string, req models.UpdateActRequest) (int64, error) {
+	version, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*int64, error) {
+		result, err := queries.ActUpdate.Run(ctx, tx, map[string]interface{}{
+			"id":          id,
+			"version":     int64OrNil(req.Version),
+			"title":       nilIfEmpty(req.Title),
+			"description": nilIfEmpty(req.Description),
+			"status":      nilIfEmpty(string(req.Status)),
+			"updatedAt":   time.Now().UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		act, found, err := database.First(ctx, result, func(record *neo4j.Record) (models.Act, error) {
+			return updatedNode[models.Act](record, "a")
+		})
+		if err != nil || !found {
+			return nil, err
+		}
+		if err := RecordEvent(ctx, tx, models.DomainActUpdated, id, act); err != nil {
+			return nil, err
+		}
+		if act.CompletedAt != nil && act.CompletedAt.Equal(act.UpdatedAt) {
+			if err := RecordEvent(ctx, tx, models.DomainActCompleted, id, act); err != nil {
+				return nil, err
+			}
+			// Number the receipt now, so receipts follow donation order
+			if err := issueReceipt(ctx, tx, id, act.UpdatedAt); err != nil {
+				return nil, err
+			}
+		}
+		return &act.Version, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if version == nil {
+		return 0, ErrActNotFound
+	}
+	return *version, nil
+}
+
+// Delete marks an act deleted, hiding it until it is restored or purged
+func (r *Neo4jActRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return nil, softDelete(ctx, tx, queries.ActDelete, models.DomainActDeleted, id)
+	})
+	return err
+}
+
+// TakeDown deletes an act a moderator removed, recording an act.deleted
+// event with their reason, or returns ErrActNotFound
+func (r *Neo4jActRepository) TakeDown(ctx context.Context, id, reason, moderatorID string, now time.Time) error {
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.ActTakeDown.Run(ctx, tx, map[string]interface{}{
+			"id":          id,
+			"reason":      reason,
+			"moderatorId": nilIfEmpty(moderatorID),
+			"now":         now.UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		if !result.Next(ctx) {
+			return false, result.Err()
+		}
+		return true, RecordEvent(ctx, tx, models.DomainActDeleted, id, models.ActTakedown{
+			DeletedAt:   now.UTC(),
+			ModeratorID: moderatorID,
+			Reason:      reason,
+		})
+	})
+	if err == nil && !found {
+		return ErrActNotFound
+	}
+	return err
+}
+
+// Expire marks the acts left pending since before before expired at now,
+// in batches, recording an act.expired event for each, and returns how
+// many
+func (r *Neo4jActRepository) Expire(ctx context.Context, before, now time.Time) (int, error) {
+	params := map[string]interface{}{"before": before.UTC(), "now": now.UTC(), "limit": BatchSize}
+	expired := 0
+	for {
+		n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+			result, err := queries.ActsExpire.Run(ctx, tx, params)
+			if err != nil {
+				return 0, err
+			}
+			ids, err := database.Collect(ctx, result, func(record *neo4j.Record) (string, error) {
+				return database.RecordValue[string](record, "id")
+			})
+			if err != nil {
+				return 0, err
+			}
+			for _, id := range ids {
+				if err := RecordEvent(ctx, tx, models.DomainActExpired, id, struct{}{}); err != nil {
+					return 0, err
+				}
+			}
+			return len(ids), nil
+		})
+		expired += n
+		if err != nil || n < BatchSize {
+			return expired, err
+		}
+	}
+}
+
+// actFromNode maps an Act node. The giver of an anonymous act is left out.
+func actFromNode(node neo4j.Node) (*models.Act, error) {
+	act, err := database.DecodeNode[models.Act](node)
+	if err != nil {
+		return nil, err
+	}
+	if act.IsAnonymous {
+		act.GiverID = ""
+	}
+	return &act, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// BatchSize is the number of rows written per transaction by the batch
+// methods. Each chunk commits on its own, so a failure part way through
+// leaves the earlier chunks written; since rows are merged on their IDs, the
+// whole batch can simply be retried.
+const BatchSize = 500
+
+// BatchUser is a user to create in bulk, with their password hash
+type BatchUser struct {
+	User         models.User
+	PasswordHash string
+}
+
+// CreateBatch creates or refreshes users by ID, BatchSize at a time, and
+// returns how many were written
+func (r *Neo4jUserRepository) CreateBatch(ctx context.Context, users []BatchUser) (int, error) {
+	rows := make([]map[string]interface{}, len(users))
+	for i, u := range users {
+		rows[i] = map[string]interface{}{
+			"id":                   u.User.ID,
+			"email":                u.User.Email,
+			"passwordHash":         u.PasswordHash,
+			"name":                 u.User.Name,
+			"avatar":               nilIfEmpty(u.User.Avatar),
+			"bio":                  nilIfEmpty(u.User.Bio),
+			"location":             nilIfEmpty(u.User.Location),
+			"isVerified":           u.User.IsVerified,
+			"hideFromLeaderboards": u.User.HideFromLeaderboards,
+			"createdAt":            u.User.CreatedAt,
+			"updatedAt":            u.User.UpdatedAt,
+		}
+	}
+	return writeBatches(ctx, r.db, queries.UserBatch, rows)
+}
+
+// CreateBatch creates or refreshes acts by ID and links them to their givers
+// and receivers, BatchSize at a time. Acts whose giver does not exist are
+// skipped; the returned count only includes the acts written.
+func (r *Neo4jActRepository) CreateBatch(ctx context.Context, acts []models.Act) (int, error) {
+	rows := make([]map[string]interface{}, len(acts))
+	for i, a := range acts {
+		row := map[string]interface{}{
+			"id":          a.ID,
+			"title":       a.Title,
+			"description": a.Description,
+			"type":        string(a.Type),
+			"category":    a.Category,
+			"value":       a.Value,
+			"currency":    a.Currency,
+			"status":      string(a.Status),
+			"giverId":     a.GiverID,
+			"receiverId":  nilIfEmpty(a.ReceiverID),
+			"location":    nilIfEmpty(a.Location),
+			"isAnonymous": a.IsAnonymous,
+			"createdAt":   a.CreatedAt,
+			"updatedAt":   a.UpdatedAt,
+		}
+		if a.CompletedAt != nil {
+			row["completedAt"] = *a.CompletedAt
+		}
+		rows[i] = row
+	}
+	return writeBatches(ctx, r.db, queries.ActBatch, rows)
+}
+
+// writeBatches runs query once per chunk of rows, passed as $rows, each in
+// its own transaction. The query must return the rows it wrote as
+// "written".
+func writeBatches(ctx context.Context, db database.DBClient, query *queries.Query, rows []map[string]interface{}) (int, error) {
+	written := 0
+	for start := 0; start < len(rows); start += BatchSize {
+		batch := rows[start:min(start+BatchSize, len(rows))]
+
+		n, err := database.ExecuteWrite(ctx, db, func(tx neo4j.ManagedTransaction) (int64, error) {
+			result, err := query.Run(ctx, tx, map[string]interface{}{"rows": batch})
+			if err != nil {
+				return 0, err
+			}
+			record, err := result.Single(ctx)
+			if err != nil {
+				return 0, err
+			}
+			return getInt64(record, "written"), nil
+		})
+		if err != nil {
+			return written, err
+		}
+		written += int(n)
+	}
+	return written, nil
+}
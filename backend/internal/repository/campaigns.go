@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jCampaignRepository stores campaigns as Campaign nodes, which users
+// JOINED and acts are PART_OF
+type Neo4jCampaignRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jCampaigns creates a CampaignRepository backed by db
+func NewNeo4jCampaigns(db database.DBClient) *Neo4jCampaignRepository {
+	return &Neo4jCampaignRepository{db: db}
+}
+
+// Create stores campaign
+func (r *Neo4jCampaignRepository) Create(ctx context.Context, campaign *models.Campaign) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.CampaignCreate.Run(ctx, tx, map[string]interface{}{
+			"id":          campaign.ID,
+			"name":        campaign.Name,
+			"description": nilIfEmpty(campaign.Description),
+			"startsAt":    campaign.StartsAt.UTC(),
+			"endsAt":      campaign.EndsAt.UTC(),
+			"goalActs":    campaign.GoalActs,
+			"goalValue":   campaign.GoalValue,
+			"createdBy":   nilIfEmpty(campaign.CreatedBy),
+			"createdAt":   campaign.CreatedAt.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, RecordEvent(ctx, tx, models.DomainCampaignCreated, campaign.ID, campaign)
+	})
+	return err
+}
+
+// Get returns a campaign with its progress, or ErrCampaignNotFound
+func (r *Neo4jCampaignRepository) Get(ctx context.Context, id string) (*models.Campaign, error) {
+	campaign, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Campaign, error) {
+		result, err := queries.CampaignGet.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		campaign, found, err := database.First(ctx, result, campaignFromRecord)
+		if err != nil || !found {
+			return nil, err
+		}
+		return &campaign, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if campaign == nil {
+		return nil, ErrCampaignNotFound
+	}
+	return campaign, nil
+}
+
+type campaignPage struct {
+	campaigns []models.Campaign
+	total     int64
+}
+
+// List returns a page of the campaigns with status at now, or all of them,
+// latest starting first and with their progress, and how many match
+func (r *Neo4jCampaignRepository) List(ctx context.Context, status models.CampaignStatus, now time.Time, page models.PaginationParams) ([]models.Campaign, int64, error) {
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (campaignPage, error) {
+		countResult, err := queries.CampaignCount.Run(ctx, tx, map[string]interface{}{
+			"status": nilIfEmpty(string(status)),
+			"now":    now.UTC(),
+		})
+		if err != nil {
+			return campaignPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.CampaignList.Run(ctx, tx, map[string]interface{}{
+			"status": nilIfEmpty(string(status)),
+			"now":    now.UTC(),
+			"skip":   (page.Page - 1) * page.PerPage,
+			"limit":  page.PerPage,
+		})
+		if err != nil {
+			return campaignPage{}, err
+		}
+		campaigns, err := database.Collect(ctx, result, campaignFromRecord)
+		if campaigns == nil {
+			campaigns = []models.Campaign{}
+		}
+		return campaignPage{campaigns: campaigns, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.campaigns, p.total, nil
+}
+
+// Join adds a user to a campaign's participants, or returns
+// ErrCampaignNotFound
+func (r *Neo4jCampaignRepository) Join(ctx context.Context, id, userID string, now time.Time) error {
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.CampaignJoin.Run(ctx, tx, map[string]interface{}{
+			"id":     id,
+			"userId": userID,
+			"now":    now.UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrCampaignNotFound
+	}
+	return nil
+}
+
+// Leave removes a user from a campaign's participants, or returns
+// ErrCampaignNotFound
+func (r *Neo4jCampaignRepository) Leave(ctx context.Context, id, userID string) error {
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.CampaignLeave.Run(ctx, tx, map[string]interface{}{
+			"id":     id,
+			"userId": userID,
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrCampaignNotFound
+	}
+	return nil
+}
+
+// Leaderboard ranks up to limit users by the acts they tagged to a campaign
+func (r *Neo4jCampaignRepository) Leaderboard(ctx context.Context, id string, limit int) ([]models.LeaderboardEntry, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.LeaderboardEntry, error) {
+		result, err := queries.CampaignLeaderboard.Run(ctx, tx, map[string]interface{}{
+			"id":    id,
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return collectLeaderboard(ctx, result)
+	})
+}
+
+func campaignFromRecord(record *neo4j.Record) (models.Campaign, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "c")
+	if err != nil {
+		return models.Campaign{}, err
+	}
+	campaign, err := database.DecodeNode[models.Campaign](node)
+	if err != nil {
+		return models.Campaign{}, err
+	}
+	campaign.Progress = &models.CampaignProgress{
+		Acts:         getInt64(record, "acts"),
+		Value:        getFloat64(record, "value"),
+		Participants: getInt64(record, "participants"),
+	}
+	return campaign, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jChainRepository stores chains as Chain nodes containing acts
+type Neo4jChainRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jChains creates a ChainRepository backed by db
+func NewNeo4jChains(db database.DBClient) *Neo4jChainRepository {
+	return &Neo4jChainRepository{db: db}
+}
+
+// Get returns a chain with the number of acts in it, or ErrChainNotFound
+func (r *Neo4jChainRepository) Get(ctx context.Context, id string) (*models.Chain, error) {
+	chain, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Chain, error) {
+		result, err := queries.ChainGet.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Next(ctx) {
+			chainNode, _ := result.Record().Get("c")
+			if chainNode == nil {
+				return nil, nil
+			}
+			chain, err := chainFromNode(chainNode.(neo4j.Node))
+			if err != nil {
+				return nil, err
+			}
+			if acts, ok := result.Record().Get("acts"); ok {
+				list, _ := acts.([]interface{})
+				chain.ActsCount = len(list)
+			}
+			return chain, nil
+		}
+
+		return nil, result.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chain == nil {
+		return nil, ErrChainNotFound
+	}
+	return chain, nil
+}
+
+// ListByUser returns the chains a user started or took part in, newest first
+func (r *Neo4jChainRepository) ListByUser(ctx context.Context, userID string) ([]models.Chain, error) {
+	chains, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.Chain, error) {
+		result, err := queries.ChainsByUser.Run(ctx, tx, map[string]interface{}{"userId": userID})
+		if err != nil {
+			return nil, err
+		}
+
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.Chain, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "c")
+			if err != nil {
+				return models.Chain{}, err
+			}
+			chain, err := chainFromNode(node)
+			if err != nil {
+				return models.Chain{}, err
+			}
+			return *chain, nil
+		})
+	})
+	return chains, err
+}
+
+// Members returns the IDs of the users who started or took part in a chain
+func (r *Neo4jChainRepository) Members(ctx context.Context, id string) ([]string, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]string, error) {
+		result, err := queries.ChainMembers.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (string, error) {
+			return database.RecordValue[string](record, "userId")
+		})
+	})
+}
+
+func chainFromNode(node neo4j.Node) (*models.Chain, error) {
+	chain, err := database.DecodeNode[models.Chain](node)
+	if err != nil {
+		return nil, err
+	}
+	return &chain, nil
+}
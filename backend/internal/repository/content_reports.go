@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jContentReportRepository stores content reports as ContentReport
+// nodes, referring to what they report by kind and ID so a report outlives
+// the content being deleted
+type Neo4jContentReportRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jContentReports creates a ContentReportRepository backed by db
+func NewNeo4jContentReports(db database.DBClient) *Neo4jContentReportRepository {
+	return &Neo4jContentReportRepository{db: db}
+}
+
+// Create stores report as open, or returns ErrReportTargetNotFound
+func (r *Neo4jContentReportRepository) Create(ctx context.Context, report *models.ContentReport) error {
+	query, ok := queries.ContentReportCreate[report.Kind]
+	if !ok {
+		return fmt.Errorf("unknown report kind %q", report.Kind)
+	}
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := query.Run(ctx, tx, map[string]interface{}{
+			"id":         report.ID,
+			"kind":       report.Kind,
+			"targetId":   report.TargetID,
+			"reporterId": report.ReporterID,
+			"reason":     report.Reason,
+			"details":    nilIfEmpty(report.Details),
+			"createdAt":  report.CreatedAt.UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrReportTargetNotFound
+	}
+	report.Status = models.ReportOpen
+	return nil
+}
+
+type contentReportPage struct {
+	reports []models.ContentReport
+	total   int64
+}
+
+// List returns a page of the reports with status, or all of them, oldest
+// first, and how many match
+func (r *Neo4jContentReportRepository) List(ctx context.Context, status models.ReportStatus, page models.PaginationParams) ([]models.ContentReport, int64, error) {
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (contentReportPage, error) {
+		countResult, err := queries.ContentReportCount.Run(ctx, tx, map[string]interface{}{
+			"status": nilIfEmpty(string(status)),
+		})
+		if err != nil {
+			return contentReportPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.ContentReportList.Run(ctx, tx, map[string]interface{}{
+			"status": nilIfEmpty(string(status)),
+			"skip":   (page.Page - 1) * page.PerPage,
+			"limit":  page.PerPage,
+		})
+		if err != nil {
+			return contentReportPage{}, err
+		}
+		reports, err := database.Collect(ctx, result, contentReportFromRecord)
+		if reports == nil {
+			reports = []models.ContentReport{}
+		}
+		return contentReportPage{reports: reports, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.reports, p.total, nil
+}
+
+// Handle closes an open report and returns it, or returns
+// ErrReportNotFound
+func (r *Neo4jContentReportRepository) Handle(ctx context.Context, id string, status models.ReportStatus, handlerID, note string, now time.Time) (*models.ContentReport, error) {
+	report, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.ContentReport, error) {
+		result, err := queries.ContentReportHandle.Run(ctx, tx, map[string]interface{}{
+			"id":        id,
+			"status":    string(status),
+			"handlerId": nilIfEmpty(handlerID),
+			"note":      nilIfEmpty(note),
+			"handledAt": now.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		report, found, err := database.First(ctx, result, contentReportFromRecord)
+		if err != nil || !found {
+			return nil, err
+		}
+		return &report, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrReportNotFound
+	}
+	return report, nil
+}
+
+func contentReportFromRecord(record *neo4j.Record) (models.ContentReport, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "r")
+	if err != nil {
+		return models.ContentReport{}, err
+	}
+	return database.DecodeNode[models.ContentReport](node)
+}
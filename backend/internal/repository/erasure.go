@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Placeholders left in place of erased personal data
+const (
+	ErasedUserName = "Deleted user"
+	ErasedActTitle = "Removed act"
+)
+
+// ErasedEmail is the address given to an erased user. Emails are unique,
+// so it includes their ID; the .invalid domain can never receive mail.
+func ErasedEmail(userID string) string {
+	return "erased-" + userID + erasedEmailDomain
+}
+
+const erasedEmailDomain = "@payforward.invalid"
+
+// IsErasedEmail reports whether email is an erased user's placeholder,
+// which must not be emailed
+func IsErasedEmail(email string) bool {
+	return strings.HasSuffix(email, erasedEmailDomain)
+}
+
+// Neo4jErasureRepository erases personal data from the graph
+type Neo4jErasureRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jErasure creates an ErasureRepository backed by db
+func NewNeo4jErasure(db database.DBClient) *Neo4jErasureRepository {
+	return &Neo4jErasureRepository{db: db}
+}
+
+// erasureSteps scrub everything but the user node, in order. Each returns
+// the number of entities it changed as n; reactions are removed before
+// testimonials are deleted so the counts of other people's testimonials
+// stay right. Steps with an event also return the IDs of the acts or
+// testimonials they changed as ids, delete the earlier events about them,
+// which carry their text, and record the event for each, so the search
+// index and other consumers drop their text too.
+var erasureSteps = []struct {
+	query *queries.Query
+	count func(r *models.ErasureReport) *int
+	event string
+}{
+	{queries.ErasureReactions, func(r *models.ErasureReport) *int { return &r.ReactionsRemoved }, ""},
+	{queries.ErasureTestimonials, func(r *models.ErasureReport) *int { return &r.TestimonialsDeleted }, models.DomainTestimonialDeleted},
+	{queries.ErasureActs, func(r *models.ErasureReport) *int { return &r.ActsRedacted }, models.DomainActRedacted},
+	{queries.ErasureModeration, func(r *models.ErasureReport) *int { return &r.ModerationScrubbed }, ""},
+	{queries.ErasureAudit, func(r *models.ErasureReport) *int { return &r.AuditEventsScrubbed }, ""},
+	{queries.ErasureNotifications, func(r *models.ErasureReport) *int { return &r.NotificationsScrubbed }, ""},
+}
+
+// Erase replaces the user's profile with placeholders, so they can no
+// longer sign in and Suspended rejects the tokens they still hold, and
+// scrubs the rest of their data with erasureSteps.
+// Their acts stay in the graph, anonymous and without text or location.
+// Audit events keep the route but lose the subject's ID and the concrete
+// path, which may contain it.
+func (r *Neo4jErasureRepository) Erase(ctx context.Context, subjectID string) (*models.ErasureReport, error) {
+	now := time.Now().UTC()
+	params := map[string]interface{}{
+		"id":       subjectID,
+		"name":     ErasedUserName,
+		"email":    ErasedEmail(subjectID),
+		"actTitle": ErasedActTitle,
+		"now":      now,
+	}
+
+	report, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.ErasureReport, error) {
+		found, err := runCount(ctx, tx, queries.ErasureUser, params)
+		if err != nil || found == 0 {
+			return nil, err
+		}
+
+		report := &models.ErasureReport{SubjectID: subjectID, ErasedAt: now}
+		for _, step := range erasureSteps {
+			if step.event == "" {
+				n, err := runCount(ctx, tx, step.query, params)
+				if err != nil {
+					return nil, err
+				}
+				*step.count(report) = n
+				continue
+			}
+			ids, err := runErasureStep(ctx, tx, step.query, params)
+			if err != nil {
+				return nil, err
+			}
+			*step.count(report) = len(ids)
+			aggregateType, _, _ := strings.Cut(step.event, ".")
+			if _, err := queries.OutboxEraseAggregates.Run(ctx, tx, map[string]interface{}{"aggregateType": aggregateType, "ids": ids}); err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				if err := RecordEvent(ctx, tx, step.event, id, map[string]time.Time{"erasedAt": now}); err != nil {
+					return nil, err
+				}
+			}
+		}
+		// The user's own events carry their profile; they are replaced by
+		// one saying they were erased
+		if _, err := queries.OutboxEraseUser.Run(ctx, tx, params); err != nil {
+			return nil, err
+		}
+		return report, RecordEvent(ctx, tx, models.DomainUserErased, subjectID, report)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, ErrUserNotFound
+	}
+	return report, nil
+}
+
+// runErasureStep runs an erasure step returning the IDs it changed as ids
+func runErasureStep(ctx context.Context, tx neo4j.ManagedTransaction, query *queries.Query, params map[string]interface{}) ([]string, error) {
+	result, err := query.Run(ctx, tx, params)
+	if err != nil {
+		return nil, err
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := database.RecordValue[[]any](record, "ids")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(raw))
+	for _, id := range raw {
+		if s, ok := id.(string); ok {
+			ids = append(ids, s)
+		}
+	}
+	return ids, nil
+}
+
+// runCount runs a query returning a single count as n
+func runCount(ctx context.Context, tx neo4j.ManagedTransaction, query *queries.Query, params map[string]interface{}) (int, error) {
+	result, err := query.Run(ctx, tx, params)
+	if err != nil {
+		return 0, err
+	}
+	record, err := result.Single(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int(getInt64(record, "n")), nil
+}
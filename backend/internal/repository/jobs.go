@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jJobRepository stores the background job queue as Job nodes
+type Neo4jJobRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jJobs creates a JobRepository backed by db
+func NewNeo4jJobs(db database.DBClient) *Neo4jJobRepository {
+	return &Neo4jJobRepository{db: db}
+}
+
+// Enqueue stores job unless a job with its ID exists, and returns whether
+// it did
+func (r *Neo4jJobRepository) Enqueue(ctx context.Context, job *models.Job) (bool, error) {
+	n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+		return runCount(ctx, tx, queries.JobEnqueue, map[string]interface{}{
+			"id":          job.ID,
+			"type":        job.Type,
+			"payload":     rawOrNil(job.Payload),
+			"maxAttempts": job.MaxAttempts,
+			"runAt":       job.RunAt.UTC(),
+			"createdAt":   job.CreatedAt.UTC(),
+		})
+	})
+	return n > 0, err
+}
+
+// Claim marks the due job of one of types that has waited longest as
+// running until now plus lease and returns it, or nil if none is due
+func (r *Neo4jJobRepository) Claim(ctx context.Context, types []string, now time.Time, lease time.Duration) (*models.Job, error) {
+	return database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Job, error) {
+		result, err := queries.JobClaim.Run(ctx, tx, map[string]interface{}{
+			"types":       types,
+			"now":         now.UTC(),
+			"lockedUntil": now.Add(lease).UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, result.Err()
+		}
+		return jobFromRecord(result.Record())
+	})
+}
+
+// Finish records the outcome of an attempt at job, as set in its status,
+// attempts, result, last error, run and finish times, unless the lease
+// claimed until claim expired and the job was claimed again since
+func (r *Neo4jJobRepository) Finish(ctx context.Context, job *models.Job, claim time.Time) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		var finishedAt interface{}
+		if job.FinishedAt != nil {
+			finishedAt = job.FinishedAt.UTC()
+		}
+		_, err := queries.JobFinish.Run(ctx, tx, map[string]interface{}{
+			"id":         job.ID,
+			"claim":      claim.UTC(),
+			"status":     string(job.Status),
+			"attempts":   job.Attempts,
+			"result":     rawOrNil(job.Result),
+			"lastError":  nilIfEmpty(job.LastError),
+			"runAt":      job.RunAt.UTC(),
+			"finishedAt": finishedAt,
+			"updatedAt":  job.UpdatedAt.UTC(),
+		})
+		return nil, err
+	})
+	return err
+}
+
+// Get returns a job, or ErrJobNotFound
+func (r *Neo4jJobRepository) Get(ctx context.Context, id string) (*models.Job, error) {
+	job, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Job, error) {
+		result, err := queries.JobGet.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, result.Err()
+		}
+		return jobFromRecord(result.Record())
+	})
+	if err == nil && job == nil {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+type jobPage struct {
+	jobs  []models.Job
+	total int64
+}
+
+// List returns a page of the jobs matching filter, most recently updated
+// first, and how many match
+func (r *Neo4jJobRepository) List(ctx context.Context, filter JobFilter, page models.PaginationParams) ([]models.Job, int64, error) {
+	params := map[string]interface{}{
+		"status": nilIfEmpty(string(filter.Status)),
+		"type":   nilIfEmpty(filter.Type),
+	}
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*jobPage, error) {
+		countResult, err := queries.JobCount.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		params["skip"] = (page.Page - 1) * page.PerPage
+		params["limit"] = page.PerPage
+		result, err := queries.JobList.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		jobs, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.Job, error) {
+			job, err := jobFromRecord(record)
+			if err != nil {
+				return models.Job{}, err
+			}
+			return *job, nil
+		})
+		if jobs == nil {
+			jobs = []models.Job{}
+		}
+		return &jobPage{jobs: jobs, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.jobs, p.total, nil
+}
+
+// Retry queues a failed job to run again at now with its attempts reset.
+// It returns ErrJobNotFound or, for a job that isn't failed,
+// ErrJobNotFailed.
+func (r *Neo4jJobRepository) Retry(ctx context.Context, id string, now time.Time) (*models.Job, error) {
+	job, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Job, error) {
+		result, err := queries.JobRetry.Run(ctx, tx, map[string]interface{}{"id": id, "now": now.UTC()})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, result.Err()
+		}
+		failed, err := database.RecordValue[bool](result.Record(), "failed")
+		if err != nil {
+			return nil, err
+		}
+		if !failed {
+			return nil, ErrJobNotFailed
+		}
+		return jobFromRecord(result.Record())
+	})
+	if err == nil && job == nil {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// Prune deletes the jobs that succeeded or failed before before, in
+// batches, and returns how many
+func (r *Neo4jJobRepository) Prune(ctx context.Context, before time.Time) (int, error) {
+	params := map[string]interface{}{"before": before.UTC(), "limit": BatchSize}
+	pruned := 0
+	for {
+		n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+			return runCount(ctx, tx, queries.JobPrune, params)
+		})
+		pruned += n
+		if err != nil || n < BatchSize {
+			return pruned, err
+		}
+	}
+}
+
+// jobFromRecord maps the Job node under j, with its JSON payload and result
+func jobFromRecord(record *neo4j.Record) (*models.Job, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "j")
+	if err != nil {
+		return nil, err
+	}
+	job, err := database.DecodeNode[models.Job](node)
+	if err != nil {
+		return nil, err
+	}
+	if payload, ok := node.Props["payload"].(string); ok {
+		job.Payload = json.RawMessage(payload)
+	}
+	if result, ok := node.Props["result"].(string); ok {
+		job.Result = json.RawMessage(result)
+	}
+	return &job, nil
+}
+
+func rawOrNil(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
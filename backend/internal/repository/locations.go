@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jLocationRepository stores geocoded places on the User and Act
+// nodes, with the location they were resolved from as geocodedLocation and
+// a point property for distance searches
+type Neo4jLocationRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jLocations creates a LocationRepository backed by db
+func NewNeo4jLocations(db database.DBClient) *Neo4jLocationRepository {
+	return &Neo4jLocationRepository{db: db}
+}
+
+// Pending returns up to limit users and acts whose location was set or
+// changed since it was last geocoded
+func (r *Neo4jLocationRepository) Pending(ctx context.Context, limit int) ([]PendingLocation, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]PendingLocation, error) {
+		result, err := queries.LocationPending.Run(ctx, tx, map[string]interface{}{"limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (PendingLocation, error) {
+			kind, _ := record.Get("kind")
+			id, _ := record.Get("id")
+			location, _ := record.Get("location")
+			pending := PendingLocation{}
+			pending.Kind, _ = kind.(string)
+			pending.ID, _ = id.(string)
+			pending.Location, _ = location.(string)
+			return pending, nil
+		})
+	})
+}
+
+// SetPlace records the place location resolves to, unless the location of
+// the user or act changed since
+func (r *Neo4jLocationRepository) SetPlace(ctx context.Context, kind, id, location string, place *models.Place) error {
+	query, ok := queries.LocationSet[kind]
+	if !ok {
+		return fmt.Errorf("unknown location kind %q", kind)
+	}
+	params := map[string]interface{}{
+		"id":          id,
+		"location":    location,
+		"latitude":    nil,
+		"longitude":   nil,
+		"countryCode": nil,
+	}
+	if place != nil {
+		params["latitude"] = place.Latitude
+		params["longitude"] = place.Longitude
+		params["countryCode"] = nilIfEmpty(place.CountryCode)
+	}
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := query.Run(ctx, tx, params)
+		return nil, err
+	})
+	return err
+}
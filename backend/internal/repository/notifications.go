@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jNotificationRepository stores notifications as Notification nodes
+// linked to the users they are addressed to
+type Neo4jNotificationRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jNotifications creates a NotificationRepository backed by db
+func NewNeo4jNotifications(db database.DBClient) *Neo4jNotificationRepository {
+	return &Neo4jNotificationRepository{db: db}
+}
+
+// Create stores notifications, skipping those addressed to users that don't
+// exist, and returns how many it stored
+func (r *Neo4jNotificationRepository) Create(ctx context.Context, notifications []models.Notification) (int, error) {
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+	rows := make([]map[string]interface{}, len(notifications))
+	for i, n := range notifications {
+		rows[i] = map[string]interface{}{
+			"id":          n.ID,
+			"userId":      n.UserID,
+			"type":        string(n.Type),
+			"actorId":     nilIfEmpty(n.ActorID),
+			"subjectType": n.SubjectType,
+			"subjectId":   n.SubjectID,
+			"createdAt":   n.CreatedAt,
+		}
+	}
+
+	return database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+		return runCount(ctx, tx, queries.NotificationCreate, map[string]interface{}{"rows": rows})
+	})
+}
+
+type notificationPage struct {
+	notifications []models.Notification
+	total         int64
+}
+
+// List returns a page of a user's notifications, newest first, and the
+// total count, of unread ones only when unreadOnly is set
+func (r *Neo4jNotificationRepository) List(ctx context.Context, userID string, unreadOnly bool, page models.PaginationParams) ([]models.Notification, int64, error) {
+	params := map[string]interface{}{
+		"userId":     userID,
+		"unreadOnly": unreadOnly,
+		"skip":       (page.Page - 1) * page.PerPage,
+		"limit":      page.PerPage,
+	}
+
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (notificationPage, error) {
+		countResult, err := queries.NotificationCount.Run(ctx, tx, params)
+		if err != nil {
+			return notificationPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+			if unreadOnly {
+				total = getInt64(countResult.Record(), "unread")
+			}
+		}
+
+		result, err := queries.NotificationList.Run(ctx, tx, params)
+		if err != nil {
+			return notificationPage{}, err
+		}
+		notifications, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.Notification, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "n")
+			if err != nil {
+				return models.Notification{}, err
+			}
+			return database.DecodeNode[models.Notification](node)
+		})
+		if notifications == nil {
+			notifications = []models.Notification{}
+		}
+		return notificationPage{notifications: notifications, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.notifications, p.total, nil
+}
+
+// Unread returns the number of a user's unread notifications
+func (r *Neo4jNotificationRepository) Unread(ctx context.Context, userID string) (int64, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (int64, error) {
+		result, err := queries.NotificationCount.Run(ctx, tx, map[string]interface{}{"userId": userID})
+		if err != nil {
+			return 0, err
+		}
+		record, err := result.Single(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return getInt64(record, "unread"), nil
+	})
+}
+
+// MarkRead marks the user's unread notifications with ids read, or all of
+// them when ids is empty, and returns how many it marked
+func (r *Neo4jNotificationRepository) MarkRead(ctx context.Context, userID string, ids []string) (int, error) {
+	var idsParam interface{}
+	if len(ids) > 0 {
+		idsParam = ids
+	}
+	return database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+		return runCount(ctx, tx, queries.NotificationMarkRead, map[string]interface{}{
+			"userId": userID,
+			"ids":    idsParam,
+			"now":    time.Now().UTC(),
+		})
+	})
+}
+
+// DigestsDue returns up to limit users due a digest, who haven't had one
+// since since and have unread notifications created since then
+func (r *Neo4jNotificationRepository) DigestsDue(ctx context.Context, since time.Time, limit int) ([]models.NotificationDigest, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.NotificationDigest, error) {
+		result, err := queries.NotificationDigestDue.Run(ctx, tx, map[string]interface{}{"since": since, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.NotificationDigest, error) {
+			email, _ := database.RecordValue[string](record, "email")
+			name, _ := database.RecordValue[string](record, "name")
+			userID, err := database.RecordValue[string](record, "userId")
+			return models.NotificationDigest{
+				UserID:               userID,
+				Email:                email,
+				Name:                 name,
+				ActsReceived:         int(getInt64(record, "actsReceived")),
+				ChainsGrown:          int(getInt64(record, "chainsGrown")),
+				TestimonialsApproved: int(getInt64(record, "testimonialsApproved")),
+			}, err
+		})
+	})
+}
+
+// DigestSent records that a user was sent a digest at at
+func (r *Neo4jNotificationRepository) DigestSent(ctx context.Context, userID string, at time.Time) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.NotificationDigestSent.Run(ctx, tx, map[string]interface{}{"userId": userID, "now": at})
+		return nil, err
+	})
+	return err
+}
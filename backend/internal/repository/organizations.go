@@ -0,0 +1,467 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jOrganizationRepository stores organizations as Organization nodes,
+// which users are MEMBER_OF with a role and acts are GIVEN_FOR, and
+// invitations to them as OrgInvitation nodes
+type Neo4jOrganizationRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jOrganizations creates an OrganizationRepository backed by db
+func NewNeo4jOrganizations(db database.DBClient) *Neo4jOrganizationRepository {
+	return &Neo4jOrganizationRepository{db: db}
+}
+
+// Create stores org with its creator as its owner. It returns false if the
+// creator does not exist, in which case nothing is stored.
+func (r *Neo4jOrganizationRepository) Create(ctx context.Context, org *models.Organization) (bool, error) {
+	return database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.OrgCreate.Run(ctx, tx, map[string]interface{}{
+			"id":          org.ID,
+			"name":        org.Name,
+			"description": nilIfEmpty(org.Description),
+			"kind":        string(org.Kind),
+			"userId":      org.CreatedBy,
+			"createdAt":   org.CreatedAt.UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		if !result.Next(ctx) {
+			return false, result.Err()
+		}
+		return true, RecordEvent(ctx, tx, models.DomainOrganizationCreated, org.ID, org)
+	})
+}
+
+// Get returns an organization with its number of members, or
+// ErrOrganizationNotFound
+func (r *Neo4jOrganizationRepository) Get(ctx context.Context, id string) (*models.Organization, error) {
+	org, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Organization, error) {
+		result, err := queries.OrgGet.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		org, found, err := database.First(ctx, result, organizationFromRecord)
+		if err != nil || !found {
+			return nil, err
+		}
+		return &org, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if org == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+// ListByUser returns the organizations a user is a member of, by name,
+// with their role in each
+func (r *Neo4jOrganizationRepository) ListByUser(ctx context.Context, userID string) ([]models.Organization, error) {
+	orgs, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.Organization, error) {
+		result, err := queries.OrgsByUser.Run(ctx, tx, map[string]interface{}{"userId": userID})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.Organization, error) {
+			org, err := organizationFromRecord(record)
+			if err != nil {
+				return models.Organization{}, err
+			}
+			role, _ := database.RecordValue[string](record, "role")
+			org.Role = models.OrgRole(role)
+			return org, nil
+		})
+	})
+	if orgs == nil {
+		orgs = []models.Organization{}
+	}
+	return orgs, err
+}
+
+type membership struct {
+	role        models.OrgRole
+	otherOwners int64
+}
+
+// membershipOf returns a user's role in an organization and how many other
+// owners it has, or ErrOrganizationNotFound
+func membershipOf(ctx context.Context, tx neo4j.ManagedTransaction, id, userID string) (membership, error) {
+	result, err := queries.OrgMembership.Run(ctx, tx, map[string]interface{}{
+		"id":     id,
+		"userId": userID,
+	})
+	if err != nil {
+		return membership{}, err
+	}
+	if !result.Next(ctx) {
+		if err := result.Err(); err != nil {
+			return membership{}, err
+		}
+		return membership{}, ErrOrganizationNotFound
+	}
+	role, _ := database.RecordValue[string](result.Record(), "role")
+	return membership{
+		role:        models.OrgRole(role),
+		otherOwners: getInt64(result.Record(), "otherOwners"),
+	}, nil
+}
+
+// Role returns a user's role in an organization, empty if they aren't a
+// member, or ErrOrganizationNotFound
+func (r *Neo4jOrganizationRepository) Role(ctx context.Context, id, userID string) (models.OrgRole, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (models.OrgRole, error) {
+		m, err := membershipOf(ctx, tx, id, userID)
+		return m.role, err
+	})
+}
+
+type memberPage struct {
+	members []models.OrgMember
+	total   int64
+}
+
+// Members returns a page of an organization's members, owners first, then
+// admins, then by name, and how many there are
+func (r *Neo4jOrganizationRepository) Members(ctx context.Context, id string, page models.PaginationParams) ([]models.OrgMember, int64, error) {
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (memberPage, error) {
+		countResult, err := queries.OrgMemberCount.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return memberPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.OrgMembers.Run(ctx, tx, map[string]interface{}{
+			"id":    id,
+			"skip":  (page.Page - 1) * page.PerPage,
+			"limit": page.PerPage,
+		})
+		if err != nil {
+			return memberPage{}, err
+		}
+		members, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.OrgMember, error) {
+			var member models.OrgMember
+			member.UserID, _ = database.RecordValue[string](record, "userId")
+			member.Name, _ = database.RecordValue[string](record, "name")
+			member.Avatar, _ = database.RecordValue[string](record, "avatar")
+			role, _ := database.RecordValue[string](record, "role")
+			member.Role = models.OrgRole(role)
+			member.JoinedAt, _ = database.RecordValue[time.Time](record, "joinedAt")
+			return member, nil
+		})
+		if members == nil {
+			members = []models.OrgMember{}
+		}
+		return memberPage{members: members, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.members, p.total, nil
+}
+
+// SetRole changes a member's role, or returns ErrNotOrgMember, or
+// ErrLastOwner if it would leave the organization without an owner
+func (r *Neo4jOrganizationRepository) SetRole(ctx context.Context, id, userID string, role models.OrgRole) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if err := checkMemberChange(ctx, tx, id, userID, role); err != nil {
+			return nil, err
+		}
+		_, err := queries.OrgSetRole.Run(ctx, tx, map[string]interface{}{
+			"id":     id,
+			"userId": userID,
+			"role":   string(role),
+		})
+		return nil, err
+	})
+	return err
+}
+
+// RemoveMember removes a user from an organization's members, or returns
+// ErrNotOrgMember or ErrLastOwner
+func (r *Neo4jOrganizationRepository) RemoveMember(ctx context.Context, id, userID string) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if err := checkMemberChange(ctx, tx, id, userID, ""); err != nil {
+			return nil, err
+		}
+		_, err := queries.OrgRemoveMember.Run(ctx, tx, map[string]interface{}{
+			"id":     id,
+			"userId": userID,
+		})
+		return nil, err
+	})
+	return err
+}
+
+// checkMemberChange checks that a member of an organization can be given
+// role, or removed if it is empty, without leaving it without an owner
+func checkMemberChange(ctx context.Context, tx neo4j.ManagedTransaction, id, userID string, role models.OrgRole) error {
+	m, err := membershipOf(ctx, tx, id, userID)
+	if err != nil {
+		return err
+	}
+	if m.role == "" {
+		return ErrNotOrgMember
+	}
+	if m.role == models.OrgRoleOwner && role != models.OrgRoleOwner && m.otherOwners == 0 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// CreateInvitation stores invitation, accepted with the token whose hash is
+// tokenHash, or returns ErrOrganizationNotFound
+func (r *Neo4jOrganizationRepository) CreateInvitation(ctx context.Context, invitation *models.OrgInvitation, tokenHash string) error {
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.OrgInvitationCreate.Run(ctx, tx, map[string]interface{}{
+			"id":             invitation.ID,
+			"organizationId": invitation.OrganizationID,
+			"email":          invitation.Email,
+			"role":           string(invitation.Role),
+			"tokenHash":      tokenHash,
+			"invitedBy":      invitation.InvitedBy,
+			"createdAt":      invitation.CreatedAt.UTC(),
+			"expiresAt":      invitation.ExpiresAt.UTC(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrOrganizationNotFound
+	}
+	return nil
+}
+
+// Invitations returns the invitations to an organization that weren't
+// accepted and haven't expired by now, newest first
+func (r *Neo4jOrganizationRepository) Invitations(ctx context.Context, id string, now time.Time) ([]models.OrgInvitation, error) {
+	invitations, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.OrgInvitation, error) {
+		result, err := queries.OrgInvitationsPending.Run(ctx, tx, map[string]interface{}{
+			"id":  id,
+			"now": now.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, invitationFromRecord)
+	})
+	if invitations == nil {
+		invitations = []models.OrgInvitation{}
+	}
+	return invitations, err
+}
+
+// RevokeInvitation deletes an invitation to an organization that wasn't
+// accepted, or returns ErrInvitationNotFound
+func (r *Neo4jOrganizationRepository) RevokeInvitation(ctx context.Context, id, invitationID string) error {
+	found, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.OrgInvitationRevoke.Run(ctx, tx, map[string]interface{}{
+			"id":           id,
+			"invitationId": invitationID,
+		})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation accepts the pending invitation for email with tokenHash
+// at now, adding userID to its organization and recording
+// organization.joined. It returns the invitation, or ErrInvitationNotFound.
+func (r *Neo4jOrganizationRepository) AcceptInvitation(ctx context.Context, tokenHash, email, userID string, now time.Time) (*models.OrgInvitation, error) {
+	invitation, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.OrgInvitation, error) {
+		result, err := queries.OrgInvitationAccept.Run(ctx, tx, map[string]interface{}{
+			"tokenHash": tokenHash,
+			"email":     email,
+			"userId":    userID,
+			"now":       now.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		invitation, found, err := database.First(ctx, result, invitationFromRecord)
+		if err != nil || !found {
+			return nil, err
+		}
+		joined := models.OrgMember{UserID: userID, Role: invitation.Role, JoinedAt: now.UTC()}
+		return &invitation, RecordEvent(ctx, tx, models.DomainOrganizationJoined, invitation.OrganizationID, joined)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// Acts returns a page of the acts given for an organization, newest first,
+// and how many there are
+func (r *Neo4jOrganizationRepository) Acts(ctx context.Context, id string, page models.PaginationParams) ([]models.Act, int64, error) {
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (actPage, error) {
+		countResult, err := queries.OrgActCount.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return actPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.OrgActs.Run(ctx, tx, map[string]interface{}{
+			"id":    id,
+			"skip":  (page.Page - 1) * page.PerPage,
+			"limit": page.PerPage,
+		})
+		if err != nil {
+			return actPage{}, err
+		}
+		acts, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.Act, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "a")
+			if err != nil {
+				return models.Act{}, err
+			}
+			act, err := actFromNode(node)
+			if err != nil {
+				return models.Act{}, err
+			}
+			return *act, nil
+		})
+		if acts == nil {
+			acts = []models.Act{}
+		}
+		return actPage{acts: acts, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.acts, p.total, nil
+}
+
+// Chains returns up to limit chains containing acts given for an
+// organization, most recently extended first
+func (r *Neo4jOrganizationRepository) Chains(ctx context.Context, id string, limit int) ([]models.Chain, error) {
+	chains, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.Chain, error) {
+		result, err := queries.OrgChains.Run(ctx, tx, map[string]interface{}{
+			"id":    id,
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.Chain, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "c")
+			if err != nil {
+				return models.Chain{}, err
+			}
+			chain, err := chainFromNode(node)
+			if err != nil {
+				return models.Chain{}, err
+			}
+			chain.ActsCount = int(getInt64(record, "actsCount"))
+			chain.TotalValue = getFloat64(record, "totalValue")
+			return *chain, nil
+		})
+	})
+	if chains == nil {
+		chains = []models.Chain{}
+	}
+	return chains, err
+}
+
+// Leaderboard ranks up to limit members by the acts they gave for an
+// organization
+func (r *Neo4jOrganizationRepository) Leaderboard(ctx context.Context, id string, limit int) ([]models.LeaderboardEntry, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.LeaderboardEntry, error) {
+		result, err := queries.OrgLeaderboard.Run(ctx, tx, map[string]interface{}{
+			"id":    id,
+			"limit": limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return collectLeaderboard(ctx, result)
+	})
+}
+
+// Stats sums up the activity of an organization's members at now, or
+// returns ErrOrganizationNotFound
+func (r *Neo4jOrganizationRepository) Stats(ctx context.Context, id string, now time.Time) (*models.OrgStats, error) {
+	stats, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.OrgStats, error) {
+		result, err := queries.StatsOrg.Run(ctx, tx, map[string]interface{}{"orgId": id})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, result.Err()
+		}
+
+		record := result.Record()
+		return &models.OrgStats{
+			OrganizationID: id,
+			Members:        getInt64(record, "members"),
+			ActiveMembers:  getInt64(record, "activeMembers"),
+			ActsGiven:      getInt64(record, "actsGiven"),
+			ChainsStarted:  getInt64(record, "chainsStarted"),
+			TotalValue:     getFloat64(record, "totalValue"),
+			GeneratedAt:    now.UTC(),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return stats, nil
+}
+
+func organizationFromRecord(record *neo4j.Record) (models.Organization, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "o")
+	if err != nil {
+		return models.Organization{}, err
+	}
+	org, err := database.DecodeNode[models.Organization](node)
+	if err != nil {
+		return models.Organization{}, err
+	}
+	org.Members = getInt64(record, "members")
+	return org, nil
+}
+
+func invitationFromRecord(record *neo4j.Record) (models.OrgInvitation, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "i")
+	if err != nil {
+		return models.OrgInvitation{}, err
+	}
+	return database.DecodeNode[models.OrgInvitation](node)
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RecordEvent stores a domain event of eventType about the aggregate with
+// aggregateID in the outbox, as part of tx, so it is published if and only
+// if the change it describes commits. The aggregate type is the event
+// type's prefix.
+func RecordEvent(ctx context.Context, tx neo4j.ManagedTransaction, eventType, aggregateID string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", eventType, err)
+	}
+	aggregateType, _, _ := strings.Cut(eventType, ".")
+	_, err = queries.OutboxAppend.Run(ctx, tx, map[string]interface{}{
+		"id":            uuid.New().String(),
+		"type":          eventType,
+		"aggregateType": aggregateType,
+		"aggregateId":   aggregateID,
+		"data":          string(encoded),
+		"createdAt":     time.Now().UTC(),
+	})
+	return err
+}
+
+// Neo4jOutboxRepository reads the OutboxEvent nodes written by RecordEvent
+type Neo4jOutboxRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jOutbox creates an OutboxRepository backed by db
+func NewNeo4jOutbox(db database.DBClient) *Neo4jOutboxRepository {
+	return &Neo4jOutboxRepository{db: db}
+}
+
+// Pending returns up to limit unpublished events, in sequence order
+func (r *Neo4jOutboxRepository) Pending(ctx context.Context, limit int) ([]models.DomainEvent, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.DomainEvent, error) {
+		result, err := queries.OutboxPending.Run(ctx, tx, map[string]interface{}{"limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, eventFromRecord)
+	})
+}
+
+// MarkPublished records the events with ids as published at now
+func (r *Neo4jOutboxRepository) MarkPublished(ctx context.Context, ids []string, now time.Time) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.OutboxMarkPublished.Run(ctx, tx, map[string]interface{}{"ids": ids, "now": now.UTC()})
+		return nil, err
+	})
+	return err
+}
+
+// Prune deletes the events created before before, only the published ones
+// with publishedOnly, in batches, and returns how many
+func (r *Neo4jOutboxRepository) Prune(ctx context.Context, before time.Time, publishedOnly bool) (int, error) {
+	params := map[string]interface{}{"before": before.UTC(), "publishedOnly": publishedOnly, "limit": BatchSize}
+	pruned := 0
+	for {
+		n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+			return runCount(ctx, tx, queries.OutboxPrune, params)
+		})
+		pruned += n
+		if err != nil || n < BatchSize {
+			return pruned, err
+		}
+	}
+}
+
+// After returns up to limit events numbered after sequence, in order
+func (r *Neo4jOutboxRepository) After(ctx context.Context, sequence int64, limit int) ([]models.DomainEvent, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.DomainEvent, error) {
+		result, err := queries.OutboxAfter.Run(ctx, tx, map[string]interface{}{"sequence": sequence, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, eventFromRecord)
+	})
+}
+
+// Latest returns the number of the last event recorded, 0 if none was
+func (r *Neo4jOutboxRepository) Latest(ctx context.Context) (int64, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (int64, error) {
+		result, err := queries.OutboxLatest.Run(ctx, tx, nil)
+		if err != nil {
+			return 0, err
+		}
+		sequence, _, err := database.First(ctx, result, func(record *neo4j.Record) (int64, error) {
+			return database.RecordValue[int64](record, "sequence")
+		})
+		return sequence, err
+	})
+}
+
+// eventFromRecord maps the OutboxEvent node e of a record, whose data is
+// stored as a JSON string
+func eventFromRecord(record *neo4j.Record) (models.DomainEvent, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "e")
+	if err != nil {
+		return models.DomainEvent{}, err
+	}
+	event, err := database.DecodeNode[models.DomainEvent](node)
+	if data, ok := node.Props["data"].(string); ok {
+		event.Data = json.RawMessage(data)
+	}
+	return event, err
+}
@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jPledgeRepository stores pledges as Pledge nodes linked from their
+// act
+type Neo4jPledgeRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jPledges creates a PledgeRepository backed by db
+func NewNeo4jPledges(db database.DBClient) *Neo4jPledgeRepository {
+	return &Neo4jPledgeRepository{db: db}
+}
+
+// Create stores pledge as held for its act if pledge.GiverID gave it. The
+// unique constraint on the act ID rejects a concurrent second pledge.
+func (r *Neo4jPledgeRepository) Create(ctx context.Context, pledge *models.Pledge) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := queries.PledgeCreate.Run(ctx, tx, map[string]interface{}{
+			"id":          pledge.ID,
+			"actId":       pledge.ActID,
+			"giverId":     pledge.GiverID,
+			"receiverId":  pledge.ReceiverID,
+			"amount":      pledge.Amount,
+			"currency":    pledge.Currency,
+			"provider":    pledge.Provider,
+			"providerRef": pledge.ProviderRef,
+			"releaseAt":   pledge.ReleaseAt.UTC(),
+			"createdAt":   pledge.CreatedAt.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			if err := result.Err(); err != nil {
+				return nil, err
+			}
+			return nil, ErrActNotFound
+		}
+		record := result.Record()
+		if isGiver, _ := database.RecordValue[bool](record, "isGiver"); !isGiver {
+			return nil, ErrNotActGiver
+		}
+		if pledged, _ := database.RecordValue[bool](record, "pledged"); pledged {
+			return nil, ErrPledgeExists
+		}
+		created, err := pledgeFromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		*pledge = created
+		return nil, RecordEvent(ctx, tx, models.DomainPledgeHeld, pledge.ID, pledge)
+	})
+	var neoErr *neo4j.Neo4jError
+	if errors.As(err, &neoErr) && neoErr.Code == "Neo.ClientError.Schema.ConstraintValidationFailed" {
+		return ErrPledgeExists
+	}
+	return err
+}
+
+// ForAct returns the pledge of an act
+func (r *Neo4jPledgeRepository) ForAct(ctx context.Context, actID string) (*models.Pledge, error) {
+	return r.get(ctx, queries.PledgeForAct, map[string]interface{}{"actId": actID})
+}
+
+// Get returns a pledge
+func (r *Neo4jPledgeRepository) Get(ctx context.Context, id string) (*models.Pledge, error) {
+	return r.get(ctx, queries.PledgeGet, map[string]interface{}{"id": id})
+}
+
+func (r *Neo4jPledgeRepository) get(ctx context.Context, query *queries.Query, params map[string]interface{}) (*models.Pledge, error) {
+	pledge, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Pledge, error) {
+		result, err := query.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		pledge, found, err := database.First(ctx, result, pledgeFromRecord)
+		if err != nil || !found {
+			return nil, err
+		}
+		return &pledge, nil
+	})
+	if err == nil && pledge == nil {
+		return nil, ErrPledgeNotFound
+	}
+	return pledge, err
+}
+
+// Transition applies change to a pledge whose status is one of from
+func (r *Neo4jPledgeRepository) Transition(ctx context.Context, id string, from []models.PledgeStatus, change PledgeChange) (*models.Pledge, error) {
+	statuses := make([]string, len(from))
+	for i, status := range from {
+		statuses[i] = string(status)
+	}
+	pledge, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Pledge, error) {
+		result, err := queries.PledgeTransition.Run(ctx, tx, map[string]interface{}{
+			"id":            id,
+			"from":          statuses,
+			"status":        string(change.Status),
+			"disputedBy":    nilIfEmpty(change.DisputedBy),
+			"disputeReason": nilIfEmpty(change.DisputeReason),
+			"resolution":    nilIfEmpty(change.Resolution),
+			"now":           change.At.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, result.Err()
+		}
+		record := result.Record()
+		if current, _ := database.RecordValue[bool](record, "current"); !current {
+			return nil, ErrPledgeState
+		}
+		pledge, err := pledgeFromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		return &pledge, RecordEvent(ctx, tx, "pledge."+string(change.Status), id, pledge)
+	})
+	if err == nil && pledge == nil {
+		return nil, ErrPledgeNotFound
+	}
+	return pledge, err
+}
+
+// Claim moves a pledge whose status is one of from to settling at now, or
+// returns ErrPledgeNotFound or ErrPledgeState
+func (r *Neo4jPledgeRepository) Claim(ctx context.Context, id string, from []models.PledgeStatus, now time.Time) error {
+	statuses := make([]string, len(from))
+	for i, status := range from {
+		statuses[i] = string(status)
+	}
+	current, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*bool, error) {
+		result, err := queries.PledgeClaim.Run(ctx, tx, map[string]interface{}{
+			"id":   id,
+			"from": statuses,
+			"now":  now.UTC(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, result.Err()
+		}
+		current, _ := database.RecordValue[bool](result.Record(), "current")
+		return &current, nil
+	})
+	switch {
+	case err != nil:
+		return err
+	case current == nil:
+		return ErrPledgeNotFound
+	case !*current:
+		return ErrPledgeState
+	}
+	return nil
+}
+
+// Unclaim moves a settling pledge back to the status it was claimed from
+func (r *Neo4jPledgeRepository) Unclaim(ctx context.Context, id string, now time.Time) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.PledgeUnclaim.Run(ctx, tx, map[string]interface{}{"id": id, "now": now.UTC()})
+		return nil, err
+	})
+	return err
+}
+
+type pledgePage struct {
+	pledges []models.Pledge
+	total   int64
+}
+
+// List returns a page of the pledges with status, most recently updated
+// first, and how many match
+func (r *Neo4jPledgeRepository) List(ctx context.Context, status models.PledgeStatus, page models.PaginationParams) ([]models.Pledge, int64, error) {
+	params := map[string]interface{}{"status": nilIfEmpty(string(status))}
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*pledgePage, error) {
+		countResult, err := queries.PledgeCount.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		params["skip"] = (page.Page - 1) * page.PerPage
+		params["limit"] = page.PerPage
+		result, err := queries.PledgeList.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		pledges, err := database.Collect(ctx, result, pledgeFromRecord)
+		if pledges == nil {
+			pledges = []models.Pledge{}
+		}
+		return &pledgePage{pledges: pledges, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.pledges, p.total, nil
+}
+
+type duePledge struct {
+	pledge models.Pledge
+	refund bool
+}
+
+// Due returns up to limit held pledges to release or refund
+func (r *Neo4jPledgeRepository) Due(ctx context.Context, now time.Time, limit int) ([]models.Pledge, []models.Pledge, error) {
+	due, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]duePledge, error) {
+		result, err := queries.PledgesDue.Run(ctx, tx, map[string]interface{}{"now": now.UTC(), "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (duePledge, error) {
+			pledge, err := pledgeFromRecord(record)
+			if err != nil {
+				return duePledge{}, err
+			}
+			refund, err := database.RecordValue[bool](record, "refund")
+			return duePledge{pledge: pledge, refund: refund}, err
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var release, refund []models.Pledge
+	for _, d := range due {
+		if d.refund {
+			refund = append(refund, d.pledge)
+		} else {
+			release = append(release, d.pledge)
+		}
+	}
+	return release, refund, nil
+}
+
+func pledgeFromRecord(record *neo4j.Record) (models.Pledge, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "p")
+	if err != nil {
+		return models.Pledge{}, err
+	}
+	return database.DecodeNode[models.Pledge](node)
+}
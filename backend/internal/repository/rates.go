@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jRateRepository stores exchange rates as one ExchangeRate node per
+// currency, which act creation looks up to convert the act's value
+type Neo4jRateRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jRates creates a RateRepository backed by db
+func NewNeo4jRates(db database.DBClient) *Neo4jRateRepository {
+	return &Neo4jRateRepository{db: db}
+}
+
+// exchangeRate is a stored ExchangeRate node
+type exchangeRate struct {
+	Currency  string    `neo4j:"currency"`
+	Rate      float64   `neo4j:"rate"`
+	Base      string    `neo4j:"base"`
+	Date      time.Time `neo4j:"date"`
+	Provider  string    `neo4j:"provider"`
+	UpdatedAt time.Time `neo4j:"updatedAt"`
+}
+
+// Replace stores rates in place of the current ones in one transaction
+func (r *Neo4jRateRepository) Replace(ctx context.Context, rates *models.ExchangeRates) error {
+	rows := make([]map[string]interface{}, 0, len(rates.Rates))
+	currencies := make([]string, 0, len(rates.Rates))
+	for currency, rate := range rates.Rates {
+		rows = append(rows, map[string]interface{}{"currency": currency, "rate": rate})
+		currencies = append(currencies, currency)
+	}
+
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if _, err := queries.RateUpsert.Run(ctx, tx, map[string]interface{}{
+			"rates":    rows,
+			"base":     rates.Base,
+			"date":     rates.Date,
+			"provider": rates.Provider,
+			"now":      rates.UpdatedAt,
+		}); err != nil {
+			return nil, err
+		}
+		_, err := queries.RatePrune.Run(ctx, tx, map[string]interface{}{"currencies": currencies})
+		return nil, err
+	})
+	return err
+}
+
+// Current returns the stored rates, or ErrNoRates
+func (r *Neo4jRateRepository) Current(ctx context.Context) (*models.ExchangeRates, error) {
+	stored, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]exchangeRate, error) {
+		result, err := queries.RateList.Run(ctx, tx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (exchangeRate, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "x")
+			if err != nil {
+				return exchangeRate{}, err
+			}
+			return database.DecodeNode[exchangeRate](node)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, ErrNoRates
+	}
+
+	rates := &models.ExchangeRates{
+		Base:      stored[0].Base,
+		Date:      stored[0].Date,
+		Provider:  stored[0].Provider,
+		UpdatedAt: stored[0].UpdatedAt,
+		Rates:     make(map[string]float64, len(stored)),
+	}
+	for _, x := range stored {
+		rates.Rates[x.Currency] = x.Rate
+	}
+	return rates, nil
+}
+
+// Normalize converts the values of up to limit acts and returns how many
+func (r *Neo4jRateRepository) Normalize(ctx context.Context, limit int) (int, error) {
+	return database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+		return runCount(ctx, tx, queries.RateNormalize, map[string]interface{}{"limit": limit})
+	})
+}
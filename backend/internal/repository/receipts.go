@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jReceiptRepository stores donation receipts as Receipt nodes linked
+// from their act, numbered with a ReceiptSequence counter per tax year
+type Neo4jReceiptRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jReceipts creates a ReceiptRepository backed by db
+func NewNeo4jReceipts(db database.DBClient) *Neo4jReceiptRepository {
+	return &Neo4jReceiptRepository{db: db}
+}
+
+// ForAct returns the receipt of an act, issuing it at now if needed
+func (r *Neo4jReceiptRepository) ForAct(ctx context.Context, actID string, now time.Time) (*models.DonationReceipt, error) {
+	if err := r.issue(ctx, actID, now); err != nil {
+		return nil, err
+	}
+	receipts, err := r.collect(ctx, queries.ReceiptForAct, map[string]interface{}{"id": actID})
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) == 0 {
+		return nil, ErrActNotFound
+	}
+	if receipts[0] == nil {
+		return nil, ErrNoReceipt
+	}
+	return receipts[0], nil
+}
+
+// ForGiver returns the receipts of the acts a user completed from from
+// until to, issuing those missing at now
+func (r *Neo4jReceiptRepository) ForGiver(ctx context.Context, giverID string, from, to, now time.Time) ([]models.DonationReceipt, error) {
+	params := map[string]interface{}{"giverId": giverID, "from": from, "to": to}
+	unissued, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]string, error) {
+		result, err := queries.ReceiptsUnissued.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (string, error) {
+			return database.RecordValue[string](record, "id")
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, actID := range unissued {
+		if err := r.issue(ctx, actID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	found, err := r.collect(ctx, queries.ReceiptsForGiver, params)
+	if err != nil {
+		return nil, err
+	}
+	receipts := make([]models.DonationReceipt, 0, len(found))
+	for _, receipt := range found {
+		receipts = append(receipts, *receipt)
+	}
+	return receipts, nil
+}
+
+// issue issues the receipt of an act if it's eligible and has none
+func (r *Neo4jReceiptRepository) issue(ctx context.Context, actID string, now time.Time) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return nil, issueReceipt(ctx, tx, actID, now)
+	})
+	return err
+}
+
+// issueReceipt issues the receipt of an act in tx if it's eligible and has
+// none
+func issueReceipt(ctx context.Context, tx neo4j.ManagedTransaction, actID string, now time.Time) error {
+	result, err := queries.ReceiptIssue.Run(ctx, tx, map[string]interface{}{"id": actID, "now": now})
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume(ctx)
+	return err
+}
+
+// collect runs a query returning receipts with their act's description,
+// giver and receiver. Rows without a receipt map to nil.
+func (r *Neo4jReceiptRepository) collect(ctx context.Context, query *queries.Query, params map[string]interface{}) ([]*models.DonationReceipt, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]*models.DonationReceipt, error) {
+		result, err := query.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, receiptFromRecord)
+	})
+}
+
+func receiptFromRecord(record *neo4j.Record) (*models.DonationReceipt, error) {
+	raw, _ := record.Get("r")
+	if raw == nil {
+		return nil, nil
+	}
+	receipt, err := database.DecodeNode[models.DonationReceipt](raw.(neo4j.Node))
+	if err != nil {
+		return nil, err
+	}
+	receipt.Description, err = database.RecordValue[string](record, "description")
+	if err != nil {
+		return nil, err
+	}
+
+	giver, err := database.RecordValue[neo4j.Node](record, "giver")
+	if err != nil {
+		return nil, err
+	}
+	donor, err := database.DecodeNode[models.User](giver)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Donor = models.ReceiptParty{ID: donor.ID, Name: donor.Name, Email: donor.Email, Location: donor.Location}
+
+	if node, ok := record.Get("receiver"); ok && node != nil {
+		receiver, err := database.DecodeNode[models.User](node.(neo4j.Node))
+		if err != nil {
+			return nil, err
+		}
+		receipt.Recipient = &models.ReceiptParty{ID: receiver.ID, Name: receiver.Name}
+	}
+	return &receipt, nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jRecommendationRepository walks the GAVE, RECEIVED_BY, STARTED and
+// PARTICIPATED_IN relationships around a user to find acts and chains
+// close to them
+type Neo4jRecommendationRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jRecommendations creates a RecommendationRepository backed by db
+func NewNeo4jRecommendations(db database.DBClient) *Neo4jRecommendationRepository {
+	return &Neo4jRecommendationRepository{db: db}
+}
+
+// Network returns the neighbourhood of a user, with up to recent of their
+// latest acts. It is empty for a user that doesn't exist.
+func (r *Neo4jRecommendationRepository) Network(ctx context.Context, userID string, recent int) (*Network, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*Network, error) {
+		result, err := queries.RecommendationNetwork.Run(ctx, tx, map[string]interface{}{
+			"userId": userID,
+			"recent": recent,
+		})
+		if err != nil {
+			return nil, err
+		}
+		network := &Network{}
+		if result.Next(ctx) {
+			record := result.Record()
+			network.Connections = stringList(record, "connections")
+			network.FriendsOfFriends = stringList(record, "friendsOfFriends")
+			network.Categories = stringList(record, "categories")
+			network.RecentActs = stringList(record, "recentActs")
+		}
+		return network, result.Err()
+	})
+}
+
+// Acts returns up to limit acts of kind close to a user, best first
+func (r *Neo4jRecommendationRepository) Acts(ctx context.Context, kind, userID string, network *Network, limit int) ([]models.RecommendedAct, error) {
+	query, ok := queries.RecommendationActs[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown recommended act kind %q", kind)
+	}
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.RecommendedAct, error) {
+		result, err := query.Run(ctx, tx, networkParams(userID, network, limit))
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.RecommendedAct, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "a")
+			if err != nil {
+				return models.RecommendedAct{}, err
+			}
+			act, err := actFromNode(node)
+			if err != nil {
+				return models.RecommendedAct{}, err
+			}
+			return models.RecommendedAct{Act: *act, Reasons: recommendationReasons(record)}, nil
+		})
+	})
+}
+
+// Chains returns up to limit chains close to a user, best first
+func (r *Neo4jRecommendationRepository) Chains(ctx context.Context, userID string, network *Network, limit int) ([]models.RecommendedChain, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.RecommendedChain, error) {
+		result, err := queries.RecommendationChains.Run(ctx, tx, networkParams(userID, network, limit))
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.RecommendedChain, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "c")
+			if err != nil {
+				return models.RecommendedChain{}, err
+			}
+			chain, err := chainFromNode(node)
+			if err != nil {
+				return models.RecommendedChain{}, err
+			}
+			chain.ActsCount = int(getInt64(record, "acts"))
+			return models.RecommendedChain{Chain: *chain, Reasons: recommendationReasons(record)}, nil
+		})
+	})
+}
+
+func networkParams(userID string, network *Network, limit int) map[string]interface{} {
+	return map[string]interface{}{
+		"userId":           userID,
+		"connections":      nonNil(network.Connections),
+		"friendsOfFriends": nonNil(network.FriendsOfFriends),
+		"categories":       nonNil(network.Categories),
+		"limit":            limit,
+	}
+}
+
+// recommendationReasons maps the connection, friendOfFriend and category
+// flags of a recommendation record
+func recommendationReasons(record *neo4j.Record) []models.RecommendationReason {
+	var reasons []models.RecommendationReason
+	for _, flag := range []struct {
+		key    string
+		reason models.RecommendationReason
+	}{
+		{"connection", models.ReasonConnection},
+		{"friendOfFriend", models.ReasonFriendOfFriend},
+		{"category", models.ReasonCategory},
+	} {
+		if set, _ := database.RecordValue[bool](record, flag.key); set {
+			reasons = append(reasons, flag.reason)
+		}
+	}
+	return reasons
+}
+
+// stringList returns the strings in the list at key, skipping nulls
+func stringList(record *neo4j.Record, key string) []string {
+	raw, _ := record.Get(key)
+	list, _ := raw.([]interface{})
+	values := make([]string, 0, len(list))
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// nonNil returns values, or an empty list for nil, which the driver would
+// send as null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func boolOrNil(b *bool) interface{} {
+	if b == nil {
+		return nil
+	}
+	return *b
+}
+
+func intOrNil(i *int) interface{} {
+	if i == nil {
+		return nil
+	}
+	return *i
+}
+
+func int64OrNil(i *int64) interface{} {
+	if i == nil {
+		return nil
+	}
+	return *i
+}
+
+// updatedVersion returns the version of the node under key in a record of
+// a conditional update, or ErrVersionConflict if the record's current
+// column says the update didn't apply
+func updatedVersion(record *neo4j.Record, key string) (int64, error) {
+	current, err := database.RecordValue[bool](record, "current")
+	if err != nil {
+		return 0, err
+	}
+	if !current {
+		return 0, ErrVersionConflict
+	}
+	node, err := database.RecordValue[neo4j.Node](record, key)
+	if err != nil {
+		return 0, err
+	}
+	version, _ := node.Props["version"].(int64)
+	return version, nil
+}
+
+// updatedNode decodes the node under key in a record of a conditional
+// update, or returns ErrVersionConflict like updatedVersion
+func updatedNode[T any](record *neo4j.Record, key string) (T, error) {
+	var zero T
+	if _, err := updatedVersion(record, key); err != nil {
+		return zero, err
+	}
+	node, err := database.RecordValue[neo4j.Node](record, key)
+	if err != nil {
+		return zero, err
+	}
+	return database.DecodeNode[T](node)
+}
+
+func getInt64(record *neo4j.Record, key string) int64 {
+	if val, ok := record.Get(key); ok && val != nil {
+		return val.(int64)
+	}
+	return 0
+}
+
+func getFloat64(record *neo4j.Record, key string) float64 {
+	if val, ok := record.Get(key); ok && val != nil {
+		switch v := val.(type) {
+		case float64:
+			return v
+		case int64:
+			return float64(v)
+		}
+	}
+	return 0
+}
+
+// collectLeaderboard ranks the users of result's records, given as id,
+// name, avatar, actsCount and totalValue in rank order
+func collectLeaderboard(ctx context.Context, result neo4j.ResultWithContext) ([]models.LeaderboardEntry, error) {
+	entries := []models.LeaderboardEntry{}
+	for result.Next(ctx) {
+		record := result.Record()
+		user := models.LeaderboardUser{}
+		user.ID, _ = database.RecordValue[string](record, "id")
+		user.Name, _ = database.RecordValue[string](record, "name")
+		user.Avatar, _ = database.RecordValue[string](record, "avatar")
+		entries = append(entries, models.LeaderboardEntry{
+			Rank:       len(entries) + 1,
+			User:       user,
+			ActsCount:  getInt64(record, "actsCount"),
+			TotalValue: getFloat64(record, "totalValue"),
+		})
+	}
+	return entries, result.Err()
+}
@@ -0,0 +1,626 @@
+// Package repository holds the graph queries behind the API. Handlers
+// depend on the interfaces defined here, so their logic can be tested with
+// fakes instead of a live database, and every Cypher query for an aggregate
+// lives in one place.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrActNotFound         = errors.New("act not found")
+	ErrChainNotFound       = errors.New("chain not found")
+	ErrTestimonialNotFound = errors.New("testimonial not found")
+	ErrNotTestimonialOwner = errors.New("not the testimonial author")
+	ErrNotApproved         = errors.New("testimonial not approved")
+	// ErrVersionConflict rejects a write based on a version of an entity
+	// that has since changed
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrNotDeleted is returned when restoring an entity that doesn't exist
+	// or isn't deleted
+	ErrNotDeleted = errors.New("not deleted")
+	// ErrWebhookNotFound is returned for a webhook that doesn't exist
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrJobNotFound is returned for a job that doesn't exist
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobNotFailed rejects retrying a job that hasn't failed
+	ErrJobNotFailed = errors.New("job not failed")
+	// ErrNoReceipt is returned for an act receipts aren't issued for
+	ErrNoReceipt = errors.New("act has no receipt")
+	// ErrPledgeNotFound is returned for a pledge that doesn't exist
+	ErrPledgeNotFound = errors.New("pledge not found")
+	// ErrNotActGiver rejects a change only an act's giver can make
+	ErrNotActGiver = errors.New("not the act's giver")
+	// ErrPledgeExists rejects pledging an act that already has a pledge
+	ErrPledgeExists = errors.New("act already pledged")
+	// ErrPledgeState rejects a change a pledge's status doesn't allow, such
+	// as releasing a refunded pledge
+	ErrPledgeState = errors.New("pledge status doesn't allow this")
+	// ErrNoRates is returned when no exchange rates were stored yet
+	ErrNoRates = errors.New("no exchange rates")
+	// ErrReportNotFound is returned for a content report that doesn't
+	// exist or was already handled
+	ErrReportNotFound = errors.New("report not found")
+	// ErrReportTargetNotFound rejects reporting content that doesn't exist
+	ErrReportTargetNotFound = errors.New("reported content not found")
+	// ErrCampaignNotFound is returned for a campaign that doesn't exist
+	ErrCampaignNotFound = errors.New("campaign not found")
+	// ErrOrganizationNotFound is returned for an organization that doesn't
+	// exist
+	ErrOrganizationNotFound = errors.New("organization not found")
+	// ErrNotOrgMember is returned for a user who isn't a member of an
+	// organization
+	ErrNotOrgMember = errors.New("not an organization member")
+	// ErrLastOwner rejects a change that would leave an organization
+	// without an owner
+	ErrLastOwner = errors.New("organization's last owner")
+	// ErrInvitationNotFound is returned for an invitation that doesn't
+	// exist, was accepted or expired
+	ErrInvitationNotFound = errors.New("invitation not found")
+)
+
+// UserRepository stores users
+type UserRepository interface {
+	// Get returns a user with their activity counts
+	Get(ctx context.Context, id string) (*models.User, error)
+	// FindCredentials returns the user registered with email and their
+	// password hash, or ErrUserNotFound
+	FindCredentials(ctx context.Context, email string) (*models.User, string, error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	Create(ctx context.Context, user *models.User, passwordHash string) error
+	// Update changes the profile fields set in req and returns the user's
+	// new version. It fails with ErrVersionConflict if req.Version is set
+	// and isn't the current one.
+	Update(ctx context.Context, id string, req models.UpdateUserRequest) (int64, error)
+	Delete(ctx context.Context, id string) error
+	// Stream passes every user to fn, oldest first, without holding them
+	// all in memory. An error from fn stops it and is returned.
+	Stream(ctx context.Context, fn func(*models.User) error) error
+	// CreateBatch creates or refreshes users by ID in chunks and returns
+	// how many were written
+	CreateBatch(ctx context.Context, users []BatchUser) (int, error)
+	// List returns a page of the users matching filter, newest first and
+	// without activity counts, and how many match
+	List(ctx context.Context, filter UserFilter, page models.PaginationParams) ([]models.User, int64, error)
+	// Suspend suspends a user at now for reason, or reinstates them when
+	// suspended is false, recording user.suspended or user.reinstated by
+	// adminID. It returns the user or ErrUserNotFound.
+	Suspend(ctx context.Context, id string, suspended bool, reason, adminID string, now time.Time) (*models.User, error)
+	// Suspended reports whether an admin suspended user id or they were
+	// erased, so their tokens are rejected
+	Suspended(ctx context.Context, id string) (bool, error)
+}
+
+// UserFilter holds the optional filters for listing users
+type UserFilter struct {
+	// Query matches users whose name or email contains it, ignoring case
+	Query     string
+	Suspended *bool
+}
+
+// ActRepository stores acts of kindness
+type ActRepository interface {
+	// List returns a page of acts, newest first, and the total count
+	List(ctx context.Context, page models.PaginationParams) ([]models.Act, int64, error)
+	Get(ctx context.Context, id string) (*models.Act, error)
+	// Giver returns the ID of the user who gave an act, which Get leaves
+	// out for anonymous acts, or ErrActNotFound
+	Giver(ctx context.Context, id string) (string, error)
+	// Create stores act and links it to its giver, setting act.BaseValue if
+	// there is a rate for its currency. It returns false if the giver does
+	// not exist.
+	Create(ctx context.Context, act *models.Act) (bool, error)
+	// Update changes the fields set in req and returns the act's new
+	// version. Completing an act records when in its CompletedAt. It fails
+	// with ErrVersionConflict if req.Version is set and isn't the current
+	// one.
+	Update(ctx context.Context, id string, req models.UpdateActRequest) (int64, error)
+	Delete(ctx context.Context, id string) error
+	// Completed returns up to limit acts completed after after, or at after
+	// with an ID after afterID, in the order they were completed, so a
+	// reader can resume from the last act it saw
+	Completed(ctx context.Context, after time.Time, afterID string, limit int) ([]models.Act, error)
+	// Stream passes every act to fn, oldest first, without holding them
+	// all in memory. An error from fn stops it and is returned.
+	Stream(ctx context.Context, fn func(*models.Act) error) error
+	// CreateBatch creates or refreshes acts by ID in chunks, skipping acts
+	// whose giver does not exist, and returns how many were written
+	CreateBatch(ctx context.Context, acts []models.Act) (int, error)
+	// Expire marks the acts left pending since before before expired at
+	// now and returns how many
+	Expire(ctx context.Context, before, now time.Time) (int, error)
+	// Nearby returns a page of the acts placed within radiusKm of
+	// latitude, longitude, nearest first, and how many there are
+	Nearby(ctx context.Context, latitude, longitude, radiusKm float64, page models.PaginationParams) ([]models.NearbyAct, int64, error)
+	// Screen records the screening of an act's title and description at
+	// now, or fails with ErrActNotFound
+	Screen(ctx context.Context, id string, screening moderation.Result, now time.Time) error
+	// Flagged returns a page of the acts screening flagged, highest score
+	// first, and how many there are
+	Flagged(ctx context.Context, page models.PaginationParams) ([]models.FlaggedAct, int64, error)
+	// DismissFlag clears the flag of an act reviewerID found acceptable,
+	// or fails with ErrActNotFound if it isn't flagged
+	DismissFlag(ctx context.Context, id, reviewerID string, now time.Time) error
+	// TakeDown deletes an act as Delete does, recording that moderatorID
+	// took it down at now and why, or fails with ErrActNotFound
+	TakeDown(ctx context.Context, id, reason, moderatorID string, now time.Time) error
+}
+
+// ChainRepository stores chains of acts
+type ChainRepository interface {
+	// Get returns a chain with the number of acts in it, or
+	// ErrChainNotFound
+	Get(ctx context.Context, id string) (*models.Chain, error)
+	// ListByUser returns the chains a user started or took part in
+	ListByUser(ctx context.Context, userID string) ([]models.Chain, error)
+	// Members returns the IDs of the users who started or took part in a
+	// chain, none if it doesn't exist
+	Members(ctx context.Context, id string) ([]string, error)
+}
+
+// TestimonialFilter holds the optional filters for listing testimonials
+type TestimonialFilter struct {
+	Approved *bool
+	Featured *bool
+	UserID   string
+}
+
+// TestimonialUpdate is an edit to a testimonial by its author or an admin
+type TestimonialUpdate struct {
+	Story       string
+	Impact      string
+	Media       *models.Media
+	RemoveMedia bool
+	// UserID is the editor; only the author or an admin may edit
+	UserID string
+	Admin  bool
+	// Version, when set, must be the testimonial's current version
+	Version *int64
+}
+
+// Screen runs automated moderation on a testimonial's text
+type Screen func(story, impact string) moderation.Result
+
+// TestimonialRepository stores testimonials and their reactions
+type TestimonialRepository interface {
+	// List returns a page of testimonials translated to the first available
+	// of locales, and the total count
+	List(ctx context.Context, filter TestimonialFilter, page models.PaginationParams, locales []string) ([]models.Testimonial, int64, error)
+	// Featured returns approved, featured testimonials in their explicit
+	// order, then newest first
+	Featured(ctx context.Context, limit int, locales []string) ([]models.Testimonial, error)
+	// SetFeatured features or unfeatures a testimonial. Only approved
+	// testimonials can be featured; others fail with ErrNotApproved.
+	SetFeatured(ctx context.Context, id string, featured bool, order *int) (*models.Testimonial, error)
+	// Create stores t pending review, with the result of its screening. It
+	// returns false if the author does not exist.
+	Create(ctx context.Context, t *models.Testimonial, screening moderation.Result) (bool, error)
+	// Update applies an edit. Edits by the author send the testimonial back
+	// to moderation and, when the text changed, through screen. A stale
+	// update.Version fails with ErrVersionConflict.
+	Update(ctx context.Context, id string, update TestimonialUpdate, screen Screen) (*models.Testimonial, error)
+	// Delete marks a testimonial deleted if userID is its author or admin
+	// is set
+	Delete(ctx context.Context, id, userID string, admin bool) error
+	// ToggleReaction adds or removes a user's reaction to an approved
+	// testimonial
+	ToggleReaction(ctx context.Context, id, userID string) (*models.ReactionResult, error)
+}
+
+// NotificationRepository stores the notifications addressed to users
+type NotificationRepository interface {
+	// Create stores notifications, skipping those addressed to users that
+	// don't exist, and returns how many it stored
+	Create(ctx context.Context, notifications []models.Notification) (int, error)
+	// List returns a page of a user's notifications, newest first, and the
+	// total count, of unread ones only when unreadOnly is set
+	List(ctx context.Context, userID string, unreadOnly bool, page models.PaginationParams) ([]models.Notification, int64, error)
+	// Unread returns the number of a user's unread notifications
+	Unread(ctx context.Context, userID string) (int64, error)
+	// MarkRead marks the user's unread notifications with ids read, or all
+	// of them when ids is empty, and returns how many it marked. IDs of
+	// other users' notifications are ignored.
+	MarkRead(ctx context.Context, userID string, ids []string) (int, error)
+	// DigestsDue returns up to limit users due a digest, who haven't had
+	// one since since and have unread notifications created since then
+	DigestsDue(ctx context.Context, since time.Time, limit int) ([]models.NotificationDigest, error)
+	// DigestSent records that a user was sent a digest at at
+	DigestSent(ctx context.Context, userID string, at time.Time) error
+}
+
+// SuppressionRepository stores the email addresses never to email.
+// Addresses are compared case-insensitively.
+type SuppressionRepository interface {
+	// Suppressed reports whether email is on the list
+	Suppressed(ctx context.Context, email string) (bool, error)
+	// Suppress adds email to the list, keeping the reason and time of an
+	// existing entry
+	Suppress(ctx context.Context, email, reason string) error
+	// Unsuppress removes email from the list, reporting whether it was on
+	// it
+	Unsuppress(ctx context.Context, email string) (bool, error)
+	// List returns a page of the list, newest first, and its size
+	List(ctx context.Context, page models.PaginationParams) ([]models.EmailSuppression, int64, error)
+}
+
+// WebhookRepository stores webhook subscriptions and their delivery logs
+type WebhookRepository interface {
+	// Create stores hook
+	Create(ctx context.Context, hook *models.Webhook) error
+	// List returns every webhook, newest first, without their secrets
+	List(ctx context.Context) ([]models.Webhook, error)
+	// Subscribed returns the webhooks subscribed to event, with their
+	// secrets
+	Subscribed(ctx context.Context, event string) ([]models.Webhook, error)
+	// Delete removes a webhook and its delivery log, or returns
+	// ErrWebhookNotFound
+	Delete(ctx context.Context, id string) error
+	// RecordDelivery adds an attempt to its webhook's delivery log,
+	// dropping it if the webhook was deleted since
+	RecordDelivery(ctx context.Context, d models.WebhookDelivery) error
+	// Deliveries returns a page of a webhook's delivery log, newest first,
+	// and its size, or ErrWebhookNotFound
+	Deliveries(ctx context.Context, id string, page models.PaginationParams) ([]models.WebhookDelivery, int64, error)
+	// PruneDeliveries deletes the delivery log entries created before
+	// before and returns how many
+	PruneDeliveries(ctx context.Context, before time.Time) (int, error)
+}
+
+// OutboxRepository reads the domain events repositories store with
+// RecordEvent, for publishing
+type OutboxRepository interface {
+	// Pending returns up to limit unpublished events, in sequence order
+	Pending(ctx context.Context, limit int) ([]models.DomainEvent, error)
+	// MarkPublished records the events with ids as published at now
+	MarkPublished(ctx context.Context, ids []string, now time.Time) error
+	// Prune deletes the events created before before and returns how
+	// many. With publishedOnly, events not published yet are kept.
+	Prune(ctx context.Context, before time.Time, publishedOnly bool) (int, error)
+	// After returns up to limit events numbered after sequence, published
+	// or not, in sequence order
+	After(ctx context.Context, sequence int64, limit int) ([]models.DomainEvent, error)
+	// Latest returns the number of the last event recorded, 0 if none was
+	Latest(ctx context.Context) (int64, error)
+}
+
+// JobFilter holds the optional filters for listing jobs
+type JobFilter struct {
+	Status models.JobStatus
+	Type   string
+}
+
+// JobRepository stores the background job queue
+type JobRepository interface {
+	// Enqueue stores job unless a job with its ID exists, and returns
+	// whether it did
+	Enqueue(ctx context.Context, job *models.Job) (bool, error)
+	// Claim marks the due job of one of types that has waited longest as
+	// running until now plus lease and returns it, or nil if none is due.
+	// Running jobs whose lease expired are due again.
+	Claim(ctx context.Context, types []string, now time.Time, lease time.Duration) (*models.Job, error)
+	// Finish records the outcome of an attempt at job, unless the lease
+	// claimed until claim expired and the job was claimed again since
+	Finish(ctx context.Context, job *models.Job, claim time.Time) error
+	// Get returns a job, or ErrJobNotFound
+	Get(ctx context.Context, id string) (*models.Job, error)
+	// List returns a page of the jobs matching filter, most recently
+	// updated first, and how many match
+	List(ctx context.Context, filter JobFilter, page models.PaginationParams) ([]models.Job, int64, error)
+	// Retry queues a failed job to run again at now with its attempts
+	// reset, or returns ErrJobNotFound or ErrJobNotFailed
+	Retry(ctx context.Context, id string, now time.Time) (*models.Job, error)
+	// Prune deletes the jobs that succeeded or failed before before and
+	// returns how many
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+// ReceiptRepository issues donation receipts for completed monetary acts.
+// A receipt is issued when its act is completed, numbered after the last
+// one of its tax year. Acts completed before receipts were issued get
+// theirs the first time they're asked for.
+type ReceiptRepository interface {
+	// ForAct returns the receipt of an act, issuing it at now if needed, or
+	// returns ErrActNotFound or ErrNoReceipt
+	ForAct(ctx context.Context, actID string, now time.Time) (*models.DonationReceipt, error)
+	// ForGiver returns the receipts of the acts a user completed from from
+	// until to in number order, issuing those missing at now in the order
+	// the acts were completed
+	ForGiver(ctx context.Context, giverID string, from, to, now time.Time) ([]models.DonationReceipt, error)
+}
+
+// PledgeChange moves a pledge to Status at At, setting the other fields if
+// they aren't empty
+type PledgeChange struct {
+	Status        models.PledgeStatus
+	At            time.Time
+	DisputedBy    string
+	DisputeReason string
+	Resolution    string
+}
+
+// PledgeRepository stores the pledges held for monetary acts. Transitions
+// record a pledge.<status> domain event.
+type PledgeRepository interface {
+	// Create stores pledge as held for its act, or returns ErrActNotFound,
+	// ErrNotActGiver if pledge.GiverID didn't give the act or
+	// ErrPledgeExists
+	Create(ctx context.Context, pledge *models.Pledge) error
+	// ForAct returns the pledge of an act, or ErrPledgeNotFound
+	ForAct(ctx context.Context, actID string) (*models.Pledge, error)
+	// Get returns a pledge, or ErrPledgeNotFound
+	Get(ctx context.Context, id string) (*models.Pledge, error)
+	// Transition applies change to a pledge whose status is one of from and
+	// returns it, or returns ErrPledgeNotFound or ErrPledgeState
+	Transition(ctx context.Context, id string, from []models.PledgeStatus, change PledgeChange) (*models.Pledge, error)
+	// Claim moves a pledge whose status is one of from to settling at now,
+	// so no other change can settle it while the payments provider moves
+	// its funds, or returns ErrPledgeNotFound or ErrPledgeState. It records
+	// no domain event.
+	Claim(ctx context.Context, id string, from []models.PledgeStatus, now time.Time) error
+	// Unclaim moves a settling pledge back to the status it was claimed
+	// from, once the payments provider failed to move its funds
+	Unclaim(ctx context.Context, id string, now time.Time) error
+	// List returns a page of the pledges with status, or all of them if it
+	// is empty, most recently updated first, and how many match
+	List(ctx context.Context, status models.PledgeStatus, page models.PaginationParams) ([]models.Pledge, int64, error)
+	// Due returns up to limit held pledges to settle: those to release
+	// because their ReleaseAt passed by now and those to refund because
+	// their act was cancelled, expired or deleted
+	Due(ctx context.Context, now time.Time, limit int) (release, refund []models.Pledge, err error)
+}
+
+// LocationKinds are the kinds of entities whose locations are geocoded
+var LocationKinds = []string{"user", "act"}
+
+// PendingLocation is the location of a user or act, of one of the
+// LocationKinds, waiting to be geocoded
+type PendingLocation struct {
+	Kind     string
+	ID       string
+	Location string
+}
+
+// LocationRepository stores the places the free-text locations of users
+// and acts were geocoded to
+type LocationRepository interface {
+	// Pending returns up to limit users and acts whose location was set or
+	// changed since it was last geocoded
+	Pending(ctx context.Context, limit int) ([]PendingLocation, error)
+	// SetPlace records that location, the location of the user or act of
+	// kind with id, resolves to place, or to no place if place is nil. It
+	// does nothing if the location has changed since.
+	SetPlace(ctx context.Context, kind, id, location string, place *models.Place) error
+}
+
+// SearchRepository searches acts, chains, users and testimonials with the
+// Neo4j full-text indexes, and reads their searchable text for other
+// search engines to index
+type SearchRepository interface {
+	// Search returns a page of the documents of kinds matching text, best
+	// first, and their number
+	Search(ctx context.Context, text string, kinds []string, page models.PaginationParams) ([]models.SearchHit, int64, error)
+	// Documents returns the searchable documents of kind with ids, leaving
+	// out deleted ones and testimonials that aren't approved
+	Documents(ctx context.Context, kind string, ids []string) ([]models.SearchDocument, error)
+	// List returns up to limit searchable documents of kind with IDs after
+	// after, in ID order
+	List(ctx context.Context, kind, after string, limit int) ([]models.SearchDocument, error)
+}
+
+// RecommendedActKinds are the kinds of acts recommended to users: acts in
+// chains they aren't part of, and needs, pending acts with no receiver
+var RecommendedActKinds = []string{"acts", "needs"}
+
+// Network is the neighbourhood of a user in the graph recommendations are
+// drawn from
+type Network struct {
+	// Connections are the users the user gave to, received from or shared
+	// a chain with
+	Connections []string
+	// FriendsOfFriends are the connections of Connections, other than the
+	// user and Connections
+	FriendsOfFriends []string
+	// Categories are the categories of the acts the user gave or received
+	Categories []string
+	// RecentActs are the IDs of the latest acts the user gave or received,
+	// newest first
+	RecentActs []string
+}
+
+// RecommendationRepository finds the acts and chains close to a user in
+// the graph
+type RecommendationRepository interface {
+	// Network returns the neighbourhood of a user, with up to recent of
+	// their latest acts
+	Network(ctx context.Context, userID string, recent int) (*Network, error)
+	// Acts returns up to limit acts of one of the RecommendedActKinds that
+	// the user neither gave nor received, given by someone in network or
+	// in one of its categories, best first, with the reasons they were
+	// found for and no score
+	Acts(ctx context.Context, kind, userID string, network *Network, limit int) ([]models.RecommendedAct, error)
+	// Chains returns up to limit chains the user isn't part of, that
+	// someone in network takes part in or holding acts in one of its
+	// categories, best first, with the reasons they were found for and no
+	// score
+	Chains(ctx context.Context, userID string, network *Network, limit int) ([]models.RecommendedChain, error)
+}
+
+// RateRepository stores the exchange rates act values are converted to the
+// base currency with
+type RateRepository interface {
+	// Replace stores rates in place of the current ones, dropping the
+	// currencies rates doesn't have
+	Replace(ctx context.Context, rates *models.ExchangeRates) error
+	// Current returns the stored rates, or ErrNoRates
+	Current(ctx context.Context) (*models.ExchangeRates, error)
+	// Normalize converts the values of up to limit acts with a rate for
+	// their currency that weren't converted to the current base currency
+	// yet, and returns how many
+	Normalize(ctx context.Context, limit int) (int, error)
+}
+
+// ContentReportRepository stores users' reports of acts, testimonials and
+// users that break the rules
+type ContentReportRepository interface {
+	// Create stores report as open, or returns ErrReportTargetNotFound if
+	// the reported content doesn't exist
+	Create(ctx context.Context, report *models.ContentReport) error
+	// List returns a page of the reports with status, or all of them if it
+	// is empty, oldest first, and how many match
+	List(ctx context.Context, status models.ReportStatus, page models.PaginationParams) ([]models.ContentReport, int64, error)
+	// Handle closes an open report with status, recording handlerID's note
+	// at now, and returns it, or returns ErrReportNotFound
+	Handle(ctx context.Context, id string, status models.ReportStatus, handlerID, note string, now time.Time) (*models.ContentReport, error)
+}
+
+// CampaignRepository stores campaigns, the users who joined them and the
+// acts tagged to them
+type CampaignRepository interface {
+	Create(ctx context.Context, campaign *models.Campaign) error
+	// Get returns a campaign with its progress, or ErrCampaignNotFound
+	Get(ctx context.Context, id string) (*models.Campaign, error)
+	// List returns a page of the campaigns with status at now, or all of
+	// them if it is empty, latest starting first and with their progress,
+	// and how many match
+	List(ctx context.Context, status models.CampaignStatus, now time.Time, page models.PaginationParams) ([]models.Campaign, int64, error)
+	// Join adds a user to a campaign's participants, or returns
+	// ErrCampaignNotFound
+	Join(ctx context.Context, id, userID string, now time.Time) error
+	// Leave removes a user from a campaign's participants, or returns
+	// ErrCampaignNotFound. The acts they tagged to it still count.
+	Leave(ctx context.Context, id, userID string) error
+	// Leaderboard ranks up to limit users by the acts they tagged to a
+	// campaign, leaving out anonymous acts and users who opted out
+	Leaderboard(ctx context.Context, id string, limit int) ([]models.LeaderboardEntry, error)
+}
+
+// OrganizationRepository stores organizations, their members with their
+// roles, the invitations to join them and the acts given for them
+type OrganizationRepository interface {
+	// Create stores org with its creator, org.CreatedBy, as its owner. It
+	// returns false if the creator does not exist.
+	Create(ctx context.Context, org *models.Organization) (bool, error)
+	// Get returns an organization with its number of members, or
+	// ErrOrganizationNotFound
+	Get(ctx context.Context, id string) (*models.Organization, error)
+	// ListByUser returns the organizations a user is a member of, by name,
+	// with their role in each
+	ListByUser(ctx context.Context, userID string) ([]models.Organization, error)
+	// Role returns a user's role in an organization, empty if they aren't
+	// a member, or ErrOrganizationNotFound
+	Role(ctx context.Context, id, userID string) (models.OrgRole, error)
+	// Members returns a page of an organization's members, owners first,
+	// then admins, then by name, and how many there are
+	Members(ctx context.Context, id string, page models.PaginationParams) ([]models.OrgMember, int64, error)
+	// SetRole changes a member's role, or returns ErrNotOrgMember, or
+	// ErrLastOwner if it would leave the organization without an owner
+	SetRole(ctx context.Context, id, userID string, role models.OrgRole) error
+	// RemoveMember removes a user from an organization's members, or
+	// returns ErrNotOrgMember or ErrLastOwner. The acts they gave for it
+	// still count.
+	RemoveMember(ctx context.Context, id, userID string) error
+	// CreateInvitation stores invitation, accepted with the token whose
+	// SHA-256 hash is tokenHash, or returns ErrOrganizationNotFound
+	CreateInvitation(ctx context.Context, invitation *models.OrgInvitation, tokenHash string) error
+	// Invitations returns the invitations to an organization that weren't
+	// accepted and haven't expired by now, newest first
+	Invitations(ctx context.Context, id string, now time.Time) ([]models.OrgInvitation, error)
+	// RevokeInvitation deletes an invitation to an organization that
+	// wasn't accepted, or returns ErrInvitationNotFound
+	RevokeInvitation(ctx context.Context, id, invitationID string) error
+	// AcceptInvitation accepts the pending invitation for email with
+	// tokenHash at now, adding userID to its organization with its role
+	// unless they are a member already. It returns the invitation, or
+	// ErrInvitationNotFound if there is none or it expired.
+	AcceptInvitation(ctx context.Context, tokenHash, email, userID string, now time.Time) (*models.OrgInvitation, error)
+	// Acts returns a page of the acts given for an organization, newest
+	// first, and how many there are
+	Acts(ctx context.Context, id string, page models.PaginationParams) ([]models.Act, int64, error)
+	// Chains returns up to limit chains containing acts given for an
+	// organization, most recently extended first
+	Chains(ctx context.Context, id string, limit int) ([]models.Chain, error)
+	// Leaderboard ranks up to limit members by the acts they gave for an
+	// organization, leaving out anonymous acts and users who opted out
+	Leaderboard(ctx context.Context, id string, limit int) ([]models.LeaderboardEntry, error)
+	// Stats sums up the activity of an organization's members at now, or
+	// returns ErrOrganizationNotFound
+	Stats(ctx context.Context, id string, now time.Time) (*models.OrgStats, error)
+}
+
+// ErasureRepository removes a person's personal data for right to be
+// forgotten requests
+type ErasureRepository interface {
+	// Erase scrubs the personal data of the user with subjectID from their
+	// profile, acts, testimonials, reactions, notifications, moderation
+	// records and audit events in one transaction, or returns ErrUserNotFound. Erasing the
+	// same user again only reports what was left.
+	Erase(ctx context.Context, subjectID string) (*models.ErasureReport, error)
+}
+
+// TrashRepository manages soft deleted users, acts and testimonials. Their
+// Delete methods only set deletedAt, which hides an entity from every query
+// but the ones here, erasure and backups.
+type TrashRepository interface {
+	// Restore undeletes the entity of kind, one of TrashKinds, with id. It
+	// returns ErrNotDeleted if there is no deleted entity to restore.
+	Restore(ctx context.Context, kind, id string) error
+	// Purge permanently deletes entities deleted before before and returns
+	// how many of each kind
+	Purge(ctx context.Context, before time.Time) (map[string]int, error)
+}
+
+// Repositories bundles the repositories used by the API
+type Repositories struct {
+	Users           UserRepository
+	Acts            ActRepository
+	Chains          ChainRepository
+	Testimonials    TestimonialRepository
+	Notifications   NotificationRepository
+	Suppressions    SuppressionRepository
+	Webhooks        WebhookRepository
+	Jobs            JobRepository
+	Receipts        ReceiptRepository
+	Pledges         PledgeRepository
+	Rates           RateRepository
+	Locations       LocationRepository
+	Recommendations RecommendationRepository
+	Search          SearchRepository
+	ContentReports  ContentReportRepository
+	Campaigns       CampaignRepository
+	Organizations   OrganizationRepository
+	Erasure         ErasureRepository
+	Trash           TrashRepository
+}
+
+// NewNeo4j returns Neo4j-backed repositories using db
+func NewNeo4j(db database.DBClient) Repositories {
+	return Repositories{
+		Users:           NewNeo4jUsers(db),
+		Acts:            NewNeo4jActs(db),
+		Chains:          NewNeo4jChains(db),
+		Testimonials:    NewNeo4jTestimonials(db),
+		Notifications:   NewNeo4jNotifications(db),
+		Suppressions:    NewNeo4jSuppressions(db),
+		Webhooks:        NewNeo4jWebhooks(db),
+		Jobs:            NewNeo4jJobs(db),
+		Receipts:        NewNeo4jReceipts(db),
+		Pledges:         NewNeo4jPledges(db),
+		Rates:           NewNeo4jRates(db),
+		Locations:       NewNeo4jLocations(db),
+		Recommendations: NewNeo4jRecommendations(db),
+		Search:          NewNeo4jSearch(db),
+		ContentReports:  NewNeo4jContentReports(db),
+		Campaigns:       NewNeo4jCampaigns(db),
+		Organizations:   NewNeo4jOrganizations(db),
+		Erasure:         NewNeo4jErasure(db),
+		Trash:           NewNeo4jTrash(db),
+	}
+}
@@ -0,0 +1,73 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/seed"
+	"payforwardnow/internal/testinfra"
+)
+
+func TestSoftDelete_Integration(t *testing.T) {
+	db := testinfra.Shared(t)
+	repos := db.Repositories()
+	ctx := context.Background()
+
+	cfg := seed.DefaultConfig()
+	cfg.Users, cfg.Acts, cfg.Chains, cfg.Testimonials = 5, 10, 0, 0
+	d := db.Seed(t, cfg)
+	act := d.Acts[0]
+
+	if err := repos.Acts.Delete(ctx, act.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repos.Acts.Get(ctx, act.ID); !errors.Is(err, repository.ErrActNotFound) {
+		t.Errorf("expected a deleted act to be hidden, got %v", err)
+	}
+	if _, total, err := repos.Acts.List(ctx, models.DefaultPagination()); err != nil || total != int64(len(d.Acts)-1) {
+		t.Errorf("expected %d acts listed, got %d (%v)", len(d.Acts)-1, total, err)
+	}
+
+	if err := repos.Trash.Restore(ctx, "acts", act.ID); err != nil {
+		t.Fatalf("restore failed: %v", err)
+	}
+	if _, err := repos.Acts.Get(ctx, act.ID); err != nil {
+		t.Errorf("expected the restored act, got %v", err)
+	}
+
+	repos.Acts.Delete(ctx, act.ID)
+	purged, err := repos.Trash.Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil || purged["acts"] != 1 {
+		t.Errorf("expected one act purged, got %v (%v)", purged, err)
+	}
+	if err := repos.Trash.Restore(ctx, "acts", act.ID); !errors.Is(err, repository.ErrNotDeleted) {
+		t.Errorf("expected a purged act to be gone, got %v", err)
+	}
+}
+
+func TestStream_Integration(t *testing.T) {
+	db := testinfra.Shared(t)
+	repos := db.Repositories()
+
+	cfg := seed.DefaultConfig()
+	cfg.Users, cfg.Acts, cfg.Chains, cfg.Testimonials = 20, 0, 0, 0
+	db.Seed(t, cfg)
+
+	var last time.Time
+	count := 0
+	err := repos.Users.Stream(context.Background(), func(u *models.User) error {
+		if u.CreatedAt.Before(last) {
+			t.Errorf("expected users oldest first, got %s after %s", u.CreatedAt, last)
+		}
+		last = u.CreatedAt
+		count++
+		return nil
+	})
+	if err != nil || count != cfg.Users {
+		t.Errorf("expected %d users, got %d (%v)", cfg.Users, count, err)
+	}
+}
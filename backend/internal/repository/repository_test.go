@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// fakeDB returns canned results from transactions without running them
+type fakeDB struct {
+	result interface{}
+	err    error
+}
+
+func (f *fakeDB) ExecuteRead(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	return f.result, f.err
+}
+
+func (f *fakeDB) ExecuteWrite(ctx context.Context, work func(tx neo4j.ManagedTransaction) (interface{}, error)) (interface{}, error) {
+	return f.result, f.err
+}
+
+func (f *fakeDB) ReadSession(ctx context.Context) neo4j.SessionWithContext {
+	return nil
+}
+
+func (f *fakeDB) Close() error {
+	return nil
+}
+
+func TestNotFound(t *testing.T) {
+	repos := NewNeo4j(&fakeDB{})
+	ctx := context.Background()
+
+	if _, err := repos.Users.Get(ctx, "u1"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+	if _, _, err := repos.Users.FindCredentials(ctx, "a@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+	if _, err := repos.Users.Update(ctx, "u1", models.UpdateUserRequest{}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+	if _, err := repos.Acts.Get(ctx, "a1"); !errors.Is(err, ErrActNotFound) {
+		t.Errorf("expected ErrActNotFound, got %v", err)
+	}
+	if _, err := repos.Acts.Update(ctx, "a1", models.UpdateActRequest{}); !errors.Is(err, ErrActNotFound) {
+		t.Errorf("expected ErrActNotFound, got %v", err)
+	}
+	if _, err := repos.Chains.Get(ctx, "c1"); !errors.Is(err, ErrChainNotFound) {
+		t.Errorf("expected ErrChainNotFound, got %v", err)
+	}
+	if _, err := repos.Erasure.Erase(ctx, "u1"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+	if err := repos.Trash.Restore(ctx, "acts", "a1"); !errors.Is(err, ErrNotDeleted) {
+		t.Errorf("expected ErrNotDeleted, got %v", err)
+	}
+}
+
+func TestDatabaseErrorsPropagate(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	repos := NewNeo4j(&fakeDB{err: dbErr})
+	ctx := context.Background()
+
+	if _, err := repos.Users.Get(ctx, "u1"); !errors.Is(err, dbErr) {
+		t.Errorf("expected database error, got %v", err)
+	}
+	if _, _, err := repos.Acts.List(ctx, models.DefaultPagination()); !errors.Is(err, dbErr) {
+		t.Errorf("expected database error, got %v", err)
+	}
+	if _, err := repos.Testimonials.ToggleReaction(ctx, "t1", "u1"); !errors.Is(err, dbErr) {
+		t.Errorf("expected database error, got %v", err)
+	}
+	if _, err := repos.Acts.CreateBatch(ctx, []models.Act{{ID: "a1"}}); !errors.Is(err, dbErr) {
+		t.Errorf("expected database error, got %v", err)
+	}
+	if _, err := repos.Trash.Purge(ctx, time.Now()); !errors.Is(err, dbErr) {
+		t.Errorf("expected database error, got %v", err)
+	}
+}
+
+func TestCreateBatch_Chunks(t *testing.T) {
+	// Every chunk reports 7 rows written, so the total shows how many
+	// transactions ran
+	repos := NewNeo4j(&fakeDB{result: int64(7)})
+	ctx := context.Background()
+
+	written, err := repos.Acts.CreateBatch(ctx, make([]models.Act, 2*BatchSize+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 3*7 {
+		t.Errorf("expected 3 chunks, got a total of %d", written)
+	}
+
+	written, err = repos.Users.CreateBatch(ctx, nil)
+	if err != nil || written != 0 {
+		t.Errorf("expected an empty batch to write nothing, got %d, %v", written, err)
+	}
+}
+
+func TestEscapeLucene(t *testing.T) {
+	for text, want := range map[string]string{
+		"soup kitchen":       "soup kitchen",
+		"  food   AND  bike": "food and bike",
+		"C++ (beginner)":     `C\+\+ \(beginner\)`,
+		`title:"x" *`:        `title\:\"x\" \*`,
+	} {
+		if got := escapeLucene(text); got != want {
+			t.Errorf("escapeLucene(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
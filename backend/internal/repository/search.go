@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jSearchRepository searches the full-text indexes act_search,
+// chain_search, user_search and testimonial_search
+type Neo4jSearchRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jSearch creates a SearchRepository backed by db
+func NewNeo4jSearch(db database.DBClient) *Neo4jSearchRepository {
+	return &Neo4jSearchRepository{db: db}
+}
+
+// searchPage is a page of search hits and the total number of hits
+type searchPage struct {
+	hits  []models.SearchHit
+	total int64
+}
+
+// Search returns a page of the documents of kinds matching text, best
+// first, and their number. Lucene's special characters in text are
+// escaped, so it is searched as plain words.
+func (r *Neo4jSearchRepository) Search(ctx context.Context, text string, kinds []string, page models.PaginationParams) ([]models.SearchHit, int64, error) {
+	params := map[string]interface{}{
+		"query": escapeLucene(text),
+		"kinds": kinds,
+		"skip":  (page.Page - 1) * page.PerPage,
+		"limit": page.PerPage,
+	}
+	result, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (searchPage, error) {
+		count, err := queries.SearchCount.Run(ctx, tx, params)
+		if err != nil {
+			return searchPage{}, err
+		}
+		var total int64
+		if count.Next(ctx) {
+			total = getInt64(count.Record(), "total")
+		}
+
+		hits, err := queries.SearchHits.Run(ctx, tx, params)
+		if err != nil {
+			return searchPage{}, err
+		}
+		found, err := database.Collect(ctx, hits, func(record *neo4j.Record) (models.SearchHit, error) {
+			hit := models.SearchHit{}
+			hit.Kind, _ = database.RecordValue[string](record, "kind")
+			hit.ID, _ = database.RecordValue[string](record, "id")
+			hit.Title, _ = database.RecordValue[string](record, "title")
+			hit.Text, _ = database.RecordValue[string](record, "text")
+			hit.Score, _ = database.RecordValue[float64](record, "score")
+			return hit, nil
+		})
+		return searchPage{hits: found, total: total}, err
+	})
+	return result.hits, result.total, err
+}
+
+// Documents returns the searchable documents of kind with ids
+func (r *Neo4jSearchRepository) Documents(ctx context.Context, kind string, ids []string) ([]models.SearchDocument, error) {
+	query, ok := queries.SearchDocuments[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown search kind %q", kind)
+	}
+	return r.documents(ctx, kind, query, map[string]interface{}{"ids": ids})
+}
+
+// List returns up to limit searchable documents of kind with IDs after
+// after, in ID order
+func (r *Neo4jSearchRepository) List(ctx context.Context, kind, after string, limit int) ([]models.SearchDocument, error) {
+	query, ok := queries.SearchList[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown search kind %q", kind)
+	}
+	return r.documents(ctx, kind, query, map[string]interface{}{"after": after, "limit": limit})
+}
+
+func (r *Neo4jSearchRepository) documents(ctx context.Context, kind string, query *queries.Query, params map[string]interface{}) ([]models.SearchDocument, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.SearchDocument, error) {
+		result, err := query.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.SearchDocument, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "n")
+			if err != nil {
+				return models.SearchDocument{}, err
+			}
+			return searchDocument(kind, node.Props), nil
+		})
+	})
+}
+
+// searchFields are the properties holding the title and text of each kind
+// of document
+var searchFields = map[string][2]string{
+	"act":         {"title", "description"},
+	"chain":       {"name", "description"},
+	"user":        {"name", "bio"},
+	"testimonial": {"impact", "story"},
+}
+
+// searchDocument maps the properties of a node of kind
+func searchDocument(kind string, props map[string]any) models.SearchDocument {
+	fields := searchFields[kind]
+	doc := models.SearchDocument{Kind: kind}
+	doc.ID, _ = props["id"].(string)
+	doc.Title, _ = props[fields[0]].(string)
+	doc.Text, _ = props[fields[1]].(string)
+	return doc
+}
+
+// luceneSpecial are the characters with a meaning in Lucene's query syntax
+const luceneSpecial = `+-&|!(){}[]^"~*?:\/`
+
+// escapeLucene escapes the special characters of Lucene's query syntax in
+// text, and the AND, OR and NOT operators, so it matches as plain words
+func escapeLucene(text string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(text) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		switch word {
+		case "AND", "OR", "NOT":
+			word = strings.ToLower(word)
+		}
+		for _, c := range word {
+			if strings.ContainsRune(luceneSpecial, c) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
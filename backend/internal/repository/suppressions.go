@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jSuppressionRepository stores the suppression list as
+// EmailSuppression nodes keyed by lowercased address
+type Neo4jSuppressionRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jSuppressions creates a SuppressionRepository backed by db
+func NewNeo4jSuppressions(db database.DBClient) *Neo4jSuppressionRepository {
+	return &Neo4jSuppressionRepository{db: db}
+}
+
+// NormalizeEmail returns the form addresses are compared in
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Suppressed reports whether email is on the list
+func (r *Neo4jSuppressionRepository) Suppressed(ctx context.Context, email string) (bool, error) {
+	n, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+		return runCount(ctx, tx, queries.MailSuppressed, map[string]interface{}{"email": NormalizeEmail(email)})
+	})
+	return n > 0, err
+}
+
+// Suppress adds email to the list, keeping the reason and time of an
+// existing entry
+func (r *Neo4jSuppressionRepository) Suppress(ctx context.Context, email, reason string) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.MailSuppress.Run(ctx, tx, map[string]interface{}{
+			"email":  NormalizeEmail(email),
+			"reason": reason,
+			"now":    time.Now().UTC(),
+		})
+		return nil, err
+	})
+	return err
+}
+
+// Unsuppress removes email from the list, reporting whether it was on it
+func (r *Neo4jSuppressionRepository) Unsuppress(ctx context.Context, email string) (bool, error) {
+	n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+		return runCount(ctx, tx, queries.MailUnsuppress, map[string]interface{}{"email": NormalizeEmail(email)})
+	})
+	return n > 0, err
+}
+
+type suppressionPage struct {
+	suppressions []models.EmailSuppression
+	total        int64
+}
+
+// List returns a page of the list, newest first, and its size
+func (r *Neo4jSuppressionRepository) List(ctx context.Context, page models.PaginationParams) ([]models.EmailSuppression, int64, error) {
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (suppressionPage, error) {
+		countResult, err := queries.MailSuppressionCount.Run(ctx, tx, nil)
+		if err != nil {
+			return suppressionPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.MailSuppressionList.Run(ctx, tx, map[string]interface{}{
+			"skip":  (page.Page - 1) * page.PerPage,
+			"limit": page.PerPage,
+		})
+		if err != nil {
+			return suppressionPage{}, err
+		}
+		suppressions, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.EmailSuppression, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "s")
+			if err != nil {
+				return models.EmailSuppression{}, err
+			}
+			return database.DecodeNode[models.EmailSuppression](node)
+		})
+		if suppressions == nil {
+			suppressions = []models.EmailSuppression{}
+		}
+		return suppressionPage{suppressions: suppressions, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.suppressions, p.total, nil
+}
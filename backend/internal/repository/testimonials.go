@@ -0,0 +1,433 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/moderation"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jTestimonialRepository stores testimonials as Testimonial nodes
+// written by users, with their translations and reactions
+type Neo4jTestimonialRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jTestimonials creates a TestimonialRepository backed by db
+func NewNeo4jTestimonials(db database.DBClient) *Neo4jTestimonialRepository {
+	return &Neo4jTestimonialRepository{db: db}
+}
+
+type testimonialPage struct {
+	testimonials []models.Testimonial
+	total        int64
+}
+
+// List returns a page of testimonials matching filter, translated to the
+// first available of locales, and the total count
+func (r *Neo4jTestimonialRepository) List(ctx context.Context, filter TestimonialFilter, page models.PaginationParams, locales []string) ([]models.Testimonial, int64, error) {
+	sortBy := "created"
+	if page.SortBy == "reactions" {
+		sortBy = "reactions"
+	}
+	direction := "desc"
+	if page.Order == "asc" {
+		direction = "asc"
+	}
+
+	queryParams := map[string]interface{}{
+		"locales":  locales,
+		"approved": boolOrNil(filter.Approved),
+		"featured": boolOrNil(filter.Featured),
+		"userId":   nilIfEmpty(filter.UserID),
+		"skip":     (page.Page - 1) * page.PerPage,
+		"limit":    page.PerPage,
+	}
+
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (testimonialPage, error) {
+		countResult, err := queries.TestimonialCount.Run(ctx, tx, queryParams)
+		if err != nil {
+			return testimonialPage{}, err
+		}
+
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.TestimonialList.Get(sortBy+"_"+direction).Run(ctx, tx, queryParams)
+		if err != nil {
+			return testimonialPage{}, err
+		}
+
+		testimonials := []models.Testimonial{}
+		for result.Next(ctx) {
+			testimonial, err := TestimonialFromRecord(result.Record())
+			if err != nil {
+				return testimonialPage{}, err
+			}
+			applyTranslation(&testimonial, result.Record(), locales)
+			testimonials = append(testimonials, testimonial)
+		}
+
+		return testimonialPage{testimonials: testimonials, total: total}, result.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.testimonials, p.total, nil
+}
+
+// Featured returns approved, featured testimonials in their explicit order,
+// then newest first
+func (r *Neo4jTestimonialRepository) Featured(ctx context.Context, limit int, locales []string) ([]models.Testimonial, error) {
+	testimonials, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.Testimonial, error) {
+		result, err := queries.TestimonialFeatured.Run(ctx, tx, map[string]interface{}{
+			"limit":   limit,
+			"locales": locales,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		testimonials := []models.Testimonial{}
+		for result.Next(ctx) {
+			testimonial, err := TestimonialFromRecord(result.Record())
+			if err != nil {
+				return nil, err
+			}
+			applyTranslation(&testimonial, result.Record(), locales)
+			testimonials = append(testimonials, testimonial)
+		}
+
+		return testimonials, result.Err()
+	})
+	return testimonials, err
+}
+
+// SetFeatured features or unfeatures a testimonial. It returns
+// ErrTestimonialNotFound or, when featuring a testimonial that isn't
+// approved yet, ErrNotApproved.
+func (r *Neo4jTestimonialRepository) SetFeatured(ctx context.Context, id string, featured bool, order *int) (*models.Testimonial, error) {
+	testimonial, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Testimonial, error) {
+		result, err := queries.TestimonialSetFeatured.Run(ctx, tx, map[string]interface{}{
+			"id":       id,
+			"featured": featured,
+			"order":    intOrNil(order),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Next(ctx) {
+			t, err := TestimonialFromRecord(result.Record())
+			if err != nil {
+				return nil, err
+			}
+			return &t, nil
+		}
+
+		// Distinguish a missing testimonial from one that isn't approved yet
+		exists, err := queries.TestimonialOwner.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		if exists.Next(ctx) {
+			return nil, ErrNotApproved
+		}
+		return nil, ErrTestimonialNotFound
+	})
+	return testimonial, err
+}
+
+// Create stores t pending review and records the result of its screening.
+// It returns false if the author does not exist, in which case nothing is
+// stored.
+func (r *Neo4jTestimonialRepository) Create(ctx context.Context, t *models.Testimonial, screening moderation.Result) (bool, error) {
+	mediaType, mediaURL, mediaDuration := mediaParams(t.Media)
+
+	created, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.TestimonialCreate.Run(ctx, tx, map[string]interface{}{
+			"id":        t.ID,
+			"userId":    t.UserID,
+			"story":     t.Story,
+			"impact":    t.Impact,
+			"createdAt": t.CreatedAt,
+
+			"mediaType":     mediaType,
+			"mediaUrl":      mediaURL,
+			"mediaDuration": mediaDuration,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if !result.Next(ctx) {
+			return false, nil
+		}
+		if err := RecordEvent(ctx, tx, models.DomainTestimonialCreated, t.ID, t); err != nil {
+			return false, err
+		}
+		return true, applyScreening(ctx, tx, t.ID, screening, t.CreatedAt)
+	})
+	return created, err
+}
+
+// Update applies an edit by the author or an admin. Translations of changed
+// text are dropped since they would be stale. Edits by the author send an
+// approved or rejected testimonial back to the moderation queue, and edited
+// text goes back through screen before human review.
+func (r *Neo4jTestimonialRepository) Update(ctx context.Context, id string, update TestimonialUpdate, screen Screen) (*models.Testimonial, error) {
+	now := time.Now().UTC()
+	mediaType, mediaURL, mediaDuration := mediaParams(update.Media)
+	textChanged := update.Story != "" || update.Impact != ""
+
+	testimonial, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.Testimonial, error) {
+		if err := checkTestimonialOwner(ctx, tx, id, update.UserID, update.Admin); err != nil {
+			return nil, err
+		}
+
+		if textChanged {
+			_, err := queries.TestimonialDropTranslations.Run(ctx, tx, map[string]interface{}{"id": id})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		result, err := queries.TestimonialUpdate.Run(ctx, tx, map[string]interface{}{
+			"id":            id,
+			"version":       int64OrNil(update.Version),
+			"story":         nilIfEmpty(update.Story),
+			"impact":        nilIfEmpty(update.Impact),
+			"updatedAt":     now,
+			"resetApproval": !update.Admin,
+			"removeMedia":   update.RemoveMedia,
+			"mediaType":     mediaType,
+			"mediaUrl":      mediaURL,
+			"mediaDuration": mediaDuration,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Next(ctx) {
+			return nil, ErrTestimonialNotFound
+		}
+		if _, err := updatedVersion(result.Record(), "t"); err != nil {
+			return nil, err
+		}
+		t, err := TestimonialFromRecord(result.Record())
+		if err != nil {
+			return nil, err
+		}
+		if err := RecordEvent(ctx, tx, models.DomainTestimonialUpdated, id, t); err != nil {
+			return nil, err
+		}
+
+		if !update.Admin && textChanged && screen != nil {
+			if err := applyScreening(ctx, tx, id, screen(t.Story, t.Impact), now); err != nil {
+				return nil, err
+			}
+		}
+		return &t, nil
+	})
+	return testimonial, err
+}
+
+// Delete marks a testimonial deleted if userID is its author or admin is
+// set. It returns ErrTestimonialNotFound or ErrNotTestimonialOwner
+// otherwise.
+func (r *Neo4jTestimonialRepository) Delete(ctx context.Context, id, userID string, admin bool) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if err := checkTestimonialOwner(ctx, tx, id, userID, admin); err != nil {
+			return nil, err
+		}
+
+		return nil, softDelete(ctx, tx, queries.TestimonialDelete, models.DomainTestimonialDeleted, id)
+	})
+	return err
+}
+
+// ToggleReaction adds the user's "this moved me" reaction to an approved
+// testimonial, or removes it if already present. Each user counts once
+// thanks to the MOVED relationship; the total is kept on the testimonial so
+// listings don't have to count. It returns ErrTestimonialNotFound or
+// ErrUserNotFound if either doesn't exist.
+func (r *Neo4jTestimonialRepository) ToggleReaction(ctx context.Context, id, userID string) (*models.ReactionResult, error) {
+	params := map[string]interface{}{
+		"id":     id,
+		"userId": userID,
+		"now":    time.Now().UTC(),
+	}
+
+	reaction, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.ReactionResult, error) {
+		existing, err := queries.TestimonialReacted.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		if !existing.Next(ctx) {
+			return nil, ErrTestimonialNotFound
+		}
+		reacted, _ := existing.Record().Get("reacted")
+		authorID, _ := existing.Record().Get("authorId")
+
+		query := queries.TestimonialReact
+		if reacted == true {
+			query = queries.TestimonialUnreact
+		}
+
+		result, err := query.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next(ctx) {
+			return nil, ErrUserNotFound
+		}
+
+		author, _ := authorID.(string)
+		reaction := &models.ReactionResult{
+			Reacted:       reacted != true,
+			ReactionCount: getInt64(result.Record(), "count"),
+			AuthorID:      author,
+		}
+		eventType := models.DomainTestimonialReacted
+		if !reaction.Reacted {
+			eventType = models.DomainTestimonialUnreacted
+		}
+		change := models.ReactionChange{UserID: userID, ReactionCount: reaction.ReactionCount}
+		return reaction, RecordEvent(ctx, tx, eventType, id, change)
+	})
+	return reaction, err
+}
+
+// applyScreening records an automated screening result on a testimonial.
+// Blocked content is rejected straight away so it never reaches the human
+// queue; flagged content stays pending with its score for moderators.
+func applyScreening(ctx context.Context, tx neo4j.ManagedTransaction, testimonialID string, screening moderation.Result, now time.Time) error {
+	var reason interface{}
+	if screening.Verdict == moderation.Block {
+		reason = string(models.RejectionSpam)
+		if screening.HasFlag(moderation.FlagProfanity) {
+			reason = string(models.RejectionOffensive)
+		}
+	}
+
+	_, err := queries.TestimonialScreening.Run(ctx, tx, map[string]interface{}{
+		"id":      testimonialID,
+		"score":   screening.Score,
+		"flags":   screening.Flags,
+		"verdict": string(screening.Verdict),
+		"reason":  reason,
+		"now":     now,
+	})
+	return err
+}
+
+// checkTestimonialOwner returns ErrTestimonialNotFound if the testimonial
+// doesn't exist and ErrNotTestimonialOwner if userID may not modify it.
+func checkTestimonialOwner(ctx context.Context, tx neo4j.ManagedTransaction, testimonialID, userID string, admin bool) error {
+	result, err := queries.TestimonialOwner.Run(ctx, tx, map[string]interface{}{
+		"id": testimonialID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !result.Next(ctx) {
+		return ErrTestimonialNotFound
+	}
+
+	ownerID, _ := result.Record().Get("userId")
+	if !admin && ownerID != userID {
+		return ErrNotTestimonialOwner
+	}
+	return nil
+}
+
+// TestimonialFromRecord maps a record with a testimonial "t" and an optional
+// author "u" into a Testimonial. Only the author's public profile is kept.
+func TestimonialFromRecord(record *neo4j.Record) (models.Testimonial, error) {
+	node, err := database.RecordValue[neo4j.Node](record, "t")
+	if err != nil {
+		return models.Testimonial{}, err
+	}
+	testimonial, err := database.DecodeNode[models.Testimonial](node)
+	if err != nil {
+		return models.Testimonial{}, err
+	}
+
+	props := node.Props
+	if mediaURL, ok := props["mediaUrl"].(string); ok {
+		mediaType, _ := props["mediaType"].(string)
+		testimonial.Media = &models.Media{Type: models.MediaType(mediaType), URL: mediaURL}
+		if duration, ok := props["mediaDuration"].(int64); ok {
+			testimonial.Media.DurationSeconds = int(duration)
+		}
+	}
+
+	if userNode, ok := record.Get("u"); ok && userNode != nil {
+		author, err := database.DecodeNode[models.User](userNode.(neo4j.Node))
+		if err != nil {
+			return models.Testimonial{}, err
+		}
+		testimonial.User = &models.User{
+			ID:       author.ID,
+			Name:     author.Name,
+			Location: author.Location,
+		}
+	}
+
+	return testimonial, nil
+}
+
+// applyTranslation replaces the story and impact of t with the translation
+// in the record's "translations" that best matches the preferred locales.
+// The original text is kept when none match.
+func applyTranslation(t *models.Testimonial, record *neo4j.Record, locales []string) {
+	raw, ok := record.Get("translations")
+	if !ok {
+		return
+	}
+	translations, _ := raw.([]interface{})
+
+	byLocale := make(map[string]map[string]interface{}, len(translations))
+	for _, tr := range translations {
+		props := tr.(neo4j.Node).Props
+		if locale, ok := props["locale"].(string); ok {
+			byLocale[locale] = props
+		}
+	}
+
+	for _, locale := range locales {
+		props, ok := byLocale[locale]
+		if !ok {
+			continue
+		}
+		if story, ok := props["story"].(string); ok {
+			t.Story = story
+		}
+		if impact, ok := props["impact"].(string); ok {
+			t.Impact = impact
+		}
+		t.Locale = locale
+		return
+	}
+}
+
+// mediaParams returns the query parameters for an attachment's type, URL and
+// duration, each nil when no media is attached
+func mediaParams(m *models.Media) (interface{}, interface{}, interface{}) {
+	if m == nil {
+		return nil, nil, nil
+	}
+	var duration interface{}
+	if m.DurationSeconds > 0 {
+		duration = int64(m.DurationSeconds)
+	}
+	return string(m.Type), m.URL, duration
+}
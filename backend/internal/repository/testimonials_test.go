@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+func TestTestimonialFromRecord(t *testing.T) {
+	record := &neo4j.Record{
+		Keys: []string{"t", "u"},
+		Values: []interface{}{
+			neo4j.Node{Props: map[string]interface{}{
+				"id":            "t1",
+				"userId":        "u1",
+				"story":         "A story",
+				"impact":        "Big",
+				"isApproved":    true,
+				"isFeatured":    true,
+				"featuredOrder": int64(2),
+				"reactionCount": int64(7),
+				"createdAt":     time.Now(),
+			}},
+			neo4j.Node{Props: map[string]interface{}{
+				"id":       "u1",
+				"name":     "Jane",
+				"location": "Rome",
+			}},
+		},
+	}
+
+	testimonial, err := TestimonialFromRecord(record)
+	if err != nil {
+		t.Fatalf("TestimonialFromRecord failed: %v", err)
+	}
+
+	if testimonial.ID != "t1" || testimonial.UserID != "u1" || !testimonial.IsFeatured {
+		t.Errorf("unexpected testimonial %+v", testimonial)
+	}
+	if testimonial.FeaturedOrder == nil || *testimonial.FeaturedOrder != 2 {
+		t.Errorf("expected featured order 2, got %v", testimonial.FeaturedOrder)
+	}
+	if testimonial.User == nil || testimonial.User.Location != "Rome" {
+		t.Errorf("expected author with location, got %+v", testimonial.User)
+	}
+	if testimonial.ReactionCount != 7 {
+		t.Errorf("expected 7 reactions, got %d", testimonial.ReactionCount)
+	}
+}
+
+func TestTestimonialFromRecord_Media(t *testing.T) {
+	record := &neo4j.Record{
+		Keys: []string{"t"},
+		Values: []interface{}{
+			neo4j.Node{Props: map[string]interface{}{
+				"id":            "t1",
+				"story":         "A story",
+				"impact":        "Big",
+				"isApproved":    true,
+				"createdAt":     time.Now(),
+				"mediaType":     "video",
+				"mediaUrl":      "https://cdn.example.com/clip.mp4",
+				"mediaDuration": int64(20),
+			}},
+		},
+	}
+
+	testimonial, err := TestimonialFromRecord(record)
+	if err != nil {
+		t.Fatalf("TestimonialFromRecord failed: %v", err)
+	}
+
+	if testimonial.Media == nil {
+		t.Fatal("expected media to be mapped")
+	}
+	if testimonial.Media.Type != models.MediaTypeVideo || testimonial.Media.DurationSeconds != 20 {
+		t.Errorf("unexpected media %+v", testimonial.Media)
+	}
+}
+
+func TestApplyTranslation(t *testing.T) {
+	record := &neo4j.Record{
+		Keys: []string{"translations"},
+		Values: []interface{}{
+			[]interface{}{
+				neo4j.Node{Props: map[string]interface{}{"locale": "pt", "story": "Uma história", "impact": "Grande"}},
+				neo4j.Node{Props: map[string]interface{}{"locale": "it", "story": "Una storia", "impact": "Grande"}},
+			},
+		},
+	}
+
+	testimonial := models.Testimonial{Story: "A story", Impact: "Big"}
+	applyTranslation(&testimonial, record, []string{"pt-br", "it", "pt"})
+
+	if testimonial.Locale != "it" || testimonial.Story != "Una storia" {
+		t.Errorf("expected the Italian translation, got %+v", testimonial)
+	}
+
+	untranslated := models.Testimonial{Story: "A story", Impact: "Big"}
+	applyTranslation(&untranslated, record, []string{"de"})
+
+	if untranslated.Locale != "" || untranslated.Story != "A story" {
+		t.Errorf("expected the original text, got %+v", untranslated)
+	}
+}
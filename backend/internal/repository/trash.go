@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// TrashKinds lists the kinds of entity that are soft deleted, as named in
+// admin routes
+var TrashKinds = []string{"users", "acts", "testimonials"}
+
+// restoredEvents are the domain events recorded when an entity of each
+// kind is restored
+var restoredEvents = map[string]string{
+	"users":        models.DomainUserRestored,
+	"acts":         models.DomainActRestored,
+	"testimonials": models.DomainTestimonialRestored,
+}
+
+// Neo4jTrashRepository restores and purges soft deleted entities
+type Neo4jTrashRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jTrash creates a TrashRepository backed by db
+func NewNeo4jTrash(db database.DBClient) *Neo4jTrashRepository {
+	return &Neo4jTrashRepository{db: db}
+}
+
+// Restore clears the deletion mark of the entity of kind with id, or
+// returns ErrNotDeleted
+func (r *Neo4jTrashRepository) Restore(ctx context.Context, kind, id string) error {
+	query, ok := queries.TrashRestore[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+
+	restored, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := query.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return false, err
+		}
+		if !result.Next(ctx) {
+			return false, result.Err()
+		}
+		return true, RecordEvent(ctx, tx, restoredEvents[kind], id, struct{}{})
+	})
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrNotDeleted
+	}
+	return nil
+}
+
+// Purge permanently deletes the entities deleted before before, BatchSize
+// at a time so a large backlog doesn't need one huge transaction, and
+// returns how many of each kind it removed
+func (r *Neo4jTrashRepository) Purge(ctx context.Context, before time.Time) (map[string]int, error) {
+	purged := make(map[string]int, len(TrashKinds))
+	params := map[string]interface{}{"before": before.UTC(), "limit": BatchSize}
+	for _, kind := range TrashKinds {
+		query := queries.TrashPurge.Get(kind)
+		for {
+			n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+				return runCount(ctx, tx, query, params)
+			})
+			if err != nil {
+				return purged, err
+			}
+			purged[kind] += n
+			if n < BatchSize {
+				break
+			}
+		}
+	}
+	return purged, nil
+}
+
+// softDelete marks the entity with id deleted with query, which returns a
+// row if it did, and records eventType if so
+func softDelete(ctx context.Context, tx neo4j.ManagedTransaction, query *queries.Query, eventType, id string) error {
+	now := time.Now().UTC()
+	result, err := query.Run(ctx, tx, map[string]interface{}{"id": id, "now": now})
+	if err != nil {
+		return err
+	}
+	if !result.Next(ctx) {
+		return result.Err()
+	}
+	return RecordEvent(ctx, tx, eventType, id, map[string]time.Time{"deletedAt": now})
+}
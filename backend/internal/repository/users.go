@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jUserRepository stores users as User nodes
+type Neo4jUserRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jUsers creates a UserRepository backed by db
+func NewNeo4jUsers(db database.DBClient) *Neo4jUserRepository {
+	return &Neo4jUserRepository{db: db}
+}
+
+// Get returns a user with their activity counts, or ErrUserNotFound
+func (r *Neo4jUserRepository) Get(ctx context.Context, id string) (*models.User, error) {
+	user, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.User, error) {
+		result, err := queries.UserGet.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Next(ctx) {
+			record := result.Record()
+			userNode, _ := record.Get("u")
+			if userNode == nil {
+				return nil, nil
+			}
+
+			user, err := userFromNode(userNode.(neo4j.Node))
+			if err != nil {
+				return nil, err
+			}
+			user.Stats = models.UserStats{
+				ActsGiven:     int(getInt64(record, "actsGiven")),
+				ActsReceived:  int(getInt64(record, "actsReceived")),
+				ChainsStarted: int(getInt64(record, "chainsStarted")),
+			}
+			return user, nil
+		}
+
+		return nil, result.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Stream passes every user, oldest first and without activity counts, to
+// fn as they are read
+func (r *Neo4jUserRepository) Stream(ctx context.Context, fn func(*models.User) error) error {
+	return database.Stream(ctx, r.db, func(tx neo4j.ManagedTransaction) (neo4j.ResultWithContext, error) {
+		return queries.UserStream.Run(ctx, tx, nil)
+	}, func(record *neo4j.Record) (*models.User, error) {
+		node, err := database.RecordValue[neo4j.Node](record, "u")
+		if err != nil {
+			return nil, err
+		}
+		return userFromNode(node)
+	}, fn)
+}
+
+// FindCredentials returns the user registered with email and their password
+// hash, or ErrUserNotFound
+func (r *Neo4jUserRepository) FindCredentials(ctx context.Context, email string) (*models.User, string, error) {
+	props, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (map[string]interface{}, error) {
+		result, err := queries.UserByEmail.Run(ctx, tx, map[string]interface{}{"email": email})
+		if err != nil {
+			return nil, err
+		}
+
+		if result.Next(ctx) {
+			userNode, _ := result.Record().Get("u")
+			return userNode.(neo4j.Node).Props, nil
+		}
+		return nil, result.Err()
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if props == nil || props["deletedAt"] != nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	hash, _ := props["passwordHash"].(string)
+	user, err := userFromNode(neo4j.Node{Props: props})
+	if err != nil {
+		return nil, "", err
+	}
+	return user, hash, nil
+}
+
+// EmailExists reports whether a user registered with email
+func (r *Neo4jUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.UserByEmail.Run(ctx, tx, map[string]interface{}{"email": email})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+}
+
+// Create stores a new, unverified user
+func (r *Neo4jUserRepository) Create(ctx context.Context, user *models.User, passwordHash string) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.UserCreate.Run(ctx, tx, map[string]interface{}{
+			"id":           user.ID,
+			"email":        user.Email,
+			"passwordHash": passwordHash,
+			"name":         user.Name,
+			"createdAt":    user.CreatedAt,
+			"updatedAt":    user.UpdatedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return nil, RecordEvent(ctx, tx, models.DomainUserCreated, user.ID, user)
+	})
+	return err
+}
+
+// Update changes the profile fields set in req and returns the user's new
+// version, or returns ErrUserNotFound. A stale req.Version fails with
+// ErrVersionConflict.
+func (r *Neo4jUserRepository) Update(ctx context.Context, id string, req models.UpdateUserRequest) (int64, error) {
+	version, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*int64, error) {
+		result, err := queries.UserUpdate.Run(ctx, tx, map[string]interface{}{
+			"id":                   id,
+			"version":              int64OrNil(req.Version),
+			"name":                 nilIfEmpty(req.Name),
+			"avatar":               nilIfEmpty(req.Avatar),
+			"bio":                  nilIfEmpty(req.Bio),
+			"location":             nilIfEmpty(req.Location),
+			"locale":               nilIfEmpty(req.Locale),
+			"updatedAt":            time.Now().UTC(),
+			"hideFromLeaderboards": boolOrNil(req.HideFromLeaderboards),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		user, found, err := database.First(ctx, result, func(record *neo4j.Record) (*models.User, error) {
+			if _, err := updatedVersion(record, "u"); err != nil {
+				return nil, err
+			}
+			node, err := database.RecordValue[neo4j.Node](record, "u")
+			if err != nil {
+				return nil, err
+			}
+			return userFromNode(node)
+		})
+		if err != nil || !found {
+			return nil, err
+		}
+		if err := RecordEvent(ctx, tx, models.DomainUserUpdated, id, user); err != nil {
+			return nil, err
+		}
+		return &user.Version, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if version == nil {
+		return 0, ErrUserNotFound
+	}
+	return *version, nil
+}
+
+// Delete marks a user deleted. They disappear from every default query and
+// can't sign in, but keep their relationships until restored or purged.
+func (r *Neo4jUserRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return nil, softDelete(ctx, tx, queries.UserDelete, models.DomainUserDeleted, id)
+	})
+	return err
+}
+
+type userPage struct {
+	users []models.User
+	total int64
+}
+
+// List returns a page of the users matching filter, newest first and
+// without activity counts, and how many match
+func (r *Neo4jUserRepository) List(ctx context.Context, filter UserFilter, page models.PaginationParams) ([]models.User, int64, error) {
+	params := map[string]interface{}{
+		"query":     nilIfEmpty(strings.ToLower(strings.TrimSpace(filter.Query))),
+		"suspended": boolOrNil(filter.Suspended),
+	}
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (userPage, error) {
+		countResult, err := queries.UserListCount.Run(ctx, tx, params)
+		if err != nil {
+			return userPage{}, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.UserList.Run(ctx, tx, map[string]interface{}{
+			"query":     params["query"],
+			"suspended": params["suspended"],
+			"skip":      (page.Page - 1) * page.PerPage,
+			"limit":     page.PerPage,
+		})
+		if err != nil {
+			return userPage{}, err
+		}
+		users, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.User, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "u")
+			if err != nil {
+				return models.User{}, err
+			}
+			return database.DecodeNode[models.User](node)
+		})
+		if users == nil {
+			users = []models.User{}
+		}
+		return userPage{users: users, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.users, p.total, nil
+}
+
+// Suspend suspends or reinstates a user and records the change, or returns
+// ErrUserNotFound
+func (r *Neo4jUserRepository) Suspend(ctx context.Context, id string, suspended bool, reason, adminID string, now time.Time) (*models.User, error) {
+	params := map[string]interface{}{
+		"id":          id,
+		"suspendedAt": nil,
+		"reason":      nil,
+		"now":         now.UTC(),
+	}
+	eventType := models.DomainUserReinstated
+	if suspended {
+		params["suspendedAt"], params["reason"] = now.UTC(), reason
+		eventType = models.DomainUserSuspended
+	}
+
+	user, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (*models.User, error) {
+		result, err := queries.UserSuspend.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		user, found, err := database.First(ctx, result, func(record *neo4j.Record) (*models.User, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "u")
+			if err != nil {
+				return nil, err
+			}
+			return userFromNode(node)
+		})
+		if err != nil || !found {
+			return nil, err
+		}
+		return user, RecordEvent(ctx, tx, eventType, id, models.UserSuspension{AdminID: adminID, Reason: reason})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// Suspended reports whether an admin suspended user id or they were
+// erased. Users who don't exist aren't suspended.
+func (r *Neo4jUserRepository) Suspended(ctx context.Context, id string) (bool, error) {
+	return database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (bool, error) {
+		result, err := queries.UserSuspended.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return false, err
+		}
+		return result.Next(ctx), result.Err()
+	})
+}
+
+// userFromNode maps a User node, leaving out the password hash
+func userFromNode(node neo4j.Node) (*models.User, error) {
+	user, err := database.DecodeNode[models.User](node)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
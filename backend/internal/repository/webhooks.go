@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/queries"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Neo4jWebhookRepository stores webhooks as Webhook nodes linked to the
+// WebhookDelivery nodes of their delivery log
+type Neo4jWebhookRepository struct {
+	db database.DBClient
+}
+
+// NewNeo4jWebhooks creates a WebhookRepository backed by db
+func NewNeo4jWebhooks(db database.DBClient) *Neo4jWebhookRepository {
+	return &Neo4jWebhookRepository{db: db}
+}
+
+// Create stores hook
+func (r *Neo4jWebhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.WebhookCreate.Run(ctx, tx, map[string]interface{}{
+			"id":        hook.ID,
+			"url":       hook.URL,
+			"events":    hook.Events,
+			"secret":    hook.Secret,
+			"createdAt": hook.CreatedAt,
+		})
+		return nil, err
+	})
+	return err
+}
+
+// List returns every webhook, newest first, without their secrets
+func (r *Neo4jWebhookRepository) List(ctx context.Context) ([]models.Webhook, error) {
+	hooks, err := r.collect(ctx, queries.WebhookList, nil)
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, err
+}
+
+// Subscribed returns the webhooks subscribed to event, with their secrets
+func (r *Neo4jWebhookRepository) Subscribed(ctx context.Context, event string) ([]models.Webhook, error) {
+	return r.collect(ctx, queries.WebhookSubscribed, map[string]interface{}{"event": event})
+}
+
+func (r *Neo4jWebhookRepository) collect(ctx context.Context, query *queries.Query, params map[string]interface{}) ([]models.Webhook, error) {
+	hooks, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) ([]models.Webhook, error) {
+		result, err := query.Run(ctx, tx, params)
+		if err != nil {
+			return nil, err
+		}
+		return database.Collect(ctx, result, func(record *neo4j.Record) (models.Webhook, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "w")
+			if err != nil {
+				return models.Webhook{}, err
+			}
+			return database.DecodeNode[models.Webhook](node)
+		})
+	})
+	if hooks == nil {
+		hooks = []models.Webhook{}
+	}
+	return hooks, err
+}
+
+// Delete removes a webhook and its delivery log, or returns
+// ErrWebhookNotFound
+func (r *Neo4jWebhookRepository) Delete(ctx context.Context, id string) error {
+	n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+		return runCount(ctx, tx, queries.WebhookDelete, map[string]interface{}{"id": id})
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RecordDelivery adds an attempt to its webhook's delivery log. Attempts
+// for a webhook deleted since are dropped.
+func (r *Neo4jWebhookRepository) RecordDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	_, err := r.db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		_, err := queries.WebhookRecordDelivery.Run(ctx, tx, map[string]interface{}{
+			"id":         d.ID,
+			"webhookId":  d.WebhookID,
+			"eventId":    d.EventID,
+			"event":      d.Event,
+			"attempt":    d.Attempt,
+			"statusCode": d.StatusCode,
+			"error":      nilIfEmpty(d.Error),
+			"succeeded":  d.Succeeded,
+			"durationMs": d.DurationMS,
+			"createdAt":  d.CreatedAt,
+		})
+		return nil, err
+	})
+	return err
+}
+
+type deliveryPage struct {
+	deliveries []models.WebhookDelivery
+	total      int64
+}
+
+// Deliveries returns a page of a webhook's delivery log, newest first, and
+// its size, or ErrWebhookNotFound
+func (r *Neo4jWebhookRepository) Deliveries(ctx context.Context, id string, page models.PaginationParams) ([]models.WebhookDelivery, int64, error) {
+	p, err := database.ExecuteRead(ctx, r.db, func(tx neo4j.ManagedTransaction) (*deliveryPage, error) {
+		n, err := runCount(ctx, tx, queries.WebhookExists, map[string]interface{}{"id": id})
+		if err != nil || n == 0 {
+			return nil, err
+		}
+
+		countResult, err := queries.WebhookDeliveryCount.Run(ctx, tx, map[string]interface{}{"id": id})
+		if err != nil {
+			return nil, err
+		}
+		var total int64
+		if countResult.Next(ctx) {
+			total = getInt64(countResult.Record(), "total")
+		}
+
+		result, err := queries.WebhookDeliveryList.Run(ctx, tx, map[string]interface{}{
+			"id":    id,
+			"skip":  (page.Page - 1) * page.PerPage,
+			"limit": page.PerPage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		deliveries, err := database.Collect(ctx, result, func(record *neo4j.Record) (models.WebhookDelivery, error) {
+			node, err := database.RecordValue[neo4j.Node](record, "d")
+			if err != nil {
+				return models.WebhookDelivery{}, err
+			}
+			return database.DecodeNode[models.WebhookDelivery](node)
+		})
+		if deliveries == nil {
+			deliveries = []models.WebhookDelivery{}
+		}
+		return &deliveryPage{deliveries: deliveries, total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if p == nil {
+		return nil, 0, ErrWebhookNotFound
+	}
+	return p.deliveries, p.total, nil
+}
+
+// PruneDeliveries deletes the delivery log entries created before before,
+// in batches, and returns how many
+func (r *Neo4jWebhookRepository) PruneDeliveries(ctx context.Context, before time.Time) (int, error) {
+	params := map[string]interface{}{"before": before.UTC(), "limit": BatchSize}
+	pruned := 0
+	for {
+		n, err := database.ExecuteWrite(ctx, r.db, func(tx neo4j.ManagedTransaction) (int, error) {
+			return runCount(ctx, tx, queries.WebhookPruneDeliveries, params)
+		})
+		pruned += n
+		if err != nil || n < BatchSize {
+			return pruned, err
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// maxLength bounds request IDs accepted from clients and proxies
+const maxLength = 128
+
+type contextKey struct{}
+
+// New returns a new request ID. UUIDv7 values are unique across instances
+// and sort by creation time, which keeps them readable in logs.
+func New() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.New().String()
+	}
+	return id.String()
+}
+
+// Valid reports whether an incoming request ID is safe to reuse in headers,
+// logs and database metadata
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, if any
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
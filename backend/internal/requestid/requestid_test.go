@@ -0,0 +1,50 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNew(t *testing.T) {
+	first, second := New(), New()
+	if first == second {
+		t.Fatal("expected unique request IDs")
+	}
+
+	id, err := uuid.Parse(first)
+	if err != nil || id.Version() != 7 {
+		t.Errorf("expected a UUIDv7, got %q (%v)", first, err)
+	}
+	// UUIDv7 values sort by creation time
+	if first > second {
+		t.Errorf("expected %s to sort before %s", first, second)
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := map[string]bool{
+		"":                        false,
+		"abc-123":                 true,
+		"with space":              false,
+		"line\nbreak":             false,
+		strings.Repeat("a", 129):  false,
+		"0190f1e2-7c3a-7def-8abc": true,
+	}
+	for id, expected := range tests {
+		if got := Valid(id); got != expected {
+			t.Errorf("Valid(%q): expected %v, got %v", id, expected, got)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if FromContext(context.Background()) != "" {
+		t.Error("expected no request ID in an empty context")
+	}
+	if got := FromContext(NewContext(context.Background(), "req-1")); got != "req-1" {
+		t.Errorf("expected req-1, got %q", got)
+	}
+}
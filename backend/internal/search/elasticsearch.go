@@ -0,0 +1,292 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/version"
+)
+
+// positionID is the ID of the document holding the position of an
+// Elasticsearch index, which searches leave out by kind
+const positionID = "meta:position"
+
+// Elasticsearch is an Index stored in Elasticsearch through its REST API.
+// Documents are stored under their kind and ID, such as "act:42", and the
+// position as a document of its own.
+type Elasticsearch struct {
+	URL      string
+	Index    string
+	Username string
+	Password string
+	// Client defaults to a client with a 10 second timeout
+	Client *http.Client
+}
+
+// Ensure Elasticsearch implements Index
+var _ Index = (*Elasticsearch)(nil)
+
+// esMappings are the mappings of the index Setup creates
+const esMappings = `{"mappings": {"properties": {
+	"kind": {"type": "keyword"},
+	"id": {"type": "keyword"},
+	"title": {"type": "text"},
+	"text": {"type": "text"},
+	"sequence": {"type": "long"}
+}}}`
+
+// esDocument is a stored document, or the position with Sequence set
+type esDocument struct {
+	Kind     string `json:"kind"`
+	ID       string `json:"id,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Sequence int64  `json:"sequence,omitempty"`
+}
+
+// esError is the error body of an Elasticsearch response
+type esError struct {
+	Error struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+// Setup creates the index with its mappings unless it exists
+func (e *Elasticsearch) Setup(ctx context.Context) error {
+	status, _, err := e.do(ctx, http.MethodHead, e.path(), nil, "")
+	if err != nil || status == http.StatusOK {
+		return err
+	}
+	if status != http.StatusNotFound {
+		return fmt.Errorf("elasticsearch: checking index %s: status %d", e.Index, status)
+	}
+	status, body, err := e.do(ctx, http.MethodPut, e.path(), strings.NewReader(esMappings), "application/json")
+	if err != nil {
+		return err
+	}
+	var failure esError
+	json.Unmarshal(body, &failure)
+	// Another replica may have created it meanwhile
+	if status >= 300 && failure.Error.Type != "resource_already_exists_exception" {
+		return fmt.Errorf("elasticsearch: creating index %s: status %d %s", e.Index, status, failure.Error.Reason)
+	}
+	return nil
+}
+
+// Reset deletes every document and the position
+func (e *Elasticsearch) Reset(ctx context.Context) error {
+	return e.expect(ctx, http.MethodPost, e.path("_delete_by_query")+"?refresh=true",
+		strings.NewReader(`{"query": {"match_all": {}}}`))
+}
+
+// Put adds or replaces docs with a bulk request
+func (e *Elasticsearch) Put(ctx context.Context, docs []models.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, d := range docs {
+		enc.Encode(map[string]any{"index": map[string]string{"_id": d.Kind + ":" + d.ID}})
+		enc.Encode(esDocument{Kind: d.Kind, ID: d.ID, Title: d.Title, Text: d.Text})
+	}
+	if err := e.bulk(ctx, &body); err != nil {
+		return err
+	}
+	metrics.SearchIndexed.WithLabelValues(e.Name(), "put").Add(float64(len(docs)))
+	return nil
+}
+
+// Delete removes the documents of kind with ids with a bulk request
+func (e *Elasticsearch) Delete(ctx context.Context, kind string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, id := range ids {
+		enc.Encode(map[string]any{"delete": map[string]string{"_id": kind + ":" + id}})
+	}
+	if err := e.bulk(ctx, &body); err != nil {
+		return err
+	}
+	metrics.SearchIndexed.WithLabelValues(e.Name(), "delete").Add(float64(len(ids)))
+	return nil
+}
+
+// Position returns the sequence stored by SetPosition
+func (e *Elasticsearch) Position(ctx context.Context) (int64, bool, error) {
+	status, body, err := e.do(ctx, http.MethodGet, e.path("_doc", positionID), nil, "")
+	if err != nil {
+		return 0, false, err
+	}
+	if status == http.StatusNotFound {
+		return 0, false, nil
+	}
+	if status != http.StatusOK {
+		return 0, false, fmt.Errorf("elasticsearch: reading position: status %d", status)
+	}
+	var doc struct {
+		Source esDocument `json:"_source"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return 0, false, fmt.Errorf("elasticsearch: invalid position: %w", err)
+	}
+	return doc.Source.Sequence, true, nil
+}
+
+// SetPosition stores sequence as the position
+func (e *Elasticsearch) SetPosition(ctx context.Context, sequence int64) error {
+	doc, _ := json.Marshal(esDocument{Kind: "meta", Sequence: sequence})
+	return e.expect(ctx, http.MethodPut, e.path("_doc", positionID)+"?refresh=true", bytes.NewReader(doc))
+}
+
+// Search matches q.Text against the titles and, with less weight, the
+// texts of the documents of q.Kinds
+func (e *Elasticsearch) Search(ctx context.Context, q Query) ([]models.SearchHit, int64, error) {
+	hits, total, err := e.search(ctx, q)
+	countQuery(e.Name(), err)
+	return hits, total, err
+}
+
+func (e *Elasticsearch) search(ctx context.Context, q Query) ([]models.SearchHit, int64, error) {
+	request, _ := json.Marshal(map[string]any{
+		"query": map[string]any{"bool": map[string]any{
+			"must":   map[string]any{"multi_match": map[string]any{"query": q.Text, "fields": []string{"title^2", "text"}}},
+			"filter": map[string]any{"terms": map[string]any{"kind": q.Kinds}},
+		}},
+		"from":             (q.Page.Page - 1) * q.Page.PerPage,
+		"size":             q.Page.PerPage,
+		"track_total_hits": true,
+	})
+	status, body, err := e.do(ctx, http.MethodPost, e.path("_search"), bytes.NewReader(request), "application/json")
+	if err != nil {
+		return nil, 0, err
+	}
+	if status != http.StatusOK {
+		return nil, 0, e.failure("searching", status, body)
+	}
+
+	var resp struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				Score  float64    `json:"_score"`
+				Source esDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, 0, fmt.Errorf("elasticsearch: invalid search response: %w", err)
+	}
+	hits := make([]models.SearchHit, len(resp.Hits.Hits))
+	for i, h := range resp.Hits.Hits {
+		hits[i] = models.SearchHit{
+			SearchDocument: models.SearchDocument{Kind: h.Source.Kind, ID: h.Source.ID, Title: h.Source.Title, Text: h.Source.Text},
+			Score:          h.Score,
+		}
+	}
+	return hits, resp.Hits.Total.Value, nil
+}
+
+// Name returns "elasticsearch"
+func (e *Elasticsearch) Name() string { return "elasticsearch" }
+
+// bulk sends a bulk request to the index, failing if any item failed
+func (e *Elasticsearch) bulk(ctx context.Context, body io.Reader) error {
+	status, resp, err := e.do(ctx, http.MethodPost, e.path("_bulk")+"?refresh=true", body, "application/x-ndjson")
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return e.failure("bulk request", status, resp)
+	}
+	var result struct {
+		Errors bool                         `json:"errors"`
+		Items  []map[string]json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("elasticsearch: invalid bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	for _, item := range result.Items {
+		for _, raw := range item {
+			var outcome struct {
+				ID    string `json:"_id"`
+				Error *struct {
+					Reason string `json:"reason"`
+				} `json:"error"`
+			}
+			if json.Unmarshal(raw, &outcome) == nil && outcome.Error != nil {
+				return fmt.Errorf("elasticsearch: indexing %s: %s", outcome.ID, outcome.Error.Reason)
+			}
+		}
+	}
+	return fmt.Errorf("elasticsearch: bulk request failed")
+}
+
+// expect sends a JSON request, failing on a non-2xx status
+func (e *Elasticsearch) expect(ctx context.Context, method, path string, body io.Reader) error {
+	status, resp, err := e.do(ctx, method, path, body, "application/json")
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return e.failure(strings.ToLower(method)+" "+path, status, resp)
+	}
+	return nil
+}
+
+func (e *Elasticsearch) failure(what string, status int, body []byte) error {
+	var failure esError
+	json.Unmarshal(body, &failure)
+	return fmt.Errorf("elasticsearch: %s: status %d %s", what, status, failure.Error.Reason)
+}
+
+// path returns the path of the index followed by elems, escaped
+func (e *Elasticsearch) path(elems ...string) string {
+	path := "/" + url.PathEscape(e.Index)
+	for _, elem := range elems {
+		path += "/" + url.PathEscape(elem)
+	}
+	return path
+}
+
+// do sends a request and returns the status and body of the response
+func (e *Elasticsearch) do(ctx context.Context, method, path string, body io.Reader, contentType string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(e.URL, "/")+path, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if e.Username != "" {
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+	client := e.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("elasticsearch: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	return resp.StatusCode, data, err
+}
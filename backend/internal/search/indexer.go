@@ -0,0 +1,184 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+
+	"payforwardnow/internal/models"
+)
+
+// DefaultBatchSize bounds the events and documents an Indexer reads at
+// once
+const DefaultBatchSize = 100
+
+// Events reads the domain events in the outbox;
+// repository.OutboxRepository implements it
+type Events interface {
+	// After returns up to limit events numbered after sequence, in order
+	After(ctx context.Context, sequence int64, limit int) ([]models.DomainEvent, error)
+	// Latest returns the number of the last event recorded
+	Latest(ctx context.Context) (int64, error)
+}
+
+// Source reads the searchable documents; repository.SearchRepository
+// implements it
+type Source interface {
+	// Documents returns the searchable documents of kind with ids
+	Documents(ctx context.Context, kind string, ids []string) ([]models.SearchDocument, error)
+	// List returns up to limit searchable documents of kind with IDs
+	// after after, in ID order
+	List(ctx context.Context, kind, after string, limit int) ([]models.SearchDocument, error)
+}
+
+// Indexer keeps an Index in sync with the database. It follows the domain
+// events recorded since the index's position and, for each user, act or
+// testimonial they name, reads the document again from source, putting it
+// in the index or deleting it if it is no longer searchable. Events are
+// only used to know what changed, so applying one twice is harmless.
+// Chains have no events; they are indexed by Rebuild.
+type Indexer struct {
+	index  Index
+	events Events
+	source Source
+	// BatchSize bounds the events and documents read at once. Defaults to
+	// DefaultBatchSize.
+	BatchSize int
+	ready     atomic.Bool
+}
+
+// NewIndexer creates an Indexer keeping index in sync
+func NewIndexer(index Index, events Events, source Source) *Indexer {
+	return &Indexer{index: index, events: events, source: source, BatchSize: DefaultBatchSize}
+}
+
+// Run applies the events recorded since the last run, building the index
+// first if it never was, and returns how many documents it updated
+func (i *Indexer) Run(ctx context.Context) (int, error) {
+	if err := i.setup(ctx); err != nil {
+		return 0, err
+	}
+	position, built, err := i.index.Position(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !built {
+		return i.Rebuild(ctx)
+	}
+
+	updated := 0
+	for {
+		events, err := i.events.After(ctx, position, i.BatchSize)
+		if err != nil || len(events) == 0 {
+			return updated, err
+		}
+		n, err := i.apply(ctx, events)
+		updated += n
+		if err != nil {
+			return updated, err
+		}
+		position = events[len(events)-1].Sequence
+		if err := i.index.SetPosition(ctx, position); err != nil {
+			return updated, err
+		}
+		if len(events) < i.BatchSize {
+			return updated, nil
+		}
+	}
+}
+
+// Rebuild empties the index and indexes every searchable document again,
+// positioning it at the last event recorded before it started, and returns
+// how many documents it indexed
+func (i *Indexer) Rebuild(ctx context.Context) (int, error) {
+	if err := i.setup(ctx); err != nil {
+		return 0, err
+	}
+	latest, err := i.events.Latest(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if err := i.index.Reset(ctx); err != nil {
+		return 0, err
+	}
+	indexed := 0
+	for _, kind := range models.SearchKinds {
+		after := ""
+		for {
+			docs, err := i.source.List(ctx, kind, after, i.BatchSize)
+			if err != nil {
+				return indexed, fmt.Errorf("listing %s documents: %w", kind, err)
+			}
+			if err := i.index.Put(ctx, docs); err != nil {
+				return indexed, err
+			}
+			indexed += len(docs)
+			if len(docs) < i.BatchSize {
+				break
+			}
+			after = docs[len(docs)-1].ID
+		}
+	}
+	return indexed, i.index.SetPosition(ctx, latest)
+}
+
+// setup creates the index on the first run, so the server starts even
+// when the index's backend is down
+func (i *Indexer) setup(ctx context.Context) error {
+	if i.ready.Load() {
+		return nil
+	}
+	if err := i.index.Setup(ctx); err != nil {
+		return fmt.Errorf("setting up the %s index: %w", i.index.Name(), err)
+	}
+	i.ready.Store(true)
+	return nil
+}
+
+// apply updates the documents events name and returns how many
+func (i *Indexer) apply(ctx context.Context, events []models.DomainEvent) (int, error) {
+	changed := map[string][]string{}
+	for _, e := range events {
+		if !indexedEvent(e) || slices.Contains(changed[e.AggregateType], e.AggregateID) {
+			continue
+		}
+		changed[e.AggregateType] = append(changed[e.AggregateType], e.AggregateID)
+	}
+
+	updated := 0
+	for _, kind := range models.SearchKinds {
+		ids := changed[kind]
+		if len(ids) == 0 {
+			continue
+		}
+		docs, err := i.source.Documents(ctx, kind, ids)
+		if err != nil {
+			return updated, fmt.Errorf("reading %s documents: %w", kind, err)
+		}
+		if err := i.index.Put(ctx, docs); err != nil {
+			return updated, err
+		}
+		var gone []string
+		for _, id := range ids {
+			if !slices.ContainsFunc(docs, func(d models.SearchDocument) bool { return d.ID == id }) {
+				gone = append(gone, id)
+			}
+		}
+		if err := i.index.Delete(ctx, kind, gone); err != nil {
+			return updated, err
+		}
+		updated += len(ids)
+	}
+	return updated, nil
+}
+
+// indexedEvent reports whether an event may change a searchable document.
+// Reactions don't change a testimonial's text and pledges aren't searched.
+func indexedEvent(e models.DomainEvent) bool {
+	switch e.Type {
+	case models.DomainTestimonialReacted, models.DomainTestimonialUnreacted:
+		return false
+	}
+	return slices.Contains(models.SearchKinds, e.AggregateType)
+}
@@ -0,0 +1,124 @@
+// Package search powers the unified search across acts, chains, users and
+// testimonials. The default engine queries Neo4j's full-text indexes,
+// which Neo4j keeps up to date as nodes change. An Elasticsearch index can
+// be used instead, kept in sync by an Indexer following the domain events
+// in the outbox.
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+)
+
+// Query is a search
+type Query struct {
+	Text string
+	// Kinds are the models.SearchKinds searched
+	Kinds []string
+	Page  models.PaginationParams
+}
+
+// Engine runs searches
+type Engine interface {
+	// Search returns a page of the documents matching q, best first, and
+	// their number
+	Search(ctx context.Context, q Query) ([]models.SearchHit, int64, error)
+	// Name names the engine in logs and metrics
+	Name() string
+}
+
+// Index is an Engine holding its own copy of the documents, which an
+// Indexer keeps up to date
+type Index interface {
+	Engine
+	// Setup creates the index if it doesn't exist
+	Setup(ctx context.Context) error
+	// Reset deletes every document and the position
+	Reset(ctx context.Context) error
+	// Put adds or replaces docs
+	Put(ctx context.Context, docs []models.SearchDocument) error
+	// Delete removes the documents of kind with ids, ignoring missing ones
+	Delete(ctx context.Context, kind string, ids []string) error
+	// Position returns the sequence of the last domain event applied to
+	// the index, and false if the index was never built
+	Position(ctx context.Context) (int64, bool, error)
+	// SetPosition records the sequence of the last domain event applied
+	SetPosition(ctx context.Context, sequence int64) error
+}
+
+// Config selects and configures the search engine
+type Config struct {
+	// Driver is "neo4j", the default, or "elasticsearch"
+	Driver string
+	// ElasticsearchURL is the base URL of an Elasticsearch cluster, and
+	// ElasticsearchIndex the name of the index the elasticsearch driver
+	// creates there. ElasticsearchUsername and ElasticsearchPassword
+	// enable basic auth.
+	ElasticsearchURL      string
+	ElasticsearchIndex    string
+	ElasticsearchUsername string
+	ElasticsearchPassword string
+}
+
+// NewIndex returns the index described by cfg, or nil for the neo4j
+// driver, whose full-text indexes need no syncing
+func NewIndex(cfg Config) (Index, error) {
+	switch cfg.Driver {
+	case "", "neo4j":
+		return nil, nil
+	case "elasticsearch":
+		if cfg.ElasticsearchURL == "" || cfg.ElasticsearchIndex == "" {
+			return nil, errors.New("the elasticsearch search driver needs a URL and an index name")
+		}
+		if u, err := url.Parse(cfg.ElasticsearchURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid Elasticsearch URL %q", cfg.ElasticsearchURL)
+		}
+		return &Elasticsearch{
+			URL:      cfg.ElasticsearchURL,
+			Index:    cfg.ElasticsearchIndex,
+			Username: cfg.ElasticsearchUsername,
+			Password: cfg.ElasticsearchPassword,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown search driver %q; use neo4j or elasticsearch", cfg.Driver)
+}
+
+// FullText searches the Neo4j full-text indexes; repository.SearchRepository
+// implements it
+type FullText interface {
+	Search(ctx context.Context, text string, kinds []string, page models.PaginationParams) ([]models.SearchHit, int64, error)
+}
+
+// Neo4j is the default Engine, searching the Neo4j full-text indexes
+type Neo4j struct {
+	fullText FullText
+}
+
+// NewNeo4j returns an Engine searching with fullText
+func NewNeo4j(fullText FullText) *Neo4j {
+	return &Neo4j{fullText: fullText}
+}
+
+// Search returns a page of the documents matching q
+func (n *Neo4j) Search(ctx context.Context, q Query) ([]models.SearchHit, int64, error) {
+	hits, total, err := n.fullText.Search(ctx, q.Text, q.Kinds, q.Page)
+	countQuery(n.Name(), err)
+	return hits, total, err
+}
+
+// Name returns "neo4j"
+func (n *Neo4j) Name() string { return "neo4j" }
+
+// countQuery counts a search run by engine in the metrics
+func countQuery(engine string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.SearchQueries.WithLabelValues(engine, result).Inc()
+}
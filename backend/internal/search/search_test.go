@@ -0,0 +1,244 @@
+package search
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"payforwardnow/internal/databasetest"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/repository"
+)
+
+// memIndex is an in-memory Index counting the documents it is given
+type memIndex struct {
+	docs     map[string]models.SearchDocument
+	position int64
+	built    bool
+	puts     int
+}
+
+func newMemIndex() *memIndex {
+	return &memIndex{docs: map[string]models.SearchDocument{}}
+}
+
+func (m *memIndex) Search(context.Context, Query) ([]models.SearchHit, int64, error) {
+	return nil, 0, nil
+}
+
+func (m *memIndex) Name() string                { return "memory" }
+func (m *memIndex) Setup(context.Context) error { return nil }
+func (m *memIndex) Position(context.Context) (int64, bool, error) {
+	return m.position, m.built, nil
+}
+
+func (m *memIndex) Reset(context.Context) error {
+	m.docs, m.position, m.built = map[string]models.SearchDocument{}, 0, false
+	return nil
+}
+
+func (m *memIndex) Put(_ context.Context, docs []models.SearchDocument) error {
+	for _, d := range docs {
+		m.docs[d.Kind+":"+d.ID] = d
+	}
+	m.puts += len(docs)
+	return nil
+}
+
+func (m *memIndex) Delete(_ context.Context, kind string, ids []string) error {
+	for _, id := range ids {
+		delete(m.docs, kind+":"+id)
+	}
+	return nil
+}
+
+func (m *memIndex) SetPosition(_ context.Context, sequence int64) error {
+	m.position, m.built = sequence, true
+	return nil
+}
+
+func TestIndexer(t *testing.T) {
+	ctx := context.Background()
+	db := databasetest.New()
+	db.AddUser(models.User{ID: "u1", Name: "Ada"}, "")
+	db.AddChain(models.Chain{ID: "c1", Name: "Kindness", StarterID: "u1"})
+	repos := db.Repositories()
+	for i := range 3 {
+		repos.Acts.Create(ctx, &models.Act{ID: fmt.Sprintf("a%d", i), Title: "Act", GiverID: "u1"})
+	}
+
+	index := newMemIndex()
+	indexer := NewIndexer(index, db.Outbox(), repos.Search)
+	indexer.BatchSize = 2
+
+	// The first run builds the index, chains included
+	if n, err := indexer.Run(ctx); err != nil || n != 5 {
+		t.Fatalf("expected 5 documents indexed, got %d and %v", n, err)
+	}
+	if _, ok := index.docs["chain:c1"]; !ok || index.position != 3 {
+		t.Errorf("expected the chain indexed at position 3, got %v at %d", index.docs, index.position)
+	}
+
+	repos.Acts.Create(ctx, &models.Act{ID: "a3", Title: "Another act", GiverID: "u1"})
+	repos.Acts.Update(ctx, "a3", models.UpdateActRequest{Title: "Renamed act"})
+	repos.Acts.Delete(ctx, "a0")
+	puts := index.puts
+	if n, err := indexer.Run(ctx); err != nil || n != 2 {
+		t.Fatalf("expected 2 documents updated, got %d and %v", n, err)
+	}
+	if index.puts-puts != 1 || index.docs["act:a3"].Title != "Renamed act" {
+		t.Errorf("expected a3 put once with its new title, got %d puts and %+v", index.puts-puts, index.docs["act:a3"])
+	}
+	if _, ok := index.docs["act:a0"]; ok {
+		t.Error("expected the deleted act removed from the index")
+	}
+	if n, _ := indexer.Run(ctx); n != 0 {
+		t.Errorf("expected nothing left to update, got %d", n)
+	}
+
+	// Erasing a user redacts their acts in the index too
+	if _, err := repos.Erasure.Erase(ctx, "u1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := indexer.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for id, doc := range index.docs {
+		if strings.HasPrefix(id, "act:") && doc.Title != repository.ErasedActTitle {
+			t.Errorf("expected %s redacted, got %q", id, doc.Title)
+		}
+	}
+}
+
+// fakeElasticsearch serves the part of the Elasticsearch API the driver
+// uses, storing documents in memory
+type fakeElasticsearch struct {
+	mu      sync.Mutex
+	created bool
+	docs    map[string]json.RawMessage
+	query   map[string]any
+}
+
+func (f *fakeElasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if user, pass, _ := r.BasicAuth(); user != "elastic" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodHead && r.URL.Path == "/pf":
+		if !f.created {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/pf":
+		f.created = true
+	case r.URL.Path == "/pf/_bulk":
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action map[string]struct {
+				ID string `json:"_id"`
+			}
+			json.Unmarshal(scanner.Bytes(), &action)
+			if a, ok := action["index"]; ok {
+				scanner.Scan()
+				f.docs[a.ID] = json.RawMessage(slices.Clone(scanner.Bytes()))
+			} else {
+				delete(f.docs, action["delete"].ID)
+			}
+		}
+		fmt.Fprint(w, `{"errors": false, "items": []}`)
+	case r.URL.Path == "/pf/_doc/meta:position":
+		if r.Method == http.MethodPut {
+			var doc json.RawMessage
+			json.NewDecoder(r.Body).Decode(&doc)
+			f.docs[positionID] = doc
+			return
+		}
+		doc, ok := f.docs[positionID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"_source": %s}`, doc)
+	case r.URL.Path == "/pf/_search":
+		json.NewDecoder(r.Body).Decode(&f.query)
+		var hits []string
+		for id, doc := range f.docs {
+			if strings.HasPrefix(id, "act:") {
+				hits = append(hits, fmt.Sprintf(`{"_score": 1.5, "_source": %s}`, doc))
+			}
+		}
+		fmt.Fprintf(w, `{"hits": {"total": {"value": %d}, "hits": [%s]}}`, len(hits), strings.Join(hits, ","))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestElasticsearch(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeElasticsearch{docs: map[string]json.RawMessage{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	index, err := NewIndex(Config{Driver: "elasticsearch", ElasticsearchURL: server.URL,
+		ElasticsearchIndex: "pf", ElasticsearchUsername: "elastic", ElasticsearchPassword: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Setup(ctx); err != nil || !fake.created {
+		t.Fatalf("expected the index created, got %v", err)
+	}
+	if err := index.Setup(ctx); err != nil {
+		t.Errorf("expected an existing index kept, got %v", err)
+	}
+	if _, built, err := index.Position(ctx); err != nil || built {
+		t.Errorf("expected a new index not built, got %v and %v", built, err)
+	}
+
+	err = index.Put(ctx, []models.SearchDocument{
+		{Kind: "act", ID: "a1", Title: "Shared groceries"},
+		{Kind: "user", ID: "u1", Title: "Ada"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Delete(ctx, "user", []string{"u1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.SetPosition(ctx, 7); err != nil {
+		t.Fatal(err)
+	}
+	if position, built, err := index.Position(ctx); err != nil || !built || position != 7 {
+		t.Errorf("expected position 7, got %d, %v and %v", position, built, err)
+	}
+	if _, ok := fake.docs["user:u1"]; ok {
+		t.Error("expected the user deleted")
+	}
+
+	hits, total, err := index.Search(ctx, Query{Text: "groceries", Kinds: []string{"act"}, Page: models.PaginationParams{Page: 2, PerPage: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(hits) != 1 || hits[0].ID != "a1" || hits[0].Kind != "act" || hits[0].Score != 1.5 {
+		t.Errorf("expected the act, got %d and %+v", total, hits)
+	}
+	if from := fake.query["from"]; from != float64(10) {
+		t.Errorf("expected the second page from 10, got %v", from)
+	}
+
+	if _, err := NewIndex(Config{Driver: "bleve"}); err == nil {
+		t.Error("expected an unknown driver rejected")
+	}
+	if index, err := NewIndex(Config{}); err != nil || index != nil {
+		t.Errorf("expected no index for the neo4j default, got %v and %v", index, err)
+	}
+}
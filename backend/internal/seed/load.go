@@ -0,0 +1,126 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/queries"
+	"payforwardnow/internal/repository"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// batchSize is the number of rows written per UNWIND query
+const batchSize = repository.BatchSize
+
+// Load writes d to the database. Nodes are merged on their IDs, so loading
+// the same dataset again refreshes it rather than duplicating it. Users and
+// acts go through the repositories' batch methods; the rest of the graph has
+// no repository equivalent and is written here.
+func Load(ctx context.Context, db database.DBClient, d *Dataset) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	users := make([]repository.BatchUser, len(d.Users))
+	for i, u := range d.Users {
+		users[i] = repository.BatchUser{User: u, PasswordHash: string(hash)}
+	}
+	if _, err := repository.NewNeo4jUsers(db).CreateBatch(ctx, users); err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+	if _, err := repository.NewNeo4jActs(db).CreateBatch(ctx, d.Acts); err != nil {
+		return fmt.Errorf("failed to load acts: %w", err)
+	}
+
+	steps := []struct {
+		name  string
+		query *queries.Query
+		rows  []map[string]interface{}
+	}{
+		{"chains", queries.SeedChains, chainRows(d)},
+		{"testimonials", queries.SeedTestimonials, testimonialRows(d)},
+		{"reactions", queries.SeedReactions, reactionRows(d)},
+	}
+
+	for _, step := range steps {
+		for start := 0; start < len(step.rows); start += batchSize {
+			end := min(start+batchSize, len(step.rows))
+			batch := step.rows[start:end]
+
+			_, err := db.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+				result, err := step.query.Run(ctx, tx, map[string]interface{}{"rows": batch})
+				if err != nil {
+					return nil, err
+				}
+				_, err = result.Consume(ctx)
+				return nil, err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to load %s: %w", step.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func chainRows(d *Dataset) []map[string]interface{} {
+	rows := make([]map[string]interface{}, len(d.Chains))
+	for i, c := range d.Chains {
+		rows[i] = map[string]interface{}{
+			"chain": map[string]interface{}{
+				"id":          c.ID,
+				"name":        c.Name,
+				"description": c.Description,
+				"starterId":   c.StarterID,
+				"actsCount":   c.ActsCount,
+				"totalValue":  c.TotalValue,
+				"reach":       c.Reach,
+				"createdAt":   c.CreatedAt,
+				"updatedAt":   c.UpdatedAt,
+			},
+			"actIds":         c.ActIDs,
+			"participantIds": c.ParticipantIDs,
+		}
+	}
+	return rows
+}
+
+func testimonialRows(d *Dataset) []map[string]interface{} {
+	rows := make([]map[string]interface{}, len(d.Testimonials))
+	for i, t := range d.Testimonials {
+		row := map[string]interface{}{
+			"id":            t.ID,
+			"userId":        t.UserID,
+			"story":         t.Story,
+			"impact":        t.Impact,
+			"isApproved":    t.IsApproved,
+			"isFeatured":    t.IsFeatured,
+			"reactionCount": t.ReactionCount,
+			"createdAt":     t.CreatedAt,
+		}
+		if t.FeaturedOrder != nil {
+			row["featuredOrder"] = *t.FeaturedOrder
+		}
+		if t.IsApproved {
+			row["reviewedAt"] = t.CreatedAt
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+func reactionRows(d *Dataset) []map[string]interface{} {
+	rows := make([]map[string]interface{}, len(d.Reactions))
+	for i, r := range d.Reactions {
+		rows[i] = map[string]interface{}{
+			"userId":        r.UserID,
+			"testimonialId": r.TestimonialID,
+			"createdAt":     r.CreatedAt,
+		}
+	}
+	return rows
+}
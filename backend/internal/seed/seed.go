@@ -0,0 +1,362 @@
+// Package seed generates realistic, linked fixture data for local
+// development and demo environments. Generation is deterministic for a given
+// seed, including IDs, so loading the same dataset twice updates the
+// existing nodes instead of duplicating them.
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"payforwardnow/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Password is the password of every seeded user
+const Password = "payforward-demo"
+
+// Epoch anchors the timestamps of the default dataset, so the same seed
+// generates the same data whenever it runs
+var Epoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Config controls the size and shape of a generated dataset
+type Config struct {
+	// Seed makes generation reproducible
+	Seed         int64
+	Users        int
+	Acts         int
+	Chains       int
+	Testimonials int
+	// Now anchors generated timestamps, which spread over the preceding year
+	Now time.Time
+}
+
+// DefaultConfig returns a small dataset suitable for local development
+func DefaultConfig() Config {
+	return Config{
+		Seed:         1,
+		Users:        50,
+		Acts:         200,
+		Chains:       12,
+		Testimonials: 40,
+		Now:          Epoch,
+	}
+}
+
+// Chain is a seeded chain with the acts it contains and its participants
+type Chain struct {
+	models.Chain
+	ActIDs         []string
+	ParticipantIDs []string
+}
+
+// Reaction is a user's "this moved me" reaction to a testimonial
+type Reaction struct {
+	UserID        string
+	TestimonialID string
+	CreatedAt     time.Time
+}
+
+// Dataset is a generated set of linked fixtures
+type Dataset struct {
+	Users        []models.User
+	Acts         []models.Act
+	Chains       []Chain
+	Testimonials []models.Testimonial
+	Reactions    []Reaction
+}
+
+var (
+	firstNames = []string{
+		"Amara", "Luca", "Sofia", "Mateo", "Aiko", "Noah", "Fatima", "Elias", "Priya", "Jonas",
+		"Chiara", "Kwame", "Ingrid", "Diego", "Leila", "Oskar", "Mei", "Samuel", "Nadia", "Tomás",
+	}
+	lastNames = []string{
+		"Rossi", "Okafor", "Nakamura", "García", "Schmidt", "Haddad", "Kowalski", "Silva", "Patel", "Larsen",
+		"Bianchi", "Mensah", "Novak", "Fernández", "Ahmed", "Lindqvist", "Chen", "Dubois", "Moreau", "Costa",
+	}
+	locations = []string{
+		"Rome, Italy", "Lisbon, Portugal", "Berlin, Germany", "Accra, Ghana", "Osaka, Japan",
+		"Toronto, Canada", "Mexico City, Mexico", "Nairobi, Kenya", "Melbourne, Australia", "Oslo, Norway",
+	}
+	bios = []string{
+		"Weekend volunteer at the community garden.",
+		"Retired teacher who still loves explaining fractions.",
+		"Software engineer, amateur baker, believer in small kindnesses.",
+		"Nurse on night shifts, cyclist on days off.",
+		"Student trying to leave every place a bit better.",
+		"",
+	}
+
+	// acts holds title and description templates for each act type, with
+	// the categories they belong to
+	acts = map[models.ActType][]struct {
+		title, description, category string
+	}{
+		models.ActTypeMonetary: {
+			{"Covered a stranger's groceries", "The card of the person ahead of me was declined, so I paid for their shopping and told them to pass it on.", "food"},
+			{"Paid a month of bus passes", "Bought monthly bus passes for two students who walk an hour to school.", "education"},
+			{"Funded a neighbour's vet bill", "Their old dog needed surgery they couldn't afford, so I covered the difference.", "animals"},
+		},
+		models.ActTypeService: {
+			{"Fixed a neighbour's bicycle", "Replaced the chain and brake pads so they can ride to work again.", "community"},
+			{"Cleaned up the riverside path", "Spent the morning collecting litter along the river with a few friends.", "environment"},
+			{"Drove a patient to hospital", "Gave a lift to an elderly neighbour with a morning appointment across town.", "health"},
+		},
+		models.ActTypeGoods: {
+			{"Donated winter coats", "Gave three warm coats my kids outgrew to the shelter before the cold set in.", "community"},
+			{"Shared seedlings from my garden", "Potted tomato and basil seedlings for anyone on the street who wanted them.", "environment"},
+			{"Dropped off school supplies", "Filled ten backpacks with notebooks and pens for the local primary school.", "education"},
+		},
+		models.ActTypeMentoring: {
+			{"Helped with a CV rewrite", "Spent an evening reworking a CV with a friend returning to work after years away.", "career"},
+			{"Tutored maths after school", "Two afternoons a week of algebra practice with a student preparing for exams.", "education"},
+			{"Taught a coding workshop", "Ran a beginner's workshop on building a first website at the library.", "education"},
+		},
+		models.ActTypeOther: {
+			{"Wrote thank-you notes to nurses", "Left handwritten notes for the night shift on the ward that cared for my father.", "health"},
+			{"Organised a street picnic", "Invited the whole street for a shared lunch so neighbours could finally meet.", "community"},
+		},
+	}
+	actTypes = []models.ActType{
+		models.ActTypeMonetary, models.ActTypeService, models.ActTypeGoods, models.ActTypeMentoring, models.ActTypeOther,
+	}
+
+	chainThemes = []string{
+		"Coffee Forward", "Books for Every Kid", "Winter Warmth", "Green Streets",
+		"Rides to Recovery", "Code Club", "Seeds of Kindness", "Meals on the Corner",
+	}
+
+	stories = []string{
+		"A stranger paid for my groceries on a day when everything had gone wrong. I cried in the car park, then paid for the coffee of the person behind me the next morning.",
+		"After losing my job I had no idea how to present myself. A volunteer spent two evenings on my CV and I started my new position last month.",
+		"My daughter's bike was stolen and a neighbour fixed up an old one for her. She now rides to school with the neighbour's son every day.",
+		"When my father was in hospital, someone drove me there every morning for a week. I still don't know their surname but I think of them often.",
+		"Our street had lived side by side for years without talking. One picnic later, we share tools, recipes and babysitting.",
+	}
+	impacts = []string{
+		"It restored my faith in people.",
+		"I passed it on to three others.",
+		"It changed how I treat strangers.",
+		"My family got through a hard winter.",
+		"I started volunteering myself.",
+	}
+)
+
+// Generate builds a dataset from cfg. The same config always produces the
+// same dataset.
+func Generate(cfg Config) *Dataset {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	g := &generator{rng: rng, now: cfg.Now}
+
+	d := &Dataset{}
+	d.Users = g.users(cfg.Users)
+	if len(d.Users) == 0 {
+		return d
+	}
+	d.Acts = g.acts(cfg.Acts, d.Users)
+	d.Chains = g.chains(cfg.Chains, d.Users, d.Acts)
+	d.Testimonials = g.testimonials(cfg.Testimonials, d.Users)
+	d.Reactions = g.reactions(d.Users, d.Testimonials)
+	return d
+}
+
+type generator struct {
+	rng *rand.Rand
+	now time.Time
+}
+
+// id returns a random UUID drawn from the seeded source
+func (g *generator) id() string {
+	id, err := uuid.NewRandomFromReader(g.rng)
+	if err != nil {
+		panic(err) // math/rand never fails to read
+	}
+	return id.String()
+}
+
+// within returns a time up to d before now
+func (g *generator) within(d time.Duration) time.Time {
+	return g.now.Add(-time.Duration(g.rng.Int63n(int64(d))))
+}
+
+// after returns a time between t and now
+func (g *generator) after(t time.Time) time.Time {
+	span := g.now.Sub(t)
+	if span <= 0 {
+		return t
+	}
+	return t.Add(time.Duration(g.rng.Int63n(int64(span))))
+}
+
+func pick[T any](rng *rand.Rand, items []T) T {
+	return items[rng.Intn(len(items))]
+}
+
+func (g *generator) users(n int) []models.User {
+	const year = 365 * 24 * time.Hour
+
+	users := make([]models.User, n)
+	for i := range users {
+		first, last := pick(g.rng, firstNames), pick(g.rng, lastNames)
+		createdAt := g.within(year)
+		users[i] = models.User{
+			ID:         g.id(),
+			Email:      fmt.Sprintf("%s.%s%d@example.com", strings.ToLower(asciiFold(first)), strings.ToLower(asciiFold(last)), i+1),
+			Name:       first + " " + last,
+			Bio:        pick(g.rng, bios),
+			Location:   pick(g.rng, locations),
+			IsVerified: g.rng.Intn(10) < 8,
+			CreatedAt:  createdAt,
+			UpdatedAt:  g.after(createdAt),
+
+			HideFromLeaderboards: g.rng.Intn(20) == 0,
+		}
+	}
+	return users
+}
+
+func (g *generator) acts(n int, users []models.User) []models.Act {
+	statuses := []models.ActStatus{
+		models.ActStatusCompleted, models.ActStatusCompleted, models.ActStatusCompleted,
+		models.ActStatusAccepted, models.ActStatusPending, models.ActStatusCancelled,
+	}
+
+	result := make([]models.Act, n)
+	for i := range result {
+		actType := pick(g.rng, actTypes)
+		template := pick(g.rng, acts[actType])
+		giver := pick(g.rng, users)
+		createdAt := g.after(giver.CreatedAt)
+
+		act := models.Act{
+			ID:          g.id(),
+			Title:       template.title,
+			Description: template.description,
+			Type:        actType,
+			Category:    template.category,
+			Status:      pick(g.rng, statuses),
+			GiverID:     giver.ID,
+			Location:    giver.Location,
+			IsAnonymous: g.rng.Intn(10) == 0,
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		}
+		if actType == models.ActTypeMonetary {
+			act.Value = float64(5+g.rng.Intn(200)) + float64(g.rng.Intn(100))/100
+			act.Currency = "EUR"
+		}
+		if len(users) > 1 && g.rng.Intn(4) != 0 {
+			receiver := pick(g.rng, users)
+			for receiver.ID == giver.ID {
+				receiver = pick(g.rng, users)
+			}
+			act.ReceiverID = receiver.ID
+		}
+		if act.Status == models.ActStatusCompleted {
+			completedAt := g.after(createdAt)
+			act.CompletedAt = &completedAt
+			act.UpdatedAt = completedAt
+		}
+		result[i] = act
+	}
+	return result
+}
+
+// chains links acts into chains where each act's receiver tends to become
+// the next giver, as in a real pay-it-forward chain
+func (g *generator) chains(n int, users []models.User, acts []models.Act) []Chain {
+	if len(acts) == 0 {
+		return nil
+	}
+
+	chains := make([]Chain, n)
+	for i := range chains {
+		starter := pick(g.rng, users)
+		createdAt := g.after(starter.CreatedAt)
+		theme := chainThemes[i%len(chainThemes)]
+		if i >= len(chainThemes) {
+			theme = fmt.Sprintf("%s #%d", theme, i/len(chainThemes)+1)
+		}
+
+		c := Chain{Chain: models.Chain{
+			ID:          g.id(),
+			Name:        theme,
+			Description: "A chain of kindness started by " + starter.Name + ".",
+			StarterID:   starter.ID,
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		}}
+
+		participants := map[string]bool{starter.ID: true}
+		c.ParticipantIDs = append(c.ParticipantIDs, starter.ID)
+		length := min(2+g.rng.Intn(6), len(acts))
+		for _, j := range g.rng.Perm(len(acts))[:length] {
+			act := acts[j]
+			c.ActIDs = append(c.ActIDs, act.ID)
+			c.ActsCount++
+			c.TotalValue += act.Value
+			for _, id := range []string{act.GiverID, act.ReceiverID} {
+				if id != "" && !participants[id] {
+					participants[id] = true
+					c.ParticipantIDs = append(c.ParticipantIDs, id)
+				}
+			}
+		}
+		c.Reach = len(c.ParticipantIDs)
+		chains[i] = c
+	}
+	return chains
+}
+
+func (g *generator) testimonials(n int, users []models.User) []models.Testimonial {
+	testimonials := make([]models.Testimonial, n)
+	featured := 0
+	for i := range testimonials {
+		author := pick(g.rng, users)
+		t := models.Testimonial{
+			ID:         g.id(),
+			UserID:     author.ID,
+			Story:      pick(g.rng, stories),
+			Impact:     pick(g.rng, impacts),
+			IsApproved: g.rng.Intn(4) != 0,
+			CreatedAt:  g.after(author.CreatedAt),
+		}
+		if t.IsApproved && featured < 5 && g.rng.Intn(3) == 0 {
+			t.IsFeatured = true
+			order := featured
+			t.FeaturedOrder = &order
+			featured++
+		}
+		testimonials[i] = t
+	}
+	return testimonials
+}
+
+func (g *generator) reactions(users []models.User, testimonials []models.Testimonial) []Reaction {
+	var reactions []Reaction
+	for i := range testimonials {
+		t := &testimonials[i]
+		if !t.IsApproved {
+			continue
+		}
+		for _, j := range g.rng.Perm(len(users))[:g.rng.Intn(len(users)/4+1)] {
+			reactions = append(reactions, Reaction{
+				UserID:        users[j].ID,
+				TestimonialID: t.ID,
+				CreatedAt:     g.after(t.CreatedAt),
+			})
+			t.ReactionCount++
+		}
+	}
+	return reactions
+}
+
+// asciiFold drops accents from the few accented letters in the name lists so
+// they can be used in email addresses
+func asciiFold(s string) string {
+	return strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ñ", "n").Replace(s)
+}
@@ -0,0 +1,102 @@
+package seed
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testConfig(seed int64) Config {
+	cfg := DefaultConfig()
+	cfg.Seed = seed
+	cfg.Now = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	return cfg
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	a := Generate(testConfig(42))
+	b := Generate(testConfig(42))
+	if !reflect.DeepEqual(a, b) {
+		t.Error("expected the same seed to generate the same dataset")
+	}
+
+	c := Generate(testConfig(43))
+	if a.Users[0].ID == c.Users[0].ID {
+		t.Error("expected a different seed to generate different IDs")
+	}
+}
+
+func TestDefaultConfig_Reproducible(t *testing.T) {
+	a := Generate(DefaultConfig())
+	if b := Generate(DefaultConfig()); !reflect.DeepEqual(a, b) {
+		t.Error("expected the default config to generate the same dataset on every run")
+	}
+}
+
+func TestGenerate_Linked(t *testing.T) {
+	cfg := testConfig(7)
+	d := Generate(cfg)
+
+	if len(d.Users) != cfg.Users || len(d.Acts) != cfg.Acts || len(d.Chains) != cfg.Chains || len(d.Testimonials) != cfg.Testimonials {
+		t.Fatalf("unexpected sizes: %d users, %d acts, %d chains, %d testimonials",
+			len(d.Users), len(d.Acts), len(d.Chains), len(d.Testimonials))
+	}
+
+	users := make(map[string]bool)
+	emails := make(map[string]bool)
+	for _, u := range d.Users {
+		users[u.ID] = true
+		if emails[u.Email] {
+			t.Errorf("duplicate email %s", u.Email)
+		}
+		emails[u.Email] = true
+	}
+
+	acts := make(map[string]bool)
+	for _, a := range d.Acts {
+		acts[a.ID] = true
+		if !users[a.GiverID] {
+			t.Errorf("act %s has unknown giver", a.ID)
+		}
+		if a.ReceiverID != "" && (!users[a.ReceiverID] || a.ReceiverID == a.GiverID) {
+			t.Errorf("act %s has invalid receiver", a.ID)
+		}
+		if a.CreatedAt.After(cfg.Now) {
+			t.Errorf("act %s created in the future", a.ID)
+		}
+	}
+
+	for _, c := range d.Chains {
+		if c.ActsCount != len(c.ActIDs) || c.Reach != len(c.ParticipantIDs) {
+			t.Errorf("chain %s counts don't match its links", c.ID)
+		}
+		for _, id := range c.ActIDs {
+			if !acts[id] {
+				t.Errorf("chain %s contains unknown act", c.ID)
+			}
+		}
+	}
+
+	approved := make(map[string]bool)
+	for _, tm := range d.Testimonials {
+		if !users[tm.UserID] {
+			t.Errorf("testimonial %s has unknown author", tm.ID)
+		}
+		if tm.IsFeatured && !tm.IsApproved {
+			t.Errorf("testimonial %s is featured but not approved", tm.ID)
+		}
+		approved[tm.ID] = tm.IsApproved
+	}
+	for _, r := range d.Reactions {
+		if !approved[r.TestimonialID] {
+			t.Errorf("reaction to unapproved testimonial %s", r.TestimonialID)
+		}
+	}
+}
+
+func TestGenerate_Empty(t *testing.T) {
+	d := Generate(Config{Seed: 1, Acts: 10, Chains: 2, Testimonials: 3, Now: time.Now()})
+	if len(d.Acts) != 0 || len(d.Chains) != 0 || len(d.Testimonials) != 0 {
+		t.Error("expected no fixtures without users")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package socket
+
+import (
+	"errors"
+	"syscall"
+)
+
+func setReusePort(network, address string, conn syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this platform")
+}
+
+func closeOnExec(fd int) {}
@@ -0,0 +1,101 @@
+// Package socket opens the server's listening sockets so a new process can
+// take over from a running one without refusing connections: either the
+// sockets are held by the service manager and passed to each process
+// (systemd socket activation), or each process opens its own with
+// SO_REUSEPORT and the old one stops accepting once the new one is up.
+package socket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor systemd passes
+const listenFDsStart = 3
+
+// Listeners are the sockets passed in by the service manager, keyed by the
+// names given to them with FileDescriptorName=
+type Listeners map[string]net.Listener
+
+// Inherited returns the sockets systemd passed to this process, if any,
+// and clears the variables describing them so child processes don't
+// mistake them for their own
+func Inherited() (Listeners, error) {
+	pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if pid != strconv.Itoa(os.Getpid()) {
+		return Listeners{}, nil
+	}
+	return inherit(fds, names, listenFDsStart)
+}
+
+// inherit wraps n (from fds) file descriptors starting at first in
+// listeners named after names, a colon-separated list
+func inherit(fds, names string, first int) (Listeners, error) {
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", fds)
+	}
+	nameList := strings.Split(names, ":")
+	if len(nameList) != n {
+		return nil, fmt.Errorf("LISTEN_FDNAMES names %d sockets, LISTEN_FDS %d; name each one with FileDescriptorName=", len(nameList), n)
+	}
+
+	listeners := make(Listeners, n)
+	for i, name := range nameList {
+		fd := first + i
+		closeOnExec(fd)
+		f := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(f)
+		// FileListener holds a copy of the descriptor
+		f.Close()
+		if err != nil {
+			listeners.Close()
+			return nil, fmt.Errorf("socket %s is not a listening socket: %w", name, err)
+		}
+		if _, ok := listeners[name]; ok {
+			l.Close()
+			listeners.Close()
+			return nil, fmt.Errorf("more than one socket is named %s", name)
+		}
+		listeners[name] = l
+	}
+	return listeners, nil
+}
+
+// Take returns the listener called name and forgets it, or nil if there is
+// none
+func (ls Listeners) Take(name string) net.Listener {
+	l := ls[name]
+	delete(ls, name)
+	return l
+}
+
+// Close closes the listeners that weren't taken
+func (ls Listeners) Close() error {
+	var errs []error
+	for name, l := range ls {
+		errs = append(errs, l.Close())
+		delete(ls, name)
+	}
+	return errors.Join(errs...)
+}
+
+// Listen listens on the TCP address addr. With reusePort the socket is
+// opened with SO_REUSEPORT, so a new process can listen on the same port
+// while this one finishes its requests; the kernel spreads new connections
+// across both until this one closes its socket.
+func Listen(ctx context.Context, addr string, reusePort bool) (net.Listener, error) {
+	var lc net.ListenConfig
+	if reusePort {
+		lc.Control = setReusePort
+	}
+	return lc.Listen(ctx, "tcp", addr)
+}
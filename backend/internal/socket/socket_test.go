@@ -0,0 +1,72 @@
+package socket
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+)
+
+func TestListenReusePort(t *testing.T) {
+	ctx := context.Background()
+	first, err := Listen(ctx, "127.0.0.1:0", true)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer first.Close()
+
+	// A second process would listen on the same port during a deploy
+	second, err := Listen(ctx, first.Addr().String(), true)
+	if err != nil {
+		t.Fatalf("expected a second listener on the same port, got %v", err)
+	}
+	second.Close()
+
+	if l, err := Listen(ctx, first.Addr().String(), false); err == nil {
+		l.Close()
+		t.Error("expected the port to be taken without SO_REUSEPORT")
+	}
+}
+
+func TestInherit(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listeners, err := inherit("1", "http", int(f.Fd()))
+	if err != nil {
+		t.Fatalf("inherit: %v", err)
+	}
+	inherited := listeners.Take("http")
+	if inherited == nil || inherited.Addr().String() != l.Addr().String() {
+		t.Fatalf("expected the http socket on %s, got %v", l.Addr(), inherited)
+	}
+	inherited.Close()
+	if listeners.Take("http") != nil || len(listeners) != 0 {
+		t.Error("expected Take to forget the listener")
+	}
+
+	for _, tc := range []struct{ fds, names string }{
+		{"two", "http"},
+		{"2", "http"},
+	} {
+		if _, err := inherit(tc.fds, tc.names, 1000); err == nil {
+			t.Errorf("LISTEN_FDS=%s LISTEN_FDNAMES=%s: expected an error", tc.fds, tc.names)
+		}
+	}
+}
+
+func TestInheritedIgnoresOtherProcesses(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(1))
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, err := Inherited()
+	if err != nil || len(listeners) != 0 {
+		t.Errorf("Inherited = %v, %v; want no listeners", listeners, err)
+	}
+}
@@ -0,0 +1,74 @@
+package stream
+
+import (
+	"sync"
+)
+
+// Event is a message delivered to stream subscribers
+type Event struct {
+	Type string
+	// Topics are what the event is about, such as "chain:<id>", for
+	// subscribers that only want some events
+	Topics []string
+	Data   interface{}
+}
+
+// Broker fans out published events to all current subscribers. Slow
+// subscribers never block publishers: events are dropped for a subscriber
+// whose buffer is full.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[chan Event]struct{}
+	bufferSize  int
+}
+
+// NewBroker creates a new Broker with the given per-subscriber buffer size
+func NewBroker(bufferSize int) *Broker {
+	return &Broker{
+		subscribers: make(map[chan Event]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe registers a new subscriber and returns its event channel
+func (b *Broker) Subscribe() chan Event {
+	ch := make(chan Event, b.bufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (b *Broker) Unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Publish delivers an event to all subscribers
+func (b *Broker) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscriberCount returns the number of active subscribers
+func (b *Broker) SubscriberCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.subscribers)
+}
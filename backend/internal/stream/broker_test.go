@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"testing"
+)
+
+func TestBroker_PublishSubscribe(t *testing.T) {
+	broker := NewBroker(1)
+
+	ch1 := broker.Subscribe()
+	ch2 := broker.Subscribe()
+
+	if broker.SubscriberCount() != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", broker.SubscriberCount())
+	}
+
+	broker.Publish(Event{Type: "test", Data: 1})
+
+	for _, ch := range []chan Event{ch1, ch2} {
+		event := <-ch
+		if event.Type != "test" || event.Data != 1 {
+			t.Errorf("unexpected event %+v", event)
+		}
+	}
+
+	broker.Unsubscribe(ch1)
+	if _, open := <-ch1; open {
+		t.Error("expected channel to be closed after unsubscribe")
+	}
+	if broker.SubscriberCount() != 1 {
+		t.Errorf("expected 1 subscriber, got %d", broker.SubscriberCount())
+	}
+}
+
+func TestBroker_SlowSubscriberDoesNotBlock(t *testing.T) {
+	broker := NewBroker(1)
+	ch := broker.Subscribe()
+
+	broker.Publish(Event{Type: "first"})
+	broker.Publish(Event{Type: "dropped"})
+
+	event := <-ch
+	if event.Type != "first" {
+		t.Errorf("expected first event, got %s", event.Type)
+	}
+
+	select {
+	case event := <-ch:
+		t.Errorf("expected no buffered event, got %s", event.Type)
+	default:
+	}
+}
@@ -0,0 +1,179 @@
+// Package testinfra runs integration tests against a real Neo4j started in
+// a container with testcontainers. The database has the embedded migrations
+// applied, and fixtures can be loaded from seed datasets. Tests using it are
+// skipped in short mode and need Docker.
+package testinfra
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/database"
+	"payforwardnow/internal/migrations"
+	"payforwardnow/internal/queries"
+	"payforwardnow/internal/repository"
+	"payforwardnow/internal/seed"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Image is the Neo4j image tests run against
+const Image = "neo4j:5.15"
+
+// Credentials of the test database
+const (
+	User     = "neo4j"
+	Password = "testpassword"
+)
+
+// Neo4j is a migrated database in a container
+type Neo4j struct {
+	Client *database.Neo4jClient
+	// URI is the bolt address of the container, for tests that need their
+	// own client
+	URI string
+
+	container testcontainers.Container
+}
+
+// Start starts a database for t alone and removes it when t ends
+func Start(t testing.TB) *Neo4j {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	n, err := start(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to start Neo4j: %v", err)
+	}
+	t.Cleanup(n.terminate)
+	return n
+}
+
+var shared struct {
+	once sync.Once
+	db   *Neo4j
+	err  error
+}
+
+// Shared returns a database shared by every test in the package, started
+// on first use, which is much faster than a container per test. The data is
+// deleted when t ends, keeping the schema, so tests using it must not run in
+// parallel. The container is removed by the testcontainers reaper when the
+// test binary exits.
+func Shared(t testing.TB) *Neo4j {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	shared.once.Do(func() {
+		shared.db, shared.err = start(context.Background())
+	})
+	if shared.err != nil {
+		t.Fatalf("Failed to start Neo4j: %v", shared.err)
+	}
+	t.Cleanup(func() {
+		if err := shared.db.Reset(context.Background()); err != nil {
+			t.Errorf("Failed to reset the shared database: %v", err)
+		}
+	})
+	return shared.db
+}
+
+func start(ctx context.Context) (*Neo4j, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        Image,
+			ExposedPorts: []string{"7687/tcp"},
+			Env:          map[string]string{"NEO4J_AUTH": User + "/" + Password},
+			WaitingFor:   wait.ForLog("Started").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+	n := &Neo4j{container: container}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		n.terminate()
+		return nil, fmt.Errorf("failed to get container host: %w", err)
+	}
+	port, err := container.MappedPort(ctx, "7687")
+	if err != nil {
+		n.terminate()
+		return nil, fmt.Errorf("failed to get mapped port: %w", err)
+	}
+	n.URI = "bolt://" + host + ":" + port.Port()
+
+	// Bolt may accept connections a little after the log line, which the
+	// client's connectivity check retries through
+	cfg := database.DefaultClientConfig()
+	cfg.VerifyTimeout = 30 * time.Second
+	n.Client, err = database.NewNeo4jClientWithConfig(n.URI, User, Password, cfg)
+	if err != nil {
+		n.terminate()
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	schema, err := migrations.Embedded()
+	if err != nil {
+		n.terminate()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if _, err := migrations.New(n.Client, schema).Up(ctx); err != nil {
+		n.terminate()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return n, nil
+}
+
+func (n *Neo4j) terminate() {
+	if n.Client != nil {
+		n.Client.Close()
+	}
+	n.container.Terminate(context.Background())
+}
+
+// Reset deletes all data, keeping the schema and the record of applied
+// migrations
+func (n *Neo4j) Reset(ctx context.Context) error {
+	_, err := n.Client.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		result, err := queries.TestReset.Run(ctx, tx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return result.Consume(ctx)
+	})
+	return err
+}
+
+// Repositories returns the Neo4j repositories over the database
+func (n *Neo4j) Repositories() repository.Repositories {
+	return repository.NewNeo4j(n.Client)
+}
+
+// Load writes the fixtures of d, failing t on error. Every user signs in
+// with seed.Password.
+func (n *Neo4j) Load(t testing.TB, d *seed.Dataset) {
+	t.Helper()
+	if err := seed.Load(context.Background(), n.Client, d); err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+}
+
+// Seed generates a dataset from cfg, loads it and returns it
+func (n *Neo4j) Seed(t testing.TB, cfg seed.Config) *seed.Dataset {
+	t.Helper()
+	d := seed.Generate(cfg)
+	n.Load(t, d)
+	return d
+}
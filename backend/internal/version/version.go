@@ -0,0 +1,93 @@
+// Package version describes the build of the running binary. The release
+// build sets the variables below with -ldflags, as the Makefile does:
+//
+//	go build -ldflags "-X payforwardnow/internal/version.Version=v1.4.0 \
+//	    -X payforwardnow/internal/version.Commit=$(git rev-parse HEAD) \
+//	    -X payforwardnow/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Builds without them fall back to the VCS details the Go toolchain records.
+package version
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set at build time with -ldflags -X
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"buildTime,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+var (
+	info     Info
+	infoOnce sync.Once
+)
+
+// Get returns the build's details
+func Get() Info {
+	infoOnce.Do(func() {
+		info = Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+		build, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, s := range build.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	})
+	return info
+}
+
+// UserAgent is sent with the server's outbound requests, such as
+// "payforward/v1.4.0 (3f4cc12)"
+func UserAgent() string {
+	i := Get()
+	ua := "payforward/" + i.Version
+	if commit := i.Commit; commit != "" {
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		ua += " (" + commit + ")"
+	}
+	return ua
+}
+
+// transport sets the User-Agent of requests that don't have one
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps base, http.DefaultTransport when nil, so requests
+// without a User-Agent are sent with UserAgent
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		// A RoundTripper must not modify the request it was given
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", UserAgent())
+	}
+	return t.base.RoundTrip(req)
+}
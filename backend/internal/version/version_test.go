@@ -0,0 +1,35 @@
+package version
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransportSetsUserAgent(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewTransport(nil)}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("User-Agent") != "" {
+		t.Error("expected the caller's request to be left alone")
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("User-Agent", "custom")
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || !strings.HasPrefix(got[0], "payforward/"+Get().Version) || got[1] != "custom" {
+		t.Errorf("User-Agents = %q", got)
+	}
+}
@@ -0,0 +1,139 @@
+// Package web serves the single-page frontend, embedded in the binary at
+// build time or read from a directory, so small deployments can ship the
+// API and its UI as one program.
+//
+// The embedded copy is whatever `make embed-frontend` left in dist: the
+// frontend's production build. Binaries built without it serve no frontend.
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+//go:embed all:dist
+var dist embed.FS
+
+// Cache policies. Vite writes every bundled file to assets/ with a hash of
+// its content in the name, so those never change; anything else may change
+// with the next deployment and is revalidated using its ETag.
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+)
+
+// Embedded returns the frontend built into the binary, or nil when it was
+// built without one
+func Embedded() fs.FS {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil
+	}
+	if _, err := fs.Stat(sub, "index.html"); err != nil {
+		return nil
+	}
+	return sub
+}
+
+// file is a frontend file held in memory with the ETag of its content
+type file struct {
+	name         string
+	content      []byte
+	etag         string
+	cacheControl string
+}
+
+// Handler serves a built single-page app
+type Handler struct {
+	files    map[string]*file
+	index    *file
+	excluded []string
+}
+
+// NewHandler reads the files of fsys, which must contain index.html.
+// Requests for paths without a file are answered with index.html so the
+// frontend's router can handle them, unless they look like a missing file,
+// having an extension, or start with one of the excluded prefixes, such as
+// "/api/"; those get a 404.
+func NewHandler(fsys fs.FS, excluded ...string) (*Handler, error) {
+	h := &Handler{files: make(map[string]*file), excluded: excluded}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(content)
+		f := &file{
+			name:         name,
+			content:      content,
+			etag:         `"` + base64.RawURLEncoding.EncodeToString(sum[:12]) + `"`,
+			cacheControl: revalidateCacheControl,
+		}
+		if strings.HasPrefix(name, "assets/") {
+			f.cacheControl = immutableCacheControl
+		}
+		h.files["/"+name] = f
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the frontend: %w", err)
+	}
+
+	h.index = h.files["/index.html"]
+	if h.index == nil {
+		return nil, fmt.Errorf("the frontend has no index.html")
+	}
+	return h, nil
+}
+
+// ServeHTTP serves the file at the request's path or, failing that,
+// index.html
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+	f := h.files[name]
+	if f == nil && name == "/" {
+		f = h.index
+	}
+	if f == nil {
+		if !h.fallsBack(name) {
+			http.NotFound(w, r)
+			return
+		}
+		f = h.index
+	}
+
+	w.Header().Set("Cache-Control", f.cacheControl)
+	w.Header().Set("ETag", f.etag)
+	// ServeContent sets Content-Type from the extension and answers
+	// If-None-Match with 304
+	http.ServeContent(w, r, f.name, time.Time{}, bytes.NewReader(f.content))
+}
+
+// fallsBack reports whether a path without a file is a route of the
+// frontend, answered with index.html
+func (h *Handler) fallsBack(name string) bool {
+	for _, prefix := range h.excluded {
+		if strings.HasPrefix(name+"/", prefix) {
+			return false
+		}
+	}
+	return path.Ext(name) == ""
+}
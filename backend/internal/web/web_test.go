@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	h, err := NewHandler(fstest.MapFS{
+		"index.html":               {Data: []byte("<!doctype html><div id=root></div>")},
+		"favicon.svg":              {Data: []byte("<svg></svg>")},
+		"assets/index-Bx3k9aQ1.js": {Data: []byte("console.log(1)")},
+		".gitkeep":                 {},
+	}, "/api/", "/metrics")
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	return h
+}
+
+func TestHandler(t *testing.T) {
+	h := newTestHandler(t)
+
+	tests := []struct {
+		path         string
+		status       int
+		body         string
+		cacheControl string
+	}{
+		{"/", http.StatusOK, "id=root", revalidateCacheControl},
+		{"/index.html", http.StatusOK, "id=root", revalidateCacheControl},
+		{"/acts/42", http.StatusOK, "id=root", revalidateCacheControl},
+		{"/assets/index-Bx3k9aQ1.js", http.StatusOK, "console.log", immutableCacheControl},
+		{"/favicon.svg", http.StatusOK, "<svg>", revalidateCacheControl},
+		{"/assets/missing.js", http.StatusNotFound, "", ""},
+		{"/api/v1/unknown", http.StatusNotFound, "", ""},
+		{"/api", http.StatusNotFound, "", ""},
+		{"/metrics", http.StatusNotFound, "", ""},
+		{"/.gitkeep", http.StatusNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.status)
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), tt.body) {
+			t.Errorf("%s: body = %q, want it to contain %q", tt.path, rec.Body, tt.body)
+		}
+		if got := rec.Header().Get("Cache-Control"); got != tt.cacheControl {
+			t.Errorf("%s: Cache-Control = %q, want %q", tt.path, got, tt.cacheControl)
+		}
+	}
+}
+
+func TestHandler_ETag(t *testing.T) {
+	h := newTestHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/assets/index-Bx3k9aQ1.js", nil))
+	etag := rec.Header().Get("ETag")
+	if etag == "" || rec.Header().Get("Content-Type") != "text/javascript; charset=utf-8" {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/index-Bx3k9aQ1.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304 for a matching ETag", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rec.Code)
+	}
+}
+
+func TestNewHandler_RequiresIndex(t *testing.T) {
+	if _, err := NewHandler(fstest.MapFS{"app.js": {Data: []byte("x")}}); err == nil {
+		t.Error("expected an error without index.html")
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	// The repository only holds a placeholder; the build is embedded by
+	// make embed-frontend
+	if fsys := Embedded(); fsys != nil {
+		if _, err := NewHandler(fsys); err != nil {
+			t.Errorf("embedded frontend: %v", err)
+		}
+	}
+}
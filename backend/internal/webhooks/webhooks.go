@@ -0,0 +1,280 @@
+// Package webhooks delivers events to the URLs subscribed to them, as JSON
+// payloads signed with each subscription's secret
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/metrics"
+	"payforwardnow/internal/models"
+	"payforwardnow/internal/version"
+	"payforwardnow/internal/workers"
+
+	"github.com/google/uuid"
+)
+
+// Events webhooks can subscribe to
+const (
+	EventActCreated          = "act.created"
+	EventActCompleted        = "act.completed"
+	EventChainExtended       = "chain.extended"
+	EventTestimonialApproved = "testimonial.approved"
+)
+
+// Events lists every event webhooks can subscribe to
+var Events = []string{EventActCreated, EventActCompleted, EventChainExtended, EventTestimonialApproved}
+
+// Headers set on every delivery
+const (
+	// SignatureHeader carries the payload's signature, as made by Sign
+	SignatureHeader = "X-Payforward-Signature"
+	// EventHeader carries the event type
+	EventHeader = "X-Payforward-Event"
+	// DeliveryHeader carries the event ID, the same for every attempt, so
+	// receivers can ignore duplicates
+	DeliveryHeader = "X-Payforward-Delivery"
+)
+
+// Store is where subscriptions are read from and delivery attempts logged
+type Store interface {
+	// Subscribed returns the webhooks subscribed to event, with their
+	// secrets
+	Subscribed(ctx context.Context, event string) ([]models.Webhook, error)
+	// RecordDelivery adds an attempt to its webhook's delivery log
+	RecordDelivery(ctx context.Context, d models.WebhookDelivery) error
+}
+
+// Payload is the body of a delivery
+type Payload struct {
+	// ID identifies the event, the same for every webhook and attempt
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// Options tunes a Dispatcher
+type Options struct {
+	// MaxAttempts bounds the deliveries of an event to a webhook, the first
+	// included. Defaults to 5.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled before each of
+	// the next ones. Defaults to a second.
+	Backoff time.Duration
+	// Client sends the deliveries. Defaults to a client giving up after
+	// 10 seconds.
+	Client *http.Client
+}
+
+// Dispatcher delivers events to the webhooks subscribed to them, retrying
+// failed deliveries with exponential backoff
+type Dispatcher struct {
+	store   Store
+	pool    *workers.Pool
+	options Options
+}
+
+// New creates a Dispatcher reading subscriptions from store
+func New(store Store, options Options) *Dispatcher {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 5
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = time.Second
+	}
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: 10 * time.Second, Transport: version.NewTransport(nil)}
+	}
+	return &Dispatcher{store: store, options: options}
+}
+
+// SetPool sets the pool Emit queues deliveries on. Without one, each event
+// is delivered in its own goroutine.
+func (d *Dispatcher) SetPool(pool *workers.Pool) {
+	d.pool = pool
+}
+
+// NewSecret returns a random secret to sign a webhook's deliveries with
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature of body sent at t with secret, as
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">".
+// Signing the time along with the body lets receivers reject replays.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+// Verify checks that signature was made by Sign for body with secret no
+// more than tolerance before now
+func Verify(secret, signature string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			sig = value
+		}
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return errors.New("malformed signature")
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return errors.New("signature timestamp outside the tolerance")
+	}
+	expected, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, mac(secret, ts, body)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func mac(secret, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Emit queues the delivery of event with data to every webhook subscribed
+// to it and returns once it is queued. The deliveries keep ctx's values but
+// not its cancellation. Encoding errors and a full queue are returned;
+// delivery failures are logged, counted and kept in the delivery log.
+func (d *Dispatcher) Emit(ctx context.Context, event string, data any) error {
+	payload := Payload{ID: uuid.New().String(), Type: event, CreatedAt: time.Now().UTC(), Data: data}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding webhook payload: %w", err)
+	}
+
+	task := func(ctx context.Context) error {
+		hooks, err := d.store.Subscribed(ctx, event)
+		if err != nil {
+			return fmt.Errorf("listing webhooks: %w", err)
+		}
+		// Each webhook is delivered to concurrently, so a slow or failing
+		// receiver doesn't hold up the others
+		var wg sync.WaitGroup
+		for _, hook := range hooks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := d.Deliver(ctx, hook, payload.ID, event, body); err != nil {
+					slog.WarnContext(ctx, "Webhook delivery failed", "webhook_id", hook.ID, "event", event, "error", err)
+				}
+			}()
+		}
+		wg.Wait()
+		return nil
+	}
+	return d.pool.Go(ctx, "webhooks", task)
+}
+
+// Deliver posts body, the payload of event eventID, to hook now, signed
+// with its secret. Network errors, 5xx, 408 and 429 responses are retried
+// with exponential backoff up to the configured number of attempts; other
+// responses aren't. Every attempt is added to the delivery log.
+func (d *Dispatcher) Deliver(ctx context.Context, hook models.Webhook, eventID, event string, body []byte) error {
+	wait := d.options.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		retry, err = d.attempt(ctx, hook, eventID, event, body, attempt)
+		if err == nil {
+			metrics.WebhookDeliveries.WithLabelValues(event, "delivered").Inc()
+			return nil
+		}
+		if !retry || attempt == d.options.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			metrics.WebhookDeliveries.WithLabelValues(event, "failed").Inc()
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+	metrics.WebhookDeliveries.WithLabelValues(event, "failed").Inc()
+	return err
+}
+
+// attempt posts body to hook once and logs the attempt. It reports whether
+// a failure may be retried.
+func (d *Dispatcher) attempt(ctx context.Context, hook models.Webhook, eventID, event string, body []byte, attempt int) (bool, error) {
+	start := time.Now()
+	delivery := models.WebhookDelivery{
+		ID:        uuid.New().String(),
+		WebhookID: hook.ID,
+		EventID:   eventID,
+		Event:     event,
+		Attempt:   attempt,
+		CreatedAt: start.UTC(),
+	}
+
+	retry, err := d.post(ctx, hook, eventID, event, body, &delivery)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	delivery.Succeeded = err == nil
+	if err != nil {
+		delivery.Error = err.Error()
+		metrics.WebhookAttempts.WithLabelValues(event, "error").Inc()
+	} else {
+		metrics.WebhookAttempts.WithLabelValues(event, "ok").Inc()
+	}
+	if lerr := d.store.RecordDelivery(ctx, delivery); lerr != nil {
+		slog.WarnContext(ctx, "Failed to log a webhook delivery", "webhook_id", hook.ID, "error", lerr)
+	}
+	return retry, err
+}
+
+func (d *Dispatcher) post(ctx context.Context, hook models.Webhook, eventID, event string, body []byte, delivery *models.WebhookDelivery) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, eventID)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, time.Now(), body))
+
+	resp, err := d.options.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("status %d", resp.StatusCode)
+}
@@ -0,0 +1,157 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"payforwardnow/internal/models"
+)
+
+// memoryStore is a Store holding its webhooks and delivery log in memory
+type memoryStore struct {
+	mu         sync.Mutex
+	hooks      []models.Webhook
+	deliveries []models.WebhookDelivery
+}
+
+func (s *memoryStore) Subscribed(_ context.Context, event string) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	for _, hook := range s.hooks {
+		for _, e := range hook.Events {
+			if e == event {
+				hooks = append(hooks, hook)
+			}
+		}
+	}
+	return hooks, nil
+}
+
+func (s *memoryStore) RecordDelivery(_ context.Context, d models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, d)
+	return nil
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"e1"}`)
+	signature := Sign("secret", now, body)
+
+	if err := Verify("secret", signature, body, 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Errorf("expected the signature to verify, got %v", err)
+	}
+	for name, check := range map[string]func() error{
+		"wrong secret": func() error { return Verify("other", signature, body, 5*time.Minute, now) },
+		"altered body": func() error { return Verify("secret", signature, []byte(`{"id":"e2"}`), 5*time.Minute, now) },
+		"too old":      func() error { return Verify("secret", signature, body, 5*time.Minute, now.Add(time.Hour)) },
+		"malformed":    func() error { return Verify("secret", "v1=abc", body, 5*time.Minute, now) },
+	} {
+		if check() == nil {
+			t.Errorf("%s: expected the signature to be rejected", name)
+		}
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	var mu sync.Mutex
+	var statuses = []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}
+	var received []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(statuses[len(received)-1])
+	}))
+	defer server.Close()
+
+	store := &memoryStore{}
+	d := New(store, Options{Backoff: time.Millisecond})
+	hook := models.Webhook{ID: "w1", URL: server.URL, Secret: "secret"}
+	body := []byte(`{"id":"e1","type":"act.created"}`)
+	if err := d.Deliver(context.Background(), hook, "e1", EventActCreated, body); err != nil {
+		t.Fatalf("expected the third attempt to succeed, got %v", err)
+	}
+
+	if len(received) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(received))
+	}
+	for i, r := range received {
+		if r.Header.Get(DeliveryHeader) != "e1" || r.Header.Get(EventHeader) != EventActCreated {
+			t.Errorf("attempt %d: unexpected headers %v", i+1, r.Header)
+		}
+		if err := Verify("secret", r.Header.Get(SignatureHeader), bodies[i], time.Minute, time.Now()); err != nil {
+			t.Errorf("attempt %d: %v", i+1, err)
+		}
+	}
+	if len(store.deliveries) != 3 {
+		t.Fatalf("expected 3 logged attempts, got %d", len(store.deliveries))
+	}
+	for i, delivery := range store.deliveries {
+		succeeded := i == 2
+		if delivery.Attempt != i+1 || delivery.StatusCode != statuses[i] || delivery.Succeeded != succeeded {
+			t.Errorf("unexpected delivery log entry %+v", delivery)
+		}
+	}
+}
+
+func TestDeliverDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	store := &memoryStore{}
+	d := New(store, Options{Backoff: time.Millisecond})
+	err := d.Deliver(context.Background(), models.Webhook{ID: "w1", URL: server.URL}, "e1", EventActCreated, []byte(`{}`))
+	if err == nil || attempts != 1 {
+		t.Errorf("expected a single failed attempt, got %d attempts and %v", attempts, err)
+	}
+	if len(store.deliveries) != 1 || store.deliveries[0].Error == "" {
+		t.Errorf("expected the failure to be logged, got %+v", store.deliveries)
+	}
+}
+
+func TestEmit(t *testing.T) {
+	payloads := make(chan Payload, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p Payload
+		json.NewDecoder(r.Body).Decode(&p)
+		payloads <- p
+	}))
+	defer server.Close()
+
+	store := &memoryStore{hooks: []models.Webhook{
+		{ID: "w1", URL: server.URL, Events: []string{EventActCreated}},
+		{ID: "w2", URL: server.URL, Events: []string{EventActCompleted}},
+	}}
+	d := New(store, Options{})
+	if err := d.Emit(context.Background(), EventActCompleted, map[string]string{"id": "a1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case p := <-payloads:
+		if p.Type != EventActCompleted || p.ID == "" || p.Data.(map[string]any)["id"] != "a1" {
+			t.Errorf("unexpected payload %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the subscribed webhook to be called")
+	}
+	select {
+	case p := <-payloads:
+		t.Errorf("expected only the subscribed webhook to be called, got %+v", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
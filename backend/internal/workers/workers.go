@@ -0,0 +1,189 @@
+// Package workers runs background tasks, such as storing audit events or
+// building heavy reports, on a bounded pool of goroutines instead of one
+// goroutine per task, so a burst of requests can't start an unbounded
+// number of them.
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"payforwardnow/internal/metrics"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when every worker is busy and the
+	// queue has no room left
+	ErrQueueFull = errors.New("worker queue is full")
+	// ErrStopped is returned by Submit once the pool is stopping
+	ErrStopped = errors.New("worker pool is stopped")
+)
+
+// Task is a unit of background work. Its error is logged and counted.
+type Task func(ctx context.Context) error
+
+type job struct {
+	ctx      context.Context
+	name     string
+	task     Task
+	queuedAt time.Time
+}
+
+// Pool runs submitted tasks on a fixed number of workers, queueing those
+// that can't start straight away
+type Pool struct {
+	name  string
+	queue chan job
+	wg    sync.WaitGroup
+
+	mu      sync.RWMutex
+	stopped bool
+
+	// abort is cancelled when Stop runs out of time, cancelling the
+	// contexts of the tasks still running
+	abort  context.Context
+	cancel context.CancelFunc
+}
+
+// New starts a pool of workers goroutines with room for queueSize waiting
+// tasks. name labels the pool's metrics and logs.
+func New(name string, workers, queueSize int) *Pool {
+	p := &Pool{name: name, queue: make(chan job, queueSize)}
+	p.abort, p.cancel = context.WithCancel(context.Background())
+	for range workers {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues task to run on the pool without waiting for it. The task's
+// context keeps the values of ctx, such as the tenant database, but not
+// its cancellation, so the task outlives the request that submitted it.
+// name labels the task's logs and metrics. Submit fails when the queue is
+// full or the pool is stopping.
+func (p *Pool) Submit(ctx context.Context, name string, task Task) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		metrics.WorkerTasks.WithLabelValues(p.name, name, "rejected").Inc()
+		return ErrStopped
+	}
+
+	select {
+	case p.queue <- job{ctx: context.WithoutCancel(ctx), name: name, task: task, queuedAt: time.Now()}:
+		metrics.WorkerQueueDepth.WithLabelValues(p.name).Inc()
+		return nil
+	default:
+		metrics.WorkerTasks.WithLabelValues(p.name, name, "rejected").Inc()
+		return ErrQueueFull
+	}
+}
+
+// Go runs task like Submit, or on a goroutine of its own when p is nil, so
+// code with an optional pool needs no branch of its own. The goroutine also
+// keeps ctx's values but not its cancellation, and logs the task's error.
+func (p *Pool) Go(ctx context.Context, name string, task Task) error {
+	if p != nil {
+		return p.Submit(ctx, name, task)
+	}
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		if err := task(ctx); err != nil {
+			slog.ErrorContext(ctx, "Background task failed", "task", name, "error", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops accepting tasks and waits for the queued and running ones to
+// finish. When ctx is done first, the running tasks' contexts are
+// cancelled, tasks still queued are dropped and ctx's error is returned.
+func (p *Pool) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for j := range p.queue {
+		metrics.WorkerQueueDepth.WithLabelValues(p.name).Dec()
+		if p.abort.Err() != nil {
+			metrics.WorkerTasks.WithLabelValues(p.name, j.name, "dropped").Inc()
+			continue
+		}
+		p.run(j)
+	}
+}
+
+// run runs one task, recovering from its panics so they don't take the
+// worker, or the server, down with them
+func (p *Pool) run(j job) {
+	ctx, cancel := context.WithCancel(j.ctx)
+	defer cancel()
+	stop := context.AfterFunc(p.abort, cancel)
+	defer stop()
+
+	metrics.WorkerBusy.WithLabelValues(p.name).Inc()
+	defer metrics.WorkerBusy.WithLabelValues(p.name).Dec()
+	metrics.WorkerWaitSeconds.WithLabelValues(p.name).Observe(time.Since(j.queuedAt).Seconds())
+
+	start := time.Now()
+	err := p.call(ctx, j)
+	metrics.WorkerTaskSeconds.WithLabelValues(p.name, j.name).Observe(time.Since(start).Seconds())
+
+	var panicErr *panicError
+	switch {
+	case errors.As(err, &panicErr):
+		metrics.WorkerTasks.WithLabelValues(p.name, j.name, "panicked").Inc()
+		slog.ErrorContext(ctx, "Background task panicked", "pool", p.name, "task", j.name, "panic", panicErr.value, "stack", string(panicErr.stack))
+	case err != nil:
+		metrics.WorkerTasks.WithLabelValues(p.name, j.name, "failed").Inc()
+		slog.ErrorContext(ctx, "Background task failed", "pool", p.name, "task", j.name, "error", err)
+	default:
+		metrics.WorkerTasks.WithLabelValues(p.name, j.name, "succeeded").Inc()
+	}
+}
+
+func (p *Pool) call(ctx context.Context, j job) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &panicError{value: v, stack: debug.Stack()}
+		}
+	}()
+	return j.task(ctx)
+}
+
+// panicError is a task's recovered panic
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
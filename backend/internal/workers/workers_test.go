@@ -0,0 +1,130 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+func TestPoolRunsTasks(t *testing.T) {
+	p := New("test", 2, 10)
+
+	var ran atomic.Int32
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "tenant"))
+	for range 5 {
+		err := p.Submit(ctx, "count", func(ctx context.Context) error {
+			if ctx.Value(ctxKey{}) != "tenant" {
+				t.Error("expected the task to keep the submitter's context values")
+			}
+			if ctx.Err() != nil {
+				t.Error("expected the task not to be cancelled with the submitter")
+			}
+			ran.Add(1)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Submit: %v", err)
+		}
+	}
+	cancel()
+
+	if err := p.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if n := ran.Load(); n != 5 {
+		t.Errorf("expected Stop to wait for every queued task, %d ran", n)
+	}
+	if err := p.Submit(context.Background(), "late", func(context.Context) error { return nil }); !errors.Is(err, ErrStopped) {
+		t.Errorf("expected ErrStopped after Stop, got %v", err)
+	}
+}
+
+func TestNilPoolGo(t *testing.T) {
+	var p *Pool
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "tenant"))
+	err := p.Go(ctx, "alone", func(ctx context.Context) error {
+		defer close(done)
+		if ctx.Value(ctxKey{}) != "tenant" {
+			t.Error("expected the task to keep the caller's context values")
+		}
+		return nil
+	})
+	cancel()
+	if err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the task to run without a pool")
+	}
+}
+
+func TestPoolBoundsConcurrency(t *testing.T) {
+	p := New("test", 1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	p.Submit(context.Background(), "block", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	if err := p.Submit(context.Background(), "queued", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("expected the task to be queued, got %v", err)
+	}
+	if err := p.Submit(context.Background(), "overflow", func(context.Context) error { return nil }); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("expected ErrQueueFull, got %v", err)
+	}
+
+	close(release)
+	p.Stop(context.Background())
+}
+
+func TestPoolRecoversPanics(t *testing.T) {
+	p := New("test", 1, 2)
+	done := make(chan struct{})
+	p.Submit(context.Background(), "panic", func(context.Context) error { panic("boom") })
+	p.Submit(context.Background(), "after", func(context.Context) error {
+		close(done)
+		return nil
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the worker to survive a panicking task")
+	}
+	p.Stop(context.Background())
+}
+
+func TestPoolStopTimeout(t *testing.T) {
+	p := New("test", 1, 1)
+	cancelled := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit(context.Background(), "slow", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Stop to time out, got %v", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the running task's context to be cancelled")
+	}
+}